@@ -17,11 +17,13 @@
 package vcert
 
 import (
+	"context"
 	"crypto/x509"
 	"fmt"
 	"log"
 
 	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/httputils"
 	"github.com/Venafi/vcert/v5/pkg/venafi/cloud"
 	"github.com/Venafi/vcert/v5/pkg/venafi/fake"
 	"github.com/Venafi/vcert/v5/pkg/venafi/firefly"
@@ -40,11 +42,17 @@ type newClientArgs struct {
 // The returned connector will be authenticated by default, but it's possible to pass a bool argument to indicate if it's
 // desired to get the connector authenticated already or not.
 func (cfg *Config) NewClient(args ...interface{}) (connector endpoint.Connector, err error) {
-	return cfg.newClient(args)
+	return cfg.newClient(context.Background(), args)
+}
+
+// NewClientContext is NewClient with a context that cancels the authentication of the returned connector, whose
+// methods take a context as well
+func (cfg *Config) NewClientContext(ctx context.Context, args ...interface{}) (connector endpoint.ConnectorContext, err error) {
+	return cfg.newClient(ctx, args)
 }
 
 // this function is to manage the variadic arguments
-func (cfg *Config) newClient(args []interface{}) (connector endpoint.Connector, err error) {
+func (cfg *Config) newClient(ctx context.Context, args []interface{}) (connector endpoint.ConnectorContext, err error) {
 
 	var clientArgs *newClientArgs
 	clientArgs, err = getNewClientArguments(args)
@@ -68,9 +76,18 @@ func (cfg *Config) newClient(args []interface{}) (connector endpoint.Connector,
 	case endpoint.ConnectorTypeTPP:
 		connector, err = tpp.NewConnector(cfg.BaseUrl, cfg.Zone, cfg.LogVerbose, connectionTrustBundle)
 	case endpoint.ConnectorTypeFirefly:
-		connector, err = firefly.NewConnector(cfg.BaseUrl, cfg.Zone, cfg.LogVerbose, connectionTrustBundle)
+		var fireflyConnector *firefly.Connector
+		fireflyConnector, err = firefly.NewConnector(cfg.BaseUrl, cfg.Zone, cfg.LogVerbose, connectionTrustBundle)
+		if err == nil && cfg.TokenCachePath != "" {
+			fireflyConnector.SetTokenCache(firefly.NewTokenCache(cfg.TokenCachePath))
+		}
+		connector = fireflyConnector
 	case endpoint.ConnectorTypeFake:
-		connector = fake.NewConnector(cfg.LogVerbose, connectionTrustBundle)
+		if cfg.FakeInventoryPath != "" {
+			connector, err = fake.NewConnectorWithInventory(cfg.LogVerbose, connectionTrustBundle, cfg.FakeInventoryPath)
+		} else {
+			connector = fake.NewConnector(cfg.LogVerbose, connectionTrustBundle)
+		}
 	default:
 		err = fmt.Errorf("%w: ConnectorType is not defined", verror.UserDataError)
 	}
@@ -82,10 +99,19 @@ func (cfg *Config) newClient(args []interface{}) (connector endpoint.Connector,
 		connector.SetUserAgent(*cfg.UserAgent)
 	}
 	connector.SetZone(cfg.Zone)
-	connector.SetHTTPClient(cfg.Client)
+	retryPolicy := httputils.DefaultRetryPolicy
+	if cfg.RetryPolicy != nil {
+		retryPolicy = *cfg.RetryPolicy
+	}
+	connector.SetHTTPClient(httputils.WithRetries(cfg.Client, retryPolicy))
+	if cfg.RetryPolicy != nil {
+		if r, ok := connector.(interface{ SetRetryPolicy(httputils.RetryPolicy) }); ok {
+			r.SetRetryPolicy(*cfg.RetryPolicy)
+		}
+	}
 
 	if clientArgs.authenticate {
-		err = connector.Authenticate(cfg.Credentials)
+		err = connector.AuthenticateContext(ctx, cfg.Credentials)
 	}
 
 	return
@@ -124,5 +150,11 @@ func getNewClientArguments(args []interface{}) (*newClientArgs, error) {
 // The returned connector will be authenticated by default, but it's possible to pass a bool argument to indicate if it's
 // desired to get the connector authenticated already or not.
 func NewClient(cfg *Config, args ...interface{}) (endpoint.Connector, error) {
-	return cfg.newClient(args)
+	return cfg.newClient(context.Background(), args)
+}
+
+// NewClientContext is NewClient with a context that cancels the authentication of the returned connector, whose
+// methods take a context as well
+func NewClientContext(ctx context.Context, cfg *Config, args ...interface{}) (endpoint.ConnectorContext, error) {
+	return cfg.newClient(ctx, args)
 }
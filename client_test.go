@@ -26,6 +26,7 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"testing"
@@ -34,11 +35,13 @@ import (
 	"github.com/go-http-utils/headers"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
 	"k8s.io/utils/ptr"
 
 	"github.com/Venafi/vcert/v5/pkg/certificate"
 	"github.com/Venafi/vcert/v5/pkg/endpoint"
 	"github.com/Venafi/vcert/v5/pkg/util"
+	"github.com/Venafi/vcert/v5/pkg/venafi/firefly"
 )
 
 func init() {
@@ -88,6 +91,26 @@ func TestNewClient(t *testing.T) {
 	print(certs)
 }
 
+func TestNewClient_FakeInventory(t *testing.T) {
+	cfg := &Config{
+		ConnectorType:     endpoint.ConnectorTypeFake,
+		BaseUrl:           "https://unused.example.com",
+		FakeInventoryPath: filepath.Join(t.TempDir(), "inventory.json"),
+	}
+	c, err := NewClient(cfg)
+	require.NoError(t, err)
+
+	req := &certificate.Request{Subject: pkix.Name{CommonName: "inventory.example.com"}}
+	require.NoError(t, c.GenerateRequest(nil, req))
+	_, err = c.RequestCertificate(req)
+	require.NoError(t, err)
+	_, err = c.RetrieveCertificate(req)
+	require.NoError(t, err)
+
+	// the inventory is written to FakeInventoryPath rather than to the base url
+	assert.FileExists(t, cfg.FakeInventoryPath)
+}
+
 func TestNewClientWithFileConfig(t *testing.T) {
 	var haltIf = func(err error) {
 		if err != nil {
@@ -211,6 +234,31 @@ func (o *userAgentChecker) RequireRoundTripError(err error) {
 			"2. the function is ignoring or hiding the error returned in the HTTP response.")
 }
 
+// TestNewClient_FireflyTokenCache checks that a Firefly connector built with
+// Config.TokenCachePath authenticates with the cached token.
+func TestNewClient_FireflyTokenCache(t *testing.T) {
+	auth := &endpoint.Authentication{
+		ClientId:     "vcert",
+		ClientSecret: "secret",
+		IdentityProvider: &endpoint.OAuthProvider{
+			// the identity provider is not reachable, so the cached token must be used
+			TokenURL: "http://127.0.0.1:1/token",
+		},
+	}
+	cache := firefly.NewTokenCache(filepath.Join(t.TempDir(), "tokens.json"))
+	require.NoError(t, cache.Put(auth, &oauth2.Token{AccessToken: "cached", Expiry: time.Now().Add(time.Hour)}))
+
+	cfg := &Config{
+		ConnectorType:  endpoint.ConnectorTypeFirefly,
+		BaseUrl:        "https://firefly.example.local",
+		Credentials:    auth,
+		TokenCachePath: cache.Path(),
+	}
+	_, err := NewClient(cfg)
+	require.NoError(t, err)
+	require.Equal(t, "cached", auth.AccessToken)
+}
+
 // TestNewClient_UserAgent checks that all connectors are consistent in the way
 // they set the User-Agent header.
 //
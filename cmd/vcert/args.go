@@ -17,6 +17,8 @@
 package main
 
 import (
+	"time"
+
 	"github.com/Venafi/vcert/v5/pkg/certificate"
 	"github.com/Venafi/vcert/v5/pkg/venafi"
 )
@@ -36,8 +38,13 @@ const (
 	commandSshPickupName        = "sshpickup"
 	commandSshEnrollName        = "sshenroll"
 	commandSshGetConfigName     = "sshgetconfig"
+	commandSshInspectName       = "sshinspect"
 	commandProvisionName        = "provision"
+	commandSearchName           = "search"
+	commandDiscoverName         = "discover"
 	subCommandCloudKeystoreName = "cloudkeystore"
+	commandPolicyName           = "policy"
+	subCommandPolicyCheckName   = "check"
 )
 
 var (
@@ -45,6 +52,9 @@ var (
 	provisionCommands = stringSlice{
 		subCommandCloudKeystoreName,
 	}
+	policyCommands = stringSlice{
+		subCommandPolicyCheckName,
+	}
 )
 
 type commandFlags struct {
@@ -86,6 +96,7 @@ type commandFlags struct {
 	keyType              *certificate.KeyType
 	keyTypeString        string
 	locality             string
+	maxRetries           int
 	noPickup             bool
 	noPrompt             bool
 	noRetire             bool
@@ -94,6 +105,7 @@ type commandFlags struct {
 	pickupID             string
 	pickupIDFile         string
 	profile              string
+	rateLimit            float64
 	replaceInstance      bool
 	revocationReason     string
 	scope                string
@@ -102,6 +114,7 @@ type commandFlags struct {
 	state                string
 	testMode             bool
 	testModeDelay        int
+	testModeInventory    string
 	thumbprint           string
 	timeout              int
 	tlsAddress           string
@@ -119,14 +132,23 @@ type commandFlags struct {
 	omitSans             bool
 	csrFormat            string
 	credFormat           string
+	clearCache           bool
 	validDays            string
 	validPeriod          string
+	manifest             string
+	manifestConcurrency  int
+	manifestState        string
 	platformString       string
 	platform             venafi.Platform
 	policyName           string
 	policySpecLocation   string
 	policyConfigStarter  bool
 	verifyPolicyConfig   bool
+	policyPlan           bool
+	policyRecursive      bool
+	policyCheckCSR       string
+	policyCheckCert      string
+	policyCheckRequest   string
 	sshCertKeyId         string
 	sshCertObjectName    string
 	sshCertDestAddrs     stringSlice
@@ -140,12 +162,22 @@ type commandFlags struct {
 	sshCertGuid          string
 	sshCertPubKey        string
 	sshCertKeySize       int
+	sshCertKeyType       string
 	sshCertKeyPassphrase string
 	sshCertExtension     stringSlice
 	sshCertPrincipal     stringSlice
 	sshCertWindows       bool
 	sshFileCertEnroll    string
 	sshFileGetConfig     string
+	sshTrustUserKeys     string
+	sshTrustSshdConfig   string
+	sshTrustKnownHosts   string
+	sshTrustHostPattern  stringSlice
+	sshTrustDryRun       bool
+	sshInspectFile       string
+	sshInspectCaKey      string
+	sshInspectFormat     string
+	sshInspectWarnWithin string
 	certificateID        string
 	certificateIDFile    string
 	keystoreID           string
@@ -156,4 +188,17 @@ type commandFlags struct {
 	provisionOutputFile  string
 	provisionPickupID    string
 	provisionFormat      string
+	searchSAN            string
+	searchIssuer         string
+	searchSerial         string
+	searchExpiringWithin string
+	searchFormat         string
+	searchLimit          int
+	searchPage           int
+	discoverPaths        []string
+	discoverTargets      []string
+	discoverPasswords    []string
+	discoverDryRun       bool
+	discoverProbeTimeout time.Duration
+	discoverConcurrency  int
 }
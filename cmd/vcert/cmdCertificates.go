@@ -17,6 +17,7 @@
 package main
 
 import (
+	"crypto"
 	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
@@ -77,7 +78,10 @@ var (
 		Flags:  revokeFlags,
 		Action: doCommandRevoke1,
 		Usage:  "To revoke a certificate",
-		UsageText: ` vcert revoke <Required Trust Protection Platform Config> <Options>
+		UsageText: ` vcert revoke <Required Venafi Control Plane -OR- Trust Protection Platform Config> <Options>
+
+		 vcert revoke -k <VCP API key> --thumbprint <cert SHA1 thumbprint> --reason key-compromise
+		 vcert revoke -p vcp -t <VCP access token> --id <certificate ID value>
 
 		 vcert revoke -u https://tpp.example.com -t <TPP access token> --thumbprint <cert SHA1 thumbprint>
 		 vcert revoke -u https://tpp.example.com -t <TPP access token> --id <ID value>
@@ -128,6 +132,13 @@ func doCommandEnroll1(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	var manifest []manifestRow
+	if flags.manifest != "" {
+		manifest, err = readManifest(flags.manifest, flags)
+		if err != nil {
+			return err
+		}
+	}
 	err = setTLSConfig()
 	if err != nil {
 		return err
@@ -144,54 +155,95 @@ func doCommandEnroll1(c *cli.Context) error {
 	} else {
 		logf("Successfully connected to %s", cfg.ConnectorType)
 	}
-	var req = &certificate.Request{}
-	var pcc = &certificate.PEMCollection{}
-
 	zoneConfig, err := connector.ReadZoneConfiguration()
 
 	if err != nil {
 		return err
 	}
 	logf("Successfully read zone configuration for %s", flags.zone)
-	req = fillCertificateRequest(req, &flags)
-	err = connector.GenerateRequest(zoneConfig, req)
-	if err != nil {
-		return err
+
+	if manifest != nil {
+		statePath := flags.manifestState
+		if statePath == "" {
+			statePath = flags.manifest + manifestStateSuffix
+		}
+		return enrollManifest(connector, zoneConfig, manifest, statePath, flags.manifestConcurrency)
+	}
+	return enrollCertificate(connector, zoneConfig, &flags, c.Command.Name, nil)
+}
+
+// enrollProgress lets enrollCertificate resume a request posted before and report the requests it posts
+type enrollProgress struct {
+	// pickupID and privateKey are those of a request posted before, whose certificate is retrieved
+	// instead of requesting it again. privateKey is nil unless the CSR was generated locally
+	pickupID   string
+	privateKey crypto.Signer
+	// posted is called as soon as the request is posted, before its certificate is retrieved
+	posted func(pickupID string, privateKey crypto.Signer)
+}
+
+// enrollCertificate requests the certificate described by cf and writes it to the outputs of cf.
+// progress may be nil
+func enrollCertificate(connector endpoint.Connector, zoneConfig *endpoint.ZoneConfiguration, cf *commandFlags, command string, progress *enrollProgress) error {
+	var req = &certificate.Request{}
+	var pcc = &certificate.PEMCollection{}
+	var err error
+
+	req = fillCertificateRequest(req, cf)
+	resumed := progress != nil && progress.pickupID != ""
+	if resumed {
+		cf.pickupID = progress.pickupID
+		req.PrivateKey = progress.privateKey
+	} else {
+		err = connector.GenerateRequest(zoneConfig, req)
+		if err != nil {
+			return err
+		}
 	}
 
 	var requestedFor string
 	if req.Subject.CommonName != "" {
 		requestedFor = req.Subject.CommonName
 	} else {
-		requestedFor = flags.csrOption
+		requestedFor = cf.csrOption
 	}
 
-	logf("Successfully created request for %s", requestedFor)
 	passwordAutogenerated := false
 
-	if connector.SupportSynchronousRequestCertificate() {
+	if resumed {
+		logf("Resuming request for %s, will pick up by %s", requestedFor, cf.pickupID)
+	} else {
+		logf("Successfully created request for %s", requestedFor)
+	}
+
+	if connector.SupportSynchronousRequestCertificate() && !resumed {
 		pcc, err = connector.SynchronousRequestCertificate(req)
 		if err != nil {
 			return err
 		}
 		logf("Successfully requested certificate for %s", requestedFor)
 	} else {
-		flags.pickupID, err = connector.RequestCertificate(req)
-		if err != nil {
-			return err
-		}
+		if !resumed {
+			cf.pickupID, err = connector.RequestCertificate(req)
+			if err != nil {
+				return err
+			}
 
-		logf("Successfully posted request for %s, will pick up by %s", requestedFor, flags.pickupID)
+			logf("Successfully posted request for %s, will pick up by %s", requestedFor, cf.pickupID)
+			if progress != nil && progress.posted != nil {
+				progress.posted(cf.pickupID, req.PrivateKey)
+			}
+		}
 
-		if flags.noPickup {
-			pcc, err = certificate.NewPEMCollection(nil, req.PrivateKey, []byte(flags.keyPassword), flags.format)
+		if cf.noPickup {
+			pcc, err = certificate.NewPEMCollection(nil, req.PrivateKey, []byte(cf.keyPassword), cf.format)
 			if err != nil {
 				return err
 			}
 		} else {
-			req.PickupID = flags.pickupID
-			req.ChainOption = certificate.ChainOptionFromString(flags.chainOption)
-			req.KeyPassword = flags.keyPassword
+			req.PickupID = cf.pickupID
+			req.ChainOption = certificate.ChainOptionFromString(cf.chainOption)
+			req.KeyPassword = cf.keyPassword
 
 			// Creates a temporary password for service generated csr if following validation is fulfilled.
 			// Analyzing validation assuming that pkcs12, legacy-pkcs12, jks and service flags are true
@@ -207,31 +259,31 @@ func doCommandEnroll1(c *cli.Context) error {
 			//|    false    |     false      |VCert will prompt to enter password and process will not be completed |
 			//|             |                |until password is provided by user                                    |
 			//+-------------+----------------+----------------------------------------------------------------------+
-			if flags.noPrompt && flags.keyPassword == "" && flags.format != P12Format && flags.format != LegacyP12Format && flags.format != JKSFormat && flags.csrOption == "service" {
-				flags.keyPassword = fmt.Sprintf("t%d-%s.tem.pwd", time.Now().Unix(), randRunes(4))
-				req.KeyPassword = flags.keyPassword
+			if cf.noPrompt && cf.keyPassword == "" && cf.format != P12Format && cf.format != LegacyP12Format && cf.format != JKSFormat && cf.csrOption == "service" {
+				cf.keyPassword = fmt.Sprintf("t%d-%s.tem.pwd", time.Now().Unix(), randRunes(4))
+				req.KeyPassword = cf.keyPassword
 				passwordAutogenerated = true
 			}
 
 			req.Timeout = time.Duration(180) * time.Second
-			pcc, err = retrieveCertificate(connector, req, time.Duration(flags.timeout)*time.Second)
+			pcc, err = retrieveCertificate(connector, req, time.Duration(cf.timeout)*time.Second)
 			if err != nil {
 				return err
 			}
-			logf("Successfully retrieved request for %s", flags.pickupID)
+			logf("Successfully retrieved request for %s", cf.pickupID)
 
 			if req.CsrOrigin == certificate.LocalGeneratedCSR {
 				// otherwise private key can be taken from *req
-				err := pcc.AddPrivateKey(req.PrivateKey, []byte(flags.keyPassword), flags.format)
+				err := pcc.AddPrivateKey(req.PrivateKey, []byte(cf.keyPassword), cf.format)
 				if err != nil {
-					log.Fatal(err)
+					return err
 				}
 			}
 		}
 	}
 
-	if (pcc.PrivateKey != "" && (flags.format == P12Format || flags.format == LegacyP12Format || flags.format == JKSFormat)) || (flags.format == util.LegacyPem && flags.csrOption == "service") || flags.noPrompt && passwordAutogenerated {
-		privKey, err := util.DecryptPkcs8PrivateKey(pcc.PrivateKey, flags.keyPassword)
+	if (pcc.PrivateKey != "" && (cf.format == P12Format || cf.format == LegacyP12Format || cf.format == JKSFormat)) || (cf.format == util.LegacyPem && cf.csrOption == "service") || cf.noPrompt && passwordAutogenerated {
+		privKey, err := util.DecryptPkcs8PrivateKey(pcc.PrivateKey, cf.keyPassword)
 		if err != nil {
 			if err.Error() == "pkcs8: only PBES2 supported" && connector.GetType() == endpoint.ConnectorTypeTPP {
 				return fmt.Errorf("ERROR: To continue, you must select either the SHA1 3DES or SHA256 AES256 private key PBE algorithm. In a web browser, log in to TLS Protect and go to Configuration > Folders, select your zone, then click Certificate Policy and expand Show Advanced Options to make the change.")
@@ -241,8 +293,8 @@ func doCommandEnroll1(c *cli.Context) error {
 		pcc.PrivateKey = privKey
 	}
 
-	if flags.csrOption == "service" && flags.format == util.LegacyPem && !passwordAutogenerated {
-		pcc.PrivateKey, err = util.EncryptPkcs1PrivateKey(pcc.PrivateKey, flags.keyPassword)
+	if cf.csrOption == "service" && cf.format == util.LegacyPem && !passwordAutogenerated {
+		pcc.PrivateKey, err = util.EncryptPkcs1PrivateKey(pcc.PrivateKey, cf.keyPassword)
 		if err != nil {
 			return nil
 		}
@@ -250,23 +302,23 @@ func doCommandEnroll1(c *cli.Context) error {
 
 	// removing temporary password if it was set
 	if passwordAutogenerated {
-		flags.keyPassword = ""
+		cf.keyPassword = ""
 	}
 	result := &Result{
 		Pcc:      pcc,
-		PickupId: flags.pickupID,
+		PickupId: cf.pickupID,
 		Config: &Config{
-			Command:      c.Command.Name,
-			Format:       flags.format,
-			JKSAlias:     flags.jksAlias,
-			JKSPassword:  flags.jksPassword,
-			ChainOption:  certificate.ChainOptionFromString(flags.chainOption),
-			AllFile:      flags.file,
-			KeyFile:      flags.keyFile,
-			CertFile:     flags.certFile,
-			ChainFile:    flags.chainFile,
-			PickupIdFile: flags.pickupIDFile,
-			KeyPassword:  flags.keyPassword,
+			Command:      command,
+			Format:       cf.format,
+			JKSAlias:     cf.jksAlias,
+			JKSPassword:  cf.jksPassword,
+			ChainOption:  certificate.ChainOptionFromString(cf.chainOption),
+			AllFile:      cf.file,
+			KeyFile:      cf.keyFile,
+			CertFile:     cf.certFile,
+			ChainFile:    cf.chainFile,
+			PickupIdFile: cf.pickupIDFile,
+			KeyPassword:  cf.keyPassword,
 		},
 	}
 
@@ -439,6 +491,7 @@ func doCommandRevoke1(c *cli.Context) error {
 
 	revReq.Reason = flags.revocationReason
 	revReq.Comments = "revocation request from command line utility"
+	revReq.Timeout = time.Duration(flags.timeout) * time.Second
 
 	err = connector.RevokeCertificate(revReq)
 	if err != nil {
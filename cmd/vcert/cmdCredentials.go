@@ -47,7 +47,8 @@ var (
 		vcert getcred -p tpp -u https://tpp.example.com -t <TPP refresh token>
 
 		vcert getcred -p oidc -u https://authorization-server.com/oauth/token --username <okta user> --password <okta user password> --scope okta.behaviors.manage
-		vcert getcred -p oidc -u https://authorization-server.com/oauth/token --client-id <okta client id> --client-secret <okta client secret> --scope okta.behaviors.manage`,
+		vcert getcred -p oidc -u https://authorization-server.com/oauth/token --client-id <okta client id> --client-secret <okta client secret> --scope okta.behaviors.manage
+		vcert getcred -p oidc --clear-cache`,
 	}
 
 	commandCheckCred = &cli.Command{
@@ -0,0 +1,231 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/Venafi/vcert/v5"
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+	"github.com/Venafi/vcert/v5/pkg/endpoint"
+)
+
+const (
+	discoverStatusImported = "imported"
+	discoverStatusManaged  = "managed"
+	discoverStatusDryRun   = "to import"
+	discoverStatusFailed   = "failed"
+)
+
+var (
+	commandDiscover = &cli.Command{
+		Before: runBeforeCommand,
+		Name:   commandDiscoverName,
+		Flags:  discoverFlags,
+		Action: doCommandDiscover,
+		Usage:  "To import the certificates found in files and on TLS endpoints",
+		UsageText: ` vcert discover <Required Venafi Control Plane -OR- Trust Protection Platform Config> <--path | --target> <Options>
+
+		 vcert discover -k <VCP API key> -z "<app name>\<CIT alias>" --path /etc/ssl --path /opt/app/keystore.jks --keystore-password changeit
+		 vcert discover -p vcp -t <VCP access token> --target 10.0.0.0/24:443 --dry-run
+
+		 vcert discover -u https://tpp.example.com -t <TPP access token> -z "<policy folder DN>" --target www.example.com:443 --target app.example.com:8443-8450`,
+	}
+)
+
+// discoverResult is the outcome of the import of a discovered certificate
+type discoverResult struct {
+	Thumbprint string
+	CN         string
+	Serial     string
+	ValidTo    time.Time
+	Sources    []string
+	Status     string
+	ID         string `json:",omitempty"`
+	Error      string `json:",omitempty"`
+}
+
+func doCommandDiscover(c *cli.Context) error {
+	err := validateDiscoverFlags(c.Command.Name)
+	if err != nil {
+		return err
+	}
+	err = setTLSConfig()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := buildConfig(c, &flags)
+	if err != nil {
+		return fmt.Errorf("Failed to build vcert config: %s", err)
+	}
+	if cfg.Zone == "" && !flags.discoverDryRun {
+		return fmt.Errorf("zone cannot be empty. Use -z option")
+	}
+
+	d := newDiscovery()
+	err = d.scanPaths(flags.discoverPaths, flags.discoverPasswords)
+	if err != nil {
+		return err
+	}
+	if len(flags.discoverTargets) > 0 {
+		probed, answered, err := d.probeTargets(flags.discoverTargets, flags.discoverProbeTimeout, flags.discoverConcurrency)
+		if err != nil {
+			return err
+		}
+		logf("Probed %d endpoints, %d answered with TLS", probed, answered)
+	}
+
+	connector, err := vcert.NewClient(&cfg)
+	if err != nil {
+		return fmt.Errorf("Unable to connect to %s: %s", cfg.ConnectorType, err)
+	}
+	logf("Successfully connected to %s", cfg.ConnectorType)
+
+	results, err := importDiscovered(connector, d, flags.discoverDryRun)
+	if err != nil {
+		return err
+	}
+	err = writeDiscoverResults(os.Stdout, flags.searchFormat, results)
+	if err != nil {
+		return err
+	}
+
+	counts := map[string]int{}
+	for _, r := range results {
+		counts[r.Status]++
+	}
+	ignored := len(d.certificates) - len(results)
+	if flags.discoverDryRun {
+		logf("Discovered %d certificates, %d CA certificates ignored: %d to import, %d already managed",
+			len(d.certificates), ignored, counts[discoverStatusDryRun], counts[discoverStatusManaged])
+		return nil
+	}
+	logf("Discovered %d certificates, %d CA certificates ignored: %d imported, %d already managed, %d failed",
+		len(d.certificates), ignored, counts[discoverStatusImported], counts[discoverStatusManaged], counts[discoverStatusFailed])
+	if counts[discoverStatusFailed] > 0 {
+		return fmt.Errorf("failed to import %d certificates", counts[discoverStatusFailed])
+	}
+	return nil
+}
+
+// importDiscovered imports the end-entity certificates discovered that the platform doesn't manage yet. CA
+// certificates are left out
+func importDiscovered(connector endpoint.Connector, d *discovery, dryRun bool) ([]discoverResult, error) {
+	var results []discoverResult
+	for _, found := range d.certificates {
+		if found.cert.IsCA {
+			continue
+		}
+		result := discoverResult{
+			Thumbprint: found.thumbprint,
+			CN:         found.cert.Subject.CommonName,
+			Serial:     strings.ToUpper(found.cert.SerialNumber.Text(16)),
+			ValidTo:    found.cert.NotAfter,
+			Sources:    found.sources,
+		}
+
+		managed, err := connector.SearchCertificates(&certificate.SearchRequest{"Thumbprint=" + found.thumbprint})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search certificate %s: %w", found.thumbprint, err)
+		}
+		switch {
+		case len(managed.Certificates) > 0:
+			result.Status = discoverStatusManaged
+			result.ID = searchResultInfo(managed.Certificates[0]).ID
+		case dryRun:
+			result.Status = discoverStatusDryRun
+		default:
+			resp, err := connector.ImportCertificate(&certificate.ImportRequest{
+				CertificateData: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: found.cert.Raw})),
+				CustomFields:    []certificate.CustomField{{Name: "Origin", Value: OriginName, Type: certificate.CustomFieldOrigin}},
+			})
+			if err != nil {
+				result.Status = discoverStatusFailed
+				result.Error = err.Error()
+				logf("Failed to import %s found in %s: %s", result.CN, found.sources[0], err)
+				break
+			}
+			result.Status = discoverStatusImported
+			result.ID = resp.CertificateDN
+			if result.ID == "" {
+				result.ID = resp.CertId
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func writeDiscoverResults(w io.Writer, format string, results []discoverResult) error {
+	switch format {
+	case searchFormatJSON:
+		if results == nil {
+			results = []discoverResult{}
+		}
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case searchFormatCSV:
+		cw := csv.NewWriter(w)
+		err := cw.Write([]string{"status", "cn", "serial", "thumbprint", "valid-to", "id", "sources", "error"})
+		if err != nil {
+			return err
+		}
+		for _, r := range results {
+			err = cw.Write([]string{
+				r.Status,
+				r.CN,
+				r.Serial,
+				r.Thumbprint,
+				r.ValidTo.UTC().Format(time.RFC3339),
+				r.ID,
+				strings.Join(r.Sources, searchListSeparator),
+				r.Error,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "STATUS\tCOMMON NAME\tVALID TO\tTHUMBPRINT\tFOUND IN")
+		for _, r := range results {
+			source := r.Sources[0]
+			if len(r.Sources) > 1 {
+				source = fmt.Sprintf("%s (+%d more)", source, len(r.Sources)-1)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.Status, r.CN, r.ValidTo.UTC().Format(time.DateOnly), r.Thumbprint, source)
+		}
+		return tw.Flush()
+	}
+}
@@ -17,6 +17,7 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
@@ -53,6 +54,10 @@ func runBeforeCommand(c *cli.Context) error {
 	flags.sshCertPrincipal = c.StringSlice("principal")
 	flags.sshCertSourceAddrs = c.StringSlice("source-address")
 	flags.sshCertDestAddrs = c.StringSlice("destination-address")
+	flags.sshTrustHostPattern = c.StringSlice("host-pattern")
+	flags.discoverPaths = c.StringSlice("path")
+	flags.discoverTargets = c.StringSlice("target")
+	flags.discoverPasswords = c.StringSlice("keystore-password")
 
 	noDuplicatedFlags := []string{"instance", "tls-address", "app-info"}
 	for _, f := range noDuplicatedFlags {
@@ -294,13 +299,26 @@ func getVaaSCredentials(vaasConnector *cloud.Connector, cfg *vcert.Config) error
 }
 
 func getFireflyCredentials(fireflyConnector *firefly.Connector, cfg *vcert.Config) error {
+	if flags.clearCache {
+		if cfg.TokenCachePath == "" {
+			return fmt.Errorf("failed to clear the token cache: its location is unknown")
+		}
+		err := firefly.NewTokenCache(cfg.TokenCachePath).Clear()
+		if err != nil {
+			return err
+		}
+		logf("Removed the token cache %s", cfg.TokenCachePath)
+		return nil
+	}
+
 	//TODO: quick workaround to suppress logs when output is in JSON.
 	if flags.credFormat != "json" {
 		logf("Getting credentials...")
 	}
 
-	token, err := fireflyConnector.Authorize(cfg.Credentials)
-
+	// the cached token is returned while it is valid or can be refreshed, so that the authorization flow doesn't run
+	// on every call
+	token, err := fireflyConnector.TokenSource(context.Background(), cfg.Credentials).Token()
 	if err != nil {
 		return err
 	}
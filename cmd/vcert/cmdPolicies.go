@@ -17,19 +17,34 @@
 package main
 
 import (
+	"crypto/x509"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"log"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/urfave/cli/v2"
 	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
 
 	"github.com/Venafi/vcert/v5"
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/playbook/app/domain"
+	"github.com/Venafi/vcert/v5/pkg/playbook/app/vcertutil"
 	"github.com/Venafi/vcert/v5/pkg/policy"
+	"github.com/Venafi/vcert/v5/pkg/util"
+	"github.com/Venafi/vcert/v5/pkg/venafi/cloud"
+	"github.com/Venafi/vcert/v5/pkg/venafi/tpp"
 )
 
+// policyPlanChangesExitCode is the exit code of setpolicy --plan when there are changes, so that they can be told apart
+// from the errors, which exit with 1
+const policyPlanChangesExitCode = 2
+
 var (
 	commandCreatePolicy = &cli.Command{
 		Before: runBeforeCommand,
@@ -52,6 +67,25 @@ var (
         vcert getpolicy -u https://tpp.example.com -t <TPP access token> -z "<policy folder DN>"
 		vcert getpolicy -p vcp -t <VCP access token> -z "<app name>\<CIT alias>"`,
 	}
+
+	commandPolicy = &cli.Command{
+		Before:      runBeforeCommand,
+		Name:        commandPolicyName,
+		Action:      doCommandPolicy,
+		Usage:       "To work with certificate policy specifications",
+		Subcommands: []*cli.Command{subCommandPolicyCheck},
+	}
+
+	subCommandPolicyCheck = &cli.Command{
+		Name:   subCommandPolicyCheckName,
+		Flags:  policyCheckFlags,
+		Action: doCommandPolicyCheck,
+		Usage:  "To check a CSR, certificate or request against a certificate policy specification",
+		UsageText: ` vcert policy check --file /path-to/policy.spec <--csr-file | --cert-file | --request-file> <file>
+        vcert policy check --file /path-to/policy.spec --csr-file /path-to/csr.pem
+        vcert policy check -u https://tpp.example.com -t <TPP access token> -z "<policy folder DN>" --cert-file /path-to/cert.pem
+		vcert policy check -p vcp -t <VCP access token> -z "<app name>\<CIT alias>" --request-file /path-to/request.yaml`,
+	}
 )
 
 func doCommandCreatePolicy(c *cli.Context) error {
@@ -67,6 +101,10 @@ func doCommandCreatePolicy(c *cli.Context) error {
 		return err
 	}
 
+	if flags.policyRecursive {
+		return setPolicyTree(c)
+	}
+
 	policyName := flags.policyName
 	policySpecLocation := flags.policySpecLocation
 
@@ -124,6 +162,10 @@ func doCommandCreatePolicy(c *cli.Context) error {
 		return err
 	}
 
+	if flags.policyPlan {
+		return planPolicy(connector, policyName, &policySpecification)
+	}
+
 	_, err = connector.SetPolicy(policyName, &policySpecification)
 
 	defer file.Close()
@@ -131,6 +173,116 @@ func doCommandCreatePolicy(c *cli.Context) error {
 	return err
 }
 
+// planPolicy shows the changes that applying ps would make to the policy of the zone. When there are any, it returns
+// an error that exits with policyPlanChangesExitCode
+func planPolicy(connector endpoint.Connector, policyName string, ps *policy.PolicySpecification) error {
+	count, err := printPolicyPlan(connector, policyName, ps)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return cli.Exit(fmt.Sprintf("policy of %s differs from the policy specification: found %d change(s)", policyName, count), policyPlanChangesExitCode)
+	}
+	return nil
+}
+
+// printPolicyPlan shows the changes that applying ps would make to the policy of the zone and returns how many there are.
+// Every field of ps is an addition when the zone doesn't exist yet
+func printPolicyPlan(connector endpoint.Connector, policyName string, ps *policy.PolicySpecification) (int, error) {
+	exists, err := policyExists(connector, policyName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check whether %s exists: %w", policyName, err)
+	}
+	current := &policy.PolicySpecification{}
+	if exists {
+		current, err = currentPolicy(connector, policyName)
+		if err != nil {
+			return 0, fmt.Errorf("failed to retrieve the current policy of %s: %w", policyName, err)
+		}
+	} else {
+		logf("%s does not exist, it would be created", policyName)
+	}
+
+	var unsetValues map[string]string
+	switch connector.GetType() {
+	case endpoint.ConnectorTypeTPP:
+		unsetValues = policy.TppUnsetValues
+	case endpoint.ConnectorTypeCloud:
+		unsetValues = policy.CloudUnsetValues
+	}
+
+	changes, err := policy.DiffPolicySpecifications(current, ps, unsetValues)
+	if err != nil {
+		return 0, err
+	}
+	// VCP keeps the owners of the application when the policy specification has no users
+	if connector.GetType() == endpoint.ConnectorTypeCloud && len(ps.Users) == 0 {
+		changes = slices.DeleteFunc(changes, func(change policy.Change) bool {
+			return change.Field == "users"
+		})
+	}
+	if len(changes) == 0 {
+		logf("policy of %s matches the policy specification", policyName)
+		return 0, nil
+	}
+
+	for _, change := range changes {
+		fmt.Printf("%s (%s)\n", change.String(), policyChangeScope(connector.GetType(), change.Field))
+	}
+	return len(changes), nil
+}
+
+// currentPolicy returns the policy of the zone that a policy specification applied to it would replace. For Trust
+// Protection Platform, that is the attributes set on the policy folder, without the values it inherits
+func currentPolicy(connector endpoint.Connector, policyName string) (*policy.PolicySpecification, error) {
+	if c, ok := connector.(*tpp.Connector); ok {
+		return c.GetFolderPolicy(policyName)
+	}
+	return connector.GetPolicy(policyName)
+}
+
+// policyExists returns whether the zone exists on the platform
+func policyExists(connector endpoint.Connector, policyName string) (bool, error) {
+	switch c := connector.(type) {
+	case *tpp.Connector:
+		if !strings.HasPrefix(policyName, util.PathSeparator) {
+			policyName = util.PathSeparator + policyName
+		}
+		if !strings.HasPrefix(policyName, policy.RootPath) {
+			policyName = policy.RootPath + policyName
+		}
+		return tpp.PolicyExist(policyName, c)
+	case *cloud.Connector:
+		return cloud.PolicyExist(policyName, c)
+	default:
+		return true, nil
+	}
+}
+
+// policyChangeScope describes what a change of the field updates on the platform
+func policyChangeScope(connectorType endpoint.ConnectorType, field string) string {
+	isPolicy := strings.HasPrefix(field, "policy.")
+	isDefault := strings.HasPrefix(field, "defaults.")
+
+	switch connectorType {
+	case endpoint.ConnectorTypeTPP:
+		if isPolicy {
+			return "locked attribute"
+		}
+		if isDefault {
+			return "default attribute"
+		}
+		return "policy folder"
+	case endpoint.ConnectorTypeCloud:
+		if isPolicy || isDefault {
+			return "issuing template"
+		}
+		return "application"
+	default:
+		return connectorType.String()
+	}
+}
+
 func doCommandGetPolicy(c *cli.Context) error {
 
 	err := validateGetPolicyFlags(c.Command.Name)
@@ -144,6 +296,10 @@ func doCommandGetPolicy(c *cli.Context) error {
 		return err
 	}
 
+	if flags.policyRecursive {
+		return getPolicyTree(c)
+	}
+
 	policyName := flags.policyName
 
 	policySpecLocation := flags.policySpecLocation
@@ -216,3 +372,131 @@ func doCommandGetPolicy(c *cli.Context) error {
 
 	return nil
 }
+
+func doCommandPolicy(c *cli.Context) error {
+	return fmt.Errorf("the following subcommand(s) are required: \n%s", createBulletList(policyCommands))
+}
+
+func doCommandPolicyCheck(c *cli.Context) error {
+	err := validatePolicyCheckFlags(c.Command.Name)
+	if err != nil {
+		return err
+	}
+
+	var ps *policy.PolicySpecification
+	if flags.policySpecLocation != "" {
+		logf("Loading policy specification from %s", flags.policySpecLocation)
+		ps, err = readPolicySpecification(flags.policySpecLocation)
+		if err != nil {
+			return err
+		}
+	} else {
+		err = setTLSConfig()
+		if err != nil {
+			return err
+		}
+		cfg, err := buildConfig(c, &flags)
+		if err != nil {
+			return fmt.Errorf("failed to build vcert config: %s", err)
+		}
+		connector, err := vcert.NewClient(&cfg)
+		if err != nil {
+			return err
+		}
+		ps, err = connector.GetPolicy(flags.policyName)
+		if err != nil {
+			return err
+		}
+	}
+
+	var violations []policy.Violation
+	var checked string
+	switch {
+	case flags.policyCheckCSR != "":
+		checked = flags.policyCheckCSR
+		violations, err = checkCSRFile(ps, flags.policyCheckCSR)
+	case flags.policyCheckCert != "":
+		checked = flags.policyCheckCert
+		violations, err = checkCertificateFile(ps, flags.policyCheckCert)
+	default:
+		checked = flags.policyCheckRequest
+		violations, err = checkRequestFile(ps, flags.policyCheckRequest)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(violations) == 0 {
+		logf("%s complies with the policy", checked)
+		return nil
+	}
+	for _, v := range violations {
+		fmt.Println(v.String())
+	}
+	return fmt.Errorf("%s does not comply with the policy: found %d violation(s)", checked, len(violations))
+}
+
+func readPolicySpecification(location string) (*policy.PolicySpecification, error) {
+	file, bytes, err := policy.GetFileAndBytes(location)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var ps policy.PolicySpecification
+	fileExt := strings.ToLower(policy.GetFileType(location))
+	if fileExt == policy.JsonExtension {
+		err = json.Unmarshal(bytes, &ps)
+	} else if fileExt == policy.YamlExtension {
+		err = yaml.Unmarshal(bytes, &ps)
+	} else {
+		return nil, fmt.Errorf("the specified file is not supported")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("policy specification file is not valid: %w", err)
+	}
+	return &ps, nil
+}
+
+func checkCSRFile(ps *policy.PolicySpecification, location string) ([]policy.Violation, error) {
+	data, err := os.ReadFile(location)
+	if err != nil {
+		return nil, err
+	}
+	request := &certificate.Request{}
+	err = request.SetCSR(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSR from %s: %w", location, err)
+	}
+	return policy.CheckRequest(ps, request)
+}
+
+func checkCertificateFile(ps *policy.PolicySpecification, location string) ([]policy.Violation, error) {
+	data, err := os.ReadFile(location)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("failed to find a PEM certificate in %s", location)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate from %s: %w", location, err)
+	}
+	return policy.CheckCertificate(ps, cert)
+}
+
+func checkRequestFile(ps *policy.PolicySpecification, location string) ([]policy.Violation, error) {
+	data, err := os.ReadFile(location)
+	if err != nil {
+		return nil, err
+	}
+	var playbookRequest domain.PlaybookRequest
+	err = yamlv3.Unmarshal(data, &playbookRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse request from %s: %w", location, err)
+	}
+	request := vcertutil.BuildRequest(playbookRequest)
+	return policy.CheckRequest(ps, &request)
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+
+	"github.com/Venafi/vcert/v5/pkg/policy"
+	"github.com/Venafi/vcert/v5/pkg/venafi/tpp/tpptest"
+)
+
+func TestPrintPolicyPlan(t *testing.T) {
+	server := tpptest.NewServer()
+	defer server.Close()
+	connector := newPolicyTreeConnector(t, server)
+
+	ps := &policy.PolicySpecification{Policy: &policy.Policy{Subject: &policy.Subject{Orgs: []string{"Venafi"}}}}
+	// a zone that doesn't exist yet gets every field as an addition
+	count, err := printPolicyPlan(connector, "Certificates\\plan", ps)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+	var exitErr cli.ExitCoder
+	require.ErrorAs(t, planPolicy(connector, "Certificates\\plan", ps), &exitErr)
+	require.Equal(t, policyPlanChangesExitCode, exitErr.ExitCode(), "changes exit with a code of their own")
+
+	_, err = connector.SetPolicy("Certificates\\plan", ps)
+	require.NoError(t, err)
+	count, err = printPolicyPlan(connector, "Certificates\\plan", ps)
+	require.NoError(t, err)
+	require.Zero(t, count)
+	require.NoError(t, planPolicy(connector, "Certificates\\plan", ps))
+}
@@ -17,17 +17,27 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/Venafi/vcert/v5/pkg/util"
 	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh"
 
 	"github.com/Venafi/vcert/v5"
 	"github.com/Venafi/vcert/v5/pkg/certificate"
 )
 
+const (
+	sshInspectFormatText = "text"
+	sshInspectFormatJSON = "json"
+)
+
 var (
 	commandSshPickup = &cli.Command{
 		Before:    runBeforeCommand,
@@ -55,6 +65,18 @@ var (
 		Usage:     "To get the SSH CA public key and default principals from Trust Protection Platform",
 		UsageText: `vcert sshgetconfig -u https://tpp.example.com -t <TPP access token> --template <val>`,
 	}
+
+	commandSshInspect = &cli.Command{
+		Before: runBeforeCommand,
+		Name:   commandSshInspectName,
+		Flags:  sshInspectFlags,
+		Action: doCommandSSHInspect,
+		Usage:  "To show the content of an SSH certificate and verify it against its CA",
+		UsageText: ` vcert sshinspect --file <ssh cert file> [--format json]
+
+		 vcert sshinspect --file <ssh cert file> --ca-public-key <CA public key file>
+		 vcert sshinspect --file <ssh cert file> -u https://tpp.example.com -t <TPP access token> --template <val>`,
+	}
 )
 
 func doCommandSSHPickup(c *cli.Context) error {
@@ -173,12 +195,19 @@ func doCommandSSHEnroll(c *cli.Context) error {
 	//support for local generated keypair or provided public key
 	if flags.sshCertPubKey == SshCertPubKeyLocal {
 
-		keySize := flags.sshCertKeySize
-		if keySize <= 0 {
-			keySize = 3072
+		// The default format of the private key depends on its type
+		format := ""
+		if c.IsSet(flagFormat.Name) {
+			format = flags.format
 		}
 
-		privateKey, publicKey, err = util.GenerateSshKeyPair(keySize, flags.keyPassword, flags.sshCertKeyId, flags.format)
+		privateKey, publicKey, err = util.GenerateSshKeyPairWithOptions(util.SshKeyPairOptions{
+			KeyType:    flags.sshCertKeyType,
+			KeySize:    flags.sshCertKeySize,
+			Passphrase: flags.keyPassword,
+			Comment:    flags.sshCertKeyId,
+			Format:     format,
+		})
 
 		if err != nil {
 			return err
@@ -345,9 +374,240 @@ func doCommandSSHGetConfig(c *cli.Context) error {
 		}
 	}
 
+	return writeSshCaTrust(conf.CaPublicKey)
+}
+
+// writeSshCaTrust writes the trust files of the CA requested by --trusted-user-ca-keys and --known-hosts.
+// On a dry run, the content of the files that would change is printed instead
+func writeSshCaTrust(caPublicKey string) error {
+	// The lines of the CA are identified by its template, so that they are replaced when it rotates
+	name := flags.sshCertTemplate
+	if name == "" {
+		name = flags.sshCertGuid
+	}
+
+	var files []util.SshTrustFile
+	if flags.sshTrustUserKeys != "" {
+		written, err := util.SshUserCaTrust{
+			Name:         name,
+			CaPublicKeys: caPublicKey,
+			KeysFile:     flags.sshTrustUserKeys,
+			ConfigFile:   flags.sshTrustSshdConfig,
+		}.Write(flags.sshTrustDryRun)
+		if err != nil {
+			return err
+		}
+		files = append(files, written...)
+	}
+	if flags.sshTrustKnownHosts != "" {
+		written, err := util.SshHostCaTrust{
+			Name:           name,
+			CaPublicKeys:   caPublicKey,
+			KnownHostsFile: flags.sshTrustKnownHosts,
+			HostPatterns:   flags.sshTrustHostPattern,
+		}.Write(flags.sshTrustDryRun)
+		if err != nil {
+			return err
+		}
+		files = append(files, written...)
+	}
+
+	for _, f := range files {
+		switch {
+		case !f.Changed:
+			logf("%s already trusts the SSH CA", f.Path)
+		case flags.sshTrustDryRun:
+			fmt.Println()
+			fmt.Printf("%s would be written:\n", f.Path)
+			fmt.Print(string(f.Content))
+		default:
+			logf("SSH CA trust has been written to: %s", f.Path)
+		}
+	}
 	return nil
 }
 
+func doCommandSSHInspect(c *cli.Context) error {
+	err := validateSshInspectFlags(c.Command.Name)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(flags.sshInspectFile)
+	if err != nil {
+		return err
+	}
+	cert, err := certificate.ParseSshCertificate(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", flags.sshInspectFile, err)
+	}
+
+	caPublicKey, err := retrieveSshInspectCaPublicKey(c)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	result := newSshInspectResult(cert)
+	var verifyErr error
+	if caPublicKey != "" {
+		verifyErr = certificate.VerifySshCertificate(cert, caPublicKey, now)
+		verified := verifyErr == nil
+		result.Verified = &verified
+	}
+
+	var warnWithin time.Duration
+	if flags.sshInspectWarnWithin != "" {
+		warnWithin, _ = parseExpiringWithin(flags.sshInspectWarnWithin)
+	}
+	if warning := sshCertificateExpiryWarning(result.SshCertificateDetails, now, warnWithin); warning != "" {
+		logf("Warning: %s", warning)
+	}
+
+	err = writeSshInspectResult(os.Stdout, flags.sshInspectFormat, result)
+	if err != nil {
+		return err
+	}
+	if verifyErr != nil {
+		return fmt.Errorf("failed to verify SSH certificate: %w", verifyErr)
+	}
+	return nil
+}
+
+// retrieveSshInspectCaPublicKey returns the CA public keys to verify the inspected certificate against, read from
+// --ca-public-key or retrieved from Trust Protection Platform. It returns an empty string if there is none
+func retrieveSshInspectCaPublicKey(c *cli.Context) (string, error) {
+	if flags.sshInspectCaKey != "" {
+		data, err := os.ReadFile(flags.sshInspectCaKey)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+	if flags.sshCertTemplate == "" && flags.sshCertGuid == "" {
+		return "", nil
+	}
+
+	err := setTLSConfig()
+	if err != nil {
+		return "", err
+	}
+	cfg, err := buildConfig(c, &flags)
+	if err != nil {
+		return "", fmt.Errorf("failed to build vcert config: %s", err)
+	}
+	connector, err := vcert.NewClient(&cfg)
+	if err != nil {
+		return "", fmt.Errorf("unable to connect to %s: %s", cfg.ConnectorType, err)
+	}
+	logf("Successfully connected to %s", cfg.ConnectorType)
+
+	conf, err := connector.RetrieveSshConfig(&certificate.SshCaTemplateRequest{
+		Template: flags.sshCertTemplate,
+		Guid:     flags.sshCertGuid,
+	})
+	if err != nil {
+		return "", err
+	}
+	return conf.CaPublicKey, nil
+}
+
+// sshInspectResult is the content of an SSH certificate shown by sshinspect
+type sshInspectResult struct {
+	certificate.SshCertificateDetails
+	// CriticalOptions holds all the critical options, ForceCommand and SourceAddresses included
+	CriticalOptions map[string]string `json:"CriticalOptions,omitempty"`
+	// Verified is set when the certificate is verified against a CA public key
+	Verified *bool `json:"Verified,omitempty"`
+}
+
+func newSshInspectResult(cert *ssh.Certificate) sshInspectResult {
+	return sshInspectResult{
+		SshCertificateDetails: certificate.NewSshCertificateDetails(cert),
+		CriticalOptions:       cert.CriticalOptions,
+	}
+}
+
+// sshCertificateExpiryWarning returns a warning if the certificate is not valid yet, has expired or expires within
+// the period warnWithin
+func sshCertificateExpiryWarning(details certificate.SshCertificateDetails, now time.Time, warnWithin time.Duration) string {
+	validFrom := util.ConvertSecondsToTime(details.ValidFrom)
+	validTo := util.ConvertSecondsToTime(details.ValidTo)
+	switch {
+	case now.Before(validFrom):
+		return fmt.Sprintf("SSH certificate is not valid before %s", validFrom)
+	case details.ValidTo == 0:
+		return ""
+	case !now.Before(validTo):
+		return fmt.Sprintf("SSH certificate expired on %s", validTo)
+	case validTo.Sub(now) <= warnWithin:
+		return fmt.Sprintf("SSH certificate expires on %s, in %s", validTo, validTo.Sub(now).Round(time.Minute))
+	}
+	return ""
+}
+
+func writeSshInspectResult(w io.Writer, format string, result sshInspectResult) error {
+	if format == sshInspectFormatJSON {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	}
+
+	details := result.SshCertificateDetails
+	validTo := "forever"
+	if details.ValidTo != 0 {
+		validTo = util.ConvertSecondsToTime(details.ValidTo).String()
+	}
+	fmt.Fprintln(w, "SSH certificate:")
+	fmt.Fprintf(w, "\tCertificate Type: %s\n", details.CertificateType)
+	fmt.Fprintf(w, "\tPublic key: %s %s:%s\n", details.KeyType, Sha256, details.PublicKeyFingerprintSHA256)
+	fmt.Fprintf(w, "\tSigning CA: %s:%s\n", Sha256, details.CAFingerprintSHA256)
+	fmt.Fprintf(w, "\tCertificate Identifier: %s\n", details.KeyID)
+	fmt.Fprintf(w, "\tSerial: %s\n", details.SerialNumber)
+	fmt.Fprintf(w, "\tValid From: %s\n", util.ConvertSecondsToTime(details.ValidFrom))
+	fmt.Fprintf(w, "\tValid To: %s\n", validTo)
+	fmt.Fprintln(w, "\tPrincipals:")
+	writeSshInspectList(w, details.Principals)
+	fmt.Fprintln(w, "\tCritical Options:")
+	writeSshInspectList(w, sshInspectOptions(result.CriticalOptions))
+	fmt.Fprintln(w, "\tExtensions:")
+	extensions := make(map[string]string, len(details.Extensions))
+	for k, v := range details.Extensions {
+		extensions[k] = fmt.Sprint(v)
+	}
+	writeSshInspectList(w, sshInspectOptions(extensions))
+	if result.Verified != nil {
+		fmt.Fprintf(w, "\tVerified against CA: %t\n", *result.Verified)
+	}
+	return nil
+}
+
+func writeSshInspectList(w io.Writer, values []string) {
+	if len(values) == 0 {
+		fmt.Fprintln(w, "\t\tNone")
+	}
+	for _, v := range values {
+		fmt.Fprintf(w, "\t\t%s\n", v)
+	}
+}
+
+// sshInspectOptions returns the critical options or the extensions of a certificate as sorted name:value entries
+func sshInspectOptions(options map[string]string) []string {
+	entries := make([]string, 0, len(options))
+	for k, v := range options {
+		if v != "" {
+			entries = append(entries, fmt.Sprintf("%s:%s", k, v))
+		} else {
+			entries = append(entries, k)
+		}
+	}
+	sort.Strings(entries)
+	return entries
+}
+
 func buildSSHCertificateRequest(r certificate.SshCertRequest, cf *commandFlags) certificate.SshCertRequest {
 
 	if cf.sshCertKeyPassphrase != "" {
@@ -0,0 +1,156 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+)
+
+func TestSshInspectResult(t *testing.T) {
+	_, caKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ca, err := ssh.NewSignerFromKey(caKey)
+	require.NoError(t, err)
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	sshPub, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cert := &ssh.Certificate{
+		Key:             sshPub,
+		CertType:        ssh.UserCert,
+		KeyId:           "alice",
+		ValidPrincipals: []string{"alice"},
+		ValidAfter:      uint64(now.Add(-time.Hour).Unix()),
+		ValidBefore:     uint64(now.Add(72 * time.Hour).Unix()),
+		Permissions: ssh.Permissions{
+			CriticalOptions: map[string]string{"verify-required": ""},
+			Extensions:      map[string]string{"permit-pty": ""},
+		},
+	}
+	require.NoError(t, cert.SignCert(rand.Reader, ca))
+
+	result := newSshInspectResult(cert)
+	verified := true
+	result.Verified = &verified
+
+	var text bytes.Buffer
+	require.NoError(t, writeSshInspectResult(&text, sshInspectFormatText, result))
+	require.Contains(t, text.String(), "\tCertificate Identifier: alice\n")
+	require.Contains(t, text.String(), "\tCritical Options:\n\t\tverify-required\n")
+	require.Contains(t, text.String(), "\tExtensions:\n\t\tpermit-pty\n")
+	require.Contains(t, text.String(), "\tVerified against CA: true\n")
+
+	var out bytes.Buffer
+	require.NoError(t, writeSshInspectResult(&out, sshInspectFormatJSON, result))
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	require.Equal(t, "alice", decoded["KeyID"])
+	require.Equal(t, certificate.SshCertTypeUser, decoded["CertificateType"])
+	require.Equal(t, map[string]interface{}{"verify-required": ""}, decoded["CriticalOptions"])
+	require.Equal(t, true, decoded["Verified"])
+
+	details := result.SshCertificateDetails
+	require.Empty(t, sshCertificateExpiryWarning(details, now, 24*time.Hour))
+	require.Contains(t, sshCertificateExpiryWarning(details, now, 7*24*time.Hour), "expires on")
+	require.Contains(t, sshCertificateExpiryWarning(details, now.Add(100*time.Hour), 0), "expired on")
+	require.Contains(t, sshCertificateExpiryWarning(details, now.Add(-2*time.Hour), 0), "not valid before")
+
+	details.ValidTo = 0
+	require.Empty(t, sshCertificateExpiryWarning(details, now.Add(1000*time.Hour), 7*24*time.Hour))
+}
+
+func TestValidateSshInspectFlags(t *testing.T) {
+	defer func() { flags = commandFlags{} }()
+
+	flags = commandFlags{sshInspectFormat: sshInspectFormatText}
+	require.ErrorContains(t, validateSshInspectFlags(commandSshInspectName), "--file")
+
+	flags = commandFlags{sshInspectFile: "id-cert.pub", sshInspectFormat: "yaml"}
+	require.ErrorContains(t, validateSshInspectFlags(commandSshInspectName), "unexpected output format")
+
+	flags = commandFlags{sshInspectFile: "id-cert.pub", sshInspectFormat: sshInspectFormatJSON, sshInspectWarnWithin: "soon"}
+	require.ErrorContains(t, validateSshInspectFlags(commandSshInspectName), "invalid --expiring-within")
+
+	flags = commandFlags{sshInspectFile: "id-cert.pub", sshInspectFormat: sshInspectFormatText, sshInspectCaKey: "ca.pub", sshCertTemplate: "Users"}
+	require.ErrorContains(t, validateSshInspectFlags(commandSshInspectName), "cannot be used with --template")
+
+	flags = commandFlags{sshInspectFile: "id-cert.pub", sshInspectFormat: sshInspectFormatText, sshInspectCaKey: "ca.pub", sshInspectWarnWithin: "12h"}
+	require.NoError(t, validateSshInspectFlags(commandSshInspectName))
+}
+
+func TestWriteSshCaTrust(t *testing.T) {
+	defer func() { flags = commandFlags{} }()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	caKey, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+	caPublicKey := strings.TrimSpace(string(ssh.MarshalAuthorizedKey(caKey)))
+
+	dir := t.TempDir()
+	flags = commandFlags{
+		sshCertTemplate:     "Users",
+		sshTrustUserKeys:    filepath.Join(dir, "trusted_user_ca_keys"),
+		sshTrustSshdConfig:  filepath.Join(dir, "sshd_config.d", "vcert.conf"),
+		sshTrustKnownHosts:  filepath.Join(dir, "known_hosts"),
+		sshTrustHostPattern: []string{"*.example.com"},
+		sshTrustDryRun:      true,
+	}
+	require.NoError(t, writeSshCaTrust(caPublicKey))
+	require.NoFileExists(t, flags.sshTrustUserKeys)
+	require.NoFileExists(t, flags.sshTrustKnownHosts)
+
+	flags.sshTrustDryRun = false
+	require.NoError(t, writeSshCaTrust(caPublicKey))
+	content, err := os.ReadFile(flags.sshTrustUserKeys)
+	require.NoError(t, err)
+	require.Equal(t, "# BEGIN VCERT SSH CA Users\n"+caPublicKey+"\n# END VCERT SSH CA Users\n", string(content))
+	content, err = os.ReadFile(flags.sshTrustKnownHosts)
+	require.NoError(t, err)
+	require.Contains(t, string(content), "\n@cert-authority *.example.com "+caPublicKey+"\n")
+	require.FileExists(t, flags.sshTrustSshdConfig)
+}
+
+func TestValidateGetSshConfigFlags(t *testing.T) {
+	defer func() { flags = commandFlags{} }()
+
+	flags = commandFlags{url: "https://tpp.example.com", token: "token", sshCertTemplate: "Users", sshTrustSshdConfig: "vcert.conf"}
+	require.ErrorContains(t, validateGetSshConfigFlags(commandSshGetConfigName), "--sshd-config can only be used with --trusted-user-ca-keys")
+
+	flags = commandFlags{url: "https://tpp.example.com", token: "token", sshCertTemplate: "Hosts", sshTrustHostPattern: []string{"*"}}
+	require.ErrorContains(t, validateGetSshConfigFlags(commandSshGetConfigName), "--host-pattern can only be used with --known-hosts")
+
+	flags = commandFlags{url: "https://tpp.example.com", token: "token", sshCertTemplate: "Hosts", sshTrustDryRun: true}
+	require.ErrorContains(t, validateGetSshConfigFlags(commandSshGetConfigName), "--dry-run can only be used")
+
+	flags = commandFlags{url: "https://tpp.example.com", token: "token", sshCertTemplate: "Hosts", sshTrustKnownHosts: "known_hosts", sshTrustDryRun: true}
+	require.NoError(t, validateGetSshConfigFlags(commandSshGetConfigName))
+}
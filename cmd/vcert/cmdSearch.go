@@ -0,0 +1,244 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/Venafi/vcert/v5"
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/policy"
+	"github.com/Venafi/vcert/v5/pkg/util"
+)
+
+const (
+	searchFormatTable = "table"
+	searchFormatJSON  = "json"
+	searchFormatCSV   = "csv"
+
+	// searchListSeparator joins the values of a list in a csv column
+	searchListSeparator = ";"
+)
+
+var (
+	commandSearch = &cli.Command{
+		Before: runBeforeCommand,
+		Name:   commandSearchName,
+		Flags:  searchFlags,
+		Action: doCommandSearch,
+		Usage:  "To search the certificate inventory",
+		UsageText: ` vcert search <Required Venafi Control Plane -OR- Trust Protection Platform Config> <Options>
+
+		 vcert search -k <VCP API key> -z "<app name>\<CIT alias>" --expiring-within 30d
+		 vcert search -p vcp -t <VCP access token> --san www.example.com --format json
+
+		 vcert search -u https://tpp.example.com -t <TPP access token> -z "<policy folder DN>" --expiring-within 30d
+		 vcert search -u https://tpp.example.com -t <TPP access token> --issuer "Example CA" --format csv --limit 500 --page 2`,
+	}
+)
+
+func doCommandSearch(c *cli.Context) error {
+	err := validateSearchFlags(c.Command.Name)
+	if err != nil {
+		return err
+	}
+	err = setTLSConfig()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := buildConfig(c, &flags)
+	if err != nil {
+		return fmt.Errorf("Failed to build vcert config: %s", err)
+	}
+
+	connector, err := vcert.NewClient(&cfg)
+	if err != nil {
+		return fmt.Errorf("Unable to connect to %s: %s", cfg.ConnectorType, err)
+	}
+	logf("Successfully connected to %s", cfg.ConnectorType)
+
+	req, err := buildSearchRequest(&flags, connector.GetType(), time.Now())
+	if err != nil {
+		return err
+	}
+	resp, err := connector.SearchCertificates(&req)
+	if err != nil {
+		return fmt.Errorf("Failed to search certificates: %s", err)
+	}
+
+	results := make([]certificate.CertificateInfo, 0, len(resp.Certificates))
+	for _, found := range resp.Certificates {
+		results = append(results, searchResultInfo(found))
+	}
+	if len(results) == 0 {
+		logf("No certificates found")
+	} else {
+		first := (flags.searchPage-1)*flags.searchLimit + 1
+		logf("Found %d certificates, listing %d to %d", resp.Count, first, first+len(results)-1)
+	}
+	return writeSearchResults(os.Stdout, flags.searchFormat, results)
+}
+
+// buildSearchRequest translates the search flags to the attributes of a certificate search
+func buildSearchRequest(cf *commandFlags, connectorType endpoint.ConnectorType, now time.Time) (certificate.SearchRequest, error) {
+	var req certificate.SearchRequest
+	add := func(attribute, value string) {
+		req = append(req, attribute+"="+url.QueryEscape(value))
+	}
+
+	if cf.commonName != "" {
+		add("CN", cf.commonName)
+	}
+	if cf.searchSAN != "" {
+		add(searchSANAttribute(cf.searchSAN), cf.searchSAN)
+	}
+	if cf.zone != "" {
+		switch connectorType {
+		case endpoint.ConnectorTypeCloud:
+			add("Zone", cf.zone)
+		case endpoint.ConnectorTypeTPP:
+			add("ParentDnRecursive", searchPolicyDN(cf.zone))
+		default:
+			add("ParentDnRecursive", cf.zone)
+		}
+	}
+	if cf.searchIssuer != "" {
+		add("Issuer", cf.searchIssuer)
+	}
+	if cf.searchExpiringWithin != "" {
+		within, err := parseExpiringWithin(cf.searchExpiringWithin)
+		if err != nil {
+			return nil, err
+		}
+		add("ValidToGreater", now.UTC().Format(time.RFC3339))
+		add("ValidToLess", now.Add(within).UTC().Format(time.RFC3339))
+	}
+	if cf.thumbprint != "" {
+		add("Thumbprint", cf.thumbprint)
+	}
+	if cf.searchSerial != "" {
+		add("Serial", cf.searchSerial)
+	}
+	if cf.searchLimit > 0 {
+		add("Limit", strconv.Itoa(cf.searchLimit))
+		add("Offset", strconv.Itoa((cf.searchPage-1)*cf.searchLimit))
+	}
+	return req, nil
+}
+
+// searchSANAttribute returns the search attribute matching the type of the subject alternative name
+func searchSANAttribute(san string) string {
+	switch {
+	case net.ParseIP(san) != nil:
+		return "SAN-IP"
+	case strings.Contains(san, "://"):
+		return "SAN-URI"
+	case strings.Contains(san, "@"):
+		return "SAN-Email"
+	default:
+		return "SAN-DNS"
+	}
+}
+
+// searchPolicyDN returns the DN of the policy folder of a Trust Protection Platform zone
+func searchPolicyDN(zone string) string {
+	if strings.HasPrefix(zone, policy.RootPath) {
+		return zone
+	}
+	return policy.RootPath + util.PathSeparator + strings.TrimPrefix(zone, util.PathSeparator)
+}
+
+// parseExpiringWithin parses a number of days such as 30d, or a duration such as 12h
+func parseExpiringWithin(period string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(strings.ToLower(period), "d"); found {
+		n, err := strconv.Atoi(days)
+		if err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	} else if d, err := time.ParseDuration(period); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid --expiring-within %q. Expected a number of days such as 30d or a duration such as 12h", period)
+}
+
+// searchResultInfo returns the details of a certificate found, identified by its ID in VCP and its DN in TPP
+func searchResultInfo(found certificate.CertSeachInfo) certificate.CertificateInfo {
+	info := found.X509
+	if info.ID == "" {
+		info.ID = found.CertificateRequestId
+	}
+	return info
+}
+
+func writeSearchResults(w io.Writer, format string, results []certificate.CertificateInfo) error {
+	switch format {
+	case searchFormatJSON:
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case searchFormatCSV:
+		cw := csv.NewWriter(w)
+		err := cw.Write([]string{"id", "cn", "san-dns", "san-email", "san-ip", "san-uri", "issuer", "serial", "thumbprint", "valid-from", "valid-to"})
+		if err != nil {
+			return err
+		}
+		for _, info := range results {
+			err = cw.Write([]string{
+				info.ID,
+				info.CN,
+				strings.Join(info.SANS.DNS, searchListSeparator),
+				strings.Join(info.SANS.Email, searchListSeparator),
+				strings.Join(info.SANS.IP, searchListSeparator),
+				strings.Join(info.SANS.URI, searchListSeparator),
+				info.Issuer,
+				info.Serial,
+				info.Thumbprint,
+				info.ValidFrom.UTC().Format(time.RFC3339),
+				info.ValidTo.UTC().Format(time.RFC3339),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "COMMON NAME\tSERIAL\tVALID TO\tISSUER\tID")
+		for _, info := range results {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", info.CN, info.Serial, info.ValidTo.UTC().Format(time.DateOnly), info.Issuer, info.ID)
+		}
+		return tw.Flush()
+	}
+}
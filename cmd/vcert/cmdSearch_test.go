@@ -0,0 +1,114 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/venafi/fake"
+)
+
+func TestBuildSearchRequest(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cf := commandFlags{
+		zone:                 "Certificates\\Web",
+		searchSAN:            "10.0.0.1",
+		searchExpiringWithin: "30d",
+		searchLimit:          50,
+		searchPage:           3,
+	}
+	req, err := buildSearchRequest(&cf, endpoint.ConnectorTypeTPP, now)
+	require.NoError(t, err)
+	require.Equal(t, certificate.SearchRequest{
+		"SAN-IP=10.0.0.1",
+		"ParentDnRecursive=%5CVED%5CPolicy%5CCertificates%5CWeb",
+		"ValidToGreater=2024-05-01T12%3A00%3A00Z",
+		"ValidToLess=2024-05-31T12%3A00%3A00Z",
+		"Limit=50",
+		"Offset=100",
+	}, req)
+
+	req, err = buildSearchRequest(&cf, endpoint.ConnectorTypeCloud, now)
+	require.NoError(t, err)
+	require.Contains(t, req, "Zone=Certificates%5CWeb")
+
+	for san, attribute := range map[string]string{
+		"www.example.com":         "SAN-DNS",
+		"admin@example.com":       "SAN-Email",
+		"spiffe://example.com/id": "SAN-URI",
+		"2001:db8::1":             "SAN-IP",
+	} {
+		require.Equal(t, attribute, searchSANAttribute(san), san)
+	}
+
+	for _, period := range []string{"", "0d", "-1d", "soon", "-2h"} {
+		_, err = parseExpiringWithin(period)
+		require.Error(t, err, period)
+	}
+	d, err := parseExpiringWithin("36h")
+	require.NoError(t, err)
+	require.Equal(t, 36*time.Hour, d)
+}
+
+func TestSearchCertificates(t *testing.T) {
+	connector := fake.NewConnector(false, nil)
+	for _, cn := range []string{"one.example.com", "two.example.com"} {
+		req := &certificate.Request{}
+		req.Subject.CommonName = cn
+		req.DNSNames = []string{"www." + cn}
+		require.NoError(t, connector.GenerateRequest(nil, req))
+		_, err := connector.RequestCertificate(req)
+		require.NoError(t, err)
+		_, err = connector.RetrieveCertificate(req)
+		require.NoError(t, err)
+	}
+
+	cf := commandFlags{searchSAN: "www.two.example.com", searchExpiringWithin: "365d", searchPage: 1}
+	req, err := buildSearchRequest(&cf, connector.GetType(), time.Now())
+	require.NoError(t, err)
+	resp, err := connector.SearchCertificates(&req)
+	require.NoError(t, err)
+	require.Len(t, resp.Certificates, 1)
+	results := []certificate.CertificateInfo{searchResultInfo(resp.Certificates[0])}
+	require.Equal(t, "two.example.com", results[0].CN)
+	require.NotEmpty(t, results[0].ID)
+
+	var out bytes.Buffer
+	require.NoError(t, writeSearchResults(&out, searchFormatJSON, results))
+	var decoded []certificate.CertificateInfo
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	require.Equal(t, results[0].Serial, decoded[0].Serial)
+
+	out.Reset()
+	require.NoError(t, writeSearchResults(&out, searchFormatCSV, results))
+	records, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, []string{"two.example.com", "www.two.example.com"}, records[1][1:3])
+
+	out.Reset()
+	require.NoError(t, writeSearchResults(&out, searchFormatTable, results))
+	require.Contains(t, out.String(), "two.example.com")
+}
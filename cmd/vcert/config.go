@@ -28,7 +28,9 @@ import (
 
 	"github.com/Venafi/vcert/v5"
 	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/httputils"
 	"github.com/Venafi/vcert/v5/pkg/venafi"
+	"github.com/Venafi/vcert/v5/pkg/venafi/firefly"
 )
 
 func buildConfig(c *cli.Context, flags *commandFlags) (cfg vcert.Config, err error) {
@@ -54,6 +56,27 @@ func buildConfig(c *cli.Context, flags *commandFlags) (cfg vcert.Config, err err
 	//verbosity
 	cfg.LogVerbose = flags.verbose
 
+	// retries and rate limit of the requests to the platform
+	if flags.maxRetries < 0 {
+		return cfg, fmt.Errorf("--max-retries cannot be negative")
+	}
+	if flags.rateLimit < 0 {
+		return cfg, fmt.Errorf("--rate-limit cannot be negative")
+	}
+	retryPolicy := httputils.DefaultRetryPolicy
+	retryPolicy.MaxRetries = flags.maxRetries
+	retryPolicy.RequestsPerSecond = flags.rateLimit
+	cfg.RetryPolicy = &retryPolicy
+
+	// the OAuth 2.0 tokens are cached, so that the authorization flow, e.g. the device approval, doesn't run on every call
+	if cfg.ConnectorType == endpoint.ConnectorTypeFirefly {
+		path, cacheErr := firefly.DefaultTokenCachePath()
+		if cacheErr != nil {
+			logf("Warning: the OAuth 2.0 tokens are not cached: %s", cacheErr)
+		}
+		cfg.TokenCachePath = path
+	}
+
 	// trust bundle may be overridden by CLI flag
 	if flags.trustBundle != "" {
 		logf("Detected trust bundle...")
@@ -126,10 +149,11 @@ func buildConfigFromFlags(commandName string, flags *commandFlags) (*vcert.Confi
 	}
 }
 
-func buildConfigFake(_ *commandFlags) (*vcert.Config, error) {
+func buildConfigFake(flags *commandFlags) (*vcert.Config, error) {
 	return &vcert.Config{
-		ConnectorType: endpoint.ConnectorTypeFake,
-		Credentials:   &endpoint.Authentication{},
+		ConnectorType:     endpoint.ConnectorTypeFake,
+		Credentials:       &endpoint.Authentication{},
+		FakeInventoryPath: flags.testModeInventory,
 	}, nil
 }
 
@@ -0,0 +1,339 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pavel-v-chernykh/keystore-go/v4"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+const (
+	// discoverMaxFileSize is the size above which a file cannot be a certificate or a keystore worth reading
+	discoverMaxFileSize = 1 << 20
+	// discoverMaxProbes bounds the number of endpoints the targets of a single discovery expand to
+	discoverMaxProbes = 1 << 16
+	// jksMagic starts every Java keystore
+	jksMagic = 0xFEEDFEED
+)
+
+// discoveredCertificate is a certificate found by discover, with the files and endpoints where it was found
+type discoveredCertificate struct {
+	cert       *x509.Certificate
+	thumbprint string
+	sources    []string
+}
+
+// discovery collects the certificates found in files and on TLS endpoints, deduplicated by thumbprint
+type discovery struct {
+	mu           sync.Mutex
+	certificates []*discoveredCertificate
+	byThumbprint map[string]*discoveredCertificate
+}
+
+func newDiscovery() *discovery {
+	return &discovery{byThumbprint: map[string]*discoveredCertificate{}}
+}
+
+func (d *discovery) add(cert *x509.Certificate, source string) {
+	fp := sha1.Sum(cert.Raw)
+	thumbprint := strings.ToUpper(hex.EncodeToString(fp[:]))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	found, ok := d.byThumbprint[thumbprint]
+	if !ok {
+		found = &discoveredCertificate{cert: cert, thumbprint: thumbprint}
+		d.byThumbprint[thumbprint] = found
+		d.certificates = append(d.certificates, found)
+	}
+	for _, s := range found.sources {
+		if s == source {
+			return
+		}
+	}
+	found.sources = append(found.sources, source)
+}
+
+// scanPaths adds the certificates of the files found under paths. Files that don't hold certificates are ignored
+func (d *discovery) scanPaths(paths []string, passwords []string) error {
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				if path == root {
+					return err
+				}
+				logf("Skipping %s: %s", path, err)
+				return nil
+			}
+			if !entry.Type().IsRegular() {
+				return nil
+			}
+			info, err := entry.Info()
+			if err != nil || info.Size() > discoverMaxFileSize {
+				return nil
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				logf("Skipping %s: %s", path, err)
+				return nil
+			}
+			certs, err := readDiscoveredCertificates(path, data, passwords)
+			if err != nil {
+				logf("Skipping %s: %s", path, err)
+				return nil
+			}
+			for _, cert := range certs {
+				d.add(cert, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scan %s: %w", root, err)
+		}
+	}
+	return nil
+}
+
+// readDiscoveredCertificates returns the certificates of a PEM, DER, PKCS#12 or JKS file. Keystores are opened with
+// the first of the passwords that works
+func readDiscoveredCertificates(path string, data []byte, passwords []string) ([]*x509.Certificate, error) {
+	switch {
+	case bytes.Contains(data, []byte("-----BEGIN")):
+		var certs []*x509.Certificate
+		for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, cert)
+		}
+		return certs, nil
+	case len(data) > 4 && binary.BigEndian.Uint32(data) == jksMagic:
+		return readJKSCertificates(data, passwords)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".p12", ".pfx":
+		return readPKCS12Certificates(data, passwords)
+	}
+	certs, err := x509.ParseCertificates(data)
+	if err != nil {
+		// not a DER certificate either
+		return nil, nil
+	}
+	return certs, nil
+}
+
+func readPKCS12Certificates(data []byte, passwords []string) ([]*x509.Certificate, error) {
+	var err error
+	for _, password := range append([]string{""}, passwords...) {
+		var blocks []*pem.Block
+		blocks, err = pkcs12.ToPEM(data, password)
+		if err != nil {
+			continue
+		}
+		var certs []*x509.Certificate
+		for _, block := range blocks {
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, cert)
+		}
+		return certs, nil
+	}
+	return nil, fmt.Errorf("failed to open PKCS#12 keystore: %w", err)
+}
+
+func readJKSCertificates(data []byte, passwords []string) ([]*x509.Certificate, error) {
+	var err error
+	for _, password := range append([]string{""}, passwords...) {
+		ks := keystore.New()
+		err = ks.Load(bytes.NewReader(data), []byte(password))
+		if err != nil {
+			continue
+		}
+		var certs []*x509.Certificate
+		for _, alias := range ks.Aliases() {
+			var chain []keystore.Certificate
+			if ks.IsTrustedCertificateEntry(alias) {
+				entry, err := ks.GetTrustedCertificateEntry(alias)
+				if err != nil {
+					return nil, err
+				}
+				chain = append(chain, entry.Certificate)
+			} else if ks.IsPrivateKeyEntry(alias) {
+				entry, err := ks.GetPrivateKeyEntry(alias, []byte(password))
+				if err != nil {
+					return nil, fmt.Errorf("failed to open entry %s: %w", alias, err)
+				}
+				chain = entry.CertificateChain
+			}
+			for _, c := range chain {
+				cert, err := x509.ParseCertificate(c.Content)
+				if err != nil {
+					return nil, err
+				}
+				certs = append(certs, cert)
+			}
+		}
+		return certs, nil
+	}
+	return nil, fmt.Errorf("failed to open Java keystore: %w", err)
+}
+
+// probeTargets adds the certificate chains of the TLS endpoints of targets, probing up to concurrency of them at once
+func (d *discovery) probeTargets(targets []string, timeout time.Duration, concurrency int) (probed, answered int, err error) {
+	addresses, err := expandDiscoverTargets(targets)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sem := make(chan struct{}, concurrency)
+	for _, address := range addresses {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(address string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			chain, err := probeTLSEndpoint(address, timeout)
+			if err != nil {
+				if flags.verbose {
+					logf("No TLS endpoint at %s: %s", address, err)
+				}
+				return
+			}
+			mu.Lock()
+			answered++
+			mu.Unlock()
+			for _, cert := range chain {
+				d.add(cert, "tls://"+address)
+			}
+		}(address)
+	}
+	wg.Wait()
+	return len(addresses), answered, nil
+}
+
+func probeTLSEndpoint(address string, timeout time.Duration) ([]*x509.Certificate, error) {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{InsecureSkipVerify: true} // the certificates are collected, not trusted
+	if net.ParseIP(host) == nil {
+		config.ServerName = host
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", address, config)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.ConnectionState().PeerCertificates, nil
+}
+
+// expandDiscoverTargets returns the addresses of targets such as host:443, 10.0.0.0/24:443 or host:8443-8450
+func expandDiscoverTargets(targets []string) ([]string, error) {
+	var addresses []string
+	for _, target := range targets {
+		host, portRange, err := net.SplitHostPort(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target %q. Expected <host>:<port>: %w", target, err)
+		}
+		first, last, err := parsePortRange(portRange)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target %q: %w", target, err)
+		}
+
+		hosts := []string{host}
+		if strings.Contains(host, "/") {
+			ip, ipNet, err := net.ParseCIDR(host)
+			if err != nil {
+				return nil, fmt.Errorf("invalid target %q: %w", target, err)
+			}
+			hosts = nil
+			for ip = ip.Mask(ipNet.Mask); ipNet.Contains(ip); ip = nextIP(ip) {
+				hosts = append(hosts, ip.String())
+				if len(hosts) > discoverMaxProbes {
+					break
+				}
+			}
+		}
+		for _, h := range hosts {
+			for port := first; port <= last; port++ {
+				addresses = append(addresses, net.JoinHostPort(h, strconv.Itoa(port)))
+			}
+			if len(addresses) > discoverMaxProbes {
+				return nil, fmt.Errorf("the targets expand to more than %d endpoints", discoverMaxProbes)
+			}
+		}
+	}
+	return addresses, nil
+}
+
+func parsePortRange(portRange string) (first, last int, err error) {
+	from, to, isRange := strings.Cut(portRange, "-")
+	first, err = strconv.Atoi(from)
+	if err != nil || first < 1 || first > 65535 {
+		return 0, 0, fmt.Errorf("invalid port %q", from)
+	}
+	last = first
+	if isRange {
+		last, err = strconv.Atoi(to)
+		if err != nil || last < first || last > 65535 {
+			return 0, 0, fmt.Errorf("invalid port range %q", portRange)
+		}
+	}
+	return first, last, nil
+}
+
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
@@ -0,0 +1,185 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pavel-v-chernykh/keystore-go/v4"
+	"github.com/stretchr/testify/require"
+	"software.sslmate.com/src/go-pkcs12"
+
+	"github.com/Venafi/vcert/v5/pkg/venafi/fake"
+)
+
+// newDiscoverTestCertificate returns a certificate for cn signed by issuer, or a self-signed CA without issuer
+func newDiscoverTestCertificate(t *testing.T, cn string, issuer *x509.Certificate, issuerKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn},
+		DNSNames:              []string{cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		BasicConstraintsValid: true,
+	}
+	if issuer == nil {
+		template.IsCA = true
+		template.KeyUsage = x509.KeyUsageCertSign
+		issuer, issuerKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, key.Public(), issuerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func pemCertificate(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
+func TestDiscoverFiles(t *testing.T) {
+	ca, caKey := newDiscoverTestCertificate(t, "Discovery Test CA", nil, nil)
+	web, webKey := newDiscoverTestCertificate(t, "web.example.com", ca, caKey)
+	mail, _ := newDiscoverTestCertificate(t, "mail.example.com", ca, caKey)
+	app, appKey := newDiscoverTestCertificate(t, "app.example.com", ca, caKey)
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "keystores"), 0700))
+	write := func(name string, data []byte) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), data, 0600))
+	}
+	write("web.pem", append(pemCertificate(web), pemCertificate(ca)...))
+	write("mail.der", mail.Raw)
+	write("notes.txt", []byte("not a certificate"))
+
+	p12, err := pkcs12.Modern2023.Encode(webKey, web, []*x509.Certificate{ca}, "secret")
+	require.NoError(t, err)
+	write(filepath.Join("keystores", "web.p12"), p12)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(appKey)
+	require.NoError(t, err)
+	ks := keystore.New()
+	require.NoError(t, ks.SetPrivateKeyEntry("app", keystore.PrivateKeyEntry{
+		CreationTime:     time.Now(),
+		PrivateKey:       pkcs8,
+		CertificateChain: []keystore.Certificate{{Type: "X509", Content: app.Raw}, {Type: "X509", Content: ca.Raw}},
+	}, []byte("changeit")))
+	var jks bytes.Buffer
+	require.NoError(t, ks.Store(&jks, []byte("changeit")))
+	write(filepath.Join("keystores", "app.jks"), jks.Bytes())
+
+	d := newDiscovery()
+	require.NoError(t, d.scanPaths([]string{dir}, []string{"secret", "changeit"}))
+	require.Len(t, d.certificates, 4, "the certificates are deduplicated by thumbprint")
+
+	sources := map[string][]string{}
+	for _, found := range d.certificates {
+		sources[found.cert.Subject.CommonName] = found.sources
+	}
+	require.ElementsMatch(t, []string{filepath.Join(dir, "web.pem"), filepath.Join(dir, "keystores", "web.p12")}, sources["web.example.com"])
+	require.Equal(t, []string{filepath.Join(dir, "mail.der")}, sources["mail.example.com"])
+	require.Equal(t, []string{filepath.Join(dir, "keystores", "app.jks")}, sources["app.example.com"])
+	require.Len(t, sources["Discovery Test CA"], 3)
+
+	// without the passwords the keystores are skipped
+	d = newDiscovery()
+	require.NoError(t, d.scanPaths([]string{dir}, nil))
+	require.Len(t, d.certificates, 3)
+
+	connector := fake.NewConnector(false, nil)
+	connector.SetZone("Discovered")
+	results, err := importDiscovered(connector, d, true)
+	require.NoError(t, err)
+	require.Len(t, results, 2, "the CA certificate is not imported")
+	for _, r := range results {
+		require.Equal(t, discoverStatusDryRun, r.Status)
+	}
+
+	results, err = importDiscovered(connector, d, false)
+	require.NoError(t, err)
+	for _, r := range results {
+		require.Equal(t, discoverStatusImported, r.Status, r.Error)
+		require.True(t, strings.HasPrefix(r.ID, "\\VED\\Policy\\Discovered\\"), r.ID)
+	}
+
+	results, err = importDiscovered(connector, d, false)
+	require.NoError(t, err)
+	for _, r := range results {
+		require.Equal(t, discoverStatusManaged, r.Status)
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, writeDiscoverResults(&out, searchFormatTable, results))
+	require.Contains(t, out.String(), "mail.example.com")
+}
+
+func TestDiscoverTLSEndpoints(t *testing.T) {
+	ca, caKey := newDiscoverTestCertificate(t, "Discovery Test CA", nil, nil)
+	web, webKey := newDiscoverTestCertificate(t, "web.example.com", ca, caKey)
+
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{web.Raw, ca.Raw}, PrivateKey: webKey}}}
+	server.StartTLS()
+	defer server.Close()
+	address := strings.TrimPrefix(server.URL, "https://")
+
+	d := newDiscovery()
+	probed, answered, err := d.probeTargets([]string{address}, time.Second, 4)
+	require.NoError(t, err)
+	require.Equal(t, 1, probed)
+	require.Equal(t, 1, answered)
+	require.Len(t, d.certificates, 2)
+	require.Equal(t, web.Raw, d.certificates[0].cert.Raw)
+	require.Equal(t, []string{"tls://" + address}, d.certificates[0].sources)
+}
+
+func TestExpandDiscoverTargets(t *testing.T) {
+	addresses, err := expandDiscoverTargets([]string{"www.example.com:443", "10.0.0.0/31:8443-8444", "[2001:db8::1]:443"})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"www.example.com:443",
+		"10.0.0.0:8443", "10.0.0.0:8444", "10.0.0.1:8443", "10.0.0.1:8444",
+		"[2001:db8::1]:443",
+	}, addresses)
+
+	for _, target := range []string{"www.example.com", "www.example.com:0", "www.example.com:443-80", "10.0.0.0/33:443", "10.0.0.0/8:443"} {
+		_, err = expandDiscoverTargets([]string{target})
+		require.Error(t, err, target)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"go.uber.org/zap"
+
+	"github.com/Venafi/vcert/v5/pkg/playbook/app/domain"
+	"github.com/Venafi/vcert/v5/pkg/playbook/app/service"
+	"github.com/Venafi/vcert/v5/pkg/util"
+)
+
+const (
+	commandExporterName = "exporter"
+
+	defaultExporterAddr = "localhost:9469"
+)
+
+var commandExporter = &cli.Command{
+	Name: commandExporterName,
+	Usage: `Serves Prometheus metrics on the certificates installed by a vcert playbook file, such as the number 
+	of days until each of them expires. The playbook is read again on every scrape; no certificate is requested.`,
+	UsageText: `vcert exporter
+   vcert exporter -f /path/to/my/file.yml
+   vcert exporter -f ./myFile.yaml --listen-addr :9469`,
+	Action: doRunExporter,
+	Flags:  exporterFlags,
+}
+
+var (
+	PBFlagListenAddr = &cli.StringFlag{
+		Name:        "listen-addr",
+		Usage:       "the address on which the metrics are served on /metrics",
+		Required:    false,
+		Value:       defaultExporterAddr,
+		Destination: &playbookOptions.listenAddr,
+	}
+
+	exporterFlags = flagsApppend(
+		PBFlagDebug,
+		PBFlagFilepath,
+		PBFlagListenAddr,
+	)
+)
+
+func doRunExporter(_ *cli.Context) error {
+	err := util.ConfigureLogger(playbookOptions.debug)
+	if err != nil {
+		return err
+	}
+
+	// The playbook is validated once so that a wrong file is reported right away
+	_, err = readPlaybook(playbookOptions.filepath)
+	if err != nil {
+		zap.L().Error(err.Error())
+		os.Exit(1)
+	}
+
+	exporter := service.NewExporter(func() (domain.Playbook, error) {
+		return readPlaybook(playbookOptions.filepath)
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exporter)
+	server := &http.Server{
+		Addr:              playbookOptions.listenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+
+	zap.L().Info("serving playbook metrics", zap.String("file", playbookOptions.filepath),
+		zap.String("address", playbookOptions.listenAddr))
+	err = server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		zap.L().Error("metrics server failed", zap.Error(err))
+		os.Exit(1)
+	}
+	zap.L().Info("exporter stopped")
+	return nil
+}
@@ -19,8 +19,12 @@ package main
 import (
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/urfave/cli/v2"
+
+	"github.com/Venafi/vcert/v5/pkg/httputils"
+	"github.com/Venafi/vcert/v5/pkg/util"
 )
 
 var (
@@ -338,6 +342,28 @@ var (
 		Destination: &flags.testModeDelay,
 	}
 
+	flagTestModeInventory = &cli.StringFlag{
+		Name: "test-mode-inventory",
+		Usage: "Use to specify the `path` of a file where test-mode keeps the inventory of the certificates it issues, " +
+			"so that later runs can retrieve, renew or revoke them. Example: --test-mode-inventory /tmp/vcert-inventory.json",
+		Destination: &flags.testModeInventory,
+	}
+
+	flagMaxRetries = &cli.IntFlag{
+		Name: "max-retries",
+		Usage: "Use to specify how many times a request to the Venafi platform is retried when it fails on a transient " +
+			"error, such as a 429 or 503 status or a refused connection. The delay between retries grows exponentially " +
+			"and honors the Retry-After header. 0 disables the retries.",
+		Value:       httputils.DefaultRetryPolicy.MaxRetries,
+		Destination: &flags.maxRetries,
+	}
+
+	flagRateLimit = &cli.Float64Flag{
+		Name:        "rate-limit",
+		Usage:       "Use to specify the maximum number of requests per second sent to the Venafi platform. 0 means no limit.",
+		Destination: &flags.rateLimit,
+	}
+
 	flagCSROption = &cli.StringFlag{
 		Name: "csr",
 		Usage: "Use to specify the CSR and private key location. Options include: local | service | file.\n" +
@@ -547,6 +573,13 @@ var (
 		Value:       "pem",
 	}
 
+	flagClearCache = &cli.BoolFlag{
+		Name: "clear-cache",
+		Usage: "Firefly. Use to remove the cached OAuth 2.0 tokens. The tokens obtained by getcred are cached and refreshed\n" +
+			"\t\tonce expired, so that the authorization flow, e.g. the device approval, runs again only when needed.",
+		Destination: &flags.clearCache,
+	}
+
 	flagCredFormat = &cli.StringFlag{
 		Name:        "format",
 		Usage:       "Use to output credentials in an alternate format. Example: --format json",
@@ -570,6 +603,27 @@ var (
 		Destination: &flags.validPeriod,
 	}
 
+	flagManifest = &cli.StringFlag{
+		Name: "manifest",
+		Usage: "Use to enroll a certificate for each row of a CSV or YAML `file`. The columns are named after the enroll flags,\n" +
+			"\te.g. cn, san-dns, key-type or cert-file, and the flags of the command line are the defaults of every row.",
+		Destination: &flags.manifest,
+	}
+
+	flagManifestConcurrency = &cli.IntFlag{
+		Name:        "manifest-concurrency",
+		Usage:       "Use to specify the maximum `number` of certificates of the --manifest that are enrolled at the same time.",
+		Value:       4,
+		Destination: &flags.manifestConcurrency,
+	}
+
+	flagManifestState = &cli.StringFlag{
+		Name: "manifest-state",
+		Usage: "Use to specify the `file` where the progress of the --manifest is recorded, so that enrolling it again only\n" +
+			"\tenrolls the rows that did not succeed. Defaults to the path of the manifest followed by .state",
+		Destination: &flags.manifestState,
+	}
+
 	flagPolicyName = &cli.StringFlag{
 		Name: "zone",
 		Usage: "REQUIRED. Use to specify target zone for applying or retrieving certificate policy. " +
@@ -598,6 +652,42 @@ var (
 		Destination: &flags.verifyPolicyConfig,
 	}
 
+	flagPolicyPlan = &cli.BoolFlag{
+		Name: "plan",
+		Usage: "Use to show the changes the policy specification would make to the policy of the zone without applying it. " +
+			"Exits with code 2 when there are changes and 1 on errors",
+		Destination: &flags.policyPlan,
+	}
+
+	flagPolicyRecursive = &cli.BoolFlag{
+		Name: "recursive",
+		Usage: "Use to work with the policy of the zone and of every zone below it. --file is then a directory with a " +
+			"policy.json file for each zone, in a sub directory per child zone",
+		Destination: &flags.policyRecursive,
+	}
+
+	flagPolicyCheckCSR = &cli.StringFlag{
+		Name:        "csr-file",
+		Usage:       "Use to specify the location of a PEM CSR to check against the policy. Example: --csr-file /path-to/csr.pem",
+		Destination: &flags.policyCheckCSR,
+		TakesFile:   true,
+	}
+
+	flagPolicyCheckCert = &cli.StringFlag{
+		Name:        "cert-file",
+		Usage:       "Use to specify the location of a PEM certificate to check against the policy. Example: --cert-file /path-to/cert.pem",
+		Destination: &flags.policyCheckCert,
+		TakesFile:   true,
+	}
+
+	flagPolicyCheckRequest = &cli.StringFlag{
+		Name: "request-file",
+		Usage: "Use to specify the location of a YAML certificate request to check against the policy. It has the " +
+			"layout of the request of a playbook certificate task. Example: --request-file /path-to/request.yaml",
+		Destination: &flags.policyCheckRequest,
+		TakesFile:   true,
+	}
+
 	//SSH Certificate flags
 
 	flagKeyId = &cli.StringFlag{
@@ -635,11 +725,20 @@ var (
 	}
 
 	flagSshKeySize = &cli.IntFlag{
-		Name:        "key-size",
-		Usage:       "key size bits, they will be used for creating keypair on case public-key:local",
+		Name: "key-size",
+		Usage: "key size bits, they will be used for creating keypair on case public-key:local. " +
+			"RSA: 3072 by default. ECDSA: 256 (default), 384 or 521",
 		Destination: &flags.sshCertKeySize,
 	}
 
+	flagSshKeyType = &cli.StringFlag{
+		Name: "key-type",
+		Usage: "the type of the keypair created on case public-key:local. Options include: rsa | ecdsa | ed25519. " +
+			"ECDSA and ED25519 private keys are written in the OpenSSH format unless --format pem is set",
+		Value:       util.SshKeyTypeRSA,
+		Destination: &flags.sshCertKeyType,
+	}
+
 	flagSshPassPhrase = &cli.StringFlag{
 		Name:        "key-passphrase",
 		Usage:       "passphrase for encrypting the private key",
@@ -708,6 +807,70 @@ var (
 		TakesFile:   true,
 	}
 
+	flagSshInspectFile = &cli.StringFlag{
+		Name:        "file",
+		Usage:       "Use to specify the SSH certificate file to inspect. Example: --file /path-to/id_ed25519-cert.pub",
+		Destination: &flags.sshInspectFile,
+		TakesFile:   true,
+	}
+
+	flagSshInspectCaKey = &cli.StringFlag{
+		Name: "ca-public-key",
+		Usage: "Use to verify the certificate against the CA public keys of a file, such as the one written by sshgetconfig.\n" +
+			"\tTo verify it against the CA public key of a certificate issuing template instead, use --template or --guid",
+		Destination: &flags.sshInspectCaKey,
+		TakesFile:   true,
+	}
+
+	flagSshInspectFormat = &cli.StringFlag{
+		Name:        "format",
+		Usage:       "Use to specify the output format: text or json",
+		Destination: &flags.sshInspectFormat,
+		Value:       sshInspectFormatText,
+	}
+
+	flagSshInspectWarnWithin = &cli.StringFlag{
+		Name:        "expiring-within",
+		Usage:       "Use to warn when the certificate expires within the `period`, in days (7d) or as a duration (12h)",
+		Destination: &flags.sshInspectWarnWithin,
+		Value:       "7d",
+	}
+
+	flagSshTrustUserKeys = &cli.StringFlag{
+		Name: "trusted-user-ca-keys",
+		Usage: "Use to trust the user certificates signed by the CA: its public keys are written to the TrustedUserCAKeys `file` of sshd.\n" +
+			"\tThe keys of the CA are replaced in place when it rotates. Example: --trusted-user-ca-keys /etc/ssh/trusted_user_ca_keys",
+		Destination: &flags.sshTrustUserKeys,
+		TakesFile:   true,
+	}
+
+	flagSshTrustSshdConfig = &cli.StringFlag{
+		Name: "sshd-config",
+		Usage: "Use with --trusted-user-ca-keys to write an sshd_config drop-in `file` setting TrustedUserCAKeys.\n" +
+			"\tExample: --sshd-config /etc/ssh/sshd_config.d/vcert.conf",
+		Destination: &flags.sshTrustSshdConfig,
+		TakesFile:   true,
+	}
+
+	flagSshTrustKnownHosts = &cli.StringFlag{
+		Name: "known-hosts",
+		Usage: "Use to trust the host certificates signed by the CA: @cert-authority lines are written to the known_hosts `file`.\n" +
+			"\tThe lines of the CA are replaced in place when it rotates. Example: --known-hosts ~/.ssh/known_hosts",
+		Destination: &flags.sshTrustKnownHosts,
+		TakesFile:   true,
+	}
+
+	flagSshTrustHostPattern = &cli.StringSliceFlag{
+		Name:  "host-pattern",
+		Usage: "Use with --known-hosts to specify the hosts whose certificates are signed by the CA. Example: --host-pattern *.example.com (default: *)",
+	}
+
+	flagSshTrustDryRun = &cli.BoolFlag{
+		Name:        "dry-run",
+		Usage:       "Use to print the trust files that would be written, without writing them",
+		Destination: &flags.sshTrustDryRun,
+	}
+
 	flagCertificateID = &cli.StringFlag{
 		Name:        "certificate-id",
 		Usage:       "The id of the certificate to be provisioned to a cloud keystore.",
@@ -771,6 +934,119 @@ var (
 		Destination: &flags.provisionFormat,
 	}
 
+	flagSearchCommonName = &cli.StringFlag{
+		Name:        "cn",
+		Usage:       "Use to search the certificates with the `common name` (CN)",
+		Destination: &flags.commonName,
+	}
+
+	flagSearchSAN = &cli.StringFlag{
+		Name: "san",
+		Usage: "Use to search the certificates with the subject alternative `name`. An IP address, an email address or an URI\n" +
+			"\tis searched as such, any other value as a DNS name",
+		Destination: &flags.searchSAN,
+	}
+
+	flagSearchZone = &cli.StringFlag{
+		Name: "zone",
+		Usage: "Use to search the certificates of a `zone`. In Trust Protection Platform this is the policy folder and its\n" +
+			"\tchildren, in Venafi Control Plane the application of the zone",
+		Destination: &flags.zone,
+		Aliases:     []string{"z"},
+	}
+
+	flagSearchIssuer = &cli.StringFlag{
+		Name:        "issuer",
+		Usage:       "Use to search the certificates whose issuer contains `name`",
+		Destination: &flags.searchIssuer,
+	}
+
+	flagSearchExpiringWithin = &cli.StringFlag{
+		Name: "expiring-within",
+		Usage: "Use to search the certificates that expire within the `period`, in days (30d) or as a duration (12h).\n" +
+			"\tCertificates that already expired are not included",
+		Destination: &flags.searchExpiringWithin,
+	}
+
+	flagSearchThumbprint = &cli.StringFlag{
+		Name: "thumbprint",
+		Usage: "Use to search the certificate with the SHA1 `thumbprint`. Value may be specified as a string or read from the\n" +
+			"\tcertificate file using the file: prefix",
+		Destination: &flags.thumbprint,
+	}
+
+	flagSearchSerial = &cli.StringFlag{
+		Name:        "serial",
+		Usage:       "Use to search the certificate with the hexadecimal `serial number`",
+		Destination: &flags.searchSerial,
+	}
+
+	flagSearchFormat = &cli.StringFlag{
+		Name:        "format",
+		Usage:       "Use to specify the output format of the results: table, json or csv",
+		Destination: &flags.searchFormat,
+		Value:       "table",
+	}
+
+	flagSearchLimit = &cli.IntFlag{
+		Name:        "limit",
+		Usage:       "Use to specify the `number` of certificates listed per page. 0 lists all the certificates found",
+		Destination: &flags.searchLimit,
+		Value:       100,
+	}
+
+	flagSearchPage = &cli.IntFlag{
+		Name:        "page",
+		Usage:       "Use to specify the `number` of the page to list, starting at 1",
+		Destination: &flags.searchPage,
+		Value:       1,
+	}
+
+	flagDiscoverPath = &cli.StringSliceFlag{
+		Name: "path",
+		Usage: "Use to specify a `directory` or a file to scan for PEM, DER, PKCS#12 and JKS certificates. Directories are\n" +
+			"\tscanned recursively. Can be specified multiple times",
+	}
+
+	flagDiscoverTarget = &cli.StringSliceFlag{
+		Name: "target",
+		Usage: "Use to specify the TLS endpoints to probe as `host:port`. The host may be a CIDR range and the port a range\n" +
+			"\tof ports. Example: --target 10.0.0.0/24:443 --target www.example.com:8443-8450",
+	}
+
+	flagDiscoverKeystorePassword = &cli.StringSliceFlag{
+		Name:  "keystore-password",
+		Usage: "Use to specify a `password` to try on the PKCS#12 and JKS keystores found. Can be specified multiple times",
+	}
+
+	flagDiscoverZone = &cli.StringFlag{
+		Name: "zone",
+		Usage: "Use to specify the `zone` where the certificates discovered are imported. In Trust Protection Platform this is\n" +
+			"\tthe policy folder, in Venafi Control Plane the application of the zone",
+		Destination: &flags.zone,
+		Aliases:     []string{"z"},
+	}
+
+	flagDiscoverDryRun = &cli.BoolFlag{
+		Name:        "dry-run",
+		Usage:       "Use to report the certificates discovered without importing them",
+		Destination: &flags.discoverDryRun,
+	}
+
+	flagDiscoverProbeTimeout = &cli.DurationFlag{
+		Name:        "probe-timeout",
+		Usage:       "Use to specify how long to wait for a TLS endpoint to answer",
+		Destination: &flags.discoverProbeTimeout,
+		Value:       3 * time.Second,
+	}
+
+	flagDiscoverConcurrency = &cli.IntFlag{
+		Name:        "probe-concurrency",
+		Usage:       "Use to specify the `number` of TLS endpoints probed at the same time",
+		Destination: &flags.discoverConcurrency,
+		Value:       16,
+	}
+
 	commonFlags              = []cli.Flag{flagInsecure, flagVerbose, flagNoPrompt}
 	keyFlags                 = []cli.Flag{flagKeyType, flagKeySize, flagKeyCurve, flagKeyFile, flagKeyPassword}
 	sansFlags                = []cli.Flag{flagDNSSans, flagEmailSans, flagIPSans, flagURISans, flagUPNSans}
@@ -778,6 +1054,7 @@ var (
 	sortableCredentialsFlags = []cli.Flag{
 		flagTestMode,
 		flagTestModeDelay,
+		flagTestModeInventory,
 		flagConfig,
 		flagProfile,
 		flagUrlDeprecated,
@@ -788,6 +1065,8 @@ var (
 		flagClientP12Deprecated,
 		flagClientP12PWDeprecated,
 		flagTrustBundle,
+		flagMaxRetries,
+		flagRateLimit,
 	}
 
 	credentialsFlags = []cli.Flag{
@@ -839,6 +1118,9 @@ var (
 			flagOmitSans,
 			flagValidDays,
 			flagValidPeriod,
+			flagManifest,
+			flagManifestConcurrency,
+			flagManifestState,
 		)),
 	)
 
@@ -869,6 +1151,7 @@ var (
 			flagRevocationNoRetire,
 			flagRevocationReason,
 			flagThumbprint,
+			flagTimeout,
 			commonFlags,
 			sortableCredentialsFlags,
 		)),
@@ -909,6 +1192,42 @@ var (
 		)),
 	)
 
+	searchFlags = flagsApppend(
+		flagPlatform,
+		credentialsFlags,
+		flagSearchCommonName,
+		flagSearchSAN,
+		flagSearchZone,
+		flagSearchIssuer,
+		flagSearchExpiringWithin,
+		flagSearchThumbprint,
+		flagSearchSerial,
+		flagSearchFormat,
+		flagSearchLimit,
+		flagSearchPage,
+		sortedFlags(flagsApppend(
+			commonFlags,
+			sortableCredentialsFlags,
+		)),
+	)
+
+	discoverFlags = flagsApppend(
+		flagPlatform,
+		credentialsFlags,
+		flagDiscoverZone,
+		flagDiscoverPath,
+		flagDiscoverTarget,
+		flagDiscoverKeystorePassword,
+		flagDiscoverDryRun,
+		flagSearchFormat,
+		flagDiscoverProbeTimeout,
+		flagDiscoverConcurrency,
+		sortedFlags(flagsApppend(
+			commonFlags,
+			sortableCredentialsFlags,
+		)),
+	)
+
 	provisionFlags = flagsApppend(
 		credentialsFlags,
 		flagPlatform,
@@ -932,6 +1251,7 @@ var (
 		commonCredFlags,
 		flagClientP12,
 		flagClientP12PW,
+		flagClearCache,
 		flagCredFormat,
 		flagEmail,
 		flagPassword,
@@ -967,6 +1287,8 @@ var (
 		flagPolicyName,
 		flagPolicyConfigFile,
 		flagPolicyVerifyConfigFile,
+		flagPolicyPlan,
+		flagPolicyRecursive,
 		flagTrustBundle,
 		flagInsecure,
 	))
@@ -979,6 +1301,21 @@ var (
 		flagPolicyName,
 		flagPolicyConfigFile,
 		flagPolicyStarterConfigFile,
+		flagPolicyRecursive,
+		flagTrustBundle,
+		flagInsecure,
+	))
+
+	policyCheckFlags = sortedFlags(flagsApppend(
+		flagKey,
+		flagUrl,
+		flagToken,
+		flagVerbose,
+		flagPolicyName,
+		flagPolicyConfigFile,
+		flagPolicyCheckCSR,
+		flagPolicyCheckCert,
+		flagPolicyCheckRequest,
 		flagTrustBundle,
 		flagInsecure,
 	))
@@ -1010,6 +1347,7 @@ var (
 		flagSshCertCa,
 		flagSshPubKey,
 		flagSshKeySize,
+		flagSshKeyType,
 		flagSshPassPhrase,
 		flagSshCertWindows,
 		flagSshFileCertEnroll,
@@ -1024,6 +1362,25 @@ var (
 		flagSshCertCa,
 		flagSshCertGuid,
 		flagSshFileGetConfig,
+		flagSshTrustUserKeys,
+		flagSshTrustSshdConfig,
+		flagSshTrustKnownHosts,
+		flagSshTrustHostPattern,
+		flagSshTrustDryRun,
+		flagInsecure,
+		flagVerbose,
+	))
+
+	sshInspectFlags = sortedFlags(flagsApppend(
+		flagSshInspectFile,
+		flagSshInspectCaKey,
+		flagSshInspectFormat,
+		flagSshInspectWarnWithin,
+		flagUrl,
+		flagTrustBundle,
+		flagToken,
+		flagSshCertCa,
+		flagSshCertGuid,
 		flagInsecure,
 		flagVerbose,
 	))
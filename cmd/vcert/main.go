@@ -80,13 +80,18 @@ func main() {
 			commandRenew,
 			commandRevoke,
 			commandRetire,
+			commandSearch,
+			commandDiscover,
 			commandCreatePolicy,
 			commandGetPolicy,
 			commandSshPickup,
 			commandSshEnroll,
 			commandSshGetConfig,
+			commandSshInspect,
 			commandRunPlaybook,
+			commandExporter,
 			commandProvision,
+			commandPolicy,
 		},
 		EnableBashCompletion: true, //todo: write BashComplete function for options
 		Authors:              authors,
@@ -114,12 +119,16 @@ ACTIONS:
    pickup        tpp | vcp            To retrieve a certificate
    renew         tpp | vcp            To renew a certificate
    retire        tpp | vcp            To retire a certificate
-   revoke        tpp                  To revoke a certificate
+   revoke        tpp | vcp            To revoke a certificate
+   search        tpp | vcp            To search the certificate inventory
+   discover      tpp | vcp            To import the certificates found in files and on TLS endpoints
    run           tpp | vcp | firefly  To retrieve and install certificates using a vcert playbook file
+   exporter                           To serve Prometheus metrics on the certificates installed by a vcert playbook file
    provision           vcp            To provision a certificate to cloud keystore
 
    getpolicy     tpp | vcp            To retrieve the certificate policy of a zone
    setpolicy     tpp | vcp            To apply a certificate policy specification to a zone
+   policy check  tpp | vcp            To check a CSR, certificate or request against a certificate policy specification
 
    getcred       tpp | vcp | oidc     To obtain a new authentication token from any Venafi platform or to register for a new Venafi Control Plane user API key
    checkcred     tpp                  To check the validity of a Trust Protection Platform token and grant
@@ -128,6 +137,7 @@ ACTIONS:
    sshenroll     tpp                  To enroll an SSH certificate
    sshpickup     tpp                  To retrieve an SSH certificate
    sshgetconfig  tpp                  To get the SSH CA public key and default principals
+   sshinspect                         To show the content of an SSH certificate and verify it against its CA
 
 OPTIONS:
    {{range .VisibleFlags}}{{.}}
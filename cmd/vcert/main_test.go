@@ -34,6 +34,7 @@ import (
 
 	"github.com/Venafi/vcert/v5/pkg/certificate"
 	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/venafi"
 )
 
 var testEmail = "test@vcert.test"
@@ -482,6 +483,28 @@ func TestGetCredFlagsNoTrust(t *testing.T) {
 
 }
 
+func TestGetCredFlagsClearCache(t *testing.T) {
+
+	flags = commandFlags{}
+
+	flags.platform = venafi.Firefly
+	flags.clearCache = true
+
+	err := validateCredMgmtFlags1(commandGetCredName)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	flags.platform = venafi.TPP
+	flags.url = "https://tpp.example.com"
+	flags.token = "3rlybZwAdV1qo/KpNJ5FWg=="
+
+	err = validateCredMgmtFlags1(commandGetCredName)
+	if err == nil {
+		t.Fatalf("--clear-cache must only be allowed for Firefly")
+	}
+}
+
 func TestIPSliceString(t *testing.T) {
 	ips := ipSlice{net.ParseIP("1.1.1.1"), net.ParseIP("1.1.1.2"), net.ParseIP("1.1.1.3")}
 	ipString := ips.String()
@@ -0,0 +1,538 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/Venafi/vcert/v5/pkg/endpoint"
+)
+
+const (
+	// manifestListSeparator separates the values of a CSV cell of a column that accepts several values
+	manifestListSeparator = ";"
+	manifestStateSuffix   = ".state"
+
+	// manifestStatusRequested is the status of a row whose request was posted and whose certificate is not retrieved yet
+	manifestStatusRequested = "requested"
+	manifestStatusEnrolled  = "enrolled"
+	manifestStatusFailed    = "failed"
+)
+
+// manifestRow is a certificate request of an enrollment manifest
+type manifestRow struct {
+	// id identifies the row in the state file. Taken from the id column, the common name or the row number
+	id    string
+	flags commandFlags
+}
+
+// manifestValues are the values of a column of a manifest row. YAML accepts a single value or a list of them
+type manifestValues []string
+
+func (v *manifestValues) UnmarshalYAML(value *yamlv3.Node) error {
+	if value.Kind == yamlv3.ScalarNode {
+		*v = manifestValues{value.Value}
+		return nil
+	}
+	var values []string
+	err := value.Decode(&values)
+	if err != nil {
+		return err
+	}
+	*v = values
+	return nil
+}
+
+// manifestState is the progress of an enrollment manifest, by row id
+type manifestState struct {
+	Rows map[string]manifestRowState `json:"rows"`
+}
+
+// manifestRowState is the progress of a manifest row. A row that is not enrolled and has a pickup ID was posted
+// already, so its certificate is retrieved with the private key of the request, kept until the row is enrolled
+type manifestRowState struct {
+	Status     string    `json:"status"`
+	PickupID   string    `json:"pickupId,omitempty"`
+	PrivateKey string    `json:"privateKey,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Updated    time.Time `json:"updated"`
+}
+
+// readManifest returns the rows of a CSV or YAML enrollment manifest. Every row starts from defaults, the flags of the
+// command line, and overrides the values of its columns
+func readManifest(path string, defaults commandFlags) ([]manifestRow, error) {
+	var records []map[string]manifestValues
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		records, err = readManifestCSV(path)
+	case ".yaml", ".yml":
+		records, err = readManifestYAML(path)
+	default:
+		return nil, fmt.Errorf("manifest %s should be a .csv, .yaml or .yml file", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest %s: %w", path, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("manifest %s has no rows", path)
+	}
+
+	rows := make([]manifestRow, 0, len(records))
+	ids := make(map[string]bool)
+	outputs := make(map[string]string)
+	for i, record := range records {
+		row, err := newManifestRow(i+1, record, defaults)
+		if err != nil {
+			return nil, fmt.Errorf("row %d of manifest %s: %w", i+1, path, err)
+		}
+		if ids[row.id] {
+			return nil, fmt.Errorf("row %d of manifest %s: id %s is used by another row", i+1, path, row.id)
+		}
+		ids[row.id] = true
+		for _, file := range []string{row.flags.file, row.flags.certFile, row.flags.keyFile, row.flags.chainFile, row.flags.pickupIDFile} {
+			if file == "" {
+				continue
+			}
+			if other, ok := outputs[file]; ok {
+				return nil, fmt.Errorf("row %d of manifest %s: file %s is also written by row %s", i+1, path, file, other)
+			}
+			outputs[file] = row.id
+		}
+		rows = append(rows, *row)
+	}
+	return rows, nil
+}
+
+func readManifestCSV(path string) ([]map[string]manifestValues, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []map[string]manifestValues
+	for {
+		line, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		record := make(map[string]manifestValues)
+		for i, cell := range line {
+			column := strings.TrimSpace(header[i])
+			for _, value := range strings.Split(cell, manifestListSeparator) {
+				if value = strings.TrimSpace(value); value != "" {
+					record[column] = append(record[column], value)
+				}
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func readManifestYAML(path string) ([]map[string]manifestValues, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var records []map[string]manifestValues
+	err = yamlv3.Unmarshal(b, &records)
+	if err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// newManifestRow applies the columns of a manifest record to defaults and validates the result like the enroll flags
+func newManifestRow(number int, record map[string]manifestValues, defaults commandFlags) (*manifestRow, error) {
+	row := &manifestRow{flags: defaults}
+	cf := &row.flags
+	for column, values := range record {
+		if len(values) == 0 {
+			continue
+		}
+		if column == "id" {
+			row.id = values[0]
+			continue
+		}
+		err := setManifestValue(cf, column, values)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if row.id == "" {
+		row.id = cf.commonName
+	}
+	if row.id == "" {
+		row.id = fmt.Sprintf("row %d", number)
+	}
+
+	err := parseKeyFlags(cf)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(cf.csrOption, "file:") {
+		if cf.commonName != "" {
+			return nil, fmt.Errorf("cn cannot be used with csr file:")
+		}
+		if cf.keyTypeString != "" || cf.keyCurveString != "" || cf.keySize > 0 {
+			return nil, fmt.Errorf("key-type, key-curve and key-size cannot be used with csr file:")
+		}
+		_, err = readCSRfromFile(cf.csrOption[5:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSR: %w", err)
+		}
+	} else if cf.csrOption != "" && cf.csrOption != "local" && cf.csrOption != "service" {
+		return nil, fmt.Errorf("unexpected csr option: %s", cf.csrOption)
+	} else if cf.commonName == "" {
+		return nil, fmt.Errorf("a cn is required for enrollment")
+	}
+
+	if cf.file != "" && (cf.certFile != "" || cf.chainFile != "" || cf.keyFile != "") {
+		return nil, fmt.Errorf("file cannot be used with any other *-file column")
+	}
+	if cf.file == "" && cf.certFile == "" && !cf.noPickup {
+		return nil, fmt.Errorf("file or cert-file is required to write the certificate")
+	}
+	if cf.validDays != "" && !isValidDays(cf.validDays) {
+		return nil, fmt.Errorf("valid-days has an invalid format: %s", cf.validDays)
+	}
+	if cf.tlsAddress != "" && cf.instance == "" {
+		return nil, fmt.Errorf("tls-address cannot be used without instance")
+	}
+	for _, f := range cf.customFields {
+		_, _, err = parseCustomField(f)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return row, nil
+}
+
+// setManifestValue sets the flag of cf named by a manifest column. The values of list columns replace the defaults
+func setManifestValue(cf *commandFlags, column string, values []string) error {
+	var err error
+	switch column {
+	case "ou", "orgUnit":
+		var orgUnits stringSlice
+		for _, v := range values {
+			_ = orgUnits.Set(v)
+		}
+		cf.orgUnits = orgUnits
+		return nil
+	case "san-dns":
+		var dnsSans stringSlice
+		for _, v := range values {
+			_ = dnsSans.Set(v)
+		}
+		cf.dnsSans = dnsSans
+		return nil
+	case "san-ip":
+		var ipSans ipSlice
+		for _, v := range values {
+			if err = ipSans.Set(v); err != nil {
+				return err
+			}
+		}
+		cf.ipSans = ipSans
+		return nil
+	case "san-email":
+		var emailSans rfc822NameSlice
+		for _, v := range values {
+			if err = emailSans.Set(v); err != nil {
+				return err
+			}
+		}
+		cf.emailSans = emailSans
+		return nil
+	case "san-uri":
+		var uriSans uriSlice
+		for _, v := range values {
+			if err = uriSans.Set(v); err != nil {
+				return err
+			}
+		}
+		cf.uriSans = uriSans
+		return nil
+	case "san-upn":
+		var upnSans rfc822NameSlice
+		for _, v := range values {
+			if err = upnSans.Set(v); err != nil {
+				return err
+			}
+		}
+		cf.upnSans = upnSans
+		return nil
+	case "field":
+		cf.customFields = values
+		return nil
+	}
+
+	if len(values) > 1 {
+		return fmt.Errorf("column %s accepts a single value", column)
+	}
+	value := values[0]
+	switch column {
+	case "cn", "commonName":
+		cf.commonName = value
+	case "o", "org":
+		cf.org = value
+	case "l", "locality":
+		cf.locality = value
+	case "st", "state":
+		cf.state = value
+	case "c", "country":
+		cf.country = value
+	case "ca-dn":
+		cf.caDN = value
+	case "nickname":
+		cf.friendlyName = value
+	case "instance":
+		cf.instance = value
+	case "tls-address":
+		cf.tlsAddress = value
+	case "app-info":
+		cf.appInfo = value
+	case "replace-instance":
+		cf.replaceInstance, err = strconv.ParseBool(value)
+	case "omit-sans":
+		cf.omitSans, err = strconv.ParseBool(value)
+	case "csr":
+		cf.csrOption = value
+	case "key-type":
+		cf.keyTypeString = value
+	case "key-size":
+		cf.keySize, err = strconv.Atoi(value)
+	case "key-curve":
+		cf.keyCurveString = value
+	case "valid-days":
+		cf.validDays = value
+	case "valid-period":
+		cf.validPeriod = value
+	case "file":
+		cf.file = value
+	case "cert-file":
+		cf.certFile = value
+	case "key-file":
+		cf.keyFile = value
+	case "chain-file":
+		cf.chainFile = value
+	case "pickup-id-file":
+		cf.pickupIDFile = value
+	default:
+		return fmt.Errorf("unknown column %s", column)
+	}
+	if err != nil {
+		return fmt.Errorf("column %s: %w", column, err)
+	}
+	return nil
+}
+
+func readManifestState(path string) (*manifestState, error) {
+	state := &manifestState{Rows: make(map[string]manifestRowState)}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest state %s: %w", path, err)
+	}
+	if state.Rows == nil {
+		state.Rows = make(map[string]manifestRowState)
+	}
+	return state, nil
+}
+
+// writeManifestState replaces the state file at once, so that an interrupted enrollment leaves a valid one behind
+func writeManifestState(path string, state *manifestState) error {
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	err = os.WriteFile(tmp, b, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// encodeManifestKey returns the PKCS#8 PEM of the private key of a posted request, or "" when there is none
+func encodeManifestKey(privateKey crypto.Signer) (string, error) {
+	if privateKey == nil {
+		return "", nil
+	}
+	b, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: b})), nil
+}
+
+func decodeManifestKey(privateKey string) (crypto.Signer, error) {
+	if privateKey == "" {
+		return nil, nil
+	}
+	block, _ := pem.Decode([]byte(privateKey))
+	if block == nil {
+		return nil, fmt.Errorf("the private key is not a PEM block")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key %T", key)
+	}
+	return signer, nil
+}
+
+// enrollManifest enrolls the rows of a manifest that are not enrolled according to the state file, up to concurrency
+// at the same time. The pickup ID of every row is recorded in the state file as soon as its request is posted, and
+// the outcome as soon as it is known, so that enrolling the manifest again retrieves the rows that were posted
+// instead of requesting them again
+func enrollManifest(connector endpoint.Connector, zoneConfig *endpoint.ZoneConfiguration, rows []manifestRow, statePath string, concurrency int) error {
+	state, err := readManifestState(statePath)
+	if err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, concurrency)
+	errs := make([]error, len(rows))
+	skipped := make([]bool, len(rows))
+	previous := make([]manifestRowState, len(rows))
+	for i, row := range rows {
+		previous[i] = state.Rows[row.id]
+		if previous[i].Status == manifestStatusEnrolled {
+			if flags.verbose {
+				logf("Skipping %s, it was already enrolled", row.id)
+			}
+			skipped[i] = true
+		}
+	}
+
+	record := func(id string, rowState manifestRowState) {
+		mu.Lock()
+		defer mu.Unlock()
+		state.Rows[id] = rowState
+		if err := writeManifestState(statePath, state); err != nil {
+			logf("Failed to record the state of the manifest in %s: %s", statePath, err)
+		}
+	}
+
+	for i := range rows {
+		if skipped[i] {
+			continue
+		}
+		row := &rows[i]
+
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			// the private key is kept in the state file until the certificate of the posted request is retrieved
+			pickupID, privateKey := previous[i].PickupID, previous[i].PrivateKey
+			progress := &enrollProgress{pickupID: pickupID}
+			progress.posted = func(postedID string, key crypto.Signer) {
+				encoded, err := encodeManifestKey(key)
+				if err != nil {
+					logf("Failed to record the private key of %s: %s", row.id, err)
+				}
+				pickupID, privateKey = postedID, encoded
+				record(row.id, manifestRowState{Status: manifestStatusRequested, PickupID: pickupID, PrivateKey: privateKey, Updated: time.Now().UTC()})
+			}
+
+			var err error
+			progress.privateKey, err = decodeManifestKey(privateKey)
+			if err != nil {
+				err = fmt.Errorf("failed to read the private key of pickup ID %s from the state file: %w", pickupID, err)
+			} else {
+				err = enrollCertificate(connector, zoneConfig, &row.flags, commandEnrollName, progress)
+			}
+			rowState := manifestRowState{Status: manifestStatusEnrolled, PickupID: pickupID, Updated: time.Now().UTC()}
+			if err != nil {
+				rowState.Status = manifestStatusFailed
+				rowState.PrivateKey = privateKey
+				rowState.Error = err.Error()
+				logf("Failed to enroll %s: %s", row.id, err)
+			}
+
+			errs[i] = err
+			record(row.id, rowState)
+		}(i)
+	}
+	wg.Wait()
+
+	enrolled, failed, skippedCount := 0, 0, 0
+	var report []string
+	for i, row := range rows {
+		switch {
+		case skipped[i]:
+			skippedCount++
+		case errs[i] != nil:
+			failed++
+			report = append(report, fmt.Sprintf("  %s: %s", row.id, errs[i]))
+		default:
+			enrolled++
+		}
+	}
+
+	logf("Manifest summary: %d enrolled, %d skipped as already enrolled, %d failed", enrolled, skippedCount, failed)
+	for _, line := range report {
+		logf("%s", line)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to enroll %d of %d certificate(s), enroll the manifest again to retry them", failed, len(rows))
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+	"github.com/Venafi/vcert/v5/pkg/venafi/fake"
+)
+
+func writeManifest(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestReadManifest(t *testing.T) {
+	defaults := commandFlags{org: "Example", keyTypeString: "rsa", dnsSans: stringSlice{"default.example.com"}}
+
+	csvPath := writeManifest(t, "requests.csv", `id,cn,san-dns,san-ip,key-type,key-curve,cert-file
+web,web.example.com,web.example.com;www.example.com,10.0.0.1,ecdsa,p384,web.pem
+,mail.example.com,,,,,mail.pem
+`)
+	rows, err := readManifest(csvPath, defaults)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	require.Equal(t, "web", rows[0].id)
+	require.Equal(t, stringSlice{"web.example.com", "www.example.com"}, rows[0].flags.dnsSans)
+	require.Equal(t, "10.0.0.1", rows[0].flags.ipSans[0].String())
+	require.Equal(t, certificate.KeyTypeECDSA, *rows[0].flags.keyType)
+	require.Equal(t, certificate.EllipticCurveP384, rows[0].flags.keyCurve)
+	require.Equal(t, "Example", rows[0].flags.org)
+	require.Equal(t, "mail.example.com", rows[1].id, "the common name identifies a row without id")
+	require.Equal(t, stringSlice{"default.example.com"}, rows[1].flags.dnsSans)
+	require.Equal(t, certificate.KeyTypeRSA, *rows[1].flags.keyType)
+
+	yamlPath := writeManifest(t, "requests.yaml", `
+- cn: api.example.com
+  ou: [Platform, Security]
+  valid-days: 30
+  file: api.pem
+`)
+	rows, err = readManifest(yamlPath, defaults)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, stringSlice{"Platform", "Security"}, rows[0].flags.orgUnits)
+	require.Equal(t, "30", rows[0].flags.validDays)
+
+	for name, content := range map[string]string{
+		"unknown column":     "cn,color,file\na.example.com,blue,a.pem\n",
+		"missing cn":         "o,file\nExample,a.pem\n",
+		"missing output":     "cn\na.example.com\n",
+		"duplicated id":      "id,cn,file\na,a.example.com,a.pem\na,b.example.com,b.pem\n",
+		"duplicated output":  "cn,file\na.example.com,a.pem\nb.example.com,a.pem\n",
+		"several single":     "cn,file\na.example.com;b.example.com,a.pem\n",
+		"invalid key type":   "cn,key-type,file\na.example.com,dsa,a.pem\n",
+		"invalid valid days": "cn,valid-days,file\na.example.com,never,a.pem\n",
+	} {
+		_, err = readManifest(writeManifest(t, "requests.csv", content), defaults)
+		require.Error(t, err, name)
+	}
+}
+
+func TestEnrollManifest(t *testing.T) {
+	dir := t.TempDir()
+	// The fake connector refuses venafi.com certificates, and the directory of web is missing
+	path := writeManifest(t, "requests.csv", "cn,cert-file,key-file\n"+
+		"a.example.com,"+filepath.Join(dir, "a.pem")+","+filepath.Join(dir, "a.key")+"\n"+
+		"b.venafi.com,"+filepath.Join(dir, "b.pem")+","+filepath.Join(dir, "b.key")+"\n"+
+		"web.example.com,"+filepath.Join(dir, "web", "web.pem")+","+filepath.Join(dir, "web", "web.key")+"\n")
+	rows, err := readManifest(path, commandFlags{format: "pem", noPrompt: true})
+	require.NoError(t, err)
+
+	connector := fake.NewConnector(false, nil)
+	zoneConfig, err := connector.ReadZoneConfiguration()
+	require.NoError(t, err)
+	statePath := path + manifestStateSuffix
+
+	err = enrollManifest(connector, zoneConfig, rows, statePath, 2)
+	require.Error(t, err)
+	require.FileExists(t, filepath.Join(dir, "a.pem"))
+	state, err := readManifestState(statePath)
+	require.NoError(t, err)
+	require.Equal(t, manifestStatusEnrolled, state.Rows["a.example.com"].Status)
+	require.NotEmpty(t, state.Rows["a.example.com"].PickupID)
+	require.Empty(t, state.Rows["a.example.com"].PrivateKey, "the private key is dropped once the row is enrolled")
+	require.Equal(t, manifestStatusFailed, state.Rows["b.venafi.com"].Status)
+	require.Empty(t, state.Rows["b.venafi.com"].PickupID)
+	// web failed after its request was posted, so its pickup ID and private key are kept to retrieve it
+	require.Equal(t, manifestStatusFailed, state.Rows["web.example.com"].Status)
+	webPickupID := state.Rows["web.example.com"].PickupID
+	require.NotEmpty(t, webPickupID)
+	require.NotEmpty(t, state.Rows["web.example.com"].PrivateKey)
+
+	// Enrolling again only retries the rows that failed, here without the row the connector refuses
+	require.NoError(t, os.Remove(filepath.Join(dir, "a.pem")))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "web"), 0700))
+	rows = append(rows[:1], rows[2])
+	err = enrollManifest(connector, zoneConfig, rows, statePath, 2)
+	require.NoError(t, err)
+	require.NoFileExists(t, filepath.Join(dir, "a.pem"))
+	require.FileExists(t, filepath.Join(dir, "web", "web.pem"))
+	require.FileExists(t, filepath.Join(dir, "web", "web.key"))
+
+	state, err = readManifestState(statePath)
+	require.NoError(t, err)
+	require.Equal(t, manifestStatusEnrolled, state.Rows["web.example.com"].Status)
+	require.Empty(t, state.Rows["web.example.com"].Error)
+	require.Equal(t, webPickupID, state.Rows["web.example.com"].PickupID, "the posted request is retrieved rather than requested again")
+	require.Empty(t, state.Rows["web.example.com"].PrivateKey)
+
+	// the retrieved certificate matches the private key of the posted request
+	_, err = tls.LoadX509KeyPair(filepath.Join(dir, "web", "web.pem"), filepath.Join(dir, "web", "web.key"))
+	require.NoError(t, err)
+}
@@ -16,12 +16,19 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/urfave/cli/v2"
 	"go.uber.org/zap"
@@ -47,15 +54,22 @@ var commandRunPlaybook = &cli.Command{
 	UsageText: `vcert run
    vcert run -f /path/to/my/file.yml
    vcert run -f ./myFile.yaml --force-renew
-   vcert run -f ./myFile.yaml --debug`,
+   vcert run -f ./myFile.yaml --debug
+   vcert run -f ./myFile.yaml --dry-run --format json
+   vcert run -f ./myFile.yaml --daemon --health-addr localhost:8080`,
 	Action: doRunPlaybook,
 	Flags:  playbookFlags,
 }
 
 type runOptions struct {
-	debug    bool
-	filepath string
-	force    bool
+	debug      bool
+	filepath   string
+	force      bool
+	dryRun     bool
+	format     string
+	daemon     bool
+	healthAddr string
+	listenAddr string
 }
 
 var (
@@ -88,10 +102,47 @@ var (
 		Destination: &playbookOptions.force,
 	}
 
+	PBFlagDryRun = &cli.BoolFlag{
+		Name:        "dry-run",
+		Usage:       "prints what the playbook would enroll, install and run, without doing it",
+		Required:    false,
+		Value:       false,
+		Destination: &playbookOptions.dryRun,
+	}
+
+	PBFlagFormat = &cli.StringFlag{
+		Name:        "format",
+		Usage:       "the format of the --dry-run output: text or json",
+		Required:    false,
+		Value:       "text",
+		Destination: &playbookOptions.format,
+	}
+
+	PBFlagDaemon = &cli.BoolFlag{
+		Name: "daemon",
+		Usage: "keeps running and renews each certificate when it is due. The playbook is reloaded on SIGHUP " +
+			"or when the file changes",
+		Required:    false,
+		Value:       false,
+		Destination: &playbookOptions.daemon,
+	}
+
+	PBFlagHealthAddr = &cli.StringFlag{
+		Name: "health-addr",
+		Usage: "with --daemon, the address on which the health status is served as JSON, and the Prometheus " +
+			"metrics on /metrics. Example: localhost:8080",
+		Required:    false,
+		Destination: &playbookOptions.healthAddr,
+	}
+
 	playbookFlags = flagsApppend(
 		PBFlagDebug,
 		PBFlagFilepath,
 		PBFlagForce,
+		PBFlagDryRun,
+		PBFlagFormat,
+		PBFlagDaemon,
+		PBFlagHealthAddr,
 	)
 )
 
@@ -103,26 +154,31 @@ func doRunPlaybook(_ *cli.Context) error {
 	zap.L().Info("running playbook file", zap.String("file", playbookOptions.filepath))
 	zap.L().Debug("debug is enabled")
 
-	playbook, err := parser.ReadPlaybook(playbookOptions.filepath)
-	if err != nil {
-		zap.L().Error(fmt.Errorf("%w", err).Error())
-		os.Exit(1)
+	if playbookOptions.daemon {
+		if playbookOptions.dryRun {
+			return fmt.Errorf("--dry-run cannot be used with --daemon")
+		}
+		return runPlaybookDaemon()
 	}
 
-	_, err = playbook.IsValid()
+	playbook, err := readPlaybook(playbookOptions.filepath)
 	if err != nil {
-		zap.L().Error("invalid playbook file", zap.String("file", playbookOptions.filepath), zap.Error(err))
+		zap.L().Error(err.Error())
 		os.Exit(1)
 	}
 
 	//Set the forceRenew variable
 	playbook.Config.ForceRenew = playbookOptions.force
 
-	if len(playbook.CertificateTasks) == 0 {
+	if len(playbook.CertificateTasks) == 0 && len(playbook.SshCertificateTasks) == 0 {
 		zap.L().Info("no tasks in the playbook. Nothing to do")
 		return nil
 	}
 
+	if playbookOptions.dryRun {
+		return printPlaybookPlan(playbook, playbookOptions.format)
+	}
+
 	// emulate the setTLSConfig from vcert
 	err = setPlaybookTLSConfig(playbook)
 	if err != nil {
@@ -140,19 +196,28 @@ func doRunPlaybook(_ *cli.Context) error {
 		}
 	}
 
-	var taskErrors []string
+	results, handlerResults := service.ExecutePlaybookTasks(playbook.Config, playbook.CertificateTasks, playbook.SshCertificateTasks, playbook.Handlers)
 
-	for _, certTask := range playbook.CertificateTasks {
-		zap.L().Info("running playbook task", zap.String("task", certTask.Name))
-		errors := service.Execute(playbook.Config, certTask)
-		if len(errors) > 0 {
-			taskErrors = append(taskErrors, certTask.Name)
-			for _, err2 := range errors {
-				zap.L().Error("error running task", zap.String("task", certTask.Name), zap.Error(err2))
+	// Errors are reported once all the tasks are done, so that they are not mixed with the output of running tasks
+	var taskErrors []string
+	for _, result := range results {
+		if len(result.Errors) > 0 {
+			taskErrors = append(taskErrors, result.Task)
+			for _, err2 := range result.Errors {
+				zap.L().Error("error running task", zap.String("task", result.Task), zap.Error(err2))
 			}
 		}
 	}
-	if len(taskErrors) > 0 {
+	var handlerErrors []string
+	for _, result := range handlerResults {
+		if result.Err != nil {
+			handlerErrors = append(handlerErrors, result.Handler)
+			zap.L().Error("error running handler", zap.String("handler", result.Handler), zap.Error(result.Err))
+		}
+	}
+	if len(taskErrors) > 0 || len(handlerErrors) > 0 {
+		zap.L().Error("playbook run finished with errors", zap.Int("tasks", len(results)),
+			zap.Strings("failedTasks", taskErrors), zap.Strings("failedHandlers", handlerErrors))
 		os.Exit(1)
 	}
 
@@ -160,6 +225,103 @@ func doRunPlaybook(_ *cli.Context) error {
 	return nil
 }
 
+// readPlaybook reads the playbook file at path and validates it
+func readPlaybook(path string) (domain.Playbook, error) {
+	playbook, err := parser.ReadPlaybook(path)
+	if err != nil {
+		return playbook, err
+	}
+
+	_, err = playbook.IsValid()
+	if err != nil {
+		return playbook, fmt.Errorf("invalid playbook file %s: %w", path, err)
+	}
+	return playbook, nil
+}
+
+// runPlaybookDaemon runs the playbook until the process is interrupted, renewing each certificate when it is due
+func runPlaybookDaemon() error {
+	daemon := service.NewDaemon(service.DaemonOptions{
+		Load: func() (domain.Playbook, error) {
+			playbook, err := readPlaybook(playbookOptions.filepath)
+			if err != nil {
+				return playbook, err
+			}
+			playbook.Config.ForceRenew = playbookOptions.force
+			err = setPlaybookTLSConfig(playbook)
+			if err != nil {
+				return playbook, fmt.Errorf("tls config error: %w", err)
+			}
+			return playbook, nil
+		},
+		WatchFile: playbookOptions.filepath,
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	go func() {
+		for range hup {
+			daemon.Reload()
+		}
+	}()
+
+	if playbookOptions.healthAddr != "" {
+		exporter := service.NewExporter(func() (domain.Playbook, error) {
+			return daemon.Playbook(), nil
+		})
+		service.SetMetrics(exporter)
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", exporter)
+		mux.Handle("/", daemon)
+		server := &http.Server{
+			Addr:              playbookOptions.healthAddr,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		go func() {
+			zap.L().Info("serving health status", zap.String("address", playbookOptions.healthAddr))
+			err := server.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				zap.L().Error("health server failed", zap.Error(err))
+				stop()
+			}
+		}()
+		defer server.Close() // nolint:errcheck
+	}
+
+	zap.L().Info("running playbook as a daemon", zap.String("file", playbookOptions.filepath))
+	err := daemon.Run(ctx)
+	if err != nil {
+		zap.L().Error(err.Error())
+		os.Exit(1)
+	}
+	zap.L().Info("playbook daemon stopped")
+	return nil
+}
+
+// printPlaybookPlan writes to stdout what running the playbook would do. The platform is not contacted
+func printPlaybookPlan(playbook domain.Playbook, format string) error {
+	plan := service.PlanPlaybookTasks(playbook.Config, playbook.CertificateTasks, playbook.SshCertificateTasks, playbook.Handlers)
+
+	switch strings.ToLower(format) {
+	case formatJson:
+		b, err := json.MarshalIndent(plan, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to construct JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(os.Stdout, string(b))
+		return err
+	case "", "text":
+		return plan.WriteText(os.Stdout)
+	default:
+		return fmt.Errorf("unexpected output format: %s", format)
+	}
+}
+
 func setPlaybookTLSConfig(playbook domain.Playbook) error {
 	// NOTE: This should use the standard setTLSConfig from vCert once incorporated into vCert
 	//  added here mostly to deal with TPP servers that are enabled for certificate authentication
@@ -0,0 +1,233 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/Venafi/vcert/v5"
+	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/policy"
+	"github.com/Venafi/vcert/v5/pkg/venafi/tpp"
+)
+
+// policyTreeFileName is the name of the policy specification of each zone of a policy tree directory
+const policyTreeFileName = "policy" + policy.JsonExtension
+
+// policyTreeFolder is a zone of a policy tree directory
+type policyTreeFolder struct {
+	// names are the names of the zones from the root of the tree down to this one. Empty for the root
+	names []string
+	file  string
+}
+
+// getPolicyTree writes the policy of the zone and of every zone below it to a directory tree
+func getPolicyTree(c *cli.Context) error {
+	cfg, err := buildConfig(c, &flags)
+	if err != nil {
+		return fmt.Errorf("failed to build vcert config: %s", err)
+	}
+	connector, err := vcert.NewClient(&cfg)
+	if err != nil {
+		return err
+	}
+	tppConnector, ok := connector.(*tpp.Connector)
+	if !ok {
+		return fmt.Errorf("recursive retrieval of policy is only supported for Trust Protection Platform")
+	}
+
+	count, err := exportPolicyFolder(tppConnector, flags.policyName, flags.policySpecLocation)
+	if err != nil {
+		return err
+	}
+	log.Printf("policy of %d zone(s) was written in: %s", count, flags.policySpecLocation)
+	return nil
+}
+
+// exportPolicyFolder writes the attributes set on the policy folder zone and on every folder below it. The values a
+// folder inherits are left out, so that they are not set again on each folder when the tree is applied
+func exportPolicyFolder(connector *tpp.Connector, zone, dir string) (int, error) {
+	ps, err := connector.GetFolderPolicy(zone)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve the policy of %s: %w", zone, err)
+	}
+	b, err := json.MarshalIndent(ps, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return 0, err
+	}
+	file := filepath.Join(dir, policyTreeFileName)
+	err = os.WriteFile(file, b, 0600)
+	if err != nil {
+		return 0, err
+	}
+	if flags.verbose {
+		logf("policy of %s was written in: %s", zone, file)
+	}
+
+	children, err := connector.GetZonesByParent(zone)
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve the zones below %s: %w", zone, err)
+	}
+	sort.Strings(children)
+
+	count := 1
+	for _, child := range children {
+		name := child[strings.LastIndex(child, "\\")+1:]
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, "/:") {
+			return 0, fmt.Errorf("zone %s cannot be written to a directory", child)
+		}
+		n, err := exportPolicyFolder(connector, zone+"\\"+name, filepath.Join(dir, name))
+		if err != nil {
+			return 0, err
+		}
+		count += n
+	}
+	return count, nil
+}
+
+// readPolicyTree returns the zones of a policy tree directory, parents first
+func readPolicyTree(dir string) ([]policyTreeFolder, error) {
+	var folders []policyTreeFolder
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		var names []string
+		if rel != "." {
+			names = strings.Split(rel, string(filepath.Separator))
+		}
+		for _, name := range []string{policyTreeFileName, "policy" + policy.YamlExtension, "policy.yml"} {
+			file := filepath.Join(path, name)
+			if _, err := os.Stat(file); err == nil {
+				folders = append(folders, policyTreeFolder{names: names, file: file})
+				return nil
+			}
+		}
+		if rel == "." {
+			return fmt.Errorf("no %s policy specification found in %s", policyTreeFileName, dir)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(folders, func(i, j int) bool {
+		return len(folders[i].names) < len(folders[j].names)
+	})
+	return folders, nil
+}
+
+// policyTreeZone returns the zone of a folder of a policy tree applied to root. Trust Protection Platform zones are
+// policy folders below root, while in Venafi Control Plane each zone is an issuing template of the root application
+// named after the root issuing template and the names of the folders, e.g. "app\template-child-grandchild"
+func policyTreeZone(connectorType endpoint.ConnectorType, root string, folder policyTreeFolder) string {
+	if len(folder.names) == 0 {
+		return root
+	}
+	if connectorType == endpoint.ConnectorTypeCloud {
+		return root + "-" + strings.Join(folder.names, "-")
+	}
+	return root + "\\" + strings.Join(folder.names, "\\")
+}
+
+// setPolicyTree applies the policy of every zone of a policy tree directory, parents first
+func setPolicyTree(c *cli.Context) error {
+	folders, err := readPolicyTree(flags.policySpecLocation)
+	if err != nil {
+		return err
+	}
+
+	if flags.verifyPolicyConfig {
+		for _, folder := range folders {
+			_, err = readPolicySpecification(folder.file)
+			if err != nil {
+				return fmt.Errorf("%s: %w", folder.file, err)
+			}
+		}
+		logf("policy specifications of %d zone(s) in %s are valid", len(folders), flags.policySpecLocation)
+		return nil
+	}
+
+	cfg, err := buildConfig(c, &flags)
+	if err != nil {
+		return fmt.Errorf("failed to build vcert config: %s", err)
+	}
+	connector, err := vcert.NewClient(&cfg)
+	if err != nil {
+		return err
+	}
+	if connector.GetType() == endpoint.ConnectorTypeCloud && policy.GetCitName(flags.policyName) == "" {
+		return fmt.Errorf("zone should have the form <application name>\\<issuing template alias> for Venafi Control Plane")
+	}
+
+	return applyPolicyTree(connector, flags.policyName, folders, flags.policyPlan)
+}
+
+// applyPolicyTree applies the policy of the folders to the zones below root, or only shows the changes with plan
+func applyPolicyTree(connector endpoint.Connector, root string, folders []policyTreeFolder, plan bool) error {
+	changes := 0
+	for _, folder := range folders {
+		zone := policyTreeZone(connector.GetType(), root, folder)
+		ps, err := readPolicySpecification(folder.file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", folder.file, err)
+		}
+
+		if plan {
+			fmt.Printf("%s:\n", zone)
+			n, err := printPolicyPlan(connector, zone, ps)
+			if err != nil {
+				return err
+			}
+			changes += n
+			continue
+		}
+
+		logf("Applying policy specification %s to %s", folder.file, zone)
+		_, err = connector.SetPolicy(zone, ps)
+		if err != nil {
+			return fmt.Errorf("failed to apply the policy of %s: %w", zone, err)
+		}
+	}
+
+	if changes > 0 {
+		return cli.Exit(fmt.Sprintf("policy of %s differs from the policy specifications: found %d change(s)", root, changes), policyPlanChangesExitCode)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+
+	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/policy"
+	"github.com/Venafi/vcert/v5/pkg/venafi/tpp"
+	"github.com/Venafi/vcert/v5/pkg/venafi/tpp/tpptest"
+)
+
+func newPolicyTreeConnector(t *testing.T, server *tpptest.Server) *tpp.Connector {
+	t.Helper()
+	connector, err := tpp.NewConnector(server.URL, "", false, nil)
+	require.NoError(t, err)
+	connector.SetHTTPClient(server.Client())
+	err = connector.Authenticate(&endpoint.Authentication{User: tpptest.DefaultUsername, Password: tpptest.DefaultPassword, Scope: "configuration:manage"})
+	require.NoError(t, err)
+	return connector
+}
+
+func TestPolicyTree(t *testing.T) {
+	lab := tpptest.NewServer()
+	defer lab.Close()
+	production := tpptest.NewServer()
+	defer production.Close()
+
+	source := newPolicyTreeConnector(t, lab)
+	// Values locked by a folder apply to the folders below it, which are exported without them
+	tree := []struct {
+		zone    string
+		subject policy.Subject
+	}{
+		{"Certificates\\lab", policy.Subject{Countries: []string{"US"}}},
+		{"Certificates\\lab\\mail", policy.Subject{Orgs: []string{"Mail"}}},
+		{"Certificates\\lab\\web", policy.Subject{Orgs: []string{"Web"}}},
+		{"Certificates\\lab\\web\\public", policy.Subject{Localities: []string{"Austin"}}},
+	}
+	for _, folder := range tree {
+		subject := folder.subject
+		_, err := source.SetPolicy(folder.zone, &policy.PolicySpecification{Policy: &policy.Policy{Subject: &subject}})
+		require.NoError(t, err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "lab")
+	count, err := exportPolicyFolder(source, "Certificates\\lab", dir)
+	require.NoError(t, err)
+	require.Equal(t, 4, count)
+	require.FileExists(t, filepath.Join(dir, "web", "public", policyTreeFileName))
+	exported, err := readPolicySpecification(filepath.Join(dir, "web", "public", policyTreeFileName))
+	require.NoError(t, err)
+	require.Equal(t, &policy.PolicySpecification{Policy: &policy.Policy{Subject: &policy.Subject{Localities: []string{"Austin"}}}}, exported,
+		"only the values set on the folder itself should be exported")
+
+	folders, err := readPolicyTree(dir)
+	require.NoError(t, err)
+	var zones []string
+	for _, folder := range folders {
+		zones = append(zones, policyTreeZone(endpoint.ConnectorTypeTPP, "Certificates\\prod", folder))
+	}
+	require.Equal(t, []string{
+		"Certificates\\prod",
+		"Certificates\\prod\\mail",
+		"Certificates\\prod\\web",
+		"Certificates\\prod\\web\\public",
+	}, zones)
+	require.Equal(t, "app\\prod-web-public", policyTreeZone(endpoint.ConnectorTypeCloud, "app\\prod", folders[3]))
+
+	target := newPolicyTreeConnector(t, production)
+	err = applyPolicyTree(target, "Certificates\\prod", folders, true)
+	var exitErr cli.ExitCoder
+	require.ErrorAs(t, err, &exitErr, "planning a tree that does not exist should report changes")
+	require.Equal(t, policyPlanChangesExitCode, exitErr.ExitCode())
+	require.ErrorContains(t, err, "found 4 change(s)", "every zone of the tree should be planned, not only the first one")
+
+	err = applyPolicyTree(target, "Certificates\\prod", folders, false)
+	require.NoError(t, err)
+	ps, err := target.GetPolicy("Certificates\\prod\\web\\public")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Austin"}, ps.Policy.Subject.Localities)
+	require.Equal(t, []string{"Web"}, ps.Policy.Subject.Orgs)
+	require.Equal(t, []string{"US"}, ps.Policy.Subject.Countries)
+	folderPolicy, err := target.GetFolderPolicy("Certificates\\prod\\web\\public")
+	require.NoError(t, err)
+	require.Equal(t, exported, folderPolicy, "inherited values should not be set on the folder")
+
+	err = applyPolicyTree(target, "Certificates\\prod", folders, true)
+	require.NoError(t, err, "an applied tree should not drift")
+}
+
+func TestReadPolicyTreeWithoutRoot(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "child"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "child", policyTreeFileName), []byte("{}"), 0600))
+
+	_, err := readPolicyTree(dir)
+	require.Error(t, err)
+}
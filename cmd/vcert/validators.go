@@ -69,28 +69,33 @@ func validateCommonFlags(commandName string) error {
 		return fmt.Errorf("the '--keytype','--keycurve' and '--key-size' options cannot be used when '--csr file:' option is provided")
 	}
 
-	switch flags.keyTypeString {
+	return parseKeyFlags(&flags)
+}
+
+// parseKeyFlags sets the key type and curve of cf from their flag values
+func parseKeyFlags(cf *commandFlags) error {
+	switch cf.keyTypeString {
 	case "rsa":
 		kt := certificate.KeyTypeRSA
-		flags.keyType = &kt
+		cf.keyType = &kt
 	case "ecdsa":
 		kt := certificate.KeyTypeECDSA
-		flags.keyType = &kt
+		cf.keyType = &kt
 	case "":
 	default:
-		return fmt.Errorf("unknown key type: %s", flags.keyTypeString)
+		return fmt.Errorf("unknown key type: %s", cf.keyTypeString)
 	}
 
-	switch strings.ToLower(flags.keyCurveString) {
+	switch strings.ToLower(cf.keyCurveString) {
 	case "p256":
-		flags.keyCurve = certificate.EllipticCurveP256
+		cf.keyCurve = certificate.EllipticCurveP256
 	case "p384":
-		flags.keyCurve = certificate.EllipticCurveP384
+		cf.keyCurve = certificate.EllipticCurveP384
 	case "p521":
-		flags.keyCurve = certificate.EllipticCurveP521
+		cf.keyCurve = certificate.EllipticCurveP521
 	case "":
 	default:
-		return fmt.Errorf("unknown EC key curve: %s", flags.keyTypeString)
+		return fmt.Errorf("unknown EC key curve: %s", cf.keyTypeString)
 
 	}
 	return nil
@@ -302,7 +307,14 @@ func validateEnrollFlags(commandName string) error {
 	if err != nil {
 		return err
 	}
-	if strings.Index(flags.csrOption, "file:") == 0 {
+	if flags.manifest != "" {
+		if flags.commonName != "" || flags.file != "" || flags.certFile != "" || flags.keyFile != "" || flags.chainFile != "" || flags.pickupIDFile != "" {
+			return fmt.Errorf("the '--cn' and output file options cannot be used with --manifest, set them for each row of the manifest instead")
+		}
+		if flags.manifestConcurrency < 1 {
+			return fmt.Errorf("--manifest-concurrency should be at least 1")
+		}
+	} else if strings.Index(flags.csrOption, "file:") == 0 {
 		if flags.commonName != "" {
 			return fmt.Errorf("the '--cn' option cannot be used in --csr file: provided mode")
 		}
@@ -371,19 +383,24 @@ func validateValidDaysFlag(cn string) bool {
 	}
 
 	if flags.validDays != "" {
-
-		validDays := flags.validDays
-
-		var regex = regexp.MustCompile("[1-9]+[0-9]*(#[DdEeMm])?")
-
-		return regex.MatchString(validDays)
-
+		return isValidDays(flags.validDays)
 	}
 
 	return true
 }
 
+// isValidDays reports whether validDays has the format of the --valid-days flag
+func isValidDays(validDays string) bool {
+	var regex = regexp.MustCompile("[1-9]+[0-9]*(#[DdEeMm])?")
+
+	return regex.MatchString(validDays)
+}
+
 func validateCredMgmtFlags1(commandName string) error {
+	if flags.clearCache && flags.platform != venafi.Firefly {
+		return fmt.Errorf("--clear-cache can only be used with --platform firefly")
+	}
+
 	err := validateConnectionFlags(commandName)
 	if err != nil {
 		return err
@@ -630,6 +647,15 @@ func validateGetPolicyFlags(commandName string) error {
 			return fmt.Errorf("zone is required")
 		}
 	}
+
+	if flags.policyRecursive {
+		if isPolicyConfigStarter {
+			return fmt.Errorf("starter and recursive flags are set, please remove one of them")
+		}
+		if flags.policySpecLocation == "" {
+			return fmt.Errorf("a directory to write the policy specifications to is required for the recursive flag")
+		}
+	}
 	return nil
 }
 
@@ -659,6 +685,31 @@ func validateSetPolicyFlags(commandName string) error {
 
 	}
 
+	if flags.policyRecursive && flags.policySpecLocation == "" {
+		return fmt.Errorf("a directory with the policy specifications is required for the recursive flag")
+	}
+
+	return nil
+}
+
+func validatePolicyCheckFlags(commandName string) error {
+	inputs := 0
+	for _, input := range []string{flags.policyCheckCSR, flags.policyCheckCert, flags.policyCheckRequest} {
+		if input != "" {
+			inputs++
+		}
+	}
+	if inputs != 1 {
+		return fmt.Errorf("exactly one of --csr-file, --cert-file or --request-file is required")
+	}
+
+	if flags.policySpecLocation == "" && flags.policyName == "" {
+		return fmt.Errorf("a policy specification file or a zone is required")
+	}
+	if flags.policySpecLocation != "" && flags.policyName != "" {
+		return fmt.Errorf("a policy specification file and a zone are both set, please set only one of them")
+	}
+
 	return nil
 }
 
@@ -682,6 +733,27 @@ func validateSshEnrollFlags(commandName string) error {
 		}
 	}
 
+	flags.sshCertKeyType = strings.ToLower(flags.sshCertKeyType)
+	switch flags.sshCertKeyType {
+	case util.SshKeyTypeRSA:
+	case util.SshKeyTypeECDSA:
+		if flags.sshCertKeySize != 0 && flags.sshCertKeySize != 256 && flags.sshCertKeySize != 384 && flags.sshCertKeySize != 521 {
+			return fmt.Errorf("key-size value: %d is not expected for an ecdsa key, please provide: 256, 384 or 521", flags.sshCertKeySize)
+		}
+	case util.SshKeyTypeED25519:
+		if flags.sshCertKeySize != 0 {
+			return fmt.Errorf("key-size cannot be used with an ed25519 key")
+		}
+		if flags.format == util.LegacyPem {
+			return fmt.Errorf("the %s format does not support ed25519 keys, please provide: pem or %s", util.LegacyPem, util.OpenSshFormat)
+		}
+	default:
+		return fmt.Errorf("key-type value: %s is not expected, please provide: rsa, ecdsa or ed25519", flags.sshCertKeyType)
+	}
+	if flags.sshCertPubKey != SshCertPubKeyLocal && flags.sshCertKeyType != util.SshKeyTypeRSA {
+		return fmt.Errorf("key-type can only be used with public-key local")
+	}
+
 	err = readData(commandName)
 	if err != nil {
 		return err
@@ -701,9 +773,47 @@ func validateGetSshConfigFlags(commandName string) error {
 		return fmt.Errorf("SSH certificate issuance template name (--template) or template guid (--guid) value is required")
 	}
 
+	if flags.sshTrustSshdConfig != "" && flags.sshTrustUserKeys == "" {
+		return fmt.Errorf("--sshd-config can only be used with --trusted-user-ca-keys")
+	}
+	if len(flags.sshTrustHostPattern) > 0 && flags.sshTrustKnownHosts == "" {
+		return fmt.Errorf("--host-pattern can only be used with --known-hosts")
+	}
+	if flags.sshTrustDryRun && flags.sshTrustUserKeys == "" && flags.sshTrustKnownHosts == "" {
+		return fmt.Errorf("--dry-run can only be used with --trusted-user-ca-keys or --known-hosts")
+	}
+
 	return nil
 }
 
+func validateSshInspectFlags(commandName string) error {
+	if flags.sshInspectFile == "" {
+		return fmt.Errorf("SSH certificate file (--file) value is required")
+	}
+
+	switch flags.sshInspectFormat {
+	case sshInspectFormatText, sshInspectFormatJSON:
+	default:
+		return fmt.Errorf("unexpected output format: %s. Expected text or json", flags.sshInspectFormat)
+	}
+
+	if flags.sshInspectWarnWithin != "" {
+		_, err := parseExpiringWithin(flags.sshInspectWarnWithin)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The CA public key is retrieved from Trust Protection Platform only if the template is set
+	if flags.sshCertTemplate == "" && flags.sshCertGuid == "" {
+		return nil
+	}
+	if flags.sshInspectCaKey != "" {
+		return fmt.Errorf("--ca-public-key cannot be used with --template or --guid, please set only one of them")
+	}
+	return validateConnectionFlags(commandName)
+}
+
 func validateSshRetrieveFlags(commandName string) error {
 
 	err := validateConnectionFlags(commandName)
@@ -768,6 +878,68 @@ func validateProvisionFlags(commandName string) error {
 	return nil
 }
 
+func validateSearchFlags(commandName string) error {
+	if flags.platform == venafi.Firefly {
+		return fmt.Errorf("command %s not supported for %s", commandName, venafi.Firefly.String())
+	}
+	err := validateConnectionFlags(commandName)
+	if err != nil {
+		return err
+	}
+	err = readData(commandName)
+	if err != nil {
+		return err
+	}
+
+	switch flags.searchFormat {
+	case searchFormatTable, searchFormatJSON, searchFormatCSV:
+	default:
+		return fmt.Errorf("unexpected output format: %s. Expected table, json or csv", flags.searchFormat)
+	}
+	if flags.searchLimit < 0 {
+		return fmt.Errorf("--limit cannot be negative")
+	}
+	if flags.searchPage < 1 {
+		return fmt.Errorf("--page starts at 1")
+	}
+	if flags.searchPage > 1 && flags.searchLimit == 0 {
+		return fmt.Errorf("--page requires --limit to be set")
+	}
+	if flags.searchExpiringWithin != "" {
+		if _, err = parseExpiringWithin(flags.searchExpiringWithin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateDiscoverFlags(commandName string) error {
+	if flags.platform == venafi.Firefly {
+		return fmt.Errorf("command %s not supported for %s", commandName, venafi.Firefly.String())
+	}
+	err := validateConnectionFlags(commandName)
+	if err != nil {
+		return err
+	}
+
+	if len(flags.discoverPaths) == 0 && len(flags.discoverTargets) == 0 {
+		return fmt.Errorf("--path or --target is required")
+	}
+	switch flags.searchFormat {
+	case searchFormatTable, searchFormatJSON, searchFormatCSV:
+	default:
+		return fmt.Errorf("unexpected output format: %s. Expected table, json or csv", flags.searchFormat)
+	}
+	if flags.discoverProbeTimeout <= 0 {
+		return fmt.Errorf("--probe-timeout must be positive")
+	}
+	if flags.discoverConcurrency < 1 {
+		return fmt.Errorf("--probe-concurrency must be at least 1")
+	}
+	_, err = expandDiscoverTargets(flags.discoverTargets)
+	return err
+}
+
 func validateExistingFile(f string) error {
 	fileNames, err := getExistingSshFiles(f)
 
@@ -13,6 +13,11 @@ func validateConnectionFlagsFirefly(commandName string) error {
 
 	//getcred command
 	if commandName == commandGetCredName {
+		//clearing the token cache doesn't require any credentials
+		if flags.clearCache {
+			return nil
+		}
+
 		clientSecretPresent := flags.clientSecret != "" || getPropertyFromEnvironment(vcertClientSecret) != ""
 		clientIDPresent := flags.clientId != "" || getPropertyFromEnvironment(vcertClientID) != ""
 		userPresent := flags.userName != "" || getPropertyFromEnvironment(vcertUser) != ""
@@ -27,6 +27,7 @@ import (
 	"gopkg.in/ini.v1"
 
 	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/httputils"
 )
 
 const (
@@ -63,6 +64,17 @@ type Config struct {
 	LogVerbose      bool
 	// http.Client to use durring construction
 	Client *http.Client
+	// RetryPolicy configures how the requests to the platform are retried on transient errors and rate limited.
+	// If nil, httputils.DefaultRetryPolicy applies. It also applies to a Client set above, which NewClient wraps with
+	// httputils.WithRetries: the Timeout of that Client bounds each attempt and is raised to cover the retries
+	RetryPolicy *httputils.RetryPolicy
+	// TokenCachePath is the file in which the Firefly connector caches the OAuth 2.0 tokens it gets from the identity
+	// provider, so that the authorization flow doesn't run while a cached token is valid or can be refreshed. See
+	// firefly.DefaultTokenCachePath. If empty, the tokens are not cached
+	TokenCachePath string
+	// FakeInventoryPath is the file in which the Fake connector persists the inventory of the certificates it issues,
+	// so that they can be retrieved, renewed or revoked by later runs. If empty, the inventory is kept in memory
+	FakeInventoryPath string
 	// UserAgent is the value of the UserAgent header in HTTP requests to Venafi
 	// API endpoints.
 	// If nil, the default is `vcert/v5`.
@@ -1,6 +1,7 @@
 package vcert
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509/pkix"
 	"fmt"
@@ -25,18 +26,16 @@ import (
 // The returned listener uses a *tls.Config that enables HTTP/2, and
 // should only be used with servers that support HTTP/2.
 //
+// The certificates are renewed in the background until the listener is
+// closed. Use a CertificateManager to cache them or to enroll certificates
+// on demand.
+//
 // The returned Listener also enables TCP keep-alives on the accepted
 // connections. The returned *tls.Conn are returned before their TLS
 // handshake has completed.
 func (cfg *Config) NewListener(domains ...string) net.Listener {
 	l := listener{}
-	conn, err := cfg.NewClient()
-	if err != nil {
-		l.e = err
-		return &l
-	}
-	certs := make([]tls.Certificate, len(domains))
-	certsMap := make(map[string]*tls.Certificate)
+	names := make([]string, len(domains))
 	port := ""
 	for i, d := range domains {
 		parsedHost, parsedPort, err := net.SplitHostPort(d)
@@ -48,67 +47,73 @@ func (cfg *Config) NewListener(domains ...string) net.Listener {
 			port = parsedPort
 			d = parsedHost
 		}
-		log.Println("Retrieving certificate for domain", d)
-		cert, err := getSimpleCertificate(conn, d)
-		if err != nil {
-			l.e = err
-			return &l
-		}
-		certs[i] = cert
-		certsMap[d] = &certs[i]
+		names[i] = d
 	}
 	if port == "" {
 		port = "443"
 	}
 
-	/* #nosec */
-	l.conf = &tls.Config{
-		Certificates:      certs,
-		NameToCertificate: certsMap,
+	manager, err := cfg.NewCertificateManager(context.Background(), CertificateManagerOptions{Domains: names})
+	if err != nil {
+		l.e = err
+		return &l
 	}
+	// The certificates are renewed until the listener is closed
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		_ = manager.Run(ctx)
+	}()
+
+	l.conf = manager.TLSConfig()
+	l.stop = cancel
 	l.Listener, l.e = net.Listen("tcp", ":"+port)
+	if l.e != nil {
+		cancel()
+		return &l
+	}
 	log.Println("Starting server on port", port)
 	return &l
 }
 
-func getSimpleCertificate(conn endpoint.Connector, cn string) (tls.Certificate, error) {
+// enrollCertificate enrolls a certificate for cn and returns the certificate with its chain, and the private key, in
+// PEM format
+func enrollCertificate(ctx context.Context, conn endpoint.ConnectorContext, cn string) ([]byte, []byte, error) {
 	req := certificate.Request{Subject: pkix.Name{CommonName: cn}, DNSNames: []string{cn}, CsrOrigin: certificate.LocalGeneratedCSR}
-	zc, err := conn.ReadZoneConfiguration()
+	zc, err := conn.ReadZoneConfigurationContext(ctx)
 	if err != nil {
-		return tls.Certificate{}, err
+		return nil, nil, err
 	}
-	err = conn.GenerateRequest(zc, &req)
+	err = conn.GenerateRequestContext(ctx, zc, &req)
 	if err != nil {
-		return tls.Certificate{}, err
+		return nil, nil, err
 	}
-	requestID, err := conn.RequestCertificate(&req)
+	requestID, err := conn.RequestCertificateContext(ctx, &req)
 	if err != nil {
-		return tls.Certificate{}, err
+		return nil, nil, err
 	}
 	req.PickupID = requestID
 	req.Timeout = time.Minute
-	certCollection, err := conn.RetrieveCertificate(&req)
+	certCollection, err := conn.RetrieveCertificateContext(ctx, &req)
 	if err != nil {
-		return tls.Certificate{}, err
+		return nil, nil, err
 	}
 	err = certCollection.AddPrivateKey(req.PrivateKey, nil)
-
 	if err != nil {
-		return tls.Certificate{}, err
+		return nil, nil, err
 	}
 
 	privKey, err := util.DecryptPkcs8PrivateKey(certCollection.PrivateKey, "")
 	if err != nil {
-		return tls.Certificate{}, err
+		return nil, nil, err
 	}
-	certCollection.PrivateKey = privKey
 
-	return certCollection.ToTLSCertificate(), err
+	return joinPEM(append([]string{certCollection.Certificate}, certCollection.Chain...)...), []byte(privKey), nil
 }
 
 type listener struct {
 	net.Listener
 	conf *tls.Config
+	stop context.CancelFunc
 	e    error
 }
 
@@ -134,6 +139,9 @@ func (ln *listener) Accept() (net.Conn, error) {
 }
 
 func (ln *listener) Close() error {
+	if ln.stop != nil {
+		ln.stop()
+	}
 	if ln.e != nil {
 		return ln.e
 	}
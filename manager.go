@@ -0,0 +1,443 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vcert
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultRetryInterval is the time the CertificateManager waits before retrying a failed renewal
+const DefaultRetryInterval = time.Minute
+
+// CertificateManagerOptions configures a CertificateManager
+type CertificateManagerOptions struct {
+	// Domains are the names whose certificates are enrolled when the manager is created. The certificate of the first
+	// domain is served to clients that do not send a known server name, and is used as client certificate
+	Domains []string
+	// AllowedHosts are the server names whose certificates are enrolled on demand, during the first TLS handshake
+	// that asks for them. A name starting with "*." allows any name with one more label, e.g. "*.example.com" allows
+	// "www.example.com" but not "example.com" nor "a.www.example.com". No certificate is enrolled on demand when empty
+	AllowedHosts []string
+	// CacheDir is the directory where the certificates and their keys are persisted, so restarts reuse them instead
+	// of enrolling new ones. Nothing is persisted when empty
+	CacheDir string
+	// RenewBefore is how long before its expiration a certificate is renewed. Defaults to a third of the certificate
+	// validity
+	RenewBefore time.Duration
+	// RetryInterval is the time to wait before retrying a failed renewal. It is also the minimum time between two
+	// renewals of a certificate. Defaults to DefaultRetryInterval
+	RetryInterval time.Duration
+}
+
+// CertificateManager enrolls and renews the certificates of a TLS server. Its GetCertificate and GetClientCertificate
+// methods are meant to be used as the callbacks of a tls.Config. Certificates are renewed in the background by Run
+type CertificateManager struct {
+	cfg  Config
+	opts CertificateManagerOptions
+
+	mu      sync.Mutex
+	certs   map[string]*managedCertificate
+	pending map[string]chan struct{}
+	wake    chan struct{}
+}
+
+type managedCertificate struct {
+	name string
+	cert atomic.Pointer[tls.Certificate]
+	// renewAt is only used by Run, after the manager is created
+	renewAt time.Time
+}
+
+// NewCertificateManager returns a CertificateManager for the certificates of opts.Domains. The certificates are read
+// from opts.CacheDir when they are found there and have not expired. Otherwise, they are enrolled before returning
+func (cfg *Config) NewCertificateManager(ctx context.Context, opts CertificateManagerOptions) (*CertificateManager, error) {
+	if len(opts.Domains) == 0 && len(opts.AllowedHosts) == 0 {
+		return nil, fmt.Errorf("no domains nor allowed hosts defined for the certificate manager")
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = DefaultRetryInterval
+	}
+	if opts.CacheDir != "" {
+		if err := os.MkdirAll(opts.CacheDir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create certificate cache directory: %w", err)
+		}
+	}
+
+	m := &CertificateManager{
+		cfg:     *cfg,
+		opts:    opts,
+		certs:   make(map[string]*managedCertificate),
+		pending: make(map[string]chan struct{}),
+		wake:    make(chan struct{}, 1),
+	}
+	for _, d := range opts.Domains {
+		name := normalizeServerName(d)
+		if _, ok := m.certs[name]; ok {
+			continue
+		}
+		cert, err := m.loadOrEnroll(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		m.add(name, cert)
+	}
+	return m, nil
+}
+
+// GetCertificate returns the certificate for the server name of the client. It is meant to be the
+// tls.Config.GetCertificate callback
+func (m *CertificateManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	name := normalizeServerName(hello.ServerName)
+	if name == "" {
+		return m.defaultCertificate()
+	}
+	if cert := m.lookup(name); cert != nil {
+		return cert, nil
+	}
+	if !m.allowed(name) {
+		return m.defaultCertificate()
+	}
+	ctx := hello.Context()
+	if ctx == nil {
+		// The hello was not created by a handshake
+		ctx = context.Background()
+	}
+	return m.enrollOnDemand(ctx, name)
+}
+
+// GetClientCertificate returns the first certificate of the domains that the server accepts, or the certificate of
+// the first domain. It is meant to be the tls.Config.GetClientCertificate callback
+func (m *CertificateManager) GetClientCertificate(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	for _, d := range m.opts.Domains {
+		cert := m.lookup(normalizeServerName(d))
+		if cert != nil && info.SupportsCertificate(cert) == nil {
+			return cert, nil
+		}
+	}
+	cert, err := m.defaultCertificate()
+	if err != nil {
+		// An empty certificate tells the server that there is no client certificate
+		return &tls.Certificate{}, nil
+	}
+	return cert, nil
+}
+
+// TLSConfig returns a tls.Config that serves the certificates of the manager
+func (m *CertificateManager) TLSConfig() *tls.Config {
+	/* #nosec */
+	return &tls.Config{
+		GetCertificate:       m.GetCertificate,
+		GetClientCertificate: m.GetClientCertificate,
+	}
+}
+
+// Run renews the certificates before they expire, until ctx is done. A failed renewal is retried after
+// CertificateManagerOptions.RetryInterval. The certificate in use is kept until its renewal succeeds
+func (m *CertificateManager) Run(ctx context.Context) error {
+	m.mu.Lock()
+	for _, mc := range m.certs {
+		mc.renewAt = m.renewalTime(mc.cert.Load().Leaf)
+	}
+	m.mu.Unlock()
+
+	for {
+		next := m.renewDue(ctx)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-m.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// renewDue renews the certificates whose renewal time has come, and returns the next renewal time
+func (m *CertificateManager) renewDue(ctx context.Context) time.Time {
+	m.mu.Lock()
+	managed := make([]*managedCertificate, 0, len(m.certs))
+	for _, mc := range m.certs {
+		managed = append(managed, mc)
+	}
+	m.mu.Unlock()
+
+	next := time.Now().Add(24 * time.Hour)
+	for _, mc := range managed {
+		if mc.renewAt.IsZero() {
+			// Enrolled on demand after Run started
+			mc.renewAt = m.renewalTime(mc.cert.Load().Leaf)
+		}
+		if !time.Now().Before(mc.renewAt) {
+			log.Printf("Renewing certificate for %s", mc.name)
+			cert, err := m.enroll(ctx, mc.name)
+			if err != nil {
+				if ctx.Err() != nil {
+					return next
+				}
+				log.Printf("Failed to renew certificate for %s, retrying in %s: %s", mc.name, m.opts.RetryInterval, err)
+				mc.renewAt = time.Now().Add(m.opts.RetryInterval)
+			} else {
+				mc.cert.Store(cert)
+				mc.renewAt = m.renewalTime(cert.Leaf)
+				if minimum := time.Now().Add(m.opts.RetryInterval); mc.renewAt.Before(minimum) {
+					mc.renewAt = minimum
+				}
+			}
+		}
+		if mc.renewAt.Before(next) {
+			next = mc.renewAt
+		}
+	}
+	return next
+}
+
+func (m *CertificateManager) renewalTime(leaf *x509.Certificate) time.Time {
+	renewBefore := m.opts.RenewBefore
+	if renewBefore <= 0 {
+		renewBefore = leaf.NotAfter.Sub(leaf.NotBefore) / 3
+	}
+	return leaf.NotAfter.Add(-renewBefore)
+}
+
+func (m *CertificateManager) add(name string, cert *tls.Certificate) {
+	mc := &managedCertificate{name: name}
+	mc.cert.Store(cert)
+	m.mu.Lock()
+	m.certs[name] = mc
+	m.mu.Unlock()
+}
+
+func (m *CertificateManager) lookup(name string) *tls.Certificate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mc, ok := m.certs[name]
+	if !ok {
+		if _, parent, found := strings.Cut(name, "."); found {
+			mc, ok = m.certs["*."+parent]
+		}
+	}
+	if !ok {
+		return nil
+	}
+	return mc.cert.Load()
+}
+
+func (m *CertificateManager) defaultCertificate() (*tls.Certificate, error) {
+	if len(m.opts.Domains) == 0 {
+		return nil, fmt.Errorf("no certificate for the server name requested by the client")
+	}
+	return m.lookup(normalizeServerName(m.opts.Domains[0])), nil
+}
+
+func (m *CertificateManager) allowed(name string) bool {
+	for _, h := range m.opts.AllowedHosts {
+		h = normalizeServerName(h)
+		if suffix, ok := strings.CutPrefix(h, "*"); ok {
+			label, found := strings.CutSuffix(name, suffix)
+			if found && label != "" && !strings.Contains(label, ".") {
+				return true
+			}
+		} else if h == name {
+			return true
+		}
+	}
+	return false
+}
+
+// enrollOnDemand enrolls the certificate of name once, even when several handshakes ask for it at the same time
+func (m *CertificateManager) enrollOnDemand(ctx context.Context, name string) (*tls.Certificate, error) {
+	m.mu.Lock()
+	if mc, ok := m.certs[name]; ok {
+		m.mu.Unlock()
+		return mc.cert.Load(), nil
+	}
+	if wait, ok := m.pending[name]; ok {
+		m.mu.Unlock()
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if cert := m.lookup(name); cert != nil {
+			return cert, nil
+		}
+		return nil, fmt.Errorf("failed to enroll certificate for %s", name)
+	}
+	wait := make(chan struct{})
+	m.pending[name] = wait
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		delete(m.pending, name)
+		m.mu.Unlock()
+		close(wait)
+	}()
+
+	cert, err := m.loadOrEnroll(ctx, name)
+	if err != nil {
+		log.Printf("Failed to enroll certificate on demand for %s: %s", name, err)
+		return nil, err
+	}
+	m.add(name, cert)
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+	return cert, nil
+}
+
+func (m *CertificateManager) loadOrEnroll(ctx context.Context, name string) (*tls.Certificate, error) {
+	cert, err := m.loadCached(name)
+	if err != nil {
+		log.Printf("Ignoring cached certificate for %s: %s", name, err)
+	}
+	if cert != nil {
+		return cert, nil
+	}
+	return m.enroll(ctx, name)
+}
+
+// enroll requests a new certificate for name and stores it in the cache. A new connector is used for every
+// enrollment, so that expired authentication tokens are never reused by long-running servers
+func (m *CertificateManager) enroll(ctx context.Context, name string) (*tls.Certificate, error) {
+	log.Println("Retrieving certificate for domain", name)
+	conn, err := m.cfg.NewClientContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	certPEM, keyPEM, err := enrollCertificate(ctx, conn, name)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := parseKeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if err = m.storeCached(name, certPEM, keyPEM); err != nil {
+		log.Printf("Failed to cache certificate for %s: %s", name, err)
+	}
+	return cert, nil
+}
+
+func (m *CertificateManager) cachePaths(name string) (string, string) {
+	base := filepath.Join(m.opts.CacheDir, strings.ReplaceAll(name, "*", "_"))
+	return base + ".crt", base + ".key"
+}
+
+// loadCached returns the cached certificate of name, or nil when there is none or when it has expired
+func (m *CertificateManager) loadCached(name string) (*tls.Certificate, error) {
+	if m.opts.CacheDir == "" {
+		return nil, nil
+	}
+	certPath, keyPath := m.cachePaths(name)
+	certPEM, err := os.ReadFile(certPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := parseKeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if !time.Now().Before(cert.Leaf.NotAfter) {
+		return nil, nil
+	}
+	if !strings.HasPrefix(name, "*.") {
+		if err = cert.Leaf.VerifyHostname(name); err != nil {
+			return nil, err
+		}
+	}
+	return cert, nil
+}
+
+func (m *CertificateManager) storeCached(name string, certPEM, keyPEM []byte) error {
+	if m.opts.CacheDir == "" {
+		return nil
+	}
+	certPath, keyPath := m.cachePaths(name)
+	// The key is written first, so that a new certificate is never paired with an old key
+	if err := writeCacheFile(keyPath, keyPEM); err != nil {
+		return err
+	}
+	return writeCacheFile(certPath, certPEM)
+}
+
+// writeCacheFile replaces the file at path atomically, so that a crash never leaves a partially written file
+func writeCacheFile(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+	if _, err = f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func parseKeyPair(certPEM, keyPEM []byte) (*tls.Certificate, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if cert.Leaf == nil {
+		if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+			return nil, err
+		}
+	}
+	return &cert, nil
+}
+
+func normalizeServerName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+}
+
+// joinPEM concatenates the PEM blocks of a certificate and its chain
+func joinPEM(blocks ...string) []byte {
+	var b bytes.Buffer
+	for _, block := range blocks {
+		b.WriteString(strings.TrimSpace(block))
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
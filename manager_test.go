@@ -0,0 +1,125 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vcert
+
+import (
+	"context"
+	"crypto/tls"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Venafi/vcert/v5/pkg/endpoint"
+)
+
+func serverCertificate(t *testing.T, m *CertificateManager, name string) *tls.Certificate {
+	t.Helper()
+	cert, err := m.GetCertificate(&tls.ClientHelloInfo{ServerName: name})
+	if err != nil {
+		t.Fatalf("failed to get certificate for %s: %s", name, err)
+	}
+	return cert
+}
+
+func TestCertificateManager_Cache(t *testing.T) {
+	cfg := Config{ConnectorType: endpoint.ConnectorTypeFake}
+	opts := CertificateManagerOptions{Domains: []string{"cache.example.com"}, CacheDir: t.TempDir()}
+
+	m, err := cfg.NewCertificateManager(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := serverCertificate(t, m, "cache.example.com")
+
+	m, err = cfg.NewCertificateManager(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second := serverCertificate(t, m, "cache.example.com")
+	if first.Leaf.SerialNumber.Cmp(second.Leaf.SerialNumber) != 0 {
+		t.Fatal("expected the cached certificate to be reused")
+	}
+}
+
+func TestCertificateManager_Renewal(t *testing.T) {
+	cfg := Config{ConnectorType: endpoint.ConnectorTypeFake}
+	// Certificates are always due for renewal, so they are renewed every RetryInterval
+	m, err := cfg.NewCertificateManager(context.Background(), CertificateManagerOptions{
+		Domains:       []string{"renew.example.com"},
+		RenewBefore:   1000 * 24 * time.Hour,
+		RetryInterval: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := serverCertificate(t, m, "renew.example.com")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- m.Run(ctx)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for serverCertificate(t, m, "renew.example.com").Leaf.SerialNumber.Cmp(first.Leaf.SerialNumber) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the certificate was not renewed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	if err = <-done; err != context.Canceled {
+		t.Fatalf("expected Run to stop when cancelled, got %v", err)
+	}
+}
+
+func TestCertificateManager_OnDemand(t *testing.T) {
+	cfg := Config{ConnectorType: endpoint.ConnectorTypeFake}
+	m, err := cfg.NewCertificateManager(context.Background(), CertificateManagerOptions{
+		Domains:      []string{"default.example.com"},
+		AllowedHosts: []string{"*.example.com"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	certs := make([]*tls.Certificate, 5)
+	for i := range certs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			certs[i] = serverCertificate(t, m, "www.example.com")
+		}(i)
+	}
+	wg.Wait()
+	for _, cert := range certs {
+		if cert != certs[0] {
+			t.Fatal("expected a single certificate to be enrolled on demand")
+		}
+	}
+	if err = certs[0].Leaf.VerifyHostname("www.example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Names that are not allowed get the certificate of the first domain
+	for _, name := range []string{"a.www.example.com", "example.org", ""} {
+		cert := serverCertificate(t, m, name)
+		if err = cert.Leaf.VerifyHostname("default.example.com"); err != nil {
+			t.Fatalf("expected the default certificate for %q: %s", name, err)
+		}
+	}
+}
@@ -156,6 +156,9 @@ type RevocationRequest struct {
 	Reason        string
 	Comments      string
 	Disable       bool
+	// Timeout is the maximum time to wait for the revocation to complete.
+	// Connectors that revoke synchronously ignore it.
+	Timeout time.Duration
 }
 
 type RetireRequest struct {
@@ -200,6 +203,7 @@ type CertificateInfo struct {
 	ID         string `json:",omitempty"`
 	CN         string
 	SANS       Sans
+	Issuer     string `json:",omitempty"`
 	Serial     string
 	Thumbprint string
 	ValidFrom  time.Time
@@ -283,8 +287,9 @@ type CustomFieldDetails struct {
 }
 
 type CertSeachInfo struct {
-	CertificateRequestId   string `json:"DN"`
-	CertificateRequestGuid string `json:"Guid"`
+	CertificateRequestId   string          `json:"DN"`
+	CertificateRequestGuid string          `json:"Guid"`
+	X509                   CertificateInfo `json:"X509"`
 }
 
 // Deprecated: GenerateRequest is deprecated
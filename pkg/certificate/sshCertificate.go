@@ -0,0 +1,115 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package certificate
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	SshCertTypeUser = "User"
+	SshCertTypeHost = "Host"
+
+	sshForceCommandOption  = "force-command"
+	sshSourceAddressOption = "source-address"
+)
+
+// ParseSshCertificate parses an OpenSSH certificate in the authorized_keys format, as written to a -cert.pub file
+func ParseSshCertificate(data []byte) (*ssh.Certificate, error) {
+	pub, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH certificate: %w", err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("%s key is not an SSH certificate", pub.Type())
+	}
+	return cert, nil
+}
+
+// NewSshCertificateDetails returns the details of an SSH certificate, as Trust Protection Platform reports them.
+// ValidTo is 0 for a certificate that never expires
+func NewSshCertificateDetails(cert *ssh.Certificate) SshCertificateDetails {
+	details := SshCertificateDetails{
+		KeyType:                      cert.Key.Type(),
+		CertificateType:              SshCertTypeUser,
+		CertificateFingerprintSHA256: strings.TrimPrefix(ssh.FingerprintSHA256(cert), "SHA256:"),
+		CAFingerprintSHA256:          strings.TrimPrefix(ssh.FingerprintSHA256(cert.SignatureKey), "SHA256:"),
+		KeyID:                        cert.KeyId,
+		SerialNumber:                 strconv.FormatUint(cert.Serial, 10),
+		Principals:                   cert.ValidPrincipals,
+		ValidFrom:                    int64(cert.ValidAfter),
+		ForceCommand:                 cert.CriticalOptions[sshForceCommandOption],
+		PublicKeyFingerprintSHA256:   strings.TrimPrefix(ssh.FingerprintSHA256(cert.Key), "SHA256:"),
+	}
+	if cert.CertType == ssh.HostCert {
+		details.CertificateType = SshCertTypeHost
+	}
+	if cert.ValidBefore != ssh.CertTimeInfinity {
+		details.ValidTo = int64(cert.ValidBefore)
+	}
+	if addresses := cert.CriticalOptions[sshSourceAddressOption]; addresses != "" {
+		details.SourceAddresses = strings.Split(addresses, ",")
+	}
+	if len(cert.Extensions) > 0 {
+		details.Extensions = make(map[string]interface{}, len(cert.Extensions))
+		for k, v := range cert.Extensions {
+			details.Extensions[k] = v
+		}
+	}
+	return details
+}
+
+// VerifySshCertificate checks that cert is signed by one of the CA public keys of caPublicKeys, in the
+// authorized_keys format as returned by RetrieveSshConfig, and that it is valid at the time now
+func VerifySshCertificate(cert *ssh.Certificate, caPublicKeys string, now time.Time) error {
+	signedByCA := false
+	rest := []byte(caPublicKeys)
+	for len(bytes.TrimSpace(rest)) > 0 {
+		var caKey ssh.PublicKey
+		var err error
+		caKey, _, _, rest, err = ssh.ParseAuthorizedKey(rest)
+		if err != nil {
+			return fmt.Errorf("failed to parse SSH CA public key: %w", err)
+		}
+		if bytes.Equal(caKey.Marshal(), cert.SignatureKey.Marshal()) {
+			signedByCA = true
+			break
+		}
+	}
+	if !signedByCA {
+		return fmt.Errorf("SSH certificate is not signed by the CA: its signing CA is %s", ssh.FingerprintSHA256(cert.SignatureKey))
+	}
+
+	// The options are checked by the server the certificate is presented to. Only the signature and the
+	// validity are checked here
+	checker := ssh.CertChecker{Clock: func() time.Time { return now }}
+	for option := range cert.CriticalOptions {
+		checker.SupportedCriticalOptions = append(checker.SupportedCriticalOptions, option)
+	}
+	principal := ""
+	if len(cert.ValidPrincipals) > 0 {
+		principal = cert.ValidPrincipals[0]
+	}
+	return checker.CheckCert(principal, cert)
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package certificate
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func newTestSshSigner(t *testing.T) ssh.Signer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.NoError(t, err)
+	return signer
+}
+
+func TestSshCertificate(t *testing.T) {
+	ca := newTestSshSigner(t)
+	key := newTestSshSigner(t)
+	now := time.Now()
+	cert := &ssh.Certificate{
+		Key:             key.PublicKey(),
+		Serial:          42,
+		CertType:        ssh.HostCert,
+		KeyId:           "web-01",
+		ValidPrincipals: []string{"web-01.example.com", "web-01"},
+		ValidAfter:      uint64(now.Add(-time.Hour).Unix()),
+		ValidBefore:     uint64(now.Add(time.Hour).Unix()),
+		Permissions: ssh.Permissions{
+			CriticalOptions: map[string]string{"source-address": "10.0.0.0/8,192.168.1.1", "verify-required": ""},
+			Extensions:      map[string]string{"permit-pty": ""},
+		},
+	}
+	require.NoError(t, cert.SignCert(rand.Reader, ca))
+
+	parsed, err := ParseSshCertificate(ssh.MarshalAuthorizedKey(cert))
+	require.NoError(t, err)
+
+	details := NewSshCertificateDetails(parsed)
+	require.Equal(t, SshCertTypeHost, details.CertificateType)
+	require.Equal(t, "web-01", details.KeyID)
+	require.Equal(t, "42", details.SerialNumber)
+	require.Equal(t, []string{"web-01.example.com", "web-01"}, details.Principals)
+	require.Equal(t, now.Add(time.Hour).Unix(), details.ValidTo)
+	require.Equal(t, []string{"10.0.0.0/8", "192.168.1.1"}, details.SourceAddresses)
+	require.Equal(t, map[string]interface{}{"permit-pty": ""}, details.Extensions)
+	require.Equal(t, strings.TrimPrefix(ssh.FingerprintSHA256(ca.PublicKey()), "SHA256:"), details.CAFingerprintSHA256)
+
+	caPublicKey := string(ssh.MarshalAuthorizedKey(ca.PublicKey()))
+	require.NoError(t, VerifySshCertificate(parsed, caPublicKey, now))
+
+	// Any of the CA keys can sign the certificate
+	otherCA := string(ssh.MarshalAuthorizedKey(newTestSshSigner(t).PublicKey()))
+	require.NoError(t, VerifySshCertificate(parsed, otherCA+caPublicKey, now))
+
+	err = VerifySshCertificate(parsed, otherCA, now)
+	require.ErrorContains(t, err, "not signed by the CA")
+
+	err = VerifySshCertificate(parsed, caPublicKey, now.Add(2*time.Hour))
+	require.ErrorContains(t, err, "expired")
+
+	err = VerifySshCertificate(parsed, "not a key", now)
+	require.ErrorContains(t, err, "failed to parse SSH CA public key")
+
+	_, err = ParseSshCertificate(ssh.MarshalAuthorizedKey(key.PublicKey()))
+	require.ErrorContains(t, err, "is not an SSH certificate")
+}
+
+func TestSshCertificateValidForever(t *testing.T) {
+	ca := newTestSshSigner(t)
+	cert := &ssh.Certificate{
+		Key:         newTestSshSigner(t).PublicKey(),
+		CertType:    ssh.UserCert,
+		ValidBefore: ssh.CertTimeInfinity,
+	}
+	require.NoError(t, cert.SignCert(rand.Reader, ca))
+
+	details := NewSshCertificateDetails(cert)
+	require.Equal(t, SshCertTypeUser, details.CertificateType)
+	require.Zero(t, details.ValidTo)
+	require.NoError(t, VerifySshCertificate(cert, string(ssh.MarshalAuthorizedKey(ca.PublicKey())), time.Now()))
+}
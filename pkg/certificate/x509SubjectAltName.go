@@ -125,7 +125,13 @@ func marshalSANs(dnsNames, emailAddresses []string, ipAddresses []net.IP, uris [
 
 // Since crypto/x509 package is not aware of UPN SANs, implement our own parsing method
 func getUserPrincipalNameSANs(cert *x509.Certificate) (ret []string, err error) {
-	for _, ext := range cert.Extensions {
+	return GetUserPrincipalNameSANs(cert.Extensions)
+}
+
+// GetUserPrincipalNameSANs returns the UPN SANs of the subjectAltName extension found in
+// the extensions of a certificate or CSR
+func GetUserPrincipalNameSANs(extensions []pkix.Extension) (ret []string, err error) {
+	for _, ext := range extensions {
 		if !ext.Id.Equal(oidExtensionSubjectAltName) {
 			continue
 		}
@@ -17,6 +17,7 @@
 package endpoint
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/rsa"
@@ -149,6 +150,43 @@ type Connector interface {
 	SetUserAgent(userAgent string)
 }
 
+// ConnectorContext is a Connector whose methods take a context. Cancelling the context stops the requests to the
+// platform and the waits for a certificate to be issued. The methods of Connector use context.Background().
+//
+// The connectors of this module implement ConnectorContext; a Connector implemented elsewhere may not
+type ConnectorContext interface {
+	Connector
+
+	PingContext(ctx context.Context) (err error)
+	AuthenticateContext(ctx context.Context, auth *Authentication) (err error)
+	ReadPolicyConfigurationContext(ctx context.Context) (policy *Policy, err error)
+	ReadZoneConfigurationContext(ctx context.Context) (config *ZoneConfiguration, err error)
+	GetZonesByParentContext(ctx context.Context, parent string) ([]string, error)
+	GenerateRequestContext(ctx context.Context, config *ZoneConfiguration, req *certificate.Request) (err error)
+	ResetCertificateContext(ctx context.Context, req *certificate.Request, restart bool) (err error)
+	RequestCertificateContext(ctx context.Context, req *certificate.Request) (requestID string, err error)
+	RetrieveCertificateContext(ctx context.Context, req *certificate.Request) (certificates *certificate.PEMCollection, err error)
+	ProvisionCertificateContext(ctx context.Context, req *domain.ProvisioningRequest, options *domain.ProvisioningOptions) (*domain.ProvisioningMetadata, error)
+	IsCSRServiceGeneratedContext(ctx context.Context, req *certificate.Request) (bool, error)
+	RevokeCertificateContext(ctx context.Context, req *certificate.RevocationRequest) error
+	RenewCertificateContext(ctx context.Context, req *certificate.RenewalRequest) (requestID string, err error)
+	RetireCertificateContext(ctx context.Context, req *certificate.RetireRequest) error
+	ImportCertificateContext(ctx context.Context, req *certificate.ImportRequest) (*certificate.ImportResponse, error)
+	ListCertificatesContext(ctx context.Context, filter Filter) ([]certificate.CertificateInfo, error)
+	SearchCertificatesContext(ctx context.Context, req *certificate.SearchRequest) (*certificate.CertSearchResponse, error)
+	SearchCertificateContext(ctx context.Context, zone string, cn string, sans *certificate.Sans, certMinTimeLeft time.Duration) (*certificate.CertificateInfo, error)
+	RetrieveCertificateMetaDataContext(ctx context.Context, dn string) (*certificate.CertificateMetaData, error)
+	SetPolicyContext(ctx context.Context, name string, ps *policy.PolicySpecification) (string, error)
+	GetPolicyContext(ctx context.Context, name string) (*policy.PolicySpecification, error)
+	RequestSSHCertificateContext(ctx context.Context, req *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error)
+	RetrieveSSHCertificateContext(ctx context.Context, req *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error)
+	RetrieveSshConfigContext(ctx context.Context, ca *certificate.SshCaTemplateRequest) (*certificate.SshConfig, error)
+	RetrieveAvailableSSHTemplatesContext(ctx context.Context) ([]certificate.SshAvaliableTemplate, error)
+	SynchronousRequestCertificateContext(ctx context.Context, req *certificate.Request) (certificates *certificate.PEMCollection, err error)
+	RetrieveSystemVersionContext(ctx context.Context) (string, error)
+	WriteLogContext(ctx context.Context, req *LogRequest) error
+}
+
 type Filter struct {
 	Limit       *int
 	WithExpired bool
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httputils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultAttemptTimeout is the time an attempt of a request sent by a client of NewClient may take
+const DefaultAttemptTimeout = 30 * time.Second
+
+// NewClient returns the http.Client of the connectors. It trusts the CAs of trust, or the system ones when trust is
+// nil, and retries and rate limits its requests according to policy, or DefaultRetryPolicy when policy is nil.
+//
+// The transport bounds the steps of an attempt up to the response headers: dialing, the TLS handshake and the wait
+// for the headers. It cannot bound the read of the response body, which the caller does after the round trip, so
+// the client timeout bounds the whole request instead. That timeout covers every attempt and the backoffs between
+// them, see RetryPolicy.ClientTimeout, so a pending retry isn't cut short
+func NewClient(trust *x509.CertPool, policy *RetryPolicy) *http.Client {
+	netTransport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   DefaultAttemptTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		ResponseHeaderTimeout: DefaultAttemptTimeout,
+	}
+	tlsConfig := http.DefaultTransport.(*http.Transport).TLSClientConfig
+	/* #nosec */
+	if trust != nil {
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{
+				MinVersion: tls.VersionTLS12,
+			}
+		} else {
+			tlsConfig = tlsConfig.Clone()
+		}
+		tlsConfig.RootCAs = trust
+	}
+	netTransport.TLSClientConfig = tlsConfig
+
+	retryPolicy := DefaultRetryPolicy
+	if policy != nil {
+		retryPolicy = *policy
+	}
+	return &http.Client{
+		Timeout:   retryPolicy.ClientTimeout(DefaultAttemptTimeout),
+		Transport: NewRetryTransport(netTransport, retryPolicy),
+	}
+}
@@ -0,0 +1,297 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httputils
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Venafi/vcert/v5/pkg/verror"
+)
+
+// RetryPolicy configures the retries and the rate limit of a RetryTransport
+type RetryPolicy struct {
+	// MaxRetries is the number of times a request is retried after its first attempt. Zero disables the retries
+	MaxRetries int
+	// MinBackoff is the delay before the first retry. It doubles on each retry, up to MaxBackoff, and is jittered
+	MinBackoff time.Duration
+	// MaxBackoff bounds the delay between two attempts. A Retry-After longer than MaxBackoff is not waited for
+	MaxBackoff time.Duration
+	// RequestsPerSecond limits the rate of the requests, retries included, allowing bursts of Burst requests.
+	// Zero disables the limit
+	RequestsPerSecond float64
+	Burst             int
+}
+
+// DefaultRetryPolicy is the RetryPolicy of the connectors unless configured otherwise
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	MinBackoff: 500 * time.Millisecond,
+	MaxBackoff: 30 * time.Second,
+}
+
+// ClientTimeout returns the timeout of an http.Client sending its requests through a RetryTransport with the policy.
+// It covers every attempt, each one bounded by attemptTimeout, and the longest backoffs between them, so that the
+// client doesn't time out while a retry is pending
+func (p RetryPolicy) ClientTimeout(attemptTimeout time.Duration) time.Duration {
+	if p.MaxRetries <= 0 {
+		return attemptTimeout
+	}
+	maxBackoff := p.MaxBackoff
+	if maxBackoff < p.MinBackoff {
+		maxBackoff = p.MinBackoff
+	}
+	return time.Duration(p.MaxRetries+1)*attemptTimeout + time.Duration(p.MaxRetries)*maxBackoff
+}
+
+// RetryTransport is an http.RoundTripper that retries the requests failing on a transient error: a 429, 502, 503 or
+// 504 status, a refused or reset connection. The Retry-After header of the response is honored.
+//
+// A request that is not idempotent, e.g. a POST requesting a certificate, may already have been processed by the
+// server when a gateway fails or the connection breaks, so it is only retried when the server did not process it:
+// a refused connection, a 429 or a 503 status.
+//
+// When the retries are exhausted, the error returned wraps verror.ServerTemporaryUnavailableError, so that the
+// caller can try again later
+type RetryTransport struct {
+	Wrapped http.RoundTripper
+	Policy  RetryPolicy
+
+	limiter *rateLimiter
+	// sleep waits for d or until the request is canceled. It is replaced by the tests
+	sleep func(req *http.Request, d time.Duration) error
+}
+
+// NewRetryTransport returns a RetryTransport sending the requests with wrapped, retried and rate limited
+// according to policy
+func NewRetryTransport(wrapped http.RoundTripper, policy RetryPolicy) *RetryTransport {
+	if wrapped == nil {
+		wrapped = http.DefaultTransport
+	}
+	if policy.MinBackoff <= 0 {
+		policy.MinBackoff = DefaultRetryPolicy.MinBackoff
+	}
+	if policy.MaxBackoff < policy.MinBackoff {
+		policy.MaxBackoff = policy.MinBackoff
+	}
+	t := &RetryTransport{Wrapped: wrapped, Policy: policy, sleep: sleepContext}
+	if policy.RequestsPerSecond > 0 {
+		t.limiter = newRateLimiter(policy.RequestsPerSecond, policy.Burst)
+	}
+	return t
+}
+
+// WithRetries returns a copy of client whose requests are retried and rate limited according to policy. The client is
+// returned as is when it is nil or already sends its requests through a RetryTransport. The Timeout of client is the
+// time an attempt may take, and is raised to RetryPolicy.ClientTimeout in the copy so that it covers the retries
+func WithRetries(client *http.Client, policy RetryPolicy) *http.Client {
+	if client == nil {
+		return nil
+	}
+	if _, ok := client.Transport.(*RetryTransport); ok {
+		return client
+	}
+	withRetries := *client
+	withRetries.Transport = NewRetryTransport(client.Transport, policy)
+	if client.Timeout > 0 {
+		withRetries.Timeout = policy.ClientTimeout(client.Timeout)
+	}
+	return &withRetries
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A request whose body cannot be read again is sent once
+	retries := t.Policy.MaxRetries
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		retries = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		if t.limiter != nil {
+			err := t.sleep(req, t.limiter.reserve())
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		r := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+
+		resp, err := t.Wrapped.RoundTrip(r)
+		if !isRetryable(req, resp, err) {
+			return resp, err
+		}
+		if attempt >= retries {
+			return nil, exhausted(req, resp, err, attempt+1)
+		}
+
+		wait := t.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				if after > t.Policy.MaxBackoff {
+					return nil, exhausted(req, resp, err, attempt+1)
+				}
+				if after > wait {
+					wait = after
+				}
+			}
+			// The connection is reused only once the body is read
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+			resp.Body.Close()
+		}
+
+		err = t.sleep(req, wait)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// backoff returns the delay before the retry following attempt: MinBackoff doubled on each attempt, up to
+// MaxBackoff, of which a random half is waited
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	d := t.Policy.MinBackoff
+	for i := 0; i < attempt && d < t.Policy.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > t.Policy.MaxBackoff {
+		d = t.Policy.MaxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1)) // #nosec G404 -- the jitter needs no secure randomness
+}
+
+// isRetryable tells whether the request failed on a transient error and can be sent again
+func isRetryable(req *http.Request, resp *http.Response, err error) bool {
+	idempotent := isIdempotent(req)
+	if err != nil {
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return true
+		}
+		return idempotent && (errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) ||
+			errors.Is(err, io.ErrUnexpectedEOF))
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return idempotent
+	}
+	return false
+}
+
+// isIdempotent tells whether sending req twice has the same effect as sending it once
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func exhausted(req *http.Request, resp *http.Response, err error, attempts int) error {
+	if err != nil {
+		return fmt.Errorf("%w: %s %s failed after %d attempts: %w", verror.ServerTemporaryUnavailableError,
+			req.Method, req.URL.Redacted(), attempts, err)
+	}
+	resp.Body.Close()
+	return fmt.Errorf("%w: %s %s returned %s after %d attempts", verror.ServerTemporaryUnavailableError,
+		req.Method, req.URL.Redacted(), resp.Status, attempts)
+}
+
+// retryAfter parses the value of a Retry-After header, either a number of seconds or an HTTP date
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if d := date.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
+
+func sleepContext(req *http.Request, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rateLimiter is a token bucket shared by the requests of a RetryTransport
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	burst    int
+	// next is the time at which the next request can be sent without exceeding the burst
+	next time.Time
+}
+
+func newRateLimiter(perSecond float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond), burst: burst}
+}
+
+// reserve takes a token from the bucket and returns how long to wait before sending the request
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	// Unused tokens accumulate up to the burst
+	earliest := now.Add(-time.Duration(l.burst-1) * l.interval)
+	if l.next.Before(earliest) {
+		l.next = earliest
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
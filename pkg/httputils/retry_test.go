@@ -0,0 +1,250 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package httputils
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Venafi/vcert/v5/pkg/verror"
+)
+
+// newTestRetryTransport returns a RetryTransport that records its waits instead of sleeping
+func newTestRetryTransport(policy RetryPolicy) (*RetryTransport, *[]time.Duration) {
+	var waits []time.Duration
+	t := NewRetryTransport(http.DefaultTransport, policy)
+	t.sleep = func(_ *http.Request, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return t, &waits
+}
+
+func TestRetryTransport(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		require.Equal(t, "payload", string(body), "the body is sent again on each attempt")
+		switch atomic.AddInt32(&attempts, 1) {
+		case 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			_, _ = w.Write([]byte("done"))
+		}
+	}))
+	defer server.Close()
+
+	transport, waits := newTestRetryTransport(RetryPolicy{MaxRetries: 3, MinBackoff: time.Second, MaxBackoff: 10 * time.Second})
+	client := &http.Client{Transport: transport}
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, 3, attempts)
+
+	require.Len(t, *waits, 2)
+	require.GreaterOrEqual(t, (*waits)[0], 500*time.Millisecond)
+	require.LessOrEqual(t, (*waits)[0], time.Second)
+	require.Equal(t, 7*time.Second, (*waits)[1], "Retry-After is honored")
+}
+
+func TestRetryTransportExhausted(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/later" {
+			w.Header().Set("Retry-After", "3600")
+		}
+		if r.URL.Path == "/bad" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	transport, waits := newTestRetryTransport(RetryPolicy{MaxRetries: 2})
+	client := &http.Client{Transport: transport}
+	_, err := client.Get(server.URL)
+	require.ErrorIs(t, err, verror.ServerTemporaryUnavailableError)
+	require.Contains(t, err.Error(), "503 Service Unavailable after 3 attempts")
+	require.EqualValues(t, 3, attempts)
+	require.Len(t, *waits, 2)
+
+	// a Retry-After beyond the maximum backoff is not waited for
+	attempts = 0
+	_, err = client.Get(server.URL + "/later")
+	require.ErrorIs(t, err, verror.ServerTemporaryUnavailableError)
+	require.EqualValues(t, 1, attempts)
+
+	// other errors are returned as is
+	resp, err := client.Get(server.URL + "/bad")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestRetryTransportNotIdempotent(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		if r.URL.Path == "/busy" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	transport, waits := newTestRetryTransport(RetryPolicy{MaxRetries: 2})
+	client := &http.Client{Transport: transport}
+
+	// the server may have processed the request behind the failing gateway
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	require.EqualValues(t, 1, attempts)
+	require.Empty(t, *waits)
+
+	// the same failure is retried for an idempotent request
+	attempts = 0
+	_, err = client.Get(server.URL)
+	require.ErrorIs(t, err, verror.ServerTemporaryUnavailableError)
+	require.EqualValues(t, 3, attempts)
+
+	// a 503 means the request was not processed
+	attempts = 0
+	_, err = client.Post(server.URL+"/busy", "text/plain", strings.NewReader("payload"))
+	require.ErrorIs(t, err, verror.ServerTemporaryUnavailableError)
+	require.EqualValues(t, 3, attempts)
+}
+
+func TestRetryTransportConnectionErrors(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	transport, waits := newTestRetryTransport(RetryPolicy{MaxRetries: 1})
+	client := &http.Client{Transport: transport}
+	_, err = client.Get("http://" + address)
+	require.ErrorIs(t, err, verror.ServerTemporaryUnavailableError)
+	require.Len(t, *waits, 1, "a refused connection is retried")
+
+	// a canceled request is not retried
+	transport.sleep = func(r *http.Request, _ time.Duration) error {
+		return errors.New("unexpected retry")
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+	client.Timeout = 10 * time.Millisecond
+	_, err = client.Get(server.URL)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, verror.ServerTemporaryUnavailableError)
+}
+
+func TestRetryPolicyClientTimeout(t *testing.T) {
+	require.Equal(t, 30*time.Second, RetryPolicy{}.ClientTimeout(30*time.Second))
+	require.Equal(t, 210*time.Second, DefaultRetryPolicy.ClientTimeout(30*time.Second))
+
+	// a Retry-After up to the maximum backoff is waited for without the client timing out
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	policy := RetryPolicy{MaxRetries: 1, MinBackoff: 10 * time.Millisecond, MaxBackoff: time.Second}
+	client := &http.Client{
+		Timeout:   policy.ClientTimeout(500 * time.Millisecond),
+		Transport: NewRetryTransport(http.DefaultTransport, policy),
+	}
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, 2, attempts)
+}
+
+func TestWithRetries(t *testing.T) {
+	require.Nil(t, WithRetries(nil, DefaultRetryPolicy))
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Timeout: time.Minute}
+	policy := RetryPolicy{MaxRetries: 1, MinBackoff: time.Millisecond}
+	withRetries := WithRetries(client, policy)
+	require.Nil(t, client.Transport, "the client set by the caller is not modified")
+	require.Equal(t, time.Minute, client.Timeout, "the client set by the caller is not modified")
+	require.Equal(t, policy.ClientTimeout(time.Minute), withRetries.Timeout, "the timeout of an attempt covers the retries")
+	require.Same(t, withRetries, WithRetries(withRetries, DefaultRetryPolicy), "the transport is wrapped once")
+
+	resp, err := withRetries.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, 2, attempts)
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(10, 3)
+	for i := 0; i < 3; i++ {
+		require.Zero(t, limiter.reserve(), "the burst is not delayed")
+	}
+	require.InDelta(t, float64(100*time.Millisecond), float64(limiter.reserve()), float64(10*time.Millisecond))
+	require.InDelta(t, float64(200*time.Millisecond), float64(limiter.reserve()), float64(10*time.Millisecond))
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	d, ok := retryAfter("120", now)
+	require.True(t, ok)
+	require.Equal(t, 2*time.Minute, d)
+
+	d, ok = retryAfter(now.Add(30*time.Second).Format(http.TimeFormat), now)
+	require.True(t, ok)
+	require.Equal(t, 30*time.Second, d)
+
+	for _, value := range []string{"", "-1", "soon"} {
+		_, ok = retryAfter(value, now)
+		require.False(t, ok, value)
+	}
+}
@@ -29,6 +29,8 @@ type CertificateTask struct {
 	Installations Installations   `yaml:"installations,omitempty"`
 	RenewBefore   string          `yaml:"renewBefore,omitempty"`
 	SetEnvVars    []string        `yaml:"setEnvVars,omitempty"`
+	// DependsOn lists the names of the tasks that must be done before this task starts
+	DependsOn []string `yaml:"dependsOn,omitempty"`
 }
 
 // CertificateTasks is a slice of CertificateTask
@@ -67,3 +69,54 @@ func (task CertificateTask) IsValid() (bool, error) {
 
 	return rValid, rErr
 }
+
+// dependencyCycle returns the names of the tasks forming a cycle through their dependsOn fields,
+// or nil if the tasks can be ordered. Unknown dependencies are ignored
+func (tasks CertificateTasks) dependencyCycle() []string {
+	deps := make(map[string][]string, len(tasks))
+	for _, t := range tasks {
+		deps[t.Name] = t.DependsOn
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(tasks))
+	var path []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			// The cycle starts at the first occurrence of name in the current path
+			for i, n := range path {
+				if n == name {
+					return append(append([]string{}, path[i:]...), name)
+				}
+			}
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range deps[name] {
+			if _, ok := deps[dep]; !ok || dep == name {
+				continue
+			}
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	for _, t := range tasks {
+		if cycle := visit(t.Name); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
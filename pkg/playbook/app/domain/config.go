@@ -16,13 +16,28 @@
 
 package domain
 
+import (
+	"errors"
+	"fmt"
+)
+
 // Config contains all the values necessary to connect to a given Venafi platform: TPP or TLSPC
 type Config struct {
 	Connection Connection `yaml:"connection,omitempty"`
-	ForceRenew bool       `yaml:"-"`
+	// Concurrency is the maximum number of certificate tasks run in parallel. Tasks run one after another when unset
+	Concurrency int `yaml:"concurrency,omitempty"`
+	// MetricsFile is the file in which the outcome of the tasks and of the certificate requests is kept, for
+	// vcert exporter to serve them. Not kept when unset
+	MetricsFile string `yaml:"metricsFile,omitempty"`
+	ForceRenew  bool   `yaml:"-"`
 }
 
 // IsValid Ensures the provided connection configuration is valid and logical
 func (c Config) IsValid() (bool, error) {
-	return c.Connection.IsValid()
+	valid, err := c.Connection.IsValid()
+	if c.Concurrency < 0 {
+		valid = false
+		err = errors.Join(err, fmt.Errorf("%w: %d", ErrInvalidConcurrency, c.Concurrency))
+	}
+	return valid, err
 }
@@ -28,8 +28,10 @@ import (
 // Connection represents the issuer that vCert will connect to
 // in order to issue certificates
 type Connection struct {
-	Credentials     Authentication  `yaml:"credentials,omitempty"`
-	Insecure        bool            `yaml:"insecure,omitempty"`
+	Credentials Authentication `yaml:"credentials,omitempty"`
+	Insecure    bool           `yaml:"insecure,omitempty"`
+	// InventoryPath is the file where the fake platform keeps the inventory of the certificates it issues
+	InventoryPath   string          `yaml:"inventoryPath,omitempty"`
 	Platform        venafi.Platform `yaml:"platform,omitempty"`
 	TrustBundlePath string          `yaml:"trustBundle,omitempty"`
 	URL             string          `yaml:"url,omitempty"`
@@ -70,6 +72,9 @@ func (c Connection) IsValid() (bool, error) {
 		return isValidVaaS(c)
 	case venafi.Firefly:
 		return isValidFirefly(c)
+	case venafi.Fake:
+		// the fake connector needs no credentials
+		return true, nil
 	default:
 		return false, fmt.Errorf("invalid connection type %v", c.Platform)
 	}
@@ -231,6 +231,16 @@ func (s *ConnectionSuite) SetupTest() {
 			expectedValid: false,
 			expectedErr:   ErrNoCredentials,
 		},
+		// FAKE USE CASES
+		{
+			name: "Fake_valid",
+			c: Connection{
+				Platform:      venafi.Fake,
+				InventoryPath: "/tmp/vcert-inventory.json",
+			},
+			expectedCType: endpoint.ConnectorTypeFake,
+			expectedValid: true,
+		},
 		// UNKNOWN USE CASES
 		{
 			name: "Unknown_invalid",
@@ -21,7 +21,7 @@ import "fmt"
 var (
 	// ErrNoConfig is thrown when the Playbook has no config section
 	ErrNoConfig = fmt.Errorf("no config found on playbook")
-	// ErrNoTasks is thrown when the Playbook has no certificateTasks section
+	// ErrNoTasks is thrown when the Playbook has neither a certificateTasks nor a sshCertificateTasks section
 	ErrNoTasks = fmt.Errorf("no certificate tasks found on playbook")
 	// ErrNoInstallations is thrown when any task (item in Certificates section) has no installations defined
 	ErrNoInstallations = fmt.Errorf("no installations found on certificate task")
@@ -31,6 +31,29 @@ var (
 	// ErrNoRequestCN si thrown when a certificate request does not contain subject.CommonName
 	ErrNoRequestCN = fmt.Errorf("request.subject.commonName is required and was not found")
 
+	// ErrUnknownDependency is thrown when certificateTasks[].dependsOn refers to a task that does not exist
+	ErrUnknownDependency = fmt.Errorf("dependsOn refers to an unknown task")
+	// ErrDependencyCycle is thrown when the certificateTasks[].dependsOn of some tasks refer to each other
+	ErrDependencyCycle = fmt.Errorf("certificate tasks depend on each other")
+	// ErrNoHandlerName is thrown when an item of the handlers section has no name
+	ErrNoHandlerName = fmt.Errorf("handler name is required and was not found")
+	// ErrNoHandlerAction is thrown when an item of the handlers section has no action
+	ErrNoHandlerAction = fmt.Errorf("handler action is required and was not found")
+	// ErrUnknownHandler is thrown when certificateTasks[].installations[].notify refers to a handler that does not exist
+	ErrUnknownHandler = fmt.Errorf("notify refers to an unknown handler")
+
+	// ErrNoSshTemplate is thrown when a SSH certificate request is specified without a template
+	ErrNoSshTemplate = fmt.Errorf("request.template is required and was not found")
+	// ErrInvalidSshKeyType is thrown when sshCertificateTasks[].request.keyType is unknown
+	ErrInvalidSshKeyType = fmt.Errorf("request.keyType should be one of rsa, ecdsa or ed25519")
+	// ErrNoSshKeyFile is thrown when sshCertificateTasks[].installation.keyFile is not set
+	ErrNoSshKeyFile = fmt.Errorf("installation.keyFile is required and was not found")
+	// ErrSshTasksPlatform is thrown when the Playbook has a sshCertificateTasks section but the platform is not TPP
+	ErrSshTasksPlatform = fmt.Errorf("sshCertificateTasks are only supported by the TPP platform")
+
+	// ErrInvalidConcurrency is thrown when config.concurrency is negative
+	ErrInvalidConcurrency = fmt.Errorf("config.concurrency must not be negative")
+
 	// ErrNoCredentials is thrown when the Playbook has no config section
 	ErrNoCredentials = fmt.Errorf("no credentials defined on playbook")
 	// ErrMultipleCredentials is thrown when the config.credentials section has both apikey and accessToken declared
@@ -57,6 +80,21 @@ var (
 	// ErrNoKeyFile is thrown when certificates.installations[].type is PEM but no pemKeyFilename is set
 	ErrNoKeyFile = fmt.Errorf("keyFile should not be empty when installing a certificate in PEM format")
 
+	// ErrInvalidFileMode is thrown when certificates.installations[].fileMode is not an octal file mode
+	ErrInvalidFileMode = fmt.Errorf("fileMode should be an octal file mode (i.e. '0640')")
+	// ErrOwnershipOnWindows is thrown when certificates.installations[].owner or group is set on a windows system
+	ErrOwnershipOnWindows = fmt.Errorf("owner and group are not supported on windows systems")
+	// ErrBundleNotPEM is thrown when certificates.installations[].bundle is set but the format is not PEM
+	ErrBundleNotPEM = fmt.Errorf("bundle is only supported when installing a certificate in PEM format")
+	// ErrInvalidBundlePart is thrown when certificates.installations[].bundle has an unknown part
+	ErrInvalidBundlePart = fmt.Errorf("unknown bundle part. Valid parts are 'certificate', 'chain' and 'key'")
+	// ErrDuplicateBundlePart is thrown when certificates.installations[].bundle has a part multiple times
+	ErrDuplicateBundlePart = fmt.Errorf("bundle part defined multiple times")
+	// ErrNoBundleCertificate is thrown when certificates.installations[].bundle does not include the certificate
+	ErrNoBundleCertificate = fmt.Errorf("bundle should include the 'certificate' part")
+	// ErrBundleChainBeforeCertificate is thrown when certificates.installations[].bundle has the chain before the certificate
+	ErrBundleChainBeforeCertificate = fmt.Errorf("bundle 'chain' part should come after the 'certificate' part")
+
 	// ErrUndefinedInstallationFormat is thrown when certificates.installations[].type is unknown
 	ErrUndefinedInstallationFormat = fmt.Errorf("unknown installation format specified")
 	// ErrNoInstallationFile is thrown when certificates.installations[].File is not set
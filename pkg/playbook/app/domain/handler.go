@@ -0,0 +1,52 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Handler represents an action shared by the installations of a playbook, e.g. reloading a web server.
+//
+// Installations queue handlers by name in their notify list. A queued handler runs once,
+// after all the certificate tasks are done
+type Handler struct {
+	Name   string `yaml:"name,omitempty"`
+	Action string `yaml:"action,omitempty"`
+}
+
+// Handlers is a slice of Handler
+type Handlers []Handler
+
+// IsValid returns true if the Handler has the required fields to be run
+func (h Handler) IsValid() (bool, error) {
+	var rErr error = nil
+	rValid := true
+
+	if h.Name == "" {
+		rValid = false
+		rErr = errors.Join(rErr, fmt.Errorf("\t\t%w", ErrNoHandlerName))
+	}
+
+	if h.Action == "" {
+		rValid = false
+		rErr = errors.Join(rErr, fmt.Errorf("\t\t%w", ErrNoHandlerAction))
+	}
+
+	return rValid, rErr
+}
@@ -18,16 +18,31 @@ package domain
 
 import (
 	"fmt"
+	"os"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"go.uber.org/zap"
+
+	"github.com/Venafi/vcert/v5/pkg/playbook/util"
 )
 
 const (
 	// JKSMinPasswordLength represents the minimum length a JKS password must have per the JKS specification
 	JKSMinPasswordLength = 6
 
+	// BundleCertificate is the part of Installation.Bundle for the certificate
+	BundleCertificate = "certificate"
+	// BundleChain is the part of Installation.Bundle for the chain of the certificate
+	BundleChain = "chain"
+	// BundleKey is the part of Installation.Bundle for the private key
+	BundleKey = "key"
+
+	// DefaultKeyFileMode is the mode of the private key file when Installation.KeyFileMode is not set,
+	// whatever Installation.FileMode is
+	DefaultKeyFileMode os.FileMode = 0600
+
 	capiLocationCurrentUser  = "currentuser"
 	capiLocationLocalMachine = "localmachine"
 )
@@ -38,20 +53,31 @@ var validStoreNames = []string{"addressbook", "authroot", "certificateauthority"
 // Installation represents a location in which a certificate will be installed,
 // along with the format in which it will be installed
 type Installation struct {
-	AfterAction         string `yaml:"afterInstallAction,omitempty"`
-	BackupFiles         bool   `yaml:"backupFiles,omitempty"`
-	CAPIFriendlyName    string `yaml:"capiFriendlyName,omitempty"` // In a future version of vCert this will become REQUIRED!
-	CAPIIsNonExportable bool   `yaml:"capiIsNonExportable,omitempty"`
-	CAPILocation        string `yaml:"capiLocation,omitempty"` // This is an alias for Location
-	ChainFile           string `yaml:"chainFile,omitempty"`
-	File                string `yaml:"file,omitempty"`
-	InstallValidation   string `yaml:"installValidationAction,omitempty"`
-	JKSAlias            string `yaml:"jksAlias,omitempty"`
-	JKSPassword         string `yaml:"jksPassword,omitempty"`
-	KeyFile             string `yaml:"keyFile,omitempty"`
-	KeyPassword         string `yaml:"keyPassword,omitempty"`
+	AfterAction string `yaml:"afterInstallAction,omitempty"`
+	BackupFiles bool   `yaml:"backupFiles,omitempty"`
+	// Bundle lists the parts of the certificate concatenated in File, in order. Only valid for PEM format
+	Bundle              []string `yaml:"bundle,omitempty"`
+	CAPIFriendlyName    string   `yaml:"capiFriendlyName,omitempty"` // In a future version of vCert this will become REQUIRED!
+	CAPIIsNonExportable bool     `yaml:"capiIsNonExportable,omitempty"`
+	CAPILocation        string   `yaml:"capiLocation,omitempty"` // This is an alias for Location
+	ChainFile           string   `yaml:"chainFile,omitempty"`
+	File                string   `yaml:"file,omitempty"`
+	// FileMode is the octal mode of the installed files, e.g. "0640"
+	FileMode          string `yaml:"fileMode,omitempty"`
+	Group             string `yaml:"group,omitempty"`
+	InstallValidation string `yaml:"installValidationAction,omitempty"`
+	JKSAlias          string `yaml:"jksAlias,omitempty"`
+	JKSPassword       string `yaml:"jksPassword,omitempty"`
+	KeyFile           string `yaml:"keyFile,omitempty"`
+	// KeyFileMode is the octal mode of KeyFile, and of File when Bundle includes the key. FileMode doesn't apply
+	// to them, so that the private key isn't made readable by others unless requested
+	KeyFileMode string `yaml:"keyFileMode,omitempty"`
+	KeyPassword string `yaml:"keyPassword,omitempty"`
 	// Deprecated: Location is deprecated in favor of CAPILocation. It will be removed on a future release
-	Location     string             `yaml:"location,omitempty"`
+	Location string `yaml:"location,omitempty"`
+	// Notify lists the names of the handlers to queue when a certificate is installed
+	Notify       []string           `yaml:"notify,omitempty"`
+	Owner        string             `yaml:"owner,omitempty"`
 	P12Password  string             `yaml:"p12Password,omitempty"`
 	UseLegacyP12 bool               `yaml:"useLegacyP12,omitempty"`
 	Type         InstallationFormat `yaml:"format,omitempty"`
@@ -85,9 +111,50 @@ func (installation Installation) IsValid() (bool, error) {
 		return false, fmt.Errorf("\t\t\t%w", ErrUndefinedInstallationFormat)
 	}
 
+	if installation.Type != FormatCAPI {
+		if err := validateFileOptions(installation); err != nil {
+			return false, fmt.Errorf("\t\t\t%w", err)
+		}
+	}
+
 	return true, nil
 }
 
+// GetFileMode returns the mode of the installed files
+func (installation Installation) GetFileMode() (os.FileMode, error) {
+	return parseFileMode(installation.FileMode, util.DefaultFileMode)
+}
+
+// GetKeyFileMode returns the mode of the installed private key file
+func (installation Installation) GetKeyFileMode() (os.FileMode, error) {
+	return parseFileMode(installation.KeyFileMode, DefaultKeyFileMode)
+}
+
+func parseFileMode(value string, defaultMode os.FileMode) (os.FileMode, error) {
+	if value == "" {
+		return defaultMode, nil
+	}
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidFileMode, value)
+	}
+	return os.FileMode(mode), nil
+}
+
+func validateFileOptions(installation Installation) error {
+	if _, err := installation.GetFileMode(); err != nil {
+		return err
+	}
+	if _, err := installation.GetKeyFileMode(); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" && (installation.Owner != "" || installation.Group != "") {
+		return ErrOwnershipOnWindows
+	}
+	return nil
+}
+
 func validateCAPI(installation Installation) error {
 	if runtime.GOOS != "windows" {
 		return ErrCAPIOnNonWindows
@@ -143,11 +210,22 @@ func validateCAPI(installation Installation) error {
 	return nil
 }
 
+func validateNoBundle(installation Installation) error {
+	if len(installation.Bundle) > 0 {
+		return ErrBundleNotPEM
+	}
+	return nil
+}
+
 func validateJKS(installation Installation) error {
 	if installation.File == "" {
 		return ErrNoInstallationFile
 	}
 
+	if err := validateNoBundle(installation); err != nil {
+		return err
+	}
+
 	if installation.JKSAlias == "" {
 		return ErrNoJKSAlias
 	}
@@ -174,6 +252,11 @@ func validatePEM(installation Installation) error {
 		return ErrNoInstallationFile
 	}
 
+	// The chain and key may be in the bundle instead of their own files
+	if len(installation.Bundle) > 0 {
+		return validateBundle(installation.Bundle)
+	}
+
 	if installation.ChainFile == "" {
 		return ErrNoChainFile
 	}
@@ -183,10 +266,38 @@ func validatePEM(installation Installation) error {
 	return nil
 }
 
+func validateBundle(bundle []string) error {
+	seen := make(map[string]bool)
+	for _, part := range bundle {
+		switch part {
+		case BundleCertificate, BundleKey:
+		case BundleChain:
+			// The certificate is the first one found in the file when checking it for renewal
+			if !seen[BundleCertificate] {
+				return ErrBundleChainBeforeCertificate
+			}
+		default:
+			return fmt.Errorf("%w: %s", ErrInvalidBundlePart, part)
+		}
+		if seen[part] {
+			return fmt.Errorf("%w: %s", ErrDuplicateBundlePart, part)
+		}
+		seen[part] = true
+	}
+
+	if !seen[BundleCertificate] {
+		return ErrNoBundleCertificate
+	}
+	return nil
+}
+
 func validateP12(installation Installation) error {
 	if installation.File == "" {
 		return ErrNoInstallationFile
 	}
+	if err := validateNoBundle(installation); err != nil {
+		return err
+	}
 	if installation.P12Password == "" {
 		return ErrNoP12Password
 	}
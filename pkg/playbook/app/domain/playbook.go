@@ -19,6 +19,7 @@ package domain
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/Venafi/vcert/v5/pkg/venafi"
 )
@@ -35,10 +36,17 @@ const (
 // A task includes:
 //   - a Request object that defines the values of the certificate to request
 //   - a list of locations where the certificate will be installed
+//
+// SSH certificate tasks request SSH certificates from the SSH CA of Trust Protection Platform and install them along
+// with their key pairs.
+//
+// Handlers are actions queued by the installations and run once, after all the tasks are done.
 type Playbook struct {
-	CertificateTasks CertificateTasks `yaml:"certificateTasks,omitempty"`
-	Config           Config           `yaml:"config,omitempty"`
-	Location         string           `yaml:"-"`
+	CertificateTasks    CertificateTasks    `yaml:"certificateTasks,omitempty"`
+	SshCertificateTasks SshCertificateTasks `yaml:"sshCertificateTasks,omitempty"`
+	Config              Config              `yaml:"config,omitempty"`
+	Handlers            Handlers            `yaml:"handlers,omitempty"`
+	Location            string              `yaml:"-"`
 }
 
 // NewPlaybook returns a Playbook with some default values
@@ -67,7 +75,7 @@ func (p Playbook) IsValid() (bool, error) {
 	rValid = rValid && valid
 
 	// There is at least one task to execute
-	if len(p.CertificateTasks) < 1 {
+	if len(p.CertificateTasks) < 1 && len(p.SshCertificateTasks) < 1 {
 		rValid = false
 		rErr = errors.Join(rErr, ErrNoTasks)
 	}
@@ -90,6 +98,72 @@ func (p Playbook) IsValid() (bool, error) {
 		}
 	}
 
+	// The names of the SSH certificate tasks are unique among all the tasks
+	for _, t := range p.SshCertificateTasks {
+		if !taskNames[t.Name] {
+			taskNames[t.Name] = true
+		} else {
+			rErr = errors.Join(rErr, fmt.Errorf("task '%s' is defined multiple times", t.Name))
+			rValid = false
+		}
+
+		_, err := t.IsValid()
+		if err != nil {
+			rErr = errors.Join(rErr, fmt.Errorf("task '%s' is invalid: %w", t.Name, err))
+			rValid = false
+		}
+	}
+	if len(p.SshCertificateTasks) > 0 && p.Config.Connection.Platform != venafi.TPP && p.Config.Connection.Platform != venafi.Fake {
+		rErr = errors.Join(rErr, ErrSshTasksPlatform)
+		rValid = false
+	}
+
+	handlerNames := make(map[string]bool)
+	for i, h := range p.Handlers {
+		if h.Name != "" && handlerNames[h.Name] {
+			rErr = errors.Join(rErr, fmt.Errorf("handler '%s' is defined multiple times", h.Name))
+			rValid = false
+		}
+		handlerNames[h.Name] = true
+
+		_, err := h.IsValid()
+		if err != nil {
+			rErr = errors.Join(rErr, fmt.Errorf("handlers[%d] is invalid:\n%w", i, err))
+			rValid = false
+		}
+	}
+
+	// Check that dependencies and notifications refer to existing tasks and handlers
+	for _, t := range p.CertificateTasks {
+		for _, dep := range t.DependsOn {
+			if !taskNames[dep] || dep == t.Name {
+				rErr = errors.Join(rErr, fmt.Errorf("task '%s' is invalid: %w: '%s'", t.Name, ErrUnknownDependency, dep))
+				rValid = false
+			}
+		}
+		for _, inst := range t.Installations {
+			for _, h := range inst.Notify {
+				if !handlerNames[h] {
+					rErr = errors.Join(rErr, fmt.Errorf("task '%s' is invalid: %w: '%s'", t.Name, ErrUnknownHandler, h))
+					rValid = false
+				}
+			}
+		}
+	}
+	for _, t := range p.SshCertificateTasks {
+		for _, h := range t.Installation.Notify {
+			if !handlerNames[h] {
+				rErr = errors.Join(rErr, fmt.Errorf("task '%s' is invalid: %w: '%s'", t.Name, ErrUnknownHandler, h))
+				rValid = false
+			}
+		}
+	}
+
+	if cycle := p.CertificateTasks.dependencyCycle(); cycle != nil {
+		rErr = errors.Join(rErr, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, " -> ")))
+		rValid = false
+	}
+
 	return rValid, rErr
 
 }
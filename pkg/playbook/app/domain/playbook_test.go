@@ -135,6 +135,335 @@ func (s *PlaybookSuite) SetupTest() {
 				CertificateTasks: nil,
 			},
 		},
+		{
+			err:  ErrInvalidConcurrency,
+			name: "NegativeConcurrency",
+			pb: Playbook{
+				Config: Config{
+					Connection:  config.Connection,
+					Concurrency: -1,
+				},
+				CertificateTasks: CertificateTasks{
+					CertificateTask{
+						Name:    "testTask",
+						Request: req,
+						Installations: Installations{
+							Installation{
+								Type:      FormatPEM,
+								File:      "/foo/bar/cert.pem",
+								ChainFile: "/foo/bar/chain.pem",
+								KeyFile:   "/foo/bar/key.pem",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			err:  nil,
+			name: "PEMBundle",
+			pb: Playbook{
+				Config: config,
+				CertificateTasks: CertificateTasks{
+					CertificateTask{
+						Name:    "testTask",
+						Request: req,
+						Installations: Installations{
+							Installation{
+								Type:     FormatPEM,
+								File:     "/foo/bar/bundle.pem",
+								Bundle:   []string{"key", "certificate", "chain"},
+								FileMode: "0640",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			err:  ErrInvalidFileMode,
+			name: "InvalidFileMode",
+			pb: Playbook{
+				Config: config,
+				CertificateTasks: CertificateTasks{
+					CertificateTask{
+						Name:    "testTask",
+						Request: req,
+						Installations: Installations{
+							Installation{
+								Type:     FormatPEM,
+								File:     "/foo/bar/bundle.pem",
+								Bundle:   []string{"certificate"},
+								FileMode: "rw-r-----",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			err:  ErrInvalidFileMode,
+			name: "InvalidKeyFileMode",
+			pb: Playbook{
+				Config: config,
+				CertificateTasks: CertificateTasks{
+					CertificateTask{
+						Name:    "testTask",
+						Request: req,
+						Installations: Installations{
+							Installation{
+								Type:        FormatPEM,
+								File:        "/foo/bar/cert.pem",
+								ChainFile:   "/foo/bar/chain.pem",
+								KeyFile:     "/foo/bar/key.pem",
+								KeyFileMode: "0999",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			err:  ErrInvalidBundlePart,
+			name: "InvalidBundlePart",
+			pb: Playbook{
+				Config: config,
+				CertificateTasks: CertificateTasks{
+					CertificateTask{
+						Name:    "testTask",
+						Request: req,
+						Installations: Installations{
+							Installation{
+								Type:   FormatPEM,
+								File:   "/foo/bar/bundle.pem",
+								Bundle: []string{"certificate", "issuer"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			err:  ErrBundleChainBeforeCertificate,
+			name: "BundleChainBeforeCertificate",
+			pb: Playbook{
+				Config: config,
+				CertificateTasks: CertificateTasks{
+					CertificateTask{
+						Name:    "testTask",
+						Request: req,
+						Installations: Installations{
+							Installation{
+								Type:   FormatPEM,
+								File:   "/foo/bar/bundle.pem",
+								Bundle: []string{"chain", "certificate"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			err:  ErrBundleNotPEM,
+			name: "BundleNotPEM",
+			pb: Playbook{
+				Config: config,
+				CertificateTasks: CertificateTasks{
+					CertificateTask{
+						Name:    "testTask",
+						Request: req,
+						Installations: Installations{
+							Installation{
+								Type:        FormatPKCS12,
+								File:        "/foo/bar/cert.p12",
+								P12Password: "foobar",
+								Bundle:      []string{"certificate"},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			err:  nil,
+			name: "DependenciesAndHandlers",
+			pb: Playbook{
+				Config: config,
+				CertificateTasks: CertificateTasks{
+					CertificateTask{
+						Name:    "first",
+						Request: req,
+						Installations: Installations{
+							Installation{
+								Type:      FormatPEM,
+								File:      "/foo/bar/cert.pem",
+								ChainFile: "/foo/bar/chain.pem",
+								KeyFile:   "/foo/bar/key.pem",
+								Notify:    []string{"reload"},
+							},
+						},
+					},
+					CertificateTask{
+						Name:      "second",
+						Request:   req,
+						DependsOn: []string{"first"},
+						Installations: Installations{
+							Installation{
+								Type:      FormatPEM,
+								File:      "/foo/bar/cert.pem",
+								ChainFile: "/foo/bar/chain.pem",
+								KeyFile:   "/foo/bar/key.pem",
+								Notify:    []string{"reload"},
+							},
+						},
+					},
+				},
+				Handlers: Handlers{
+					{Name: "reload", Action: "echo reload"},
+				},
+			},
+		},
+		{
+			err:  ErrUnknownDependency,
+			name: "UnknownDependency",
+			pb: Playbook{
+				Config: config,
+				CertificateTasks: CertificateTasks{
+					CertificateTask{
+						Name:      "first",
+						Request:   req,
+						DependsOn: []string{"missing"},
+						Installations: Installations{
+							Installation{
+								Type:      FormatPEM,
+								File:      "/foo/bar/cert.pem",
+								ChainFile: "/foo/bar/chain.pem",
+								KeyFile:   "/foo/bar/key.pem",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			err:  ErrUnknownDependency,
+			name: "SelfDependency",
+			pb: Playbook{
+				Config: config,
+				CertificateTasks: CertificateTasks{
+					CertificateTask{
+						Name:      "first",
+						Request:   req,
+						DependsOn: []string{"first"},
+						Installations: Installations{
+							Installation{
+								Type:      FormatPEM,
+								File:      "/foo/bar/cert.pem",
+								ChainFile: "/foo/bar/chain.pem",
+								KeyFile:   "/foo/bar/key.pem",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			err:  ErrDependencyCycle,
+			name: "DependencyCycle",
+			pb: Playbook{
+				Config: config,
+				CertificateTasks: CertificateTasks{
+					CertificateTask{
+						Name:      "first",
+						Request:   req,
+						DependsOn: []string{"third"},
+						Installations: Installations{
+							Installation{
+								Type:      FormatPEM,
+								File:      "/foo/bar/cert.pem",
+								ChainFile: "/foo/bar/chain.pem",
+								KeyFile:   "/foo/bar/key.pem",
+							},
+						},
+					},
+					CertificateTask{
+						Name:      "second",
+						Request:   req,
+						DependsOn: []string{"first"},
+						Installations: Installations{
+							Installation{
+								Type:      FormatPEM,
+								File:      "/foo/bar/cert.pem",
+								ChainFile: "/foo/bar/chain.pem",
+								KeyFile:   "/foo/bar/key.pem",
+							},
+						},
+					},
+					CertificateTask{
+						Name:      "third",
+						Request:   req,
+						DependsOn: []string{"second"},
+						Installations: Installations{
+							Installation{
+								Type:      FormatPEM,
+								File:      "/foo/bar/cert.pem",
+								ChainFile: "/foo/bar/chain.pem",
+								KeyFile:   "/foo/bar/key.pem",
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			err:  ErrUnknownHandler,
+			name: "UnknownHandler",
+			pb: Playbook{
+				Config: config,
+				CertificateTasks: CertificateTasks{
+					CertificateTask{
+						Name:    "first",
+						Request: req,
+						Installations: Installations{
+							Installation{
+								Type:      FormatPEM,
+								File:      "/foo/bar/cert.pem",
+								ChainFile: "/foo/bar/chain.pem",
+								KeyFile:   "/foo/bar/key.pem",
+								Notify:    []string{"restart"},
+							},
+						},
+					},
+				},
+				Handlers: Handlers{
+					{Name: "reload", Action: "echo reload"},
+				},
+			},
+		},
+		{
+			err:  ErrNoHandlerAction,
+			name: "NoHandlerAction",
+			pb: Playbook{
+				Config: config,
+				CertificateTasks: CertificateTasks{
+					CertificateTask{
+						Name:    "first",
+						Request: req,
+						Installations: Installations{
+							Installation{
+								Type:      FormatPEM,
+								File:      "/foo/bar/cert.pem",
+								ChainFile: "/foo/bar/chain.pem",
+								KeyFile:   "/foo/bar/key.pem",
+							},
+						},
+					},
+				},
+				Handlers: Handlers{
+					{Name: "reload"},
+				},
+			},
+		},
 		{
 			err:  ErrNoRequestZone,
 			name: "NoRequestZone",
@@ -578,3 +907,49 @@ func (s *PlaybookSuite) TestPlaybook_IsValid() {
 		})
 	}
 }
+
+func (s *PlaybookSuite) TestPlaybook_IsValidSshTasks() {
+	config := Config{
+		Connection: Connection{
+			Platform: venafi.TPP,
+			URL:      "https://tpp.venafi.example",
+			Credentials: Authentication{
+				Authentication: endpoint.Authentication{AccessToken: "foobarGibberish123"},
+			},
+		},
+	}
+	task := SshCertificateTask{
+		Name:         "sshd",
+		Request:      SshCertificateRequest{Template: "Host CA", KeyType: "ED25519"},
+		Installation: SshInstallation{KeyFile: "/etc/ssh/ssh_host_ed25519_key", Notify: []string{"reload"}},
+	}
+	pb := Playbook{
+		Config:              config,
+		SshCertificateTasks: SshCertificateTasks{task},
+		Handlers:            Handlers{{Name: "reload", Action: "systemctl reload sshd"}},
+	}
+	_, err := pb.IsValid()
+	s.Require().NoError(err)
+	s.Equal("/etc/ssh/ssh_host_ed25519_key-cert.pub", task.Installation.CertificateFile())
+	s.Equal("sshd", task.KeyID())
+
+	invalid := task
+	invalid.Request = SshCertificateRequest{KeyType: "dsa"}
+	invalid.Installation.KeyFile = ""
+	pb.SshCertificateTasks = SshCertificateTasks{invalid}
+	_, err = pb.IsValid()
+	s.ErrorIs(err, ErrNoSshTemplate)
+	s.ErrorIs(err, ErrInvalidSshKeyType)
+	s.ErrorIs(err, ErrNoSshKeyFile)
+
+	pb.SshCertificateTasks = SshCertificateTasks{task}
+	pb.Handlers = nil
+	_, err = pb.IsValid()
+	s.ErrorIs(err, ErrUnknownHandler)
+
+	pb.Handlers = Handlers{{Name: "reload", Action: "systemctl reload sshd"}}
+	pb.Config.Connection.Platform = venafi.TLSPCloud
+	pb.Config.Connection.Credentials = Authentication{Authentication: endpoint.Authentication{APIKey: "foobarGibberish123"}}
+	_, err = pb.IsValid()
+	s.ErrorIs(err, ErrSshTasksPlatform)
+}
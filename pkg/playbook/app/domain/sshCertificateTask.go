@@ -0,0 +1,130 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/Venafi/vcert/v5/pkg/util"
+)
+
+const (
+	// SshPublicKeyFileSuffix is appended to SshInstallation.KeyFile to name the public key file
+	SshPublicKeyFileSuffix = ".pub"
+	// SshCertificateFileSuffix is appended to SshInstallation.KeyFile to name the certificate file
+	SshCertificateFileSuffix = "-cert.pub"
+)
+
+// SshCertificateTask represents an SSH certificate to be requested from the SSH CA of Trust Protection Platform and
+// installed along with its key pair. A new key pair is generated every time the certificate is requested
+type SshCertificateTask struct {
+	Name         string                `yaml:"name,omitempty"`
+	Request      SshCertificateRequest `yaml:"request,omitempty"`
+	Installation SshInstallation       `yaml:"installation,omitempty"`
+	RenewBefore  string                `yaml:"renewBefore,omitempty"`
+}
+
+// SshCertificateTasks is a slice of SshCertificateTask
+type SshCertificateTasks []SshCertificateTask
+
+// SshCertificateRequest contains the values of an SSH certificate request
+type SshCertificateRequest struct {
+	DestinationAddresses []string `yaml:"destinationAddresses,omitempty"`
+	// Extensions are either a name, e.g. permit-pty, or a name:value pair
+	Extensions   []string `yaml:"extensions,omitempty"`
+	Folder       string   `yaml:"folder,omitempty"`
+	ForceCommand string   `yaml:"forceCommand,omitempty"`
+	// KeyID identifies the certificate. The name of the task is used when not set
+	KeyID string `yaml:"keyId,omitempty"`
+	// KeySize is the size of an RSA key, or the curve size of an ECDSA key
+	KeySize int `yaml:"keySize,omitempty"`
+	// KeyType is the type of the generated key pair: rsa (the default), ecdsa or ed25519
+	KeyType         string   `yaml:"keyType,omitempty"`
+	ObjectName      string   `yaml:"objectName,omitempty"`
+	Principals      []string `yaml:"principals,omitempty"`
+	SourceAddresses []string `yaml:"sourceAddresses,omitempty"`
+	// Template is the SSH certificate issuing template that signs the certificate
+	Template      string `yaml:"template,omitempty"`
+	Timeout       int    `yaml:"timeout,omitempty"`
+	ValidityHours int    `yaml:"validHours,omitempty"`
+}
+
+// SshInstallation represents the files in which an SSH key pair and its certificate are installed. Following the
+// naming of ssh-keygen, the public key and the certificate are written next to the private key
+type SshInstallation struct {
+	AfterAction string `yaml:"afterInstallAction,omitempty"`
+	BackupFiles bool   `yaml:"backupFiles,omitempty"`
+	Group       string `yaml:"group,omitempty"`
+	// KeyFile is the private key file, e.g. /etc/ssh/ssh_host_ed25519_key
+	KeyFile string `yaml:"keyFile,omitempty"`
+	// KeyPassword encrypts the private key when set
+	KeyPassword string `yaml:"keyPassword,omitempty"`
+	// Notify lists the names of the handlers to queue when the certificate is installed
+	Notify []string `yaml:"notify,omitempty"`
+	Owner  string   `yaml:"owner,omitempty"`
+}
+
+// KeyID returns the identifier of the certificate requested by the task
+func (task SshCertificateTask) KeyID() string {
+	if task.Request.KeyID != "" {
+		return task.Request.KeyID
+	}
+	return task.Name
+}
+
+// PublicKeyFile returns the file of the public key, KeyFile.pub
+func (installation SshInstallation) PublicKeyFile() string {
+	return installation.KeyFile + SshPublicKeyFileSuffix
+}
+
+// CertificateFile returns the file of the certificate, KeyFile-cert.pub
+func (installation SshInstallation) CertificateFile() string {
+	return installation.KeyFile + SshCertificateFileSuffix
+}
+
+// IsValid returns true if the SshCertificateTask has the minimum required fields to be run
+func (task SshCertificateTask) IsValid() (bool, error) {
+	var rErr error = nil
+	rValid := true
+
+	if task.Request.Template == "" {
+		rValid = false
+		rErr = errors.Join(rErr, fmt.Errorf("\t\t%w", ErrNoSshTemplate))
+	}
+
+	switch strings.ToLower(task.Request.KeyType) {
+	case "", util.SshKeyTypeRSA, util.SshKeyTypeECDSA, util.SshKeyTypeED25519:
+	default:
+		rValid = false
+		rErr = errors.Join(rErr, fmt.Errorf("\t\t%w: %s", ErrInvalidSshKeyType, task.Request.KeyType))
+	}
+
+	if task.Installation.KeyFile == "" {
+		rValid = false
+		rErr = errors.Join(rErr, fmt.Errorf("\t\t%w", ErrNoSshKeyFile))
+	}
+
+	if runtime.GOOS == "windows" && (task.Installation.Owner != "" || task.Installation.Group != "") {
+		rValid = false
+		rErr = errors.Join(rErr, fmt.Errorf("\t\t%w", ErrOwnershipOnWindows))
+	}
+
+	return rValid, rErr
+}
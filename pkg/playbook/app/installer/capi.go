@@ -19,6 +19,7 @@
 package installer
 
 import (
+	"crypto/x509"
 	"fmt"
 	"strings"
 
@@ -48,6 +49,26 @@ func NewCAPIInstaller(inst domain.Installation) CAPIInstaller {
 func (r CAPIInstaller) Check(renewBefore string, request domain.PlaybookRequest) (bool, error) {
 	zap.L().Info("checking certificate health", zap.String("format", r.Type.String()), zap.String("location", r.CAPILocation))
 
+	cert, err := r.InstalledCertificate(request)
+	if err != nil {
+		return true, err
+	}
+
+	// Certificate was not found.
+	if cert == nil {
+		zap.L().Info("certificate not found")
+		return true, nil
+	}
+
+	// Check certificate expiration
+	renew := needRenewal(cert, renewBefore)
+
+	return renew, nil
+}
+
+// InstalledCertificate returns the certificate currently installed in the location of the Installer,
+// or nil if no certificate is installed there
+func (r CAPIInstaller) InstalledCertificate(request domain.PlaybookRequest) (*x509.Certificate, error) {
 	// Get friendly name. If no friendly name is set, get CN from request as friendly name.
 	//  NOTE: This functionality is deprecated, and in a future version will be removed, and CAPIFriendlyName will be req'd
 	friendlyName := r.CAPIFriendlyName
@@ -64,7 +85,7 @@ func (r CAPIInstaller) Check(renewBefore string, request domain.PlaybookRequest)
 	storeLocation, storeName, err := getCertStore(location)
 	if err != nil {
 		zap.L().Error("failed to get certificate store", zap.Error(err))
-		return true, err
+		return nil, err
 	}
 
 	config := capistore.InstallationConfig{
@@ -78,25 +99,15 @@ func (r CAPIInstaller) Check(renewBefore string, request domain.PlaybookRequest)
 	certPem, err := ps.RetrieveCertificateFromCAPI(config)
 	if err != nil {
 		zap.L().Error("failed to retrieve certificate from CAPI store", zap.Error(err))
-		return true, err
+		return nil, err
 	}
 
 	// Certificate was not found.
 	if certPem == "" {
-		zap.L().Info("certificate not found")
-		return true, nil
+		return nil, nil
 	}
 
-	// Check certificate expiration
-	cert, err := parsePEMCertificate([]byte(certPem))
-	if err != nil {
-		return false, err
-	}
-
-	// Check certificate expiration
-	renew := needRenewal(cert, renewBefore)
-
-	return renew, nil
+	return parsePEMCertificate([]byte(certPem))
 }
 
 // Backup takes the certificate request and backs up the current version prior to overwriting
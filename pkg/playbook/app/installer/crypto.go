@@ -136,11 +136,15 @@ func loadPEMCertificate(certFile string) (*x509.Certificate, error) {
 }
 
 func parsePEMCertificate(certData []byte) (*x509.Certificate, error) {
-	p, _ := pem.Decode(certData)
-	if p == nil {
-		return nil, fmt.Errorf("could not decode PEM data")
+	// A bundle may hold a private key before the certificate
+	var p *pem.Block
+	for {
+		p, certData = pem.Decode(certData)
+		if p == nil || p.Type == "CERTIFICATE" {
+			break
+		}
 	}
-	if p.Type != "CERTIFICATE" {
+	if p == nil {
 		return nil, fmt.Errorf("certificate data does not contain a certificate")
 	}
 
@@ -153,14 +157,48 @@ func parsePEMCertificate(certData []byte) (*x509.Certificate, error) {
 }
 
 func needRenewal(cert *x509.Certificate, renewBefore string) bool {
+	timeToRenew, enabled := RenewalDate(cert, renewBefore)
+
 	// if duration is 0 anything, then return false, auto-renewal is disabled
-	if renewBefore == "0" || strings.ToLower(renewBefore) == "disabled" {
+	if !enabled {
 		zap.L().Warn("certificate expiring soon but automatic renewal disabled",
 			zap.String("certificate", cert.Subject.CommonName),
 			zap.String("expirationDate", cert.NotAfter.String()))
 		return false
 	}
 
+	// Cert expired, renew
+	if cert.NotAfter.Before(time.Now()) {
+		zap.L().Debug("certificate is expired", zap.String("certificate", cert.Subject.CommonName))
+		return true
+	}
+
+	// Check certificate renew window
+	//Time now + renew window is bigger than cert expiration day? Then renew
+	if time.Now().After(timeToRenew) {
+		zap.L().Debug("certificate in renew window", zap.String("certificate", cert.Subject.CommonName))
+		return true
+	}
+
+	zap.L().Info(fmt.Sprintf("cert expires on %s and will auto-renew on %s", cert.NotAfter, timeToRenew),
+		zap.String("expirationDate", cert.NotAfter.String()), zap.String("renewDate", timeToRenew.String()))
+	return false
+}
+
+// RenewalDate returns the date from which cert is renewed according to renewBefore.
+// The returned bool is false when renewBefore disables the automatic renewal
+func RenewalDate(cert *x509.Certificate, renewBefore string) (time.Time, bool) {
+	return renewalDate(cert.NotBefore, cert.NotAfter, renewBefore)
+}
+
+// renewalDate returns the date from which a certificate valid from notBefore to notAfter is renewed according to
+// renewBefore
+func renewalDate(notBefore time.Time, notAfter time.Time, renewBefore string) (time.Time, bool) {
+	// if duration is 0 anything, then auto-renewal is disabled
+	if renewBefore == "0" || strings.ToLower(renewBefore) == "disabled" {
+		return time.Time{}, false
+	}
+
 	timePostfix := renewBefore[len(renewBefore)-1:]
 
 	renew := renewBefore[:len(renewBefore)-1]
@@ -173,18 +211,9 @@ func needRenewal(cert *x509.Certificate, renewBefore string) bool {
 		renewValue = 10
 	}
 
-	// if duration is 0 anything, then return false, auto-renewal is disabled
+	// if duration is 0 anything, then auto-renewal is disabled
 	if renewValue == 0 {
-		zap.L().Warn("certificate expiring soon but automatic renewal disabled",
-			zap.String("certificate", cert.Subject.CommonName),
-			zap.String("expirationDate", cert.NotAfter.String()))
-		return false
-	}
-
-	// Cert expired, renew
-	if cert.NotAfter.Before(time.Now()) {
-		zap.L().Debug("certificate is expired", zap.String("certificate", cert.Subject.CommonName))
-		return true
+		return time.Time{}, false
 	}
 
 	var timeToRenew time.Time
@@ -194,40 +223,31 @@ func needRenewal(cert *x509.Certificate, renewBefore string) bool {
 		// operation happens in integers to avoid issues with linter and time.Duration struct
 		ns := DayDuration.Nanoseconds()
 		renewDuration := time.Duration(ns * renewValue)
-		timeToRenew = cert.NotAfter.Add(-renewDuration)
+		timeToRenew = notAfter.Add(-renewDuration)
 	case "h":
 		ns := time.Hour.Nanoseconds()
 		renewDuration := time.Duration(ns * renewValue)
-		timeToRenew = cert.NotAfter.Add(-renewDuration)
+		timeToRenew = notAfter.Add(-renewDuration)
 	case "%":
 		// Total # of ns in the whole certificate lifetime
-		nsCertValidity := cert.NotAfter.Sub(cert.NotBefore).Nanoseconds()
+		nsCertValidity := notAfter.Sub(notBefore).Nanoseconds()
 
 		// if 10%, then renew when 90% of the validity time has elapsed
 		pct := float64(renewValue) / 100
 		renewDuration := time.Duration(float64(nsCertValidity) * pct)
-		timeToRenew = cert.NotAfter.Add(-renewDuration)
+		timeToRenew = notAfter.Add(-renewDuration)
 	default:
 		zap.L().Warn("unknown duration postfix. Valid postfixes are: d (Days) and h (Hours): Using default [10%]",
 			zap.String("postfix", timePostfix))
 		// Total # of ns in the whole certificate lifetime
-		nsCertValidity := cert.NotAfter.Sub(cert.NotBefore).Nanoseconds()
+		nsCertValidity := notAfter.Sub(notBefore).Nanoseconds()
 
 		// TODO: Respect global DefaultRenewal
 		renewDuration := time.Duration(float64(nsCertValidity) * float64(0.10))
-		timeToRenew = cert.NotAfter.Add(-renewDuration)
-	}
-
-	// Check certificate renew window
-	//Time now + renew window is bigger than cert expiration day? Then renew
-	if time.Now().After(timeToRenew) {
-		zap.L().Debug("certificate in renew window", zap.String("certificate", cert.Subject.CommonName))
-		return true
+		timeToRenew = notAfter.Add(-renewDuration)
 	}
 
-	zap.L().Info(fmt.Sprintf("cert expires on %s and will auto-renew on %s", cert.NotAfter, timeToRenew),
-		zap.String("expirationDate", cert.NotAfter.String()), zap.String("renewDate", timeToRenew.String()))
-	return false
+	return timeToRenew, true
 }
 
 // CreateX509Cert takes a PEMCollection and creates an x509.Certificate object from it
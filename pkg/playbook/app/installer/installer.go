@@ -17,8 +17,11 @@
 package installer
 
 import (
+	"crypto/x509"
+
 	"github.com/Venafi/vcert/v5/pkg/certificate"
 	"github.com/Venafi/vcert/v5/pkg/playbook/app/domain"
+	"github.com/Venafi/vcert/v5/pkg/playbook/util"
 )
 
 // Installer represents the interface for all installers.
@@ -31,6 +34,10 @@ type Installer interface {
 	// Returns true if the certificate needs to be installed.
 	Check(renewBefore string, request domain.PlaybookRequest) (bool, error)
 
+	// InstalledCertificate returns the certificate currently installed in the location of the Installer,
+	// or nil if no certificate is installed there
+	InstalledCertificate(request domain.PlaybookRequest) (*x509.Certificate, error)
+
 	// Backup takes the certificate request and backs up the current version prior to overwriting
 	Backup() error
 
@@ -47,3 +54,16 @@ type Installer interface {
 	// No validations happen over the content of the InstallValidation string, so caution is advised
 	InstallValidationActions() (string, error)
 }
+
+// fileOptions returns the permissions and ownership of the files written by the installation
+func fileOptions(inst domain.Installation) (util.FileOptions, error) {
+	mode, err := inst.GetFileMode()
+	if err != nil {
+		return util.FileOptions{}, err
+	}
+	return util.FileOptions{
+		Mode:  mode,
+		Owner: inst.Owner,
+		Group: inst.Group,
+	}, nil
+}
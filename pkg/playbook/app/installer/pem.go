@@ -17,7 +17,9 @@
 package installer
 
 import (
+	"crypto/x509"
 	"fmt"
+	"slices"
 	"strings"
 
 	"go.uber.org/zap"
@@ -42,28 +44,42 @@ func NewPEMInstaller(inst domain.Installation) PEMInstaller {
 // 1. Does the certificate exists? > Install if it doesn't.
 // 2. Does the certificate is about to expire? Renew if about to expire.
 // Returns true if the certificate needs to be installed.
-func (r PEMInstaller) Check(renewBefore string, _ domain.PlaybookRequest) (bool, error) {
+func (r PEMInstaller) Check(renewBefore string, request domain.PlaybookRequest) (bool, error) {
 	zap.L().Info("checking certificate health", zap.String("format", r.Type.String()), zap.String("location", r.File))
 
+	cert, err := r.InstalledCertificate(request)
+	if err != nil {
+		return false, err
+	}
+	if cert == nil {
+		return true, nil
+	}
+
+	// Check certificate expiration
+	renew := needRenewal(cert, renewBefore)
+
+	return renew, nil
+}
+
+// InstalledCertificate returns the certificate currently installed in the location of the Installer,
+// or nil if no certificate is installed there
+func (r PEMInstaller) InstalledCertificate(_ domain.PlaybookRequest) (*x509.Certificate, error) {
 	// Check certificate bundle file exists
 	certExists, err := util.FileExists(r.File)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	if !certExists {
-		return true, nil
+		return nil, nil
 	}
 
 	// Load Certificate
 	cert, err := loadPEMCertificate(r.File)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	// Check certificate expiration
-	renew := needRenewal(cert, renewBefore)
-
-	return renew, nil
+	return cert, nil
 }
 
 // Backup takes the certificate request and backs up the current version prior to overwriting
@@ -114,10 +130,17 @@ func (r PEMInstaller) Backup() error {
 func (r PEMInstaller) Install(pcc certificate.PEMCollection) error {
 	zap.L().Debug("installing certificate", zap.String("location", r.File))
 
-	//TODO: should we add support for PEM bundle?
+	opts, err := fileOptions(r.Installation)
+	if err != nil {
+		return err
+	}
+	keyOpts := opts
+	keyOpts.Mode, err = r.GetKeyFileMode()
+	if err != nil {
+		return err
+	}
 
 	preppedPK := pcc.PrivateKey
-	var err error
 	// Needs to be encrypted again using legacy PEM
 	if r.KeyPassword != "" {
 		preppedPK, err = vcertutil.EncryptPrivateKeyPKCS1(pcc.PrivateKey, r.KeyPassword)
@@ -127,20 +150,46 @@ func (r PEMInstaller) Install(pcc certificate.PEMCollection) error {
 		}
 	}
 
+	parts := map[string]string{
+		domain.BundleCertificate: pcc.Certificate,
+		domain.BundleChain:       strings.Join(pcc.Chain, ""),
+		domain.BundleKey:         preppedPK,
+	}
+
+	certContent := pcc.Certificate
+	certOpts := opts
+	if len(r.Bundle) > 0 {
+		var bundle strings.Builder
+		for _, part := range r.Bundle {
+			content := parts[part]
+			bundle.WriteString(content)
+			if content != "" && !strings.HasSuffix(content, "\n") {
+				bundle.WriteString("\n")
+			}
+		}
+		certContent = bundle.String()
+		// A bundle holding the private key is installed as the key file
+		if slices.Contains(r.Bundle, domain.BundleKey) {
+			certOpts = keyOpts
+		}
+	}
+
 	resources := []struct {
 		path    string
 		content []byte
+		opts    util.FileOptions
 	}{
-		{path: r.File, content: []byte(pcc.Certificate)},
-		{path: r.KeyFile, content: []byte(preppedPK)},
-		{path: r.ChainFile, content: []byte(strings.Join(pcc.Chain, ""))},
+		{path: r.File, content: []byte(certContent), opts: certOpts},
+		{path: r.KeyFile, content: []byte(preppedPK), opts: keyOpts},
+		{path: r.ChainFile, content: []byte(parts[domain.BundleChain]), opts: opts},
 	}
 
 	for _, resource := range resources {
-		if len(resource.content) == 0 {
+		// The key and chain files are optional when a bundle is installed
+		if resource.path == "" || len(resource.content) == 0 {
 			continue
 		}
-		err = util.WriteFile(resource.path, resource.content)
+		err = util.WriteFileWithOptions(resource.path, resource.content, resource.opts)
 		if err != nil {
 			return err
 		}
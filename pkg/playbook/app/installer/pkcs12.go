@@ -44,28 +44,42 @@ func NewPKCS12Installer(inst domain.Installation) PKCS12Installer {
 // 1. Does the certificate exists? > Install if it doesn't.
 // 2. Does the certificate is about to expire? Renew if about to expire.
 // Returns true if the certificate needs to be installed.
-func (r PKCS12Installer) Check(renewBefore string, _ domain.PlaybookRequest) (bool, error) {
+func (r PKCS12Installer) Check(renewBefore string, request domain.PlaybookRequest) (bool, error) {
 	zap.L().Info("checking certificate health", zap.String("format", r.Type.String()), zap.String("location", r.File))
 
+	cert, err := r.InstalledCertificate(request)
+	if err != nil {
+		return false, err
+	}
+	if cert == nil {
+		return true, nil
+	}
+
+	// Check certificate expiration
+	renew := needRenewal(cert, renewBefore)
+
+	return renew, nil
+}
+
+// InstalledCertificate returns the certificate currently installed in the location of the Installer,
+// or nil if no certificate is installed there
+func (r PKCS12Installer) InstalledCertificate(_ domain.PlaybookRequest) (*x509.Certificate, error) {
 	// Check certificate file exists
 	certExists, err := util.FileExists(r.File)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	if !certExists {
-		return true, nil
+		return nil, nil
 	}
 
 	// Load Certificate
 	cert, err := loadPKCS12(r.File, r.P12Password)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	// Check certificate expiration
-	renew := needRenewal(cert, renewBefore)
-
-	return renew, nil
+	return cert, nil
 }
 
 // Backup takes the certificate request and backs up the current version prior to overwriting
@@ -107,7 +121,12 @@ func (r PKCS12Installer) Install(pcc certificate.PEMCollection) error {
 		return err
 	}
 
-	err = util.WriteFile(r.File, content)
+	opts, err := fileOptions(r.Installation)
+	if err != nil {
+		return err
+	}
+
+	err = util.WriteFileWithOptions(r.File, content, opts)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,122 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package installer
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+	"github.com/Venafi/vcert/v5/pkg/playbook/app/domain"
+	"github.com/Venafi/vcert/v5/pkg/playbook/util"
+)
+
+const (
+	// sshPrivateKeyFileMode is the mode of the private key file, as required by ssh and sshd
+	sshPrivateKeyFileMode os.FileMode = 0600
+	// sshPublicFileMode is the mode of the public key and certificate files
+	sshPublicFileMode os.FileMode = 0644
+)
+
+// SshInstaller installs an SSH key pair and its certificate, following the naming of ssh-keygen
+type SshInstaller struct {
+	domain.SshInstallation
+}
+
+// NewSshInstaller returns a new installer of SSH certificates with the values defined in inst
+func NewSshInstaller(inst domain.SshInstallation) SshInstaller {
+	return SshInstaller{inst}
+}
+
+// InstalledCertificate returns the SSH certificate currently installed, or nil if no certificate is installed
+func (r SshInstaller) InstalledCertificate() (*ssh.Certificate, error) {
+	certFile := r.CertificateFile()
+	certExists, err := util.FileExists(certFile)
+	if err != nil {
+		return nil, err
+	}
+	if !certExists {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH certificate %s: %w", certFile, err)
+	}
+	return certificate.ParseSshCertificate(data)
+}
+
+// Backup copies the installed key pair and certificate to .bak files prior to overwriting them
+func (r SshInstaller) Backup() error {
+	zap.L().Debug("backing up SSH certificate", zap.String("location", r.KeyFile))
+
+	for _, file := range []string{r.KeyFile, r.PublicKeyFile(), r.CertificateFile()} {
+		fileExists, err := util.FileExists(file)
+		if err != nil {
+			return err
+		} else if !fileExists {
+			zap.L().Info(fmt.Sprintf("file %s does not exist, no backup taken", file))
+			continue
+		}
+		err = util.CopyFile(file, fmt.Sprintf("%s.bak", file))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Install writes the private key, the public key and the certificate. The private key is only readable by its owner
+func (r SshInstaller) Install(privateKey []byte, publicKey []byte, cert []byte) error {
+	files := []struct {
+		location string
+		content  []byte
+		mode     os.FileMode
+	}{
+		{location: r.KeyFile, content: privateKey, mode: sshPrivateKeyFileMode},
+		{location: r.PublicKeyFile(), content: publicKey, mode: sshPublicFileMode},
+		{location: r.CertificateFile(), content: cert, mode: sshPublicFileMode},
+	}
+
+	for _, f := range files {
+		err := util.WriteFileWithOptions(f.location, f.content, util.FileOptions{Mode: f.mode, Owner: r.Owner, Group: r.Group})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AfterInstallActions runs the AfterAction of the installation on a terminal, e.g. to reload sshd.
+//
+// No validations happen over the content of the AfterAction string, so caution is advised
+func (r SshInstaller) AfterInstallActions() (string, error) {
+	return util.ExecuteScript(r.AfterAction)
+}
+
+// SshRenewalDate returns the date from which cert is renewed according to renewBefore.
+// The returned bool is false when renewBefore disables the automatic renewal, or when cert never expires
+func SshRenewalDate(cert *ssh.Certificate, renewBefore string) (time.Time, bool) {
+	if cert.ValidBefore == ssh.CertTimeInfinity {
+		return time.Time{}, false
+	}
+	return renewalDate(time.Unix(int64(cert.ValidAfter), 0), time.Unix(int64(cert.ValidBefore), 0), renewBefore)
+}
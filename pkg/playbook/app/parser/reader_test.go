@@ -91,6 +91,16 @@ func (s *ReaderSuite) TestReader_ReadPlaybook() {
 	})
 }
 
+func (s *ReaderSuite) TestReader_ReadPlaybookFakeInventory() {
+	location := filepath.Join(s.T().TempDir(), "fake.yaml")
+	err := os.WriteFile(location, []byte("config:\n  connection:\n    platform: fake\n    inventoryPath: /tmp/vcert-inventory.json\n"), 0600)
+	s.Require().NoError(err)
+
+	pb, err := ReadPlaybook(location)
+	s.Require().NoError(err)
+	s.Equal("/tmp/vcert-inventory.json", pb.Config.Connection.InventoryPath)
+}
+
 func (s *ReaderSuite) TestReader_ReadPlaybookTpl() {
 	pb, err := ReadPlaybook(filepath.Join(s.playbookFolder, "sample_tpl.yaml"))
 	s.Nil(err)
@@ -0,0 +1,478 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Venafi/vcert/v5/pkg/playbook/app/domain"
+	"github.com/Venafi/vcert/v5/pkg/playbook/app/installer"
+	"github.com/Venafi/vcert/v5/pkg/venafi"
+)
+
+// Default values of DaemonOptions
+const (
+	DefaultMinBackoff    = time.Minute
+	DefaultMaxBackoff    = time.Hour
+	DefaultMaxSleep      = time.Hour
+	DefaultWatchInterval = 10 * time.Second
+)
+
+// DaemonOptions configures a Daemon
+type DaemonOptions struct {
+	// Load reads and validates the playbook. It is called on start and on every reload
+	Load func() (domain.Playbook, error)
+	// WatchFile is the playbook file. The playbook is reloaded when it changes. Empty disables the watch
+	WatchFile     string
+	WatchInterval time.Duration
+	// MinBackoff and MaxBackoff bound the delay before a failed task is retried. The delay doubles on each failure
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxSleep bounds the time between two checks of the installed certificates, so that certificates removed or
+	// replaced outside the daemon are noticed
+	MaxSleep time.Duration
+}
+
+// TaskStatus is the state of a certificate or SSH certificate task run by a Daemon
+type TaskStatus struct {
+	Task      string     `json:"task"`
+	NextRun   *time.Time `json:"nextRun,omitempty"`
+	LastRun   *time.Time `json:"lastRun,omitempty"`
+	LastError string     `json:"lastError,omitempty"`
+	Failures  int        `json:"failures"`
+}
+
+// DaemonStatus is the state of a Daemon, as served by its health endpoint
+type DaemonStatus struct {
+	// Healthy is false when the last reload failed or a task is failing
+	Healthy   bool         `json:"healthy"`
+	LoadedAt  time.Time    `json:"loadedAt"`
+	LoadError string       `json:"loadError,omitempty"`
+	Tasks     []TaskStatus `json:"tasks"`
+}
+
+// Daemon keeps a playbook in memory and runs each of its tasks when the installed certificates are due for renewal
+type Daemon struct {
+	opts   DaemonOptions
+	reload chan struct{}
+
+	mu       sync.Mutex
+	playbook domain.Playbook
+	loadedAt time.Time
+	loadErr  error
+	tasks    map[string]*TaskStatus
+	// retryAt holds when the failed tasks are retried
+	retryAt map[string]time.Time
+
+	watchedModTime time.Time
+	watchedSize    int64
+}
+
+// NewDaemon returns a Daemon with opts. The zero values of opts are replaced by the defaults
+func NewDaemon(opts DaemonOptions) *Daemon {
+	if opts.WatchInterval <= 0 {
+		opts.WatchInterval = DefaultWatchInterval
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = DefaultMinBackoff
+	}
+	if opts.MaxBackoff < opts.MinBackoff {
+		opts.MaxBackoff = DefaultMaxBackoff
+		if opts.MaxBackoff < opts.MinBackoff {
+			opts.MaxBackoff = opts.MinBackoff
+		}
+	}
+	if opts.MaxSleep <= 0 {
+		opts.MaxSleep = DefaultMaxSleep
+	}
+	return &Daemon{
+		opts:    opts,
+		reload:  make(chan struct{}, 1),
+		tasks:   make(map[string]*TaskStatus),
+		retryAt: make(map[string]time.Time),
+	}
+}
+
+// Reload asks the running daemon to load the playbook again. It does not block
+func (d *Daemon) Reload() {
+	select {
+	case d.reload <- struct{}{}:
+	default:
+	}
+}
+
+// Run loads the playbook and runs its tasks whenever they are due, until ctx is done.
+// An error is only returned when the playbook cannot be loaded on start
+func (d *Daemon) Run(ctx context.Context) error {
+	playbook, err := d.opts.Load()
+	if err != nil {
+		return err
+	}
+	d.setPlaybook(playbook)
+	d.watchedModTime, d.watchedSize = d.statWatchFile()
+
+	var watch <-chan time.Time
+	if d.opts.WatchFile != "" {
+		ticker := time.NewTicker(d.opts.WatchInterval)
+		defer ticker.Stop()
+		watch = ticker.C
+	}
+
+	for {
+		d.runDueTasks()
+
+		wait := time.Until(d.schedule())
+		if wait > d.opts.MaxSleep {
+			wait = d.opts.MaxSleep
+		}
+		zap.L().Info("waiting for the next renewal", zap.Duration("wait", wait))
+		timer := time.NewTimer(wait)
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			case <-timer.C:
+				break wait
+			case <-d.reload:
+				zap.L().Info("reloading playbook")
+				d.load()
+				timer.Stop()
+				break wait
+			case <-watch:
+				if !d.watchFileChanged() {
+					continue
+				}
+				zap.L().Info("playbook file changed, reloading", zap.String("file", d.opts.WatchFile))
+				d.load()
+				timer.Stop()
+				break wait
+			}
+		}
+	}
+}
+
+// Status returns the current state of the daemon
+func (d *Daemon) Status() DaemonStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	status := DaemonStatus{
+		Healthy:  d.loadErr == nil,
+		LoadedAt: d.loadedAt,
+		Tasks:    make([]TaskStatus, 0, len(d.tasks)),
+	}
+	if d.loadErr != nil {
+		status.LoadError = d.loadErr.Error()
+	}
+	for _, t := range daemonTasks(d.playbook) {
+		ts := *d.tasks[t.name]
+		if ts.Failures > 0 {
+			status.Healthy = false
+		}
+		status.Tasks = append(status.Tasks, ts)
+	}
+	return status
+}
+
+// Playbook returns the playbook last loaded by the daemon
+func (d *Daemon) Playbook() domain.Playbook {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.playbook
+}
+
+// ServeHTTP serves the status of the daemon as JSON, with status 503 when it is not healthy
+func (d *Daemon) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	status := d.Status()
+	w.Header().Set("Content-Type", "application/json")
+	if !status.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(status)
+}
+
+func (d *Daemon) load() {
+	playbook, err := d.opts.Load()
+	d.watchedModTime, d.watchedSize = d.statWatchFile()
+	if err != nil {
+		zap.L().Error("failed to reload playbook, keeping the previous one", zap.Error(err))
+		d.mu.Lock()
+		d.loadErr = err
+		d.mu.Unlock()
+		return
+	}
+	d.setPlaybook(playbook)
+}
+
+func (d *Daemon) setPlaybook(playbook domain.Playbook) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// A forced renewal only applies to the first load
+	if !d.loadedAt.IsZero() {
+		playbook.Config.ForceRenew = false
+	}
+
+	// The status of the tasks kept through the reload is preserved, but failed tasks are retried right away
+	// as the reload may have fixed them
+	all := daemonTasks(playbook)
+	tasks := make(map[string]*TaskStatus, len(all))
+	for _, t := range all {
+		ts, ok := d.tasks[t.name]
+		if !ok {
+			ts = &TaskStatus{Task: t.name}
+		}
+		tasks[t.name] = ts
+	}
+
+	d.playbook = playbook
+	d.tasks = tasks
+	d.retryAt = make(map[string]time.Time)
+	d.loadedAt = time.Now()
+	d.loadErr = nil
+	zap.L().Info("playbook loaded", zap.Int("tasks", len(all)))
+}
+
+// daemonTask is a task of any kind of the playbook, along with the function returning its next renewal
+type daemonTask struct {
+	name        string
+	nextRenewal func() (time.Time, error)
+}
+
+// daemonTasks returns the certificate tasks of playbook followed by its SSH certificate tasks
+func daemonTasks(playbook domain.Playbook) []daemonTask {
+	tasks := make([]daemonTask, 0, len(playbook.CertificateTasks)+len(playbook.SshCertificateTasks))
+	for _, t := range playbook.CertificateTasks {
+		task := t
+		tasks = append(tasks, daemonTask{name: task.Name, nextRenewal: func() (time.Time, error) { return NextRenewal(task) }})
+	}
+	for _, t := range playbook.SshCertificateTasks {
+		task := t
+		tasks = append(tasks, daemonTask{name: task.Name, nextRenewal: func() (time.Time, error) { return NextSshRenewal(task) }})
+	}
+	return tasks
+}
+
+// schedule computes the next run of each task and returns the earliest one
+func (d *Daemon) schedule() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	earliest := now.Add(d.opts.MaxSleep)
+	for _, t := range daemonTasks(d.playbook) {
+		next, err := d.nextRun(t, now)
+		ts := d.tasks[t.name]
+		if err != nil {
+			ts.LastError = err.Error()
+		}
+		if next.IsZero() {
+			ts.NextRun = nil
+			continue
+		}
+		ts.NextRun = &next
+		if next.Before(earliest) {
+			earliest = next
+		}
+	}
+	return earliest
+}
+
+// nextRun returns when the task should run next, or the zero time if it never needs to. It must be called with the
+// lock held
+func (d *Daemon) nextRun(task daemonTask, now time.Time) (time.Time, error) {
+	if retry, ok := d.retryAt[task.name]; ok {
+		return retry, nil
+	}
+	next, err := task.nextRenewal()
+	if err != nil {
+		// The certificate cannot be checked: this is retried as a failure
+		return now, err
+	}
+	return next, nil
+}
+
+// NextRenewal returns the earliest date on which a certificate installed by the task is due for renewal,
+// according to the task's renewBefore. It returns the current time when a certificate is missing,
+// and the zero time when the renewal of all the installations is disabled
+func NextRenewal(task domain.CertificateTask) (time.Time, error) {
+	renewBefore := DefaultRenew
+	if task.RenewBefore != "" {
+		renewBefore = task.RenewBefore
+	}
+
+	var next time.Time
+	for _, inst := range task.Installations {
+		cert, err := installer.GetInstaller(inst).InstalledCertificate(task.Request)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("error checking for certificate %s: %w", task.Name, err)
+		}
+		if cert == nil {
+			return time.Now(), nil
+		}
+		renewal, enabled := installer.RenewalDate(cert, renewBefore)
+		if !enabled {
+			continue
+		}
+		if next.IsZero() || renewal.Before(next) {
+			next = renewal
+		}
+	}
+	return next, nil
+}
+
+// runDueTasks runs the tasks whose next run is past, then the handlers they notified
+func (d *Daemon) runDueTasks() {
+	d.mu.Lock()
+	playbook := d.playbook
+	now := time.Now()
+	dueTasks := make(map[string]daemonTask)
+	for _, t := range daemonTasks(playbook) {
+		if playbook.Config.ForceRenew {
+			dueTasks[t.name] = t
+			continue
+		}
+		next, err := d.nextRun(t, now)
+		if err != nil {
+			zap.L().Error("error checking task", zap.String("task", t.name), zap.Error(err))
+			d.failed(t.name, err, now)
+			continue
+		}
+		if !next.IsZero() && !next.After(time.Now()) {
+			dueTasks[t.name] = t
+		}
+	}
+	d.mu.Unlock()
+
+	if len(dueTasks) == 0 {
+		return
+	}
+	due := make(domain.CertificateTasks, 0)
+	for _, t := range playbook.CertificateTasks {
+		if _, ok := dueTasks[t.Name]; ok {
+			due = append(due, t)
+		}
+	}
+	dueSsh := make(domain.SshCertificateTasks, 0)
+	for _, t := range playbook.SshCertificateTasks {
+		if _, ok := dueTasks[t.Name]; ok {
+			dueSsh = append(dueSsh, t)
+		}
+	}
+	zap.L().Info("running due tasks", zap.Int("tasks", len(dueTasks)))
+
+	if playbook.Config.Connection.Platform == venafi.TPP {
+		err := ValidateTPPCredentials(&playbook)
+		if err != nil {
+			zap.L().Error("invalid tpp credentials", zap.Error(err))
+			d.mu.Lock()
+			for name := range dueTasks {
+				d.failed(name, err, now)
+			}
+			d.mu.Unlock()
+			return
+		}
+		// Keep the refreshed tokens for the next runs
+		d.mu.Lock()
+		d.playbook.Config.Connection.Credentials = playbook.Config.Connection.Credentials
+		d.mu.Unlock()
+	}
+
+	results, handlerResults := ExecutePlaybookTasks(playbook.Config, due, dueSsh, playbook.Handlers)
+	for _, result := range handlerResults {
+		if result.Err != nil {
+			zap.L().Error("error running handler", zap.String("handler", result.Handler), zap.Error(result.Err))
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// A forced renewal only applies to the first run
+	d.playbook.Config.ForceRenew = false
+	for _, result := range results {
+		if len(result.Errors) > 0 {
+			err := errors.Join(result.Errors...)
+			zap.L().Error("error running task", zap.String("task", result.Task), zap.Error(err))
+			d.failed(result.Task, err, now)
+			continue
+		}
+
+		// A certificate still due right after its renewal would run the task in a loop
+		next, err := dueTasks[result.Task].nextRenewal()
+		if err == nil && !next.IsZero() && !next.After(time.Now()) {
+			err = fmt.Errorf("certificate is still due for renewal after being renewed. Check the renewBefore of the task against the validity of the issued certificate")
+		}
+		if err != nil {
+			zap.L().Error("error running task", zap.String("task", result.Task), zap.Error(err))
+			d.failed(result.Task, err, now)
+			continue
+		}
+
+		ts := d.tasks[result.Task]
+		ts.LastRun = &now
+		ts.LastError = ""
+		ts.Failures = 0
+		delete(d.retryAt, result.Task)
+	}
+}
+
+// failed records that the task failed at now, and schedules its retry. It must be called with the lock held
+func (d *Daemon) failed(task string, err error, now time.Time) {
+	ts := d.tasks[task]
+	ts.LastRun = &now
+	ts.LastError = err.Error()
+	ts.Failures++
+
+	backoff := d.opts.MinBackoff
+	for i := 1; i < ts.Failures && backoff < d.opts.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > d.opts.MaxBackoff {
+		backoff = d.opts.MaxBackoff
+	}
+	d.retryAt[task] = now.Add(backoff)
+	zap.L().Info("task will be retried", zap.String("task", task), zap.Int("failures", ts.Failures),
+		zap.Duration("backoff", backoff))
+}
+
+func (d *Daemon) statWatchFile() (time.Time, int64) {
+	if d.opts.WatchFile == "" {
+		return time.Time{}, 0
+	}
+	info, err := os.Stat(d.opts.WatchFile)
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return info.ModTime(), info.Size()
+}
+
+func (d *Daemon) watchFileChanged() bool {
+	modTime, size := d.statWatchFile()
+	return !modTime.Equal(d.watchedModTime) || size != d.watchedSize
+}
@@ -0,0 +1,472 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/Venafi/vcert/v5/pkg/playbook/app/domain"
+	"github.com/Venafi/vcert/v5/pkg/playbook/app/installer"
+	"github.com/Venafi/vcert/v5/pkg/venafi"
+)
+
+// MetricsContentType is the content type of the Prometheus text exposition format served by Exporter
+const MetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Metrics is notified of the outcome of the tasks run by Execute, ExecuteSsh, ExecuteTasks and ExecutePlaybookTasks
+type Metrics interface {
+	// TaskFinished is called when a task is done, with the errors it failed with
+	TaskFinished(task string, errs []error)
+	// EnrollmentFinished is called after each certificate request to the platform
+	EnrollmentFinished(platform venafi.Platform, latency time.Duration, err error)
+}
+
+type noMetrics struct{}
+
+func (noMetrics) TaskFinished(string, []error) {}
+
+func (noMetrics) EnrollmentFinished(venafi.Platform, time.Duration, error) {}
+
+var (
+	metricsMu sync.RWMutex
+	metrics   Metrics = noMetrics{}
+)
+
+// SetMetrics sets the Metrics notified by Execute and ExecuteTasks. A nil m disables the notifications
+func SetMetrics(m Metrics) {
+	if m == nil {
+		m = noMetrics{}
+	}
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+	metrics = m
+}
+
+func currentMetrics() Metrics {
+	metricsMu.RLock()
+	defer metricsMu.RUnlock()
+	return metrics
+}
+
+// configMetrics returns the Metrics notified of the tasks run with config: the ones set with SetMetrics and, when the
+// config has a metrics file, the file
+func configMetrics(config domain.Config) Metrics {
+	if config.MetricsFile == "" {
+		return currentMetrics()
+	}
+	return multiMetrics{currentMetrics(), metricsFile(config.MetricsFile)}
+}
+
+type multiMetrics []Metrics
+
+func (mm multiMetrics) TaskFinished(task string, errs []error) {
+	for _, m := range mm {
+		m.TaskFinished(task, errs)
+	}
+}
+
+func (mm multiMetrics) EnrollmentFinished(platform venafi.Platform, latency time.Duration, err error) {
+	for _, m := range mm {
+		m.EnrollmentFinished(platform, latency, err)
+	}
+}
+
+// metricsFileMode is the mode of the metrics file, which the exporter may read as another user
+const metricsFileMode os.FileMode = 0644
+
+// metricsFileMu serializes the updates of the metrics files by the tasks run in parallel
+var metricsFileMu sync.Mutex
+
+// metricsFile is a Metrics that keeps the outcome of the tasks and of the certificate requests in a file, so that
+// an Exporter run by another process, such as vcert exporter, serves them
+type metricsFile string
+
+func (f metricsFile) TaskFinished(task string, errs []error) {
+	f.update(func(state *metricsState) {
+		state.taskFinished(task, errs)
+	})
+}
+
+func (f metricsFile) EnrollmentFinished(platform venafi.Platform, latency time.Duration, err error) {
+	f.update(func(state *metricsState) {
+		state.enrollmentFinished(platform, latency, err)
+	})
+}
+
+// update applies fn to the state of the file. A failure is only logged, as it must not fail the task
+func (f metricsFile) update(fn func(state *metricsState)) {
+	metricsFileMu.Lock()
+	defer metricsFileMu.Unlock()
+
+	state, err := readMetricsFile(string(f))
+	if err != nil {
+		zap.L().Warn("replacing unreadable metrics file", zap.String("file", string(f)), zap.Error(err))
+		state = newMetricsState()
+	}
+	fn(state)
+	err = writeMetricsFile(string(f), state)
+	if err != nil {
+		zap.L().Warn("failed to write metrics file", zap.String("file", string(f)), zap.Error(err))
+	}
+}
+
+// readMetricsFile returns the state kept in the metrics file at path, which is empty when the file doesn't exist yet
+func readMetricsFile(path string) (*metricsState, error) {
+	state := newMetricsState()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metrics file %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// writeMetricsFile replaces the metrics file at path through a rename, so that the exporter never reads a partial file
+func writeMetricsFile(path string, state *metricsState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), fmt.Sprintf(".%s.*.tmp", filepath.Base(path)))
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(metricsFileMode)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// metricsState is the outcome of the last runs of the tasks and the latency of the certificate requests per platform
+type metricsState struct {
+	Tasks       map[string]*taskMetrics       `json:"tasks"`
+	Enrollments map[string]*enrollmentMetrics `json:"enrollments"`
+}
+
+func newMetricsState() *metricsState {
+	return &metricsState{
+		Tasks:       make(map[string]*taskMetrics),
+		Enrollments: make(map[string]*enrollmentMetrics),
+	}
+}
+
+// taskMetrics is the outcome of the last runs of a task
+type taskMetrics struct {
+	Success     bool      `json:"success"`
+	LastRun     time.Time `json:"lastRun"`
+	LastSuccess time.Time `json:"lastSuccess,omitempty"`
+}
+
+// enrollmentMetrics is the latency of the certificate requests to a platform
+type enrollmentMetrics struct {
+	Last     time.Duration `json:"last"`
+	Total    time.Duration `json:"total"`
+	Count    int           `json:"count"`
+	Failures int           `json:"failures"`
+}
+
+func (s *metricsState) taskFinished(task string, errs []error) {
+	tm, ok := s.Tasks[task]
+	if !ok {
+		tm = &taskMetrics{}
+		s.Tasks[task] = tm
+	}
+	tm.LastRun = time.Now()
+	tm.Success = len(errs) == 0
+	if tm.Success {
+		tm.LastSuccess = tm.LastRun
+	}
+}
+
+func (s *metricsState) enrollmentFinished(platform venafi.Platform, latency time.Duration, err error) {
+	em, ok := s.Enrollments[platform.String()]
+	if !ok {
+		em = &enrollmentMetrics{}
+		s.Enrollments[platform.String()] = em
+	}
+	em.Last = latency
+	em.Total += latency
+	em.Count++
+	if err != nil {
+		em.Failures++
+	}
+}
+
+// Exporter serves Prometheus metrics on the certificates installed by a playbook: the days until each one expires,
+// read through the installers on every scrape, and the outcome and latency of the enrollments it is notified of
+// as the Metrics of the tasks. When the playbook has a metrics file, the outcome and latency of the enrollments are
+// read from that file instead, so that they include the tasks run by other processes
+type Exporter struct {
+	load func() (domain.Playbook, error)
+
+	mu    sync.Mutex
+	state *metricsState
+}
+
+// NewExporter returns an Exporter of the certificates installed by the playbook returned by load
+func NewExporter(load func() (domain.Playbook, error)) *Exporter {
+	return &Exporter{
+		load:  load,
+		state: newMetricsState(),
+	}
+}
+
+// TaskFinished records the outcome of the last run of the task
+func (e *Exporter) TaskFinished(task string, errs []error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.state.taskFinished(task, errs)
+}
+
+// EnrollmentFinished records the latency of a certificate request to the platform
+func (e *Exporter) EnrollmentFinished(platform venafi.Platform, latency time.Duration, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.state.enrollmentFinished(platform, latency, err)
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", MetricsContentType)
+	_ = e.WriteMetrics(w)
+}
+
+// WriteMetrics writes the metrics to w in the Prometheus text exposition format
+func (e *Exporter) WriteMetrics(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	mw := &metricsWriter{w: bw}
+
+	playbook, err := e.load()
+	loaded := 1.0
+	if err != nil {
+		zap.L().Error("failed to load playbook for metrics", zap.Error(err))
+		loaded = 0
+	}
+	mw.family("vcert_playbook_loaded", "gauge", "Whether the playbook could be loaded (1) or not (0)")
+	mw.sample("vcert_playbook_loaded", nil, loaded)
+	var fileState *metricsState
+	if err == nil {
+		e.writeCertificates(mw, playbook)
+
+		if playbook.Config.MetricsFile != "" {
+			fileState, err = readMetricsFile(playbook.Config.MetricsFile)
+			if err != nil {
+				zap.L().Error("failed to read metrics file", zap.String("file", playbook.Config.MetricsFile), zap.Error(err))
+			}
+		}
+	}
+
+	e.mu.Lock()
+	state := e.state
+	if fileState != nil {
+		state = fileState
+	}
+	writeTasks(mw, state)
+	writeEnrollments(mw, state)
+	e.mu.Unlock()
+
+	if mw.err != nil {
+		return mw.err
+	}
+	return bw.Flush()
+}
+
+// writeCertificates writes the expiry of the certificates installed by each installation of the playbook, and of the
+// SSH certificates installed by its SSH certificate tasks
+func (e *Exporter) writeCertificates(mw *metricsWriter, playbook domain.Playbook) {
+	type installed struct {
+		labels []string
+		days   float64
+		found  bool
+		// neverExpires is true for the SSH certificates without an end of validity
+		neverExpires bool
+	}
+	var certs []installed
+	now := time.Now()
+	for _, task := range playbook.CertificateTasks {
+		for _, inst := range task.Installations {
+			labels := []string{"task", task.Name, "type", inst.Type.String(), "location", getInstallationLocationString(inst)}
+			cert, err := installer.GetInstaller(inst).InstalledCertificate(task.Request)
+			if err != nil {
+				zap.L().Warn("failed to read installed certificate", zap.String("task", task.Name),
+					zap.String("location", getInstallationLocationString(inst)), zap.Error(err))
+			}
+			if err != nil || cert == nil {
+				certs = append(certs, installed{labels: labels})
+				continue
+			}
+			certs = append(certs, installed{labels: labels, days: cert.NotAfter.Sub(now).Hours() / 24, found: true})
+		}
+	}
+	for _, task := range playbook.SshCertificateTasks {
+		labels := []string{"task", task.Name, "type", sshPlanFormat, "location", task.Installation.CertificateFile()}
+		cert, err := installer.NewSshInstaller(task.Installation).InstalledCertificate()
+		if err != nil {
+			zap.L().Warn("failed to read installed SSH certificate", zap.String("task", task.Name),
+				zap.String("location", task.Installation.CertificateFile()), zap.Error(err))
+		}
+		if err != nil || cert == nil {
+			certs = append(certs, installed{labels: labels})
+			continue
+		}
+		if cert.ValidBefore == ssh.CertTimeInfinity {
+			certs = append(certs, installed{labels: labels, found: true, neverExpires: true})
+			continue
+		}
+		validBefore := time.Unix(int64(cert.ValidBefore), 0)
+		certs = append(certs, installed{labels: labels, days: validBefore.Sub(now).Hours() / 24, found: true})
+	}
+
+	mw.family("vcert_certificate_installed", "gauge", "Whether the certificate of the installation can be read (1) or not (0)")
+	for _, c := range certs {
+		mw.sample("vcert_certificate_installed", c.labels, boolValue(c.found))
+	}
+	mw.family("vcert_certificate_expiry_days", "gauge", "Days until the installed certificate expires")
+	for _, c := range certs {
+		if c.found && !c.neverExpires {
+			mw.sample("vcert_certificate_expiry_days", c.labels, c.days)
+		}
+	}
+}
+
+// writeTasks writes the outcome of the last runs of the tasks
+func writeTasks(mw *metricsWriter, state *metricsState) {
+	names := make([]string, 0, len(state.Tasks))
+	for name := range state.Tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	mw.family("vcert_task_last_run_success", "gauge", "Whether the last run of the task succeeded (1) or failed (0)")
+	for _, name := range names {
+		mw.sample("vcert_task_last_run_success", []string{"task", name}, boolValue(state.Tasks[name].Success))
+	}
+	mw.family("vcert_task_last_run_timestamp_seconds", "gauge", "Time of the last run of the task")
+	for _, name := range names {
+		mw.sample("vcert_task_last_run_timestamp_seconds", []string{"task", name}, unixSeconds(state.Tasks[name].LastRun))
+	}
+	mw.family("vcert_task_last_success_timestamp_seconds", "gauge", "Time of the last successful run of the task")
+	for _, name := range names {
+		if !state.Tasks[name].LastSuccess.IsZero() {
+			mw.sample("vcert_task_last_success_timestamp_seconds", []string{"task", name}, unixSeconds(state.Tasks[name].LastSuccess))
+		}
+	}
+}
+
+// writeEnrollments writes the latency of the certificate requests per platform
+func writeEnrollments(mw *metricsWriter, state *metricsState) {
+	platforms := make([]string, 0, len(state.Enrollments))
+	for platform := range state.Enrollments {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+
+	mw.family("vcert_enrollment_last_duration_seconds", "gauge", "Duration of the last certificate request to the platform")
+	for _, p := range platforms {
+		mw.sample("vcert_enrollment_last_duration_seconds", []string{"platform", p}, state.Enrollments[p].Last.Seconds())
+	}
+	mw.family("vcert_enrollment_duration_seconds", "summary", "Duration of the certificate requests to the platform")
+	for _, p := range platforms {
+		mw.sample("vcert_enrollment_duration_seconds_sum", []string{"platform", p}, state.Enrollments[p].Total.Seconds())
+		mw.sample("vcert_enrollment_duration_seconds_count", []string{"platform", p}, float64(state.Enrollments[p].Count))
+	}
+	mw.family("vcert_enrollment_failures_total", "counter", "Number of failed certificate requests to the platform")
+	for _, p := range platforms {
+		mw.sample("vcert_enrollment_failures_total", []string{"platform", p}, float64(state.Enrollments[p].Failures))
+	}
+}
+
+// metricsWriter writes metric families in the Prometheus text exposition format, keeping the first error
+type metricsWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (mw *metricsWriter) family(name, metricType, help string) {
+	mw.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// sample writes a sample of the metric. labels holds the label names and values in turn
+func (mw *metricsWriter) sample(name string, labels []string, value float64) {
+	var sb strings.Builder
+	sb.WriteString(name)
+	if len(labels) > 0 {
+		sb.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(labels[i])
+			sb.WriteString(`="`)
+			sb.WriteString(labelEscaper.Replace(labels[i+1]))
+			sb.WriteByte('"')
+		}
+		sb.WriteByte('}')
+	}
+	mw.printf("%s %s\n", sb.String(), strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func (mw *metricsWriter) printf(format string, args ...interface{}) {
+	if mw.err != nil {
+		return
+	}
+	_, mw.err = fmt.Fprintf(mw.w, format, args...)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / float64(time.Second)
+}
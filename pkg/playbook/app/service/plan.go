@@ -0,0 +1,378 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/Venafi/vcert/v5/pkg/playbook/app/domain"
+	"github.com/Venafi/vcert/v5/pkg/playbook/app/installer"
+	"github.com/Venafi/vcert/v5/pkg/playbook/util"
+)
+
+// sshPlanFormat is the format of the installation of an SSH certificate task in a Plan
+const sshPlanFormat = "SSH"
+
+// Reasons for a certificate task to enroll a certificate, or not
+const (
+	ReasonForced     = "forced"
+	ReasonMissing    = "missing"
+	ReasonRenewalDue = "renewal due"
+	ReasonHealthy    = "healthy"
+)
+
+// Plan describes what a playbook run would do, without doing it
+type Plan struct {
+	Tasks []TaskPlan `json:"tasks"`
+	// Handlers are the handlers that would run once all the tasks are done
+	Handlers []string `json:"handlers,omitempty"`
+}
+
+// TaskPlan describes what a certificate or SSH certificate task would do
+type TaskPlan struct {
+	Task       string `json:"task"`
+	CommonName string `json:"commonName"`
+	Zone       string `json:"zone"`
+	// KeyID and Template are only set for SSH certificate tasks
+	KeyID     string   `json:"keyId,omitempty"`
+	Template  string   `json:"template,omitempty"`
+	DependsOn []string `json:"dependsOn,omitempty"`
+	// Enroll is true when a certificate would be requested or renewed. All the installations of the task are then
+	// overwritten, even those whose certificate is healthy
+	Enroll        bool               `json:"enroll"`
+	Installations []InstallationPlan `json:"installations"`
+	Error         string             `json:"error,omitempty"`
+}
+
+// InstallationPlan describes what would happen to an installation of a certificate task, or to the key pair and
+// certificate files of an SSH certificate task
+type InstallationPlan struct {
+	Format   string `json:"format"`
+	Location string `json:"location"`
+	// Reason tells why the installation requires a new certificate, or why it does not
+	Reason         string     `json:"reason"`
+	ExpirationDate *time.Time `json:"expirationDate,omitempty"`
+	// RenewalDate is the date from which the installed certificate is renewed, unless renewal is disabled
+	RenewalDate       *time.Time `json:"renewalDate,omitempty"`
+	Overwrite         []string   `json:"overwrite,omitempty"`
+	Backup            []string   `json:"backup,omitempty"`
+	AfterAction       string     `json:"afterAction,omitempty"`
+	InstallValidation string     `json:"installValidation,omitempty"`
+	Notify            []string   `json:"notify,omitempty"`
+	Error             string     `json:"error,omitempty"`
+}
+
+// PlanTasks checks the tasks the same way ExecuteTasks does and describes what running them would do.
+// Nothing is enrolled, written or run, so the platform is not contacted
+func PlanTasks(config domain.Config, tasks domain.CertificateTasks, handlers domain.Handlers) Plan {
+	return PlanPlaybookTasks(config, tasks, nil, handlers)
+}
+
+// PlanPlaybookTasks works as PlanTasks, describing the SSH certificate tasks after the certificate tasks
+func PlanPlaybookTasks(config domain.Config, tasks domain.CertificateTasks, sshTasks domain.SshCertificateTasks, handlers domain.Handlers) Plan {
+	plan := Plan{Tasks: make([]TaskPlan, 0, len(tasks)+len(sshTasks))}
+
+	notified := make(map[string]bool)
+	for _, task := range tasks {
+		taskPlan := planTask(config, task)
+		plan.Tasks = append(plan.Tasks, taskPlan)
+		if !taskPlan.Enroll {
+			continue
+		}
+		for _, inst := range task.Installations {
+			for _, h := range inst.Notify {
+				notified[h] = true
+			}
+		}
+	}
+	for _, task := range sshTasks {
+		taskPlan := planSshTask(config, task)
+		plan.Tasks = append(plan.Tasks, taskPlan)
+		if !taskPlan.Enroll {
+			continue
+		}
+		for _, h := range task.Installation.Notify {
+			notified[h] = true
+		}
+	}
+
+	for _, h := range handlers {
+		if notified[h.Name] {
+			plan.Handlers = append(plan.Handlers, h.Name)
+		}
+	}
+	return plan
+}
+
+func planTask(config domain.Config, task domain.CertificateTask) TaskPlan {
+	taskPlan := TaskPlan{
+		Task:          task.Name,
+		CommonName:    task.Request.Subject.CommonName,
+		Zone:          task.Request.Zone,
+		DependsOn:     task.DependsOn,
+		Installations: make([]InstallationPlan, 0, len(task.Installations)),
+	}
+
+	renewBefore := DefaultRenew
+	if task.RenewBefore != "" {
+		renewBefore = task.RenewBefore
+	}
+
+	var errs []string
+	for _, inst := range task.Installations {
+		instPlan := InstallationPlan{
+			Format:            inst.Type.String(),
+			Location:          getInstallationLocationString(inst),
+			AfterAction:       inst.AfterAction,
+			InstallValidation: inst.InstallValidation,
+			Notify:            inst.Notify,
+		}
+
+		instlr := installer.GetInstaller(inst)
+		cert, certErr := instlr.InstalledCertificate(task.Request)
+
+		switch {
+		case config.ForceRenew:
+			// The installed certificates are not checked when renewal is forced
+			instPlan.Reason = ReasonForced
+			taskPlan.Enroll = true
+		case certErr != nil:
+			instPlan.Error = certErr.Error()
+			errs = append(errs, fmt.Sprintf("%s: %s", instPlan.Location, certErr))
+		case cert == nil:
+			instPlan.Reason = ReasonMissing
+			taskPlan.Enroll = true
+		default:
+			changed, err := instlr.Check(renewBefore, task.Request)
+			if err != nil {
+				instPlan.Error = err.Error()
+				errs = append(errs, fmt.Sprintf("%s: %s", instPlan.Location, err))
+			} else if changed {
+				instPlan.Reason = ReasonRenewalDue
+				taskPlan.Enroll = true
+			} else {
+				instPlan.Reason = ReasonHealthy
+			}
+		}
+
+		if cert != nil {
+			notAfter := cert.NotAfter
+			instPlan.ExpirationDate = &notAfter
+			if renewalDate, enabled := installer.RenewalDate(cert, renewBefore); enabled {
+				instPlan.RenewalDate = &renewalDate
+			}
+		}
+		taskPlan.Installations = append(taskPlan.Installations, instPlan)
+	}
+
+	if len(errs) > 0 {
+		// The task fails without enrolling when any installation cannot be checked
+		taskPlan.Enroll = false
+		taskPlan.Error = strings.Join(errs, "; ")
+	}
+
+	for i, inst := range task.Installations {
+		instPlan := &taskPlan.Installations[i]
+		if !taskPlan.Enroll {
+			instPlan.AfterAction = ""
+			instPlan.InstallValidation = ""
+			instPlan.Notify = nil
+			continue
+		}
+		instPlan.Overwrite, instPlan.Backup = installationFiles(inst)
+		if inst.AfterAction == "" {
+			// The validation only runs after an after-install action
+			instPlan.InstallValidation = ""
+		}
+	}
+
+	return taskPlan
+}
+
+func planSshTask(config domain.Config, task domain.SshCertificateTask) TaskPlan {
+	taskPlan := TaskPlan{
+		Task:     task.Name,
+		KeyID:    task.KeyID(),
+		Template: task.Request.Template,
+	}
+	inst := task.Installation
+	instPlan := InstallationPlan{
+		Format:      sshPlanFormat,
+		Location:    inst.KeyFile,
+		AfterAction: inst.AfterAction,
+		Notify:      inst.Notify,
+	}
+
+	renewBefore := DefaultRenew
+	if task.RenewBefore != "" {
+		renewBefore = task.RenewBefore
+	}
+	cert, err := installer.NewSshInstaller(inst).InstalledCertificate()
+
+	switch {
+	case config.ForceRenew:
+		instPlan.Reason = ReasonForced
+		taskPlan.Enroll = true
+	case err != nil:
+		instPlan.Error = err.Error()
+		taskPlan.Error = fmt.Sprintf("%s: %s", instPlan.Location, err)
+	case cert == nil:
+		instPlan.Reason = ReasonMissing
+		taskPlan.Enroll = true
+	default:
+		renewal, enabled := installer.SshRenewalDate(cert, renewBefore)
+		if enabled && !renewal.After(time.Now()) {
+			instPlan.Reason = ReasonRenewalDue
+			taskPlan.Enroll = true
+		} else {
+			instPlan.Reason = ReasonHealthy
+		}
+	}
+
+	if cert != nil && cert.ValidBefore != ssh.CertTimeInfinity {
+		validBefore := time.Unix(int64(cert.ValidBefore), 0)
+		instPlan.ExpirationDate = &validBefore
+		if renewalDate, enabled := installer.SshRenewalDate(cert, renewBefore); enabled {
+			instPlan.RenewalDate = &renewalDate
+		}
+	}
+
+	if taskPlan.Enroll {
+		for _, f := range []string{inst.KeyFile, inst.PublicKeyFile(), inst.CertificateFile()} {
+			if exists, err := util.FileExists(f); err == nil && exists {
+				instPlan.Overwrite = append(instPlan.Overwrite, f)
+			}
+		}
+		if inst.BackupFiles {
+			for _, f := range instPlan.Overwrite {
+				instPlan.Backup = append(instPlan.Backup, fmt.Sprintf("%s.bak", f))
+			}
+		}
+	} else {
+		instPlan.AfterAction = ""
+		instPlan.Notify = nil
+	}
+	taskPlan.Installations = []InstallationPlan{instPlan}
+	return taskPlan
+}
+
+// installationFiles returns the existing files an installation would overwrite, and the ones it would back up first
+func installationFiles(inst domain.Installation) ([]string, []string) {
+	if inst.Type == domain.FormatCAPI {
+		return nil, nil
+	}
+
+	files := []string{inst.File}
+	if inst.Type == domain.FormatPEM {
+		files = append(files, inst.KeyFile, inst.ChainFile)
+	}
+
+	var overwrite, backup []string
+	certExists := false
+	for _, f := range files {
+		if f == "" {
+			continue
+		}
+		exists, err := util.FileExists(f)
+		if err != nil || !exists {
+			continue
+		}
+		overwrite = append(overwrite, f)
+		if f == inst.File {
+			certExists = true
+		}
+	}
+
+	// Backups are only taken when the certificate file itself exists
+	if inst.BackupFiles && certExists {
+		for _, f := range overwrite {
+			backup = append(backup, fmt.Sprintf("%s.bak", f))
+		}
+	}
+	return overwrite, backup
+}
+
+// WriteText writes the plan in a human-readable form
+func (p Plan) WriteText(w io.Writer) error {
+	var b strings.Builder
+	enrolls := 0
+	for _, t := range p.Tasks {
+		action := "no changes"
+		if t.Enroll {
+			action = "enroll"
+			enrolls++
+		}
+		if t.Error != "" {
+			action = "error"
+		}
+		fmt.Fprintf(&b, "task %s: %s\n", t.Task, action)
+		if t.Template != "" {
+			fmt.Fprintf(&b, "  SSH certificate: %s (template %s)\n", t.KeyID, t.Template)
+		} else {
+			fmt.Fprintf(&b, "  certificate: %s (zone %s)\n", t.CommonName, t.Zone)
+		}
+		if len(t.DependsOn) > 0 {
+			fmt.Fprintf(&b, "  depends on: %s\n", strings.Join(t.DependsOn, ", "))
+		}
+		if t.Error != "" {
+			fmt.Fprintf(&b, "  error: %s\n", t.Error)
+		}
+		for _, inst := range t.Installations {
+			fmt.Fprintf(&b, "  - %s %s", inst.Format, inst.Location)
+			if inst.Reason != "" {
+				fmt.Fprintf(&b, ": %s", inst.Reason)
+			}
+			b.WriteString("\n")
+			if inst.Error != "" {
+				fmt.Fprintf(&b, "      error: %s\n", inst.Error)
+			}
+			if inst.ExpirationDate != nil {
+				fmt.Fprintf(&b, "      expires: %s\n", inst.ExpirationDate.Format(time.RFC3339))
+			}
+			if inst.RenewalDate != nil {
+				fmt.Fprintf(&b, "      renews: %s\n", inst.RenewalDate.Format(time.RFC3339))
+			}
+			for _, f := range inst.Overwrite {
+				fmt.Fprintf(&b, "      overwrite: %s\n", f)
+			}
+			for _, f := range inst.Backup {
+				fmt.Fprintf(&b, "      backup: %s\n", f)
+			}
+			if inst.AfterAction != "" {
+				fmt.Fprintf(&b, "      after-install action: %s\n", inst.AfterAction)
+			}
+			if inst.InstallValidation != "" {
+				fmt.Fprintf(&b, "      install validation: %s\n", inst.InstallValidation)
+			}
+			if len(inst.Notify) > 0 {
+				fmt.Fprintf(&b, "      notify: %s\n", strings.Join(inst.Notify, ", "))
+			}
+		}
+	}
+	for _, h := range p.Handlers {
+		fmt.Fprintf(&b, "handler %s: run\n", h)
+	}
+	fmt.Fprintf(&b, "%d of %d tasks would enroll a certificate\n", enrolls, len(p.Tasks))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
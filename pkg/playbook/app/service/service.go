@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -27,6 +29,7 @@ import (
 	"github.com/Venafi/vcert/v5/pkg/playbook/app/domain"
 	"github.com/Venafi/vcert/v5/pkg/playbook/app/installer"
 	"github.com/Venafi/vcert/v5/pkg/playbook/app/vcertutil"
+	"github.com/Venafi/vcert/v5/pkg/playbook/util"
 	"github.com/Venafi/vcert/v5/pkg/venafi"
 )
 
@@ -39,39 +42,275 @@ const (
 	envVarBase64     = "base64"
 )
 
+// TaskResult holds the errors of a certificate or SSH certificate task run by ExecuteTasks or ExecutePlaybookTasks
+type TaskResult struct {
+	Task   string
+	Errors []error
+	// Skipped is true when the task did not run because one of its dependencies failed
+	Skipped bool
+}
+
+// HandlerResult holds the error of a handler run by ExecuteTasks or ExecutePlaybookTasks
+type HandlerResult struct {
+	Handler string
+	Err     error
+}
+
+// playbookJob is a task of any kind scheduled by ExecutePlaybookTasks
+type playbookJob struct {
+	name      string
+	dependsOn []string
+	// run executes the task and returns its errors, along with the handlers it notified
+	run func(connectors *vcertutil.Connectors) ([]error, []string)
+}
+
+// taskDone is sent by a worker when it finishes a task
+type taskDone struct {
+	index    int
+	errors   []error
+	notified []string
+}
+
+// ExecuteTasks runs the tasks with up to config.Concurrency of them in parallel. All the tasks share the connectors
+// built from config, so that each zone is authenticated only once.
+//
+// A task starts only once the tasks in its dependsOn list are done. When a task fails, the tasks depending on it,
+// directly or not, are skipped. Once all the tasks are done, the handlers notified by the installations are run
+// once each, in the order they are defined.
+//
+// The results are returned in the order of the tasks and handlers.
+func ExecuteTasks(config domain.Config, tasks domain.CertificateTasks, handlers domain.Handlers) ([]TaskResult, []HandlerResult) {
+	return ExecutePlaybookTasks(config, tasks, nil, handlers)
+}
+
+// ExecutePlaybookTasks works as ExecuteTasks, running the SSH certificate tasks along with the certificate tasks.
+// The results of the SSH certificate tasks follow the results of the certificate tasks
+func ExecutePlaybookTasks(config domain.Config, tasks domain.CertificateTasks, sshTasks domain.SshCertificateTasks, handlers domain.Handlers) ([]TaskResult, []HandlerResult) {
+	jobs := make([]playbookJob, 0, len(tasks)+len(sshTasks))
+	for _, t := range tasks {
+		task := t
+		jobs = append(jobs, playbookJob{
+			name:      task.Name,
+			dependsOn: task.DependsOn,
+			run: func(connectors *vcertutil.Connectors) ([]error, []string) {
+				return execute(config, task, connectors)
+			},
+		})
+	}
+	for _, t := range sshTasks {
+		task := t
+		jobs = append(jobs, playbookJob{
+			name: task.Name,
+			run: func(connectors *vcertutil.Connectors) ([]error, []string) {
+				return executeSsh(config, task, connectors)
+			},
+		})
+	}
+	return runJobs(config, jobs, handlers)
+}
+
+// runJobs schedules the jobs as described by ExecuteTasks
+func runJobs(config domain.Config, tasks []playbookJob, handlers domain.Handlers) ([]TaskResult, []HandlerResult) {
+	workers := config.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(tasks) {
+		workers = len(tasks)
+	}
+	zap.L().Debug("running playbook tasks", zap.Int("tasks", len(tasks)), zap.Int("concurrency", workers))
+
+	connectors := vcertutil.NewConnectors(config)
+	results := make([]TaskResult, len(tasks))
+	jobs := make(chan int)
+	dones := make(chan taskDone)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				zap.L().Info("running playbook task", zap.String("task", tasks[i].name))
+				errs, notified := tasks[i].run(connectors)
+				dones <- taskDone{index: i, errors: errs, notified: notified}
+			}
+		}()
+	}
+
+	// Count the dependencies of each task and index its dependents
+	indexes := make(map[string]int, len(tasks))
+	for i, t := range tasks {
+		indexes[t.name] = i
+	}
+	pending := make([]int, len(tasks))
+	dependents := make([][]int, len(tasks))
+	for i, t := range tasks {
+		for _, dep := range t.dependsOn {
+			j, ok := indexes[dep]
+			if !ok {
+				continue
+			}
+			pending[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+
+	ready := make([]int, 0, len(tasks))
+	for i := range tasks {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	finished := make([]bool, len(tasks))
+	remaining := len(tasks)
+	notified := make(map[string]bool)
+
+	// skip marks the dependents of a failed task as skipped, recursively
+	var skip func(i int)
+	skip = func(i int) {
+		for _, d := range dependents[i] {
+			if finished[d] {
+				continue
+			}
+			finished[d] = true
+			remaining--
+			zap.L().Warn("skipping playbook task", zap.String("task", tasks[d].name),
+				zap.String("dependency", tasks[i].name))
+			results[d] = TaskResult{
+				Task:    tasks[d].name,
+				Errors:  []error{fmt.Errorf("skipped as its dependency %s failed", tasks[i].name)},
+				Skipped: true,
+			}
+			configMetrics(config).TaskFinished(tasks[d].name, results[d].Errors)
+			skip(d)
+		}
+	}
+
+	running := 0
+	for remaining > 0 {
+		if len(ready) == 0 && running == 0 {
+			// Only tasks depending on each other are left. Playbook validation prevents this
+			for i := range tasks {
+				if !finished[i] {
+					finished[i] = true
+					results[i] = TaskResult{Task: tasks[i].name, Errors: []error{domain.ErrDependencyCycle}}
+				}
+			}
+			break
+		}
+
+		// Only offer a job to the workers when one is ready
+		var next chan int
+		nextIndex := 0
+		if len(ready) > 0 {
+			next = jobs
+			nextIndex = ready[0]
+		}
+		select {
+		case next <- nextIndex:
+			ready = ready[1:]
+			running++
+		case done := <-dones:
+			running--
+			remaining--
+			finished[done.index] = true
+			results[done.index] = TaskResult{Task: tasks[done.index].name, Errors: done.errors}
+			for _, h := range done.notified {
+				notified[h] = true
+			}
+			if len(done.errors) > 0 {
+				skip(done.index)
+				continue
+			}
+			for _, d := range dependents[done.index] {
+				pending[d]--
+				if pending[d] == 0 && !finished[d] {
+					ready = append(ready, d)
+				}
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	handlerResults := make([]HandlerResult, 0)
+	for _, h := range handlers {
+		if !notified[h.Name] {
+			continue
+		}
+		handlerResults = append(handlerResults, HandlerResult{Handler: h.Name, Err: runHandler(h)})
+	}
+
+	return results, handlerResults
+}
+
+func runHandler(handler domain.Handler) error {
+	log := zap.L().With(zap.String("handler", handler.Name))
+	log.Info("running handler")
+
+	result, err := util.ExecuteScript(handler.Action)
+	if err != nil {
+		e := "error running handler"
+		log.Error(e, zap.Error(err))
+		return fmt.Errorf("%s %s: %w", e, handler.Name, err)
+	} else if strings.TrimSpace(result) == "1" {
+		log.Info("handler action failed")
+	}
+	log.Info("successfully executed handler")
+	return nil
+}
+
 // Execute takes the task and requests the certificate specified,
 // then it installs it in the locations defined by the installers.
 //
 // Config is used to make the connection to the Venafi platform for the certificate request.
+//
+// The handlers notified by the installations are not run; use ExecuteTasks for that.
 func Execute(config domain.Config, task domain.CertificateTask) []error {
+	errs, _ := execute(config, task, vcertutil.NewConnectors(config))
+	return errs
+}
+
+// execute runs the task and returns its errors, along with the handlers notified by the successful installations.
+// The outcome is reported to the Metrics set with SetMetrics
+func execute(config domain.Config, task domain.CertificateTask, connectors *vcertutil.Connectors) (errs []error, notified []string) {
+	// Every message is tagged with the task, as tasks may run in parallel
+	log := zap.L().With(zap.String("task", task.Name))
+	defer func() {
+		configMetrics(config).TaskFinished(task.Name, errs)
+	}()
+
 	// Check if certificate needs action
-	changed, err := isCertificateChanged(config, task)
+	changed, err := isCertificateChanged(log, config, task)
 	if err != nil {
-		zap.L().Error("error checking certificate in task", zap.String("task", task.Name), zap.Error(err))
-		return []error{err}
+		log.Error("error checking certificate in task", zap.Error(err))
+		return []error{err}, nil
 	}
 
 	// Config has not changed. Do nothing
 	if !changed {
-		zap.L().Info("certificate in good health. No actions needed",
+		log.Info("certificate in good health. No actions needed",
 			zap.String("certificate", task.Request.Subject.CommonName))
-		return nil
+		return nil, nil
 	}
-	zap.L().Info("certificate needs action", zap.String("certificate", task.Request.Subject.CommonName))
+	log.Info("certificate needs action", zap.String("certificate", task.Request.Subject.CommonName))
 
 	// Ensure there is a keyPassword in the request when origin is service
 	csrOrigin := certificate.ParseCSROrigin(task.Request.CsrOrigin)
 	if csrOrigin == certificate.ServiceGeneratedCSR {
-		zap.L().Info("csr option is 'service'. Generating random password for certificate request")
+		log.Info("csr option is 'service'. Generating random password for certificate request")
 		task.Request.KeyPassword = vcertutil.GeneratePassword()
 	}
 
 	// Config changed or certificate needs renewal. Do request
-	pcc, certRequest, err := vcertutil.EnrollCertificate(config, task.Request)
+	start := time.Now()
+	pcc, certRequest, err := connectors.EnrollCertificate(task.Request)
+	configMetrics(config).EnrollmentFinished(config.Connection.Platform, time.Since(start), err)
 	if err != nil {
-		return []error{fmt.Errorf("error requesting certificate %s: %w", task.Name, err)}
+		return []error{fmt.Errorf("error requesting certificate %s: %w", task.Name, err)}, nil
 	}
-	zap.L().Info("successfully enrolled certificate", zap.String("certificate", task.Request.Subject.CommonName))
+	log.Info("successfully enrolled certificate", zap.String("certificate", task.Request.Subject.CommonName))
 
 	// Private Key should not be decrypted when csrOrigin is service and Platform is Firefly.
 	// Firefly does not support encryption of private keys
@@ -85,33 +324,36 @@ func Execute(config domain.Config, task domain.CertificateTask) []error {
 	x509Certificate, prepedPcc, err := installer.CreateX509Cert(pcc, certRequest, decryptPK)
 	if err != nil {
 		e := "error preparing certificate for installation"
-		zap.L().Error(e, zap.Error(err))
-		return []error{fmt.Errorf("%s: %w", e, err)}
+		log.Error(e, zap.Error(err))
+		return []error{fmt.Errorf("%s: %w", e, err)}, nil
 	}
-	zap.L().Info("successfully prepared certificate for installation")
+	log.Info("successfully prepared certificate for installation")
 
 	// Set certificate to environment variables
 	if task.SetEnvVars != nil {
-		zap.L().Debug("setting environment variables")
-		setEnvVars(task, x509Certificate, prepedPcc)
+		log.Debug("setting environment variables")
+		setEnvVars(log, task, x509Certificate, prepedPcc)
 	}
 
 	// Install certificate on locations
 	errorList := make([]error, 0)
+	notified = make([]string, 0)
 	for _, installation := range task.Installations {
-		e := runInstaller(installation, prepedPcc)
+		e := runInstaller(log, installation, prepedPcc)
 		if e != nil {
 			errorList = append(errorList, e)
+			continue
 		}
+		notified = append(notified, installation.Notify...)
 	}
-	return errorList
+	return errorList, notified
 
 }
 
-func isCertificateChanged(config domain.Config, task domain.CertificateTask) (bool, error) {
+func isCertificateChanged(log *zap.Logger, config domain.Config, task domain.CertificateTask) (bool, error) {
 	//If forceRenew is set, then no need to check the certificate status
 	if config.ForceRenew {
-		zap.L().Info("Flag [force-renew] is set. All certificates will be requested/renewed regardless of status")
+		log.Info("Flag [force-renew] is set. All certificates will be requested/renewed regardless of status")
 		return true, nil
 	}
 	renewBefore := DefaultRenew
@@ -134,22 +376,22 @@ func isCertificateChanged(config domain.Config, task domain.CertificateTask) (bo
 	return changed, nil
 }
 
-func runInstaller(installation domain.Installation, prepedPcc *certificate.PEMCollection) error {
+func runInstaller(log *zap.Logger, installation domain.Installation, prepedPcc *certificate.PEMCollection) error {
 	location := getInstallationLocationString(installation)
 
 	instlr := installer.GetInstaller(installation)
-	zap.L().Info("running Installer", zap.String("installer", installation.Type.String()),
+	log.Info("running Installer", zap.String("installer", installation.Type.String()),
 		zap.String("location", location))
 
 	var err error
 
 	if installation.BackupFiles {
-		zap.L().Info("backing up certificate for Installer", zap.String("installer", installation.Type.String()),
+		log.Info("backing up certificate for Installer", zap.String("installer", installation.Type.String()),
 			zap.String("location", location))
 		err = instlr.Backup()
 		if err != nil {
 			e := "error backing up certificate"
-			zap.L().Error(e, zap.String("location", location), zap.Error(err))
+			log.Error(e, zap.String("location", location), zap.Error(err))
 			return fmt.Errorf("%s at location %s: %w", e, location, err)
 		}
 	}
@@ -157,10 +399,10 @@ func runInstaller(installation domain.Installation, prepedPcc *certificate.PEMCo
 	err = instlr.Install(*prepedPcc)
 	if err != nil {
 		e := "error installing certificate"
-		zap.L().Error(e, zap.String("location", location), zap.Error(err))
+		log.Error(e, zap.String("location", location), zap.Error(err))
 		return fmt.Errorf("%s at location %s: %w", e, location, err)
 	}
-	zap.L().Info("successfully installed certificate", zap.String("location", location))
+	log.Info("successfully installed certificate", zap.String("location", location))
 
 	if installation.AfterAction == "" {
 		return nil
@@ -169,12 +411,12 @@ func runInstaller(installation domain.Installation, prepedPcc *certificate.PEMCo
 	result, err := instlr.AfterInstallActions()
 	if err != nil {
 		e := "error running after-install actions"
-		zap.L().Error(e, zap.String("location", location), zap.Error(err))
+		log.Error(e, zap.String("location", location), zap.Error(err))
 		return fmt.Errorf("%s at location %s: %w", e, location, err)
 	} else if strings.TrimSpace(result) == "1" {
-		zap.L().Info("after-install actions failed")
+		log.Info("after-install actions failed")
 	}
-	zap.L().Info("successfully executed after-install actions")
+	log.Info("successfully executed after-install actions")
 
 	if installation.InstallValidation == "" {
 		return nil
@@ -184,17 +426,17 @@ func runInstaller(installation domain.Installation, prepedPcc *certificate.PEMCo
 
 	if err != nil {
 		e := "error running installation validation actions"
-		zap.L().Error(e, zap.String("location", location), zap.Error(err))
+		log.Error(e, zap.String("location", location), zap.Error(err))
 		return fmt.Errorf("%s at location %s: %w", e, location, err)
 	} else if strings.TrimSpace(validationResults) == "1" {
-		zap.L().Info("installation validation actions failed")
+		log.Info("installation validation actions failed")
 	}
-	zap.L().Info("successfully executed installation validation actions")
+	log.Info("successfully executed installation validation actions")
 
 	return nil
 }
 
-func setEnvVars(task domain.CertificateTask, cert *installer.Certificate, prepedPcc *certificate.PEMCollection) {
+func setEnvVars(log *zap.Logger, task domain.CertificateTask, cert *installer.Certificate, prepedPcc *certificate.PEMCollection) {
 	//todo case sensitivity. upper the name
 	for _, envVar := range task.SetEnvVars {
 		varName := ""
@@ -210,18 +452,18 @@ func setEnvVars(task domain.CertificateTask, cert *installer.Certificate, preped
 			varName = fmt.Sprintf("VCERT_%s_BASE64", strings.ToUpper(task.Name))
 			varValue = prepedPcc.Certificate
 		default:
-			zap.L().Error("environment variable not supported", zap.String("envVar", envVar))
+			log.Error("environment variable not supported", zap.String("envVar", envVar))
 			continue
 		}
 
 		if varValue == "" {
-			zap.L().Error("environment variable value not found", zap.String("envVar", varName))
+			log.Error("environment variable value not found", zap.String("envVar", varName))
 			continue
 		}
 
 		err := os.Setenv(varName, varValue)
 		if err != nil {
-			zap.L().Error("failed to set environment variable", zap.String("envVar", varName), zap.Error(err))
+			log.Error("failed to set environment variable", zap.String("envVar", varName), zap.Error(err))
 		}
 	}
 }
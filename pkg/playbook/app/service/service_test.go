@@ -17,14 +17,27 @@
 package service
 
 import (
+	"context"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/ssh"
 
 	"github.com/Venafi/vcert/v5/pkg/certificate"
 	"github.com/Venafi/vcert/v5/pkg/playbook/app/domain"
+	"github.com/Venafi/vcert/v5/pkg/playbook/app/installer"
 	"github.com/Venafi/vcert/v5/pkg/util"
+	"github.com/Venafi/vcert/v5/pkg/venafi"
 )
 
 type ServiceSuite struct {
@@ -166,6 +179,524 @@ func (s *ServiceSuite) TestService_Execute() {
 	}
 }
 
+func (s *ServiceSuite) TestService_ExecuteTasks() {
+	dir := s.T().TempDir()
+	blocker := filepath.Join(dir, "blocker")
+	s.Require().NoError(os.WriteFile(blocker, []byte("not a directory"), 0600))
+
+	tasks := make(domain.CertificateTasks, 0)
+	for i := 0; i < 5; i++ {
+		task := s.testCases[0].task
+		task.Name = fmt.Sprintf("task%d", i)
+		task.SetEnvVars = nil
+		task.Request.Subject.CommonName = fmt.Sprintf("task%d.venafi.example", i)
+		task.Installations = domain.Installations{{
+			Type:      domain.FormatPEM,
+			File:      filepath.Join(dir, task.Name, "cert.pem"),
+			ChainFile: filepath.Join(dir, task.Name, "chain.pem"),
+			KeyFile:   filepath.Join(dir, task.Name, "key.pem"),
+		}}
+		tasks = append(tasks, task)
+	}
+	// installing under a regular file fails
+	tasks[3].Installations[0].File = filepath.Join(blocker, "cert.pem")
+
+	results, handlerResults := ExecuteTasks(domain.Config{Concurrency: 3, ForceRenew: true}, tasks, nil)
+
+	s.Require().Len(results, len(tasks))
+	for i, result := range results {
+		s.Equal(tasks[i].Name, result.Task)
+		if i == 3 {
+			s.NotEmpty(result.Errors)
+			continue
+		}
+		s.Empty(result.Errors)
+		s.FileExists(tasks[i].Installations[0].File)
+	}
+	s.Empty(handlerResults)
+}
+
+func (s *ServiceSuite) TestService_ExecuteTasksDependencies() {
+	dir := s.T().TempDir()
+	blocker := filepath.Join(dir, "blocker")
+	s.Require().NoError(os.WriteFile(blocker, []byte("not a directory"), 0600))
+	reloads := filepath.Join(dir, "reloads")
+	order := filepath.Join(dir, "order")
+
+	newTask := func(name string, dependsOn ...string) domain.CertificateTask {
+		task := s.testCases[0].task
+		task.Name = name
+		task.SetEnvVars = nil
+		task.DependsOn = dependsOn
+		task.Request.Subject.CommonName = fmt.Sprintf("%s.venafi.example", name)
+		task.Installations = domain.Installations{{
+			Type:        domain.FormatPEM,
+			File:        filepath.Join(dir, name, "cert.pem"),
+			ChainFile:   filepath.Join(dir, name, "chain.pem"),
+			KeyFile:     filepath.Join(dir, name, "key.pem"),
+			AfterAction: fmt.Sprintf("echo %s >> %s", name, order),
+			Notify:      []string{"reload"},
+		}}
+		return task
+	}
+	tasks := domain.CertificateTasks{
+		newTask("leaf", "intermediate"),
+		newTask("intermediate", "root"),
+		newTask("root"),
+		newTask("broken"),
+		newTask("afterBroken", "broken"),
+		newTask("afterSkipped", "afterBroken", "root"),
+	}
+	// installing under a regular file fails
+	tasks[3].Installations[0].File = filepath.Join(blocker, "cert.pem")
+	handlers := domain.Handlers{
+		{Name: "reload", Action: fmt.Sprintf("echo reload >> %s", reloads)},
+		{Name: "unused", Action: "exit 1"},
+	}
+
+	results, handlerResults := ExecuteTasks(domain.Config{Concurrency: 3, ForceRenew: true}, tasks, handlers)
+
+	s.Require().Len(results, len(tasks))
+	for i := 0; i < 3; i++ {
+		s.Empty(results[i].Errors, results[i].Task)
+		s.False(results[i].Skipped)
+	}
+	s.NotEmpty(results[3].Errors)
+	s.False(results[3].Skipped)
+	s.True(results[4].Skipped)
+	s.True(results[5].Skipped)
+	s.NoFileExists(tasks[4].Installations[0].File)
+
+	// dependencies run first
+	content, err := os.ReadFile(order)
+	s.Require().NoError(err)
+	s.Equal("root\nintermediate\nleaf\n", string(content))
+
+	// the handler runs once although notified by several tasks
+	s.Equal([]HandlerResult{{Handler: "reload"}}, handlerResults)
+	content, err = os.ReadFile(reloads)
+	s.Require().NoError(err)
+	s.Equal("reload\n", string(content))
+}
+
+func (s *ServiceSuite) TestService_PlanTasks() {
+	dir := s.T().TempDir()
+	task := s.testCases[0].task
+	task.Name = "planned"
+	task.SetEnvVars = nil
+	task.RenewBefore = "30d"
+	task.Installations = domain.Installations{{
+		Type:        domain.FormatPEM,
+		File:        filepath.Join(dir, "cert.pem"),
+		ChainFile:   filepath.Join(dir, "chain.pem"),
+		KeyFile:     filepath.Join(dir, "key.pem"),
+		BackupFiles: true,
+		AfterAction: "echo installed",
+		Notify:      []string{"reload"},
+	}}
+	tasks := domain.CertificateTasks{task}
+	handlers := domain.Handlers{{Name: "reload", Action: "echo reload"}}
+
+	// nothing is installed yet
+	plan := PlanTasks(domain.Config{}, tasks, handlers)
+	s.Require().Len(plan.Tasks, 1)
+	s.True(plan.Tasks[0].Enroll)
+	inst := plan.Tasks[0].Installations[0]
+	s.Equal(ReasonMissing, inst.Reason)
+	s.Empty(inst.Overwrite)
+	s.Equal("echo installed", inst.AfterAction)
+	s.Equal([]string{"reload"}, plan.Handlers)
+	s.NoFileExists(task.Installations[0].File)
+
+	errs := Execute(domain.Config{}, task)
+	s.Require().Empty(errs)
+
+	// the installed certificate is healthy
+	plan = PlanTasks(domain.Config{}, tasks, handlers)
+	s.False(plan.Tasks[0].Enroll)
+	inst = plan.Tasks[0].Installations[0]
+	s.Equal(ReasonHealthy, inst.Reason)
+	s.Require().NotNil(inst.ExpirationDate)
+	s.Require().NotNil(inst.RenewalDate)
+	s.Equal(inst.ExpirationDate.Add(-30*installer.DayDuration), *inst.RenewalDate)
+	s.Empty(inst.AfterAction)
+	s.Empty(plan.Handlers)
+
+	// renewal is forced
+	plan = PlanTasks(domain.Config{ForceRenew: true}, tasks, handlers)
+	s.True(plan.Tasks[0].Enroll)
+	inst = plan.Tasks[0].Installations[0]
+	s.Equal(ReasonForced, inst.Reason)
+	s.ElementsMatch([]string{task.Installations[0].File, task.Installations[0].KeyFile, task.Installations[0].ChainFile}, inst.Overwrite)
+	s.Contains(inst.Backup, task.Installations[0].File+".bak")
+
+	var text strings.Builder
+	s.Require().NoError(plan.WriteText(&text))
+	s.Contains(text.String(), "task planned: enroll")
+	s.Contains(text.String(), "handler reload: run")
+	s.NoFileExists(task.Installations[0].File + ".bak")
+}
+
+func (s *ServiceSuite) TestService_Daemon() {
+	dir := s.T().TempDir()
+	blocker := filepath.Join(dir, "blocker")
+	s.Require().NoError(os.WriteFile(blocker, []byte("not a directory"), 0600))
+
+	newTask := func(name string, file string) domain.CertificateTask {
+		task := s.testCases[0].task
+		task.Name = name
+		task.SetEnvVars = nil
+		task.RenewBefore = "30d"
+		task.Installations = domain.Installations{{
+			Type:      domain.FormatPEM,
+			File:      file,
+			ChainFile: filepath.Join(dir, name, "chain.pem"),
+			KeyFile:   filepath.Join(dir, name, "key.pem"),
+		}}
+		return task
+	}
+	good := newTask("good", filepath.Join(dir, "good", "cert.pem"))
+	// installing under a regular file fails
+	broken := newTask("broken", filepath.Join(blocker, "cert.pem"))
+
+	daemon := NewDaemon(DaemonOptions{
+		Load: func() (domain.Playbook, error) {
+			return domain.Playbook{CertificateTasks: domain.CertificateTasks{good, broken}}, nil
+		},
+		MinBackoff: time.Hour,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- daemon.Run(ctx)
+	}()
+
+	s.Require().Eventually(func() bool {
+		status := daemon.Status()
+		return len(status.Tasks) == 2 && status.Tasks[0].NextRun != nil && status.Tasks[1].NextRun != nil
+	}, 30*time.Second, 10*time.Millisecond)
+	cancel()
+	s.Require().NoError(<-done)
+
+	status := daemon.Status()
+	s.False(status.Healthy)
+
+	s.Equal("good", status.Tasks[0].Task)
+	s.Zero(status.Tasks[0].Failures)
+	s.FileExists(good.Installations[0].File)
+	next, err := NextRenewal(good)
+	s.Require().NoError(err)
+	s.Equal(next, *status.Tasks[0].NextRun)
+	s.True(next.After(time.Now().Add(24 * time.Hour)))
+
+	// the failed task is retried after the backoff
+	s.Equal("broken", status.Tasks[1].Task)
+	s.Equal(1, status.Tasks[1].Failures)
+	s.NotEmpty(status.Tasks[1].LastError)
+	s.WithinDuration(status.Tasks[1].LastRun.Add(time.Hour), *status.Tasks[1].NextRun, time.Second)
+
+	recorder := httptest.NewRecorder()
+	daemon.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	s.Equal(http.StatusServiceUnavailable, recorder.Code)
+	s.Contains(recorder.Body.String(), `"healthy":false`)
+}
+
+func (s *ServiceSuite) TestService_ExecuteBundle() {
+	if runtime.GOOS == "windows" {
+		s.T().Skip("file modes and ownership are not supported on windows")
+	}
+	dir := s.T().TempDir()
+	task := s.testCases[0].task
+	task.Name = "bundle"
+	task.SetEnvVars = nil
+	task.Installations = domain.Installations{{
+		Type:        domain.FormatPEM,
+		File:        filepath.Join(dir, "haproxy.pem"),
+		Bundle:      []string{domain.BundleKey, domain.BundleCertificate, domain.BundleChain},
+		KeyFileMode: "0640",
+		Owner:       strconv.Itoa(os.Getuid()),
+		Group:       strconv.Itoa(os.Getgid()),
+		BackupFiles: true,
+	}}
+	_, err := task.Installations[0].IsValid()
+	s.Require().NoError(err)
+
+	errs := Execute(domain.Config{}, task)
+	s.Require().Empty(errs)
+
+	info, err := os.Stat(task.Installations[0].File)
+	s.Require().NoError(err)
+	s.Equal(os.FileMode(0640), info.Mode().Perm())
+
+	content, err := os.ReadFile(task.Installations[0].File)
+	s.Require().NoError(err)
+	var types []string
+	for block, rest := pem.Decode(content); block != nil; block, rest = pem.Decode(rest) {
+		types = append(types, block.Type)
+	}
+	s.Require().GreaterOrEqual(len(types), 3)
+	s.Contains(types[0], "PRIVATE KEY")
+	s.Equal("CERTIFICATE", types[1])
+	s.Equal("CERTIFICATE", types[2])
+
+	// the certificate is found after the key when checking for renewal
+	changed, err := installer.GetInstaller(task.Installations[0]).Check(DefaultRenew, task.Request)
+	s.Require().NoError(err)
+	s.False(changed)
+
+	// the backup keeps the content and mode of the replaced bundle
+	errs = Execute(domain.Config{ForceRenew: true}, task)
+	s.Require().Empty(errs)
+	backup, err := os.ReadFile(task.Installations[0].File + ".bak")
+	s.Require().NoError(err)
+	s.Equal(content, backup)
+	info, err = os.Stat(task.Installations[0].File + ".bak")
+	s.Require().NoError(err)
+	s.Equal(os.FileMode(0640), info.Mode().Perm())
+
+	// no temporary file is left behind
+	entries, err := os.ReadDir(dir)
+	s.Require().NoError(err)
+	s.Len(entries, 2)
+}
+
+func (s *ServiceSuite) TestService_Metrics() {
+	dir := s.T().TempDir()
+	task := s.testCases[0].task
+	task.Name = "metrics"
+	task.SetEnvVars = nil
+	task.Installations = domain.Installations{{
+		Type:      domain.FormatPEM,
+		File:      filepath.Join(dir, "cert.pem"),
+		ChainFile: filepath.Join(dir, "chain.pem"),
+		KeyFile:   filepath.Join(dir, "key.pem"),
+	}}
+	missing := task
+	missing.Name = "missing"
+	missing.Installations = domain.Installations{{
+		Type:      domain.FormatPEM,
+		File:      filepath.Join(dir, `missing "quoted"`, "cert.pem"),
+		ChainFile: filepath.Join(dir, "missing", "chain.pem"),
+		KeyFile:   filepath.Join(dir, "missing", "key.pem"),
+	}}
+
+	exporter := NewExporter(func() (domain.Playbook, error) {
+		return domain.Playbook{CertificateTasks: domain.CertificateTasks{task, missing}}, nil
+	})
+	SetMetrics(exporter)
+	defer SetMetrics(nil)
+
+	s.Require().Empty(Execute(domain.Config{Connection: domain.Connection{Platform: venafi.Fake}}, task))
+
+	recorder := httptest.NewRecorder()
+	exporter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	s.Equal(MetricsContentType, recorder.Header().Get("Content-Type"))
+
+	samples := make(map[string]string)
+	for _, line := range strings.Split(recorder.Body.String(), "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, " ")
+		samples[line[:i]] = line[i+1:]
+	}
+
+	labels := fmt.Sprintf(`{task="metrics",type="PEM",location="%s"}`, task.Installations[0].File)
+	s.Equal("1", samples["vcert_playbook_loaded"])
+	s.Equal("1", samples["vcert_certificate_installed"+labels])
+	days, err := strconv.ParseFloat(samples["vcert_certificate_expiry_days"+labels], 64)
+	s.Require().NoError(err)
+	s.Greater(days, 1.0)
+
+	missingLabels := fmt.Sprintf(`{task="missing",type="PEM",location="%s"}`,
+		strings.ReplaceAll(missing.Installations[0].File, `"`, `\"`))
+	s.Equal("0", samples["vcert_certificate_installed"+missingLabels])
+	s.NotContains(samples, "vcert_certificate_expiry_days"+missingLabels)
+
+	s.Equal("1", samples[`vcert_task_last_run_success{task="metrics"}`])
+	s.Contains(samples, `vcert_task_last_success_timestamp_seconds{task="metrics"}`)
+	s.NotContains(samples, `vcert_task_last_run_success{task="missing"}`)
+	s.Equal("1", samples[`vcert_enrollment_duration_seconds_count{platform="FAKE"}`])
+	s.Equal("0", samples[`vcert_enrollment_failures_total{platform="FAKE"}`])
+
+	// a failed run is reported, keeping the time of the last success
+	exporter.TaskFinished("metrics", []error{fmt.Errorf("enrollment failed")})
+	recorder = httptest.NewRecorder()
+	exporter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	s.Contains(recorder.Body.String(), "vcert_task_last_run_success{task=\"metrics\"} 0\n")
+	s.Contains(recorder.Body.String(), `vcert_task_last_success_timestamp_seconds{task="metrics"}`)
+}
+
+func (s *ServiceSuite) TestService_MetricsFile() {
+	dir := s.T().TempDir()
+	task := s.testCases[0].task
+	task.Name = "metrics"
+	task.SetEnvVars = nil
+	task.Installations = domain.Installations{{
+		Type:      domain.FormatPEM,
+		File:      filepath.Join(dir, "cert.pem"),
+		ChainFile: filepath.Join(dir, "chain.pem"),
+		KeyFile:   filepath.Join(dir, "key.pem"),
+	}}
+	config := domain.Config{
+		Connection:  domain.Connection{Platform: venafi.Fake},
+		MetricsFile: filepath.Join(dir, "metrics.json"),
+	}
+
+	// the tasks are run by another process than the exporter, which is not notified of them
+	exporter := NewExporter(func() (domain.Playbook, error) {
+		return domain.Playbook{Config: config, CertificateTasks: domain.CertificateTasks{task}}, nil
+	})
+	recorder := httptest.NewRecorder()
+	exporter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	s.NotContains(recorder.Body.String(), `vcert_task_last_run_success{task="metrics"}`, "no task has run yet")
+
+	s.Require().Empty(Execute(config, task))
+	config.ForceRenew = true
+	s.Require().Empty(Execute(config, task))
+
+	info, err := os.Stat(config.MetricsFile)
+	s.Require().NoError(err)
+	s.Equal(metricsFileMode, info.Mode().Perm())
+
+	recorder = httptest.NewRecorder()
+	exporter.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	s.Contains(recorder.Body.String(), "vcert_task_last_run_success{task=\"metrics\"} 1\n")
+	s.Contains(recorder.Body.String(), `vcert_task_last_success_timestamp_seconds{task="metrics"}`)
+	s.Contains(recorder.Body.String(), "vcert_enrollment_duration_seconds_count{platform=\"FAKE\"} 2\n")
+	s.Contains(recorder.Body.String(), "vcert_enrollment_failures_total{platform=\"FAKE\"} 0\n")
+}
+
+func (s *ServiceSuite) TestService_ExecuteSshTasks() {
+	if runtime.GOOS == "windows" {
+		s.T().Skip("file modes are not supported on windows")
+	}
+	dir := s.T().TempDir()
+	reloads := filepath.Join(dir, "reloads")
+	task := domain.SshCertificateTask{
+		Name: "sshd",
+		Request: domain.SshCertificateRequest{
+			Template:      "fake-ssh-host-ca",
+			Principals:    []string{"web-01.venafi.example"},
+			KeyType:       util.SshKeyTypeED25519,
+			ValidityHours: 24,
+		},
+		Installation: domain.SshInstallation{
+			KeyFile:     filepath.Join(dir, "ssh_host_ed25519_key"),
+			BackupFiles: true,
+			AfterAction: "echo installed",
+			Notify:      []string{"reload"},
+		},
+		RenewBefore: "2h",
+	}
+	handlers := domain.Handlers{{Name: "reload", Action: fmt.Sprintf("echo reload >> %s", reloads)}}
+	config := domain.Config{Connection: domain.Connection{Platform: venafi.Fake}}
+
+	plan := PlanPlaybookTasks(config, nil, domain.SshCertificateTasks{task}, handlers)
+	s.Require().Len(plan.Tasks, 1)
+	s.True(plan.Tasks[0].Enroll)
+	s.Equal("sshd", plan.Tasks[0].KeyID)
+	s.Equal(ReasonMissing, plan.Tasks[0].Installations[0].Reason)
+	s.Equal([]string{"reload"}, plan.Handlers)
+
+	results, handlerResults := ExecutePlaybookTasks(config, nil, domain.SshCertificateTasks{task}, handlers)
+	s.Require().Len(results, 1)
+	s.Require().Empty(results[0].Errors)
+	s.Equal([]HandlerResult{{Handler: "reload"}}, handlerResults)
+
+	for file, mode := range map[string]os.FileMode{
+		task.Installation.KeyFile:           0600,
+		task.Installation.PublicKeyFile():   0644,
+		task.Installation.CertificateFile(): 0644,
+	} {
+		info, err := os.Stat(file)
+		s.Require().NoError(err)
+		s.Equal(mode, info.Mode().Perm(), file)
+	}
+
+	cert, err := installer.NewSshInstaller(task.Installation).InstalledCertificate()
+	s.Require().NoError(err)
+	s.Require().NotNil(cert)
+	s.Equal(uint32(ssh.HostCert), cert.CertType)
+	s.Equal("sshd", cert.KeyId)
+	s.Equal([]string{"web-01.venafi.example"}, cert.ValidPrincipals)
+	publicKey, err := os.ReadFile(task.Installation.PublicKeyFile())
+	s.Require().NoError(err)
+	key, _, _, _, err := ssh.ParseAuthorizedKey(publicKey)
+	s.Require().NoError(err)
+	s.Equal(ssh.KeyAlgoED25519, key.Type())
+	s.Equal(key.Marshal(), cert.Key.Marshal(), "the certificate is issued for the installed key")
+
+	// the certificate is renewed 2 hours before it expires
+	next, err := NextSshRenewal(task)
+	s.Require().NoError(err)
+	s.WithinDuration(time.Unix(int64(cert.ValidBefore), 0).Add(-2*time.Hour), next, time.Second)
+
+	// a healthy certificate is not requested again
+	s.Empty(ExecuteSsh(config, task))
+	installed, err := installer.NewSshInstaller(task.Installation).InstalledCertificate()
+	s.Require().NoError(err)
+	s.Equal(cert.Serial, installed.Serial)
+	s.NoFileExists(task.Installation.CertificateFile() + ".bak")
+
+	// a forced renewal generates a new key pair and backs up the previous one
+	config.ForceRenew = true
+	s.Empty(ExecuteSsh(config, task))
+	installed, err = installer.NewSshInstaller(task.Installation).InstalledCertificate()
+	s.Require().NoError(err)
+	s.NotEqual(cert.Key.Marshal(), installed.Key.Marshal())
+	s.FileExists(task.Installation.KeyFile + ".bak")
+	s.FileExists(task.Installation.CertificateFile() + ".bak")
+}
+
+func (s *ServiceSuite) TestService_ExecuteKeyFileMode() {
+	if runtime.GOOS == "windows" {
+		s.T().Skip("file modes are not supported on windows")
+	}
+	dir := s.T().TempDir()
+	task := s.testCases[0].task
+	task.Name = "keyFileMode"
+	task.SetEnvVars = nil
+	task.Installations = domain.Installations{{
+		Type:      domain.FormatPEM,
+		File:      filepath.Join(dir, "cert.pem"),
+		ChainFile: filepath.Join(dir, "chain.pem"),
+		KeyFile:   filepath.Join(dir, "key.pem"),
+		FileMode:  "0644",
+	}}
+
+	errs := Execute(domain.Config{}, task)
+	s.Require().Empty(errs)
+
+	// fileMode doesn't make the private key readable by others
+	for file, mode := range map[string]os.FileMode{"cert.pem": 0644, "chain.pem": 0644, "key.pem": 0600} {
+		info, err := os.Stat(filepath.Join(dir, file))
+		s.Require().NoError(err)
+		s.Equal(mode, info.Mode().Perm(), file)
+	}
+
+	task.Installations[0].KeyFileMode = "0640"
+	errs = Execute(domain.Config{ForceRenew: true}, task)
+	s.Require().Empty(errs)
+	info, err := os.Stat(filepath.Join(dir, "key.pem"))
+	s.Require().NoError(err)
+	s.Equal(os.FileMode(0640), info.Mode().Perm())
+
+	// a bundle holding the private key is installed with the mode of the key
+	bundle := filepath.Join(dir, "bundle.pem")
+	task.Installations = domain.Installations{{
+		Type:     domain.FormatPEM,
+		File:     bundle,
+		Bundle:   []string{domain.BundleCertificate, domain.BundleChain, domain.BundleKey},
+		FileMode: "0644",
+	}}
+	errs = Execute(domain.Config{ForceRenew: true}, task)
+	s.Require().Empty(errs)
+	info, err = os.Stat(bundle)
+	s.Require().NoError(err)
+	s.Equal(domain.DefaultKeyFileMode, info.Mode().Perm())
+}
+
 // this function executes after each test case
 func (s *ServiceSuite) TearDownTest() {
 	err := os.RemoveAll("./jks")
@@ -0,0 +1,154 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Venafi/vcert/v5/pkg/playbook/app/domain"
+	"github.com/Venafi/vcert/v5/pkg/playbook/app/installer"
+	"github.com/Venafi/vcert/v5/pkg/playbook/app/vcertutil"
+	"github.com/Venafi/vcert/v5/pkg/util"
+)
+
+// ExecuteSsh takes the SSH certificate task, generates a new key pair and requests a certificate for it,
+// then it installs the key pair and the certificate in the files defined by the installation.
+//
+// The handlers notified by the installation are not run; use ExecutePlaybookTasks for that.
+func ExecuteSsh(config domain.Config, task domain.SshCertificateTask) []error {
+	errs, _ := executeSsh(config, task, vcertutil.NewConnectors(config))
+	return errs
+}
+
+// executeSsh runs the SSH certificate task and returns its errors, along with the handlers notified by the
+// installation. The outcome is reported to the Metrics set with SetMetrics
+func executeSsh(config domain.Config, task domain.SshCertificateTask, connectors *vcertutil.Connectors) (errs []error, notified []string) {
+	log := zap.L().With(zap.String("task", task.Name))
+	defer func() {
+		configMetrics(config).TaskFinished(task.Name, errs)
+	}()
+
+	// Check if the certificate needs action
+	if config.ForceRenew {
+		log.Info("Flag [force-renew] is set. All certificates will be requested/renewed regardless of status")
+	} else {
+		next, err := NextSshRenewal(task)
+		if err != nil {
+			log.Error("error checking SSH certificate in task", zap.Error(err))
+			return []error{err}, nil
+		}
+		if next.IsZero() || next.After(time.Now()) {
+			log.Info("SSH certificate in good health. No actions needed", zap.String("keyId", task.KeyID()))
+			return nil, nil
+		}
+	}
+	log.Info("SSH certificate needs action", zap.String("keyId", task.KeyID()))
+
+	// A new key pair is generated on every renewal
+	privateKey, publicKey, err := util.GenerateSshKeyPairWithOptions(util.SshKeyPairOptions{
+		KeyType:    strings.ToLower(task.Request.KeyType),
+		KeySize:    task.Request.KeySize,
+		Passphrase: task.Installation.KeyPassword,
+		Comment:    task.KeyID(),
+		Format:     util.OpenSshFormat,
+	})
+	if err != nil {
+		e := "error generating SSH key pair"
+		log.Error(e, zap.Error(err))
+		return []error{fmt.Errorf("%s: %w", e, err)}, nil
+	}
+
+	start := time.Now()
+	data, err := connectors.RequestSshCertificate(task.KeyID(), task.Request, string(publicKey))
+	configMetrics(config).EnrollmentFinished(config.Connection.Platform, time.Since(start), err)
+	if err != nil {
+		return []error{fmt.Errorf("error requesting SSH certificate %s: %w", task.Name, err)}, nil
+	}
+	log.Info("successfully enrolled SSH certificate", zap.String("keyId", task.KeyID()))
+
+	err = runSshInstaller(log, task.Installation, privateKey, publicKey, []byte(data.CertificateData))
+	if err != nil {
+		return []error{err}, nil
+	}
+	return nil, task.Installation.Notify
+}
+
+// NextSshRenewal returns the date on which the SSH certificate installed by the task is due for renewal,
+// according to the task's renewBefore. It returns the current time when the certificate is missing,
+// and the zero time when the renewal is disabled or the certificate never expires
+func NextSshRenewal(task domain.SshCertificateTask) (time.Time, error) {
+	renewBefore := DefaultRenew
+	if task.RenewBefore != "" {
+		renewBefore = task.RenewBefore
+	}
+
+	cert, err := installer.NewSshInstaller(task.Installation).InstalledCertificate()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error checking for SSH certificate %s: %w", task.Name, err)
+	}
+	if cert == nil {
+		return time.Now(), nil
+	}
+	renewal, enabled := installer.SshRenewalDate(cert, renewBefore)
+	if !enabled {
+		return time.Time{}, nil
+	}
+	return renewal, nil
+}
+
+func runSshInstaller(log *zap.Logger, installation domain.SshInstallation, privateKey []byte, publicKey []byte, cert []byte) error {
+	location := installation.KeyFile
+	instlr := installer.NewSshInstaller(installation)
+	log.Info("running SSH installer", zap.String("location", location))
+
+	if installation.BackupFiles {
+		log.Info("backing up SSH certificate", zap.String("location", location))
+		err := instlr.Backup()
+		if err != nil {
+			e := "error backing up SSH certificate"
+			log.Error(e, zap.String("location", location), zap.Error(err))
+			return fmt.Errorf("%s at location %s: %w", e, location, err)
+		}
+	}
+
+	err := instlr.Install(privateKey, publicKey, cert)
+	if err != nil {
+		e := "error installing SSH certificate"
+		log.Error(e, zap.String("location", location), zap.Error(err))
+		return fmt.Errorf("%s at location %s: %w", e, location, err)
+	}
+	log.Info("successfully installed SSH certificate", zap.String("location", location))
+
+	if installation.AfterAction == "" {
+		return nil
+	}
+
+	result, err := instlr.AfterInstallActions()
+	if err != nil {
+		e := "error running after-install actions"
+		log.Error(e, zap.String("location", location), zap.Error(err))
+		return fmt.Errorf("%s at location %s: %w", e, location, err)
+	} else if strings.TrimSpace(result) == "1" {
+		log.Info("after-install actions failed")
+	}
+	log.Info("successfully executed after-install actions")
+	return nil
+}
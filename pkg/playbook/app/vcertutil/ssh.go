@@ -0,0 +1,91 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package vcertutil
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+	"github.com/Venafi/vcert/v5/pkg/playbook/app/domain"
+)
+
+// sshPendingIssue is the status of an SSH certificate request that is not issued yet
+const sshPendingIssue = "Pending Issue"
+
+// RequestSshCertificate requests an SSH certificate for publicKey to the SSH CA of the Venafi platform and retrieves it
+// when it is not issued right away. The connector is shared with the tasks of the same timeout, as SSH certificates
+// do not belong to a zone
+func (c *Connectors) RequestSshCertificate(keyID string, request domain.SshCertificateRequest, publicKey string) (*certificate.SshCertificateObject, error) {
+	client, err := c.Get("", request.Timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	vRequest := BuildSshRequest(keyID, request)
+	vRequest.PublicKeyData = publicKey
+
+	data, err := client.RequestSSHCertificate(&vRequest)
+	if err != nil {
+		return nil, err
+	}
+	zap.L().Debug("successfully requested SSH certificate", zap.String("keyId", keyID), zap.String("dn", data.DN))
+
+	// 'Rejected' status is handled in the connector
+	if data.ProcessingDetails.Status == sshPendingIssue || data.CertificateData == "" {
+		retrieveRequest := certificate.SshCertRequest{
+			PickupID:                  data.DN,
+			IncludeCertificateDetails: true,
+			Timeout:                   vRequest.Timeout,
+		}
+		data, err = client.RetrieveSSHCertificate(&retrieveRequest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve SSH certificate %s: %w", retrieveRequest.PickupID, err)
+		}
+	}
+	zap.L().Debug("successfully retrieved SSH certificate", zap.String("keyId", keyID))
+
+	return data, nil
+}
+
+// BuildSshRequest takes a playbook SshCertificateRequest and returns a vcert SshCertRequest for the certificate
+// identified by keyID
+func BuildSshRequest(keyID string, request domain.SshCertificateRequest) certificate.SshCertRequest {
+	vRequest := certificate.SshCertRequest{
+		Template:             request.Template,
+		PolicyDN:             request.Folder,
+		ObjectName:           request.ObjectName,
+		DestinationAddresses: request.DestinationAddresses,
+		KeyId:                keyID,
+		Principals:           request.Principals,
+		Extensions:           request.Extensions,
+		ForceCommand:         request.ForceCommand,
+		SourceAddresses:      request.SourceAddresses,
+	}
+	if request.ValidityHours > 0 {
+		vRequest.ValidityPeriod = strconv.Itoa(request.ValidityHours) + "h"
+	}
+	timeout := DefaultTimeout
+	if request.Timeout > 0 {
+		timeout = request.Timeout
+	}
+	vRequest.Timeout = time.Duration(timeout) * time.Second
+	return vRequest
+}
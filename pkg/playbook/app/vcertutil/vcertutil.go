@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
@@ -38,16 +39,69 @@ import (
 	"github.com/Venafi/vcert/v5/pkg/verror"
 )
 
+// Connectors shares the authenticated connectors to the Venafi platform defined by a playbook config between the
+// certificate tasks of a run. A connector is built for each zone and timeout, as both are set when it is created.
+//
+// Connectors is safe for concurrent use.
+type Connectors struct {
+	config  domain.Config
+	mu      sync.Mutex
+	clients map[connectorKey]*sharedConnector
+}
+
+type connectorKey struct {
+	zone    string
+	timeout int
+}
+
+type sharedConnector struct {
+	once   sync.Once
+	client endpoint.Connector
+	err    error
+}
+
+// NewConnectors returns an empty set of connectors to the Venafi platform defined by config
+func NewConnectors(config domain.Config) *Connectors {
+	return &Connectors{
+		config:  config,
+		clients: make(map[connectorKey]*sharedConnector),
+	}
+}
+
+// Get returns the connector for zone, building and authenticating it on first use.
+// The error of a failed authentication is returned to every caller asking for the same connector
+func (c *Connectors) Get(zone string, timeout int) (endpoint.Connector, error) {
+	key := connectorKey{zone: zone, timeout: timeout}
+
+	c.mu.Lock()
+	shared, ok := c.clients[key]
+	if !ok {
+		shared = &sharedConnector{}
+		c.clients[key] = shared
+	}
+	c.mu.Unlock()
+
+	shared.once.Do(func() {
+		shared.client, shared.err = buildClient(c.config, zone, timeout)
+	})
+	return shared.client, shared.err
+}
+
 // EnrollCertificate takes a Request object and requests a certificate to the Venafi platform defined by config.
 //
 // Then it retrieves the certificate and returns it along with the certificate chain and the private key used.
 func EnrollCertificate(config domain.Config, request domain.PlaybookRequest) (*certificate.PEMCollection, *certificate.Request, error) {
-	client, err := buildClient(config, request.Zone, request.Timeout)
+	return NewConnectors(config).EnrollCertificate(request)
+}
+
+// EnrollCertificate works as the EnrollCertificate function, using the shared connector of the request zone
+func (c *Connectors) EnrollCertificate(request domain.PlaybookRequest) (*certificate.PEMCollection, *certificate.Request, error) {
+	client, err := c.Get(request.Zone, request.Timeout)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	vRequest := buildRequest(request)
+	vRequest := BuildRequest(request)
 
 	zoneCfg, err := client.ReadZoneConfiguration()
 	if err != nil {
@@ -87,13 +141,16 @@ func EnrollCertificate(config domain.Config, request domain.PlaybookRequest) (*c
 
 func buildClient(config domain.Config, zone string, timeout int) (endpoint.Connector, error) {
 	vcertConfig := &vcert.Config{
-		ConnectorType:   config.Connection.GetConnectorType(),
-		BaseUrl:         config.Connection.URL,
-		Zone:            zone,
-		ConnectionTrust: loadTrustBundle(config.Connection.TrustBundlePath),
-		LogVerbose:      false,
+		ConnectorType:     config.Connection.GetConnectorType(),
+		BaseUrl:           config.Connection.URL,
+		Zone:              zone,
+		ConnectionTrust:   loadTrustBundle(config.Connection.TrustBundlePath),
+		LogVerbose:        false,
+		FakeInventoryPath: config.Connection.InventoryPath,
 	}
 
+	// The timeout is the time an attempt may take. vcert.NewClient raises it to the ClientTimeout of the retry
+	// policy, so that the retries of a request aren't cut short
 	vcertConfig.Client = &http.Client{
 		Timeout: time.Duration(DefaultTimeout) * time.Second,
 	}
@@ -228,7 +285,8 @@ func getAttributeValue(attrName string, attrValue string) (string, error) {
 	return fileValue, nil
 }
 
-func buildRequest(request domain.PlaybookRequest) certificate.Request {
+// BuildRequest converts a PlaybookRequest into the certificate.Request sent to the Venafi platform
+func BuildRequest(request domain.PlaybookRequest) certificate.Request {
 
 	vcertRequest := certificate.Request{
 		CADN: request.CADN,
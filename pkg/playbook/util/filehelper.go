@@ -21,7 +21,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/user"
 	"path/filepath"
+	"strconv"
 
 	"go.uber.org/zap"
 )
@@ -40,8 +42,29 @@ func FileExists(certPath string) (bool, error) {
 	return true, nil
 }
 
+// DefaultFileMode is the mode of the files written by WriteFile, and of the installed files when
+// Installation.FileMode is not set
+const DefaultFileMode os.FileMode = 0600
+
+// FileOptions holds the permissions and ownership set by WriteFileWithOptions
+type FileOptions struct {
+	Mode os.FileMode
+	// Owner and Group are names or numeric ids. When empty, the owner and group of the process are kept
+	Owner string
+	Group string
+}
+
 // WriteFile saves the content in the given location. Creates any folders necessary for this action
 func WriteFile(location string, content []byte) error {
+	return WriteFileWithOptions(location, content, FileOptions{Mode: DefaultFileMode})
+}
+
+// WriteFileWithOptions saves the content in the given location with the mode and ownership of opts.
+// Creates any folders necessary for this action.
+//
+// The content is written to a temporary file in the same folder, which then replaces the file at location,
+// so that readers never see a partially written file
+func WriteFileWithOptions(location string, content []byte, opts FileOptions) error {
 	dirPath := filepath.Dir(location)
 	err := os.MkdirAll(dirPath, 0750)
 	if err != nil {
@@ -50,14 +73,97 @@ func WriteFile(location string, content []byte) error {
 		return err
 	}
 
-	err = os.WriteFile(location, content, 0600)
+	uid, gid, err := lookupOwnership(opts.Owner, opts.Group)
+	if err != nil {
+		zap.L().Error("could not find file owner", zap.String("file", location), zap.Error(err))
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dirPath, fmt.Sprintf(".%s.*.tmp", filepath.Base(location)))
+	if err != nil {
+		zap.L().Error("could not create temporary file", zap.String("file", location), zap.Error(err))
+		return err
+	}
+	tmpName := tmp.Name()
+	// The temporary file is left only if the rename fails or is never reached
+	defer os.Remove(tmpName) // nolint:errcheck
+
+	err = writeAndSync(tmp, content, opts.Mode, uid, gid)
 	if err != nil {
 		zap.L().Error("could not write certificate to file", zap.String("file", location), zap.Error(err))
 		return err
 	}
+
+	err = os.Rename(tmpName, location)
+	if err != nil {
+		zap.L().Error("could not replace file", zap.String("file", location), zap.Error(err))
+		return err
+	}
 	return nil
 }
 
+func writeAndSync(f *os.File, content []byte, mode os.FileMode, uid int, gid int) error {
+	defer f.Close() // nolint:errcheck
+
+	if mode == 0 {
+		mode = DefaultFileMode
+	}
+	// Permissions are set before the content is written, so that a key is never readable by others
+	err := f.Chmod(mode)
+	if err != nil {
+		return err
+	}
+	if uid != -1 || gid != -1 {
+		err = f.Chown(uid, gid)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = f.Write(content)
+	if err != nil {
+		return err
+	}
+	err = f.Sync()
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// lookupOwnership returns the ids of owner and group, or -1 when they are empty
+func lookupOwnership(owner string, group string) (int, int, error) {
+	uid, gid := -1, -1
+	if owner != "" {
+		id, err := strconv.Atoi(owner)
+		if err != nil {
+			u, err := user.Lookup(owner)
+			if err != nil {
+				return 0, 0, err
+			}
+			id, err = strconv.Atoi(u.Uid)
+			if err != nil {
+				return 0, 0, fmt.Errorf("unexpected uid %s for user %s", u.Uid, owner)
+			}
+		}
+		uid = id
+	}
+	if group != "" {
+		id, err := strconv.Atoi(group)
+		if err != nil {
+			g, err := user.LookupGroup(group)
+			if err != nil {
+				return 0, 0, err
+			}
+			id, err = strconv.Atoi(g.Gid)
+			if err != nil {
+				return 0, 0, fmt.Errorf("unexpected gid %s for group %s", g.Gid, group)
+			}
+		}
+		gid = id
+	}
+	return uid, gid, nil
+}
+
 // CopyFile makes a copy of the given source to the given destination using Go's native copy function io.Copy
 func CopyFile(source string, destination string) error {
 	zap.L().Debug("checking file", zap.String("location", source))
@@ -74,20 +180,28 @@ func CopyFile(source string, destination string) error {
 		return fmt.Errorf("%s: %s", m, source)
 	}
 
-	sourceFile, err := os.Create(source)
+	sourceFile, err := os.Open(source)
 	if err != nil {
-		zap.L().Error("failed to create/truncate file", zap.String("file", source), zap.Error(err))
+		zap.L().Error("failed to open file", zap.String("file", source), zap.Error(err))
 		return err
 	}
 	defer sourceFile.Close()
 
-	destinationFile, err := os.Create(destination)
+	// The copy keeps the permissions of the source, as it may hold a private key
+	destinationFile, err := os.OpenFile(destination, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, sourceFileStat.Mode().Perm())
 	if err != nil {
 		zap.L().Error("failed to create/truncate file", zap.String("file", destination), zap.Error(err))
 		return err
 	}
 	defer destinationFile.Close()
 
+	// The mode of an existing destination is not changed by OpenFile
+	err = destinationFile.Chmod(sourceFileStat.Mode().Perm())
+	if err != nil {
+		zap.L().Error("failed to set file mode", zap.String("file", destination), zap.Error(err))
+		return err
+	}
+
 	_, err = io.Copy(destinationFile, sourceFile)
 	if err != nil {
 		zap.L().Error("failed to copy file", zap.String("source", source),
@@ -0,0 +1,407 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package policy
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+)
+
+// key types and curves as named by certificate.KeyType and certificate.EllipticCurve
+const (
+	checkKeyTypeRSA     = "RSA"
+	checkKeyTypeECDSA   = "ECDSA"
+	checkKeyTypeED25519 = "ED25519"
+	checkCurveED25519   = "ED25519"
+)
+
+// Violation is a value of a certificate, CSR or request that is not allowed by a PolicySpecification
+type Violation struct {
+	// Field is the policy specification field that is violated, e.g. "policy.subject.orgs"
+	Field string
+	// Value is the offending value
+	Value string
+	// Message describes why the value is not allowed
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Field, v.Message)
+}
+
+// checkedValues are the values of a certificate, CSR or request that are checked against a policy
+type checkedValues struct {
+	commonName     string
+	orgs           []string
+	orgUnits       []string
+	localities     []string
+	states         []string
+	countries      []string
+	dnsNames       []string
+	emailAddresses []string
+	ipAddresses    []net.IP
+	uris           []*url.URL
+	upns           []string
+	keyType        string
+	keySize        int
+	keyCurve       string
+	csrOrigin      certificate.CSrOriginOption
+	// validity is zero when it is unknown
+	validity time.Duration
+}
+
+// CheckRequest reports every value of the request that is not allowed by the policy specification.
+// When the request has a CSR, the subject, SANs and key are taken from it
+func CheckRequest(ps *PolicySpecification, request *certificate.Request) ([]Violation, error) {
+	values := checkedValues{
+		csrOrigin: request.CsrOrigin,
+		validity:  requestValidity(request),
+	}
+
+	if csr := request.GetCSR(); len(csr) > 0 {
+		block, _ := pem.Decode(csr)
+		if block == nil {
+			return nil, fmt.Errorf("failed to decode CSR PEM block")
+		}
+		parsedCSR, err := x509.ParseCertificateRequest(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSR: %w", err)
+		}
+		upns, err := certificate.GetUserPrincipalNameSANs(parsedCSR.Extensions)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSR UPN SANs: %w", err)
+		}
+		setSubjectValues(&values, parsedCSR.Subject.CommonName, parsedCSR.Subject.Organization,
+			parsedCSR.Subject.OrganizationalUnit, parsedCSR.Subject.Locality, parsedCSR.Subject.Province,
+			parsedCSR.Subject.Country)
+		values.dnsNames = parsedCSR.DNSNames
+		values.emailAddresses = parsedCSR.EmailAddresses
+		values.ipAddresses = parsedCSR.IPAddresses
+		values.uris = parsedCSR.URIs
+		values.upns = upns
+		err = setPublicKeyValues(&values, parsedCSR.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		// A CSR can only be uploaded to a policy that allows it
+		values.csrOrigin = certificate.UserProvidedCSR
+		return check(ps, values), nil
+	}
+
+	setSubjectValues(&values, request.Subject.CommonName, request.Subject.Organization,
+		request.Subject.OrganizationalUnit, request.Subject.Locality, request.Subject.Province,
+		request.Subject.Country)
+	values.dnsNames = request.DNSNames
+	values.emailAddresses = request.EmailAddresses
+	values.ipAddresses = request.IPAddresses
+	values.uris = request.URIs
+	values.upns = request.UPNs
+	values.keyType = request.KeyType.String()
+	switch request.KeyType {
+	case certificate.KeyTypeRSA:
+		values.keySize = request.KeyLength
+	case certificate.KeyTypeECDSA, certificate.KeyTypeED25519:
+		values.keyCurve = request.KeyCurve.String()
+	}
+
+	return check(ps, values), nil
+}
+
+// CheckCertificate reports every value of the certificate that is not allowed by the policy specification
+func CheckCertificate(ps *PolicySpecification, cert *x509.Certificate) ([]Violation, error) {
+	upns, err := certificate.GetUserPrincipalNameSANs(cert.Extensions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate UPN SANs: %w", err)
+	}
+
+	values := checkedValues{
+		dnsNames:       cert.DNSNames,
+		emailAddresses: cert.EmailAddresses,
+		ipAddresses:    cert.IPAddresses,
+		uris:           cert.URIs,
+		upns:           upns,
+		// The origin of the key of an issued certificate is unknown
+		csrOrigin: certificate.UnknownCSR,
+		validity:  cert.NotAfter.Sub(cert.NotBefore),
+	}
+	setSubjectValues(&values, cert.Subject.CommonName, cert.Subject.Organization, cert.Subject.OrganizationalUnit,
+		cert.Subject.Locality, cert.Subject.Province, cert.Subject.Country)
+	err = setPublicKeyValues(&values, cert.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return check(ps, values), nil
+}
+
+func setSubjectValues(values *checkedValues, cn string, orgs, orgUnits, localities, states, countries []string) {
+	values.commonName = cn
+	values.orgs = orgs
+	values.orgUnits = orgUnits
+	values.localities = localities
+	values.states = states
+	values.countries = countries
+}
+
+func setPublicKeyValues(values *checkedValues, publicKey any) error {
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		values.keyType = checkKeyTypeRSA
+		values.keySize = key.N.BitLen()
+	case *ecdsa.PublicKey:
+		var curve certificate.EllipticCurve
+		err := curve.Set(key.Curve.Params().Name)
+		if err != nil {
+			return err
+		}
+		values.keyType = checkKeyTypeECDSA
+		values.keyCurve = curve.String()
+	case ed25519.PublicKey:
+		values.keyType = checkKeyTypeED25519
+		values.keyCurve = checkCurveED25519
+	default:
+		return fmt.Errorf("unsupported public key type %T", publicKey)
+	}
+	return nil
+}
+
+func requestValidity(request *certificate.Request) time.Duration {
+	if request.ValidityDuration != nil {
+		return *request.ValidityDuration
+	}
+	return time.Duration(request.ValidityHours) * time.Hour //nolint:staticcheck
+}
+
+func check(ps *PolicySpecification, values checkedValues) []Violation {
+	var violations []Violation
+	if ps == nil || ps.Policy == nil {
+		return violations
+	}
+	p := ps.Policy
+
+	names := make([]string, 0, len(values.dnsNames)+1)
+	if values.commonName != "" {
+		names = append(names, values.commonName)
+	}
+	names = append(names, values.dnsNames...)
+
+	domains := nonEmpty(p.Domains)
+	if len(domains) > 0 {
+		domainRegexes := ConvertToRegex(domains, true)
+		for _, name := range names {
+			if !matchesAny(strings.ToLower(name), domainRegexes) {
+				violations = append(violations, Violation{Field: "policy.domains", Value: name,
+					Message: fmt.Sprintf("%s is not in the allowed domains %v", name, domains)})
+			}
+		}
+		emailRegexes := ConvertToRfc822Regex(domains)
+		for _, email := range values.emailAddresses {
+			if !matchesAny(strings.ToLower(email), emailRegexes) {
+				violations = append(violations, Violation{Field: "policy.domains", Value: email,
+					Message: fmt.Sprintf("email address %s is not in the allowed domains %v", email, domains)})
+			}
+		}
+	}
+
+	if p.WildcardAllowed != nil && !*(p.WildcardAllowed) {
+		for _, name := range names {
+			if strings.HasPrefix(name, "*") {
+				violations = append(violations, Violation{Field: "policy.wildcardAllowed", Value: name,
+					Message: fmt.Sprintf("wildcard %s is not allowed", name)})
+			}
+		}
+	}
+
+	if p.MaxValidDays != nil && *(p.MaxValidDays) > 0 && values.validity > 0 {
+		days := int(values.validity / (24 * time.Hour))
+		if days > *(p.MaxValidDays) {
+			violations = append(violations, Violation{Field: "policy.maxValidDays", Value: fmt.Sprint(days),
+				Message: fmt.Sprintf("validity of %d days exceeds the maximum of %d days", days, *(p.MaxValidDays))})
+		}
+	}
+
+	if p.Subject != nil {
+		violations = append(violations, checkSubjectValues("policy.subject.orgs", "organization", values.orgs, p.Subject.Orgs)...)
+		violations = append(violations, checkSubjectValues("policy.subject.orgUnits", "organizational unit", values.orgUnits, p.Subject.OrgUnits)...)
+		violations = append(violations, checkSubjectValues("policy.subject.localities", "locality", values.localities, p.Subject.Localities)...)
+		violations = append(violations, checkSubjectValues("policy.subject.states", "state", values.states, p.Subject.States)...)
+		violations = append(violations, checkSubjectValues("policy.subject.countries", "country", values.countries, p.Subject.Countries)...)
+	}
+
+	if p.KeyPair != nil {
+		violations = append(violations, checkKeyPair(p.KeyPair, values)...)
+	}
+
+	if p.SubjectAltNames != nil {
+		violations = append(violations, checkSubjectAltNames(p.SubjectAltNames, values)...)
+	}
+
+	return violations
+}
+
+func checkSubjectValues(field, name string, values, allowed []string) []Violation {
+	allowed = nonEmpty(allowed)
+	if len(allowed) == 0 || existValueInArray(allowed, AllowAll) {
+		return nil
+	}
+
+	var violations []Violation
+	for _, value := range nonEmpty(values) {
+		if !existValueInArray(allowed, value) {
+			violations = append(violations, Violation{Field: field, Value: value,
+				Message: fmt.Sprintf("%s %s is not one of the allowed values %v", name, value, allowed)})
+		}
+	}
+	return violations
+}
+
+func checkKeyPair(keyPair *KeyPair, values checkedValues) []Violation {
+	var violations []Violation
+
+	if keyPair.ServiceGenerated != nil {
+		if *(keyPair.ServiceGenerated) && values.csrOrigin == certificate.UserProvidedCSR {
+			violations = append(violations, Violation{Field: "policy.keyPair.serviceGenerated", Value: values.csrOrigin.String(),
+				Message: "the policy requires the key to be generated by the service"})
+		}
+		if !*(keyPair.ServiceGenerated) && values.csrOrigin == certificate.ServiceGeneratedCSR {
+			violations = append(violations, Violation{Field: "policy.keyPair.serviceGenerated", Value: values.csrOrigin.String(),
+				Message: "the policy does not allow the key to be generated by the service"})
+		}
+	}
+
+	if values.keyType == "" {
+		return violations
+	}
+
+	keyTypes := nonEmpty(keyPair.KeyTypes)
+	if len(keyTypes) > 0 && !isKeyTypeAllowed(values.keyType, keyTypes) {
+		violations = append(violations, Violation{Field: "policy.keyPair.keyTypes", Value: values.keyType,
+			Message: fmt.Sprintf("key type %s is not one of the allowed key types %v", values.keyType, keyTypes)})
+	}
+
+	if values.keySize > 0 && len(keyPair.RsaKeySizes) > 0 && !existIntInArray([]int{values.keySize}, keyPair.RsaKeySizes) {
+		violations = append(violations, Violation{Field: "policy.keyPair.rsaKeySizes", Value: fmt.Sprint(values.keySize),
+			Message: fmt.Sprintf("RSA key size %d is not one of the allowed sizes %v", values.keySize, keyPair.RsaKeySizes)})
+	}
+
+	curves := nonEmpty(keyPair.EllipticCurves)
+	if values.keyCurve != "" && len(curves) > 0 && !existValueInArray(curves, values.keyCurve) {
+		violations = append(violations, Violation{Field: "policy.keyPair.ellipticCurves", Value: values.keyCurve,
+			Message: fmt.Sprintf("elliptic curve %s is not one of the allowed curves %v", values.keyCurve, curves)})
+	}
+
+	return violations
+}
+
+// isKeyTypeAllowed checks keyType against the key types of a policy. The platforms use "EC" or "ECDSA" for
+// elliptic curve keys, ED25519 included, and leave the curve to policy.keyPair.ellipticCurves
+func isKeyTypeAllowed(keyType string, keyTypes []string) bool {
+	for _, allowed := range keyTypes {
+		switch strings.ToUpper(allowed) {
+		case keyType:
+			return true
+		case "EC", "ECC", checkKeyTypeECDSA:
+			if keyType == checkKeyTypeECDSA || keyType == checkKeyTypeED25519 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func checkSubjectAltNames(sans *SubjectAltNames, values checkedValues) []Violation {
+	var violations []Violation
+
+	notAllowed := func(field, sanType string, allowed *bool, sanValues []string) {
+		if allowed == nil || *allowed {
+			return
+		}
+		for _, value := range sanValues {
+			violations = append(violations, Violation{Field: field, Value: value,
+				Message: fmt.Sprintf("%s SAN %s is not allowed", sanType, value)})
+		}
+	}
+
+	ips := make([]string, len(values.ipAddresses))
+	for i, ip := range values.ipAddresses {
+		ips[i] = ip.String()
+	}
+	uris := make([]string, len(values.uris))
+	for i, uri := range values.uris {
+		uris[i] = uri.String()
+	}
+
+	notAllowed("policy.subjectAltNames.dnsAllowed", "DNS", sans.DnsAllowed, values.dnsNames)
+	notAllowed("policy.subjectAltNames.ipAllowed", "IP", sans.IpAllowed, ips)
+	notAllowed("policy.subjectAltNames.emailAllowed", "email", sans.EmailAllowed, values.emailAddresses)
+	notAllowed("policy.subjectAltNames.uriAllowed", "URI", sans.UriAllowed, uris)
+	notAllowed("policy.subjectAltNames.upnAllowed", "UPN", sans.UpnAllowed, values.upns)
+
+	ipConstraints := nonEmpty(sans.IpConstraints)
+	if len(ipConstraints) > 0 && (sans.IpAllowed == nil || *(sans.IpAllowed)) {
+		ipRegexes := getIpRegexes(ipConstraints)
+		for _, ip := range ips {
+			if !matchesAny(ip, ipRegexes) {
+				violations = append(violations, Violation{Field: "policy.subjectAltNames.ipConstraints", Value: ip,
+					Message: fmt.Sprintf("IP SAN %s does not satisfy the IP constraints %v", ip, ipConstraints)})
+			}
+		}
+	}
+
+	protocols := nonEmpty(sans.UriProtocols)
+	if len(protocols) > 0 && (sans.UriAllowed == nil || *(sans.UriAllowed)) {
+		for _, uri := range values.uris {
+			if !existValueInArray(protocols, strings.ToLower(uri.Scheme)) {
+				violations = append(violations, Violation{Field: "policy.subjectAltNames.uriProtocols", Value: uri.String(),
+					Message: fmt.Sprintf("URI SAN %s does not use one of the allowed protocols %v", uri, protocols)})
+			}
+		}
+	}
+
+	return violations
+}
+
+func matchesAny(value string, regexes []string) bool {
+	for _, r := range regexes {
+		matched, err := regexp.MatchString("^(?:"+r+")$", value)
+		if err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+func nonEmpty(values []string) []string {
+	var result []string
+	for _, v := range values {
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package policy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+)
+
+func violationFields(violations []Violation) []string {
+	fields := make([]string, len(violations))
+	for i, v := range violations {
+		fields[i] = v.Field
+	}
+	return fields
+}
+
+func TestCheckRequest(t *testing.T) {
+	ps := getPolicySpecificationFromFile("../../test-files/policy_specification_cloud.json")
+
+	t.Run("compliant", func(t *testing.T) {
+		validity := 90 * 24 * time.Hour
+		request := &certificate.Request{
+			Subject: pkix.Name{
+				CommonName:         "app.venafi.com",
+				Organization:       []string{"Venafi"},
+				OrganizationalUnit: []string{"DevOps"},
+				Locality:           []string{"Salt Lake City"},
+				Province:           []string{"Utah"},
+				Country:            []string{"US"},
+			},
+			DNSNames:         []string{"*.app.venafi.com"},
+			IPAddresses:      []net.IP{net.ParseIP("10.0.0.1")},
+			KeyType:          certificate.KeyTypeRSA,
+			KeyLength:        2048,
+			ValidityDuration: &validity,
+		}
+		violations, err := CheckRequest(ps, request)
+		require.NoError(t, err)
+		require.Empty(t, violations)
+	})
+
+	t.Run("every violation", func(t *testing.T) {
+		validity := 365 * 24 * time.Hour
+		uri, _ := url.Parse("ftp://files.venafi.com")
+		request := &certificate.Request{
+			Subject: pkix.Name{
+				CommonName:         "app.example.com",
+				Organization:       []string{"Example"},
+				OrganizationalUnit: []string{"DevOps", "Sales"},
+				Country:            []string{"US"},
+			},
+			URIs:             []*url.URL{uri},
+			UPNs:             []string{"user@venafi.com"},
+			KeyType:          certificate.KeyTypeRSA,
+			KeyLength:        4096,
+			ValidityDuration: &validity,
+		}
+		violations, err := CheckRequest(ps, request)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{
+			"policy.domains",
+			"policy.maxValidDays",
+			"policy.subject.orgs",
+			"policy.subject.orgUnits",
+			"policy.keyPair.rsaKeySizes",
+			"policy.subjectAltNames.upnAllowed",
+			"policy.subjectAltNames.uriProtocols",
+		}, violationFields(violations))
+	})
+
+	t.Run("CSR", func(t *testing.T) {
+		key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+			Subject:  pkix.Name{CommonName: "*.venafi.com", Organization: []string{"Venafi"}},
+			DNSNames: []string{"www.venafi.com"},
+		}, key)
+		require.NoError(t, err)
+
+		wildcard := false
+		strict := *ps
+		policy := *ps.Policy
+		policy.WildcardAllowed = &wildcard
+		strict.Policy = &policy
+
+		request := &certificate.Request{}
+		err = request.SetCSR(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}))
+		require.NoError(t, err)
+		violations, err := CheckRequest(&strict, request)
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{
+			"policy.wildcardAllowed",
+			"policy.keyPair.keyTypes",
+		}, violationFields(violations))
+	})
+}
+
+func TestCheckCertificate(t *testing.T) {
+	ps := getPolicySpecificationFromFile("../../test-files/policy_specification_cloud.json")
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "app.venafi.com", Country: []string{"CA"}},
+		DNSNames:     []string{"app.venafi.com"},
+		IPAddresses:  []net.IP{net.ParseIP("8.8.8.8")},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(200 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	violations, err := CheckCertificate(ps, cert)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{
+		"policy.maxValidDays",
+		"policy.subject.countries",
+		"policy.keyPair.keyTypes",
+	}, violationFields(violations))
+
+	ps.Policy.SubjectAltNames.IpConstraints = []string{"v4private"}
+	violations, err = CheckCertificate(ps, cert)
+	require.NoError(t, err)
+	require.Contains(t, violationFields(violations), "policy.subjectAltNames.ipConstraints")
+}
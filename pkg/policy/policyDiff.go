@@ -0,0 +1,187 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Change is a field whose value differs between the current and the desired PolicySpecification.
+// An empty value means the field is not set
+type Change struct {
+	// Field is the path of the field in the policy specification, e.g. "policy.subject.orgs"
+	Field   string
+	Current string
+	Desired string
+}
+
+func (c Change) String() string {
+	switch {
+	case c.Current == "":
+		return fmt.Sprintf("+ %s: %s", c.Field, c.Desired)
+	case c.Desired == "":
+		return fmt.Sprintf("- %s: %s", c.Field, c.Current)
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", c.Field, c.Current, c.Desired)
+	}
+}
+
+// TppUnsetValues are the values reported by TPP for the fields that were not set when a policy specification was applied
+var TppUnsetValues = map[string]string{
+	"policy.wildcardAllowed":              "true",
+	"policy.keyPair.reuseAllowed":         "false",
+	"policy.subjectAltNames.dnsAllowed":   "true",
+	"policy.subjectAltNames.ipAllowed":    "true",
+	"policy.subjectAltNames.emailAllowed": "true",
+	"policy.subjectAltNames.uriAllowed":   "true",
+	"policy.subjectAltNames.upnAllowed":   "true",
+	"defaults.autoInstalled":              "false",
+	"defaults.keyPair.keyType":            "RSA",
+	"defaults.keyPair.rsaKeySize":         "2048",
+	"defaults.keyPair.serviceGenerated":   "false",
+}
+
+// CloudUnsetValues are the values reported by VCP for the fields that were not set when a policy specification was applied
+var CloudUnsetValues = map[string]string{
+	"policy.certificateAuthority":       DefaultCA,
+	"policy.domains":                    "[" + AllowAll + "]",
+	"policy.wildcardAllowed":            "false",
+	"policy.maxValidDays":               "365",
+	"policy.subject.orgs":               "[" + AllowAll + "]",
+	"policy.subject.orgUnits":           "[" + AllowAll + "]",
+	"policy.subject.localities":         "[" + AllowAll + "]",
+	"policy.subject.states":             "[" + AllowAll + "]",
+	"policy.subject.countries":          "[" + AllowAll + "]",
+	"policy.keyPair.keyTypes":           "[RSA]",
+	"policy.keyPair.rsaKeySizes":        "[2048]",
+	"policy.keyPair.reuseAllowed":       "false",
+	"policy.subjectAltNames.dnsAllowed": "true",
+}
+
+// DiffPolicySpecifications returns the changes needed to turn the current policy specification into the desired one,
+// sorted by field. Both sides are normalized first, so the order of list values, duplicated and empty list values,
+// and the difference between an empty and a missing value are not reported. A field missing on either side takes
+// its value from unsetValues, which is TppUnsetValues or CloudUnsetValues for a policy retrieved from those platforms
+func DiffPolicySpecifications(current, desired *PolicySpecification, unsetValues map[string]string) ([]Change, error) {
+	currentFields, err := flattenPolicySpecification(current)
+	if err != nil {
+		return nil, err
+	}
+	desiredFields, err := flattenPolicySpecification(desired)
+	if err != nil {
+		return nil, err
+	}
+	for f, v := range unsetValues {
+		if _, ok := currentFields[f]; !ok {
+			currentFields[f] = v
+		}
+		if _, ok := desiredFields[f]; !ok {
+			desiredFields[f] = v
+		}
+	}
+
+	fields := make(map[string]bool)
+	for f := range currentFields {
+		fields[f] = true
+	}
+	for f := range desiredFields {
+		fields[f] = true
+	}
+
+	var changes []Change
+	for f := range fields {
+		if currentFields[f] != desiredFields[f] {
+			changes = append(changes, Change{Field: f, Current: currentFields[f], Desired: desiredFields[f]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+	return changes, nil
+}
+
+// flattenPolicySpecification maps the path of every field set in ps to its normalized value
+func flattenPolicySpecification(ps *PolicySpecification) (map[string]string, error) {
+	fields := make(map[string]string)
+	if ps == nil {
+		return fields, nil
+	}
+
+	// The json names are the ones documented for the policy specification
+	b, err := json.Marshal(ps)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	err = json.Unmarshal(b, &values)
+	if err != nil {
+		return nil, err
+	}
+
+	flattenValue("", values, fields)
+	return fields, nil
+}
+
+func flattenValue(path string, value interface{}, fields map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			flattenValue(childPath, child, fields)
+		}
+	case []interface{}:
+		seen := make(map[string]bool)
+		var items []string
+		for _, item := range v {
+			s := normalizeScalar(item)
+			if s == "" || seen[s] {
+				continue
+			}
+			seen[s] = true
+			items = append(items, s)
+		}
+		if len(items) == 0 {
+			return
+		}
+		sort.Strings(items)
+		fields[path] = "[" + strings.Join(items, ", ") + "]"
+	default:
+		if s := normalizeScalar(v); s != "" {
+			fields[path] = s
+		}
+	}
+}
+
+func normalizeScalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffPolicySpecifications(t *testing.T) {
+	falseBool := false
+	trueBool := true
+	maxValidDays := 90
+	org := "Venafi"
+
+	current := &PolicySpecification{
+		Policy: &Policy{
+			Domains:         []string{"venafi.com", "example.com"},
+			WildcardAllowed: &trueBool,
+			Subject:         &Subject{Orgs: []string{"Venafi"}, OrgUnits: []string{""}},
+			KeyPair:         &KeyPair{KeyTypes: []string{"RSA"}, RsaKeySizes: []int{2048, 4096}},
+		},
+		Default: &Default{Subject: &DefaultSubject{Org: &org}},
+	}
+	desired := &PolicySpecification{
+		Users: []string{"jdoe"},
+		Policy: &Policy{
+			Domains:      []string{"example.com", "venafi.com", "example.com"},
+			MaxValidDays: &maxValidDays,
+			Subject:      &Subject{Orgs: []string{"Venafi"}},
+			KeyPair:      &KeyPair{KeyTypes: []string{"RSA"}, RsaKeySizes: []int{4096}, ReuseAllowed: &falseBool},
+		},
+	}
+
+	changes, err := DiffPolicySpecifications(current, desired, map[string]string{"policy.wildcardAllowed": "true"})
+	require.NoError(t, err)
+	require.Equal(t, []Change{
+		{Field: "defaults.subject.org", Current: "Venafi", Desired: ""},
+		{Field: "policy.keyPair.reuseAllowed", Current: "", Desired: "false"},
+		{Field: "policy.keyPair.rsaKeySizes", Current: "[2048, 4096]", Desired: "[4096]"},
+		{Field: "policy.maxValidDays", Current: "", Desired: "90"},
+		{Field: "users", Current: "", Desired: "[jdoe]"},
+	}, changes)
+
+	require.Equal(t, "- defaults.subject.org: Venafi", changes[0].String())
+	require.Equal(t, "+ policy.keyPair.reuseAllowed: false", changes[1].String())
+	require.Equal(t, "~ policy.keyPair.rsaKeySizes: [2048, 4096] -> [4096]", changes[2].String())
+
+	changes, err = DiffPolicySpecifications(desired, desired, nil)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	t "log"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -149,6 +150,32 @@ func TestBuildTppPolicyWithDefaults(t *testing.T) {
 
 }
 
+func TestBuildPolicySpecificationFromTppPolicy(t *testing.T) {
+	for _, file := range []string{"policy_specification_tpp.json", "policy_specification_tpp_management.json"} {
+		absPath, err := filepath.Abs("../../test-files/" + file)
+		if err != nil {
+			t.Fatalf("Error opening policy specification\nError: %s", err)
+		}
+
+		tppPol := BuildTppPolicy(getPolicySpecificationFromFile(absPath))
+		ps, err := BuildPolicySpecificationFromTppPolicy(tppPol)
+		if err != nil {
+			t.Fatalf("Error building policy specification from %s\nError: %s", file, err)
+		}
+		if rebuilt := BuildTppPolicy(ps); !reflect.DeepEqual(tppPol, rebuilt) {
+			t.Fatalf("%s: attributes changed when read back:\n%+v\n%+v", file, tppPol, rebuilt)
+		}
+	}
+
+	ps, err := BuildPolicySpecificationFromTppPolicy(TppPolicy{})
+	if err != nil {
+		t.Fatalf("Error building empty policy specification\nError: %s", err)
+	}
+	if ps.Policy != nil || ps.Default != nil {
+		t.Fatalf("attributes that are not set should be left out, got %+v", ps)
+	}
+}
+
 func TestValidateTppPolicySpecification(t *testing.T) {
 	absPath, err := filepath.Abs("../../test-files/policy_specification_tpp.json")
 
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 
@@ -465,6 +466,156 @@ func getProhibitedSanTypes(sa SubjectAltNames) []string {
 	return prohibitedSanTypes
 }
 
+// BuildPolicySpecificationFromTppPolicy returns the policy specification of the attributes of a policy folder, as
+// written by BuildTppPolicy. Locked attributes are policy values and the others are defaults. Unlike
+// BuildPolicySpecificationForTPP, the attributes that are not set are left out of the policy specification
+func BuildPolicySpecificationFromTppPolicy(tppPolicy TppPolicy) (*PolicySpecification, error) {
+	var ps PolicySpecification
+	var p Policy
+	var subject Subject
+	var keyPair KeyPair
+	var def Default
+	var defaultSubject DefaultSubject
+	var defaultKeyPair DefaultKeyPair
+
+	ps.Users = tppPolicy.Contact
+	ps.Approvers = tppPolicy.Approver
+
+	p.Domains = tppPolicy.DomainSuffixWhitelist
+	if tppPolicy.ProhibitWildcard != nil {
+		wildcardAllowed := *(tppPolicy.ProhibitWildcard) == 0
+		p.WildcardAllowed = &wildcardAllowed
+	}
+	p.CertificateAuthority = tppPolicy.CertificateAuthority
+
+	if tppPolicy.ManagementType != nil {
+		autoInstalled := tppPolicy.ManagementType.Value == TppManagementTypeProvisioning
+		if tppPolicy.ManagementType.Locked {
+			p.AutoInstalled = &autoInstalled
+		} else {
+			def.AutoInstalled = &autoInstalled
+		}
+	}
+
+	//subject attributes
+	if a := tppPolicy.Organization; a != nil {
+		if a.Locked {
+			subject.Orgs = []string{a.Value}
+		} else {
+			defaultSubject.Org = &a.Value
+		}
+	}
+	if a := tppPolicy.OrganizationalUnit; a != nil {
+		if a.Locked {
+			subject.OrgUnits = a.Value
+		} else {
+			defaultSubject.OrgUnits = a.Value
+		}
+	}
+	if a := tppPolicy.City; a != nil {
+		if a.Locked {
+			subject.Localities = []string{a.Value}
+		} else {
+			defaultSubject.Locality = &a.Value
+		}
+	}
+	if a := tppPolicy.State; a != nil {
+		if a.Locked {
+			subject.States = []string{a.Value}
+		} else {
+			defaultSubject.State = &a.Value
+		}
+	}
+	if a := tppPolicy.Country; a != nil {
+		if a.Locked {
+			subject.Countries = []string{a.Value}
+		} else {
+			defaultSubject.Country = &a.Value
+		}
+	}
+
+	//key pair attributes
+	if a := tppPolicy.KeyAlgorithm; a != nil {
+		if a.Locked {
+			keyPair.KeyTypes = []string{a.Value}
+		} else {
+			defaultKeyPair.KeyType = &a.Value
+		}
+	}
+	if a := tppPolicy.KeyBitStrength; a != nil {
+		rsaKeySize, err := strconv.Atoi(a.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", TppKeyBitStrength, a.Value, err)
+		}
+		if a.Locked {
+			keyPair.RsaKeySizes = []int{rsaKeySize}
+		} else {
+			defaultKeyPair.RsaKeySize = &rsaKeySize
+		}
+	}
+	if a := tppPolicy.EllipticCurve; a != nil {
+		if a.Locked {
+			keyPair.EllipticCurves = []string{a.Value}
+		} else {
+			defaultKeyPair.EllipticCurve = &a.Value
+		}
+	}
+	if a := tppPolicy.ManualCsr; a != nil {
+		//a manual csr is a user provided one, so the service doesn't generate the key pair
+		serviceGenerated := a.Value == "0"
+		if a.Locked {
+			keyPair.ServiceGenerated = &serviceGenerated
+		} else {
+			defaultKeyPair.ServiceGenerated = &serviceGenerated
+		}
+	}
+	if tppPolicy.AllowPrivateKeyReuse != nil {
+		reuseAllowed := *(tppPolicy.AllowPrivateKeyReuse) == 1
+		keyPair.ReuseAllowed = &reuseAllowed
+	}
+
+	if len(tppPolicy.ProhibitedSANType) > 0 {
+		var subjectAltNames SubjectAltNames
+		falseVal := false
+		for _, sanType := range tppPolicy.ProhibitedSANType {
+			switch sanType {
+			case TppDnsAllowed:
+				subjectAltNames.DnsAllowed = &falseVal
+			case TppIpAllowed:
+				subjectAltNames.IpAllowed = &falseVal
+			case TppEmailAllowed:
+				subjectAltNames.EmailAllowed = &falseVal
+			case TppUriAllowed:
+				subjectAltNames.UriAllowed = &falseVal
+			case TppUpnAllowed:
+				subjectAltNames.UpnAllowed = &falseVal
+			}
+		}
+		p.SubjectAltNames = &subjectAltNames
+	}
+
+	if !reflect.DeepEqual(subject, Subject{}) {
+		p.Subject = &subject
+	}
+	if !reflect.DeepEqual(keyPair, KeyPair{}) {
+		p.KeyPair = &keyPair
+	}
+	if !reflect.DeepEqual(p, Policy{}) {
+		ps.Policy = &p
+	}
+	if !reflect.DeepEqual(defaultSubject, DefaultSubject{}) {
+		def.Subject = &defaultSubject
+	}
+	if !reflect.DeepEqual(defaultKeyPair, DefaultKeyPair{}) {
+		def.KeyPair = &defaultKeyPair
+	}
+	if !reflect.DeepEqual(def, Default{}) {
+		ps.Default = &def
+	}
+
+	return &ps, nil
+}
+
 func BuildPolicySpecificationForTPP(checkPolicyResp CheckPolicyResponse) (*PolicySpecification, error) {
 
 	if checkPolicyResp.Policy == nil {
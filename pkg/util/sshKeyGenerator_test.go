@@ -1,10 +1,14 @@
 package util
 
 import (
+	"encoding/pem"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/smartystreets/assertions"
+	"github.com/youmark/pkcs8"
+	"golang.org/x/crypto/ssh"
 )
 
 func TestGenerateSshKeyPair(t *testing.T) {
@@ -76,3 +80,92 @@ func TestGenerateSshKeyPairLegacyPemPassLess(t *testing.T) {
 	}
 
 }
+
+func TestGenerateSshKeyPairWithOptions(t *testing.T) {
+	cases := []struct {
+		opts          SshKeyPairOptions
+		publicKeyType string
+		pemType       string
+	}{
+		{SshKeyPairOptions{KeyType: SshKeyTypeED25519}, ssh.KeyAlgoED25519, "OPENSSH PRIVATE KEY"},
+		{SshKeyPairOptions{KeyType: SshKeyTypeED25519, Passphrase: "1234"}, ssh.KeyAlgoED25519, "OPENSSH PRIVATE KEY"},
+		{SshKeyPairOptions{KeyType: SshKeyTypeED25519, Format: "pem"}, ssh.KeyAlgoED25519, "PRIVATE KEY"},
+		{SshKeyPairOptions{KeyType: SshKeyTypeECDSA}, ssh.KeyAlgoECDSA256, "OPENSSH PRIVATE KEY"},
+		{SshKeyPairOptions{KeyType: SshKeyTypeECDSA, KeySize: 384, Passphrase: "1234"}, ssh.KeyAlgoECDSA384, "OPENSSH PRIVATE KEY"},
+		{SshKeyPairOptions{KeyType: SshKeyTypeECDSA, KeySize: 521, Format: "pem", Passphrase: "1234"}, ssh.KeyAlgoECDSA521, "ENCRYPTED PRIVATE KEY"},
+		{SshKeyPairOptions{KeyType: SshKeyTypeECDSA, Format: LegacyPem}, ssh.KeyAlgoECDSA256, EcPrivKeyType},
+		{SshKeyPairOptions{KeyType: SshKeyTypeRSA, KeySize: 2048, Format: OpenSshFormat, Passphrase: "1234"}, ssh.KeyAlgoRSA, "OPENSSH PRIVATE KEY"},
+		{SshKeyPairOptions{KeySize: 2048}, ssh.KeyAlgoRSA, "PRIVATE KEY"},
+	}
+	for _, c := range cases {
+		name := fmt.Sprintf("%s-%d-%s-%t", c.opts.KeyType, c.opts.KeySize, c.opts.Format, c.opts.Passphrase != "")
+		t.Run(name, func(t *testing.T) {
+			c.opts.Comment = "cert-test"
+			privKey, publicKey, err := GenerateSshKeyPairWithOptions(c.opts)
+			if err != nil {
+				t.Fatalf("Error building ssh keys \nError: %s", err)
+			}
+
+			parsedPublicKey, comment, _, _, err := ssh.ParseAuthorizedKey(publicKey)
+			if err != nil {
+				t.Fatalf("failed to parse public key: %s", err)
+			}
+			if parsedPublicKey.Type() != c.publicKeyType || comment != "cert-test" {
+				t.Fatalf("unexpected public key %s", publicKey)
+			}
+
+			block, _ := pem.Decode(privKey)
+			if block == nil || block.Type != c.pemType {
+				t.Fatalf("unexpected private key %s", privKey)
+			}
+			var parsed interface{}
+			switch {
+			case block.Type == "ENCRYPTED PRIVATE KEY":
+				parsed, err = pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(c.opts.Passphrase))
+			case c.opts.Passphrase != "":
+				parsed, err = ssh.ParseRawPrivateKeyWithPassphrase(privKey, []byte(c.opts.Passphrase))
+			default:
+				parsed, err = ssh.ParseRawPrivateKey(privKey)
+			}
+			if err != nil {
+				t.Fatalf("failed to parse private key: %s", err)
+			}
+			signer, err := ssh.NewSignerFromKey(parsed)
+			if err != nil {
+				t.Fatalf("failed to use private key: %s", err)
+			}
+			if string(signer.PublicKey().Marshal()) != string(parsedPublicKey.Marshal()) {
+				t.Fatalf("the private key does not match the public key")
+			}
+		})
+	}
+}
+
+func TestGenerateSshKeyPairWithOptionsErrors(t *testing.T) {
+	for _, opts := range []SshKeyPairOptions{
+		{KeyType: "dsa"},
+		{KeyType: SshKeyTypeECDSA, KeySize: 512},
+		{KeyType: SshKeyTypeED25519, Format: LegacyPem},
+	} {
+		_, _, err := GenerateSshKeyPairWithOptions(opts)
+		if err == nil {
+			t.Fatalf("expected an error for %+v", opts)
+		}
+	}
+}
+
+func TestNormalizeSshPublicKey(t *testing.T) {
+	_, publicKey, err := GenerateSshKeyPairWithOptions(SshKeyPairOptions{KeyType: SshKeyTypeED25519, Comment: "my-key"})
+	if err != nil {
+		t.Fatalf("Error building ssh keys \nError: %s", err)
+	}
+
+	fields := strings.Fields(string(publicKey))
+	data := "  " + fields[0] + "  " + fields[1] + "\tmy-key\r\n"
+	if normalized := NormalizeSshPublicKey(data); normalized != string(publicKey) {
+		t.Fatalf("unexpected normalized public key %q", normalized)
+	}
+	if normalized := NormalizeSshPublicKey("not a key\n"); normalized != "not a key" {
+		t.Fatalf("unexpected normalized public key %q", normalized)
+	}
+}
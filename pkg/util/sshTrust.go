@@ -0,0 +1,242 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+
+	playbookutil "github.com/Venafi/vcert/v5/pkg/playbook/util"
+)
+
+const (
+	sshTrustBeginMarker = "# BEGIN VCERT SSH CA %s"
+	sshTrustEndMarker   = "# END VCERT SSH CA %s"
+
+	// sshTrustFileMode is the mode of the trust files created by the SSH CA trust writers. The mode of an existing
+	// file is kept
+	sshTrustFileMode os.FileMode = 0644
+)
+
+// SshTrustFile is a file managed by an SSH CA trust writer
+type SshTrustFile struct {
+	Path string
+	// Content is the content of the file once the CA is trusted
+	Content []byte
+	// Changed is false when the file already trusts the CA
+	Changed bool
+}
+
+// SshUserCaTrust makes sshd accept the user certificates signed by an SSH CA
+type SshUserCaTrust struct {
+	// Name identifies the CA in the managed files, e.g. the name of its template. The lines of the CA are replaced
+	// in place when it rotates, leaving the lines of the other CAs untouched
+	Name string
+	// CaPublicKeys are the CA public keys in the authorized_keys format, as returned by RetrieveSshConfig
+	CaPublicKeys string
+	// KeysFile is the TrustedUserCAKeys file of sshd, e.g. /etc/ssh/trusted_user_ca_keys
+	KeysFile string
+	// ConfigFile is an sshd_config drop-in setting TrustedUserCAKeys to KeysFile, e.g.
+	// /etc/ssh/sshd_config.d/vcert.conf. It is not written when empty
+	ConfigFile string
+}
+
+// SshHostCaTrust makes ssh accept the host certificates signed by an SSH CA
+type SshHostCaTrust struct {
+	// Name identifies the CA in the managed files, as for SshUserCaTrust
+	Name string
+	// CaPublicKeys are the CA public keys in the authorized_keys format, as returned by RetrieveSshConfig
+	CaPublicKeys string
+	// KnownHostsFile is the known_hosts file in which the @cert-authority lines are written
+	KnownHostsFile string
+	// HostPatterns are the hosts whose certificates are signed by the CA, e.g. *.example.com. Defaults to *
+	HostPatterns []string
+}
+
+// Write writes the TrustedUserCAKeys file and the sshd_config drop-in, unless dryRun is true.
+// Only the changed files are written, so that sshd is reloaded only when the CA changed
+func (t SshUserCaTrust) Write(dryRun bool) ([]SshTrustFile, error) {
+	if t.KeysFile == "" {
+		return nil, fmt.Errorf("the TrustedUserCAKeys file is required")
+	}
+	keys, err := parseSshCaPublicKeys(t.CaPublicKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]SshTrustFile, 0, 2)
+	keysFile, err := sshTrustBlockFile(t.KeysFile, t.Name, keys)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, keysFile)
+
+	if t.ConfigFile != "" {
+		// sshd only uses the first TrustedUserCAKeys it reads, so the drop-in is owned by VCert as a whole
+		content := fmt.Sprintf("# Managed by VCert, changes will be overwritten\nTrustedUserCAKeys %s\n", t.KeysFile)
+		configFile, err := sshTrustWholeFile(t.ConfigFile, []byte(content))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, configFile)
+	}
+
+	if dryRun {
+		return files, nil
+	}
+	return files, writeSshTrustFiles(files)
+}
+
+// Write writes the @cert-authority lines of the CA in the known_hosts file, unless dryRun is true.
+// The file is only written when it changed
+func (t SshHostCaTrust) Write(dryRun bool) ([]SshTrustFile, error) {
+	if t.KnownHostsFile == "" {
+		return nil, fmt.Errorf("the known_hosts file is required")
+	}
+	keys, err := parseSshCaPublicKeys(t.CaPublicKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns := "*"
+	if len(t.HostPatterns) > 0 {
+		patterns = strings.Join(t.HostPatterns, ",")
+	}
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("@cert-authority %s %s", patterns, key))
+	}
+
+	file, err := sshTrustBlockFile(t.KnownHostsFile, t.Name, lines)
+	if err != nil {
+		return nil, err
+	}
+	files := []SshTrustFile{file}
+
+	if dryRun {
+		return files, nil
+	}
+	return files, writeSshTrustFiles(files)
+}
+
+// parseSshCaPublicKeys returns the keys of caPublicKeys as "type base64" lines, without their comments
+func parseSshCaPublicKeys(caPublicKeys string) ([]string, error) {
+	var keys []string
+	rest := []byte(caPublicKeys)
+	for len(bytes.TrimSpace(rest)) > 0 {
+		var key ssh.PublicKey
+		var err error
+		key, _, _, rest, err = ssh.ParseAuthorizedKey(rest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SSH CA public key: %w", err)
+		}
+		keys = append(keys, strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))))
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no SSH CA public key found")
+	}
+	return keys, nil
+}
+
+// sshTrustBlockFile returns the file at path with the block of the CA name replaced by lines, or appended when the
+// file has no block for the CA yet
+func sshTrustBlockFile(path string, name string, lines []string) (SshTrustFile, error) {
+	if name == "" {
+		return SshTrustFile{}, fmt.Errorf("the name of the SSH CA is required")
+	}
+	current, err := readSshTrustFile(path)
+	if err != nil {
+		return SshTrustFile{}, err
+	}
+
+	begin := fmt.Sprintf(sshTrustBeginMarker, name)
+	end := fmt.Sprintf(sshTrustEndMarker, name)
+	block := append(append([]string{begin}, lines...), end)
+
+	existing := strings.Split(strings.TrimSuffix(string(current), "\n"), "\n")
+	if len(current) == 0 {
+		existing = nil
+	}
+	start, stop := -1, -1
+	for i, line := range existing {
+		switch strings.TrimSpace(line) {
+		case begin:
+			start = i
+		case end:
+			if start >= 0 && stop < 0 {
+				stop = i
+			}
+		}
+	}
+
+	var updated []string
+	if start >= 0 && stop > start {
+		updated = append(updated, existing[:start]...)
+		updated = append(updated, block...)
+		updated = append(updated, existing[stop+1:]...)
+	} else {
+		updated = append(existing, block...)
+	}
+	content := []byte(strings.Join(updated, "\n") + "\n")
+
+	return SshTrustFile{Path: path, Content: content, Changed: !bytes.Equal(current, content)}, nil
+}
+
+// sshTrustWholeFile returns the file at path with content
+func sshTrustWholeFile(path string, content []byte) (SshTrustFile, error) {
+	current, err := readSshTrustFile(path)
+	if err != nil {
+		return SshTrustFile{}, err
+	}
+	return SshTrustFile{Path: path, Content: content, Changed: !bytes.Equal(current, content)}, nil
+}
+
+// readSshTrustFile returns the content of the file at path, or nil when it does not exist
+func readSshTrustFile(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return content, nil
+}
+
+// writeSshTrustFiles writes the changed files. Each file is replaced through a rename, so that sshd never reads a
+// partially written file
+func writeSshTrustFiles(files []SshTrustFile) error {
+	for _, f := range files {
+		if !f.Changed {
+			continue
+		}
+		mode := sshTrustFileMode
+		if info, err := os.Stat(f.Path); err == nil {
+			mode = info.Mode().Perm()
+		}
+		err := playbookutil.WriteFileWithOptions(f.Path, f.Content, playbookutil.FileOptions{Mode: mode})
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
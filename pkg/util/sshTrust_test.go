@@ -0,0 +1,127 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSshCaPublicKey(t *testing.T) string {
+	_, publicKey, err := GenerateSshKeyPairWithOptions(SshKeyPairOptions{KeyType: SshKeyTypeED25519, Comment: "ca"})
+	require.NoError(t, err)
+	return string(publicKey)
+}
+
+func TestSshUserCaTrust(t *testing.T) {
+	dir := t.TempDir()
+	caKey := newTestSshCaPublicKey(t)
+	trust := SshUserCaTrust{
+		Name:         "Users",
+		CaPublicKeys: caKey,
+		KeysFile:     filepath.Join(dir, "trusted_user_ca_keys"),
+		ConfigFile:   filepath.Join(dir, "sshd_config.d", "vcert.conf"),
+	}
+	other := "# BEGIN VCERT SSH CA Admins\nssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl\n# END VCERT SSH CA Admins\n"
+	require.NoError(t, os.WriteFile(trust.KeysFile, []byte(other), 0600))
+
+	// nothing is written on a dry run
+	files, err := trust.Write(true)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.True(t, files[0].Changed)
+	require.True(t, files[1].Changed)
+	require.NoFileExists(t, trust.ConfigFile)
+
+	files, err = trust.Write(false)
+	require.NoError(t, err)
+	content, err := os.ReadFile(trust.KeysFile)
+	require.NoError(t, err)
+	key := strings.Join(strings.Fields(caKey)[:2], " ")
+	require.Equal(t, other+"# BEGIN VCERT SSH CA Users\n"+key+"\n# END VCERT SSH CA Users\n", string(content))
+	require.Equal(t, files[0].Content, content)
+	info, err := os.Stat(trust.KeysFile)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm(), "the mode of an existing file is kept")
+
+	content, err = os.ReadFile(trust.ConfigFile)
+	require.NoError(t, err)
+	require.Contains(t, string(content), "\nTrustedUserCAKeys "+trust.KeysFile+"\n")
+
+	// a second write changes nothing
+	files, err = trust.Write(false)
+	require.NoError(t, err)
+	require.False(t, files[0].Changed)
+	require.False(t, files[1].Changed)
+
+	// the rotated CA replaces the previous one in place
+	rotated := newTestSshCaPublicKey(t)
+	trust.CaPublicKeys = rotated + "\n" + caKey
+	files, err = trust.Write(false)
+	require.NoError(t, err)
+	require.True(t, files[0].Changed)
+	require.False(t, files[1].Changed)
+	content, err = os.ReadFile(trust.KeysFile)
+	require.NoError(t, err)
+	rotatedKey := strings.Join(strings.Fields(rotated)[:2], " ")
+	require.Equal(t, other+"# BEGIN VCERT SSH CA Users\n"+rotatedKey+"\n"+key+"\n# END VCERT SSH CA Users\n", string(content))
+
+	trust.CaPublicKeys = "not a key"
+	_, err = trust.Write(false)
+	require.ErrorContains(t, err, "failed to parse SSH CA public key")
+}
+
+func TestSshHostCaTrust(t *testing.T) {
+	dir := t.TempDir()
+	caKey := newTestSshCaPublicKey(t)
+	trust := SshHostCaTrust{
+		Name:           "Hosts",
+		CaPublicKeys:   caKey,
+		KnownHostsFile: filepath.Join(dir, ".ssh", "known_hosts"),
+		HostPatterns:   []string{"*.example.com", "10.0.0.*"},
+	}
+
+	files, err := trust.Write(false)
+	require.NoError(t, err)
+	require.True(t, files[0].Changed)
+	content, err := os.ReadFile(trust.KnownHostsFile)
+	require.NoError(t, err)
+	key := strings.Join(strings.Fields(caKey)[:2], " ")
+	require.Equal(t, "# BEGIN VCERT SSH CA Hosts\n@cert-authority *.example.com,10.0.0.* "+key+"\n# END VCERT SSH CA Hosts\n", string(content))
+	info, err := os.Stat(trust.KnownHostsFile)
+	require.NoError(t, err)
+	require.Equal(t, sshTrustFileMode, info.Mode().Perm())
+
+	// the lines added by ssh are kept
+	hostLine := "web-01.example.com ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl\n"
+	require.NoError(t, os.WriteFile(trust.KnownHostsFile, append(content, hostLine...), 0644))
+	trust.HostPatterns = nil
+	files, err = trust.Write(false)
+	require.NoError(t, err)
+	require.True(t, files[0].Changed)
+	content, err = os.ReadFile(trust.KnownHostsFile)
+	require.NoError(t, err)
+	require.Equal(t, "# BEGIN VCERT SSH CA Hosts\n@cert-authority * "+key+"\n# END VCERT SSH CA Hosts\n"+hostLine, string(content))
+
+	files, err = trust.Write(true)
+	require.NoError(t, err)
+	require.False(t, files[0].Changed)
+}
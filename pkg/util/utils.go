@@ -1,6 +1,7 @@
 package util
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/rand"
@@ -86,6 +87,18 @@ func EncryptPkcs1PrivateKey(privateKey, password string) (string, error) {
 	return string(pem.EncodeToMemory(encrypted)), nil
 }
 
+// SleepContext pauses for the duration d, or until ctx is done. It returns the error of ctx when ctx is done first
+func SleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
 func GetBooleanRef(val bool) *bool {
 	return &val
 }
@@ -18,13 +18,12 @@ package cloud
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha1"
-	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
-	"net"
 	"net/http"
 	"net/url"
 	"sort"
@@ -36,6 +35,7 @@ import (
 
 	"github.com/Venafi/vcert/v5/pkg/certificate"
 	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/httputils"
 	"github.com/Venafi/vcert/v5/pkg/policy"
 	"github.com/Venafi/vcert/v5/pkg/util"
 	"github.com/Venafi/vcert/v5/pkg/verror"
@@ -120,6 +120,43 @@ type certificateRequest struct {
 	ApplicationServerTypeId  string                       `json:"applicationServerTypeId,omitempty"`
 }
 
+type certificateRevokeRequest struct {
+	CertificateIds    []string         `json:"certificateIds"`
+	RevocationReason  RevocationReason `json:"revocationReason"`
+	RevocationComment string           `json:"revocationComment,omitempty"`
+}
+
+type certificateRevokeResponse struct {
+	Count              int                           `json:"count"`
+	RevocationRequests []certificateRevocationStatus `json:"revocationRequests"`
+}
+
+type certificateRevocationStatus struct {
+	Id               string                            `json:"id,omitempty"`
+	CertificateId    string                            `json:"certificateId,omitempty"`
+	Status           string                            `json:"status,omitempty"`
+	ErrorInformation CertificateStatusErrorInformation `json:"errorInformation,omitempty"`
+}
+
+type RevocationReason string
+
+const (
+	revocationStatusRevoked  = "REVOKED"
+	revocationStatusFailed   = "FAILED"
+	revocationStatusRejected = "REJECTED"
+)
+
+// RevocationReasonsMap maps *certificate.RevocationRequest.Reason to Venafi Cloud revocation reasons
+var RevocationReasonsMap = map[string]RevocationReason{
+	"":                       "UNSPECIFIED",
+	"none":                   "UNSPECIFIED",
+	"key-compromise":         "KEY_COMPROMISE",
+	"ca-compromise":          "CA_COMPROMISE",
+	"affiliation-changed":    "AFFILIATION_CHANGED",
+	"superseded":             "SUPERSEDED",
+	"cessation-of-operation": "CESSATION_OF_OPERATION",
+}
+
 type certificateRetireRequest struct {
 	CertificateIds []string `json:"certificateIds,omitempty"`
 	AddToBlocklist bool     `json:"addToBlocklist,omitempty"`
@@ -241,10 +278,15 @@ type ApplicationDetails struct {
 
 // GenerateRequest generates a CertificateRequest based on the zone configuration, and returns the request along with the private key.
 func (c *Connector) GenerateRequest(config *endpoint.ZoneConfiguration, req *certificate.Request) (err error) {
+	return c.GenerateRequestContext(context.Background(), config, req)
+}
+
+// GenerateRequestContext is GenerateRequest with a context that cancels its requests to the platform
+func (c *Connector) GenerateRequestContext(ctx context.Context, config *endpoint.ZoneConfiguration, req *certificate.Request) (err error) {
 	switch req.CsrOrigin {
 	case certificate.LocalGeneratedCSR:
 		if config == nil {
-			config, err = c.ReadZoneConfiguration()
+			config, err = c.ReadZoneConfigurationContext(ctx)
 			if err != nil {
 				return fmt.Errorf("could not read zone configuration: %w", err)
 			}
@@ -280,38 +322,11 @@ func (c *Connector) getHTTPClient() *http.Client {
 	if c.client != nil {
 		return c.client
 	}
-	var netTransport = &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-		}).DialContext,
-		MaxIdleConns:          100,
-		IdleConnTimeout:       90 * time.Second,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-	}
-	tlsConfig := http.DefaultTransport.(*http.Transport).TLSClientConfig
-	/* #nosec */
-	if c.trust != nil {
-		if tlsConfig == nil {
-			tlsConfig = &tls.Config{
-				MinVersion: tls.VersionTLS12,
-			}
-		} else {
-			tlsConfig = tlsConfig.Clone()
-		}
-		tlsConfig.RootCAs = c.trust
-	}
-	netTransport.TLSClientConfig = tlsConfig
-	c.client = &http.Client{
-		Timeout:   time.Second * 30,
-		Transport: netTransport,
-	}
+	c.client = httputils.NewClient(c.trust, c.retryPolicy)
 	return c.client
 }
 
-func (c *Connector) request(method string, url string, data interface{}, authNotRequired ...bool) (statusCode int, statusText string, body []byte, err error) {
+func (c *Connector) request(ctx context.Context, method string, url string, data interface{}, authNotRequired ...bool) (statusCode int, statusText string, body []byte, err error) {
 	if (c.accessToken == "" && c.user == nil) || (c.user != nil && c.user.Company == nil) {
 		if !(len(authNotRequired) == 1 && authNotRequired[0]) {
 			err = fmt.Errorf("%w: must be autheticated to make requests to TLSPC API", verror.VcertError)
@@ -326,7 +341,7 @@ func (c *Connector) request(method string, url string, data interface{}, authNot
 		payload = bytes.NewReader(b)
 	}
 
-	r, err := http.NewRequest(method, url, payload)
+	r, err := http.NewRequestWithContext(ctx, method, url, payload)
 	if err != nil {
 		err = fmt.Errorf("%w: %v", verror.VcertError, err)
 		return
@@ -351,7 +366,7 @@ func (c *Connector) request(method string, url string, data interface{}, authNot
 
 	res, err := httpClient.Do(r)
 	if err != nil {
-		err = fmt.Errorf("%w: %v", verror.ServerUnavailableError, err)
+		err = fmt.Errorf("%w: %w", verror.ServerUnavailableError, err)
 		return
 	}
 	statusCode = res.StatusCode
@@ -565,6 +580,31 @@ func checkCertificateRetireResults(httpStatusCode int, httpStatus string, body [
 	}
 }
 
+func parseRevokeResult(httpStatusCode int, httpStatus string, body []byte) (*certificateRevokeResponse, error) {
+	switch httpStatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
+		resp, err := parseJSON[certificateRevokeResponse](body, verror.ServerError)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.RevocationRequests) == 0 {
+			return nil, fmt.Errorf("invalid thumbprint or certificate ID. No certificates were revoked")
+		}
+		return resp, nil
+	default:
+		respErrors, err := parseResponseErrors(body)
+		if err != nil {
+			return nil, err
+		}
+
+		respError := fmt.Sprintf("Unexpected status code on Venafi Cloud certificate revocation. Status: %s\n", httpStatus)
+		for _, e := range respErrors {
+			respError += fmt.Sprintf("Error Code: %d Error: %s\n", e.Code, e.Message)
+		}
+		return nil, fmt.Errorf("%w: %v", verror.ServerError, respError)
+	}
+}
+
 func newPEMCollectionFromResponse(data []byte, chainOrder certificate.ChainOption) (*certificate.PEMCollection, error) {
 	return certificate.PEMCollectionFromBytes(data, chainOrder)
 }
@@ -1,6 +1,7 @@
 package cloud
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -65,9 +66,9 @@ func Load32KeyByte(keyBytes []byte) (*[32]byte, error) {
 	return key, nil
 }
 
-func getCsrAttributes(c *Connector, req *certificate.Request) (*CsrAttributes, error) {
+func getCsrAttributes(ctx context.Context, c *Connector, req *certificate.Request) (*CsrAttributes, error) {
 	zone := c.zone.zone
-	policy, err := c.GetPolicyWithRegex(zone)
+	policy, err := c.GetPolicyWithRegexContext(ctx, zone)
 
 	if err != nil {
 		return nil, err
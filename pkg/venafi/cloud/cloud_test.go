@@ -303,3 +303,27 @@ func TestParseCertificateRetrieveResponse(t *testing.T) {
 		t.Fatalf("err is not nil, err: %s", err)
 	}
 }
+
+func TestParseRevokeResponse(t *testing.T) {
+	successRevokeCertificate := []byte(`{"count": 1, "revocationRequests": [{"id": "a9f0e4c0-2c27-11ef-9bc6-4f8b2a5e0a66", "certificateId": "04bad390-f118-11e5-8b33-d96cf8021ce5", "status": "SUBMITTED"}]}`)
+	emptyRevokeCertificate := []byte(`{"count": 0, "revocationRequests": []}`)
+	errorRevokeCertificate := []byte(`{"errors": [{"code": 10727,"message": "Unable to find certificate with id 04bad390-f118-11e5-8b33-d96cf8021ce5","args": ["04bad390-f118-11e5-8b33-d96cf8021ce5"]}]}`)
+
+	resp, err := parseRevokeResult(http.StatusOK, "", successRevokeCertificate)
+	require.NoError(t, err)
+	require.Len(t, resp.RevocationRequests, 1)
+	require.Equal(t, "SUBMITTED", resp.RevocationRequests[0].Status)
+
+	_, err = parseRevokeResult(http.StatusOK, "", emptyRevokeCertificate)
+	require.Error(t, err)
+
+	_, err = parseRevokeResult(http.StatusNotFound, "Not Found", errorRevokeCertificate)
+	require.ErrorIs(t, err, verror.ServerError)
+}
+
+func TestRevocationReasonsMap(t *testing.T) {
+	for _, reason := range []string{"", "none", "key-compromise", "ca-compromise", "affiliation-changed", "superseded", "cessation-of-operation"} {
+		_, ok := RevocationReasonsMap[reason]
+		require.Truef(t, ok, "revocation reason %q is not mapped", reason)
+	}
+}
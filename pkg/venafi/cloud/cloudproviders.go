@@ -40,6 +40,11 @@ const (
 )
 
 func (c *Connector) ProvisionCertificate(req *domain.ProvisioningRequest, options *domain.ProvisioningOptions) (*domain.ProvisioningMetadata, error) {
+	return c.ProvisionCertificateContext(context.Background(), req, options)
+}
+
+// ProvisionCertificateContext is ProvisionCertificate with a context that cancels its requests to the platform
+func (c *Connector) ProvisionCertificateContext(ctx context.Context, req *domain.ProvisioningRequest, options *domain.ProvisioningOptions) (*domain.ProvisioningMetadata, error) {
 	log.Printf("Starting Provisioning Flow")
 
 	if req == nil {
@@ -57,7 +62,7 @@ func (c *Connector) ProvisionCertificate(req *domain.ProvisioningRequest, option
 			return nil, fmt.Errorf("no Certificate ID or Pickup ID were provided for provisioning")
 		}
 		log.Printf("Certificate ID was not provided in request. Fetching it by using Pickup ID %s", *(reqData.PickupID))
-		certID, err := c.getCertIDFromPickupID(*(reqData.PickupID), reqData.Timeout)
+		certID, err := c.getCertIDFromPickupID(ctx, *(reqData.PickupID), reqData.Timeout)
 		if err != nil {
 			return nil, err
 		}
@@ -68,7 +73,7 @@ func (c *Connector) ProvisionCertificate(req *domain.ProvisioningRequest, option
 
 	// Is certificate generated by VCP?
 	log.Printf("Validating if certificate is generated by VCP")
-	err := c.validateIfCertIsVCPGeneratedByID(*(reqData.CertificateID))
+	err := c.validateIfCertIsVCPGeneratedByID(ctx, *(reqData.CertificateID))
 	if err != nil {
 		return nil, err
 	}
@@ -89,7 +94,7 @@ func (c *Connector) ProvisionCertificate(req *domain.ProvisioningRequest, option
 		providerNameInput := util.StringPointerToString(reqData.ProviderName)
 
 		log.Printf("fetching keystore with KeystoreID: %s, KeystoreName: %s, ProviderName: %s", keystoreIDInput, keystoreNameInput, providerNameInput)
-		cloudKeystore, err = c.GetCloudKeystore(domain.GetCloudKeystoreRequest{
+		cloudKeystore, err = c.GetCloudKeystoreContext(ctx, domain.GetCloudKeystoreRequest{
 			CloudProviderName: req.ProviderName,
 			CloudKeystoreID:   req.KeystoreID,
 			CloudKeystoreName: req.KeystoreName,
@@ -114,18 +119,18 @@ func (c *Connector) ProvisionCertificate(req *domain.ProvisioningRequest, option
 	}
 
 	wsClientID := uuid.New().String()
-	wsConn, err := c.notificationSvcClient.Subscribe(wsClientID)
+	wsConn, err := c.notificationSvcClient.SubscribeContext(ctx, wsClientID)
 	if err != nil {
 		return nil, err
 	}
 
 	log.Printf("Provisioning Certificate ID %s for Keystore %s", certificateIDString, cloudKeystore.ID)
-	_, err = c.cloudProvidersClient.ProvisionCertificate(context.Background(), certificateIDString, cloudKeystore.ID, wsClientID, provisioningOptions)
+	_, err = c.cloudProvidersClient.ProvisionCertificate(ctx, certificateIDString, cloudKeystore.ID, wsClientID, provisioningOptions)
 	if err != nil {
 		return nil, err
 	}
 
-	workflowResponse, err := c.notificationSvcClient.ReadResponse(wsConn)
+	workflowResponse, err := c.notificationSvcClient.ReadResponseContext(ctx, wsConn)
 	if err != nil {
 		return nil, err
 	}
@@ -143,6 +148,11 @@ func (c *Connector) ProvisionCertificate(req *domain.ProvisioningRequest, option
 }
 
 func (c *Connector) ProvisionCertificateToMachineIdentity(req domain.ProvisioningRequest) (*domain.ProvisioningMetadata, error) {
+	return c.ProvisionCertificateToMachineIdentityContext(context.Background(), req)
+}
+
+// ProvisionCertificateToMachineIdentityContext is ProvisionCertificateToMachineIdentity with a context that cancels its requests to the platform
+func (c *Connector) ProvisionCertificateToMachineIdentityContext(ctx context.Context, req domain.ProvisioningRequest) (*domain.ProvisioningMetadata, error) {
 	log.Printf("Starting Provisioning to Machine Identity Flow")
 
 	if req.MachineIdentityID == nil {
@@ -162,7 +172,7 @@ func (c *Connector) ProvisionCertificateToMachineIdentity(req domain.Provisionin
 		}
 
 		log.Printf("Certificate ID was not provided in request. Using Pickup ID %s to fetch it", *req.PickupID)
-		certID, err := c.getCertIDFromPickupID(*req.PickupID, timeout)
+		certID, err := c.getCertIDFromPickupID(ctx, *req.PickupID, timeout)
 		if err != nil {
 			return nil, err
 		}
@@ -175,16 +185,15 @@ func (c *Connector) ProvisionCertificateToMachineIdentity(req domain.Provisionin
 
 	// Is certificate generated by VCP?
 	log.Printf("validating if certificate is generated by VCP")
-	err := c.validateIfCertIsVCPGeneratedByID(certificateID)
+	err := c.validateIfCertIsVCPGeneratedByID(ctx, certificateID)
 	if err != nil {
 		return nil, err
 	}
 	log.Println("Certificate is VCP generated")
 
-	ctx := context.Background()
 	wsClientID := uuid.New().String()
 
-	wsConn, err := c.notificationSvcClient.Subscribe(wsClientID)
+	wsConn, err := c.notificationSvcClient.SubscribeContext(ctx, wsClientID)
 	if err != nil {
 		return nil, err
 	}
@@ -195,7 +204,7 @@ func (c *Connector) ProvisionCertificateToMachineIdentity(req domain.Provisionin
 		return nil, err
 	}
 
-	ar, err := c.notificationSvcClient.ReadResponse(wsConn)
+	ar, err := c.notificationSvcClient.ReadResponseContext(ctx, wsConn)
 	if err != nil {
 		return nil, err
 	}
@@ -228,7 +237,12 @@ func (c *Connector) ProvisionCertificateToMachineIdentity(req domain.Provisionin
 }
 
 func (c *Connector) GetCloudProvider(request domain.GetCloudProviderRequest) (*domain.CloudProvider, error) {
-	cloudProvider, err := c.cloudProvidersClient.GetCloudProvider(context.Background(), request)
+	return c.GetCloudProviderContext(context.Background(), request)
+}
+
+// GetCloudProviderContext is GetCloudProvider with a context that cancels its requests to the platform
+func (c *Connector) GetCloudProviderContext(ctx context.Context, request domain.GetCloudProviderRequest) (*domain.CloudProvider, error) {
+	cloudProvider, err := c.cloudProvidersClient.GetCloudProvider(ctx, request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve Cloud Provider with name %s: %w", request.Name, err)
 	}
@@ -239,7 +253,12 @@ func (c *Connector) GetCloudProvider(request domain.GetCloudProviderRequest) (*d
 }
 
 func (c *Connector) GetCloudKeystore(request domain.GetCloudKeystoreRequest) (*domain.CloudKeystore, error) {
-	cloudKeystore, err := c.cloudProvidersClient.GetCloudKeystore(context.Background(), request)
+	return c.GetCloudKeystoreContext(context.Background(), request)
+}
+
+// GetCloudKeystoreContext is GetCloudKeystore with a context that cancels its requests to the platform
+func (c *Connector) GetCloudKeystoreContext(ctx context.Context, request domain.GetCloudKeystoreRequest) (*domain.CloudKeystore, error) {
+	cloudKeystore, err := c.cloudProvidersClient.GetCloudKeystore(ctx, request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve Cloud Keystore: %w", err)
 	}
@@ -251,11 +270,16 @@ func (c *Connector) GetCloudKeystore(request domain.GetCloudKeystoreRequest) (*d
 }
 
 func (c *Connector) GetMachineIdentity(request domain.GetCloudMachineIdentityRequest) (*domain.CloudMachineIdentity, error) {
+	return c.GetMachineIdentityContext(context.Background(), request)
+}
+
+// GetMachineIdentityContext is GetMachineIdentity with a context that cancels its requests to the platform
+func (c *Connector) GetMachineIdentityContext(ctx context.Context, request domain.GetCloudMachineIdentityRequest) (*domain.CloudMachineIdentity, error) {
 	if request.MachineIdentityID == nil {
 		return nil, fmt.Errorf("machine identity ID cannot be empty")
 	}
 
-	machineIdentity, err := c.cloudProvidersClient.GetMachineIdentity(context.Background(), request)
+	machineIdentity, err := c.cloudProvidersClient.GetMachineIdentity(ctx, request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve Cloud Machine Identity with ID %s: %w", *request.MachineIdentityID, err)
 	}
@@ -266,10 +290,15 @@ func (c *Connector) GetMachineIdentity(request domain.GetCloudMachineIdentityReq
 }
 
 func (c *Connector) DeleteMachineIdentity(machineIdentityID string) (bool, error) {
+	return c.DeleteMachineIdentityContext(context.Background(), machineIdentityID)
+}
+
+// DeleteMachineIdentityContext is DeleteMachineIdentity with a context that cancels its requests to the platform
+func (c *Connector) DeleteMachineIdentityContext(ctx context.Context, machineIdentityID string) (bool, error) {
 	if machineIdentityID == "" {
 		return false, fmt.Errorf("machine identity ID cannot be nil")
 	}
-	deleted, err := c.cloudProvidersClient.DeleteMachineIdentity(context.Background(), machineIdentityID)
+	deleted, err := c.cloudProvidersClient.DeleteMachineIdentity(ctx, machineIdentityID)
 	if err != nil {
 		return false, fmt.Errorf("failed to delete machine identity with ID %s: %w", machineIdentityID, err)
 	}
@@ -300,8 +329,8 @@ func setProvisioningOptions(options domain.ProvisioningOptions, keystoreType dom
 	return provisioningOptions, nil
 }
 
-func (c *Connector) validateIfCertIsVCPGeneratedByID(certificateId string) error {
-	cert, err := c.getCertificates(certificateId)
+func (c *Connector) validateIfCertIsVCPGeneratedByID(ctx context.Context, certificateId string) error {
+	cert, err := c.getCertificates(ctx, certificateId)
 	if err != nil {
 		return fmt.Errorf("error trying to get certificate details for cert with ID: %s, error: %s", certificateId, err.Error())
 	}
@@ -330,16 +359,22 @@ func (c *Connector) getGraphqlClient() graphql.Client {
 }
 
 func (c *Connector) getGraphqlHTTPClient() *http.Client {
+	// The client of the connector is reused so that the configured trust and retries apply to GraphQL requests
+	client := c.getHTTPClient()
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
 	// We provide every type of auth here.
 	// The logic to decide which auth to use is inside struct's function: RoundTrip
 	httpclient := &http.Client{
 		Transport: &httputils.AuthedTransportApi{
 			ApiKey:      c.apiKey,
 			AccessToken: c.accessToken,
-			Wrapped:     http.DefaultTransport,
+			Wrapped:     transport,
 			UserAgent:   util.DefaultUserAgent,
 		},
-		Timeout: 30 * time.Second,
+		Timeout: client.Timeout,
 	}
 	return httpclient
 }
@@ -0,0 +1,495 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudtest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Venafi/vcert/v5/pkg/policy"
+)
+
+type userInfo struct {
+	Username        string `json:"username"`
+	ID              string `json:"id"`
+	CompanyID       string `json:"companyId"`
+	EmailAddress    string `json:"emailAddress"`
+	UserType        string `json:"userType"`
+	UserAccountType string `json:"userAccountType"`
+	UserStatus      string `json:"userStatus"`
+	CreationDate    string `json:"creationDate"`
+}
+
+type team struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	CompanyID string `json:"companyId"`
+}
+
+type application struct {
+	ID                                   string               `json:"id"`
+	CompanyID                            string               `json:"companyId"`
+	Name                                 string               `json:"name"`
+	Description                          string               `json:"description,omitempty"`
+	OwnerIdsAndTypes                     []policy.OwnerIdType `json:"ownerIdsAndTypes"`
+	FullyQualifiedDomainNames            []string             `json:"fullyQualifiedDomainNames,omitempty"`
+	IpRanges                             []string             `json:"ipRanges,omitempty"`
+	Ports                                []string             `json:"ports,omitempty"`
+	CertificateIssuingTemplateAliasIdMap map[string]string    `json:"certificateIssuingTemplateAliasIdMap"`
+	CreationDate                         string               `json:"creationDate"`
+	ModificationDate                     string               `json:"modificationDate"`
+}
+
+// issuingTemplate is a certificate issuing template, stored as the request that created it plus the attributes
+// set by the server
+type issuingTemplate struct {
+	ID                            string `json:"id"`
+	CompanyID                     string `json:"companyId"`
+	CertificateAuthorityAccountID string `json:"certificateAuthorityAccountId"`
+	ValidityPeriod                string `json:"validityPeriod"`
+	Status                        string `json:"status"`
+	CreationDate                  string `json:"creationDate"`
+	ModificationDate              string `json:"modificationDate"`
+	policy.CloudPolicyRequest
+}
+
+// AddApplication creates an application using the issuing templates of aliases, a map of alias to template name,
+// and returns its ID
+func (s *Server) AddApplication(name string, aliases map[string]string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.findApplication(name) != nil {
+		return "", fmt.Errorf("application %s already exists", name)
+	}
+	ids := map[string]string{}
+	for alias, templateName := range aliases {
+		t := s.findTemplate(templateName)
+		if t == nil {
+			return "", fmt.Errorf("issuing template %s does not exist", templateName)
+		}
+		ids[alias] = t.ID
+	}
+	return s.addApplication(name, ids).ID, nil
+}
+
+// AddTemplate creates an issuing template from a request in the format sent by cloud.Connector.SetPolicy and
+// returns its ID
+func (s *Server) AddTemplate(req policy.CloudPolicyRequest) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.findTemplate(req.Name) != nil {
+		return "", fmt.Errorf("issuing template %s already exists", req.Name)
+	}
+	return s.addTemplate(req).ID, nil
+}
+
+// AddCertificateAuthorityAccount makes the product of a CA account available to the issuing templates. caType is
+// e.g. "DIGICERT" and accountKey the name of the account
+func (s *Server) AddCertificateAuthorityAccount(caType, accountKey, productName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addCAAccount(caType, accountKey, productName)
+}
+
+func (s *Server) addApplication(name string, aliases map[string]string) *application {
+	now := formatTime(time.Now())
+	app := &application{
+		ID:                                   uuid.New().String(),
+		CompanyID:                            s.companyID,
+		Name:                                 name,
+		OwnerIdsAndTypes:                     []policy.OwnerIdType{{OwnerId: s.user.ID, OwnerType: "USER"}},
+		CertificateIssuingTemplateAliasIdMap: aliases,
+		CreationDate:                         now,
+		ModificationDate:                     now,
+	}
+	s.applications[app.ID] = app
+	return app
+}
+
+func (s *Server) addTemplate(req policy.CloudPolicyRequest) *issuingTemplate {
+	s.productOptionID(&req)
+	now := formatTime(time.Now())
+	t := &issuingTemplate{
+		ID:           uuid.New().String(),
+		CompanyID:    s.companyID,
+		Status:       "AVAILABLE",
+		CreationDate: now,
+	}
+	s.setTemplate(t, req)
+	s.templates[t.ID] = t
+	return t
+}
+
+// setTemplate replaces the settings of t with those of req. It must be called with the lock held
+func (s *Server) setTemplate(t *issuingTemplate, req policy.CloudPolicyRequest) {
+	t.CloudPolicyRequest = req
+	t.ValidityPeriod = req.Product.ValidityPeriod
+	t.ModificationDate = formatTime(time.Now())
+	t.CertificateAuthorityAccountID = ""
+	for _, account := range s.caAccounts[req.CertificateAuthority] {
+		for _, option := range account.ProductOption {
+			if option.Id == req.CertificateAuthorityProductOptionId {
+				t.CertificateAuthorityAccountID = account.Account.Id
+			}
+		}
+	}
+}
+
+func (s *Server) addCAAccount(caType, accountKey, productName string) {
+	for i, account := range s.caAccounts[caType] {
+		if account.Account.Key == accountKey {
+			s.caAccounts[caType][i].ProductOption = append(account.ProductOption, newProductOption(productName))
+			return
+		}
+	}
+	s.caAccounts[caType] = append(s.caAccounts[caType], policy.AccountDetails{
+		Account:       policy.Account{Id: uuid.New().String(), Key: accountKey, CertificateAuthority: caType},
+		ProductOption: []policy.ProductOption{newProductOption(productName)},
+	})
+}
+
+func newProductOption(productName string) policy.ProductOption {
+	return policy.ProductOption{
+		ProductName: productName,
+		Id:          uuid.New().String(),
+		ProductDetails: policy.ProductDetails{
+			ProductTemplate: policy.ProductTemplate{OrganizationId: 1},
+		},
+	}
+}
+
+// defaultTemplateRequest returns the settings of a permissive template issued by the built-in CA
+func defaultTemplateRequest(name string) policy.CloudPolicyRequest {
+	keyReuse := false
+	return policy.CloudPolicyRequest{
+		Name:                 name,
+		CertificateAuthority: "BUILTIN",
+		Product: policy.Product{
+			CertificateAuthority: "BUILTIN",
+			ProductName:          "Default Product",
+			ValidityPeriod:       "P90D",
+		},
+		SubjectCNRegexes: []string{".*"},
+		SubjectORegexes:  []string{".*"},
+		SubjectOURegexes: []string{".*"},
+		SubjectLRegexes:  []string{".*"},
+		SubjectSTRegexes: []string{".*"},
+		SubjectCValues:   []string{".*"},
+		SanRegexes:       []string{".*"},
+
+		SanIpAddressRegexes:                 []string{".*"},
+		SanRfc822NameRegexes:                []string{".*"},
+		SanUniformResourceIdentifierRegexes: []string{"(https|http|ldap|ldaps|spiffe)://.*"},
+		KeyTypes: []policy.KeyType{
+			{KeyType: "RSA", KeyLengths: []int{2048, 3072, 4096}},
+			{KeyType: "EC", KeyCurves: []string{"P256", "P384", "P521"}},
+		},
+		KeyReuse:                    &keyReuse,
+		RecommendedSettings:         &policy.RecommendedSettings{Key: &policy.Key{Type: "RSA", Length: 2048}},
+		CsrUploadAllowed:            true,
+		KeyGeneratedByVenafiAllowed: true,
+	}
+}
+
+// findApplication returns the application named name. It must be called with the lock held
+func (s *Server) findApplication(name string) *application {
+	for _, app := range s.applications {
+		if app.Name == name {
+			return app
+		}
+	}
+	return nil
+}
+
+// findTemplate returns the issuing template named name. It must be called with the lock held
+func (s *Server) findTemplate(name string) *issuingTemplate {
+	for _, t := range s.templates {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// productOptionID returns the ID of the product option of the template request, filling it from the CA accounts
+// when the request names the product only. It must be called with the lock held
+func (s *Server) productOptionID(req *policy.CloudPolicyRequest) bool {
+	for _, account := range s.caAccounts[req.CertificateAuthority] {
+		for _, option := range account.ProductOption {
+			if option.Id == req.CertificateAuthorityProductOptionId ||
+				(req.CertificateAuthorityProductOptionId == "" && option.ProductName == req.Product.ProductName) {
+				req.CertificateAuthorityProductOptionId = option.Id
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Server) userAccount(w http.ResponseWriter, _ *http.Request, _ []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, struct {
+		User    userInfo `json:"user"`
+		Company struct {
+			ID          string `json:"id"`
+			Name        string `json:"name"`
+			CompanyType string `json:"companyType"`
+			Active      bool   `json:"active"`
+		} `json:"company"`
+		APIKey struct {
+			Username     string   `json:"username"`
+			APITypes     []string `json:"apitypes"`
+			APIKeyStatus string   `json:"apiKeyStatus"`
+		} `json:"apiKey"`
+	}{
+		User: s.user,
+		Company: struct {
+			ID          string `json:"id"`
+			Name        string `json:"name"`
+			CompanyType string `json:"companyType"`
+			Active      bool   `json:"active"`
+		}{s.companyID, "cloudtest", "TPP_CUSTOMER", true},
+		APIKey: struct {
+			Username     string   `json:"username"`
+			APITypes     []string `json:"apitypes"`
+			APIKeyStatus string   `json:"apiKeyStatus"`
+		}{s.user.Username, []string{"ALL"}, "ACTIVE"},
+	})
+}
+
+func (s *Server) userByID(w http.ResponseWriter, _ *http.Request, params []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if params[0] != s.user.ID {
+		writeError(w, http.StatusNotFound, 10101, fmt.Sprintf("User %s not found", params[0]))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.user)
+}
+
+func (s *Server) usersByName(w http.ResponseWriter, _ *http.Request, params []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := []userInfo{}
+	if strings.EqualFold(params[0], s.user.Username) {
+		users = append(users, s.user)
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Users []userInfo `json:"users"`
+	}{users})
+}
+
+func (s *Server) listTeams(w http.ResponseWriter, _ *http.Request, _ []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, struct {
+		Teams []team `json:"teams"`
+	}{s.teams})
+}
+
+func (s *Server) applicationByName(w http.ResponseWriter, _ *http.Request, params []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app := s.findApplication(params[0])
+	if app == nil {
+		writeError(w, http.StatusNotFound, 10051, fmt.Sprintf("Unable to find application %s", params[0]))
+		return
+	}
+	writeJSON(w, http.StatusOK, app)
+}
+
+func (s *Server) createApplication(w http.ResponseWriter, r *http.Request, _ []string) {
+	var req policy.Application
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, 10052, "Application name is required")
+		return
+	}
+	if s.findApplication(req.Name) != nil {
+		writeError(w, http.StatusConflict, 10053, fmt.Sprintf("Application %s already exists", req.Name))
+		return
+	}
+	if !s.validAliases(w, req.CertificateIssuingTemplateAliasIdMap) {
+		return
+	}
+	app := s.addApplication(req.Name, req.CertificateIssuingTemplateAliasIdMap)
+	app.Description = req.Description
+	if len(req.OwnerIdsAndTypes) > 0 {
+		app.OwnerIdsAndTypes = req.OwnerIdsAndTypes
+	}
+	app.FullyQualifiedDomainNames = req.FullyQualifiedDomainNames
+	app.IpRanges = req.IpRanges
+	app.Ports = req.Ports
+	writeJSON(w, http.StatusCreated, struct {
+		Applications []*application `json:"applications"`
+	}{[]*application{app}})
+}
+
+func (s *Server) updateApplication(w http.ResponseWriter, r *http.Request, params []string) {
+	var req policy.Application
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, ok := s.applications[params[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, 10051, fmt.Sprintf("Unable to find application %s", params[0]))
+		return
+	}
+	if !s.validAliases(w, req.CertificateIssuingTemplateAliasIdMap) {
+		return
+	}
+	if req.Name != "" {
+		app.Name = req.Name
+	}
+	if len(req.OwnerIdsAndTypes) > 0 {
+		app.OwnerIdsAndTypes = req.OwnerIdsAndTypes
+	}
+	if req.CertificateIssuingTemplateAliasIdMap != nil {
+		app.CertificateIssuingTemplateAliasIdMap = req.CertificateIssuingTemplateAliasIdMap
+	}
+	app.Description = req.Description
+	app.ModificationDate = formatTime(time.Now())
+	writeJSON(w, http.StatusOK, app)
+}
+
+// validAliases checks that the aliases of an application request refer to existing templates. It must be called
+// with the lock held
+func (s *Server) validAliases(w http.ResponseWriter, aliases map[string]string) bool {
+	for alias, id := range aliases {
+		if _, ok := s.templates[id]; !ok {
+			writeError(w, http.StatusBadRequest, 10054, fmt.Sprintf("Issuing template %s of alias %s does not exist", id, alias))
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) applicationTemplate(w http.ResponseWriter, _ *http.Request, params []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app := s.findApplication(params[0])
+	if app == nil {
+		writeError(w, http.StatusNotFound, 10051, fmt.Sprintf("Unable to find application %s", params[0]))
+		return
+	}
+	t, ok := s.templates[app.CertificateIssuingTemplateAliasIdMap[params[1]]]
+	if !ok {
+		writeError(w, http.StatusNotFound, 10055, fmt.Sprintf("Issuing template alias %s is not assigned to application %s", params[1], app.Name))
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) listTemplates(w http.ResponseWriter, _ *http.Request, _ []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	templates := make([]*issuingTemplate, 0, len(s.templates))
+	for _, t := range s.templates {
+		templates = append(templates, t)
+	}
+	writeJSON(w, http.StatusOK, struct {
+		CertificateIssuingTemplates []*issuingTemplate `json:"certificateIssuingTemplates"`
+	}{templates})
+}
+
+func (s *Server) createTemplate(w http.ResponseWriter, r *http.Request, _ []string) {
+	var req policy.CloudPolicyRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, 10056, "Issuing template name is required")
+		return
+	}
+	if s.findTemplate(req.Name) != nil {
+		writeError(w, http.StatusConflict, 10057, fmt.Sprintf("Issuing template %s already exists", req.Name))
+		return
+	}
+	if !s.productOptionID(&req) {
+		writeError(w, http.StatusBadRequest, 10058, fmt.Sprintf("Product option %s of CA %s does not exist", req.CertificateAuthorityProductOptionId, req.CertificateAuthority))
+		return
+	}
+	t := s.addTemplate(req)
+	writeJSON(w, http.StatusCreated, struct {
+		CertificateIssuingTemplates []*issuingTemplate `json:"certificateIssuingTemplates"`
+	}{[]*issuingTemplate{t}})
+}
+
+func (s *Server) updateTemplate(w http.ResponseWriter, r *http.Request, params []string) {
+	var req policy.CloudPolicyRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.templates[params[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, 10059, fmt.Sprintf("Issuing template %s does not exist", params[0]))
+		return
+	}
+	if !s.productOptionID(&req) {
+		writeError(w, http.StatusBadRequest, 10058, fmt.Sprintf("Product option %s of CA %s does not exist", req.CertificateAuthorityProductOptionId, req.CertificateAuthority))
+		return
+	}
+	if req.Name == "" {
+		req.Name = t.Name
+	}
+	s.setTemplate(t, req)
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) listCAAccounts(w http.ResponseWriter, _ *http.Request, params []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	accounts := s.caAccounts[params[0]]
+	if accounts == nil {
+		accounts = []policy.AccountDetails{}
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Accounts []policy.AccountDetails `json:"accounts"`
+	}{accounts})
+}
+
+func (s *Server) caAccount(w http.ResponseWriter, _ *http.Request, params []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, account := range s.caAccounts[params[0]] {
+		if account.Account.Id == params[1] {
+			writeJSON(w, http.StatusOK, account)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, 10060, fmt.Sprintf("Account %s of CA %s does not exist", params[1], params[0]))
+}
@@ -0,0 +1,1146 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudtest
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/nacl/box"
+
+	"github.com/Venafi/vcert/v5/pkg/util"
+)
+
+const defaultValidity = 90 * 24 * time.Hour
+
+// CertificateObject is the state of a certificate kept by the server
+type CertificateObject struct {
+	ID                   string
+	ManagedCertificateID string
+	CertificateRequestID string
+	ApplicationIDs       []string
+	Certificate          *x509.Certificate
+	// ServiceGenerated is set when the private key was generated by the server
+	ServiceGenerated bool
+	Retired          bool
+	Revoked          bool
+	RevocationReason string
+}
+
+type certificateObject struct {
+	id             string
+	managedID      string
+	requestID      string
+	sequence       int
+	applicationIDs []string
+	source         string
+	cert           *x509.Certificate
+	csr            *x509.CertificateRequest
+	key            crypto.Signer
+	retired        bool
+	revoked        bool
+	reason         string
+}
+
+type certificateRequest struct {
+	ID                        string   `json:"id"`
+	ApplicationID             string   `json:"applicationId"`
+	TemplateID                string   `json:"certificateIssuingTemplateId"`
+	Status                    string   `json:"status"`
+	SubjectDN                 string   `json:"subjectDN"`
+	CertificateIDs            []string `json:"certificateIds,omitempty"`
+	CertificateSigningRequest string   `json:"certificateSigningRequest,omitempty"`
+	CreationDate              string   `json:"creationDate"`
+	ModificationDate          string   `json:"modificationDate"`
+	pending                   int
+}
+
+type csrAttributes struct {
+	CommonName                    string
+	Organization                  string
+	OrganizationalUnits           []string
+	Locality                      string
+	State                         string
+	Country                       string
+	SubjectAlternativeNamesByType struct {
+		DnsNames                   []string
+		IpAddresses                []string
+		Rfc822Names                []string
+		UniformResourceIdentifiers []string
+	}
+	KeyTypeParameters struct {
+		KeyType   string
+		KeyLength int
+		KeyCurve  string
+	}
+}
+
+type certificateRequestBody struct {
+	CSR                   string `json:"certificateSigningRequest"`
+	ApplicationID         string `json:"applicationId"`
+	TemplateID            string `json:"certificateIssuingTemplateId"`
+	ExistingCertificateID string `json:"existingCertificateId"`
+	ReuseCSR              bool   `json:"reuseCSR"`
+	ValidityPeriod        string `json:"validityPeriod"`
+	IsVaaSGenerated       bool   `json:"isVaaSGenerated"`
+	CsrAttributes         csrAttributes
+}
+
+// certificateDetails is a certificate as returned by the certificates and search endpoints
+type certificateDetails struct {
+	ID                            string              `json:"id"`
+	CompanyID                     string              `json:"companyId"`
+	ManagedCertificateID          string              `json:"managedCertificateId"`
+	CertificateRequestID          string              `json:"certificateRequestId,omitempty"`
+	ApplicationIDs                []string            `json:"applicationIds"`
+	CertificateStatus             string              `json:"certificateStatus"`
+	CertificateSource             string              `json:"certificateSource"`
+	Fingerprint                   string              `json:"fingerprint"`
+	SerialNumber                  string              `json:"serialNumber"`
+	SubjectCN                     []string            `json:"subjectCN"`
+	IssuerCN                      []string            `json:"issuerCN"`
+	SubjectAlternativeNamesByType map[string][]string `json:"subjectAlternativeNamesByType"`
+	KeyStrength                   int                 `json:"keyStrength"`
+	ValidityStart                 string              `json:"validityStart"`
+	ValidityEnd                   string              `json:"validityEnd"`
+	DekHash                       string              `json:"dekHash,omitempty"`
+}
+
+type errorInformation struct {
+	Type    string `json:"type,omitempty"`
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type revocationRequest struct {
+	ID               string            `json:"id"`
+	CertificateID    string            `json:"certificateId"`
+	Status           string            `json:"status"`
+	RevocationReason string            `json:"revocationReason"`
+	ErrorInformation *errorInformation `json:"errorInformation,omitempty"`
+}
+
+// Certificate returns the state of the certificate id
+func (s *Server) Certificate(id string) (*CertificateObject, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.certificates[id]
+	if !ok {
+		return nil, false
+	}
+	return &CertificateObject{
+		ID:                   obj.id,
+		ManagedCertificateID: obj.managedID,
+		CertificateRequestID: obj.requestID,
+		ApplicationIDs:       append([]string(nil), obj.applicationIDs...),
+		Certificate:          obj.cert,
+		ServiceGenerated:     obj.key != nil,
+		Retired:              obj.retired,
+		Revoked:              obj.revoked,
+		RevocationReason:     obj.reason,
+	}, true
+}
+
+// CACertificate returns the certificate of the CA issuing the certificates of the server
+func (s *Server) CACertificate() *x509.Certificate {
+	return s.ca
+}
+
+func (s *Server) initCA() error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "cloudtest CA", Organization: []string{"Venafi, Inc."}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return err
+	}
+	s.ca, err = x509.ParseCertificate(der)
+	s.caKey = key
+	return err
+}
+
+func (s *Server) requestCertificate(w http.ResponseWriter, r *http.Request, _ []string) {
+	var req certificateRequestBody
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app, ok := s.applications[req.ApplicationID]
+	if !ok {
+		writeError(w, http.StatusBadRequest, 10051, fmt.Sprintf("Unable to find application %s", req.ApplicationID))
+		return
+	}
+	t, ok := s.templates[req.TemplateID]
+	if !ok || !hasValue(app.CertificateIssuingTemplateAliasIdMap, req.TemplateID) {
+		writeError(w, http.StatusBadRequest, 10055, fmt.Sprintf("Issuing template %s is not assigned to application %s", req.TemplateID, app.Name))
+		return
+	}
+
+	obj := &certificateObject{
+		id:             uuid.New().String(),
+		sequence:       s.nextSequence(),
+		applicationIDs: []string{app.ID},
+		source:         "USER_PROVIDED",
+	}
+	obj.managedID = obj.id
+	var previous *certificateObject
+	if req.ExistingCertificateID != "" {
+		previous, ok = s.certificates[req.ExistingCertificateID]
+		if !ok {
+			writeError(w, http.StatusBadRequest, 10201, fmt.Sprintf("Certificate %s does not exist", req.ExistingCertificateID))
+			return
+		}
+		obj.managedID = previous.managedID
+	}
+
+	var err error
+	switch {
+	case req.CSR != "":
+		if !t.CsrUploadAllowed {
+			writeError(w, http.StatusBadRequest, 10202, fmt.Sprintf("Issuing template %s does not allow CSR upload", t.Name))
+			return
+		}
+		obj.csr, err = parseCSR(req.CSR)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, 10203, fmt.Sprintf("Failed to parse the certificate signing request: %s", err))
+			return
+		}
+	case req.IsVaaSGenerated:
+		if !t.KeyGeneratedByVenafiAllowed {
+			writeError(w, http.StatusBadRequest, 10204, fmt.Sprintf("Issuing template %s does not allow keys generated by the service", t.Name))
+			return
+		}
+		obj.key, err = generateKey(req.CsrAttributes, t)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, 10205, err.Error())
+			return
+		}
+	case req.ReuseCSR && previous != nil && previous.csr != nil:
+		obj.csr = previous.csr
+	default:
+		writeError(w, http.StatusBadRequest, 10206, "A certificate signing request is required")
+		return
+	}
+
+	template, err := newCertificateTemplate(obj, req, t)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 10207, err.Error())
+		return
+	}
+	obj.cert, err = s.sign(template, obj)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 10208, err.Error())
+		return
+	}
+
+	now := formatTime(time.Now())
+	cr := &certificateRequest{
+		ID:               uuid.New().String(),
+		ApplicationID:    app.ID,
+		TemplateID:       t.ID,
+		Status:           "ISSUED",
+		SubjectDN:        obj.cert.Subject.String(),
+		CertificateIDs:   []string{obj.id},
+		CreationDate:     now,
+		ModificationDate: now,
+		pending:          s.PendingRetrievals,
+	}
+	if req.CSR != "" {
+		cr.CertificateSigningRequest = req.CSR
+	}
+	obj.requestID = cr.ID
+	s.requests[cr.ID] = cr
+	s.certificates[obj.id] = obj
+
+	response := *cr
+	response.Status = "REQUESTED"
+	response.CertificateIDs = nil
+	writeJSON(w, http.StatusCreated, struct {
+		CertificateRequests []certificateRequest `json:"certificateRequests"`
+	}{[]certificateRequest{response}})
+}
+
+// newCertificateTemplate returns the certificate to issue for the request, enforcing the CN, SAN, key and validity
+// settings of the issuing template
+func newCertificateTemplate(obj *certificateObject, req certificateRequestBody, t *issuingTemplate) (*x509.Certificate, error) {
+	template := &x509.Certificate{
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	var publicKey crypto.PublicKey
+	if obj.csr != nil {
+		template.Subject = obj.csr.Subject
+		template.DNSNames = obj.csr.DNSNames
+		template.EmailAddresses = obj.csr.EmailAddresses
+		template.IPAddresses = obj.csr.IPAddresses
+		template.URIs = obj.csr.URIs
+		publicKey = obj.csr.PublicKey
+	} else {
+		attrs := req.CsrAttributes
+		template.Subject = pkix.Name{
+			CommonName:         attrs.CommonName,
+			Organization:       nonEmpty(attrs.Organization),
+			OrganizationalUnit: attrs.OrganizationalUnits,
+			Locality:           nonEmpty(attrs.Locality),
+			Province:           nonEmpty(attrs.State),
+			Country:            nonEmpty(attrs.Country),
+		}
+		sans := attrs.SubjectAlternativeNamesByType
+		template.DNSNames = sans.DnsNames
+		template.EmailAddresses = sans.Rfc822Names
+		for _, address := range sans.IpAddresses {
+			ip := net.ParseIP(address)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP address %s", address)
+			}
+			template.IPAddresses = append(template.IPAddresses, ip)
+		}
+		for _, uri := range sans.UniformResourceIdentifiers {
+			u, err := url.Parse(uri)
+			if err != nil {
+				return nil, fmt.Errorf("invalid URI %s: %s", uri, err)
+			}
+			template.URIs = append(template.URIs, u)
+		}
+		publicKey = obj.key.Public()
+	}
+
+	if template.Subject.CommonName == "" && len(template.DNSNames) == 0 {
+		return nil, fmt.Errorf("a common name or a DNS name is required")
+	}
+	if template.Subject.CommonName != "" {
+		err := matchRegexes("common name", []string{template.Subject.CommonName}, t.SubjectCNRegexes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var ips, uris []string
+	for _, ip := range template.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+	for _, u := range template.URIs {
+		uris = append(uris, u.String())
+	}
+	for _, check := range []struct {
+		what    string
+		values  []string
+		regexes []string
+	}{
+		{"DNS name", template.DNSNames, t.SanRegexes},
+		{"IP address", ips, t.SanIpAddressRegexes},
+		{"email address", template.EmailAddresses, t.SanRfc822NameRegexes},
+		{"URI", uris, t.SanUniformResourceIdentifierRegexes},
+	} {
+		err := matchRegexes(check.what, check.values, check.regexes)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err := checkKeyType(publicKey, t)
+	if err != nil {
+		return nil, err
+	}
+
+	validity := defaultValidity
+	if d, err := parseValidityPeriod(t.ValidityPeriod); err == nil {
+		validity = d
+	}
+	if req.ValidityPeriod != "" {
+		d, err := parseValidityPeriod(req.ValidityPeriod)
+		if err != nil {
+			return nil, err
+		}
+		validity = d
+	}
+	template.NotBefore = time.Now().Add(-time.Minute)
+	template.NotAfter = time.Now().Add(validity)
+	return template, nil
+}
+
+// sign issues template for the key of obj. It must be called with the lock held
+func (s *Server) sign(template *x509.Certificate, obj *certificateObject) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template.SerialNumber = serial
+	var publicKey crypto.PublicKey
+	if obj.csr != nil {
+		publicKey = obj.csr.PublicKey
+	} else {
+		publicKey = obj.key.Public()
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, s.ca, publicKey, s.caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue certificate: %s", err)
+	}
+	return x509.ParseCertificate(der)
+}
+
+func generateKey(attrs csrAttributes, t *issuingTemplate) (crypto.Signer, error) {
+	params := attrs.KeyTypeParameters
+	if params.KeyType == "" && t.RecommendedSettings != nil && t.RecommendedSettings.Key != nil {
+		params.KeyType = t.RecommendedSettings.Key.Type
+		params.KeyLength = t.RecommendedSettings.Key.Length
+		params.KeyCurve = t.RecommendedSettings.Key.Curve
+	}
+	switch strings.ToUpper(params.KeyType) {
+	case "EC":
+		switch strings.ToUpper(params.KeyCurve) {
+		case "P384":
+			return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		case "P521":
+			return ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+		default:
+			return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		}
+	case "RSA", "":
+		bits := params.KeyLength
+		if bits == 0 {
+			bits = 2048
+		}
+		return rsa.GenerateKey(rand.Reader, bits)
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", params.KeyType)
+	}
+}
+
+// checkKeyType checks that the public key is one of the key types allowed by the issuing template
+func checkKeyType(publicKey crypto.PublicKey, t *issuingTemplate) error {
+	var keyType, curve string
+	var length int
+	switch k := publicKey.(type) {
+	case *rsa.PublicKey:
+		keyType, length = "RSA", k.N.BitLen()
+	case *ecdsa.PublicKey:
+		keyType, curve = "EC", strings.ReplaceAll(k.Curve.Params().Name, "-", "")
+	default:
+		return fmt.Errorf("unsupported key type %T", publicKey)
+	}
+	for _, allowed := range t.KeyTypes {
+		if !strings.EqualFold(allowed.KeyType, keyType) {
+			continue
+		}
+		if keyType == "RSA" && (len(allowed.KeyLengths) == 0 || containsInt(allowed.KeyLengths, length)) {
+			return nil
+		}
+		if keyType == "EC" && (len(allowed.KeyCurves) == 0 || containsFold(allowed.KeyCurves, curve)) {
+			return nil
+		}
+	}
+	if keyType == "RSA" {
+		return fmt.Errorf("key type RSA %d is not allowed by issuing template %s", length, t.Name)
+	}
+	return fmt.Errorf("key type EC %s is not allowed by issuing template %s", curve, t.Name)
+}
+
+// matchRegexes checks that every value fully matches one of regexes. No regex means no value is allowed
+func matchRegexes(what string, values, regexes []string) error {
+	for _, value := range values {
+		allowed := false
+		for _, expr := range regexes {
+			re, err := regexp.Compile("^(?:" + strings.TrimSuffix(strings.TrimPrefix(expr, "^"), "$") + ")$")
+			if err == nil && re.MatchString(value) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%s %s does not match the issuing template", what, value)
+		}
+	}
+	return nil
+}
+
+// parseValidityPeriod parses the ISO 8601 durations used by the issuing templates, e.g. "P90D", and by the
+// requests, e.g. "PT2160H0M0S"
+func parseValidityPeriod(period string) (time.Duration, error) {
+	if days, found := strings.CutPrefix(period, "P"); found && strings.HasSuffix(days, "D") {
+		n, err := strconv.Atoi(strings.TrimSuffix(days, "D"))
+		if err == nil {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	if d, found := strings.CutPrefix(period, "PT"); found {
+		duration, err := time.ParseDuration(strings.ToLower(d))
+		if err == nil {
+			return duration, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid validity period %q", period)
+}
+
+func (s *Server) certificateRequestStatus(w http.ResponseWriter, _ *http.Request, params []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cr, ok := s.requests[params[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, 10209, fmt.Sprintf("Certificate request %s does not exist", params[0]))
+		return
+	}
+	response := *cr
+	if cr.pending > 0 {
+		cr.pending--
+		response.Status = "PENDING"
+		response.CertificateIDs = nil
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (s *Server) certificateDetails(w http.ResponseWriter, _ *http.Request, params []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.certificates[params[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, 10201, fmt.Sprintf("Certificate %s does not exist", params[0]))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.details(obj))
+}
+
+// details returns the certificate as returned by the API. It must be called with the lock held
+func (s *Server) details(obj *certificateObject) certificateDetails {
+	status := "ACTIVE"
+	if obj.retired {
+		status = "RETIRED"
+	}
+	d := certificateDetails{
+		ID:                   obj.id,
+		CompanyID:            s.companyID,
+		ManagedCertificateID: obj.managedID,
+		CertificateRequestID: obj.requestID,
+		ApplicationIDs:       obj.applicationIDs,
+		CertificateStatus:    status,
+		CertificateSource:    obj.source,
+		Fingerprint:          certThumbprint(obj.cert.Raw),
+		SerialNumber:         strings.ToUpper(obj.cert.SerialNumber.Text(16)),
+		SubjectCN:            []string{obj.cert.Subject.CommonName},
+		IssuerCN:             []string{obj.cert.Issuer.CommonName},
+		SubjectAlternativeNamesByType: map[string][]string{
+			"dNSName":                   obj.cert.DNSNames,
+			"rfc822Name":                obj.cert.EmailAddresses,
+			"iPAddress":                 nil,
+			"uniformResourceIdentifier": nil,
+		},
+		ValidityStart: formatTime(obj.cert.NotBefore),
+		ValidityEnd:   formatTime(obj.cert.NotAfter),
+	}
+	for _, ip := range obj.cert.IPAddresses {
+		d.SubjectAlternativeNamesByType["iPAddress"] = append(d.SubjectAlternativeNamesByType["iPAddress"], ip.String())
+	}
+	for _, u := range obj.cert.URIs {
+		d.SubjectAlternativeNamesByType["uniformResourceIdentifier"] = append(d.SubjectAlternativeNamesByType["uniformResourceIdentifier"], u.String())
+	}
+	switch k := obj.cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		d.KeyStrength = k.N.BitLen()
+	case *ecdsa.PublicKey:
+		d.KeyStrength = k.Curve.Params().BitSize
+	}
+	if obj.key != nil {
+		d.DekHash = s.dekHash
+	}
+	return d
+}
+
+func (s *Server) certificateContents(w http.ResponseWriter, r *http.Request, params []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.certificates[params[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, 10201, fmt.Sprintf("Certificate %s does not exist", params[0]))
+		return
+	}
+	if format := r.URL.Query().Get("format"); format != "" && !strings.EqualFold(format, "PEM") {
+		writeError(w, http.StatusBadRequest, 10210, fmt.Sprintf("Format %s is not supported", format))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	for _, cert := range s.chain(obj, strings.EqualFold(r.URL.Query().Get("chainOrder"), "ROOT_FIRST")) {
+		_, _ = w.Write(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	}
+}
+
+// chain returns the certificate of obj and its issuers. It must be called with the lock held
+func (s *Server) chain(obj *certificateObject, rootFirst bool) []*x509.Certificate {
+	if obj.source != "USER_PROVIDED" || obj.cert.CheckSignatureFrom(s.ca) != nil {
+		return []*x509.Certificate{obj.cert}
+	}
+	if rootFirst {
+		return []*x509.Certificate{s.ca, obj.cert}
+	}
+	return []*x509.Certificate{obj.cert, s.ca}
+}
+
+func (s *Server) certificateKeystore(w http.ResponseWriter, r *http.Request, params []string) {
+	var req struct {
+		ExportFormat                  string `json:"exportFormat"`
+		EncryptedPrivateKeyPassphrase string `json:"encryptedPrivateKeyPassphrase"`
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.certificates[params[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, 10201, fmt.Sprintf("Certificate %s does not exist", params[0]))
+		return
+	}
+	if obj.key == nil {
+		writeError(w, http.StatusBadRequest, 10211, fmt.Sprintf("The private key of certificate %s was not generated by the service", obj.id))
+		return
+	}
+	if req.ExportFormat != "PEM" {
+		writeError(w, http.StatusBadRequest, 10210, fmt.Sprintf("Export format %s is not supported", req.ExportFormat))
+		return
+	}
+	var passphrase []byte
+	if req.EncryptedPrivateKeyPassphrase != "" {
+		sealed, err := base64.StdEncoding.DecodeString(req.EncryptedPrivateKeyPassphrase)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, 10212, fmt.Sprintf("Failed to decode the private key passphrase: %s", err))
+			return
+		}
+		passphrase, ok = box.OpenAnonymous(nil, sealed, s.dekPublicKey, s.dekPrivateKey)
+		if !ok {
+			writeError(w, http.StatusBadRequest, 10212, "Failed to decrypt the private key passphrase")
+			return
+		}
+	}
+
+	keyPEM, err := encodePrivateKey(obj.key, passphrase)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, 10213, err.Error())
+		return
+	}
+	var chain []string
+	for _, cert := range s.chain(obj, true) {
+		chain = append(chain, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})))
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	name := strings.NewReplacer("*", "star", "/", "_").Replace(obj.cert.Subject.CommonName)
+	files := []struct{ name, content string }{
+		{name + ".key", string(keyPEM)},
+		{name + "_root-first.pem", strings.Join(chain, "\n")},
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err == nil {
+			_, err = fw.Write([]byte(f.content))
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, 10213, err.Error())
+			return
+		}
+	}
+	err = zw.Close()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, 10213, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// encodePrivateKey encodes key the way the keystore endpoint does, encrypted with the legacy PEM encryption when a
+// passphrase is given
+func encodePrivateKey(key crypto.Signer, passphrase []byte) ([]byte, error) {
+	var block *pem.Block
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(k)
+		if err != nil {
+			return nil, err
+		}
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	if len(passphrase) > 0 {
+		var err error
+		block, err = util.X509EncryptPEMBlock(rand.Reader, block.Type, block.Bytes, passphrase, util.PEMCipherAES256)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+func (s *Server) edgeEncryptionKey(w http.ResponseWriter, _ *http.Request, params []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if params[0] != s.dekHash {
+		writeError(w, http.StatusNotFound, 10214, fmt.Sprintf("Edge encryption key %s does not exist", params[0]))
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Key string `json:"key"`
+	}{base64.StdEncoding.EncodeToString(s.dekPublicKey[:])})
+}
+
+func (s *Server) importCertificates(w http.ResponseWriter, r *http.Request, _ []string) {
+	var req struct {
+		Certificates []struct {
+			Certificate    string   `json:"certificate"`
+			ApplicationIds []string `json:"applicationIds"`
+		} `json:"certificates"`
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	type importedCertificate struct {
+		ID                   string `json:"id"`
+		ManagedCertificateID string `json:"managedCertificateId"`
+		CompanyID            string `json:"companyId"`
+		Fingerprint          string `json:"fingerprint"`
+		CertificateSource    string `json:"certificateSource"`
+		OwnerUserID          string `json:"ownerUserId"`
+		ValidityStartDate    string `json:"validityStartDate"`
+		ValidityEndDate      string `json:"validityEndDate"`
+	}
+	var imported []importedCertificate
+	for _, c := range req.Certificates {
+		der, err := base64.StdEncoding.DecodeString(c.Certificate)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, 10215, fmt.Sprintf("Failed to decode the certificate: %s", err))
+			return
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, 10215, fmt.Sprintf("Failed to parse the certificate: %s", err))
+			return
+		}
+		for _, id := range c.ApplicationIds {
+			if _, ok := s.applications[id]; !ok {
+				writeError(w, http.StatusBadRequest, 10051, fmt.Sprintf("Unable to find application %s", id))
+				return
+			}
+		}
+
+		obj := s.findCertificate(certThumbprint(der))
+		if obj == nil {
+			obj = &certificateObject{id: uuid.New().String(), sequence: s.nextSequence(), source: "INBOUND_IMPORT", cert: cert}
+			obj.managedID = obj.id
+			s.certificates[obj.id] = obj
+		}
+		for _, id := range c.ApplicationIds {
+			if !containsFold(obj.applicationIDs, id) {
+				obj.applicationIDs = append(obj.applicationIDs, id)
+			}
+		}
+		imported = append(imported, importedCertificate{
+			ID:                   obj.id,
+			ManagedCertificateID: obj.managedID,
+			CompanyID:            s.companyID,
+			Fingerprint:          certThumbprint(der),
+			CertificateSource:    obj.source,
+			OwnerUserID:          s.user.ID,
+			ValidityStartDate:    formatTime(cert.NotBefore),
+			ValidityEndDate:      formatTime(cert.NotAfter),
+		})
+	}
+	writeJSON(w, http.StatusCreated, struct {
+		CertificateInformations []importedCertificate `json:"certificateInformations"`
+	}{imported})
+}
+
+// findCertificate returns the certificate with the fingerprint. It must be called with the lock held
+func (s *Server) findCertificate(fingerprint string) *certificateObject {
+	for _, obj := range s.certificates {
+		if certThumbprint(obj.cert.Raw) == fingerprint {
+			return obj
+		}
+	}
+	return nil
+}
+
+func (s *Server) retireCertificates(w http.ResponseWriter, r *http.Request, _ []string) {
+	var req struct {
+		CertificateIds []string `json:"certificateIds"`
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	retired := []certificateDetails{}
+	for _, id := range req.CertificateIds {
+		obj, ok := s.certificates[id]
+		if !ok || obj.retired {
+			continue
+		}
+		obj.retired = true
+		retired = append(retired, s.details(obj))
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Count        int                  `json:"count"`
+		Certificates []certificateDetails `json:"certificates"`
+	}{len(retired), retired})
+}
+
+func (s *Server) revokeCertificates(w http.ResponseWriter, r *http.Request, _ []string) {
+	var req struct {
+		CertificateIds    []string `json:"certificateIds"`
+		RevocationReason  string   `json:"revocationReason"`
+		RevocationComment string   `json:"revocationComment"`
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var revocations []revocationRequest
+	for _, id := range req.CertificateIds {
+		obj, ok := s.certificates[id]
+		if !ok {
+			writeError(w, http.StatusBadRequest, 10201, fmt.Sprintf("Certificate %s does not exist", id))
+			return
+		}
+		rr := &revocationRequest{
+			ID:               uuid.New().String(),
+			CertificateID:    id,
+			Status:           "REVOKED",
+			RevocationReason: req.RevocationReason,
+		}
+		if obj.cert.CheckSignatureFrom(s.ca) != nil {
+			rr.Status = "FAILED"
+			rr.ErrorInformation = &errorInformation{Type: "CA_ERROR", Code: 10216, Message: "the certificate was not issued by a CA of the tenant"}
+		} else {
+			obj.revoked = true
+			obj.reason = req.RevocationReason
+		}
+		s.revocations[rr.ID] = rr
+		revocations = append(revocations, *rr)
+	}
+	writeJSON(w, http.StatusCreated, struct {
+		Count              int                 `json:"count"`
+		RevocationRequests []revocationRequest `json:"revocationRequests"`
+	}{len(revocations), revocations})
+}
+
+func (s *Server) revocationStatus(w http.ResponseWriter, _ *http.Request, params []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rr, ok := s.revocations[params[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, 10217, fmt.Sprintf("Revocation request %s does not exist", params[0]))
+		return
+	}
+	writeJSON(w, http.StatusOK, rr)
+}
+
+// searchExpression is either a group of operands combined by an AND or OR operator, or the comparison of a field
+type searchExpression struct {
+	Field    string             `json:"field"`
+	Operator string             `json:"operator"`
+	Value    interface{}        `json:"value"`
+	Values   []interface{}      `json:"values"`
+	Operands []searchExpression `json:"operands"`
+}
+
+func (s *Server) searchCertificates(w http.ResponseWriter, r *http.Request, _ []string) {
+	var req struct {
+		Expression *searchExpression `json:"expression"`
+		Paging     *struct {
+			PageNumber int `json:"pageNumber"`
+			PageSize   int `json:"pageSize"`
+		} `json:"paging"`
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matches []*certificateObject
+	for _, obj := range s.certificates {
+		if obj.retired {
+			continue
+		}
+		if req.Expression != nil {
+			ok, err := req.Expression.matches(s.details(obj))
+			if err != nil {
+				writeError(w, http.StatusBadRequest, 10218, err.Error())
+				return
+			}
+			if !ok {
+				continue
+			}
+		}
+		matches = append(matches, obj)
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].sequence < matches[j].sequence })
+
+	pageNumber, pageSize := 0, defaultPageSize
+	if req.Paging != nil && req.Paging.PageSize > 0 {
+		pageNumber, pageSize = req.Paging.PageNumber, req.Paging.PageSize
+	}
+	certificates := []certificateDetails{}
+	for i := pageNumber * pageSize; i < len(matches) && i < (pageNumber+1)*pageSize; i++ {
+		certificates = append(certificates, s.details(matches[i]))
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Count        int                  `json:"count"`
+		Certificates []certificateDetails `json:"certificates"`
+	}{len(matches), certificates})
+}
+
+func (e searchExpression) matches(d certificateDetails) (bool, error) {
+	if e.Field == "" {
+		or := strings.EqualFold(e.Operator, "OR")
+		for _, operand := range e.Operands {
+			ok, err := operand.matches(d)
+			if err != nil {
+				return false, err
+			}
+			if ok == or {
+				return ok, nil
+			}
+		}
+		return !or || len(e.Operands) == 0, nil
+	}
+
+	values, err := fieldValues(d, e.Field)
+	if err != nil {
+		return false, err
+	}
+	operator := strings.ToUpper(e.Operator)
+	for _, v := range values {
+		switch operator {
+		case "EQ", "MATCH":
+			cmp, err := compareValues(v, e.Value)
+			if err != nil {
+				return false, err
+			}
+			if cmp == 0 {
+				return true, nil
+			}
+		case "FIND":
+			if strings.Contains(strings.ToLower(fmt.Sprint(v)), strings.ToLower(fmt.Sprint(e.Value))) {
+				return true, nil
+			}
+		case "IN":
+			for _, candidate := range e.Values {
+				cmp, err := compareValues(v, candidate)
+				if err != nil {
+					return false, err
+				}
+				if cmp == 0 {
+					return true, nil
+				}
+			}
+		case "GT", "GTE", "LT", "LTE":
+			cmp, err := compareValues(v, e.Value)
+			if err != nil {
+				return false, err
+			}
+			if (operator == "GT" && cmp > 0) || (operator == "GTE" && cmp >= 0) ||
+				(operator == "LT" && cmp < 0) || (operator == "LTE" && cmp <= 0) {
+				return true, nil
+			}
+		default:
+			return false, fmt.Errorf("unsupported search operator %s", e.Operator)
+		}
+	}
+	return false, nil
+}
+
+// fieldValues returns the values of a searchable field, as strings, float64 numbers or times
+func fieldValues(d certificateDetails, field string) ([]interface{}, error) {
+	strs := func(values ...string) []interface{} {
+		result := make([]interface{}, 0, len(values))
+		for _, v := range values {
+			result = append(result, v)
+		}
+		return result
+	}
+	parseTime := func(value string) []interface{} {
+		t, _ := time.Parse(time.RFC3339, value)
+		return []interface{}{t}
+	}
+	switch field {
+	case "id":
+		return strs(d.ID), nil
+	case "managedCertificateId":
+		return strs(d.ManagedCertificateID), nil
+	case "certificateRequestId":
+		return strs(d.CertificateRequestID), nil
+	case "certificateStatus":
+		return strs(d.CertificateStatus), nil
+	case "fingerprint":
+		return strs(d.Fingerprint), nil
+	case "serialNumber":
+		return strs(d.SerialNumber), nil
+	case "subjectCN":
+		return strs(d.SubjectCN...), nil
+	case "issuerCN":
+		return strs(d.IssuerCN...), nil
+	case "applicationIds", "appstackIds":
+		return strs(d.ApplicationIDs...), nil
+	case "subjectAlternativeNameDns":
+		return strs(d.SubjectAlternativeNamesByType["dNSName"]...), nil
+	case "subjectAlternativeNameIpAddress":
+		return strs(d.SubjectAlternativeNamesByType["iPAddress"]...), nil
+	case "subjectAlternativeNameRfc822Name":
+		return strs(d.SubjectAlternativeNamesByType["rfc822Name"]...), nil
+	case "subjectAlternativeNameUri":
+		return strs(d.SubjectAlternativeNamesByType["uniformResourceIdentifier"]...), nil
+	case "keyStrength":
+		return []interface{}{float64(d.KeyStrength)}, nil
+	case "validityStart":
+		return parseTime(d.ValidityStart), nil
+	case "validityEnd":
+		return parseTime(d.ValidityEnd), nil
+	case "validityPeriodDays":
+		end, _ := time.Parse(time.RFC3339, d.ValidityEnd)
+		return []interface{}{float64(int(time.Until(end).Hours() / 24))}, nil
+	default:
+		return nil, fmt.Errorf("unsupported search field %s", field)
+	}
+}
+
+// compareValues compares a field value with a value of the search request, converted to the type of the field
+func compareValues(fieldValue, value interface{}) (int, error) {
+	switch fv := fieldValue.(type) {
+	case float64:
+		var v float64
+		switch typed := value.(type) {
+		case float64:
+			v = typed
+		case string:
+			var err error
+			v, err = strconv.ParseFloat(typed, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid number %q", typed)
+			}
+		default:
+			return 0, fmt.Errorf("invalid number %v", value)
+		}
+		switch {
+		case fv < v:
+			return -1, nil
+		case fv > v:
+			return 1, nil
+		}
+		return 0, nil
+	case time.Time:
+		t, err := time.Parse(time.RFC3339, fmt.Sprint(value))
+		if err != nil {
+			return 0, fmt.Errorf("invalid date %v", value)
+		}
+		return fv.Compare(t), nil
+	default:
+		return strings.Compare(strings.ToLower(fmt.Sprint(fv)), strings.ToLower(fmt.Sprint(value))), nil
+	}
+}
+
+func parseCSR(data string) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return csr, csr.CheckSignature()
+}
+
+func certThumbprint(data []byte) string {
+	h := sha1.Sum(data)
+	return strings.ToUpper(fmt.Sprintf("%x", h))
+}
+
+func nonEmpty(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+func hasValue(m map[string]string, value string) bool {
+	for _, v := range m {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,476 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/Venafi/vcert/v5/pkg/domain"
+)
+
+// MachineIdentity is the state of a certificate provisioned to a cloud keystore
+type MachineIdentity struct {
+	ID              string
+	CloudKeystoreID string
+	CertificateID   string
+	// CloudCertificateID is the ARN, Azure ID or GCP ID of the certificate in the cloud keystore
+	CloudCertificateID string
+	Name               string
+	Version            string
+	Status             string
+}
+
+type cloudProvider struct {
+	id           string
+	name         string
+	providerType domain.CloudProviderType
+}
+
+type cloudKeystore struct {
+	id           string
+	name         string
+	providerID   string
+	keystoreType domain.CloudKeystoreType
+}
+
+type machineIdentity struct {
+	id            string
+	keystoreID    string
+	certificateID string
+	cloudID       string
+	name          string
+	version       int
+	status        string
+	sequence      int
+}
+
+// AddCloudProvider adds a validated cloud provider and returns its ID
+func (s *Server) AddCloudProvider(name string, providerType domain.CloudProviderType) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := &cloudProvider{id: uuid.New().String(), name: name, providerType: providerType}
+	s.providers[p.id] = p
+	return p.id
+}
+
+// AddCloudKeystore adds a keystore to the cloud provider providerID and returns its ID
+func (s *Server) AddCloudKeystore(providerID string, name string, keystoreType domain.CloudKeystoreType) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.providers[providerID]; !ok {
+		return "", fmt.Errorf("cloud provider %s does not exist", providerID)
+	}
+	k := &cloudKeystore{id: uuid.New().String(), name: name, providerID: providerID, keystoreType: keystoreType}
+	s.keystores[k.id] = k
+	return k.id, nil
+}
+
+// MachineIdentity returns the state of the machine identity id
+func (s *Server) MachineIdentity(id string) (*MachineIdentity, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mi, ok := s.machineIdentities[id]
+	if !ok {
+		return nil, false
+	}
+	return &MachineIdentity{
+		ID:                 mi.id,
+		CloudKeystoreID:    mi.keystoreID,
+		CertificateID:      mi.certificateID,
+		CloudCertificateID: mi.cloudID,
+		Name:               mi.name,
+		Version:            mi.versionString(),
+		Status:             mi.status,
+	}, true
+}
+
+type graphqlRequest struct {
+	Query         string          `json:"query"`
+	OperationName string          `json:"operationName"`
+	Variables     json.RawMessage `json:"variables"`
+}
+
+// graphql serves the operations of the cloud providers client. Operations are dispatched by name, the query itself
+// is not parsed
+func (s *Server) graphql(w http.ResponseWriter, r *http.Request, _ []string) {
+	var req graphqlRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if len(req.Variables) == 0 {
+		req.Variables = json.RawMessage("{}")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var data interface{}
+	var err error
+	switch req.OperationName {
+	case "GetCloudProviders":
+		data, err = s.getCloudProviders(req.Variables)
+	case "GetCloudKeystores":
+		data, err = s.getCloudKeystores(req.Variables)
+	case "GetMachineIdentities":
+		data, err = s.getMachineIdentities(req.Variables)
+	case "DeleteMachineIdentities":
+		data, err = s.deleteMachineIdentities(req.Variables)
+	case "ProvisionCertificate":
+		data, err = s.provisionCertificate(req.Variables)
+	case "ProvisionCertificateToMachineIdentity":
+		data, err = s.provisionCertificateToMachineIdentity(req.Variables)
+	default:
+		err = fmt.Errorf("unsupported operation %q", req.OperationName)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"data":   nil,
+			"errors": []map[string]interface{}{{"message": err.Error()}},
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": data})
+}
+
+func (s *Server) getCloudProviders(variables json.RawMessage) (interface{}, error) {
+	var vars struct {
+		Status       *string `json:"status"`
+		ProviderType *string `json:"providerType"`
+		Name         string  `json:"name"`
+	}
+	err := json.Unmarshal(variables, &vars)
+	if err != nil {
+		return nil, err
+	}
+	nodes := []map[string]interface{}{}
+	for _, p := range sortedByName(s.providers, func(p *cloudProvider) string { return p.name }) {
+		if vars.Name != "" && p.name != vars.Name {
+			continue
+		}
+		if vars.Status != nil && *vars.Status != domain.CloudProviderStatusValidatedStr {
+			continue
+		}
+		if vars.ProviderType != nil && *vars.ProviderType != p.providerType.String() {
+			continue
+		}
+		nodes = append(nodes, map[string]interface{}{
+			"id":             p.id,
+			"name":           p.name,
+			"type":           p.providerType.String(),
+			"status":         domain.CloudProviderStatusValidatedStr,
+			"statusDetails":  nil,
+			"keystoresCount": s.keystoresCount(p.id),
+		})
+	}
+	return map[string]interface{}{"cloudProviders": map[string]interface{}{"nodes": nodes}}, nil
+}
+
+func (s *Server) getCloudKeystores(variables json.RawMessage) (interface{}, error) {
+	var vars struct {
+		CloudKeystoreID   *string `json:"cloudKeystoreId"`
+		CloudKeystoreName *string `json:"cloudKeystoreName"`
+		CloudProviderID   *string `json:"cloudProviderId"`
+		CloudProviderName *string `json:"cloudProviderName"`
+	}
+	err := json.Unmarshal(variables, &vars)
+	if err != nil {
+		return nil, err
+	}
+	nodes := []map[string]interface{}{}
+	for _, k := range sortedByName(s.keystores, func(k *cloudKeystore) string { return k.name }) {
+		p := s.providers[k.providerID]
+		if (vars.CloudKeystoreID != nil && *vars.CloudKeystoreID != k.id) ||
+			(vars.CloudKeystoreName != nil && *vars.CloudKeystoreName != k.name) ||
+			(vars.CloudProviderID != nil && *vars.CloudProviderID != p.id) ||
+			(vars.CloudProviderName != nil && *vars.CloudProviderName != p.name) {
+			continue
+		}
+		nodes = append(nodes, map[string]interface{}{
+			"id":                     k.id,
+			"name":                   k.name,
+			"type":                   k.keystoreType.String(),
+			"machineIdentitiesCount": s.machineIdentitiesCount(k.id),
+		})
+	}
+	return map[string]interface{}{"cloudKeystores": map[string]interface{}{"nodes": nodes}}, nil
+}
+
+func (s *Server) getMachineIdentities(variables json.RawMessage) (interface{}, error) {
+	var vars struct {
+		CloudKeystoreID   *string  `json:"cloudKeystoreId"`
+		MachineIdentityID *string  `json:"machineIdentityId"`
+		Fingerprints      []string `json:"fingerprints"`
+	}
+	err := json.Unmarshal(variables, &vars)
+	if err != nil {
+		return nil, err
+	}
+	identities := make([]*machineIdentity, 0, len(s.machineIdentities))
+	for _, mi := range s.machineIdentities {
+		identities = append(identities, mi)
+	}
+	sort.Slice(identities, func(i, j int) bool { return identities[i].sequence < identities[j].sequence })
+
+	nodes := []map[string]interface{}{}
+	for _, mi := range identities {
+		if (vars.CloudKeystoreID != nil && *vars.CloudKeystoreID != mi.keystoreID) ||
+			(vars.MachineIdentityID != nil && *vars.MachineIdentityID != mi.id) {
+			continue
+		}
+		if len(vars.Fingerprints) > 0 && !containsFold(vars.Fingerprints, certThumbprint(s.certificates[mi.certificateID].cert.Raw)) {
+			continue
+		}
+		k := s.keystores[mi.keystoreID]
+		p := s.providers[k.providerID]
+		nodes = append(nodes, map[string]interface{}{
+			"id":                mi.id,
+			"cloudKeystoreId":   k.id,
+			"cloudKeystoreName": k.name,
+			"cloudProviderId":   p.id,
+			"cloudProviderName": p.name,
+			"metadata":          mi.metadata(k.keystoreType),
+			"status":            mi.status,
+			"statusDetails":     nil,
+			"certificateId":     mi.certificateID,
+		})
+	}
+	return map[string]interface{}{"cloudMachineIdentities": map[string]interface{}{"nodes": nodes}}, nil
+}
+
+func (s *Server) deleteMachineIdentities(variables json.RawMessage) (interface{}, error) {
+	var vars struct {
+		MachineIdentityIDs []string `json:"machineIdentityIds"`
+	}
+	err := json.Unmarshal(variables, &vars)
+	if err != nil {
+		return nil, err
+	}
+	deleted := len(vars.MachineIdentityIDs) > 0
+	for _, id := range vars.MachineIdentityIDs {
+		if _, ok := s.machineIdentities[id]; !ok {
+			deleted = false
+			continue
+		}
+		delete(s.machineIdentities, id)
+	}
+	return map[string]interface{}{"deleteCloudMachineIdentities": deleted}, nil
+}
+
+func (s *Server) provisionCertificate(variables json.RawMessage) (interface{}, error) {
+	var vars struct {
+		CertificateID   string `json:"certificateId"`
+		CloudKeystoreID string `json:"cloudKeystoreId"`
+		WsClientID      string `json:"wsClientId"`
+		Options         *struct {
+			AwsOptions *struct {
+				Arn *string `json:"arn"`
+			} `json:"awsOptions"`
+			AzureOptions *struct {
+				Name *string `json:"name"`
+			} `json:"azureOptions"`
+			GcpOptions *struct {
+				ID *string `json:"id"`
+			} `json:"gcpOptions"`
+		} `json:"options"`
+	}
+	err := json.Unmarshal(variables, &vars)
+	if err != nil {
+		return nil, err
+	}
+	k, ok := s.keystores[vars.CloudKeystoreID]
+	if !ok {
+		return nil, fmt.Errorf("cloud keystore %s does not exist", vars.CloudKeystoreID)
+	}
+	obj, err := s.provisionableCertificate(vars.CertificateID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the certificate is replaced when the options name an existing certificate of the keystore
+	var name string
+	if o := vars.Options; o != nil {
+		switch {
+		case k.keystoreType == domain.CloudKeystoreTypeACM && o.AwsOptions != nil && o.AwsOptions.Arn != nil:
+			name = *o.AwsOptions.Arn
+		case k.keystoreType == domain.CloudKeystoreTypeAKV && o.AzureOptions != nil && o.AzureOptions.Name != nil:
+			name = *o.AzureOptions.Name
+		case k.keystoreType == domain.CloudKeystoreTypeGCM && o.GcpOptions != nil && o.GcpOptions.ID != nil:
+			name = *o.GcpOptions.ID
+		}
+	}
+	var mi *machineIdentity
+	if name != "" {
+		for _, existing := range s.machineIdentities {
+			if existing.keystoreID == k.id && (existing.name == name || existing.cloudID == name) {
+				mi = existing
+			}
+		}
+	}
+	if mi == nil {
+		mi = &machineIdentity{id: uuid.New().String(), keystoreID: k.id, sequence: s.nextSequence()}
+		if name == "" {
+			name = strings.ToLower(strings.ReplaceAll(obj.cert.Subject.CommonName, ".", "-"))
+		}
+		mi.name = name
+		s.machineIdentities[mi.id] = mi
+	}
+	return s.provision(mi, k, obj, vars.WsClientID, "provisionToCloudKeystore")
+}
+
+func (s *Server) provisionCertificateToMachineIdentity(variables json.RawMessage) (interface{}, error) {
+	var vars struct {
+		MachineIdentityID string  `json:"machineIdentityId"`
+		WsClientID        string  `json:"wsClientId"`
+		CertificateID     *string `json:"certificateId"`
+	}
+	err := json.Unmarshal(variables, &vars)
+	if err != nil {
+		return nil, err
+	}
+	mi, ok := s.machineIdentities[vars.MachineIdentityID]
+	if !ok {
+		return nil, fmt.Errorf("machine identity %s does not exist", vars.MachineIdentityID)
+	}
+	certificateID := mi.certificateID
+	if vars.CertificateID != nil {
+		certificateID = *vars.CertificateID
+	}
+	obj, err := s.provisionableCertificate(certificateID)
+	if err != nil {
+		return nil, err
+	}
+	return s.provision(mi, s.keystores[mi.keystoreID], obj, vars.WsClientID, "provisionToCloudMachineIdentity")
+}
+
+// provisionableCertificate returns the certificate id when its private key is held by the service
+func (s *Server) provisionableCertificate(id string) (*certificateObject, error) {
+	obj, ok := s.certificates[id]
+	if !ok {
+		return nil, fmt.Errorf("certificate %s does not exist", id)
+	}
+	if obj.key == nil {
+		return nil, fmt.Errorf("certificate %s cannot be provisioned as its private key is not held by the service", id)
+	}
+	return obj, nil
+}
+
+// provision installs obj in the machine identity and notifies the websocket client of the workflow result. It must be
+// called with the lock held
+func (s *Server) provision(mi *machineIdentity, k *cloudKeystore, obj *certificateObject, wsClientID string, field string) (interface{}, error) {
+	action := "Provision"
+	if mi.certificateID != "" {
+		action = "Renew"
+	}
+	mi.certificateID = obj.id
+	mi.version++
+	mi.status = domain.MachineIdentityStatusInstalledStr
+	switch k.keystoreType {
+	case domain.CloudKeystoreTypeACM:
+		if mi.cloudID == "" {
+			mi.cloudID = mi.name
+			if !strings.HasPrefix(mi.cloudID, "arn:") {
+				mi.cloudID = fmt.Sprintf("arn:aws:acm:us-east-1:000000000000:certificate/%s", uuid.New().String())
+			}
+		}
+	case domain.CloudKeystoreTypeAKV:
+		mi.cloudID = fmt.Sprintf("https://%s.vault.azure.net/certificates/%s/%s", k.name, mi.name, mi.versionString())
+	default:
+		mi.cloudID = fmt.Sprintf("projects/vcert/locations/global/certificates/%s", mi.name)
+	}
+
+	workflowID := uuid.New().String()
+	workflowName := "cloudKeystoreProvisioning"
+	message, err := json.Marshal(domain.WorkflowResponse{
+		SpecVersion:     "1.0",
+		Id:              uuid.New().String(),
+		Source:          "cloudtest",
+		Type:            "WORKFLOW_RESULT",
+		Subject:         workflowName,
+		DataContentType: "application/json",
+		Time:            formatTime(time.Now()),
+		Data: domain.WorkFlowResponseData{
+			Result: map[string]interface{}{
+				"cloudProviderCertificateId":      mi.cloudID,
+				"cloudProviderCertificateName":    mi.name,
+				"cloudProviderCertificateVersion": mi.versionString(),
+				"machineIdentityActionType":       action,
+				"machineIdentityId":               mi.id,
+			},
+			WorkflowID:   workflowID,
+			WorkflowName: workflowName,
+			WsClientID:   wsClientID,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.notify(wsClientID, message)
+	return map[string]interface{}{field: map[string]interface{}{"workflowId": workflowID, "workflowName": workflowName}}, nil
+}
+
+// metadata returns the cloud metadata of the machine identity in the shape of the GraphQL union
+func (mi *machineIdentity) metadata(keystoreType domain.CloudKeystoreType) map[string]interface{} {
+	switch keystoreType {
+	case domain.CloudKeystoreTypeACM:
+		return map[string]interface{}{"__typename": "AWSCertificateMetadata", "arn": mi.cloudID}
+	case domain.CloudKeystoreTypeAKV:
+		return map[string]interface{}{"__typename": "AzureCertificateMetadata", "azureId": mi.cloudID, "name": mi.name, "version": mi.versionString()}
+	default:
+		return map[string]interface{}{"__typename": "GCPCertificateMetadata", "gcpId": mi.cloudID, "name": mi.name}
+	}
+}
+
+func (mi *machineIdentity) versionString() string {
+	return fmt.Sprintf("%d", mi.version)
+}
+
+// keystoresCount must be called with the lock held
+func (s *Server) keystoresCount(providerID string) int {
+	count := 0
+	for _, k := range s.keystores {
+		if k.providerID == providerID {
+			count++
+		}
+	}
+	return count
+}
+
+// machineIdentitiesCount must be called with the lock held
+func (s *Server) machineIdentitiesCount(keystoreID string) int {
+	count := 0
+	for _, mi := range s.machineIdentities {
+		if mi.keystoreID == keystoreID {
+			count++
+		}
+	}
+	return count
+}
+
+func sortedByName[T any](m map[string]*T, name func(*T) string) []*T {
+	values := make([]*T, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool { return name(values[i]) < name(values[j]) })
+	return values
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudtest
+
+import (
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+var upgrader = websocket.Upgrader{
+	// the client is authenticated by its credentials, whatever its origin
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// notifications serves the websocket of a notification client. The workflow results addressed to the client are sent
+// as soon as it is connected, including those produced before it connected
+func (s *Server) notifications(w http.ResponseWriter, r *http.Request, params []string) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	id := params[0]
+
+	s.mu.Lock()
+	s.wsClients[id] = conn
+	for _, message := range s.pendingNotifications[id] {
+		_ = conn.WriteMessage(websocket.TextMessage, message)
+	}
+	delete(s.pendingNotifications, id)
+	s.mu.Unlock()
+
+	for {
+		if _, _, err = conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	if s.wsClients[id] == conn {
+		delete(s.wsClients, id)
+	}
+	s.mu.Unlock()
+	_ = conn.Close()
+}
+
+// notify sends message to the notification client id, or queues it until the client connects. It must be called with
+// the lock held
+func (s *Server) notify(id string, message []byte) {
+	conn, ok := s.wsClients[id]
+	if ok && conn.WriteMessage(websocket.TextMessage, message) == nil {
+		return
+	}
+	s.pendingNotifications[id] = append(s.pendingNotifications[id], message)
+}
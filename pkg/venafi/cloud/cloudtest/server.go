@@ -0,0 +1,403 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package cloudtest provides an in-process server emulating the TLS Protect Cloud API, so that cloud.Connector flows
+// can be tested without a tenant. The server keeps state for applications, issuing templates, certificates, cloud
+// providers and machine identities, delivers provisioning results over the notification websocket, and errors can
+// be injected on any endpoint:
+//
+//	server := cloudtest.NewServer()
+//	defer server.Close()
+//
+//	connector, _ := cloud.NewConnector(server.URL, cloudtest.DefaultZone, false, nil)
+//	connector.SetHTTPClient(server.Client())
+//	err := connector.Authenticate(&endpoint.Authentication{APIKey: cloudtest.DefaultAPIKey})
+//
+// The HTTP client must be set before authenticating, as the GraphQL and notification clients are created by
+// Authenticate from the transport of the connector
+package cloudtest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"golang.org/x/crypto/nacl/box"
+
+	"github.com/Venafi/vcert/v5/pkg/policy"
+	"github.com/Venafi/vcert/v5/pkg/util"
+)
+
+const (
+	// DefaultAPIKey is the API key accepted by a new server
+	DefaultAPIKey = "3a1d4b6e-5f0c-4e2a-9b7d-8c6f1e2d3a4b"
+	// DefaultUsername is the user owning the API key
+	DefaultUsername = "vcert@venafi.example"
+	// DefaultApplication and DefaultTemplate exist on a new server. DefaultZone is the zone made of them
+	DefaultApplication = "vcert"
+	DefaultTemplate    = "Default"
+	DefaultZone        = DefaultApplication + "\\" + DefaultTemplate
+
+	defaultPageSize = 10
+)
+
+// Fault describes an error response returned by the server instead of processing a request
+type Fault struct {
+	// Method and Path select the failing requests. Path is relative to the server URL, e.g.
+	// "outagedetection/v1/certificaterequests", and matched case-insensitively. An empty Method matches any method
+	Method string
+	Path   string
+	// StatusCode and Body are written as the response. Body is sent as is
+	StatusCode int
+	Body       string
+	// Count is the number of requests that fail before the fault is cleared. Zero means the fault is never cleared
+	Count int
+}
+
+// Server is an in-process TLS Protect Cloud API. The exported fields must be set before the server receives
+// requests
+type Server struct {
+	*httptest.Server
+
+	// APIKey is the API key accepted in the tppl-api-key header
+	APIKey string
+	// AccessToken is the bearer token accepted by the server and issued by its token endpoint to any service
+	// account
+	AccessToken string
+	// PendingRetrievals is the number of status requests answered as pending before a certificate request is
+	// issued
+	PendingRetrievals int
+
+	mu                   sync.Mutex
+	routes               []route
+	ca                   *x509.Certificate
+	caKey                crypto.Signer
+	dekPublicKey         *[32]byte
+	dekPrivateKey        *[32]byte
+	dekHash              string
+	companyID            string
+	user                 userInfo
+	teams                []team
+	caAccounts           map[string][]policy.AccountDetails
+	applications         map[string]*application
+	templates            map[string]*issuingTemplate
+	requests             map[string]*certificateRequest
+	certificates         map[string]*certificateObject
+	revocations          map[string]*revocationRequest
+	providers            map[string]*cloudProvider
+	keystores            map[string]*cloudKeystore
+	machineIdentities    map[string]*machineIdentity
+	wsClients            map[string]*websocket.Conn
+	pendingNotifications map[string][][]byte
+	sequence             int
+	faults               []*Fault
+	requestCounts        map[string]int
+}
+
+type route struct {
+	method   string
+	segments []string
+	handler  func(w http.ResponseWriter, r *http.Request, params []string)
+	// public routes are served without credentials
+	public bool
+}
+
+// NewServer starts a TLS server emulating TLS Protect Cloud. The server trusts nobody but can be reached with
+// server.Client(). It must be closed when done
+func NewServer() *Server {
+	s := &Server{
+		APIKey:               DefaultAPIKey,
+		AccessToken:          randomToken(),
+		companyID:            uuid.New().String(),
+		caAccounts:           map[string][]policy.AccountDetails{},
+		applications:         map[string]*application{},
+		templates:            map[string]*issuingTemplate{},
+		requests:             map[string]*certificateRequest{},
+		certificates:         map[string]*certificateObject{},
+		revocations:          map[string]*revocationRequest{},
+		providers:            map[string]*cloudProvider{},
+		keystores:            map[string]*cloudKeystore{},
+		machineIdentities:    map[string]*machineIdentity{},
+		wsClients:            map[string]*websocket.Conn{},
+		pendingNotifications: map[string][][]byte{},
+		requestCounts:        map[string]int{},
+	}
+	err := s.initCA()
+	if err != nil {
+		panic(fmt.Sprintf("cloudtest: failed to create the test CA: %s", err))
+	}
+	s.dekPublicKey, s.dekPrivateKey, err = box.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(fmt.Sprintf("cloudtest: failed to create the edge encryption key: %s", err))
+	}
+	s.dekHash = certThumbprint(s.dekPublicKey[:])
+
+	s.user = userInfo{
+		Username:        DefaultUsername,
+		ID:              uuid.New().String(),
+		CompanyID:       s.companyID,
+		EmailAddress:    DefaultUsername,
+		UserType:        "EXTERNAL",
+		UserAccountType: "API",
+		UserStatus:      "ACTIVE",
+		CreationDate:    formatTime(time.Now()),
+	}
+	s.teams = []team{{ID: uuid.New().String(), Name: "vcert-team", Role: "PLATFORM_ADMIN", CompanyID: s.companyID}}
+	s.addCAAccount("BUILTIN", "Built-In CA", "Default Product")
+	template := s.addTemplate(defaultTemplateRequest(DefaultTemplate))
+	s.addApplication(DefaultApplication, map[string]string{DefaultTemplate: template.ID})
+	s.initRoutes()
+
+	s.Server = httptest.NewUnstartedServer(s)
+	s.Server.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	s.Server.StartTLS()
+	return s
+}
+
+func (s *Server) initRoutes() {
+	s.handle("GET v1/useraccounts", s.userAccount)
+	s.handlePublic("POST v1/oauth2/v2.0/*/token", s.token)
+	s.handle("GET v1/users/*", s.userByID)
+	s.handle("GET v1/users/username/*", s.usersByName)
+	s.handle("GET v1/teams", s.listTeams)
+	s.handle("POST outagedetection/v1/certificaterequests", s.requestCertificate)
+	s.handle("GET outagedetection/v1/certificaterequests/*", s.certificateRequestStatus)
+	s.handle("POST outagedetection/v1/certificates", s.importCertificates)
+	s.handle("POST outagedetection/v1/certificates/retirement", s.retireCertificates)
+	s.handle("POST outagedetection/v1/certificates/revocation", s.revokeCertificates)
+	s.handle("GET outagedetection/v1/certificates/revocation/*", s.revocationStatus)
+	s.handle("GET outagedetection/v1/certificates/*", s.certificateDetails)
+	s.handle("GET outagedetection/v1/certificates/*/contents", s.certificateContents)
+	s.handle("POST outagedetection/v1/certificates/*/keystore", s.certificateKeystore)
+	s.handle("POST outagedetection/v1/certificatesearch", s.searchCertificates)
+	s.handle("GET v1/edgeencryptionkeys/*", s.edgeEncryptionKey)
+	s.handle("GET outagedetection/v1/applications/name/*", s.applicationByName)
+	s.handle("POST outagedetection/v1/applications", s.createApplication)
+	s.handle("PUT outagedetection/v1/applications/*", s.updateApplication)
+	s.handle("GET outagedetection/v1/applications/*/certificateissuingtemplates/*", s.applicationTemplate)
+	s.handle("GET v1/certificateissuingtemplates", s.listTemplates)
+	s.handle("POST v1/certificateissuingtemplates", s.createTemplate)
+	s.handle("PUT v1/certificateissuingtemplates/*", s.updateTemplate)
+	s.handle("GET v1/certificateauthorities/*/accounts", s.listCAAccounts)
+	s.handle("GET v1/certificateauthorities/*/accounts/*", s.caAccount)
+	s.handle("POST graphql", s.graphql)
+	s.handle("GET ws/notificationclients/*", s.notifications)
+}
+
+// handle registers the handler of the requests matching pattern, a method and a path where "*" matches any path
+// segment. The matched segments are passed to the handler. Literal segments must be registered before the patterns
+// they overlap with
+func (s *Server) handle(pattern string, handler func(w http.ResponseWriter, r *http.Request, params []string)) {
+	method, path, _ := strings.Cut(pattern, " ")
+	s.routes = append(s.routes, route{method: method, segments: strings.Split(path, "/"), handler: handler})
+}
+
+func (s *Server) handlePublic(pattern string, handler func(w http.ResponseWriter, r *http.Request, params []string)) {
+	s.handle(pattern, handler)
+	s.routes[len(s.routes)-1].public = true
+}
+
+// Close closes the notification websockets and shuts down the server
+func (s *Server) Close() {
+	s.mu.Lock()
+	for id, conn := range s.wsClients {
+		_ = conn.Close()
+		delete(s.wsClients, id)
+	}
+	s.mu.Unlock()
+	s.Server.Close()
+}
+
+// InjectFault makes the server answer the requests selected by f with an error
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f.Path = normalizePath(f.Path)
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all the injected faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// RequestCount returns the number of requests received for path, whatever their outcome
+func (s *Server) RequestCount(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requestCounts[normalizePath(path)]
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := normalizePath(r.URL.Path)
+
+	s.mu.Lock()
+	s.requestCounts[path]++
+	fault := s.matchFault(r.Method, path)
+	s.mu.Unlock()
+	if fault != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(fault.StatusCode)
+		_, _ = io.WriteString(w, fault.Body)
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+	for _, rt := range s.routes {
+		params, ok := rt.match(r.Method, segments)
+		if !ok {
+			continue
+		}
+		if !rt.public && !s.authenticated(w, r) {
+			return
+		}
+		rt.handler(w, r, params)
+		return
+	}
+	writeError(w, http.StatusNotFound, 10000, fmt.Sprintf("No resource was found that matches the request %s %s", r.Method, r.URL.Path))
+}
+
+// match returns the unescaped path segments matched by the wildcards of the route
+func (rt route) match(method string, segments []string) ([]string, bool) {
+	if method != rt.method || len(segments) != len(rt.segments) {
+		return nil, false
+	}
+	var params []string
+	for i, segment := range rt.segments {
+		if segment == "*" {
+			param, err := url.PathUnescape(segments[i])
+			if err != nil {
+				return nil, false
+			}
+			params = append(params, param)
+		} else if !strings.EqualFold(segment, segments[i]) {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// matchFault returns the fault to answer the request with, if any. It must be called with the lock held
+func (s *Server) matchFault(method, path string) *Fault {
+	for i, f := range s.faults {
+		if f.Path != path || (f.Method != "" && !strings.EqualFold(f.Method, method)) {
+			continue
+		}
+		if f.Count > 0 {
+			f.Count--
+			if f.Count == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+// authenticated checks the API key or access token of r, writing an authentication error when they are not valid
+func (s *Server) authenticated(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if apiKey := r.Header.Get(util.HeaderTpplApikey); apiKey != "" && apiKey == s.APIKey {
+		return true
+	}
+	if token, found := strings.CutPrefix(r.Header.Get("Authorization"), util.OauthTokenType+" "); found && token == s.AccessToken {
+		return true
+	}
+	writeError(w, http.StatusUnauthorized, 10001, "Unauthorized: the API key or access token is missing or not valid")
+	return false
+}
+
+// token issues the access token of the server to any service account presenting a client assertion
+func (s *Server) token(w http.ResponseWriter, r *http.Request, _ []string) {
+	err := r.ParseForm()
+	if err != nil || r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("client_assertion") == "" {
+		writeError(w, http.StatusBadRequest, 10002, "invalid_request: client_credentials grant with a client assertion is required")
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeJSON(w, http.StatusOK, struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}{s.AccessToken, util.OauthTokenType, 3600})
+}
+
+// normalizePath returns the resource addressed by path in the form used to count requests and match faults
+func normalizePath(path string) string {
+	return strings.Trim(strings.ToLower(path), "/")
+}
+
+// nextSequence returns a number ordering the objects by creation. It must be called with the lock held
+func (s *Server) nextSequence() int {
+	s.sequence++
+	return s.sequence
+}
+
+func randomToken() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, 10003, fmt.Sprintf("The request body is invalid: %s", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type responseError struct {
+	Code    int      `json:"code"`
+	Message string   `json:"message"`
+	Args    []string `json:"args,omitempty"`
+}
+
+// writeError writes an error the way the TLS Protect Cloud API does
+func writeError(w http.ResponseWriter, statusCode int, code int, message string) {
+	writeJSON(w, statusCode, struct {
+		Errors []responseError `json:"errors"`
+	}{[]responseError{{Code: code, Message: message}}})
+}
@@ -0,0 +1,359 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloudtest_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+	"github.com/Venafi/vcert/v5/pkg/domain"
+	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/policy"
+	"github.com/Venafi/vcert/v5/pkg/util"
+	"github.com/Venafi/vcert/v5/pkg/venafi/cloud"
+	"github.com/Venafi/vcert/v5/pkg/venafi/cloud/cloudtest"
+	"github.com/Venafi/vcert/v5/test"
+)
+
+func newConnector(t *testing.T, server *cloudtest.Server) *cloud.Connector {
+	t.Helper()
+	connector, err := cloud.NewConnector(server.URL, cloudtest.DefaultZone, false, nil)
+	if err != nil {
+		t.Fatalf("failed to create connector: %s", err)
+	}
+	connector.SetHTTPClient(server.Client())
+	err = connector.Authenticate(&endpoint.Authentication{APIKey: cloudtest.DefaultAPIKey})
+	if err != nil {
+		t.Fatalf("failed to authenticate: %s", err)
+	}
+	return connector
+}
+
+func TestAuthenticate(t *testing.T) {
+	server := cloudtest.NewServer()
+	defer server.Close()
+
+	connector, err := cloud.NewConnector(server.URL, cloudtest.DefaultZone, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	connector.SetHTTPClient(server.Client())
+	err = connector.Authenticate(&endpoint.Authentication{APIKey: "wrong"})
+	if err == nil {
+		t.Fatal("authentication with a wrong API key should fail")
+	}
+
+	resp, err := connector.GetAccessToken(&endpoint.Authentication{TokenURL: server.URL + "/v1/oauth2/v2.0/tenant/token", ExternalJWT: "header.payload.signature"})
+	if err != nil {
+		t.Fatalf("failed to get an access token: %s", err)
+	}
+	if resp.AccessToken != server.AccessToken {
+		t.Fatalf("unexpected access token %q", resp.AccessToken)
+	}
+	err = connector.Authenticate(&endpoint.Authentication{AccessToken: resp.AccessToken})
+	if err != nil {
+		t.Fatalf("failed to authenticate with the access token: %s", err)
+	}
+	_, err = connector.ReadZoneConfiguration()
+	if err != nil {
+		t.Fatalf("failed to read zone configuration with the access token: %s", err)
+	}
+}
+
+func TestCertificateLifecycle(t *testing.T) {
+	server := cloudtest.NewServer()
+	defer server.Close()
+	connector := newConnector(t, server)
+
+	req := test.RequestCertificate(t, connector, "lifecycle.example.com")
+	pcc, err := connector.RetrieveCertificate(req)
+	if err != nil {
+		t.Fatalf("failed to retrieve certificate: %s", err)
+	}
+	cert := test.ParseCertificatePEM(t, pcc.Certificate)
+	if cert.Subject.CommonName != "lifecycle.example.com" {
+		t.Fatalf("unexpected certificate %s", cert.Subject)
+	}
+	if len(pcc.Chain) != 1 || !test.ParseCertificatePEM(t, pcc.Chain[0]).Equal(server.CACertificate()) {
+		t.Fatalf("expected the CA certificate in the chain, got %v", pcc.Chain)
+	}
+
+	renewReq := &certificate.RenewalRequest{CertificateDN: req.PickupID, CertificateRequest: test.NewRequest(t, connector, "lifecycle.example.com")}
+	renewedID, err := connector.RenewCertificate(renewReq)
+	if err != nil {
+		t.Fatalf("failed to renew certificate: %s", err)
+	}
+	renewReq.CertificateRequest.PickupID = renewedID
+	renewed, err := connector.RetrieveCertificate(renewReq.CertificateRequest)
+	if err != nil {
+		t.Fatalf("failed to retrieve the renewed certificate: %s", err)
+	}
+	if test.ParseCertificatePEM(t, renewed.Certificate).Equal(cert) {
+		t.Fatal("renewal should issue a new certificate")
+	}
+}
+
+func TestServiceGeneratedKey(t *testing.T) {
+	server := cloudtest.NewServer()
+	defer server.Close()
+	connector := newConnector(t, server)
+
+	req := &certificate.Request{
+		Subject:     pkix.Name{CommonName: "central.example.com"},
+		DNSNames:    []string{"central.example.com"},
+		KeyType:     certificate.KeyTypeECDSA,
+		KeyCurve:    certificate.EllipticCurveP384,
+		CsrOrigin:   certificate.ServiceGeneratedCSR,
+		KeyPassword: "Passw0rd!",
+	}
+	err := connector.GenerateRequest(nil, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.PickupID, err = connector.RequestCertificate(req)
+	if err != nil {
+		t.Fatalf("failed to request certificate: %s", err)
+	}
+	pcc, err := connector.RetrieveCertificate(req)
+	if err != nil {
+		t.Fatalf("failed to retrieve certificate: %s", err)
+	}
+	if pcc.PrivateKey == "" {
+		t.Fatal("expected the private key")
+	}
+	if _, ok := test.ParseCertificatePEM(t, pcc.Certificate).PublicKey.(*ecdsa.PublicKey); !ok {
+		t.Fatal("expected an ECDSA certificate")
+	}
+}
+
+func TestPendingRetrieval(t *testing.T) {
+	server := cloudtest.NewServer()
+	server.PendingRetrievals = 1
+	defer server.Close()
+	connector := newConnector(t, server)
+
+	req := test.RequestCertificate(t, connector, "pending.example.com")
+	_, err := connector.RetrieveCertificate(req)
+	if err == nil || !strings.Contains(err.Error(), "pending") {
+		t.Fatalf("expected the issuance to be pending, got %v", err)
+	}
+	_, err = connector.RetrieveCertificate(req)
+	if err != nil {
+		t.Fatalf("failed to retrieve certificate: %s", err)
+	}
+}
+
+func TestSearchAndImport(t *testing.T) {
+	server := cloudtest.NewServer()
+	defer server.Close()
+	connector := newConnector(t, server)
+
+	for _, cn := range []string{"one.example.com", "two.example.com"} {
+		req := test.RequestCertificate(t, connector, cn)
+		_, err := connector.RetrieveCertificate(req)
+		if err != nil {
+			t.Fatalf("failed to retrieve certificate: %s", err)
+		}
+	}
+	cases := []struct {
+		name   string
+		search certificate.SearchRequest
+		cn     string
+	}{
+		{"common name", certificate.SearchRequest{"cn=two.example.com"}, "two.example.com"},
+		{"dns name", certificate.SearchRequest{"san-dns=one.example.com"}, "one.example.com"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			found, err := connector.SearchCertificates(&c.search)
+			if err != nil {
+				t.Fatalf("failed to search certificates: %s", err)
+			}
+			if found.Count != 1 {
+				t.Fatalf("unexpected search result %+v", found)
+			}
+			if info := found.Certificates[0].X509; info.CN != c.cn || info.Issuer == "" {
+				t.Fatalf("unexpected certificate details %+v", info)
+			}
+		})
+	}
+
+	otherServer := cloudtest.NewServer()
+	defer otherServer.Close()
+	other := newConnector(t, otherServer)
+	pcc, err := other.RetrieveCertificate(test.RequestCertificate(t, other, "imported.example.com"))
+	if err != nil {
+		t.Fatalf("failed to retrieve certificate: %s", err)
+	}
+	resp, err := connector.ImportCertificate(&certificate.ImportRequest{CertificateData: pcc.Certificate})
+	if err != nil {
+		t.Fatalf("failed to import certificate: %s", err)
+	}
+	obj, ok := server.Certificate(resp.CertId)
+	if !ok || obj.Certificate.Subject.CommonName != "imported.example.com" {
+		t.Fatalf("the imported certificate %s was not found", resp.CertId)
+	}
+}
+
+func TestRevokeAndRetire(t *testing.T) {
+	server := cloudtest.NewServer()
+	defer server.Close()
+	connector := newConnector(t, server)
+
+	pcc, err := connector.RetrieveCertificate(test.RequestCertificate(t, connector, "revoked.example.com"))
+	if err != nil {
+		t.Fatalf("failed to retrieve certificate: %s", err)
+	}
+	thumbprint := certThumbprint(test.ParseCertificatePEM(t, pcc.Certificate))
+	err = connector.RevokeCertificate(&certificate.RevocationRequest{Thumbprint: thumbprint, Reason: "key-compromise"})
+	if err != nil {
+		t.Fatalf("failed to revoke certificate: %s", err)
+	}
+
+	err = connector.RetireCertificate(&certificate.RetireRequest{Thumbprint: thumbprint})
+	if err != nil {
+		t.Fatalf("failed to retire certificate: %s", err)
+	}
+	found, err := connector.SearchCertificates(&certificate.SearchRequest{"thumbprint=" + thumbprint})
+	if err != nil {
+		t.Fatalf("failed to search certificates: %s", err)
+	}
+	if found.Count != 0 {
+		t.Fatalf("retired certificates should not be found, got %+v", found)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	server := cloudtest.NewServer()
+	defer server.Close()
+	connector := newConnector(t, server)
+
+	ps := &policy.PolicySpecification{
+		Policy: &policy.Policy{
+			Domains:         []string{"example.com"},
+			WildcardAllowed: util.GetBooleanRef(false),
+		},
+	}
+	_, err := connector.SetPolicy("app\\restricted", ps)
+	if err != nil {
+		t.Fatalf("failed to set policy: %s", err)
+	}
+	got, err := connector.GetPolicy("app\\restricted")
+	if err != nil {
+		t.Fatalf("failed to get policy: %s", err)
+	}
+	if len(got.Policy.Domains) != 1 || got.Policy.Domains[0] != "example.com" {
+		t.Fatalf("unexpected domains %v", got.Policy.Domains)
+	}
+
+	connector.SetZone("app\\restricted")
+	cases := []struct {
+		cn      string
+		allowed bool
+	}{
+		{"host.example.com", true},
+		{"host.other.org", false},
+	}
+	for _, c := range cases {
+		t.Run(c.cn, func(t *testing.T) {
+			_, err := connector.RequestCertificate(test.NewRequest(t, connector, c.cn))
+			if c.allowed && err != nil {
+				t.Fatalf("a request for an allowed domain should succeed: %s", err)
+			}
+			if !c.allowed && err == nil {
+				t.Fatal("a request outside of the allowed domains should fail")
+			}
+		})
+	}
+}
+
+func TestProvisionCertificate(t *testing.T) {
+	server := cloudtest.NewServer()
+	defer server.Close()
+	connector := newConnector(t, server)
+	providerID := server.AddCloudProvider("aws", domain.CloudProviderTypeAWS)
+	keystoreID, err := server.AddCloudKeystore(providerID, "acm", domain.CloudKeystoreTypeACM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := &certificate.Request{
+		Subject:     pkix.Name{CommonName: "provisioned.example.com"},
+		DNSNames:    []string{"provisioned.example.com"},
+		KeyType:     certificate.KeyTypeRSA,
+		KeyLength:   2048,
+		CsrOrigin:   certificate.ServiceGeneratedCSR,
+		KeyPassword: "Passw0rd!",
+	}
+	err = connector.GenerateRequest(nil, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.PickupID, err = connector.RequestCertificate(req)
+	if err != nil {
+		t.Fatalf("failed to request certificate: %s", err)
+	}
+	metadata, err := connector.ProvisionCertificate(&domain.ProvisioningRequest{PickupID: &req.PickupID, KeystoreID: &keystoreID}, nil)
+	if err != nil {
+		t.Fatalf("failed to provision certificate: %s", err)
+	}
+	if !strings.HasPrefix(metadata.CertificateID, "arn:aws:acm:") {
+		t.Fatalf("unexpected provisioning metadata %+v", metadata)
+	}
+	mi, ok := server.MachineIdentity(metadata.MachineIdentityID)
+	if !ok || mi.CloudCertificateID != metadata.CertificateID {
+		t.Fatalf("machine identity %s was not created", metadata.MachineIdentityID)
+	}
+	got, err := connector.GetMachineIdentity(domain.GetCloudMachineIdentityRequest{MachineIdentityID: &mi.ID})
+	if err != nil {
+		t.Fatalf("failed to get machine identity: %s", err)
+	}
+	if got.Metadata.GetKeystoreType() != domain.CloudKeystoreTypeACM {
+		t.Fatalf("unexpected machine identity %+v", got)
+	}
+}
+
+func TestInjectFault(t *testing.T) {
+	server := cloudtest.NewServer()
+	defer server.Close()
+	connector := newConnector(t, server)
+
+	server.InjectFault(cloudtest.Fault{Path: "outagedetection/v1/certificaterequests", StatusCode: http.StatusInternalServerError, Body: `{"errors":[{"code":1,"message":"boom"}]}`, Count: 1})
+	req := test.NewRequest(t, connector, "fault.example.com")
+	_, err := connector.RequestCertificate(req)
+	if err == nil {
+		t.Fatal("expected the injected fault")
+	}
+	_, err = connector.RequestCertificate(req)
+	if err != nil {
+		t.Fatalf("the fault should be cleared after one request: %s", err)
+	}
+	if n := server.RequestCount("outagedetection/v1/certificaterequests"); n != 2 {
+		t.Fatalf("expected 2 requests, got %d", n)
+	}
+}
+
+func certThumbprint(cert *x509.Certificate) string {
+	return strings.ToUpper(fmt.Sprintf("%x", sha1.Sum(cert.Raw)))
+}
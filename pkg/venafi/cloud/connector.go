@@ -19,6 +19,7 @@ package cloud
 import (
 	"archive/zip"
 	"bytes"
+	"context"
 	"crypto/rand"
 	"crypto/x509"
 	"encoding/base64"
@@ -38,6 +39,7 @@ import (
 
 	"github.com/Venafi/vcert/v5/pkg/certificate"
 	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/httputils"
 	"github.com/Venafi/vcert/v5/pkg/policy"
 	"github.com/Venafi/vcert/v5/pkg/util"
 	"github.com/Venafi/vcert/v5/pkg/verror"
@@ -48,34 +50,38 @@ import (
 type urlResource string
 
 const (
-	apiURL                                        = "api.venafi.cloud/"
-	apiVersion                                    = "v1/"
-	basePath                                      = "outagedetection/" + apiVersion
-	urlResourceUserAccounts           urlResource = apiVersion + "useraccounts"
-	urlResourceCertificateRequests    urlResource = basePath + "certificaterequests"
-	urlResourceCertificatesRetirement             = urlResourceCertificates + "/retirement"
-	urlResourceCertificateStatus                  = urlResourceCertificateRequests + "/%s"
-	urlResourceCertificates           urlResource = basePath + "certificates"
-	urlResourceCertificateByID                    = urlResourceCertificates + "/%s"
-	urlResourceCertificateRetrievePem             = urlResourceCertificates + "/%s/contents"
-	urlResourceCertificateSearch      urlResource = basePath + "certificatesearch"
-	urlResourceTemplate               urlResource = basePath + "applications/%s/certificateissuingtemplates/%s"
-	urlAppDetailsByName               urlResource = basePath + "applications/name/%s"
-	urlIssuingTemplate                urlResource = apiVersion + "certificateissuingtemplates"
-	urlAppRoot                        urlResource = basePath + "applications"
-	urlCAAccounts                     urlResource = apiVersion + "certificateauthorities/%s/accounts"
-	urlCAAccountDetails                           = urlCAAccounts + "/%s"
-	urlResourceCertificateKS                      = urlResourceCertificates + "/%s/keystore"
-	urlDekPublicKey                   urlResource = apiVersion + "edgeencryptionkeys/%s"
-	urlUsers                          urlResource = apiVersion + "users"
-	urlUserById                                   = urlUsers + "/%s"
-	urlUsersByName                                = urlUsers + "/username/%s"
-	urlTeams                          urlResource = apiVersion + "teams"
-	urlCertificateDetails                         = basePath + "certificates/%s"
-	urlGraphql                                    = "graphql"
+	apiURL                                             = "api.venafi.cloud/"
+	apiVersion                                         = "v1/"
+	basePath                                           = "outagedetection/" + apiVersion
+	urlResourceUserAccounts                urlResource = apiVersion + "useraccounts"
+	urlResourceCertificateRequests         urlResource = basePath + "certificaterequests"
+	urlResourceCertificatesRetirement                  = urlResourceCertificates + "/retirement"
+	urlResourceCertificatesRevocation                  = urlResourceCertificates + "/revocation"
+	urlResourceCertificateRevocationStatus             = urlResourceCertificatesRevocation + "/%s"
+	urlResourceCertificateStatus                       = urlResourceCertificateRequests + "/%s"
+	urlResourceCertificates                urlResource = basePath + "certificates"
+	urlResourceCertificateByID                         = urlResourceCertificates + "/%s"
+	urlResourceCertificateRetrievePem                  = urlResourceCertificates + "/%s/contents"
+	urlResourceCertificateSearch           urlResource = basePath + "certificatesearch"
+	urlResourceTemplate                    urlResource = basePath + "applications/%s/certificateissuingtemplates/%s"
+	urlAppDetailsByName                    urlResource = basePath + "applications/name/%s"
+	urlIssuingTemplate                     urlResource = apiVersion + "certificateissuingtemplates"
+	urlAppRoot                             urlResource = basePath + "applications"
+	urlCAAccounts                          urlResource = apiVersion + "certificateauthorities/%s/accounts"
+	urlCAAccountDetails                                = urlCAAccounts + "/%s"
+	urlResourceCertificateKS                           = urlResourceCertificates + "/%s/keystore"
+	urlDekPublicKey                        urlResource = apiVersion + "edgeencryptionkeys/%s"
+	urlUsers                               urlResource = apiVersion + "users"
+	urlUserById                                        = urlUsers + "/%s"
+	urlUsersByName                                     = urlUsers + "/username/%s"
+	urlTeams                               urlResource = apiVersion + "teams"
+	urlCertificateDetails                              = basePath + "certificates/%s"
+	urlGraphql                                         = "graphql"
 
 	defaultAppName = "Default"
 	oauthTokenType = "Bearer"
+
+	defaultRevocationTimeout = 60 * time.Second
 )
 
 type condorChainOption string
@@ -95,6 +101,7 @@ type Connector struct {
 	trust                 *x509.CertPool
 	zone                  cloudZone
 	client                *http.Client
+	retryPolicy           *httputils.RetryPolicy
 	userAgent             string
 	cloudProvidersClient  *cloudproviders.CloudProvidersClient
 	notificationSvcClient *notificationservice.NotificationServiceClient
@@ -130,13 +137,29 @@ func (c *Connector) SetHTTPClient(client *http.Client) {
 	c.client = client
 }
 
+// SetRetryPolicy sets how the requests to the platform are retried and rate limited. It applies to the HTTP client
+// built by the connector, not to one set with SetHTTPClient
+func (c *Connector) SetRetryPolicy(policy httputils.RetryPolicy) {
+	c.retryPolicy = &policy
+}
+
 // Ping attempts to connect to the Venafi Cloud API and returns an error if it cannot
 func (c *Connector) Ping() (err error) {
+	return c.PingContext(context.Background())
+}
+
+// PingContext is Ping with a context that cancels its requests to the platform
+func (c *Connector) PingContext(ctx context.Context) (err error) {
 	return nil
 }
 
 // Authenticate authenticates the user with Venafi Cloud using the provided API Key
 func (c *Connector) Authenticate(auth *endpoint.Authentication) error {
+	return c.AuthenticateContext(context.Background(), auth)
+}
+
+// AuthenticateContext is Authenticate with a context that cancels its requests to the platform
+func (c *Connector) AuthenticateContext(ctx context.Context, auth *endpoint.Authentication) error {
 	if auth == nil {
 		return fmt.Errorf("failed to authenticate: missing credentials")
 	}
@@ -146,7 +169,7 @@ func (c *Connector) Authenticate(auth *endpoint.Authentication) error {
 		c.accessToken = auth.AccessToken
 	} else if auth.TokenURL != "" && auth.ExternalJWT != "" {
 		//2. JWT and token URL. use it to request new access token
-		tokenResponse, err := c.GetAccessToken(auth)
+		tokenResponse, err := c.GetAccessTokenContext(ctx, auth)
 		if err != nil {
 			return err
 		}
@@ -155,7 +178,7 @@ func (c *Connector) Authenticate(auth *endpoint.Authentication) error {
 		// 3. API key. Get user to test authentication
 		c.apiKey = auth.APIKey
 		url := c.getURL(urlResourceUserAccounts)
-		statusCode, status, body, err := c.request("GET", url, nil, true)
+		statusCode, status, body, err := c.request(ctx, "GET", url, nil, true)
 		if err != nil {
 			return err
 		}
@@ -169,16 +192,28 @@ func (c *Connector) Authenticate(auth *endpoint.Authentication) error {
 	// Initialize clients
 	c.cloudProvidersClient = cloudproviders.NewCloudProvidersClient(c.getURL(urlGraphql), c.getGraphqlHTTPClient())
 	c.notificationSvcClient = notificationservice.NewNotificationServiceClient(c.baseURL, c.accessToken, c.apiKey)
+	transport := c.getHTTPClient().Transport
+	if retry, ok := transport.(*httputils.RetryTransport); ok {
+		transport = retry.Wrapped
+	}
+	if transport, ok := transport.(*http.Transport); ok {
+		c.notificationSvcClient.SetTLSConfig(transport.TLSClientConfig)
+	}
 
 	return nil
 }
 
 func (c *Connector) ReadPolicyConfiguration() (policy *endpoint.Policy, err error) {
+	return c.ReadPolicyConfigurationContext(context.Background())
+}
+
+// ReadPolicyConfigurationContext is ReadPolicyConfiguration with a context that cancels its requests to the platform
+func (c *Connector) ReadPolicyConfigurationContext(ctx context.Context) (policy *endpoint.Policy, err error) {
 	if !c.isAuthenticated() {
 		return nil, fmt.Errorf("must be autheticated to request a certificate")
 
 	}
-	config, err := c.ReadZoneConfiguration()
+	config, err := c.ReadZoneConfigurationContext(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -188,6 +223,11 @@ func (c *Connector) ReadPolicyConfiguration() (policy *endpoint.Policy, err erro
 
 // ReadZoneConfiguration reads the Zone information needed for generating and requesting a certificate from Venafi Cloud
 func (c *Connector) ReadZoneConfiguration() (config *endpoint.ZoneConfiguration, err error) {
+	return c.ReadZoneConfigurationContext(context.Background())
+}
+
+// ReadZoneConfigurationContext is ReadZoneConfiguration with a context that cancels its requests to the platform
+func (c *Connector) ReadZoneConfigurationContext(ctx context.Context) (config *endpoint.ZoneConfiguration, err error) {
 	if !c.isAuthenticated() {
 		return nil, fmt.Errorf("must be autheticated to request a certificate")
 	}
@@ -200,23 +240,23 @@ func (c *Connector) ReadZoneConfiguration() (config *endpoint.ZoneConfiguration,
 	citAlias := c.zone.getTemplateAlias()
 	if citAlias == "Default" {
 		appName := c.zone.getApplicationName()
-		_, statusCode, err = c.getAppDetailsByName(appName)
+		_, statusCode, err = c.getAppDetailsByName(ctx, appName)
 		if err != nil && statusCode == 404 {
 			log.Printf("creating application %s for issuing template %s", appName, citAlias)
 
 			ps := policy.PolicySpecification{}
-			template, err = getCit(c, citAlias)
+			template, err = getCit(ctx, c, citAlias)
 			if err != nil {
 				return
 			}
-			_, err = c.createApplication(appName, &ps, template)
+			_, err = c.createApplication(ctx, appName, &ps, template)
 			if err != nil {
 				return
 			}
 		}
 	}
 	if template == nil {
-		template, err = c.getTemplateByID()
+		template, err = c.getTemplateByID(ctx)
 		if err != nil {
 			return
 		}
@@ -227,12 +267,17 @@ func (c *Connector) ReadZoneConfiguration() (config *endpoint.ZoneConfiguration,
 
 // GetZonesByParent returns a list of valid zones for a VaaS application specified by parent
 func (c *Connector) GetZonesByParent(parent string) ([]string, error) {
+	return c.GetZonesByParentContext(context.Background(), parent)
+}
+
+// GetZonesByParentContext is GetZonesByParent with a context that cancels its requests to the platform
+func (c *Connector) GetZonesByParentContext(ctx context.Context, parent string) ([]string, error) {
 	if !c.isAuthenticated() {
 		return nil, fmt.Errorf("must be autheticated to request a certificate")
 	}
 
 	var zones []string
-	appDetails, _, err := c.getAppDetailsByName(parent)
+	appDetails, _, err := c.getAppDetailsByName(ctx, parent)
 	if err != nil {
 		return nil, err
 	}
@@ -245,23 +290,33 @@ func (c *Connector) GetZonesByParent(parent string) ([]string, error) {
 }
 
 // ResetCertificate resets the state of a certificate.
-func (c *Connector) ResetCertificate(_ *certificate.Request, _ bool) (err error) {
+func (c *Connector) ResetCertificate(req *certificate.Request, restart bool) (err error) {
+	return c.ResetCertificateContext(context.Background(), req, restart)
+}
+
+// ResetCertificateContext is ResetCertificate with a context that cancels its requests to the platform
+func (c *Connector) ResetCertificateContext(_ context.Context, _ *certificate.Request, _ bool) (err error) {
 	return fmt.Errorf("not supported by endpoint")
 }
 
 // RequestCertificate submits the CSR to the Venafi Cloud API for processing
 func (c *Connector) RequestCertificate(req *certificate.Request) (requestID string, err error) {
+	return c.RequestCertificateContext(context.Background(), req)
+}
+
+// RequestCertificateContext is RequestCertificate with a context that cancels its requests to the platform
+func (c *Connector) RequestCertificateContext(ctx context.Context, req *certificate.Request) (requestID string, err error) {
 	if !c.isAuthenticated() {
 		return "", fmt.Errorf("must be autheticated to request a certificate")
 	}
 
 	url := c.getURL(urlResourceCertificateRequests)
-	cloudReq, err := c.getCloudRequest(req)
+	cloudReq, err := c.getCloudRequest(ctx, req)
 	if err != nil {
 		return "", err
 	}
 
-	statusCode, status, body, err := c.request("POST", url, cloudReq)
+	statusCode, status, body, err := c.request(ctx, "POST", url, cloudReq)
 
 	if err != nil {
 		return "", err
@@ -277,6 +332,11 @@ func (c *Connector) RequestCertificate(req *certificate.Request) (requestID stri
 
 // RetrieveCertificate retrieves the certificate for the specified ID
 func (c *Connector) RetrieveCertificate(req *certificate.Request) (*certificate.PEMCollection, error) {
+	return c.RetrieveCertificateContext(context.Background(), req)
+}
+
+// RetrieveCertificateContext is RetrieveCertificate with a context that cancels its requests to the platform
+func (c *Connector) RetrieveCertificateContext(ctx context.Context, req *certificate.Request) (*certificate.PEMCollection, error) {
 	if !c.isAuthenticated() {
 		return nil, fmt.Errorf("must be autheticated to request a certificate")
 	}
@@ -284,7 +344,7 @@ func (c *Connector) RetrieveCertificate(req *certificate.Request) (*certificate.
 	if req.PickupID == "" && req.CertID == "" && req.Thumbprint != "" {
 		// search cert by Thumbprint and fill pickupID
 		var certificateRequestId string
-		searchResult, err := c.searchCertificatesByFingerprint(req.Thumbprint)
+		searchResult, err := c.searchCertificatesByFingerprint(ctx, req.Thumbprint)
 		if err != nil {
 			return nil, fmt.Errorf("failed to retrieve certificate: %s", err)
 		}
@@ -315,7 +375,7 @@ func (c *Connector) RetrieveCertificate(req *certificate.Request) (*certificate.
 
 	var certificateId string
 	if req.CertID == "" && req.PickupID != "" {
-		certId, err := c.getCertIDFromPickupID(req.PickupID, req.Timeout)
+		certId, err := c.getCertIDFromPickupID(ctx, req.PickupID, req.Timeout)
 		if err != nil {
 			return nil, err
 		}
@@ -333,13 +393,13 @@ func (c *Connector) RetrieveCertificate(req *certificate.Request) (*certificate.
 			currentId = certificateId
 		}
 
-		dekInfo, err := getDekInfo(c, currentId)
+		dekInfo, err := getDekInfo(ctx, c, currentId)
 		if err != nil {
 			return nil, err
 		}
 
 		req.CertID = currentId
-		return retrieveServiceGeneratedCertData(c, req, dekInfo)
+		return retrieveServiceGeneratedCertData(ctx, c, req, dekInfo)
 	}
 
 	url := c.getURL(urlResourceCertificateRetrievePem)
@@ -347,7 +407,7 @@ func (c *Connector) RetrieveCertificate(req *certificate.Request) (*certificate.
 
 	switch {
 	case req.CertID != "":
-		statusCode, status, body, err := c.waitForCertificate(url, req) //c.request("GET", url, nil)
+		statusCode, status, body, err := c.waitForCertificate(ctx, url, req) //c.request("GET", url, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -363,7 +423,7 @@ func (c *Connector) RetrieveCertificate(req *certificate.Request) (*certificate.
 		default:
 			url = fmt.Sprintf(url, condorChainOptionRootLast)
 		}
-		statusCode, status, body, err := c.waitForCertificate(url, req) //c.request("GET", url, nil)
+		statusCode, status, body, err := c.waitForCertificate(ctx, url, req) //c.request("GET", url, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -387,6 +447,11 @@ func (c *Connector) RetrieveCertificate(req *certificate.Request) (*certificate.
 
 // RenewCertificate attempts to renew the certificate
 func (c *Connector) RenewCertificate(renewReq *certificate.RenewalRequest) (requestID string, err error) {
+	return c.RenewCertificateContext(context.Background(), renewReq)
+}
+
+// RenewCertificateContext is RenewCertificate with a context that cancels its requests to the platform
+func (c *Connector) RenewCertificateContext(ctx context.Context, renewReq *certificate.RenewalRequest) (requestID string, err error) {
 	if !c.isAuthenticated() {
 		return "", fmt.Errorf("must be autheticated to request a certificate")
 	}
@@ -396,7 +461,7 @@ func (c *Connector) RenewCertificate(renewReq *certificate.RenewalRequest) (requ
 
 	if renewReq.Thumbprint != "" {
 		// by Thumbprint (aka Fingerprint)
-		searchResult, err := c.searchCertificatesByFingerprint(renewReq.Thumbprint)
+		searchResult, err := c.searchCertificatesByFingerprint(ctx, renewReq.Thumbprint)
 		if err != nil {
 			return "", fmt.Errorf("failed to create renewal request: %s", err)
 		}
@@ -424,7 +489,7 @@ func (c *Connector) RenewCertificate(renewReq *certificate.RenewalRequest) (requ
 	}
 
 	/* 2nd step is to get ManagedCertificateId & ZoneId by looking up certificate request record */
-	previousRequest, err := c.getCertificateStatus(certificateRequestId)
+	previousRequest, err := c.getCertificateStatus(ctx, certificateRequestId)
 	if err != nil {
 		return "", fmt.Errorf("certificate renew failed: %s", err)
 	}
@@ -446,7 +511,7 @@ func (c *Connector) RenewCertificate(renewReq *certificate.RenewalRequest) (requ
 
 	/* 3rd step is to get Certificate Object by id
 	and check if latestCertificateRequestId there equals to certificateRequestId from 1st step */
-	managedCertificate, err := c.getCertificate(certificateId)
+	managedCertificate, err := c.getCertificate(ctx, certificateId)
 	if err != nil {
 		return "", fmt.Errorf("failed to renew certificate: %s", err)
 	}
@@ -493,7 +558,7 @@ func (c *Connector) RenewCertificate(renewReq *certificate.RenewalRequest) (requ
 		req.ReuseCSR = true
 		return "", fmt.Errorf("reuseCSR option is not currently available for Renew Certificate operation. A new CSR must be provided in the request")
 	}
-	statusCode, status, body, err := c.request("POST", url, req)
+	statusCode, status, body, err := c.request(ctx, "POST", url, req)
 	if err != nil {
 		return
 	}
@@ -507,6 +572,11 @@ func (c *Connector) RenewCertificate(renewReq *certificate.RenewalRequest) (requ
 
 // RetireCertificate attempts to retire the certificate
 func (c *Connector) RetireCertificate(retireReq *certificate.RetireRequest) error {
+	return c.RetireCertificateContext(context.Background(), retireReq)
+}
+
+// RetireCertificateContext is RetireCertificate with a context that cancels its requests to the platform
+func (c *Connector) RetireCertificateContext(ctx context.Context, retireReq *certificate.RetireRequest) error {
 	if !c.isAuthenticated() {
 		return fmt.Errorf("must be autheticated to request a certificate")
 	}
@@ -516,7 +586,7 @@ func (c *Connector) RetireCertificate(retireReq *certificate.RetireRequest) erro
 	var certificateRequestId string
 	if retireReq.Thumbprint != "" {
 		// by Thumbprint (aka Fingerprint)
-		searchResult, err := c.searchCertificatesByFingerprint(retireReq.Thumbprint)
+		searchResult, err := c.searchCertificatesByFingerprint(ctx, retireReq.Thumbprint)
 		if err != nil {
 			return fmt.Errorf("failed to create retire request: %s", err)
 		}
@@ -544,7 +614,7 @@ func (c *Connector) RetireCertificate(retireReq *certificate.RetireRequest) erro
 	}
 
 	/* 2nd step is to get ManagedCertificateId & ZoneId by looking up certificate request record */
-	previousRequest, err := c.getCertificateStatus(certificateRequestId)
+	previousRequest, err := c.getCertificateStatus(ctx, certificateRequestId)
 	if err != nil {
 		if strings.Contains(err.Error(), "Unable to find certificateRequest") {
 			return fmt.Errorf("invalid thumbprint or certificate ID. No certificates were retired")
@@ -558,7 +628,7 @@ func (c *Connector) RetireCertificate(retireReq *certificate.RetireRequest) erro
 		CertificateIds: []string{certificateId},
 	}
 
-	statusCode, status, response, err := c.request("POST", url, retRequest)
+	statusCode, status, response, err := c.request(ctx, "POST", url, retRequest)
 	if err != nil {
 		return err
 	}
@@ -571,12 +641,162 @@ func (c *Connector) RetireCertificate(retireReq *certificate.RetireRequest) erro
 	return nil
 }
 
-// RevokeCertificate attempts to revoke the certificate
-func (c *Connector) RevokeCertificate(_ *certificate.RevocationRequest) (err error) {
-	return fmt.Errorf("not supported by endpoint")
+// RevokeCertificate attempts to revoke the certificate identified by its thumbprint or certificate ID
+// and waits until the revocation is completed by the CA
+func (c *Connector) RevokeCertificate(revReq *certificate.RevocationRequest) (err error) {
+	return c.RevokeCertificateContext(context.Background(), revReq)
+}
+
+// RevokeCertificateContext is RevokeCertificate with a context that cancels its requests to the platform
+func (c *Connector) RevokeCertificateContext(ctx context.Context, revReq *certificate.RevocationRequest) (err error) {
+	if !c.isAuthenticated() {
+		return fmt.Errorf("must be autheticated to revoke a certificate")
+	}
+
+	reason, ok := RevocationReasonsMap[revReq.Reason]
+	if !ok {
+		return fmt.Errorf("could not parse revocation reason `%s`", revReq.Reason)
+	}
+
+	/* 1st step is to get the ManagedCertificateId of the certificate to revoke */
+	var certificateId string
+	if revReq.Thumbprint != "" {
+		// by Thumbprint (aka Fingerprint)
+		searchResult, err := c.searchCertificatesByFingerprint(ctx, revReq.Thumbprint)
+		if err != nil {
+			return fmt.Errorf("failed to create revocation request: %s", err)
+		}
+		if len(searchResult.Certificates) == 0 {
+			return fmt.Errorf("no certificate found using fingerprint %s", revReq.Thumbprint)
+		}
+
+		var certIds []string
+		isOnlyOneCertificateId := true
+		for _, c := range searchResult.Certificates {
+			certIds = append(certIds, c.Id)
+			if certificateId != "" && certificateId != c.Id {
+				isOnlyOneCertificateId = false
+			}
+			certificateId = c.Id
+		}
+		if !isOnlyOneCertificateId {
+			return fmt.Errorf("error: more than one CertificateId was found with the same Fingerprint: %s", certIds)
+		}
+	} else if revReq.CertificateDN != "" {
+		// by CertificateDN (which is the ManagedCertificateId for Venafi Cloud)
+		managedCert, err := c.getCertificate(ctx, revReq.CertificateDN)
+		if err != nil {
+			return fmt.Errorf("failed to create revocation request: %s", err)
+		}
+		certificateId = managedCert.Id
+	} else {
+		return fmt.Errorf("failed to create revocation request: CertificateDN or Thumbprint required")
+	}
+
+	/* 2nd step is to submit the revocation request */
+	r := certificateRevokeRequest{
+		CertificateIds:    []string{certificateId},
+		RevocationReason:  reason,
+		RevocationComment: revReq.Comments,
+	}
+	url := c.getURL(urlResourceCertificatesRevocation)
+	statusCode, status, body, err := c.request(ctx, "POST", url, r)
+	if err != nil {
+		return err
+	}
+	revokeResponse, err := parseRevokeResult(statusCode, status, body)
+	if err != nil {
+		return err
+	}
+
+	/* 3rd step is to wait for the CA to complete the revocation */
+	timeout := revReq.Timeout
+	if timeout == 0 {
+		timeout = defaultRevocationTimeout
+	}
+	for _, rr := range revokeResponse.RevocationRequests {
+		err = c.waitForRevocation(ctx, rr, timeout)
+		if err != nil {
+			return err
+		}
+	}
+
+	/* Finally, retire the certificate when requested */
+	if revReq.Disable {
+		retRequest := certificateRetireRequest{
+			CertificateIds: []string{certificateId},
+		}
+		statusCode, status, body, err = c.request(ctx, "POST", c.getURL(urlResourceCertificatesRetirement), retRequest)
+		if err != nil {
+			return err
+		}
+		err = checkCertificateRetireResults(statusCode, status, body)
+		if err != nil {
+			return fmt.Errorf("certificate was revoked but could not be retired: %s", err)
+		}
+	}
+	return nil
+}
+
+// waitForRevocation polls the status of the revocation request until it is completed. Fails when the timeout is exceeded
+func (c *Connector) waitForRevocation(ctx context.Context, revocation certificateRevocationStatus, timeout time.Duration) error {
+	startTime := time.Now()
+	for {
+		switch revocation.Status {
+		case revocationStatusRevoked:
+			return nil
+		case revocationStatusFailed, revocationStatusRejected:
+			return fmt.Errorf("failed to revoke certificate %s. Status: %s %s", revocation.CertificateId, revocation.Status, revocation.ErrorInformation.Message)
+		}
+		if time.Now().After(startTime.Add(timeout)) {
+			return fmt.Errorf("timeout waiting for the revocation of certificate %s. Last status: %s", revocation.CertificateId, revocation.Status)
+		}
+		log.Println("Revocation of certificate is pending...")
+		if err := util.SleepContext(ctx, 2*time.Second); err != nil {
+			return err
+		}
+
+		status, err := c.getRevocationStatus(ctx, revocation.Id)
+		if err != nil {
+			return err
+		}
+		revocation = *status
+	}
+}
+
+func (c *Connector) getRevocationStatus(ctx context.Context, revocationId string) (*certificateRevocationStatus, error) {
+	url := c.getURL(urlResourceCertificateRevocationStatus)
+	url = fmt.Sprintf(url, revocationId)
+	statusCode, _, body, err := c.request(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode == http.StatusOK {
+		revStatus := &certificateRevocationStatus{}
+		err = json.Unmarshal(body, revStatus)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate revocation status response: %s", err)
+		}
+		return revStatus, nil
+	}
+	respErrors, err := parseResponseErrors(body)
+	if err == nil {
+		respError := fmt.Sprintf("Unexpected status code on Venafi Cloud certificate revocation status. Status: %d\n", statusCode)
+		for _, e := range respErrors {
+			respError += fmt.Sprintf("Error Code: %d Error: %s\n", e.Code, e.Message)
+		}
+		return nil, errors.New(respError)
+	}
+
+	return nil, fmt.Errorf("unexpected status code on Venafi Cloud certificate revocation status. Status: %d", statusCode)
 }
 
 func (c *Connector) ImportCertificate(req *certificate.ImportRequest) (*certificate.ImportResponse, error) {
+	return c.ImportCertificateContext(context.Background(), req)
+}
+
+// ImportCertificateContext is ImportCertificate with a context that cancels its requests to the platform
+func (c *Connector) ImportCertificateContext(ctx context.Context, req *certificate.ImportRequest) (*certificate.ImportResponse, error) {
 	if !c.isAuthenticated() {
 		return nil, fmt.Errorf("must be autheticated to request a certificate")
 	}
@@ -587,7 +807,7 @@ func (c *Connector) ImportCertificate(req *certificate.ImportRequest) (*certific
 	}
 	zone := req.PolicyDN
 	if zone == "" {
-		appDetails, _, err := c.getAppDetailsByName(c.zone.getApplicationName())
+		appDetails, _, err := c.getAppDetailsByName(ctx, c.zone.getApplicationName())
 		if err != nil {
 			return nil, err
 		}
@@ -616,7 +836,7 @@ func (c *Connector) ImportCertificate(req *certificate.ImportRequest) (*certific
 	}
 
 	url := c.getURL(urlResourceCertificates)
-	statusCode, status, body, err := c.request("POST", url, request)
+	statusCode, status, body, err := c.request(ctx, "POST", url, request)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", verror.ServerTemporaryUnavailableError, err)
 	}
@@ -636,8 +856,10 @@ func (c *Connector) ImportCertificate(req *certificate.ImportRequest) (*certific
 	} else if !(len(r.CertificateInformations) == 1) {
 		return nil, fmt.Errorf("%w: certificate was not imported on unknown reason", verror.ServerBadDataResponce)
 	}
-	time.Sleep(time.Second)
-	foundCert, err := c.searchCertificatesByFingerprint(fingerprint)
+	if err = util.SleepContext(ctx, time.Second); err != nil {
+		return nil, err
+	}
+	foundCert, err := c.searchCertificatesByFingerprint(ctx, fingerprint)
 	if err != nil {
 		return nil, err
 	}
@@ -650,6 +872,11 @@ func (c *Connector) ImportCertificate(req *certificate.ImportRequest) (*certific
 }
 
 func (c *Connector) ListCertificates(filter endpoint.Filter) ([]certificate.CertificateInfo, error) {
+	return c.ListCertificatesContext(context.Background(), filter)
+}
+
+// ListCertificatesContext is ListCertificates with a context that cancels its requests to the platform
+func (c *Connector) ListCertificatesContext(ctx context.Context, filter endpoint.Filter) ([]certificate.CertificateInfo, error) {
 	if !c.isAuthenticated() {
 		return nil, fmt.Errorf("must be autheticated to request a certificate")
 	}
@@ -666,7 +893,7 @@ func (c *Connector) ListCertificates(filter endpoint.Filter) ([]certificate.Cert
 	for page := 0; limit > 0; limit, page = limit-batchSize, page+1 {
 		var b []certificate.CertificateInfo
 		var err error
-		b, err = c.getCertsBatch(page, batchSize, filter.WithExpired)
+		b, err = c.getCertsBatch(ctx, page, batchSize, filter.WithExpired)
 		if limit < batchSize && len(b) > limit {
 			b = b[:limit]
 		}
@@ -691,11 +918,78 @@ func (c *Connector) ListCertificates(filter endpoint.Filter) ([]certificate.Cert
 	return infos, nil
 }
 
-func (c *Connector) SearchCertificates(_ *certificate.SearchRequest) (*certificate.CertSearchResponse, error) {
-	panic("operation is not supported yet")
+// SearchCertificates translates the generic search request to the Venafi Cloud search DSL and returns
+// all the matching certificates. When the request has no "Limit" attribute all the pages are retrieved
+func (c *Connector) SearchCertificates(req *certificate.SearchRequest) (*certificate.CertSearchResponse, error) {
+	return c.SearchCertificatesContext(context.Background(), req)
+}
+
+// SearchCertificatesContext is SearchCertificates with a context that cancels its requests to the platform
+func (c *Connector) SearchCertificatesContext(ctx context.Context, req *certificate.SearchRequest) (*certificate.CertSearchResponse, error) {
+	if !c.isAuthenticated() {
+		return nil, fmt.Errorf("must be autheticated to search certificates")
+	}
+
+	searchReq, appName, err := buildSearchRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if appName != "" {
+		app, _, err := c.getAppDetailsByName(ctx, appName)
+		if err != nil {
+			return nil, err
+		}
+		addOperand(searchReq, Operand{Field: "applicationIds", Operator: MATCH, Value: app.ApplicationId})
+	}
+
+	// a single page was requested by the caller
+	if searchReq.Paging != nil {
+		searchResult, err := c.searchCertificates(ctx, searchReq)
+		if err != nil {
+			return nil, err
+		}
+		return toCertSearchResponse(searchResult), nil
+	}
+
+	searchReq.Paging = &Paging{PageNumber: 0, PageSize: defaultSearchPageSize}
+	result := &certificate.CertSearchResponse{Certificates: []certificate.CertSeachInfo{}}
+	for {
+		searchResult, err := c.searchCertificates(ctx, searchReq)
+		if err != nil {
+			return nil, err
+		}
+		page := toCertSearchResponse(searchResult)
+		result.Certificates = append(result.Certificates, page.Certificates...)
+		result.Count = page.Count
+		if len(searchResult.Certificates) < searchReq.Paging.PageSize || len(result.Certificates) >= page.Count {
+			break
+		}
+		searchReq.Paging.PageNumber++
+	}
+	return result, nil
+}
+
+func toCertSearchResponse(searchResult *CertificateSearchResponse) *certificate.CertSearchResponse {
+	resp := &certificate.CertSearchResponse{
+		Certificates: make([]certificate.CertSeachInfo, 0, len(searchResult.Certificates)),
+		Count:        searchResult.Count,
+	}
+	for _, cert := range searchResult.Certificates {
+		resp.Certificates = append(resp.Certificates, certificate.CertSeachInfo{
+			CertificateRequestId:   cert.CertificateRequestId,
+			CertificateRequestGuid: cert.Id,
+			X509:                   cert.ToCertificateInfo(),
+		})
+	}
+	return resp
 }
 
 func (c *Connector) SearchCertificate(zone string, cn string, sans *certificate.Sans, certMinTimeLeft time.Duration) (certificateInfo *certificate.CertificateInfo, err error) {
+	return c.SearchCertificateContext(context.Background(), zone, cn, sans, certMinTimeLeft)
+}
+
+// SearchCertificateContext is SearchCertificate with a context that cancels its requests to the platform
+func (c *Connector) SearchCertificateContext(ctx context.Context, zone string, cn string, sans *certificate.Sans, certMinTimeLeft time.Duration) (certificateInfo *certificate.CertificateInfo, err error) {
 	if !c.isAuthenticated() {
 		return nil, fmt.Errorf("must be autheticated to request a certificate")
 	}
@@ -703,7 +997,7 @@ func (c *Connector) SearchCertificate(zone string, cn string, sans *certificate.
 	// retrieve application name from zone
 	appName := getAppNameFromZone(zone)
 	// get application id from name
-	app, _, err := c.getAppDetailsByName(appName)
+	app, _, err := c.getAppDetailsByName(ctx, appName)
 	if err != nil {
 		return nil, err
 	}
@@ -712,7 +1006,7 @@ func (c *Connector) SearchCertificate(zone string, cn string, sans *certificate.
 	req := formatSearchCertificateArguments(cn, sans, certMinTimeLeft)
 
 	// perform request
-	searchResult, err := c.searchCertificates(req)
+	searchResult, err := c.searchCertificates(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -743,7 +1037,7 @@ func (c *Connector) SearchCertificate(zone string, cn string, sans *certificate.
 	return certificate.FindNewestCertificateWithSans(certificates, sans)
 }
 
-func (c *Connector) getCertIDFromPickupID(pickupId string, timeout time.Duration) (*string, error) {
+func (c *Connector) getCertIDFromPickupID(ctx context.Context, pickupId string, timeout time.Duration) (*string, error) {
 	if pickupId == "" {
 		return nil, fmt.Errorf("pickupID cannot be empty in order to get certificate ID")
 	}
@@ -753,9 +1047,9 @@ func (c *Connector) getCertIDFromPickupID(pickupId string, timeout time.Duration
 
 	var certificateId string
 	for {
-		certStatus, err := c.getCertificateStatus(pickupId)
+		certStatus, err := c.getCertificateStatus(ctx, pickupId)
 		if err != nil {
-			return nil, fmt.Errorf("unable to retrieve: %s", err)
+			return nil, fmt.Errorf("unable to retrieve: %w", err)
 		}
 		if certStatus.Status == "ISSUED" {
 			certificateId = certStatus.CertificateIdsList[0]
@@ -771,7 +1065,9 @@ func (c *Connector) getCertIDFromPickupID(pickupId string, timeout time.Duration
 		if time.Now().After(startTime.Add(timeout)) {
 			return nil, endpoint.ErrRetrieveCertificateTimeout{CertificateID: pickupId}
 		}
-		time.Sleep(2 * time.Second)
+		if err = util.SleepContext(ctx, 2*time.Second); err != nil {
+			return nil, err
+		}
 	}
 	if certificateId == "" {
 		return nil, fmt.Errorf("something went wrong during polling cert status and we still got and empty CertificateID at the end")
@@ -781,6 +1077,11 @@ func (c *Connector) getCertIDFromPickupID(pickupId string, timeout time.Duration
 }
 
 func (c *Connector) IsCSRServiceGenerated(req *certificate.Request) (bool, error) {
+	return c.IsCSRServiceGeneratedContext(context.Background(), req)
+}
+
+// IsCSRServiceGeneratedContext is IsCSRServiceGenerated with a context that cancels its requests to the platform
+func (c *Connector) IsCSRServiceGeneratedContext(ctx context.Context, req *certificate.Request) (bool, error) {
 	if !c.isAuthenticated() {
 		return false, fmt.Errorf("must be autheticated to request a certificate")
 	}
@@ -788,7 +1089,7 @@ func (c *Connector) IsCSRServiceGenerated(req *certificate.Request) (bool, error
 	if req.PickupID == "" && req.CertID == "" && req.Thumbprint != "" {
 		// search cert by Thumbprint and fill pickupID
 		var certificateRequestId string
-		searchResult, err := c.searchCertificatesByFingerprint(req.Thumbprint)
+		searchResult, err := c.searchCertificatesByFingerprint(ctx, req.Thumbprint)
 		if err != nil {
 			return false, fmt.Errorf("failed to retrieve certificate: %s", err)
 		}
@@ -816,12 +1117,12 @@ func (c *Connector) IsCSRServiceGenerated(req *certificate.Request) (bool, error
 	var currentId string
 	var err error
 	if req.CertID != "" {
-		dekInfo, err = getDekInfo(c, req.CertID)
+		dekInfo, err = getDekInfo(ctx, c, req.CertID)
 	} else {
 		var certificateId string
-		certificateId, err = getCertificateId(c, req)
+		certificateId, err = getCertificateId(ctx, c, req)
 		if err == nil && certificateId != "" {
-			dekInfo, err = getDekInfo(c, certificateId)
+			dekInfo, err = getDekInfo(ctx, c, certificateId)
 		}
 	}
 
@@ -832,12 +1133,22 @@ func (c *Connector) IsCSRServiceGenerated(req *certificate.Request) (bool, error
 	return false, nil
 }
 
-func (c *Connector) RetrieveCertificateMetaData(_ string) (*certificate.CertificateMetaData, error) {
+func (c *Connector) RetrieveCertificateMetaData(dn string) (*certificate.CertificateMetaData, error) {
+	return c.RetrieveCertificateMetaDataContext(context.Background(), dn)
+}
+
+// RetrieveCertificateMetaDataContext is RetrieveCertificateMetaData with a context that cancels its requests to the platform
+func (c *Connector) RetrieveCertificateMetaDataContext(_ context.Context, _ string) (*certificate.CertificateMetaData, error) {
 	panic("operation is not supported yet")
 }
 
 // SynchronousRequestCertificate It's not supported yet in VaaS
-func (c *Connector) SynchronousRequestCertificate(_ *certificate.Request) (certificates *certificate.PEMCollection, err error) {
+func (c *Connector) SynchronousRequestCertificate(req *certificate.Request) (certificates *certificate.PEMCollection, err error) {
+	return c.SynchronousRequestCertificateContext(context.Background(), req)
+}
+
+// SynchronousRequestCertificateContext is SynchronousRequestCertificate with a context that cancels its requests to the platform
+func (c *Connector) SynchronousRequestCertificateContext(_ context.Context, _ *certificate.Request) (certificates *certificate.PEMCollection, err error) {
 	panic("operation is not supported yet")
 }
 
@@ -847,10 +1158,15 @@ func (c *Connector) SupportSynchronousRequestCertificate() bool {
 }
 
 func (c *Connector) RetrieveSystemVersion() (response string, err error) {
+	return c.RetrieveSystemVersionContext(context.Background())
+}
+
+// RetrieveSystemVersionContext is RetrieveSystemVersion with a context that cancels its requests to the platform
+func (c *Connector) RetrieveSystemVersionContext(ctx context.Context) (response string, err error) {
 	panic("operation is not supported yet")
 }
 
-func getCertificateId(c *Connector, req *certificate.Request) (string, error) {
+func getCertificateId(ctx context.Context, c *Connector, req *certificate.Request) (string, error) {
 	startTime := time.Now()
 	//Wait for certificate to be issued by checking its PickupID
 	//If certID is filled then certificate should be already issued.
@@ -858,9 +1174,9 @@ func getCertificateId(c *Connector, req *certificate.Request) (string, error) {
 		if req.PickupID == "" {
 			break
 		}
-		certStatus, err := c.getCertificateStatus(req.PickupID)
+		certStatus, err := c.getCertificateStatus(ctx, req.PickupID)
 		if err != nil {
-			return "", fmt.Errorf("unable to retrieve: %s", err)
+			return "", fmt.Errorf("unable to retrieve: %w", err)
 		}
 		if certStatus.Status == "ISSUED" {
 			return certStatus.CertificateIdsList[0], nil
@@ -875,7 +1191,9 @@ func getCertificateId(c *Connector, req *certificate.Request) (string, error) {
 		if time.Now().After(startTime.Add(req.Timeout)) {
 			return "", endpoint.ErrRetrieveCertificateTimeout{CertificateID: req.PickupID}
 		}
-		time.Sleep(2 * time.Second)
+		if err = util.SleepContext(ctx, 2*time.Second); err != nil {
+			return "", err
+		}
 	}
 
 	return "", endpoint.ErrRetrieveCertificateTimeout{CertificateID: req.PickupID}
@@ -891,6 +1209,11 @@ func normalizeURL(url string) (normalizedURL string, err error) {
 }
 
 func (c *Connector) GetAccessToken(auth *endpoint.Authentication) (*TLSPCAccessTokenResponse, error) {
+	return c.GetAccessTokenContext(context.Background(), auth)
+}
+
+// GetAccessTokenContext is GetAccessToken with a context that cancels its requests to the platform
+func (c *Connector) GetAccessTokenContext(ctx context.Context, auth *endpoint.Authentication) (*TLSPCAccessTokenResponse, error) {
 	if auth == nil || auth.TokenURL == "" || auth.ExternalJWT == "" {
 		return nil, fmt.Errorf("failed to authenticate: missing credentials")
 	}
@@ -905,7 +1228,7 @@ func (c *Connector) GetAccessToken(auth *endpoint.Authentication) (*TLSPCAccessT
 	body.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
 	body.Set("client_assertion", auth.ExternalJWT)
 
-	r, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body.Encode()))
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(body.Encode()))
 	if err != nil {
 		err = fmt.Errorf("%w: %v", verror.VcertError, err)
 		return nil, err
@@ -916,7 +1239,7 @@ func (c *Connector) GetAccessToken(auth *endpoint.Authentication) (*TLSPCAccessT
 	httpClient := c.getHTTPClient()
 	resp, err := httpClient.Do(r)
 	if err != nil {
-		err = fmt.Errorf("%w: %v", verror.ServerUnavailableError, err)
+		err = fmt.Errorf("%w: %w", verror.ServerUnavailableError, err)
 		return nil, err
 	}
 
@@ -955,7 +1278,7 @@ func (c *Connector) isAuthenticated() bool {
 	return false
 }
 
-func (c *Connector) getCloudRequest(req *certificate.Request) (*certificateRequest, error) {
+func (c *Connector) getCloudRequest(ctx context.Context, req *certificate.Request) (*certificateRequest, error) {
 	ipAddr := endpoint.LocalIP
 	origin := endpoint.SDKName
 	for _, f := range req.CustomFields {
@@ -964,7 +1287,7 @@ func (c *Connector) getCloudRequest(req *certificate.Request) (*certificateReque
 		}
 	}
 
-	appDetails, _, err := c.getAppDetailsByName(c.zone.getApplicationName())
+	appDetails, _, err := c.getAppDetailsByName(ctx, c.zone.getApplicationName())
 	if err != nil {
 		return nil, err
 	}
@@ -984,7 +1307,7 @@ func (c *Connector) getCloudRequest(req *certificate.Request) (*certificateReque
 	} else {
 
 		cloudReq.IsVaaSGenerated = true
-		csrAttr, err := getCsrAttributes(c, req)
+		csrAttr, err := getCsrAttributes(ctx, c, req)
 		if err != nil {
 			return nil, err
 		}
@@ -1025,10 +1348,10 @@ func (c *Connector) getCloudRequest(req *certificate.Request) (*certificateReque
 	return &cloudReq, nil
 }
 
-func (c *Connector) getCertificateStatus(requestID string) (certStatus *certificateStatus, err error) {
+func (c *Connector) getCertificateStatus(ctx context.Context, requestID string) (certStatus *certificateStatus, err error) {
 	url := c.getURL(urlResourceCertificateStatus)
 	url = fmt.Sprintf(url, requestID)
-	statusCode, _, body, err := c.request("GET", url, nil)
+	statusCode, _, body, err := c.request(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1053,7 +1376,7 @@ func (c *Connector) getCertificateStatus(requestID string) (certStatus *certific
 
 }
 
-func retrieveServiceGeneratedCertData(c *Connector, req *certificate.Request, dekInfo *EdgeEncryptionKey) (*certificate.PEMCollection, error) {
+func retrieveServiceGeneratedCertData(ctx context.Context, c *Connector, req *certificate.Request, dekInfo *EdgeEncryptionKey) (*certificate.PEMCollection, error) {
 
 	pkDecoded, err := base64.StdEncoding.DecodeString(dekInfo.Key)
 
@@ -1083,7 +1406,7 @@ func retrieveServiceGeneratedCertData(c *Connector, req *certificate.Request, de
 	url := c.getURL(urlResourceCertificateKS)
 	url = fmt.Sprintf(url, req.CertID)
 
-	statusCode, status, body, err := c.request("POST", url, ksRequest)
+	statusCode, status, body, err := c.request(ctx, "POST", url, ksRequest)
 
 	if err != nil {
 		return nil, err
@@ -1102,12 +1425,12 @@ func retrieveServiceGeneratedCertData(c *Connector, req *certificate.Request, de
 
 }
 
-func getDekInfo(c *Connector, cerId string) (*EdgeEncryptionKey, error) {
+func getDekInfo(ctx context.Context, c *Connector, cerId string) (*EdgeEncryptionKey, error) {
 	//get certificate details for getting DekHash
 	url := c.getURL(urlResourceCertificateByID)
 	url = fmt.Sprintf(url, cerId)
 
-	statusCode, status, body, err := c.request("GET", url, nil)
+	statusCode, status, body, err := c.request(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1122,7 +1445,7 @@ func getDekInfo(c *Connector, cerId string) (*EdgeEncryptionKey, error) {
 	url = c.getURL(urlDekPublicKey)
 	url = fmt.Sprintf(url, managedCert.DekHash)
 
-	statusCode, status, body, err = c.request("GET", url, nil)
+	statusCode, status, body, err = c.request(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1205,10 +1528,10 @@ func ConvertZipBytesToPem(dataByte []byte, rootFirst bool) (*certificate.PEMColl
 }
 
 // Waits for the Certificate to be available. Fails when the timeout is exceeded
-func (c *Connector) waitForCertificate(url string, request *certificate.Request) (statusCode int, status string, body []byte, err error) {
+func (c *Connector) waitForCertificate(ctx context.Context, url string, request *certificate.Request) (statusCode int, status string, body []byte, err error) {
 	startTime := time.Now()
 	for {
-		statusCode, status, body, err = c.request("GET", url, nil)
+		statusCode, status, body, err = c.request(ctx, "GET", url, nil)
 		if err != nil {
 			return
 		}
@@ -1223,21 +1546,28 @@ func (c *Connector) waitForCertificate(url string, request *certificate.Request)
 			err = endpoint.ErrRetrieveCertificateTimeout{CertificateID: request.PickupID}
 			return
 		}
-		time.Sleep(2 * time.Second)
+		if err = util.SleepContext(ctx, 2*time.Second); err != nil {
+			return
+		}
 	}
 }
 
 // WriteLog Custom Logging not currently supported by VaaS
-func (c *Connector) WriteLog(_ *endpoint.LogRequest) (err error) {
+func (c *Connector) WriteLog(logReq *endpoint.LogRequest) (err error) {
+	return c.WriteLogContext(context.Background(), logReq)
+}
+
+// WriteLogContext is WriteLog with a context that cancels its requests to the platform
+func (c *Connector) WriteLogContext(_ context.Context, _ *endpoint.LogRequest) (err error) {
 	return fmt.Errorf("outbound logging not supported by endpoint")
 }
 
-func (c *Connector) searchCertificates(req *SearchRequest) (*CertificateSearchResponse, error) {
+func (c *Connector) searchCertificates(ctx context.Context, req *SearchRequest) (*CertificateSearchResponse, error) {
 
 	var err error
 
 	url := c.getURL(urlResourceCertificateSearch)
-	statusCode, _, body, err := c.request("POST", url, req)
+	statusCode, _, body, err := c.request(ctx, "POST", url, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1248,7 +1578,7 @@ func (c *Connector) searchCertificates(req *SearchRequest) (*CertificateSearchRe
 	return searchResult, nil
 }
 
-func (c *Connector) searchCertificatesByFingerprint(fp string) (*CertificateSearchResponse, error) {
+func (c *Connector) searchCertificatesByFingerprint(ctx context.Context, fp string) (*CertificateSearchResponse, error) {
 	fp = strings.Replace(fp, ":", "", -1)
 	fp = strings.Replace(fp, ".", "", -1)
 	fp = strings.ToUpper(fp)
@@ -1263,7 +1593,7 @@ func (c *Connector) searchCertificatesByFingerprint(fp string) (*CertificateSear
 			},
 		},
 	}
-	return c.searchCertificates(req)
+	return c.searchCertificates(ctx, req)
 }
 
 type managedCertificate struct {
@@ -1273,11 +1603,11 @@ type managedCertificate struct {
 	DekHash              string `json:"dekHash,omitempty"`
 }
 
-func (c *Connector) getCertificate(certificateId string) (*managedCertificate, error) {
+func (c *Connector) getCertificate(ctx context.Context, certificateId string) (*managedCertificate, error) {
 	var err error
 	url := c.getURL(urlResourceCertificateByID)
 	url = fmt.Sprintf(url, certificateId)
-	statusCode, _, body, err := c.request("GET", url, nil)
+	statusCode, _, body, err := c.request(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1305,9 +1635,9 @@ func (c *Connector) getCertificate(certificateId string) (*managedCertificate, e
 	}
 }
 
-func (c *Connector) getCertsBatch(page, pageSize int, withExpired bool) ([]certificate.CertificateInfo, error) {
+func (c *Connector) getCertsBatch(ctx context.Context, page, pageSize int, withExpired bool) ([]certificate.CertificateInfo, error) {
 
-	appDetails, _, err := c.getAppDetailsByName(c.zone.getApplicationName())
+	appDetails, _, err := c.getAppDetailsByName(ctx, c.zone.getApplicationName())
 	if err != nil {
 		return nil, err
 	}
@@ -1332,7 +1662,7 @@ func (c *Connector) getCertsBatch(page, pageSize int, withExpired bool) ([]certi
 			Value:    time.Now().Format(time.RFC3339),
 		})
 	}
-	r, err := c.searchCertificates(req)
+	r, err := c.searchCertificates(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1343,11 +1673,11 @@ func (c *Connector) getCertsBatch(page, pageSize int, withExpired bool) ([]certi
 	return infos, nil
 }
 
-func (c *Connector) getAppDetailsByName(appName string) (*ApplicationDetails, int, error) {
+func (c *Connector) getAppDetailsByName(ctx context.Context, appName string) (*ApplicationDetails, int, error) {
 	url := c.getURL(urlAppDetailsByName)
 	encodedAppName := netUrl.PathEscape(appName)
 	url = fmt.Sprintf(url, encodedAppName)
-	statusCode, status, body, err := c.request("GET", url, nil)
+	statusCode, status, body, err := c.request(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, statusCode, err
 	}
@@ -1358,12 +1688,12 @@ func (c *Connector) getAppDetailsByName(appName string) (*ApplicationDetails, in
 	return details, statusCode, nil
 }
 
-func (c *Connector) getTemplateByID() (*certificateTemplate, error) {
+func (c *Connector) getTemplateByID(ctx context.Context) (*certificateTemplate, error) {
 	url := c.getURL(urlResourceTemplate)
 	appNameEncoded := netUrl.PathEscape(c.zone.getApplicationName())
 	citAliasEncoded := netUrl.PathEscape(c.zone.getTemplateAlias())
 	url = fmt.Sprintf(url, appNameEncoded, citAliasEncoded)
-	statusCode, status, body, err := c.request("GET", url, nil)
+	statusCode, status, body, err := c.request(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1371,9 +1701,9 @@ func (c *Connector) getTemplateByID() (*certificateTemplate, error) {
 	return t, err
 }
 
-func getCit(c *Connector, citName string) (*certificateTemplate, error) {
+func getCit(ctx context.Context, c *Connector, citName string) (*certificateTemplate, error) {
 	url := c.getURL(urlIssuingTemplate)
-	_, _, body, err := c.request("GET", url, nil)
+	_, _, body, err := c.request(ctx, "GET", url, nil)
 
 	if err != nil {
 		return nil, err
@@ -1402,6 +1732,11 @@ func getCit(c *Connector, citName string) (*certificateTemplate, error) {
 }
 
 func (c *Connector) CreateAPIUserAccount(userName string, password string) (int, *userDetails, error) {
+	return c.CreateAPIUserAccountContext(context.Background(), userName, password)
+}
+
+// CreateAPIUserAccountContext is CreateAPIUserAccount with a context that cancels its requests to the platform
+func (c *Connector) CreateAPIUserAccountContext(ctx context.Context, userName string, password string) (int, *userDetails, error) {
 
 	indexOfAt := strings.Index(userName, "@")
 
@@ -1417,13 +1752,18 @@ func (c *Connector) CreateAPIUserAccount(userName string, password string) (int,
 		// required the workaround to set something on firstName or lastName field. For now, we are setting the email's prefix
 	}
 
-	return c.CreateUserAccount(&userAccountReq)
+	return c.CreateUserAccountContext(ctx, &userAccountReq)
 }
 
 func (c *Connector) CreateUserAccount(userAccount *userAccount) (int, *userDetails, error) {
+	return c.CreateUserAccountContext(context.Background(), userAccount)
+}
+
+// CreateUserAccountContext is CreateUserAccount with a context that cancels its requests to the platform
+func (c *Connector) CreateUserAccountContext(ctx context.Context, userAccount *userAccount) (int, *userDetails, error) {
 
 	url := c.getURL(urlResourceUserAccounts)
-	statusCode, status, body, err := c.request("POST", url, userAccount, true)
+	statusCode, status, body, err := c.request(ctx, "POST", url, userAccount, true)
 	if err != nil {
 		return statusCode, nil, err
 	}
@@ -1435,10 +1775,10 @@ func (c *Connector) CreateUserAccount(userAccount *userAccount) (int, *userDetai
 	return statusCode, ud, nil
 }
 
-func (c *Connector) getUserDetails() (*userDetails, error) {
+func (c *Connector) getUserDetails(ctx context.Context) (*userDetails, error) {
 
 	url := c.getURL(urlResourceUserAccounts)
-	statusCode, status, body, err := c.request("GET", url, nil)
+	statusCode, status, body, err := c.request(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1450,12 +1790,12 @@ func (c *Connector) getUserDetails() (*userDetails, error) {
 	return ud, nil
 }
 
-func (c *Connector) retrieveUser(id string) (*user, error) {
+func (c *Connector) retrieveUser(ctx context.Context, id string) (*user, error) {
 
 	url := c.getURL(urlUserById)
 	url = fmt.Sprintf(url, id)
 
-	statusCode, status, body, err := c.request("GET", url, nil)
+	statusCode, status, body, err := c.request(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1466,12 +1806,12 @@ func (c *Connector) retrieveUser(id string) (*user, error) {
 	return user, nil
 }
 
-func (c *Connector) retrieveUsers(userName string) (*users, error) {
+func (c *Connector) retrieveUsers(ctx context.Context, userName string) (*users, error) {
 
 	url := c.getURL(urlUsersByName)
 	url = fmt.Sprintf(url, userName)
 
-	statusCode, status, body, err := c.request("GET", url, nil)
+	statusCode, status, body, err := c.request(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1482,11 +1822,11 @@ func (c *Connector) retrieveUsers(userName string) (*users, error) {
 	return users, nil
 }
 
-func (c *Connector) retrieveTeams() (*teams, error) {
+func (c *Connector) retrieveTeams(ctx context.Context) (*teams, error) {
 
 	url := c.getURL(urlTeams)
 
-	statusCode, status, body, err := c.request("GET", url, nil)
+	statusCode, status, body, err := c.request(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1497,11 +1837,11 @@ func (c *Connector) retrieveTeams() (*teams, error) {
 	return teams, nil
 }
 
-func (c *Connector) getCertificates(certificateId string) (*VenafiCertificate, error) {
+func (c *Connector) getCertificates(ctx context.Context, certificateId string) (*VenafiCertificate, error) {
 	url := c.getURL(urlCertificateDetails)
 	url = fmt.Sprintf(url, certificateId)
 
-	statusCode, status, body, err := c.request("GET", url, nil)
+	statusCode, status, body, err := c.request(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1512,7 +1852,7 @@ func (c *Connector) getCertificates(certificateId string) (*VenafiCertificate, e
 	return cert, nil
 }
 
-func getAccounts(caName string, c *Connector) (*policy.Accounts, *policy.CertificateAuthorityInfo, error) {
+func getAccounts(ctx context.Context, caName string, c *Connector) (*policy.Accounts, *policy.CertificateAuthorityInfo, error) {
 	info, err := policy.GetCertAuthorityInfo(caName)
 	if err != nil {
 		return nil, nil, err
@@ -1521,7 +1861,7 @@ func getAccounts(caName string, c *Connector) (*policy.Accounts, *policy.Certifi
 	caType := netUrl.PathEscape(info.CAType)
 	url := c.getURL(urlCAAccounts)
 	url = fmt.Sprintf(url, caType)
-	_, _, body, err := c.request("GET", url, nil)
+	_, _, body, err := c.request(ctx, "GET", url, nil)
 
 	if err != nil {
 		return nil, nil, err
@@ -1538,9 +1878,9 @@ func getAccounts(caName string, c *Connector) (*policy.Accounts, *policy.Certifi
 	return &accounts, &info, nil
 }
 
-func getCertificateAuthorityDetails(caName string, c *Connector) (*policy.CADetails, error) {
+func getCertificateAuthorityDetails(ctx context.Context, caName string, c *Connector) (*policy.CADetails, error) {
 
-	accounts, info, err := getAccounts(caName, c)
+	accounts, info, err := getAccounts(ctx, caName, c)
 	if err != nil {
 		return nil, err
 	}
@@ -1564,12 +1904,12 @@ func getCertificateAuthorityDetails(caName string, c *Connector) (*policy.CADeta
 	return nil, fmt.Errorf("specified CA doesn't exist")
 }
 
-func getCertificateAuthorityInfoFromCloud(caName, caAccountId, caProductOptionId string, c *Connector) (*policy.CertificateAuthorityInfo, error) {
+func getCertificateAuthorityInfoFromCloud(ctx context.Context, caName, caAccountId, caProductOptionId string, c *Connector) (*policy.CertificateAuthorityInfo, error) {
 
 	caName = netUrl.PathEscape(caName)
 	url := c.getURL(urlCAAccountDetails)
 	url = fmt.Sprintf(url, caName, caAccountId)
-	_, _, body, err := c.request("GET", url, nil)
+	_, _, body, err := c.request(ctx, "GET", url, nil)
 
 	if err != nil {
 		return nil, err
@@ -1,6 +1,7 @@
 package cloud
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
@@ -9,16 +10,21 @@ import (
 )
 
 func (c *Connector) GetPolicy(name string) (*policy.PolicySpecification, error) {
+	return c.GetPolicyContext(context.Background(), name)
+}
+
+// GetPolicyContext is GetPolicy with a context that cancels its requests to the platform
+func (c *Connector) GetPolicyContext(ctx context.Context, name string) (*policy.PolicySpecification, error) {
 	if !c.isAuthenticated() {
 		return nil, fmt.Errorf("must be autheticated to request a certificate")
 	}
 
-	cit, err := retrievePolicySpecification(c, name)
+	cit, err := retrievePolicySpecification(ctx, c, name)
 	if err != nil {
 		return nil, err
 	}
 
-	info, err := getCertificateAuthorityInfoFromCloud(cit.CertificateAuthority, cit.CertificateAuthorityAccountId, cit.CertificateAuthorityProductOptionId, c)
+	info, err := getCertificateAuthorityInfoFromCloud(ctx, cit.CertificateAuthority, cit.CertificateAuthorityAccountId, cit.CertificateAuthorityProductOptionId, c)
 
 	if err != nil {
 		return nil, err
@@ -28,7 +34,7 @@ func (c *Connector) GetPolicy(name string) (*policy.PolicySpecification, error)
 	ps := buildPolicySpecification(cit, info, true)
 
 	// getting the users to set to the PolicySpecification
-	policyUsers, err := c.getUsers()
+	policyUsers, err := c.getUsers(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -38,6 +44,11 @@ func (c *Connector) GetPolicy(name string) (*policy.PolicySpecification, error)
 }
 
 func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (string, error) {
+	return c.SetPolicyContext(context.Background(), name, ps)
+}
+
+// SetPolicyContext is SetPolicy with a context that cancels its requests to the platform
+func (c *Connector) SetPolicyContext(ctx context.Context, name string, ps *policy.PolicySpecification) (string, error) {
 	if !c.isAuthenticated() {
 		return "", fmt.Errorf("must be autheticated to request a certificate")
 	}
@@ -62,7 +73,7 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 	var caDetails *policy.CADetails
 
 	if ps.Policy != nil && ps.Policy.CertificateAuthority != nil && *(ps.Policy.CertificateAuthority) != "" {
-		caDetails, err = getCertificateAuthorityDetails(*(ps.Policy.CertificateAuthority), c)
+		caDetails, err = getCertificateAuthorityDetails(ctx, *(ps.Policy.CertificateAuthority), c)
 
 		if err != nil {
 			return "", err
@@ -74,14 +85,14 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 			defaultCA := policy.DefaultCA
 			ps.Policy.CertificateAuthority = &defaultCA
 
-			caDetails, err = getCertificateAuthorityDetails(*(ps.Policy.CertificateAuthority), c)
+			caDetails, err = getCertificateAuthorityDetails(ctx, *(ps.Policy.CertificateAuthority), c)
 			if err != nil {
 				return "", err
 			}
 
 		} else {
 			//policy is not specified so we get the default CA
-			caDetails, err = getCertificateAuthorityDetails(policy.DefaultCA, c)
+			caDetails, err = getCertificateAuthorityDetails(ctx, policy.DefaultCA, c)
 			if err != nil {
 				return "", err
 			}
@@ -98,7 +109,7 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 
 	url := c.getURL(urlIssuingTemplate)
 
-	cit, err := getCit(c, citName)
+	cit, err := getCit(ctx, c, citName)
 
 	if err != nil {
 		return "", err
@@ -108,7 +119,7 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 		log.Printf("updating issuing template: %s", citName)
 		//update cit using the new values
 		url = fmt.Sprint(url, "/", cit.ID)
-		statusCode, status, body, err := c.request("PUT", url, req)
+		statusCode, status, body, err := c.request(ctx, "PUT", url, req)
 
 		if err != nil {
 			return "", err
@@ -123,7 +134,7 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 	} else {
 		log.Printf("creating issuing template: %s", citName)
 		//var body []byte
-		statusCode, status, body, err := c.request("POST", url, req)
+		statusCode, status, body, err := c.request(ctx, "POST", url, req)
 
 		if err != nil {
 			return "", err
@@ -145,19 +156,19 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 		return "", fmt.Errorf("application name is empty, please provide zone in the format: app_name\\cit_name")
 	}
 
-	appDetails, statusCode, err := c.getAppDetailsByName(appName)
+	appDetails, statusCode, err := c.getAppDetailsByName(ctx, appName)
 
 	if err != nil && statusCode == 404 { //means application was not found.
 		log.Printf("creating application: %s", appName)
 
-		_, err = c.createApplication(appName, ps, cit)
+		_, err = c.createApplication(ctx, appName, ps, cit)
 		if err != nil {
 			return "", err
 		}
 
 	} else { //determine if the application needs to be updated
 		log.Printf("updating application: %s", appName)
-		err = c.updateApplication(name, ps, cit, appDetails)
+		err = c.updateApplication(ctx, name, ps, cit, appDetails)
 		if err != nil {
 			return "", err
 		}
@@ -169,14 +180,19 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 }
 
 func (c *Connector) GetPolicyWithRegex(name string) (*policy.PolicySpecification, error) {
+	return c.GetPolicyWithRegexContext(context.Background(), name)
+}
+
+// GetPolicyWithRegexContext is GetPolicyWithRegex with a context that cancels its requests to the platform
+func (c *Connector) GetPolicyWithRegexContext(ctx context.Context, name string) (*policy.PolicySpecification, error) {
 
-	cit, err := retrievePolicySpecification(c, name)
+	cit, err := retrievePolicySpecification(ctx, c, name)
 
 	if err != nil {
 		return nil, err
 	}
 
-	info, err := getCertificateAuthorityInfoFromCloud(cit.CertificateAuthority, cit.CertificateAuthorityAccountId, cit.CertificateAuthorityProductOptionId, c)
+	info, err := getCertificateAuthorityInfoFromCloud(ctx, cit.CertificateAuthority, cit.CertificateAuthorityAccountId, cit.CertificateAuthorityProductOptionId, c)
 
 	if err != nil {
 		return nil, err
@@ -188,7 +204,7 @@ func (c *Connector) GetPolicyWithRegex(name string) (*policy.PolicySpecification
 	return ps, nil
 }
 
-func retrievePolicySpecification(c *Connector, name string) (*certificateTemplate, error) {
+func retrievePolicySpecification(ctx context.Context, c *Connector, name string) (*certificateTemplate, error) {
 	appName := policy.GetApplicationName(name)
 	if appName != "" {
 		c.zone.appName = appName
@@ -203,7 +219,7 @@ func retrievePolicySpecification(c *Connector, name string) (*certificateTemplat
 	}
 
 	log.Println("Getting CIT")
-	cit, err := c.getTemplateByID()
+	cit, err := c.getTemplateByID(ctx)
 
 	if err != nil {
 		return nil, err
@@ -213,23 +229,23 @@ func retrievePolicySpecification(c *Connector, name string) (*certificateTemplat
 
 }
 
-func (c *Connector) getUsers() ([]string, error) {
+func (c *Connector) getUsers(ctx context.Context) ([]string, error) {
 	var usersList []string
-	appDetails, _, err := c.getAppDetailsByName(c.zone.getApplicationName())
+	appDetails, _, err := c.getAppDetailsByName(ctx, c.zone.getApplicationName())
 	if err != nil {
 		return nil, err
 	}
 	var teamsList *teams
 	for _, owner := range appDetails.OwnerIdType {
 		if owner.OwnerType == UserType.String() {
-			retrievedUser, userErr := c.retrieveUser(owner.OwnerId)
+			retrievedUser, userErr := c.retrieveUser(ctx, owner.OwnerId)
 			if userErr != nil {
 				return nil, userErr
 			}
 			usersList = append(usersList, retrievedUser.Username)
 		} else if owner.OwnerType == TeamType.String() {
 			if teamsList == nil {
-				teamsList, err = c.retrieveTeams()
+				teamsList, err = c.retrieveTeams(ctx)
 				if err != nil {
 					return nil, err
 				}
@@ -249,6 +265,11 @@ func (c *Connector) getUsers() ([]string, error) {
 }
 
 func PolicyExist(policyName string, c *Connector) (bool, error) {
+	return PolicyExistContext(context.Background(), policyName, c)
+}
+
+// PolicyExistContext is PolicyExist with a context that cancels its requests to the platform
+func PolicyExistContext(ctx context.Context, policyName string, c *Connector) (bool, error) {
 	c.zone.appName = policy.GetApplicationName(policyName)
 	citName := policy.GetCitName(policyName)
 	if citName != "" {
@@ -257,11 +278,11 @@ func PolicyExist(policyName string, c *Connector) (bool, error) {
 		return false, fmt.Errorf("cit name is not valid, please provide a valid zone name in the format: appName\\CitName")
 	}
 
-	_, err := c.getTemplateByID()
+	_, err := c.getTemplateByID(ctx)
 	return err == nil, nil
 }
 
-func (c *Connector) createApplication(appName string, ps *policy.PolicySpecification, cit *certificateTemplate) (*policy.Application, error) {
+func (c *Connector) createApplication(ctx context.Context, appName string, ps *policy.PolicySpecification, cit *certificateTemplate) (*policy.Application, error) {
 	appIssuingTemplate := make(map[string]string)
 	appIssuingTemplate[cit.Name] = cit.ID
 
@@ -272,10 +293,10 @@ func (c *Connector) createApplication(appName string, ps *policy.PolicySpecifica
 
 	//if users are passed to the PS, resolve the related Owners to set them
 	if len(ps.Users) > 0 {
-		owners, err = c.resolveOwners(ps.Users)
+		owners, err = c.resolveOwners(ctx, ps.Users)
 	} else { //if users are not specified in PS, then the current User should be used as owner
 		var owner *policy.OwnerIdType
-		owner, err = c.getOwnerFromUserDetails()
+		owner, err = c.getOwnerFromUserDetails(ctx)
 		if owner != nil {
 			owners = []policy.OwnerIdType{*owner}
 		}
@@ -294,7 +315,7 @@ func (c *Connector) createApplication(appName string, ps *policy.PolicySpecifica
 
 	url := c.getURL(urlAppRoot)
 
-	statusCode, status, _, err = c.request("POST", url, appReq)
+	statusCode, status, _, err = c.request(ctx, "POST", url, appReq)
 	if err != nil {
 		return nil, err
 	}
@@ -305,14 +326,14 @@ func (c *Connector) createApplication(appName string, ps *policy.PolicySpecifica
 	return &appReq, nil
 }
 
-func (c *Connector) updateApplication(name string, ps *policy.PolicySpecification, cit *certificateTemplate, appDetails *ApplicationDetails) error {
+func (c *Connector) updateApplication(ctx context.Context, name string, ps *policy.PolicySpecification, cit *certificateTemplate, appDetails *ApplicationDetails) error {
 
 	//creating the app to use as request
 	appReq := createAppUpdateRequest(appDetails)
 
 	//determining if the relationship between application and cit exist
 	citAddedToApp := false
-	exist, err := PolicyExist(name, c)
+	exist, err := PolicyExistContext(ctx, name, c)
 	if err != nil {
 		return err
 	}
@@ -327,7 +348,7 @@ func (c *Connector) updateApplication(name string, ps *policy.PolicySpecificatio
 	//is that users in the policy specification were provided
 	if len(ps.Users) > 0 {
 		//resolving and setting owners
-		owners, err := c.resolveOwners(ps.Users)
+		owners, err := c.resolveOwners(ctx, ps.Users)
 		if err != nil {
 			return fmt.Errorf("an error happened trying to resolve the owners: %w", err)
 		}
@@ -340,7 +361,7 @@ func (c *Connector) updateApplication(name string, ps *policy.PolicySpecificatio
 	if citAddedToApp || ownersUpdated {
 		url := c.getURL(urlAppRoot)
 		url = fmt.Sprint(url, "/", appDetails.ApplicationId)
-		_, _, _, err = c.request("PUT", url, appReq)
+		_, _, _, err = c.request(ctx, "PUT", url, appReq)
 		if err != nil {
 			return err
 		}
@@ -357,7 +378,7 @@ func (c *Connector) addCitToApp(app *policy.Application, cit *certificateTemplat
 	}
 }
 
-func (c *Connector) resolveOwners(usersList []string) ([]policy.OwnerIdType, error) {
+func (c *Connector) resolveOwners(ctx context.Context, usersList []string) ([]policy.OwnerIdType, error) {
 
 	var owners []policy.OwnerIdType
 	var teams *teams
@@ -365,13 +386,13 @@ func (c *Connector) resolveOwners(usersList []string) ([]policy.OwnerIdType, err
 
 	for _, userName := range usersList {
 		//The error should be ignored in order to confirm if the userName is not a TeamName
-		users, _ := c.retrieveUsers(userName)
+		users, _ := c.retrieveUsers(ctx, userName)
 
 		if users != nil {
 			owners = appendOwner(owners, users.Users[0].ID, UserType)
 		} else {
 			if teams == nil {
-				teams, err = c.retrieveTeams()
+				teams, err = c.retrieveTeams(ctx)
 			}
 			if err != nil {
 				return nil, err
@@ -400,8 +421,8 @@ func appendOwner(owners []policy.OwnerIdType, ownerId string, ownerType OwnerTyp
 	return append(owners, *owner)
 }
 
-func (c *Connector) getOwnerFromUserDetails() (*policy.OwnerIdType, error) {
-	userDetails, err := c.getUserDetails()
+func (c *Connector) getOwnerFromUserDetails(ctx context.Context) (*policy.OwnerIdType, error) {
+	userDetails, err := c.getUserDetails(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -1,19 +1,43 @@
 package cloud
 
-import "github.com/Venafi/vcert/v5/pkg/certificate"
+import (
+	"context"
 
-func (c *Connector) RetrieveSshConfig(_ *certificate.SshCaTemplateRequest) (*certificate.SshConfig, error) {
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+)
+
+func (c *Connector) RetrieveSshConfig(ca *certificate.SshCaTemplateRequest) (*certificate.SshConfig, error) {
+	return c.RetrieveSshConfigContext(context.Background(), ca)
+}
+
+// RetrieveSshConfigContext is RetrieveSshConfig with a context that cancels its requests to the platform
+func (c *Connector) RetrieveSshConfigContext(_ context.Context, _ *certificate.SshCaTemplateRequest) (*certificate.SshConfig, error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) RetrieveSSHCertificate(_ *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
+func (c *Connector) RetrieveSSHCertificate(req *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
+	return c.RetrieveSSHCertificateContext(context.Background(), req)
+}
+
+// RetrieveSSHCertificateContext is RetrieveSSHCertificate with a context that cancels its requests to the platform
+func (c *Connector) RetrieveSSHCertificateContext(_ context.Context, _ *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) RequestSSHCertificate(_ *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
+func (c *Connector) RequestSSHCertificate(req *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
+	return c.RequestSSHCertificateContext(context.Background(), req)
+}
+
+// RequestSSHCertificateContext is RequestSSHCertificate with a context that cancels its requests to the platform
+func (c *Connector) RequestSSHCertificateContext(_ context.Context, _ *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
 	panic("operation is not supported yet")
 }
 
 func (c *Connector) RetrieveAvailableSSHTemplates() (response []certificate.SshAvaliableTemplate, err error) {
+	return c.RetrieveAvailableSSHTemplatesContext(context.Background())
+}
+
+// RetrieveAvailableSSHTemplatesContext is RetrieveAvailableSSHTemplates with a context that cancels its requests to the platform
+func (c *Connector) RetrieveAvailableSSHTemplatesContext(ctx context.Context) (response []certificate.SshAvaliableTemplate, err error) {
 	panic("operation is not supported yet")
 }
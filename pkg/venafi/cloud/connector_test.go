@@ -17,6 +17,7 @@
 package cloud
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha1"
@@ -560,13 +561,13 @@ func TestGetCertificateStatus(t *testing.T) {
 		t.Fatalf("%s", err)
 	}
 
-	_, err = conn.getCertificateStatus(reqId)
+	_, err = conn.getCertificateStatus(context.Background(), reqId)
 	if err != nil {
 		t.Fatalf("failed to get certificate request status: %s", err)
 	}
 
 	invalidCertificateRequestId := "42424242-63a0-11e8-b5a3-f186be5c5fab"
-	_, err = conn.getCertificateStatus(invalidCertificateRequestId)
+	_, err = conn.getCertificateStatus(context.Background(), invalidCertificateRequestId)
 	if err == nil {
 		t.Fatalf("it should return error when there is not such request found")
 	}
@@ -1132,7 +1133,7 @@ func TestSearchCertificate(t *testing.T) {
 	}
 	p, _ := pem.Decode([]byte(cert.Certificate))
 	thumbprint := certThumbprint(p.Bytes)
-	_, err = conn.searchCertificatesByFingerprint(thumbprint)
+	_, err = conn.searchCertificatesByFingerprint(context.Background(), thumbprint)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1176,7 +1177,7 @@ func TestSetPolicy(t *testing.T) {
 	}
 
 	//validate each attribute
-	userDetails, err := conn.getUserDetails()
+	userDetails, err := conn.getUserDetails(context.Background())
 	//validating the default users attribute was created
 	users := []string{
 		//"jenkins@opensource.qa.venafi.io",
@@ -2212,7 +2213,7 @@ func TestGetDefaultCsrAttributes(t *testing.T) {
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
-	attributes, err := getCsrAttributes(conn, request)
+	attributes, err := getCsrAttributes(context.Background(), conn, request)
 
 	if err != nil {
 		t.Fatalf("%s", err)
@@ -2234,7 +2235,7 @@ func TestGetCsrAttributes(t *testing.T) {
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
-	attributes, err := getCsrAttributes(conn, &req)
+	attributes, err := getCsrAttributes(context.Background(), conn, &req)
 
 	if err != nil {
 		t.Fatalf("%s", err)
@@ -2268,7 +2269,7 @@ func TestCertificateSanTypes(t *testing.T) {
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
-	attributes, err := getCsrAttributes(conn, &req)
+	attributes, err := getCsrAttributes(context.Background(), conn, &req)
 
 	if err != nil {
 		t.Fatalf("%s", err)
@@ -23,10 +23,13 @@ import (
 	"log"
 	"math"
 	"net/http"
+	netUrl "net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Venafi/vcert/v5/pkg/certificate"
+	"github.com/Venafi/vcert/v5/pkg/verror"
 )
 
 type SearchRequest struct {
@@ -69,6 +72,8 @@ const (
 	AND   Operator = "AND"
 )
 
+const defaultSearchPageSize = 100
+
 type CertificateSearchResponse struct {
 	Count        int           `json:"count"`
 	Certificates []Certificate `json:"certificates"`
@@ -80,6 +85,7 @@ type Certificate struct {
 	CertificateRequestId          string              `json:"certificateRequestId"`
 	SubjectCN                     []string            `json:"subjectCN"`
 	SubjectAlternativeNamesByType map[string][]string `json:"subjectAlternativeNamesByType"`
+	IssuerCN                      []string            `json:"issuerCN"`
 	SerialNumber                  string              `json:"serialNumber"`
 	Fingerprint                   string              `json:"fingerprint"`
 	ValidityStart                 string              `json:"validityStart"`
@@ -89,10 +95,13 @@ type Certificate struct {
 }
 
 func (c Certificate) ToCertificateInfo() certificate.CertificateInfo {
-	var cn string
+	var cn, issuer string
 	if len(c.SubjectCN) > 0 {
 		cn = c.SubjectCN[0]
 	}
+	if len(c.IssuerCN) > 0 {
+		issuer = c.IssuerCN[0]
+	}
 
 	start, err := time.Parse(time.RFC3339, c.ValidityStart)
 	if err != nil { //we just print the error, and let the user know.
@@ -115,6 +124,7 @@ func (c Certificate) ToCertificateInfo() certificate.CertificateInfo {
 			// currently not supported on VaaS
 			// UPN: cert.SubjectAlternativeNamesByType["x400Address"],
 		},
+		Issuer:     issuer,
 		Serial:     c.SerialNumber,
 		Thumbprint: c.Fingerprint,
 		ValidFrom:  start,
@@ -212,3 +222,111 @@ func addOperand(req *SearchRequest, o Operand) *SearchRequest {
 	req.Expression.Operands = append(req.Expression.Operands, o)
 	return req
 }
+
+// buildSearchRequest translates a generic certificate.SearchRequest, which is a list of
+// "<attribute>=<value>" entries as accepted by the TPP certificate search, into the
+// Venafi Cloud certificate search DSL. The application name found in the "ParentDn" or
+// "Zone" entries is returned apart, as it must be resolved to an application ID
+//
+// example:
+// []string{"CN=example.com", "ValidToLess=2024-06-01T00:00:00Z", "Limit=10"}
+// -> {"expression":{"operator":"AND","operands":[{"field":"subjectCN","operator":"EQ","value":"example.com"},
+// {"field":"validityEnd","operator":"LT","value":"2024-06-01T00:00:00Z"}]},"paging":{"pageNumber":0,"pageSize":10}}
+func buildSearchRequest(req *certificate.SearchRequest) (searchReq *SearchRequest, appName string, err error) {
+	searchReq = &SearchRequest{
+		Expression: &Expression{
+			Operator: AND,
+			Operands: []Operand{},
+		},
+	}
+	if req == nil {
+		return searchReq, "", nil
+	}
+
+	limit, offset := 0, 0
+	for _, entry := range *req {
+		key, value, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, "", fmt.Errorf("%w: invalid search attribute %q. Expected format is <attribute>=<value>", verror.UserDataError, entry)
+		}
+		if unescaped, err := netUrl.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+
+		switch strings.ToLower(key) {
+		case "thumbprint":
+			fp := strings.ToUpper(strings.NewReplacer(":", "", ".", "").Replace(value))
+			addOperand(searchReq, Operand{Field: "fingerprint", Operator: MATCH, Value: fp})
+		case "serial":
+			addOperand(searchReq, Operand{Field: "serialNumber", Operator: MATCH, Value: strings.ToUpper(value)})
+		case "cn":
+			addOperand(searchReq, Operand{Field: "subjectCN", Operator: EQ, Value: value})
+		case "issuer":
+			addOperand(searchReq, Operand{Field: "issuerCN", Operator: FIND, Value: value})
+		case "san-dns":
+			addOperand(searchReq, Operand{Field: "subjectAlternativeNameDns", Operator: IN, Values: []string{value}})
+		case "san-email":
+			addOperand(searchReq, Operand{Field: "subjectAlternativeNameRfc822Name", Operator: IN, Values: []string{value}})
+		case "san-ip":
+			addOperand(searchReq, Operand{Field: "subjectAlternativeNameIpAddress", Operator: IN, Values: []string{value}})
+		case "san-uri":
+			addOperand(searchReq, Operand{Field: "subjectAlternativeNameUri", Operator: IN, Values: []string{value}})
+		case "keysize":
+			size, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, "", fmt.Errorf("%w: invalid value for search attribute %s: %s", verror.UserDataError, key, err)
+			}
+			addOperand(searchReq, Operand{Field: "keyStrength", Operator: EQ, Value: size})
+		case "validtoless", "validtogreater", "validfromless", "validfromgreater":
+			t, err := parseSearchDate(value)
+			if err != nil {
+				return nil, "", fmt.Errorf("%w: invalid value for search attribute %s: %s", verror.UserDataError, key, err)
+			}
+			field := Field("validityEnd")
+			if strings.HasPrefix(strings.ToLower(key), "validfrom") {
+				field = "validityStart"
+			}
+			operator := LT
+			if strings.HasSuffix(strings.ToLower(key), "greater") {
+				operator = GT
+			}
+			addOperand(searchReq, Operand{Field: field, Operator: operator, Value: t.UTC().Format(time.RFC3339)})
+		case "parentdn", "parentdnrecursive", "zone":
+			appName = getAppNameFromZone(value)
+		case "limit":
+			limit, err = strconv.Atoi(value)
+			if err != nil || limit < 0 {
+				return nil, "", fmt.Errorf("%w: invalid value for search attribute %s: %s", verror.UserDataError, key, value)
+			}
+		case "offset":
+			offset, err = strconv.Atoi(value)
+			if err != nil || offset < 0 {
+				return nil, "", fmt.Errorf("%w: invalid value for search attribute %s: %s", verror.UserDataError, key, value)
+			}
+		default:
+			return nil, "", fmt.Errorf("%w: search attribute %s is not supported by Venafi Cloud", verror.UserDataError, key)
+		}
+	}
+
+	if limit > 0 {
+		// Venafi Cloud pages by number, so the offset must fall on the first certificate of a page
+		if offset%limit != 0 {
+			return nil, "", fmt.Errorf("%w: search attribute Offset must be a multiple of Limit, got Offset=%d and Limit=%d", verror.UserDataError, offset, limit)
+		}
+		searchReq.Paging = &Paging{PageNumber: offset / limit, PageSize: limit}
+	} else if offset > 0 {
+		return nil, "", fmt.Errorf("%w: search attribute Offset requires Limit to be set", verror.UserDataError)
+	}
+
+	return searchReq, appName, nil
+}
+
+func parseSearchDate(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not an ISO 8601 date", value)
+}
@@ -18,11 +18,13 @@ package cloud
 
 import (
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/Venafi/vcert/v5/pkg/certificate"
 	"github.com/Venafi/vcert/v5/pkg/util"
+	"github.com/Venafi/vcert/v5/pkg/verror"
 )
 
 func TestSearchRequest(t *testing.T) {
@@ -470,3 +472,122 @@ func TestFormatSearchCertificateArguments(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildSearchRequest(t *testing.T) {
+	testCases := []struct {
+		name            string
+		input           certificate.SearchRequest
+		expected        *SearchRequest
+		expectedAppName string
+		expectedErr     bool
+	}{
+		{
+			name:  "Empty",
+			input: certificate.SearchRequest{},
+			expected: &SearchRequest{
+				Expression: &Expression{
+					Operator: AND,
+					Operands: []Operand{},
+				},
+			},
+		},
+		{
+			name:  "Thumbprint",
+			input: certificate.SearchRequest{"Thumbprint=a7:bd:ec:da:0b:67:d5:ce:f2:8d:6c:8c:7d:7c:fa:88:2e:3d:c9:d6"},
+			expected: &SearchRequest{
+				Expression: &Expression{
+					Operator: AND,
+					Operands: []Operand{
+						{
+							Field:    "fingerprint",
+							Operator: MATCH,
+							Value:    "A7BDECDA0B67D5CEF28D6C8C7D7CFA882E3DC9D6",
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "CN ValidTo Zone Paging",
+			input: certificate.SearchRequest{"CN=one.example.com", "ValidToLess=2024-06-01", "ParentDn=My App\\My CIT", "Limit=10", "Offset=20"},
+			expected: &SearchRequest{
+				Expression: &Expression{
+					Operator: AND,
+					Operands: []Operand{
+						{
+							Field:    "subjectCN",
+							Operator: EQ,
+							Value:    "one.example.com",
+						},
+						{
+							Field:    "validityEnd",
+							Operator: LT,
+							Value:    "2024-06-01T00:00:00Z",
+						},
+					},
+				},
+				Paging: &Paging{PageNumber: 2, PageSize: 10},
+			},
+			expectedAppName: "My App",
+		},
+		{
+			name:  "SAN DNS",
+			input: certificate.SearchRequest{"SAN-DNS=one.example.com"},
+			expected: &SearchRequest{
+				Expression: &Expression{
+					Operator: AND,
+					Operands: []Operand{
+						{
+							Field:    "subjectAlternativeNameDns",
+							Operator: IN,
+							Values:   []string{"one.example.com"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:        "Unsupported attribute",
+			input:       certificate.SearchRequest{"Stage=500"},
+			expectedErr: true,
+		},
+		{
+			name:        "Malformed attribute",
+			input:       certificate.SearchRequest{"CN"},
+			expectedErr: true,
+		},
+		{
+			name:        "Offset without limit",
+			input:       certificate.SearchRequest{"Offset=10"},
+			expectedErr: true,
+		},
+		{
+			name:        "Offset not a multiple of limit",
+			input:       certificate.SearchRequest{"Limit=10", "Offset=15"},
+			expectedErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			req, appName, err := buildSearchRequest(&testCase.input)
+			if testCase.expectedErr {
+				if !errors.Is(err, verror.UserDataError) {
+					t.Fatalf("expected a user data error but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if appName != testCase.expectedAppName {
+				t.Errorf("unexpected application name\nExpected: %s\nGot: %s", testCase.expectedAppName, appName)
+			}
+			expected := util.GetJsonAsString(testCase.expected)
+			request := util.GetJsonAsString(req)
+			if expected != request {
+				t.Errorf("unmatched search request\nExpected:\n%v\nGot:\n%v", expected, request)
+			}
+		})
+	}
+}
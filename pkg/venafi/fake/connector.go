@@ -29,14 +29,21 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/youmark/pkcs8"
+
 	"github.com/Venafi/vcert/v5/pkg/certificate"
 	"github.com/Venafi/vcert/v5/pkg/domain"
 	"github.com/Venafi/vcert/v5/pkg/endpoint"
 	"github.com/Venafi/vcert/v5/pkg/policy"
+	"github.com/Venafi/vcert/v5/pkg/util"
+	"github.com/Venafi/vcert/v5/pkg/verror"
 )
 
 type Connector struct {
-	verbose bool
+	verbose   bool
+	zone      string
+	inventory *inventory
 }
 
 func (c *Connector) ProvisionCertificate(_ *domain.ProvisioningRequest, _ *domain.ProvisioningOptions) (*domain.ProvisioningMetadata, error) {
@@ -47,31 +54,73 @@ func (c *Connector) RetrieveCertificateMetaData(dn string) (*certificate.Certifi
 	panic("operation is not supported yet")
 }
 
+// SearchCertificates returns the certificates of the inventory matching all the "<attribute>=<value>" entries of
+// req. The attributes are the same accepted by the TPP certificate search
 func (c *Connector) SearchCertificates(req *certificate.SearchRequest) (*certificate.CertSearchResponse, error) {
-	panic("operation is not supported yet")
-}
-
-func (c *Connector) SearchCertificate(zone string, cn string, sans *certificate.Sans, certMinTimeLeft time.Duration) (certificateInfo *certificate.CertificateInfo, err error) {
-	panic("operation is not supported yet")
-}
-
-func (c *Connector) IsCSRServiceGenerated(req *certificate.Request) (bool, error) {
-	panic("operation is not supported yet")
-}
+	filter, err := parseSearchRequest(req)
+	if err != nil {
+		return nil, err
+	}
 
-func (c *Connector) RetrieveSshConfig(ca *certificate.SshCaTemplateRequest) (*certificate.SshConfig, error) {
-	panic("operation is not supported yet")
+	resp := &certificate.CertSearchResponse{Certificates: []certificate.CertSeachInfo{}}
+	err = c.inventory.view(func() error {
+		for _, rec := range c.inventory.sorted() {
+			if !filter.matches(rec) {
+				continue
+			}
+			resp.Count++
+			if resp.Count <= filter.offset || (filter.limit > 0 && len(resp.Certificates) >= filter.limit) {
+				continue
+			}
+			resp.Certificates = append(resp.Certificates, certificate.CertSeachInfo{
+				CertificateRequestId:   rec.DN,
+				CertificateRequestGuid: rec.Guid,
+				X509:                   rec.toCertificateInfo(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
 
-func (c *Connector) RetrieveSSHCertificate(req *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
-	panic("operation is not supported yet")
-}
+// SearchCertificate returns the newest active certificate of zone that matches cn and sans and is valid for
+// at least certMinTimeLeft
+func (c *Connector) SearchCertificate(zone string, cn string, sans *certificate.Sans, certMinTimeLeft time.Duration) (certificateInfo *certificate.CertificateInfo, err error) {
+	policyDN := getPolicyDN(zone)
+	minValidTo := time.Now().Add(certMinTimeLeft)
+	certificates := make([]*certificate.CertificateInfo, 0)
+	found := false
+	err = c.inventory.view(func() error {
+		for _, rec := range c.inventory.sorted() {
+			if rec.Status != certificateStatusActive || (cn != "" && rec.CommonName != cn) || rec.ValidTo.Before(minValidTo) {
+				continue
+			}
+			found = true
+			if rec.Zone != policyDN {
+				continue
+			}
+			info := rec.toCertificateInfo()
+			certificates = append(certificates, &info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-func (c *Connector) RequestSSHCertificate(req *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
-	panic("operation is not supported yet")
+	if !found {
+		return nil, verror.NoCertificateFoundError
+	}
+	if len(certificates) == 0 {
+		return nil, verror.NoCertificateWithMatchingZoneFoundError
+	}
+	return certificate.FindNewestCertificateWithSans(certificates, sans)
 }
 
-func (c *Connector) RetrieveAvailableSSHTemplates() (response []certificate.SshAvaliableTemplate, err error) {
+func (c *Connector) IsCSRServiceGenerated(req *certificate.Request) (bool, error) {
 	panic("operation is not supported yet")
 }
 
@@ -154,15 +203,28 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 }
 
 func NewConnector(verbose bool, trust *x509.CertPool) *Connector {
-	c := Connector{verbose: verbose}
+	inv, _ := newInventory("")
+	c := Connector{verbose: verbose, inventory: inv}
 	return &c
 }
 
+// NewConnectorWithInventory creates a fake connector whose inventory of certificates is persisted to the JSON file
+// at path, so that it can be shared between several runs or processes
+func NewConnectorWithInventory(verbose bool, trust *x509.CertPool, path string) (*Connector, error) {
+	inv, err := newInventory(path)
+	if err != nil {
+		return nil, err
+	}
+	c := Connector{verbose: verbose, inventory: inv}
+	return &c, nil
+}
+
 func (c *Connector) GetType() endpoint.ConnectorType {
 	return endpoint.ConnectorTypeFake
 }
 
 func (c *Connector) SetZone(z string) {
+	c.zone = z
 }
 
 func (c *Connector) SetUserAgent(_ string) {
@@ -179,6 +241,9 @@ func (c *Connector) Authenticate(auth *endpoint.Authentication) (err error) {
 type fakeRequestID struct {
 	Req *certificate.Request
 	CSR string
+	DN  string `json:",omitempty"`
+	// ID makes every request unique, so that a renewal reusing the CSR gets a new pickup ID
+	ID string `json:",omitempty"`
 }
 
 func validateRequest(req *certificate.Request) error {
@@ -194,7 +259,11 @@ func (c *Connector) RequestCertificate(req *certificate.Request) (requestID stri
 		return "", fmt.Errorf("certificate request validation fail: %s", err)
 	}
 
-	var fakeRequest = fakeRequestID{}
+	name := req.FriendlyName
+	if name == "" {
+		name = req.Subject.CommonName
+	}
+	var fakeRequest = fakeRequestID{DN: getPolicyDN(c.zone) + "\\" + name}
 
 	switch req.CsrOrigin {
 	case certificate.LocalGeneratedCSR, certificate.UserProvidedCSR:
@@ -209,15 +278,23 @@ func (c *Connector) RequestCertificate(req *certificate.Request) (requestID stri
 		return "", fmt.Errorf("Unexpected option in PrivateKeyOrigin")
 	}
 
-	js, err := json.Marshal(fakeRequest)
+	pickupID, err := encodeRequestID(fakeRequest)
 	if err != nil {
 		return "", fmt.Errorf("failed to json.Marshal(certificate.Request: %v)", req)
 	}
-	pickupID := base64.StdEncoding.EncodeToString(js)
 	req.PickupID = pickupID
 	return pickupID, nil
 }
 
+func encodeRequestID(fakeRequest fakeRequestID) (string, error) {
+	fakeRequest.ID = uuid.New().String()
+	js, err := json.Marshal(fakeRequest)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(js), nil
+}
+
 // SynchronousRequestCertificate It's not supported yet
 func (c *Connector) SynchronousRequestCertificate(_ *certificate.Request) (certificates *certificate.PEMCollection, err error) {
 	panic("operation is not supported yet")
@@ -271,6 +348,23 @@ func issueCertificate(csr *x509.CertificateRequest) ([]byte, error) {
 
 func (c *Connector) RetrieveCertificate(req *certificate.Request) (pcc *certificate.PEMCollection, err error) {
 
+	// certificates already issued are returned as they are stored in the inventory
+	var rec *certificateRecord
+	err = c.inventory.view(func() (err error) {
+		if req.PickupID != "" {
+			rec = c.inventory.findByPickupID(req.PickupID)
+		} else if req.CertID != "" || req.Thumbprint != "" {
+			rec, err = c.inventory.find(req.CertID, req.Thumbprint)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve certificate: %s", err)
+	}
+	if rec != nil {
+		return rec.toPEMCollection(req)
+	}
+
 	bytes, err := base64.StdEncoding.DecodeString(req.PickupID)
 	if err != nil {
 		return nil, fmt.Errorf("Test-mode: could not parse requestID as base64 encoded fakeRequestID structure")
@@ -328,36 +422,129 @@ func (c *Connector) RetrieveCertificate(req *certificate.Request) (pcc *certific
 		return nil, err
 	}
 
+	dn := fakeRequest.DN
+	if dn == "" {
+		dn = getPolicyDN(c.zone) + "\\" + csr.Subject.CommonName
+	}
+	err = c.inventory.update(func() error {
+		rec = c.inventory.Certificates[dn]
+		if rec == nil {
+			rec = &certificateRecord{DN: dn, Guid: "{" + uuid.New().String() + "}", Zone: dn[:strings.LastIndex(dn, "\\")], CreatedOn: time.Now()}
+			c.inventory.Certificates[dn] = rec
+		}
+		if pk != nil {
+			keyDER, err := x509.MarshalPKCS8PrivateKey(pk)
+			if err != nil {
+				return err
+			}
+			rec.PrivateKey = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+		} else if rec.CSR != fakeRequest.CSR {
+			// the key of the previous version is not valid anymore
+			rec.PrivateKey = ""
+		}
+		rec.PickupID = req.PickupID
+		rec.CSR = base64.StdEncoding.EncodeToString(csrPEMbytes)
+		rec.Status = certificateStatusActive
+		rec.Revoked = false
+		rec.RevocationReason = ""
+		return rec.setCertificate(cert_pem)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store certificate in fake inventory: %s", err)
+	}
+
+	pcc, err = rec.toPEMCollection(req)
+	if err != nil {
+		return nil, err
+	}
+	err = req.CheckCertificate(pcc.Certificate)
+	return
+}
+
+// toPEMCollection returns the current version of the certificate with the test CA as chain. As for the other
+// connectors, the private key is only included when the request has a key password
+func (rec *certificateRecord) toPEMCollection(req *certificate.Request) (*certificate.PEMCollection, error) {
 	var certBytes []byte
 	switch req.ChainOption {
 	case certificate.ChainOptionRootFirst:
-		certBytes = append([]byte(CaCertPEM+"\n"), cert_pem...)
+		certBytes = append([]byte(CaCertPEM+"\n"), rec.Certificate...)
 	default:
-		certBytes = append(cert_pem, []byte(CaCertPEM)...)
+		certBytes = append([]byte(rec.Certificate), []byte(CaCertPEM)...)
 	}
-	pcc, err = certificate.PEMCollectionFromBytes(certBytes, req.ChainOption)
+	pcc, err := certificate.PEMCollectionFromBytes(certBytes, req.ChainOption)
 	if err != nil {
 		return nil, err
 	}
 	// no key password -- no key
-	if pk != nil && req.KeyPassword != "" {
-		err = pcc.AddPrivateKey(pk, []byte(req.KeyPassword))
+	if rec.PrivateKey != "" && req.KeyPassword != "" {
+		block, _ := pem.Decode([]byte(rec.PrivateKey))
+		if block == nil {
+			return nil, fmt.Errorf("failed to decode private key of %s", rec.DN)
+		}
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
 		if err != nil {
-			return
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type for %s", rec.DN)
+		}
+		err = pcc.AddPrivateKey(signer, []byte(req.KeyPassword))
+		if err != nil {
+			return nil, err
 		}
 	}
-	err = req.CheckCertificate(pcc.Certificate)
-	return
+	req.CertID = rec.Guid
+	return pcc, nil
 }
 
-// RevokeCertificate attempts to revoke the certificate
+var revocationReasons = map[string]bool{
+	"":                       true,
+	"none":                   true,
+	"key-compromise":         true,
+	"ca-compromise":          true,
+	"affiliation-changed":    true,
+	"superseded":             true,
+	"cessation-of-operation": true,
+}
+
+// RevokeCertificate attempts to revoke the certificate. As in TPP and VCP, the object is also retired when the request
+// disables it
 func (c *Connector) RevokeCertificate(revReq *certificate.RevocationRequest) (err error) {
-	return fmt.Errorf("revocation is not supported in -test-mode")
+	if !revocationReasons[revReq.Reason] {
+		return fmt.Errorf("could not parse revocation reason `%s`", revReq.Reason)
+	}
+	return c.inventory.update(func() error {
+		rec, err := c.inventory.find(revReq.CertificateDN, revReq.Thumbprint)
+		if err != nil {
+			return fmt.Errorf("failed to revoke certificate: %s", err)
+		}
+		if rec.Revoked || rec.Status == certificateStatusRevoked {
+			return fmt.Errorf("certificate %s is already revoked", rec.DN)
+		}
+		rec.Revoked = true
+		rec.RevocationReason = revReq.Reason
+		if revReq.Disable {
+			rec.Status = certificateStatusRetired
+		} else {
+			rec.Status = certificateStatusRevoked
+		}
+		return nil
+	})
 }
 
 // RetireCertificate attempts to retire the certificate
-func (c *Connector) RetireCertificate(revReq *certificate.RetireRequest) (err error) {
-	return fmt.Errorf("retire is not supported in -test-mode")
+func (c *Connector) RetireCertificate(retireReq *certificate.RetireRequest) (err error) {
+	return c.inventory.update(func() error {
+		rec, err := c.inventory.find(retireReq.CertificateDN, retireReq.Thumbprint)
+		if err != nil {
+			return fmt.Errorf("failed to retire certificate: %s", err)
+		}
+		if rec.Status == certificateStatusActive {
+			rec.Status = certificateStatusRetired
+		}
+		return nil
+	})
 }
 
 func (c *Connector) ReadZoneConfiguration() (config *endpoint.ZoneConfiguration, err error) {
@@ -367,13 +554,133 @@ func (c *Connector) ReadZoneConfiguration() (config *endpoint.ZoneConfiguration,
 	return
 }
 
-// RenewCertificate attempts to renew the certificate
-func (c *Connector) RenewCertificate(revReq *certificate.RenewalRequest) (requestID string, err error) {
-	return "", fmt.Errorf("renew is not supported in -test-mode")
+// RenewCertificate attempts to renew the certificate. The CSR of the renewal request is used when provided,
+// otherwise the CSR of the current version is reused
+func (c *Connector) RenewCertificate(renewReq *certificate.RenewalRequest) (requestID string, err error) {
+	var rec *certificateRecord
+	err = c.inventory.view(func() (err error) {
+		rec, err = c.inventory.find(renewReq.CertificateDN, renewReq.Thumbprint)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create renewal request: %s", err)
+	}
+	if rec.Status != certificateStatusActive {
+		return "", fmt.Errorf("certificate %s cannot be renewed, its status is %s", rec.DN, rec.Status)
+	}
+
+	fakeRequest := fakeRequestID{DN: rec.DN}
+	if renewReq.CertificateRequest != nil && len(renewReq.CertificateRequest.GetCSR()) != 0 {
+		fakeRequest.CSR = base64.StdEncoding.EncodeToString(renewReq.CertificateRequest.GetCSR())
+	} else if rec.CSR != "" {
+		fakeRequest.CSR = rec.CSR
+	} else {
+		return "", fmt.Errorf("certificate %s has no CSR to reuse. A new CSR must be provided in the request", rec.DN)
+	}
+
+	pickupID, err := encodeRequestID(fakeRequest)
+	if err != nil {
+		return "", err
+	}
+	if renewReq.CertificateRequest != nil {
+		renewReq.CertificateRequest.PickupID = pickupID
+	}
+	return pickupID, nil
 }
 
 func (c *Connector) ImportCertificate(req *certificate.ImportRequest) (*certificate.ImportResponse, error) {
-	return nil, fmt.Errorf("import is not supported in -test-mode")
+	block, _ := pem.Decode([]byte(req.CertificateData))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%w: can`t parse certificate", verror.UserDataError)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: can`t parse certificate: %s", verror.UserDataError, err)
+	}
+
+	zone := req.PolicyDN
+	if zone == "" {
+		zone = c.zone
+	}
+	name := req.ObjectName
+	if name == "" {
+		name = cert.Subject.CommonName
+	}
+	policyDN := getPolicyDN(zone)
+	dn := policyDN + "\\" + name
+
+	var rec *certificateRecord
+	err = c.inventory.update(func() error {
+		rec = c.inventory.Certificates[dn]
+		if rec != nil && !req.Reconcile {
+			return fmt.Errorf("%w: certificate %s already exists", verror.UserDataError, dn)
+		}
+		if rec == nil {
+			rec = &certificateRecord{DN: dn, Guid: "{" + uuid.New().String() + "}", Zone: policyDN, CreatedOn: time.Now()}
+			c.inventory.Certificates[dn] = rec
+		}
+		rec.Imported = true
+		rec.Status = certificateStatusActive
+		rec.Revoked = false
+		rec.RevocationReason = ""
+		rec.PickupID = ""
+		rec.CSR = ""
+		rec.PrivateKey = ""
+		if key, err := parseImportedPrivateKey(req.PrivateKeyData, req.Password); err == nil && key != nil {
+			rec.PrivateKey = string(pem.EncodeToMemory(key))
+		}
+		return rec.setCertificate(pem.EncodeToMemory(block))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &certificate.ImportResponse{CertificateDN: rec.DN, CertId: rec.Guid, Guid: rec.Guid}, nil
+}
+
+// parseImportedPrivateKey returns the PKCS#8 PEM block of the private key in keyPEM, decrypting it with password
+// when needed. A nil block is returned when there is no private key
+func parseImportedPrivateKey(keyPEM string, password string) (*pem.Block, error) {
+	if keyPEM == "" {
+		return nil, nil
+	}
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode private key")
+	}
+	der := block.Bytes
+	var err error
+	if block.Type == "ENCRYPTED PRIVATE KEY" {
+		key, err := pkcs8.ParsePKCS8PrivateKey(der, []byte(password))
+		if err != nil {
+			return nil, err
+		}
+		der, err = x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+	} else if util.X509IsEncryptedPEMBlock(block) {
+		der, err = util.X509DecryptPEMBlock(block, []byte(password))
+		if err != nil {
+			return nil, err
+		}
+	}
+	var key interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(der)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(der)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(der)
+	}
+	if err != nil {
+		return nil, err
+	}
+	der, err = x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return &pem.Block{Type: "PRIVATE KEY", Bytes: der}, nil
 }
 
 func (c *Connector) ReadPolicyConfiguration() (policy *endpoint.Policy, err error) {
@@ -413,8 +720,34 @@ func (c *Connector) GetZonesByParent(parent string) ([]string, error) {
 func (c *Connector) SetHTTPClient(client *http.Client) {
 }
 
+// ListCertificates returns the active certificates of the current zone
 func (c *Connector) ListCertificates(filter endpoint.Filter) ([]certificate.CertificateInfo, error) {
-	return nil, nil
+	if c.zone == "" {
+		return nil, fmt.Errorf("empty zone")
+	}
+
+	policyDN := getPolicyDN(c.zone)
+	now := time.Now()
+	infos := make([]certificate.CertificateInfo, 0)
+	err := c.inventory.view(func() error {
+		for _, rec := range c.inventory.sorted() {
+			if filter.Limit != nil && len(infos) >= *filter.Limit {
+				break
+			}
+			if rec.Zone != policyDN || rec.Status != certificateStatusActive {
+				continue
+			}
+			if !filter.WithExpired && rec.ValidTo.Before(now) {
+				continue
+			}
+			infos = append(infos, rec.toCertificateInfo())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return infos, nil
 }
 
 func (c *Connector) WriteLog(logReq *endpoint.LogRequest) (err error) {
@@ -17,11 +17,17 @@
 package fake
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/Venafi/vcert/v5/pkg/certificate"
+	"github.com/Venafi/vcert/v5/pkg/endpoint"
 )
 
 func TestRetrieveCertificate(t *testing.T) {
@@ -56,6 +62,28 @@ func getTestConnector() *Connector {
 	return c
 }
 
+func TestRequestCertificateContext(t *testing.T) {
+	conn := getTestConnector()
+	req := &certificate.Request{}
+	req.Subject.CommonName = "context.venafi.example.com"
+	req.KeyType = certificate.KeyTypeECDSA
+	err := conn.GenerateRequest(nil, req)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req.PickupID, err = conn.RequestCertificateContext(ctx, req)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	cancel()
+	_, err = conn.RetrieveCertificateContext(ctx, req)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancelled retrieval, got %v", err)
+	}
+}
+
 func TestRevokeCertificate(t *testing.T) {
 	var revReq = &certificate.RevocationRequest{}
 	var connector = getTestConnector()
@@ -93,3 +121,263 @@ func TestRequestCertificate(t *testing.T) {
 		t.Fatalf("should return non-empty pickupId")
 	}
 }
+
+func enrollTestCertificate(t *testing.T, conn *Connector, cn string) *certificate.Request {
+	req := &certificate.Request{}
+	req.Subject.CommonName = cn
+	req.DNSNames = []string{cn}
+	req.KeyType = certificate.KeyTypeECDSA
+	err := conn.GenerateRequest(nil, req)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	req.PickupID, err = conn.RequestCertificate(req)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	_, err = conn.RetrieveCertificate(req)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	return req
+}
+
+func TestCertificateLifecycle(t *testing.T) {
+	conn := getTestConnector()
+	conn.SetZone("Certificates\\Fake")
+	req := enrollTestCertificate(t, conn, "lifecycle.venafi.example.com")
+	dn := "\\VED\\Policy\\Certificates\\Fake\\lifecycle.venafi.example.com"
+
+	// retrieving again returns the same certificate
+	pcc, err := conn.RetrieveCertificate(&certificate.Request{PickupID: req.PickupID})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	first := pcc.Certificate
+
+	certs, err := conn.ListCertificates(endpoint.Filter{})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if len(certs) != 1 || certs[0].ID != dn {
+		t.Fatalf("expected %s in the inventory, got %+v", dn, certs)
+	}
+
+	pickupID, err := conn.RenewCertificate(&certificate.RenewalRequest{CertificateDN: dn})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	pcc, err = conn.RetrieveCertificate(&certificate.Request{PickupID: pickupID})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if pcc.Certificate == first {
+		t.Fatalf("renewal should issue a new certificate")
+	}
+
+	search := certificate.SearchRequest{"CN=lifecycle.venafi.example.com"}
+	found, err := conn.SearchCertificates(&search)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if found.Count != 1 {
+		t.Fatalf("expected 1 certificate, got %d", found.Count)
+	}
+	if info := found.Certificates[0].X509; info.CN != "lifecycle.venafi.example.com" || !strings.Contains(info.Issuer, "VCert Test Mode CA") {
+		t.Fatalf("unexpected certificate details %+v", info)
+	}
+	search = certificate.SearchRequest{"Issuer=vcert%20test%20mode"}
+	found, err = conn.SearchCertificates(&search)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if found.Count == 0 {
+		t.Fatalf("expected the certificates of the test mode CA")
+	}
+
+	err = conn.RevokeCertificate(&certificate.RevocationRequest{CertificateDN: dn, Reason: "key-compromise"})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	err = conn.RevokeCertificate(&certificate.RevocationRequest{CertificateDN: dn})
+	if err == nil {
+		t.Fatalf("revoking twice should fail")
+	}
+	_, err = conn.RenewCertificate(&certificate.RenewalRequest{CertificateDN: dn})
+	if err == nil {
+		t.Fatalf("renewing a revoked certificate should fail")
+	}
+	certs, err = conn.ListCertificates(endpoint.Filter{})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if len(certs) != 0 {
+		t.Fatalf("revoked certificates should not be listed, got %+v", certs)
+	}
+}
+
+func TestRevokeCertificateDisable(t *testing.T) {
+	conn := getTestConnector()
+	conn.SetZone("Certificates\\Fake")
+	enrollTestCertificate(t, conn, "disable.venafi.example.com")
+	dn := "\\VED\\Policy\\Certificates\\Fake\\disable.venafi.example.com"
+
+	err := conn.RevokeCertificate(&certificate.RevocationRequest{CertificateDN: dn, Reason: "superseded", Disable: true})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	rec := conn.inventory.Certificates[dn]
+	if rec.Status != certificateStatusRetired || !rec.Revoked || rec.RevocationReason != "superseded" {
+		t.Fatalf("expected a revoked and retired certificate, got %+v", rec)
+	}
+	found, err := conn.SearchCertificates(&certificate.SearchRequest{"CN=disable.venafi.example.com", "Disabled=1"})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if found.Count != 1 {
+		t.Fatalf("expected the retired certificate, got %+v", found.Certificates)
+	}
+	err = conn.RevokeCertificate(&certificate.RevocationRequest{CertificateDN: dn})
+	if err == nil {
+		t.Fatalf("revoking a retired certificate twice should fail")
+	}
+}
+
+func TestImportCertificate(t *testing.T) {
+	conn := getTestConnector()
+	req := enrollTestCertificate(t, conn, "import.venafi.example.com")
+	pcc, err := conn.RetrieveCertificate(&certificate.Request{PickupID: req.PickupID})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+
+	importReq := &certificate.ImportRequest{PolicyDN: "Imported", CertificateData: pcc.Certificate}
+	resp, err := conn.ImportCertificate(importReq)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if resp.CertificateDN != "\\VED\\Policy\\Imported\\import.venafi.example.com" {
+		t.Fatalf("unexpected DN %s", resp.CertificateDN)
+	}
+	_, err = conn.ImportCertificate(importReq)
+	if err == nil {
+		t.Fatalf("importing the same object twice without reconcile should fail")
+	}
+	importReq.Reconcile = true
+	_, err = conn.ImportCertificate(importReq)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+}
+
+func TestInventoryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	conn, err := NewConnectorWithInventory(false, nil, path)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	enrollTestCertificate(t, conn, "persisted.venafi.example.com")
+
+	conn, err = NewConnectorWithInventory(false, nil, path)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	info, err := conn.SearchCertificate("", "persisted.venafi.example.com", &certificate.Sans{DNS: []string{"persisted.venafi.example.com"}}, 0)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if info.CN != "persisted.venafi.example.com" {
+		t.Fatalf("unexpected certificate %+v", info)
+	}
+}
+
+func TestInventoryFileShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	// each connector stands for a process with its own copy of the inventory
+	var conns []*Connector
+	for i := 0; i < 3; i++ {
+		conn, err := NewConnectorWithInventory(false, nil, path)
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		conns = append(conns, conn)
+	}
+
+	// the certificates are issued, and saved to the inventory, at the same time
+	var wg sync.WaitGroup
+	errs := make([]error, len(conns))
+	for i, conn := range conns {
+		req := &certificate.Request{}
+		req.Subject.CommonName = fmt.Sprintf("shared%d.venafi.example.com", i)
+		req.KeyType = certificate.KeyTypeECDSA
+		err := conn.GenerateRequest(nil, req)
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		req.PickupID, err = conn.RequestCertificate(req)
+		if err != nil {
+			t.Fatalf("%s", err)
+		}
+		wg.Add(1)
+		go func(i int, conn *Connector) {
+			defer wg.Done()
+			_, errs[i] = conn.RetrieveCertificate(req)
+		}(i, conn)
+	}
+	wg.Wait()
+	for i := range conns {
+		if errs[i] != nil {
+			t.Fatalf("%s", errs[i])
+		}
+	}
+
+	// every connector, including the ones created before the others saved, sees all the certificates
+	conn, err := NewConnectorWithInventory(false, nil, path)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	for _, reader := range append(conns, conn) {
+		for i := range conns {
+			cn := fmt.Sprintf("shared%d.venafi.example.com", i)
+			found, err := reader.SearchCertificates(&certificate.SearchRequest{"CN=" + cn})
+			if err != nil {
+				t.Fatalf("%s", err)
+			}
+			if found.Count != 1 {
+				t.Fatalf("expected %s in the inventory, got %+v", cn, found.Certificates)
+			}
+		}
+	}
+}
+
+func TestRequestSSHCertificate(t *testing.T) {
+	conn := getTestConnector()
+	resp, err := conn.RequestSSHCertificate(&certificate.SshCertRequest{
+		Template:       defaultSshTemplate,
+		KeyId:          "fake-key",
+		Principals:     []string{"bob"},
+		ValidityPeriod: "2d",
+	})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if resp.PrivateKeyData == "" || !strings.HasPrefix(resp.CertificateData, "ssh-rsa-cert-v01@openssh.com") {
+		t.Fatalf("unexpected SSH certificate %+v", resp)
+	}
+
+	retrieved, err := conn.RetrieveSSHCertificate(&certificate.SshCertRequest{PickupID: resp.DN})
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if retrieved.CertificateData != resp.CertificateData {
+		t.Fatalf("retrieved certificate differs from the issued one")
+	}
+
+	config, err := conn.RetrieveSshConfig(nil)
+	if err != nil {
+		t.Fatalf("%s", err)
+	}
+	if retrieved.CertificateDetails.CAFingerprintSHA256 == "" || !strings.HasPrefix(config.CaPublicKey, "ssh-ed25519") {
+		t.Fatalf("unexpected SSH CA %+v", config)
+	}
+}
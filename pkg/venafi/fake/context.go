@@ -0,0 +1,226 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fake
+
+import (
+	"context"
+	"time"
+
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+	"github.com/Venafi/vcert/v5/pkg/domain"
+	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/policy"
+)
+
+// The fake connector does not make requests nor wait for certificates to be issued. The methods with a context
+// only fail when the context is already done
+
+func (c *Connector) PingContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.Ping()
+}
+
+func (c *Connector) AuthenticateContext(ctx context.Context, auth *endpoint.Authentication) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.Authenticate(auth)
+}
+
+func (c *Connector) ReadPolicyConfigurationContext(ctx context.Context) (*endpoint.Policy, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.ReadPolicyConfiguration()
+}
+
+func (c *Connector) ReadZoneConfigurationContext(ctx context.Context) (*endpoint.ZoneConfiguration, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.ReadZoneConfiguration()
+}
+
+func (c *Connector) GetZonesByParentContext(ctx context.Context, parent string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.GetZonesByParent(parent)
+}
+
+func (c *Connector) GenerateRequestContext(ctx context.Context, config *endpoint.ZoneConfiguration, req *certificate.Request) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.GenerateRequest(config, req)
+}
+
+func (c *Connector) ResetCertificateContext(ctx context.Context, req *certificate.Request, restart bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.ResetCertificate(req, restart)
+}
+
+func (c *Connector) RequestCertificateContext(ctx context.Context, req *certificate.Request) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return c.RequestCertificate(req)
+}
+
+func (c *Connector) RetrieveCertificateContext(ctx context.Context, req *certificate.Request) (*certificate.PEMCollection, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.RetrieveCertificate(req)
+}
+
+func (c *Connector) ProvisionCertificateContext(ctx context.Context, req *domain.ProvisioningRequest, options *domain.ProvisioningOptions) (*domain.ProvisioningMetadata, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.ProvisionCertificate(req, options)
+}
+
+func (c *Connector) IsCSRServiceGeneratedContext(ctx context.Context, req *certificate.Request) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return c.IsCSRServiceGenerated(req)
+}
+
+func (c *Connector) RevokeCertificateContext(ctx context.Context, req *certificate.RevocationRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.RevokeCertificate(req)
+}
+
+func (c *Connector) RenewCertificateContext(ctx context.Context, req *certificate.RenewalRequest) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return c.RenewCertificate(req)
+}
+
+func (c *Connector) RetireCertificateContext(ctx context.Context, req *certificate.RetireRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.RetireCertificate(req)
+}
+
+func (c *Connector) ImportCertificateContext(ctx context.Context, req *certificate.ImportRequest) (*certificate.ImportResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.ImportCertificate(req)
+}
+
+func (c *Connector) ListCertificatesContext(ctx context.Context, filter endpoint.Filter) ([]certificate.CertificateInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.ListCertificates(filter)
+}
+
+func (c *Connector) SearchCertificatesContext(ctx context.Context, req *certificate.SearchRequest) (*certificate.CertSearchResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.SearchCertificates(req)
+}
+
+func (c *Connector) SearchCertificateContext(ctx context.Context, zone string, cn string, sans *certificate.Sans, certMinTimeLeft time.Duration) (*certificate.CertificateInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.SearchCertificate(zone, cn, sans, certMinTimeLeft)
+}
+
+func (c *Connector) RetrieveCertificateMetaDataContext(ctx context.Context, dn string) (*certificate.CertificateMetaData, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.RetrieveCertificateMetaData(dn)
+}
+
+func (c *Connector) SetPolicyContext(ctx context.Context, name string, ps *policy.PolicySpecification) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return c.SetPolicy(name, ps)
+}
+
+func (c *Connector) GetPolicyContext(ctx context.Context, name string) (*policy.PolicySpecification, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.GetPolicy(name)
+}
+
+func (c *Connector) RequestSSHCertificateContext(ctx context.Context, req *certificate.SshCertRequest) (*certificate.SshCertificateObject, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.RequestSSHCertificate(req)
+}
+
+func (c *Connector) RetrieveSSHCertificateContext(ctx context.Context, req *certificate.SshCertRequest) (*certificate.SshCertificateObject, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.RetrieveSSHCertificate(req)
+}
+
+func (c *Connector) RetrieveSshConfigContext(ctx context.Context, ca *certificate.SshCaTemplateRequest) (*certificate.SshConfig, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.RetrieveSshConfig(ca)
+}
+
+func (c *Connector) RetrieveAvailableSSHTemplatesContext(ctx context.Context) ([]certificate.SshAvaliableTemplate, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.RetrieveAvailableSSHTemplates()
+}
+
+func (c *Connector) SynchronousRequestCertificateContext(ctx context.Context, req *certificate.Request) (*certificate.PEMCollection, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.SynchronousRequestCertificate(req)
+}
+
+func (c *Connector) RetrieveSystemVersionContext(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return c.RetrieveSystemVersion()
+}
+
+func (c *Connector) WriteLogContext(ctx context.Context, req *endpoint.LogRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.WriteLog(req)
+}
@@ -0,0 +1,356 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fake
+
+import (
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+)
+
+const (
+	policyRoot = "\\VED\\Policy"
+
+	certificateStatusActive  = "ACTIVE"
+	certificateStatusRetired = "RETIRED"
+	certificateStatusRevoked = "REVOKED"
+
+	// inventoryLockTimeout is how long an update waits for the lock file of the inventory held by another process.
+	// A lock file older than that is left over by a process that died while holding it, and is removed
+	inventoryLockTimeout = 10 * time.Second
+	inventoryLockRetry   = 10 * time.Millisecond
+)
+
+// certificateRecord is the state kept by the fake connector for a certificate object. As in TPP, an object is
+// identified by its DN and holds the latest version of the certificate issued for it
+type certificateRecord struct {
+	DN               string     `json:"dn"`
+	Guid             string     `json:"guid"`
+	Zone             string     `json:"zone"`
+	PickupID         string     `json:"pickupId"`
+	CSR              string     `json:"csr,omitempty"`
+	Certificate      string     `json:"certificate"`
+	PrivateKey       string     `json:"privateKey,omitempty"`
+	Thumbprint       string     `json:"thumbprint"`
+	Serial           string     `json:"serial"`
+	CommonName       string     `json:"commonName"`
+	Issuer           string     `json:"issuer,omitempty"`
+	Sans             sansRecord `json:"sans"`
+	ValidFrom        time.Time  `json:"validFrom"`
+	ValidTo          time.Time  `json:"validTo"`
+	Status           string     `json:"status"`
+	Revoked          bool       `json:"revoked,omitempty"`
+	RevocationReason string     `json:"revocationReason,omitempty"`
+	Imported         bool       `json:"imported,omitempty"`
+	CreatedOn        time.Time  `json:"createdOn"`
+}
+
+type sansRecord struct {
+	DNS   []string `json:"dns,omitempty"`
+	Email []string `json:"email,omitempty"`
+	IP    []string `json:"ip,omitempty"`
+	URI   []string `json:"uri,omitempty"`
+}
+
+// sshCertificateRecord is the state kept by the fake connector for an SSH certificate object
+type sshCertificateRecord struct {
+	DN          string   `json:"dn"`
+	Guid        string   `json:"guid"`
+	Template    string   `json:"template"`
+	Certificate string   `json:"certificate"`
+	PrivateKey  string   `json:"privateKey,omitempty"`
+	PublicKey   string   `json:"publicKey"`
+	KeyID       string   `json:"keyId"`
+	Principals  []string `json:"principals,omitempty"`
+}
+
+// inventory keeps track of everything issued, imported or retired through the fake connector, so that the lifecycle
+// operations behave like a real backend. When path is not empty the inventory is loaded from and saved to that file.
+// Several processes may share the file: every read re-reads the file, see view, and every update holds a lock file
+// and re-reads the file before changing it, so that no process overwrites the records saved by another one
+type inventory struct {
+	mu   sync.Mutex
+	path string
+
+	Certificates    map[string]*certificateRecord    `json:"certificates"`
+	SshCertificates map[string]*sshCertificateRecord `json:"sshCertificates"`
+	SshCAKey        string                           `json:"sshCAKey,omitempty"`
+}
+
+func newInventory(path string) (*inventory, error) {
+	inv := &inventory{
+		path:            path,
+		Certificates:    map[string]*certificateRecord{},
+		SshCertificates: map[string]*sshCertificateRecord{},
+	}
+	err := inv.load()
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// load replaces the content of the inventory with the content of its file, if any. It must be called with the lock
+// held
+func (inv *inventory) load() error {
+	if inv.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(inv.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read fake inventory file %s: %w", inv.path, err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	loaded := &inventory{}
+	err = json.Unmarshal(data, loaded)
+	if err != nil {
+		return fmt.Errorf("failed to parse fake inventory file %s: %w", inv.path, err)
+	}
+	inv.Certificates = loaded.Certificates
+	if inv.Certificates == nil {
+		inv.Certificates = map[string]*certificateRecord{}
+	}
+	inv.SshCertificates = loaded.SshCertificates
+	if inv.SshCertificates == nil {
+		inv.SshCertificates = map[string]*sshCertificateRecord{}
+	}
+	inv.SshCAKey = loaded.SshCAKey
+	return nil
+}
+
+// lockFile creates the lock file of the inventory, waiting for another process holding it to release it.
+// It returns the function that releases the lock
+func (inv *inventory) lockFile() (func(), error) {
+	if inv.path == "" {
+		return func() {}, nil
+	}
+	lockPath := inv.path + ".lock"
+	deadline := time.Now().Add(inventoryLockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to lock fake inventory file %s: %w", inv.path, err)
+		}
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > inventoryLockTimeout {
+			_ = os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to lock fake inventory file %s: %s is held by another process", inv.path, lockPath)
+		}
+		time.Sleep(inventoryLockRetry)
+	}
+}
+
+// save writes the inventory to its file, if any. The file is replaced atomically so a concurrent reader never sees
+// a partial inventory. It must be called with the lock held
+func (inv *inventory) save() error {
+	if inv.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(inv.path), filepath.Base(inv.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save fake inventory: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save fake inventory: %w", err)
+	}
+	return os.Rename(tmp.Name(), inv.path)
+}
+
+// update runs fn with the lock held and saves the inventory afterwards. When the inventory has a file, fn runs on
+// its latest content, read with the lock file held until the inventory is saved
+func (inv *inventory) update(fn func() error) error {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	unlock, err := inv.lockFile()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = inv.load()
+	if err != nil {
+		return err
+	}
+	err = fn()
+	if err != nil {
+		return err
+	}
+	return inv.save()
+}
+
+// view runs fn with the lock held on the latest content of the inventory. The file is replaced atomically by save, so
+// it is read without the lock file
+func (inv *inventory) view(fn func() error) error {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
+	err := inv.load()
+	if err != nil {
+		return err
+	}
+	return fn()
+}
+
+// findByPickupID returns the certificate issued for pickupID. It must be called with the lock held
+func (inv *inventory) findByPickupID(pickupID string) *certificateRecord {
+	for _, rec := range inv.Certificates {
+		if rec.PickupID == pickupID {
+			return rec
+		}
+	}
+	return nil
+}
+
+// find returns the certificate object identified by its DN, GUID or by the thumbprint of its current version.
+// It must be called with the lock held
+func (inv *inventory) find(id, thumbprint string) (*certificateRecord, error) {
+	if id != "" {
+		if rec, ok := inv.Certificates[id]; ok {
+			return rec, nil
+		}
+		for _, rec := range inv.Certificates {
+			if rec.Guid == id || rec.PickupID == id {
+				return rec, nil
+			}
+		}
+		return nil, fmt.Errorf("certificate %s does not exist", id)
+	}
+	if thumbprint != "" {
+		thumbprint = normalizeThumbprint(thumbprint)
+		for _, rec := range inv.Certificates {
+			if rec.Thumbprint == thumbprint {
+				return rec, nil
+			}
+		}
+		return nil, fmt.Errorf("no certificate found using thumbprint %s", thumbprint)
+	}
+	return nil, fmt.Errorf("CertificateDN or Thumbprint required")
+}
+
+// sorted returns the certificate objects ordered by DN, so listings are stable. It must be called with the lock held
+func (inv *inventory) sorted() []*certificateRecord {
+	records := make([]*certificateRecord, 0, len(inv.Certificates))
+	for _, rec := range inv.Certificates {
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].DN < records[j].DN
+	})
+	return records
+}
+
+// setCertificate replaces the current version of the certificate held by rec with the PEM encoded certificate
+func (rec *certificateRecord) setCertificate(certPEM []byte) error {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return fmt.Errorf("failed to decode certificate PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return err
+	}
+	rec.Certificate = string(certPEM)
+	rec.Thumbprint = thumbprint(block.Bytes)
+	rec.Serial = strings.ToUpper(cert.SerialNumber.Text(16))
+	rec.CommonName = cert.Subject.CommonName
+	rec.Issuer = cert.Issuer.String()
+	rec.Sans = sansRecord{DNS: cert.DNSNames, Email: cert.EmailAddresses}
+	for _, ip := range cert.IPAddresses {
+		rec.Sans.IP = append(rec.Sans.IP, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		rec.Sans.URI = append(rec.Sans.URI, uri.String())
+	}
+	rec.ValidFrom = cert.NotBefore
+	rec.ValidTo = cert.NotAfter
+	return nil
+}
+
+func (rec *certificateRecord) toCertificateInfo() certificate.CertificateInfo {
+	return certificate.CertificateInfo{
+		ID: rec.DN,
+		CN: rec.CommonName,
+		SANS: certificate.Sans{
+			DNS:   rec.Sans.DNS,
+			Email: rec.Sans.Email,
+			IP:    rec.Sans.IP,
+			URI:   rec.Sans.URI,
+		},
+		Issuer:     rec.Issuer,
+		Serial:     rec.Serial,
+		Thumbprint: rec.Thumbprint,
+		ValidFrom:  rec.ValidFrom,
+		ValidTo:    rec.ValidTo,
+	}
+}
+
+func thumbprint(der []byte) string {
+	h := sha1.Sum(der)
+	return strings.ToUpper(hex.EncodeToString(h[:]))
+}
+
+func normalizeThumbprint(fp string) string {
+	fp = strings.Replace(fp, ":", "", -1)
+	fp = strings.Replace(fp, ".", "", -1)
+	return strings.ToUpper(fp)
+}
+
+// getPolicyDN returns the DN of the policy folder of zone, the same way TPP does
+func getPolicyDN(zone string) string {
+	zone = strings.TrimPrefix(zone, "\\")
+	if zone == "" {
+		return policyRoot
+	}
+	if strings.HasPrefix(zone, "VED\\Policy") {
+		return "\\" + zone
+	}
+	return policyRoot + "\\" + zone
+}
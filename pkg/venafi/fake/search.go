@@ -0,0 +1,139 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fake
+
+import (
+	"fmt"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+	"github.com/Venafi/vcert/v5/pkg/util"
+	"github.com/Venafi/vcert/v5/pkg/verror"
+)
+
+// searchFilter is the in-memory equivalent of a TPP certificate search
+type searchFilter struct {
+	matchers []func(rec *certificateRecord) bool
+	limit    int
+	offset   int
+}
+
+func (f searchFilter) matches(rec *certificateRecord) bool {
+	for _, m := range f.matchers {
+		if !m(rec) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseSearchRequest(req *certificate.SearchRequest) (*searchFilter, error) {
+	filter := &searchFilter{}
+	if req == nil {
+		return filter, nil
+	}
+
+	for _, entry := range *req {
+		key, value, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("%w: invalid search attribute %q. Expected format is <attribute>=<value>", verror.UserDataError, entry)
+		}
+		if unescaped, err := neturl.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+
+		var m func(rec *certificateRecord) bool
+		switch strings.ToLower(key) {
+		case "thumbprint":
+			fp := normalizeThumbprint(value)
+			m = func(rec *certificateRecord) bool { return rec.Thumbprint == fp }
+		case "serial":
+			m = func(rec *certificateRecord) bool { return strings.EqualFold(rec.Serial, value) }
+		case "cn":
+			m = func(rec *certificateRecord) bool { return rec.CommonName == value }
+		case "issuer":
+			issuer := strings.ToLower(value)
+			m = func(rec *certificateRecord) bool { return strings.Contains(strings.ToLower(rec.Issuer), issuer) }
+		case "san-dns":
+			m = func(rec *certificateRecord) bool { return util.ArrayContainsString(rec.Sans.DNS, value) }
+		case "san-email":
+			m = func(rec *certificateRecord) bool { return util.ArrayContainsString(rec.Sans.Email, value) }
+		case "san-ip":
+			m = func(rec *certificateRecord) bool { return util.ArrayContainsString(rec.Sans.IP, value) }
+		case "san-uri":
+			m = func(rec *certificateRecord) bool { return util.ArrayContainsString(rec.Sans.URI, value) }
+		case "parentdn":
+			policyDN := getPolicyDN(value)
+			m = func(rec *certificateRecord) bool { return rec.Zone == policyDN }
+		case "parentdnrecursive":
+			policyDN := getPolicyDN(value)
+			m = func(rec *certificateRecord) bool {
+				return rec.Zone == policyDN || strings.HasPrefix(rec.Zone, policyDN+"\\")
+			}
+		case "disabled":
+			disabled := value == "1" || strings.EqualFold(value, "true")
+			m = func(rec *certificateRecord) bool { return (rec.Status != certificateStatusActive) == disabled }
+		case "validtoless", "validtogreater", "validfromless", "validfromgreater":
+			t, err := parseSearchDate(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid value for search attribute %s: %s", verror.UserDataError, key, err)
+			}
+			lowerKey := strings.ToLower(key)
+			m = func(rec *certificateRecord) bool {
+				date := rec.ValidTo
+				if strings.HasPrefix(lowerKey, "validfrom") {
+					date = rec.ValidFrom
+				}
+				if strings.HasSuffix(lowerKey, "greater") {
+					return date.After(t)
+				}
+				return date.Before(t)
+			}
+		case "limit":
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 0 {
+				return nil, fmt.Errorf("%w: invalid value for search attribute %s: %s", verror.UserDataError, key, value)
+			}
+			filter.limit = limit
+		case "offset":
+			offset, err := strconv.Atoi(value)
+			if err != nil || offset < 0 {
+				return nil, fmt.Errorf("%w: invalid value for search attribute %s: %s", verror.UserDataError, key, value)
+			}
+			filter.offset = offset
+		default:
+			return nil, fmt.Errorf("%w: search attribute %s is not supported in -test-mode", verror.UserDataError, key)
+		}
+		if m != nil {
+			filter.matchers = append(filter.matchers, m)
+		}
+	}
+	return filter, nil
+}
+
+func parseSearchDate(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%q is not an ISO 8601 date", value)
+}
@@ -0,0 +1,261 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package fake
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+	"github.com/Venafi/vcert/v5/pkg/util"
+)
+
+const (
+	sshTemplateRoot     = "\\VED\\Certificate Authority\\SSH\\Templates\\"
+	sshCertificatesRoot = "\\VED\\Certificate Authority\\SSH\\Certificates\\"
+
+	defaultSshTemplate        = "fake-ssh-user-ca"
+	defaultSshHostTemplate    = "fake-ssh-host-ca"
+	defaultSshValidityPeriod  = 24 * time.Hour
+	sshServiceGeneratedKeyLen = 2048
+)
+
+var defaultSshPrincipals = []string{"bob", "alice"}
+
+func (c *Connector) RetrieveSshConfig(ca *certificate.SshCaTemplateRequest) (*certificate.SshConfig, error) {
+	signer, err := c.sshCASigner()
+	if err != nil {
+		return nil, err
+	}
+	caPublicKey := strings.TrimRight(string(ssh.MarshalAuthorizedKey(signer.PublicKey())), "\r\n")
+	return &certificate.SshConfig{CaPublicKey: caPublicKey, Principals: defaultSshPrincipals}, nil
+}
+
+func (c *Connector) RetrieveAvailableSSHTemplates() (response []certificate.SshAvaliableTemplate, err error) {
+	return []certificate.SshAvaliableTemplate{
+		{DN: sshTemplateRoot + defaultSshTemplate, Guid: "{6bd5c8a3-dbc4-4f56-9a51-3f1e8b8f6a01}"},
+		{DN: sshTemplateRoot + defaultSshHostTemplate, Guid: "{6bd5c8a3-dbc4-4f56-9a51-3f1e8b8f6a02}"},
+	}, nil
+}
+
+// RequestSSHCertificate issues an SSH certificate signed by the fake SSH CA. A key pair is generated when the
+// request has no public key. Templates whose name contains "host" issue host certificates
+func (c *Connector) RequestSSHCertificate(req *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
+	if req.Template == "" {
+		return nil, fmt.Errorf("SSH certificate template is required")
+	}
+	validity := defaultSshValidityPeriod
+	if req.ValidityPeriod != "" {
+		validity, err = parseSshValidityPeriod(req.ValidityPeriod)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rec := &sshCertificateRecord{
+		Guid:       "{" + uuid.New().String() + "}",
+		Template:   req.Template,
+		KeyID:      req.KeyId,
+		Principals: req.Principals,
+	}
+	publicKeyData := req.PublicKeyData
+	if publicKeyData == "" {
+		privateKey, publicKey, err := util.GenerateSshKeyPair(sshServiceGeneratedKeyLen, req.PrivateKeyPassphrase, req.KeyId, req.PrivateKeyFormat)
+		if err != nil {
+			return nil, err
+		}
+		rec.PrivateKey = string(privateKey)
+		publicKeyData = string(publicKey)
+	}
+	rec.PublicKey = publicKeyData
+
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKeyData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH public key: %s", err)
+	}
+	signer, err := c.sshCASigner()
+	if err != nil {
+		return nil, err
+	}
+
+	var serial [8]byte
+	_, err = rand.Read(serial[:])
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	cert := &ssh.Certificate{
+		Key:             publicKey,
+		Serial:          binary.BigEndian.Uint64(serial[:]),
+		CertType:        ssh.UserCert,
+		KeyId:           req.KeyId,
+		ValidPrincipals: req.Principals,
+		ValidAfter:      uint64(now.Add(-5 * time.Minute).Unix()),
+		ValidBefore:     uint64(now.Add(validity).Unix()),
+		Permissions: ssh.Permissions{
+			CriticalOptions: map[string]string{},
+			Extensions:      map[string]string{},
+		},
+	}
+	if strings.Contains(strings.ToLower(req.Template), "host") {
+		cert.CertType = ssh.HostCert
+	}
+	if req.ForceCommand != "" {
+		cert.CriticalOptions["force-command"] = req.ForceCommand
+	}
+	if len(req.SourceAddresses) > 0 {
+		cert.CriticalOptions["source-address"] = strings.Join(req.SourceAddresses, ",")
+	}
+	for _, extension := range req.Extensions {
+		key, value, _ := strings.Cut(extension, ":")
+		cert.Extensions[key] = value
+	}
+	err = cert.SignCert(rand.Reader, signer)
+	if err != nil {
+		return nil, err
+	}
+	rec.Certificate = strings.TrimRight(string(ssh.MarshalAuthorizedKey(cert)), "\r\n")
+
+	name := req.ObjectName
+	if name == "" {
+		name = req.KeyId
+	}
+	if name == "" {
+		name = rec.Guid
+	}
+	rec.DN = sshCertificatesRoot + name
+
+	err = c.inventory.update(func() error {
+		c.inventory.SshCertificates[rec.DN] = rec
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rec.toSshCertificateObject()
+}
+
+// RetrieveSSHCertificate returns the SSH certificate identified by the PickupID (its DN) or GUID of req
+func (c *Connector) RetrieveSSHCertificate(req *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
+	var rec *sshCertificateRecord
+	var ok bool
+	err = c.inventory.view(func() error {
+		rec, ok = c.inventory.SshCertificates[req.PickupID]
+		if !ok && req.Guid != "" {
+			for _, r := range c.inventory.SshCertificates {
+				if r.Guid == req.Guid {
+					rec, ok = r, true
+					break
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("SSH certificate %s%s does not exist", req.PickupID, req.Guid)
+	}
+	return rec.toSshCertificateObject()
+}
+
+func (rec *sshCertificateRecord) toSshCertificateObject() (*certificate.SshCertificateObject, error) {
+	cert, err := certificate.ParseSshCertificate([]byte(rec.Certificate))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", rec.DN, err)
+	}
+
+	return &certificate.SshCertificateObject{
+		Guid:               rec.Guid,
+		DN:                 rec.DN,
+		CADN:               sshTemplateRoot + rec.Template,
+		CertificateData:    rec.Certificate,
+		PrivateKeyData:     rec.PrivateKey,
+		PublicKeyData:      rec.PublicKey,
+		CertificateDetails: certificate.NewSshCertificateDetails(cert),
+		ProcessingDetails:  certificate.ProcessingDetails{Status: "Issued"},
+	}, nil
+}
+
+// sshCASigner returns the signer of the fake SSH CA. The CA key is created on first use and kept in the inventory,
+// so a file-backed inventory keeps the same CA across runs
+func (c *Connector) sshCASigner() (ssh.Signer, error) {
+	var caKey string
+	err := c.inventory.update(func() error {
+		if c.inventory.SshCAKey == "" {
+			_, key, err := ed25519.GenerateKey(rand.Reader)
+			if err != nil {
+				return err
+			}
+			der, err := x509.MarshalPKCS8PrivateKey(key)
+			if err != nil {
+				return err
+			}
+			c.inventory.SshCAKey = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+		}
+		caKey = c.inventory.SshCAKey
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode([]byte(caKey))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode the fake SSH CA key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return ssh.NewSignerFromSigner(key.(crypto.Signer))
+}
+
+// parseSshValidityPeriod parses validity periods as accepted by TPP, e.g. "4h", "3d" or "1w"
+func parseSshValidityPeriod(period string) (time.Duration, error) {
+	unit := period[len(period)-1:]
+	switch unit {
+	case "d", "w":
+		n, err := strconv.Atoi(period[:len(period)-1])
+		if err != nil {
+			return 0, fmt.Errorf("invalid validity period %s", period)
+		}
+		days := time.Duration(n) * 24 * time.Hour
+		if unit == "w" {
+			days *= 7
+		}
+		return days, nil
+	default:
+		d, err := time.ParseDuration(period)
+		if err != nil {
+			return 0, fmt.Errorf("invalid validity period %s", period)
+		}
+		return d, nil
+	}
+}
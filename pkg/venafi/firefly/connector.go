@@ -34,6 +34,7 @@ import (
 	"github.com/Venafi/vcert/v5/pkg/certificate"
 	"github.com/Venafi/vcert/v5/pkg/domain"
 	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/httputils"
 	"github.com/Venafi/vcert/v5/pkg/policy"
 	"github.com/Venafi/vcert/v5/pkg/util"
 	"github.com/Venafi/vcert/v5/pkg/venafi"
@@ -51,8 +52,12 @@ type Connector struct {
 	verbose     bool
 	trust       *x509.CertPool
 	client      *http.Client
+	retryPolicy *httputils.RetryPolicy
 	zone        string // holds the policyName
 	userAgent   string
+	tokenCache  *TokenCache
+	// tokenSource refreshes the access token gotten by AuthenticateContext once it expires
+	tokenSource oauth2.TokenSource
 }
 
 // NewConnector creates a new Firefly Connector object used to communicate with Firefly
@@ -82,11 +87,22 @@ func (c *Connector) SetUserAgent(userAgent string) {
 	c.userAgent = userAgent
 }
 
+// SetTokenCache sets the cache in which the OAuth 2.0 tokens are stored, so that the authorization flow runs
+// only when no cached token is valid or can be refreshed
+func (c *Connector) SetTokenCache(cache *TokenCache) {
+	c.tokenCache = cache
+}
+
 func (c *Connector) GetType() endpoint.ConnectorType {
 	return endpoint.ConnectorTypeFirefly
 }
 
 func (c *Connector) Authenticate(auth *endpoint.Authentication) error {
+	return c.AuthenticateContext(context.Background(), auth)
+}
+
+// AuthenticateContext is Authenticate with a context that cancels its requests to the platform
+func (c *Connector) AuthenticateContext(ctx context.Context, auth *endpoint.Authentication) error {
 	if auth == nil {
 		msg := "failed to authenticate: no credentials provided"
 		zap.L().Error(msg, fieldPlatform)
@@ -95,12 +111,20 @@ func (c *Connector) Authenticate(auth *endpoint.Authentication) error {
 
 	if auth.AccessToken == "" {
 		zap.L().Info("no access token provided. Authorization needed", fieldPlatform)
-		var token *oauth2.Token
-		token, err := c.Authorize(auth)
+		c.tokenSource = nil
+		token, err := c.TokenSource(ctx, auth).Token()
 		if err != nil {
 			return err
 		}
 		auth.AccessToken = token.AccessToken
+		// the authorization flow is not run again once the connector is authenticated, so the token is only refreshed
+		c.tokenSource = oauth2.ReuseTokenSource(token, &cachedTokenSource{
+			ctx:       context.WithoutCancel(ctx),
+			connector: c,
+			auth:      auth,
+			cache:     c.tokenCache,
+			token:     token,
+		})
 	}
 
 	zap.L().Info("successfully authenticated", fieldPlatform)
@@ -109,8 +133,25 @@ func (c *Connector) Authenticate(auth *endpoint.Authentication) error {
 	return nil
 }
 
+// TokenSource returns the access tokens of auth. The token of the token cache is returned while it is valid, and
+// refreshed with its refresh token once it expires. The authorization flow of auth runs when no token can be refreshed
+func (c *Connector) TokenSource(ctx context.Context, auth *endpoint.Authentication) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, &cachedTokenSource{
+		ctx:       ctx,
+		connector: c,
+		auth:      auth,
+		cache:     c.tokenCache,
+		authorize: true,
+	})
+}
+
 // Authorize Get an OAuth access token
 func (c *Connector) Authorize(auth *endpoint.Authentication) (token *oauth2.Token, err error) {
+	return c.AuthorizeContext(context.Background(), auth)
+}
+
+// AuthorizeContext is Authorize with a context that cancels its requests to the platform
+func (c *Connector) AuthorizeContext(ctx context.Context, auth *endpoint.Authentication) (token *oauth2.Token, err error) {
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("%w: %s", verror.AuthError, err)
@@ -145,7 +186,7 @@ func (c *Connector) Authorize(auth *endpoint.Authentication) (token *oauth2.Toke
 			}
 		}
 
-		token, err = config.Token(context.Background())
+		token, err = config.Token(ctx)
 		if err != nil {
 			zap.L().Error(failureMsg, fieldPlatform, zap.Error(err))
 			return token, err
@@ -173,7 +214,7 @@ func (c *Connector) Authorize(auth *endpoint.Authentication) (token *oauth2.Toke
 			},
 		}
 
-		token, err = config.PasswordCredentialsToken(context.Background(), auth.User, auth.Password)
+		token, err = config.PasswordCredentialsToken(ctx, auth.User, auth.Password)
 		if err != nil {
 			zap.L().Error(failureMsg, fieldPlatform, zap.Error(err))
 			return token, err
@@ -187,7 +228,7 @@ func (c *Connector) Authorize(auth *endpoint.Authentication) (token *oauth2.Toke
 	if auth.IdentityProvider.DeviceURL != "" {
 		zap.L().Info("authorizing using device flow", fieldPlatform)
 
-		token, err = c.getDeviceAccessToken(auth)
+		token, err = c.getDeviceAccessToken(ctx, auth)
 		if err != nil {
 			zap.L().Error(failureMsg, fieldPlatform, zap.Error(err))
 			return token, err
@@ -204,6 +245,11 @@ func (c *Connector) Authorize(auth *endpoint.Authentication) (token *oauth2.Toke
 
 // SynchronousRequestCertificate It's not supported yet in VaaS
 func (c *Connector) SynchronousRequestCertificate(req *certificate.Request) (certificates *certificate.PEMCollection, err error) {
+	return c.SynchronousRequestCertificateContext(context.Background(), req)
+}
+
+// SynchronousRequestCertificateContext is SynchronousRequestCertificate with a context that cancels its requests to the platform
+func (c *Connector) SynchronousRequestCertificateContext(ctx context.Context, req *certificate.Request) (certificates *certificate.PEMCollection, err error) {
 
 	zap.L().Info("requesting certificate", zap.String("cn", req.Subject.CommonName), fieldPlatform)
 	//creating the request object
@@ -214,7 +260,7 @@ func (c *Connector) SynchronousRequestCertificate(req *certificate.Request) (cer
 	}
 
 	zap.L().Info("sending HTTP request", fieldPlatform)
-	statusCode, status, body, err := c.request("POST", c.getCertificateRequestUrl(req), certReq)
+	statusCode, status, body, err := c.request(ctx, "POST", c.getCertificateRequestUrl(req), certReq)
 	if err != nil {
 		zap.L().Error("HTTP request failed", fieldPlatform, zap.Error(err))
 		return nil, err
@@ -364,71 +410,156 @@ func (e *ErrCertNotFound) Unwrap() error {
 }
 
 func (c *Connector) Ping() (err error) {
+	return c.PingContext(context.Background())
+}
+
+// PingContext is Ping with a context that cancels its requests to the platform
+func (c *Connector) PingContext(ctx context.Context) (err error) {
 	panic("operation is not supported yet")
 }
 
 func (c *Connector) RetrieveSystemVersion() (string, error) {
+	return c.RetrieveSystemVersionContext(context.Background())
+}
+
+// RetrieveSystemVersionContext is RetrieveSystemVersion with a context that cancels its requests to the platform
+func (c *Connector) RetrieveSystemVersionContext(ctx context.Context) (string, error) {
 	panic("operation is not supported yet")
 }
 
 // RequestCertificate submits the CSR to the Venafi Firefly API for processing
-func (c *Connector) RequestCertificate(_ *certificate.Request) (requestID string, err error) {
+func (c *Connector) RequestCertificate(req *certificate.Request) (requestID string, err error) {
+	return c.RequestCertificateContext(context.Background(), req)
+}
+
+// RequestCertificateContext is RequestCertificate with a context that cancels its requests to the platform
+func (c *Connector) RequestCertificateContext(_ context.Context, _ *certificate.Request) (requestID string, err error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) IsCSRServiceGenerated(_ *certificate.Request) (bool, error) {
+func (c *Connector) IsCSRServiceGenerated(req *certificate.Request) (bool, error) {
+	return c.IsCSRServiceGeneratedContext(context.Background(), req)
+}
+
+// IsCSRServiceGeneratedContext is IsCSRServiceGenerated with a context that cancels its requests to the platform
+func (c *Connector) IsCSRServiceGeneratedContext(_ context.Context, _ *certificate.Request) (bool, error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) RetrieveSshConfig(_ *certificate.SshCaTemplateRequest) (*certificate.SshConfig, error) {
+func (c *Connector) RetrieveSshConfig(ca *certificate.SshCaTemplateRequest) (*certificate.SshConfig, error) {
+	return c.RetrieveSshConfigContext(context.Background(), ca)
+}
+
+// RetrieveSshConfigContext is RetrieveSshConfig with a context that cancels its requests to the platform
+func (c *Connector) RetrieveSshConfigContext(_ context.Context, _ *certificate.SshCaTemplateRequest) (*certificate.SshConfig, error) {
 	panic("operation is not supported yet")
 }
 
 func (c *Connector) RetrieveAvailableSSHTemplates() (response []certificate.SshAvaliableTemplate, err error) {
+	return c.RetrieveAvailableSSHTemplatesContext(context.Background())
+}
+
+// RetrieveAvailableSSHTemplatesContext is RetrieveAvailableSSHTemplates with a context that cancels its requests to the platform
+func (c *Connector) RetrieveAvailableSSHTemplatesContext(ctx context.Context) (response []certificate.SshAvaliableTemplate, err error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) ResetCertificate(_ *certificate.Request, _ bool) (err error) {
+func (c *Connector) ResetCertificate(req *certificate.Request, restart bool) (err error) {
+	return c.ResetCertificateContext(context.Background(), req, restart)
+}
+
+// ResetCertificateContext is ResetCertificate with a context that cancels its requests to the platform
+func (c *Connector) ResetCertificateContext(_ context.Context, _ *certificate.Request, _ bool) (err error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) GetPolicy(_ string) (*policy.PolicySpecification, error) {
+func (c *Connector) GetPolicy(name string) (*policy.PolicySpecification, error) {
+	return c.GetPolicyContext(context.Background(), name)
+}
+
+// GetPolicyContext is GetPolicy with a context that cancels its requests to the platform
+func (c *Connector) GetPolicyContext(_ context.Context, _ string) (*policy.PolicySpecification, error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) SetPolicy(_ string, _ *policy.PolicySpecification) (string, error) {
+func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (string, error) {
+	return c.SetPolicyContext(context.Background(), name, ps)
+}
+
+// SetPolicyContext is SetPolicy with a context that cancels its requests to the platform
+func (c *Connector) SetPolicyContext(_ context.Context, _ string, _ *policy.PolicySpecification) (string, error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) RetrieveCertificate(_ *certificate.Request) (certificates *certificate.PEMCollection, err error) {
+func (c *Connector) RetrieveCertificate(req *certificate.Request) (certificates *certificate.PEMCollection, err error) {
+	return c.RetrieveCertificateContext(context.Background(), req)
+}
+
+// RetrieveCertificateContext is RetrieveCertificate with a context that cancels its requests to the platform
+func (c *Connector) RetrieveCertificateContext(_ context.Context, _ *certificate.Request) (certificates *certificate.PEMCollection, err error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) RenewCertificate(_ *certificate.RenewalRequest) (requestID string, err error) {
+func (c *Connector) RenewCertificate(renewReq *certificate.RenewalRequest) (requestID string, err error) {
+	return c.RenewCertificateContext(context.Background(), renewReq)
+}
+
+// RenewCertificateContext is RenewCertificate with a context that cancels its requests to the platform
+func (c *Connector) RenewCertificateContext(_ context.Context, _ *certificate.RenewalRequest) (requestID string, err error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) RevokeCertificate(_ *certificate.RevocationRequest) (err error) {
+func (c *Connector) RevokeCertificate(revReq *certificate.RevocationRequest) (err error) {
+	return c.RevokeCertificateContext(context.Background(), revReq)
+}
+
+// RevokeCertificateContext is RevokeCertificate with a context that cancels its requests to the platform
+func (c *Connector) RevokeCertificateContext(_ context.Context, _ *certificate.RevocationRequest) (err error) {
 	panic("operation is not supported yet")
 }
 
 func (c *Connector) ReadPolicyConfiguration() (policy *endpoint.Policy, err error) {
+	return c.ReadPolicyConfigurationContext(context.Background())
+}
+
+// ReadPolicyConfigurationContext is ReadPolicyConfiguration with a context that cancels its requests to the platform
+func (c *Connector) ReadPolicyConfigurationContext(ctx context.Context) (policy *endpoint.Policy, err error) {
 	panic("operation is not supported yet")
 }
 
 func (c *Connector) ReadZoneConfiguration() (config *endpoint.ZoneConfiguration, err error) {
+	return c.ReadZoneConfigurationContext(context.Background())
+}
+
+// ReadZoneConfigurationContext is ReadZoneConfiguration with a context that cancels its requests to the platform
+func (c *Connector) ReadZoneConfigurationContext(ctx context.Context) (config *endpoint.ZoneConfiguration, err error) {
 	return nil, nil
 }
 
-func (c *Connector) ImportCertificate(_ *certificate.ImportRequest) (*certificate.ImportResponse, error) {
+func (c *Connector) ImportCertificate(req *certificate.ImportRequest) (*certificate.ImportResponse, error) {
+	return c.ImportCertificateContext(context.Background(), req)
+}
+
+// ImportCertificateContext is ImportCertificate with a context that cancels its requests to the platform
+func (c *Connector) ImportCertificateContext(_ context.Context, _ *certificate.ImportRequest) (*certificate.ImportResponse, error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) SearchCertificates(_ *certificate.SearchRequest) (*certificate.CertSearchResponse, error) {
+func (c *Connector) SearchCertificates(req *certificate.SearchRequest) (*certificate.CertSearchResponse, error) {
+	return c.SearchCertificatesContext(context.Background(), req)
+}
+
+// SearchCertificatesContext is SearchCertificates with a context that cancels its requests to the platform
+func (c *Connector) SearchCertificatesContext(_ context.Context, _ *certificate.SearchRequest) (*certificate.CertSearchResponse, error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) SearchCertificate(_ string, _ string, _ *certificate.Sans, _ time.Duration) (certificateInfo *certificate.CertificateInfo, err error) {
+func (c *Connector) SearchCertificate(zone string, cn string, sans *certificate.Sans, certMinTimeLeft time.Duration) (certificateInfo *certificate.CertificateInfo, err error) {
+	return c.SearchCertificateContext(context.Background(), zone, cn, sans, certMinTimeLeft)
+}
+
+// SearchCertificateContext is SearchCertificate with a context that cancels its requests to the platform
+func (c *Connector) SearchCertificateContext(_ context.Context, _ string, _ string, _ *certificate.Sans, _ time.Duration) (certificateInfo *certificate.CertificateInfo, err error) {
 	panic("operation is not supported yet")
 }
 
@@ -436,34 +567,80 @@ func (c *Connector) SetHTTPClient(client *http.Client) {
 	c.client = client
 }
 
-func (c *Connector) WriteLog(_ *endpoint.LogRequest) error {
+// SetRetryPolicy sets how the requests to the platform are retried and rate limited. It applies to the HTTP client
+// built by the connector, not to one set with SetHTTPClient
+func (c *Connector) SetRetryPolicy(policy httputils.RetryPolicy) {
+	c.retryPolicy = &policy
+}
+
+func (c *Connector) WriteLog(logReq *endpoint.LogRequest) error {
+	return c.WriteLogContext(context.Background(), logReq)
+}
+
+// WriteLogContext is WriteLog with a context that cancels its requests to the platform
+func (c *Connector) WriteLogContext(_ context.Context, _ *endpoint.LogRequest) error {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) ListCertificates(_ endpoint.Filter) ([]certificate.CertificateInfo, error) {
+func (c *Connector) ListCertificates(filter endpoint.Filter) ([]certificate.CertificateInfo, error) {
+	return c.ListCertificatesContext(context.Background(), filter)
+}
+
+// ListCertificatesContext is ListCertificates with a context that cancels its requests to the platform
+func (c *Connector) ListCertificatesContext(_ context.Context, _ endpoint.Filter) ([]certificate.CertificateInfo, error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) GetZonesByParent(_ string) ([]string, error) {
+func (c *Connector) GetZonesByParent(parent string) ([]string, error) {
+	return c.GetZonesByParentContext(context.Background(), parent)
+}
+
+// GetZonesByParentContext is GetZonesByParent with a context that cancels its requests to the platform
+func (c *Connector) GetZonesByParentContext(_ context.Context, _ string) ([]string, error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) RequestSSHCertificate(_ *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
+func (c *Connector) RequestSSHCertificate(req *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
+	return c.RequestSSHCertificateContext(context.Background(), req)
+}
+
+// RequestSSHCertificateContext is RequestSSHCertificate with a context that cancels its requests to the platform
+func (c *Connector) RequestSSHCertificateContext(_ context.Context, _ *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) RetrieveSSHCertificate(_ *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
+func (c *Connector) RetrieveSSHCertificate(req *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
+	return c.RetrieveSSHCertificateContext(context.Background(), req)
+}
+
+// RetrieveSSHCertificateContext is RetrieveSSHCertificate with a context that cancels its requests to the platform
+func (c *Connector) RetrieveSSHCertificateContext(_ context.Context, _ *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) ProvisionCertificate(_ *domain.ProvisioningRequest, _ *domain.ProvisioningOptions) (*domain.ProvisioningMetadata, error) {
+func (c *Connector) ProvisionCertificate(req *domain.ProvisioningRequest, options *domain.ProvisioningOptions) (*domain.ProvisioningMetadata, error) {
+	return c.ProvisionCertificateContext(context.Background(), req, options)
+}
+
+// ProvisionCertificateContext is ProvisionCertificate with a context that cancels its requests to the platform
+func (c *Connector) ProvisionCertificateContext(_ context.Context, _ *domain.ProvisioningRequest, _ *domain.ProvisioningOptions) (*domain.ProvisioningMetadata, error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) RetrieveCertificateMetaData(_ string) (*certificate.CertificateMetaData, error) {
+func (c *Connector) RetrieveCertificateMetaData(dn string) (*certificate.CertificateMetaData, error) {
+	return c.RetrieveCertificateMetaDataContext(context.Background(), dn)
+}
+
+// RetrieveCertificateMetaDataContext is RetrieveCertificateMetaData with a context that cancels its requests to the platform
+func (c *Connector) RetrieveCertificateMetaDataContext(_ context.Context, _ string) (*certificate.CertificateMetaData, error) {
 	panic("operation is not supported yet")
 }
 
-func (c *Connector) RetireCertificate(_ *certificate.RetireRequest) error {
+func (c *Connector) RetireCertificate(req *certificate.RetireRequest) error {
+	return c.RetireCertificateContext(context.Background(), req)
+}
+
+// RetireCertificateContext is RetireCertificate with a context that cancels its requests to the platform
+func (c *Connector) RetireCertificateContext(_ context.Context, _ *certificate.RetireRequest) error {
 	panic("operation is not supported yet")
 }
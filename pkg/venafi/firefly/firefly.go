@@ -18,22 +18,22 @@ package firefly
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
-	"net"
 	"net/http"
 	"net/url"
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/go-http-utils/headers"
+	"golang.org/x/oauth2"
 
 	"github.com/Venafi/vcert/v5/pkg/certificate"
 	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/httputils"
 	"github.com/Venafi/vcert/v5/pkg/verror"
 )
 
@@ -83,7 +83,12 @@ type certificateRequestResponse struct {
 // GenerateRequest should generate a CertificateRequest based on the zone configuration when the csrOrigin was
 // set to LocalGeneratedCSR but given that is not supported by Firefly yet, then it's only validating if the CSR
 // was provided when the csrOrigin was set to UserProvidedCSR
-func (c *Connector) GenerateRequest(_ *endpoint.ZoneConfiguration, req *certificate.Request) (err error) {
+func (c *Connector) GenerateRequest(config *endpoint.ZoneConfiguration, req *certificate.Request) (err error) {
+	return c.GenerateRequestContext(context.Background(), config, req)
+}
+
+// GenerateRequestContext is GenerateRequest with a context that cancels its requests to the platform
+func (c *Connector) GenerateRequestContext(ctx context.Context, _ *endpoint.ZoneConfiguration, req *certificate.Request) (err error) {
 	switch req.CsrOrigin {
 	case certificate.LocalGeneratedCSR:
 		return fmt.Errorf("local generated CSR it's not supported by Firefly yet")
@@ -100,7 +105,7 @@ func (c *Connector) GenerateRequest(_ *endpoint.ZoneConfiguration, req *certific
 	}
 }
 
-func (c *Connector) request(method string, resource urlResource, data interface{}) (statusCode int, statusText string, body []byte, err error) {
+func (c *Connector) request(ctx context.Context, method string, resource urlResource, data interface{}) (statusCode int, statusText string, body []byte, err error) {
 
 	resourceUrl := string(resource)
 
@@ -131,11 +136,23 @@ func (c *Connector) request(method string, resource urlResource, data interface{
 		}
 	}
 
-	r, _ := http.NewRequest(method, resourceUrl, payload)
+	r, err := http.NewRequestWithContext(ctx, method, resourceUrl, payload)
+	if err != nil {
+		return
+	}
 	r.Close = true
 	r.Header.Set(headers.UserAgent, c.userAgent)
-	if c.accessToken != "" {
-		r.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.accessToken))
+	accessToken := c.accessToken
+	if c.tokenSource != nil {
+		var token *oauth2.Token
+		token, err = c.tokenSource.Token()
+		if err != nil {
+			return
+		}
+		accessToken = token.AccessToken
+	}
+	if accessToken != "" {
+		r.Header.Add("Authorization", fmt.Sprintf("Bearer %s", accessToken))
 	}
 	r.Header.Add("content-type", contentType)
 	r.Header.Add("cache-control", "no-cache")
@@ -177,36 +194,7 @@ func (c *Connector) getHTTPClient() *http.Client {
 	if c.client != nil {
 		return c.client
 	}
-	var netTransport = &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-			DualStack: true,
-		}).DialContext,
-		MaxIdleConns:          100,
-		IdleConnTimeout:       90 * time.Second,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-	}
-	tlsConfig := http.DefaultTransport.(*http.Transport).TLSClientConfig
-	/* #nosec */
-	if c.trust != nil {
-		if tlsConfig == nil {
-			tlsConfig = &tls.Config{
-				MinVersion: tls.VersionTLS12,
-			}
-		} else {
-			tlsConfig = tlsConfig.Clone()
-		}
-		tlsConfig.RootCAs = c.trust
-	}
-
-	netTransport.TLSClientConfig = tlsConfig
-	c.client = &http.Client{
-		Timeout:   time.Second * 30,
-		Transport: netTransport,
-	}
+	c.client = httputils.NewClient(c.trust, c.retryPolicy)
 	return c.client
 }
 
@@ -26,11 +26,15 @@ const (
 	TestingScope                 = "my_scope"
 	TestingAudience              = "my_audience"
 	TestingAccessToken           = "my_access_token"
+	TestingRefreshToken          = "my_refresh_token"
+	TestingRefreshedAccessToken  = "my_refreshed_access_token"
 )
 
 var (
 	authPendingCount = 0
 	slowDownCount    = 0
+	// accessTokenCount is the number of access tokens issued by the identity provider
+	accessTokenCount = 0
 )
 
 func newIdentityProviderMockServer() *IdentityProviderMockServer {
@@ -71,6 +75,7 @@ type AccessTokenRequest struct {
 	deviceCode   string `json:"device_code"`
 	scope        string `json:"scope"`
 	audience     string `json:"audience,omitempty"`
+	refreshToken string
 }
 
 type AccessTokenResponse struct {
@@ -99,10 +104,14 @@ func processAccessTokenRequest(w http.ResponseWriter, r *http.Request) {
 	accessTokenResponse := AccessTokenResponse{
 		TokenType:    "Bearer",
 		AccessToken:  TestingAccessToken,
-		RefreshToken: "",
+		RefreshToken: TestingRefreshToken,
 		ExpiresIn:    120, //seconds
 		Scope:        TestingScope,
 	}
+	if accessTokenRequest.grantType == "refresh_token" {
+		accessTokenResponse.AccessToken = TestingRefreshedAccessToken
+	}
+	accessTokenCount++
 
 	jsonResp, err := json.Marshal(accessTokenResponse)
 	if err != nil {
@@ -179,6 +188,11 @@ func validateAccessTokenRequest(w http.ResponseWriter, accessTokenRequest Access
 			writeError(w, http.StatusUnauthorized, "Status Unauthorized Request", "The scope is not valid")
 			return false
 		}
+	case "refresh_token":
+		if accessTokenRequest.refreshToken != TestingRefreshToken {
+			writeError(w, http.StatusBadRequest, "invalid_grant", "The refresh token is not valid")
+			return false
+		}
 	case "urn:ietf:params:oauth:grant-type:device_code":
 		if accessTokenRequest.deviceCode == "" {
 			writeError(w, http.StatusBadRequest, "Status Bad Request", "The device_code is missing")
@@ -278,6 +292,8 @@ func parseAccessTokenRequest(r *http.Request) (accessTokenRequest AccessTokenReq
 			accessTokenRequest.scope = value[0]
 		case "audience":
 			accessTokenRequest.audience = value[0]
+		case "refresh_token":
+			accessTokenRequest.refreshToken = value[0]
 		}
 	}
 
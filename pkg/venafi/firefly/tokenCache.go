@@ -0,0 +1,237 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package firefly
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"golang.org/x/oauth2"
+
+	"github.com/Venafi/vcert/v5/pkg/endpoint"
+)
+
+const (
+	// tokenCacheFileMode is the mode of the token cache file, which holds the refresh tokens
+	tokenCacheFileMode os.FileMode = 0600
+	tokenCacheDirMode  os.FileMode = 0700
+)
+
+// TokenCache is a file that stores the OAuth 2.0 tokens of the identity providers, so that the authorization flow
+// doesn't run again while a token is valid or can be refreshed. The tokens are keyed by the token URL of the
+// identity provider, the client id and the audience
+type TokenCache struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewTokenCache returns a token cache stored in the file at path. The file is created on the first Put
+func NewTokenCache(path string) *TokenCache {
+	return &TokenCache{path: path}
+}
+
+// DefaultTokenCachePath returns the path of the token cache in the cache directory of the user,
+// e.g. ~/.cache/vcert/firefly-tokens.json on Linux
+func DefaultTokenCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the cache directory: %w", err)
+	}
+	return filepath.Join(dir, "vcert", "firefly-tokens.json"), nil
+}
+
+// Path returns the path of the file of the cache
+func (tc *TokenCache) Path() string {
+	return tc.path
+}
+
+// Get returns the cached token of auth, or nil when there is none
+func (tc *TokenCache) Get(auth *endpoint.Authentication) (*oauth2.Token, error) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	tokens, err := tc.read()
+	if err != nil {
+		return nil, err
+	}
+	return tokens[tokenCacheKey(auth)], nil
+}
+
+// Put stores the token of auth, replacing the previous one
+func (tc *TokenCache) Put(auth *endpoint.Authentication, token *oauth2.Token) error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	tokens, err := tc.read()
+	if err != nil {
+		return err
+	}
+	if tokens == nil {
+		tokens = make(map[string]*oauth2.Token)
+	}
+	tokens[tokenCacheKey(auth)] = token
+	return tc.write(tokens)
+}
+
+// Clear removes the file of the cache along with all its tokens
+func (tc *TokenCache) Clear() error {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	err := os.Remove(tc.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove token cache %s: %w", tc.path, err)
+	}
+	return nil
+}
+
+func (tc *TokenCache) read() (map[string]*oauth2.Token, error) {
+	data, err := os.ReadFile(tc.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token cache %s: %w", tc.path, err)
+	}
+
+	var tokens map[string]*oauth2.Token
+	err = json.Unmarshal(data, &tokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse token cache %s: %w", tc.path, err)
+	}
+	return tokens, nil
+}
+
+// write replaces the file of the cache through a rename, so that a concurrent read never gets a partial file
+func (tc *TokenCache) write(tokens map[string]*oauth2.Token) error {
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode token cache: %w", err)
+	}
+
+	dir := filepath.Dir(tc.path)
+	err = os.MkdirAll(dir, tokenCacheDirMode)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, fmt.Sprintf(".%s.*.tmp", filepath.Base(tc.path)))
+	if err != nil {
+		return fmt.Errorf("failed to write token cache %s: %w", tc.path, err)
+	}
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(tokenCacheFileMode)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), tc.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write token cache %s: %w", tc.path, err)
+	}
+	return nil
+}
+
+func tokenCacheKey(auth *endpoint.Authentication) string {
+	var tokenURL, audience string
+	if auth.IdentityProvider != nil {
+		tokenURL = auth.IdentityProvider.TokenURL
+		audience = auth.IdentityProvider.Audience
+	}
+	return strings.Join([]string{tokenURL, auth.ClientId, audience}, " ")
+}
+
+// cachedTokenSource returns the cached token while it is valid, and refreshes it with its refresh token once it
+// expired. When authorize is true and the token cannot be refreshed, the authorization flow of auth is run.
+// The new tokens are stored in the cache, which may be nil
+type cachedTokenSource struct {
+	ctx       context.Context
+	connector *Connector
+	auth      *endpoint.Authentication
+	cache     *TokenCache
+	authorize bool
+	token     *oauth2.Token
+}
+
+func (s *cachedTokenSource) Token() (*oauth2.Token, error) {
+	token := s.token
+	if s.cache != nil {
+		cached, err := s.cache.Get(s.auth)
+		if err != nil {
+			zap.L().Warn("ignoring the token cache", fieldPlatform, zap.Error(err))
+		} else if cached != nil {
+			token = cached
+		}
+	}
+	if token.Valid() {
+		return token, nil
+	}
+
+	if token != nil && token.RefreshToken != "" {
+		refreshed, err := s.refresh(token)
+		if err == nil {
+			return s.store(refreshed), nil
+		}
+		if !s.authorize {
+			return nil, fmt.Errorf("failed to refresh the access token: %w", err)
+		}
+		zap.L().Warn("failed to refresh the access token", fieldPlatform, zap.Error(err))
+	} else if !s.authorize {
+		return nil, fmt.Errorf("the access token expired and there is no refresh token to renew it")
+	}
+
+	token, err := s.connector.AuthorizeContext(s.ctx, s.auth)
+	if err != nil {
+		return nil, err
+	}
+	return s.store(token), nil
+}
+
+// store keeps the token for the next calls and stores it in the cache
+func (s *cachedTokenSource) store(token *oauth2.Token) *oauth2.Token {
+	s.token = token
+	if s.cache != nil {
+		err := s.cache.Put(s.auth, token)
+		if err != nil {
+			zap.L().Warn("failed to cache the access token", fieldPlatform, zap.Error(err))
+		}
+	}
+	return token
+}
+
+func (s *cachedTokenSource) refresh(token *oauth2.Token) (*oauth2.Token, error) {
+	zap.L().Info("refreshing the access token", fieldPlatform)
+	config := oauth2.Config{
+		ClientID:     s.auth.ClientId,
+		ClientSecret: s.auth.ClientSecret,
+		Endpoint: oauth2.Endpoint{
+			TokenURL: s.auth.IdentityProvider.TokenURL,
+		},
+	}
+	expired := &oauth2.Token{RefreshToken: token.RefreshToken}
+	return config.TokenSource(s.ctx, expired).Token()
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package firefly
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func (s *ConnectorSuite) newCachedConnector(cache *TokenCache) *Connector {
+	fireflyConnector, err := NewConnector(s.fireflyServer.serverURL, "", false, nil)
+	require.NoError(s.T(), err)
+	fireflyConnector.SetTokenCache(cache)
+	return fireflyConnector
+}
+
+func (s *ConnectorSuite) TestTokenCache() {
+	s.Run("CachesToken", func() {
+		cache := NewTokenCache(filepath.Join(s.T().TempDir(), "vcert", "tokens.json"))
+		auth := s.createCredFlowAuth()
+		count := accessTokenCount
+
+		token, err := s.newCachedConnector(cache).TokenSource(context.Background(), auth).Token()
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), TestingAccessToken, token.AccessToken)
+		assert.Equal(s.T(), count+1, accessTokenCount)
+
+		info, err := os.Stat(cache.Path())
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), tokenCacheFileMode, info.Mode().Perm())
+
+		// the cached token is returned without authorizing again
+		token, err = s.newCachedConnector(cache).TokenSource(context.Background(), auth).Token()
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), TestingAccessToken, token.AccessToken)
+		assert.Equal(s.T(), count+1, accessTokenCount)
+
+		// the tokens are keyed by the audience as well
+		other := s.createCredFlowAuth()
+		other.IdentityProvider.Audience = ""
+		cached, err := cache.Get(other)
+		require.NoError(s.T(), err)
+		assert.Nil(s.T(), cached)
+	})
+
+	s.Run("RefreshesExpiredToken", func() {
+		cache := NewTokenCache(filepath.Join(s.T().TempDir(), "tokens.json"))
+		auth := s.createDevFlowAuth()
+		expired := &oauth2.Token{AccessToken: "expired", RefreshToken: TestingRefreshToken, Expiry: time.Now().Add(-time.Minute)}
+		require.NoError(s.T(), cache.Put(auth, expired))
+
+		token, err := s.newCachedConnector(cache).TokenSource(context.Background(), auth).Token()
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), TestingRefreshedAccessToken, token.AccessToken)
+
+		cached, err := cache.Get(auth)
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), TestingRefreshedAccessToken, cached.AccessToken)
+		assert.True(s.T(), cached.Valid())
+	})
+
+	s.Run("AuthorizesWhenRefreshFails", func() {
+		cache := NewTokenCache(filepath.Join(s.T().TempDir(), "tokens.json"))
+		auth := s.createPasswordFlowAuth()
+		expired := &oauth2.Token{AccessToken: "expired", RefreshToken: "revoked", Expiry: time.Now().Add(-time.Minute)}
+		require.NoError(s.T(), cache.Put(auth, expired))
+
+		token, err := s.newCachedConnector(cache).TokenSource(context.Background(), auth).Token()
+		require.NoError(s.T(), err)
+		assert.Equal(s.T(), TestingAccessToken, token.AccessToken)
+	})
+
+	s.Run("AuthenticateUsesCache", func() {
+		cache := NewTokenCache(filepath.Join(s.T().TempDir(), "tokens.json"))
+		auth := s.createCredFlowAuth()
+		require.NoError(s.T(), cache.Put(auth, &oauth2.Token{AccessToken: "cached", Expiry: time.Now().Add(time.Hour)}))
+
+		fireflyConnector := s.newCachedConnector(cache)
+		require.NoError(s.T(), fireflyConnector.Authenticate(auth))
+		assert.Equal(s.T(), "cached", fireflyConnector.accessToken)
+
+		// an expired token is refreshed rather than authorized again
+		require.NoError(s.T(), cache.Put(auth, &oauth2.Token{AccessToken: "cached", RefreshToken: TestingRefreshToken, Expiry: time.Now().Add(-time.Minute)}))
+		auth.AccessToken = ""
+		count := accessTokenCount
+		fireflyConnector = s.newCachedConnector(cache)
+		require.NoError(s.T(), fireflyConnector.Authenticate(auth))
+		assert.Equal(s.T(), TestingRefreshedAccessToken, fireflyConnector.accessToken)
+		assert.Equal(s.T(), count+1, accessTokenCount)
+
+		// once authenticated, the authorization flow doesn't run again
+		require.NoError(s.T(), cache.Put(auth, &oauth2.Token{AccessToken: "cached", Expiry: time.Now().Add(-time.Minute)}))
+		source := &cachedTokenSource{ctx: context.Background(), connector: fireflyConnector, auth: auth, cache: cache}
+		_, err := source.Token()
+		assert.ErrorContains(s.T(), err, "there is no refresh token")
+	})
+
+	s.Run("Clear", func() {
+		cache := NewTokenCache(filepath.Join(s.T().TempDir(), "tokens.json"))
+		require.NoError(s.T(), cache.Clear(), "clearing a missing cache is not an error")
+		auth := s.createCredFlowAuth()
+		require.NoError(s.T(), cache.Put(auth, &oauth2.Token{AccessToken: "cached"}))
+
+		require.NoError(s.T(), cache.Clear())
+		assert.NoFileExists(s.T(), cache.Path())
+		cached, err := cache.Get(auth)
+		require.NoError(s.T(), err)
+		assert.Nil(s.T(), cached)
+	})
+}
@@ -17,6 +17,7 @@
 package tpp
 
 import (
+	"context"
 	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
@@ -34,6 +35,7 @@ import (
 	"github.com/Venafi/vcert/v5/pkg/certificate"
 	"github.com/Venafi/vcert/v5/pkg/domain"
 	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/httputils"
 	"github.com/Venafi/vcert/v5/pkg/policy"
 	"github.com/Venafi/vcert/v5/pkg/util"
 	"github.com/Venafi/vcert/v5/pkg/verror"
@@ -49,19 +51,35 @@ type Connector struct {
 	trust       *x509.CertPool
 	zone        string
 	client      *http.Client
+	retryPolicy *httputils.RetryPolicy
 	userAgent   string
 }
 
 func (c *Connector) IsCSRServiceGenerated(req *certificate.Request) (bool, error) {
+	return c.IsCSRServiceGeneratedContext(context.Background(), req)
+}
+
+// IsCSRServiceGeneratedContext is IsCSRServiceGenerated with a context that cancels its requests to the platform
+func (c *Connector) IsCSRServiceGeneratedContext(ctx context.Context, req *certificate.Request) (bool, error) {
 	panic("operation is not supported yet")
 }
 
 func (c *Connector) RetrieveSshConfig(ca *certificate.SshCaTemplateRequest) (*certificate.SshConfig, error) {
-	return RetrieveSshConfig(c, ca)
+	return c.RetrieveSshConfigContext(context.Background(), ca)
+}
+
+// RetrieveSshConfigContext is RetrieveSshConfig with a context that cancels its requests to the platform
+func (c *Connector) RetrieveSshConfigContext(ctx context.Context, ca *certificate.SshCaTemplateRequest) (*certificate.SshConfig, error) {
+	return RetrieveSshConfigContext(ctx, c, ca)
 }
 
 func (c *Connector) RetrieveAvailableSSHTemplates() (response []certificate.SshAvaliableTemplate, err error) {
-	return GetAvailableSshTemplates(c)
+	return c.RetrieveAvailableSSHTemplatesContext(context.Background())
+}
+
+// RetrieveAvailableSSHTemplatesContext is RetrieveAvailableSSHTemplates with a context that cancels its requests to the platform
+func (c *Connector) RetrieveAvailableSSHTemplatesContext(ctx context.Context) (response []certificate.SshAvaliableTemplate, err error) {
+	return GetAvailableSshTemplatesContext(ctx, c)
 }
 
 // NewConnector creates a new TPP Connector object used to communicate with TPP
@@ -105,10 +123,15 @@ func (c *Connector) GetType() endpoint.ConnectorType {
 
 // Ping attempts to connect to the TPP Server WebSDK API and returns an error if it cannot
 func (c *Connector) Ping() (err error) {
+	return c.PingContext(context.Background())
+}
+
+// PingContext is Ping with a context that cancels its requests to the platform
+func (c *Connector) PingContext(ctx context.Context) (err error) {
 
 	//Extended timeout to allow the server to wake up
 	c.getHTTPClient().Timeout = time.Second * 90
-	statusCode, status, _, err := c.request("GET", "vedsdk/", nil)
+	statusCode, status, _, err := c.request(ctx, "GET", "vedsdk/", nil)
 	if err != nil {
 		return
 	}
@@ -120,6 +143,11 @@ func (c *Connector) Ping() (err error) {
 
 // Authenticate authenticates the user to the TPP
 func (c *Connector) Authenticate(auth *endpoint.Authentication) (err error) {
+	return c.AuthenticateContext(context.Background(), auth)
+}
+
+// AuthenticateContext is Authenticate with a context that cancels its requests to the platform
+func (c *Connector) AuthenticateContext(ctx context.Context, auth *endpoint.Authentication) (err error) {
 	defer func() {
 		if err != nil {
 			err = fmt.Errorf("%w: %s", verror.AuthError, err)
@@ -136,7 +164,7 @@ func (c *Connector) Authenticate(auth *endpoint.Authentication) (err error) {
 
 	if auth.User != "" && auth.Password != "" {
 		data := authorizeResquest{Username: auth.User, Password: auth.Password}
-		result, err := processAuthData(c, urlResourceAuthorize, data)
+		result, err := processAuthData(ctx, c, urlResourceAuthorize, data)
 		if err != nil {
 			return err
 		}
@@ -145,7 +173,7 @@ func (c *Connector) Authenticate(auth *endpoint.Authentication) (err error) {
 		c.apiKey = resp.APIKey
 
 		if c.client != nil {
-			c.Identity, err = c.retrieveSelfIdentity()
+			c.Identity, err = c.retrieveSelfIdentity(ctx)
 			if err != nil {
 				return err
 			}
@@ -154,7 +182,7 @@ func (c *Connector) Authenticate(auth *endpoint.Authentication) (err error) {
 
 	} else if auth.RefreshToken != "" {
 		data := oauthRefreshAccessTokenRequest{Client_id: auth.ClientId, Refresh_token: auth.RefreshToken}
-		result, err := processAuthData(c, urlResourceRefreshAccessToken, data)
+		result, err := processAuthData(ctx, c, urlResourceRefreshAccessToken, data)
 		if err != nil {
 			return err
 		}
@@ -163,7 +191,7 @@ func (c *Connector) Authenticate(auth *endpoint.Authentication) (err error) {
 		c.accessToken = resp.Access_token
 		auth.RefreshToken = resp.Refresh_token
 		if c.client != nil {
-			c.Identity, err = c.retrieveSelfIdentity()
+			c.Identity, err = c.retrieveSelfIdentity(ctx)
 			if err != nil {
 				return err
 			}
@@ -174,7 +202,7 @@ func (c *Connector) Authenticate(auth *endpoint.Authentication) (err error) {
 		c.accessToken = auth.AccessToken
 
 		if c.client != nil {
-			c.Identity, err = c.retrieveSelfIdentity()
+			c.Identity, err = c.retrieveSelfIdentity(ctx)
 			if err != nil {
 				return err
 			}
@@ -186,6 +214,11 @@ func (c *Connector) Authenticate(auth *endpoint.Authentication) (err error) {
 
 // GetRefreshToken Get OAuth refresh and access token
 func (c *Connector) GetRefreshToken(auth *endpoint.Authentication) (resp OauthGetRefreshTokenResponse, err error) {
+	return c.GetRefreshTokenContext(context.Background(), auth)
+}
+
+// GetRefreshTokenContext is GetRefreshToken with a context that cancels its requests to the platform
+func (c *Connector) GetRefreshTokenContext(ctx context.Context, auth *endpoint.Authentication) (resp OauthGetRefreshTokenResponse, err error) {
 
 	if auth == nil {
 		return resp, fmt.Errorf("failed to authenticate: missing credentials")
@@ -200,7 +233,7 @@ func (c *Connector) GetRefreshToken(auth *endpoint.Authentication) (resp OauthGe
 
 	if auth.User != "" && auth.Password != "" {
 		data := oauthGetRefreshTokenRequest{Username: auth.User, Password: auth.Password, Scope: auth.Scope, Client_id: auth.ClientId}
-		result, err := processAuthData(c, urlResourceAuthorizeOAuth, data)
+		result, err := processAuthData(ctx, c, urlResourceAuthorizeOAuth, data)
 		if err != nil {
 			return resp, err
 		}
@@ -209,7 +242,7 @@ func (c *Connector) GetRefreshToken(auth *endpoint.Authentication) (resp OauthGe
 
 	} else if auth.ClientPKCS12 {
 		data := oauthCertificateTokenRequest{Client_id: auth.ClientId, Scope: auth.Scope}
-		result, err := processAuthData(c, urlResourceAuthorizeCertificate, data)
+		result, err := processAuthData(ctx, c, urlResourceAuthorizeCertificate, data)
 		if err != nil {
 			return resp, err
 		}
@@ -223,6 +256,11 @@ func (c *Connector) GetRefreshToken(auth *endpoint.Authentication) (resp OauthGe
 
 // RefreshAccessToken Refresh OAuth access token
 func (c *Connector) RefreshAccessToken(auth *endpoint.Authentication) (resp OauthRefreshAccessTokenResponse, err error) {
+	return c.RefreshAccessTokenContext(context.Background(), auth)
+}
+
+// RefreshAccessTokenContext is RefreshAccessToken with a context that cancels its requests to the platform
+func (c *Connector) RefreshAccessTokenContext(ctx context.Context, auth *endpoint.Authentication) (resp OauthRefreshAccessTokenResponse, err error) {
 
 	if auth == nil {
 		return resp, fmt.Errorf("failed to authenticate: missing credentials")
@@ -234,7 +272,7 @@ func (c *Connector) RefreshAccessToken(auth *endpoint.Authentication) (resp Oaut
 
 	if auth.RefreshToken != "" {
 		data := oauthRefreshAccessTokenRequest{Client_id: auth.ClientId, Refresh_token: auth.RefreshToken}
-		result, err := processAuthData(c, urlResourceRefreshAccessToken, data)
+		result, err := processAuthData(ctx, c, urlResourceRefreshAccessToken, data)
 		if err != nil {
 			return resp, err
 		}
@@ -247,6 +285,11 @@ func (c *Connector) RefreshAccessToken(auth *endpoint.Authentication) (resp Oaut
 
 // VerifyAccessToken - call to check whether token is valid and, if so, return its properties
 func (c *Connector) VerifyAccessToken(auth *endpoint.Authentication) (resp OauthVerifyTokenResponse, err error) {
+	return c.VerifyAccessTokenContext(context.Background(), auth)
+}
+
+// VerifyAccessTokenContext is VerifyAccessToken with a context that cancels its requests to the platform
+func (c *Connector) VerifyAccessTokenContext(ctx context.Context, auth *endpoint.Authentication) (resp OauthVerifyTokenResponse, err error) {
 
 	if auth == nil {
 		return resp, fmt.Errorf("failed to authenticate: missing credentials")
@@ -254,7 +297,7 @@ func (c *Connector) VerifyAccessToken(auth *endpoint.Authentication) (resp Oauth
 
 	if auth.AccessToken != "" {
 		c.accessToken = auth.AccessToken
-		statusCode, statusText, body, err := c.request("GET", urlResource(urlResourceAuthorizeVerify), nil)
+		statusCode, statusText, body, err := c.request(ctx, "GET", urlResource(urlResourceAuthorizeVerify), nil)
 		if err != nil {
 			return resp, err
 		}
@@ -275,6 +318,11 @@ func (c *Connector) VerifyAccessToken(auth *endpoint.Authentication) (resp Oauth
 
 // RevokeAccessToken - call to revoke token so that it can never be used again
 func (c *Connector) RevokeAccessToken(auth *endpoint.Authentication) (err error) {
+	return c.RevokeAccessTokenContext(context.Background(), auth)
+}
+
+// RevokeAccessTokenContext is RevokeAccessToken with a context that cancels its requests to the platform
+func (c *Connector) RevokeAccessTokenContext(ctx context.Context, auth *endpoint.Authentication) (err error) {
 
 	if auth == nil {
 		return fmt.Errorf("failed to authenticate: missing credentials")
@@ -282,7 +330,7 @@ func (c *Connector) RevokeAccessToken(auth *endpoint.Authentication) (err error)
 
 	if auth.AccessToken != "" {
 		c.accessToken = auth.AccessToken
-		statusCode, statusText, _, err := c.request("GET", urlResource(urlResourceRevokeAccessToken), nil)
+		statusCode, statusText, _, err := c.request(ctx, "GET", urlResource(urlResourceRevokeAccessToken), nil)
 		if err != nil {
 			return err
 		}
@@ -296,8 +344,8 @@ func (c *Connector) RevokeAccessToken(auth *endpoint.Authentication) (err error)
 	return fmt.Errorf("failed to authenticate: missing access token")
 }
 
-func processAuthData(c *Connector, url urlResource, data interface{}) (resp interface{}, err error) {
-	statusCode, status, body, err := c.request("POST", url, data)
+func processAuthData(ctx context.Context, c *Connector, url urlResource, data interface{}) (resp interface{}, err error) {
+	statusCode, status, body, err := c.request(ctx, "POST", url, data)
 	if err != nil {
 		return resp, err
 	}
@@ -342,12 +390,12 @@ func processAuthData(c *Connector, url urlResource, data interface{}) (resp inte
 	return resp, nil
 }
 
-func (c *Connector) isAuthServerReachable() (bool, error) {
+func (c *Connector) isAuthServerReachable(ctx context.Context) (bool, error) {
 	url := urlResource(urlResourceAuthorizeIsAuthServer)
 
 	// Extended timeout to allow the server to wake up
 	c.getHTTPClient().Timeout = time.Second * 90
-	statusCode, statusText, _, err := c.request("GET", url, nil)
+	statusCode, statusText, _, err := c.request(ctx, "GET", url, nil)
 	if err != nil {
 		return false, fmt.Errorf("error while cheking the authentication server. URL: %s; Error: %v", url, err)
 	}
@@ -377,8 +425,8 @@ func wrapAltNames(req *certificate.Request) (items []sanItem) {
 	return items
 }
 
-func prepareLegacyMetadata(c *Connector, metaItems []customField, dn string) ([]guidData, error) {
-	metadataItems, err := c.requestAllMetadataItems(dn)
+func prepareLegacyMetadata(ctx context.Context, c *Connector, metaItems []customField, dn string) ([]guidData, error) {
+	metadataItems, err := c.requestAllMetadataItems(ctx, dn)
 	if nil != err {
 		return nil, err
 	}
@@ -398,8 +446,8 @@ func prepareLegacyMetadata(c *Connector, metaItems []customField, dn string) ([]
 }
 
 // requestAllMetadataItems returns all possible metadata items for a DN
-func (c *Connector) requestAllMetadataItems(dn string) ([]metadataItem, error) {
-	statusCode, status, body, err := c.request("POST", urlResourceAllMetadataGet, metadataGetItemsRequest{dn})
+func (c *Connector) requestAllMetadataItems(ctx context.Context, dn string) ([]metadataItem, error) {
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceAllMetadataGet, metadataGetItemsRequest{dn})
 	if err != nil {
 		return nil, err
 	}
@@ -413,8 +461,8 @@ func (c *Connector) requestAllMetadataItems(dn string) ([]metadataItem, error) {
 }
 
 // requestMetadataItems returns metadata items for a DN that have a value stored
-func (c *Connector) requestMetadataItems(dn string) ([]metadataKeyValueSet, error) {
-	statusCode, status, body, err := c.request("POST", urlResourceMetadataGet, metadataGetItemsRequest{dn})
+func (c *Connector) requestMetadataItems(ctx context.Context, dn string) ([]metadataKeyValueSet, error) {
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceMetadataGet, metadataGetItemsRequest{dn})
 	if err != nil {
 		return nil, err
 	}
@@ -427,11 +475,11 @@ func (c *Connector) requestMetadataItems(dn string) ([]metadataKeyValueSet, erro
 }
 
 // Retrieve user's self identity
-func (c *Connector) retrieveSelfIdentity() (response identity, err error) {
+func (c *Connector) retrieveSelfIdentity(ctx context.Context) (response identity, err error) {
 
 	var respIndentities = &identitiesResponse{}
 
-	statusCode, statusText, body, err := c.request("GET", urlRetrieveSelfIdentity, nil)
+	statusCode, statusText, body, err := c.request(ctx, "GET", urlRetrieveSelfIdentity, nil)
 	if err != nil {
 		log.Printf("Failed to get the used user. Error: %v", err)
 		return identity{}, err
@@ -455,7 +503,12 @@ func (c *Connector) retrieveSelfIdentity() (response identity, err error) {
 
 // requestSystemVersion returns the TPP system version of the connector context
 func (c *Connector) RetrieveSystemVersion() (string, error) {
-	statusCode, status, body, err := c.request("GET", urlResourceSystemStatusVersion, "")
+	return c.RetrieveSystemVersionContext(context.Background())
+}
+
+// RetrieveSystemVersionContext is RetrieveSystemVersion with a context that cancels its requests to the platform
+func (c *Connector) RetrieveSystemVersionContext(ctx context.Context) (string, error) {
+	statusCode, status, body, err := c.request(ctx, "GET", urlResourceSystemStatusVersion, "")
 	if err != nil {
 		return "", err
 	}
@@ -474,7 +527,7 @@ func (c *Connector) RetrieveSystemVersion() (string, error) {
 }
 
 // setCertificateMetadata submits the metadata to TPP for storage returning the lock status of the metadata stored
-func (c *Connector) setCertificateMetadata(metadataRequest metadataSetRequest) (bool, error) {
+func (c *Connector) setCertificateMetadata(ctx context.Context, metadataRequest metadataSetRequest) (bool, error) {
 	if metadataRequest.DN == "" {
 		return false, fmt.Errorf("DN must be provided to setCertificateMetaData")
 	}
@@ -482,7 +535,7 @@ func (c *Connector) setCertificateMetadata(metadataRequest metadataSetRequest) (
 		return false, nil
 	} //Not an error, but there is nothing to do
 
-	statusCode, status, body, err := c.request("POST", urlResourceMetadataSet, metadataRequest)
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceMetadataSet, metadataRequest)
 	if err != nil {
 		return false, err
 	}
@@ -507,7 +560,7 @@ func (c *Connector) setCertificateMetadata(metadataRequest metadataSetRequest) (
 	return result.Locked, nil
 }
 
-func (c *Connector) prepareRequest(req *certificate.Request, zone string) (tppReq certificateRequest, err error) {
+func (c *Connector) prepareRequest(ctx context.Context, req *certificate.Request, zone string) (tppReq certificateRequest, err error) {
 	switch req.CsrOrigin {
 	case certificate.LocalGeneratedCSR, certificate.UserProvidedCSR:
 		tppReq.PKCS10 = string(req.GetCSR())
@@ -584,7 +637,7 @@ func (c *Connector) prepareRequest(req *certificate.Request, zone string) (tppRe
 	var contacts []IdentityEntry
 	if req.Contacts != nil {
 		var err error
-		prefixedUniversals, err := c.resolvePrefixedUniversals(req.Contacts)
+		prefixedUniversals, err := c.resolvePrefixedUniversals(ctx, req.Contacts)
 		if err != nil {
 			return tppReq, fmt.Errorf("failed to find contact identities: %w", err)
 		}
@@ -664,9 +717,9 @@ func (c *Connector) prepareRequest(req *certificate.Request, zone string) (tppRe
 	return tppReq, err
 }
 
-func (c *Connector) proccessLocation(req *certificate.Request) error {
+func (c *Connector) proccessLocation(ctx context.Context, req *certificate.Request) error {
 	certDN := getCertificateDN(c.zone, req.FriendlyName, req.Subject.CommonName)
-	guid, err := c.configDNToGuid(certDN)
+	guid, err := c.configDNToGuid(ctx, certDN)
 	if err != nil {
 		return fmt.Errorf("unable to retrieve certificate guid: %s", err)
 	}
@@ -676,7 +729,7 @@ func (c *Connector) proccessLocation(req *certificate.Request) error {
 		}
 		return nil
 	}
-	details, err := c.searchCertificateDetails(guid)
+	details, err := c.searchCertificateDetails(ctx, guid)
 	if err != nil {
 		return err
 	}
@@ -696,7 +749,7 @@ func (c *Connector) proccessLocation(req *certificate.Request) error {
 		}
 		if device == requestedDevice {
 			if req.Location.Replace {
-				err = c.dissociate(certDN, device)
+				err = c.dissociate(ctx, certDN, device)
 				if err != nil {
 					return err
 				}
@@ -711,19 +764,24 @@ func (c *Connector) proccessLocation(req *certificate.Request) error {
 // RequestCertificate submits the CSR to TPP returning the DN of the requested
 // Certificate.
 func (c *Connector) RequestCertificate(req *certificate.Request) (requestID string, err error) {
+	return c.RequestCertificateContext(context.Background(), req)
+}
+
+// RequestCertificateContext is RequestCertificate with a context that cancels its requests to the platform
+func (c *Connector) RequestCertificateContext(ctx context.Context, req *certificate.Request) (requestID string, err error) {
 	if req.Location != nil {
-		err = c.proccessLocation(req)
+		err = c.proccessLocation(ctx, req)
 		if err != nil {
 			return
 		}
 	}
 
-	tppCertificateRequest, err := c.prepareRequest(req, c.zone)
+	tppCertificateRequest, err := c.prepareRequest(ctx, req, c.zone)
 	if err != nil {
 		return "", err
 	}
 
-	statusCode, status, body, err := c.request("POST", urlResourceCertificateRequest, tppCertificateRequest)
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceCertificateRequest, tppCertificateRequest)
 	if err != nil {
 		return "", err
 	}
@@ -742,7 +800,7 @@ func (c *Connector) RequestCertificate(req *certificate.Request) (requestID stri
 	//saved metadata to the requested metadata. If all items match then no further
 	//changes need to be made. If they do not match, they try to update them using
 	//the 19.2 WebSDK calls
-	metadataItems, err := c.requestMetadataItems(requestID)
+	metadataItems, err := c.requestMetadataItems(ctx, requestID)
 	if err != nil {
 		log.Println(err)
 		return
@@ -777,14 +835,14 @@ func (c *Connector) RequestCertificate(req *certificate.Request) (requestID stri
 	}
 	log.Println("Saving metadata custom field using 19.2 method")
 	//Create a metadata/set command with the metadata from tppCertificateRequest
-	guidItems, err := prepareLegacyMetadata(c, tppCertificateRequest.CustomFields, requestID)
+	guidItems, err := prepareLegacyMetadata(ctx, c, tppCertificateRequest.CustomFields, requestID)
 	if err != nil {
 		log.Println(err)
 		return
 	}
 	requestData := metadataSetRequest{requestID, guidItems, true}
 	//c.request with the metadata request
-	_, err = c.setCertificateMetadata(requestData)
+	_, err = c.setCertificateMetadata(ctx, requestData)
 	if err != nil {
 		log.Println(err)
 	}
@@ -792,7 +850,12 @@ func (c *Connector) RequestCertificate(req *certificate.Request) (requestID stri
 }
 
 // SynchronousRequestCertificate It's not supported yet in TPP
-func (c *Connector) SynchronousRequestCertificate(_ *certificate.Request) (certificates *certificate.PEMCollection, err error) {
+func (c *Connector) SynchronousRequestCertificate(req *certificate.Request) (certificates *certificate.PEMCollection, err error) {
+	return c.SynchronousRequestCertificateContext(context.Background(), req)
+}
+
+// SynchronousRequestCertificateContext is SynchronousRequestCertificate with a context that cancels its requests to the platform
+func (c *Connector) SynchronousRequestCertificateContext(_ context.Context, _ *certificate.Request) (certificates *certificate.PEMCollection, err error) {
 	panic("operation is not supported yet")
 }
 
@@ -822,9 +885,14 @@ func IsCertNotFound(err error) bool {
 // reset. It returns an error of type *ErrCertNotFound if the certificate is not
 // found.
 func (c *Connector) ResetCertificate(req *certificate.Request, restart bool) (err error) {
+	return c.ResetCertificateContext(context.Background(), req, restart)
+}
+
+// ResetCertificateContext is ResetCertificate with a context that cancels its requests to the platform
+func (c *Connector) ResetCertificateContext(ctx context.Context, req *certificate.Request, restart bool) (err error) {
 	certificateDN := getCertificateDN(c.zone, req.FriendlyName, req.Subject.CommonName)
 
-	statusCode, status, body, err := c.request("POST", urlResourceCertificateReset, certificateResetRequest{
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceCertificateReset, certificateResetRequest{
 		CertificateDN: certificateDN,
 		Restart:       restart,
 	})
@@ -857,6 +925,11 @@ func (c *Connector) ResetCertificate(req *certificate.Request, restart bool) (er
 }
 
 func (c *Connector) GetPolicy(name string) (*policy.PolicySpecification, error) {
+	return c.GetPolicyContext(context.Background(), name)
+}
+
+// GetPolicyContext is GetPolicy with a context that cancels its requests to the platform
+func (c *Connector) GetPolicyContext(ctx context.Context, name string) (*policy.PolicySpecification, error) {
 	var ps *policy.PolicySpecification
 	var tp policy.TppPolicy
 
@@ -878,7 +951,7 @@ func (c *Connector) GetPolicy(name string) (*policy.PolicySpecification, error)
 	req := policy.CheckPolicyRequest{
 		PolicyDN: name,
 	}
-	_, _, body, err := c.request("POST", urlResourceCheckPolicy, req)
+	_, _, body, err := c.request(ctx, "POST", urlResourceCheckPolicy, req)
 
 	if err != nil {
 		return nil, err
@@ -899,7 +972,7 @@ func (c *Connector) GetPolicy(name string) (*policy.PolicySpecification, error)
 		return nil, err
 	}
 
-	userNames, error := c.retrieveUserNamesForPolicySpecification(name)
+	userNames, error := c.retrieveUserNamesForPolicySpecification(ctx, name)
 	if error != nil {
 		return nil, error
 	}
@@ -908,8 +981,110 @@ func (c *Connector) GetPolicy(name string) (*policy.PolicySpecification, error)
 	return ps, nil
 }
 
-func (c *Connector) retrieveUserNamesForPolicySpecification(policyName string) ([]string, error) {
-	values, _, error := getPolicyAttribute(c, policy.TppContact, policyName)
+// GetFolderPolicy returns the policy specification of the attributes set on the policy folder itself. Unlike
+// GetPolicy, the values the folder inherits from the folders above it are left out
+func (c *Connector) GetFolderPolicy(name string) (*policy.PolicySpecification, error) {
+	return c.GetFolderPolicyContext(context.Background(), name)
+}
+
+// GetFolderPolicyContext is GetFolderPolicy with a context that cancels its requests to the platform
+func (c *Connector) GetFolderPolicyContext(ctx context.Context, name string) (*policy.PolicySpecification, error) {
+	var tp policy.TppPolicy
+	var err error
+
+	if !strings.HasPrefix(name, util.PathSeparator) {
+		name = util.PathSeparator + name
+	}
+	if !strings.HasPrefix(name, policy.RootPath) {
+		name = policy.RootPath + name
+	}
+	tp.Name = &name
+
+	log.Println("Collecting policy folder attributes")
+
+	tp.Approver, _, err = getPolicyAttribute(ctx, c, policy.TppApprover, name)
+	if err != nil {
+		return nil, err
+	}
+	tp.DomainSuffixWhitelist, _, err = getPolicyAttribute(ctx, c, policy.TppDomainSuffixWhitelist, name)
+	if err != nil {
+		return nil, err
+	}
+	tp.ProhibitedSANType, _, err = getPolicyAttribute(ctx, c, policy.TppProhibitedSANTypes, name)
+	if err != nil {
+		return nil, err
+	}
+	values, _, err := getPolicyAttribute(ctx, c, policy.TppCertificateAuthority, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) > 0 {
+		tp.CertificateAuthority = &values[0]
+	}
+
+	intAttributes := map[string]**int{
+		policy.TppProhibitWildcard:     &tp.ProhibitWildcard,
+		policy.TppAllowPrivateKeyReuse: &tp.AllowPrivateKeyReuse,
+		policy.TppWantRenewal:          &tp.WantRenewal,
+	}
+	for attribute, value := range intAttributes {
+		values, _, err := getPolicyAttribute(ctx, c, attribute, name)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) > 0 {
+			intVal, err := strconv.Atoi(values[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q of %s: %w", attribute, values[0], name, err)
+			}
+			*value = &intVal
+		}
+	}
+
+	lockedAttributes := map[string]**policy.LockedAttribute{
+		policy.TppManagementType: &tp.ManagementType,
+		policy.TppOrganization:   &tp.Organization,
+		policy.TppCity:           &tp.City,
+		policy.TppState:          &tp.State,
+		policy.TppCountry:        &tp.Country,
+		policy.TppKeyAlgorithm:   &tp.KeyAlgorithm,
+		policy.TppKeyBitStrength: &tp.KeyBitStrength,
+		policy.TppEllipticCurve:  &tp.EllipticCurve,
+		policy.ServiceGenerated:  &tp.ManualCsr,
+	}
+	for attribute, value := range lockedAttributes {
+		values, locked, err := getPolicyAttribute(ctx, c, attribute, name)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) > 0 {
+			*value = &policy.LockedAttribute{Value: values[0], Locked: *locked}
+		}
+	}
+
+	values, locked, err := getPolicyAttribute(ctx, c, policy.TppOrganizationalUnit, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) > 0 {
+		tp.OrganizationalUnit = &policy.LockedArrayAttribute{Value: values, Locked: *locked}
+	}
+
+	ps, err := policy.BuildPolicySpecificationFromTppPolicy(tp)
+	if err != nil {
+		return nil, err
+	}
+
+	ps.Users, err = c.retrieveUserNamesForPolicySpecification(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return ps, nil
+}
+
+func (c *Connector) retrieveUserNamesForPolicySpecification(ctx context.Context, policyName string) ([]string, error) {
+	values, _, error := getPolicyAttribute(ctx, c, policy.TppContact, policyName)
 	if error != nil {
 		return nil, error
 	}
@@ -922,7 +1097,7 @@ func (c *Connector) retrieveUserNamesForPolicySpecification(policyName string) (
 				},
 			}
 
-			validateIdentityResponse, error := c.validateIdentity(validateIdentityRequest)
+			validateIdentityResponse, error := c.validateIdentity(ctx, validateIdentityRequest)
 			if error != nil {
 				return nil, error
 			}
@@ -936,9 +1111,9 @@ func (c *Connector) retrieveUserNamesForPolicySpecification(policyName string) (
 	return nil, nil
 }
 
-func (c *Connector) validateIdentity(validateIdentityRequest ValidateIdentityRequest) (*ValidateIdentityResponse, error) {
+func (c *Connector) validateIdentity(ctx context.Context, validateIdentityRequest ValidateIdentityRequest) (*ValidateIdentityResponse, error) {
 
-	statusCode, status, body, err := c.request("POST", urlResourceValidateIdentity, validateIdentityRequest)
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceValidateIdentity, validateIdentityRequest)
 	if err != nil {
 		return nil, err
 	}
@@ -950,11 +1125,16 @@ func (c *Connector) validateIdentity(validateIdentityRequest ValidateIdentityReq
 }
 
 func PolicyExist(policyName string, c *Connector) (bool, error) {
+	return PolicyExistContext(context.Background(), policyName, c)
+}
+
+// PolicyExistContext is PolicyExist with a context that cancels its requests to the platform
+func PolicyExistContext(ctx context.Context, policyName string, c *Connector) (bool, error) {
 
 	req := policy.PolicyExistPayloadRequest{
 		ObjectDN: policyName,
 	}
-	_, _, body, err := c.request("POST", urlResourceIsValidPolicy, req)
+	_, _, body, err := c.request(ctx, "POST", urlResourceIsValidPolicy, req)
 
 	if err != nil {
 		return false, err
@@ -978,6 +1158,11 @@ func PolicyExist(policyName string, c *Connector) (bool, error) {
 }
 
 func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (string, error) {
+	return c.SetPolicyContext(context.Background(), name, ps)
+}
+
+// SetPolicyContext is SetPolicy with a context that cancels its requests to the platform
+func (c *Connector) SetPolicyContext(ctx context.Context, name string, ps *policy.PolicySpecification) (string, error) {
 
 	//validate policy specification and policy
 	err := policy.ValidateTppPolicySpecification(ps)
@@ -1001,7 +1186,7 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 	tppPolicy.Name = &name
 
 	//validate if the policy exists
-	policyExists, err := PolicyExist(name, c)
+	policyExists, err := PolicyExistContext(ctx, name, c)
 	if err != nil {
 		return "", err
 	}
@@ -1013,7 +1198,7 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 		//validate if the parent exist
 		parent := policy.GetParent(name)
 
-		parentExist, err := PolicyExist(parent, c)
+		parentExist, err := PolicyExistContext(ctx, parent, c)
 		if err != nil {
 			return "", err
 		}
@@ -1035,7 +1220,7 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 			ObjectDN: *(tppPolicy.Name),
 		}
 
-		_, _, _, err = c.request("POST", urlResourceCreatePolicy, req)
+		_, _, _, err = c.request(ctx, "POST", urlResourceCreatePolicy, req)
 
 		if err != nil {
 			return "", err
@@ -1047,27 +1232,27 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 
 	//create Approver
 	if tppPolicy.Approver != nil {
-		_, _, _, err = createPolicyAttribute(c, policy.TppApprover, tppPolicy.Approver, *(tppPolicy.Name), true)
+		_, _, _, err = createPolicyAttribute(ctx, c, policy.TppApprover, tppPolicy.Approver, *(tppPolicy.Name), true)
 		if err != nil {
 			return "", err
 		}
 	}
 	if policyExists {
-		err = resetTPPAttributes(*(tppPolicy.Name), c)
+		err = resetTPPAttributes(ctx, *(tppPolicy.Name), c)
 		if err != nil {
 			return "", err
 		}
 	}
 
 	//set Contacts
-	status, err = c.setContact(&tppPolicy)
+	status, err = c.setContact(ctx, &tppPolicy)
 	if err != nil {
 		return "", err
 	}
 
 	//create Domain Suffix Whitelist
 	if tppPolicy.ManagementType != nil {
-		_, status, _, err = createPolicyAttribute(c, policy.TppManagementType, []string{tppPolicy.ManagementType.Value}, *(tppPolicy.Name), tppPolicy.ManagementType.Locked)
+		_, status, _, err = createPolicyAttribute(ctx, c, policy.TppManagementType, []string{tppPolicy.ManagementType.Value}, *(tppPolicy.Name), tppPolicy.ManagementType.Locked)
 		if err != nil {
 			return "", err
 		}
@@ -1075,7 +1260,7 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 
 	//create Domain Suffix Whitelist
 	if tppPolicy.DomainSuffixWhitelist != nil {
-		_, status, _, err = createPolicyAttribute(c, policy.TppDomainSuffixWhitelist, tppPolicy.DomainSuffixWhitelist, *(tppPolicy.Name), true)
+		_, status, _, err = createPolicyAttribute(ctx, c, policy.TppDomainSuffixWhitelist, tppPolicy.DomainSuffixWhitelist, *(tppPolicy.Name), true)
 		if err != nil {
 			return "", err
 		}
@@ -1083,7 +1268,7 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 
 	//create Prohibit Wildcard
 	if tppPolicy.ProhibitWildcard != nil {
-		_, status, _, err = createPolicyAttribute(c, policy.TppProhibitWildcard, []string{strconv.Itoa(*(tppPolicy.ProhibitWildcard))}, *(tppPolicy.Name), false)
+		_, status, _, err = createPolicyAttribute(ctx, c, policy.TppProhibitWildcard, []string{strconv.Itoa(*(tppPolicy.ProhibitWildcard))}, *(tppPolicy.Name), false)
 		if err != nil {
 			return "", err
 		}
@@ -1091,7 +1276,7 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 
 	//create Certificate Authority
 	if tppPolicy.CertificateAuthority != nil {
-		_, status, _, err = createPolicyAttribute(c, policy.TppCertificateAuthority, []string{*(tppPolicy.CertificateAuthority)}, *(tppPolicy.Name), false)
+		_, status, _, err = createPolicyAttribute(ctx, c, policy.TppCertificateAuthority, []string{*(tppPolicy.CertificateAuthority)}, *(tppPolicy.Name), false)
 		if err != nil {
 			return "", err
 		}
@@ -1099,7 +1284,7 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 
 	//create Organization attribute
 	if tppPolicy.Organization != nil {
-		_, status, _, err = createPolicyAttribute(c, policy.TppOrganization, []string{tppPolicy.Organization.Value}, *(tppPolicy.Name), tppPolicy.Organization.Locked)
+		_, status, _, err = createPolicyAttribute(ctx, c, policy.TppOrganization, []string{tppPolicy.Organization.Value}, *(tppPolicy.Name), tppPolicy.Organization.Locked)
 		if err != nil {
 			return "", err
 		}
@@ -1107,14 +1292,14 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 
 	//create Organizational Unit attribute
 	if tppPolicy.OrganizationalUnit != nil {
-		_, status, _, err = createPolicyAttribute(c, policy.TppOrganizationalUnit, tppPolicy.OrganizationalUnit.Value, *(tppPolicy.Name), tppPolicy.OrganizationalUnit.Locked)
+		_, status, _, err = createPolicyAttribute(ctx, c, policy.TppOrganizationalUnit, tppPolicy.OrganizationalUnit.Value, *(tppPolicy.Name), tppPolicy.OrganizationalUnit.Locked)
 		if err != nil {
 			return "", err
 		}
 	}
 	//create City attribute
 	if tppPolicy.City != nil {
-		_, status, _, err = createPolicyAttribute(c, policy.TppCity, []string{tppPolicy.City.Value}, *(tppPolicy.Name), tppPolicy.City.Locked)
+		_, status, _, err = createPolicyAttribute(ctx, c, policy.TppCity, []string{tppPolicy.City.Value}, *(tppPolicy.Name), tppPolicy.City.Locked)
 		if err != nil {
 			return "", err
 		}
@@ -1122,7 +1307,7 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 
 	//create State attribute
 	if tppPolicy.State != nil {
-		_, status, _, err = createPolicyAttribute(c, policy.TppState, []string{tppPolicy.State.Value}, *(tppPolicy.Name), tppPolicy.State.Locked)
+		_, status, _, err = createPolicyAttribute(ctx, c, policy.TppState, []string{tppPolicy.State.Value}, *(tppPolicy.Name), tppPolicy.State.Locked)
 		if err != nil {
 			return "", err
 		}
@@ -1130,7 +1315,7 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 
 	//create Country attribute
 	if tppPolicy.Country != nil {
-		_, status, _, err = createPolicyAttribute(c, policy.TppCountry, []string{tppPolicy.Country.Value}, *(tppPolicy.Name), tppPolicy.Country.Locked)
+		_, status, _, err = createPolicyAttribute(ctx, c, policy.TppCountry, []string{tppPolicy.Country.Value}, *(tppPolicy.Name), tppPolicy.Country.Locked)
 		if err != nil {
 			return "", err
 		}
@@ -1138,7 +1323,7 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 
 	//create Key Algorithm attribute
 	if tppPolicy.KeyAlgorithm != nil {
-		_, status, _, err = createPolicyAttribute(c, policy.TppKeyAlgorithm, []string{tppPolicy.KeyAlgorithm.Value}, *(tppPolicy.Name), tppPolicy.KeyAlgorithm.Locked)
+		_, status, _, err = createPolicyAttribute(ctx, c, policy.TppKeyAlgorithm, []string{tppPolicy.KeyAlgorithm.Value}, *(tppPolicy.Name), tppPolicy.KeyAlgorithm.Locked)
 		if err != nil {
 			return "", err
 		}
@@ -1146,7 +1331,7 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 
 	//create Key Bit Strength
 	if tppPolicy.KeyBitStrength != nil {
-		_, status, _, err = createPolicyAttribute(c, policy.TppKeyBitStrength, []string{tppPolicy.KeyBitStrength.Value}, *(tppPolicy.Name), tppPolicy.KeyBitStrength.Locked)
+		_, status, _, err = createPolicyAttribute(ctx, c, policy.TppKeyBitStrength, []string{tppPolicy.KeyBitStrength.Value}, *(tppPolicy.Name), tppPolicy.KeyBitStrength.Locked)
 		if err != nil {
 			return "", err
 		}
@@ -1154,7 +1339,7 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 
 	//create Elliptic Curve attribute
 	if tppPolicy.EllipticCurve != nil {
-		_, status, _, err = createPolicyAttribute(c, policy.TppEllipticCurve, []string{tppPolicy.EllipticCurve.Value}, *(tppPolicy.Name), tppPolicy.EllipticCurve.Locked)
+		_, status, _, err = createPolicyAttribute(ctx, c, policy.TppEllipticCurve, []string{tppPolicy.EllipticCurve.Value}, *(tppPolicy.Name), tppPolicy.EllipticCurve.Locked)
 		if err != nil {
 			return "", err
 		}
@@ -1162,14 +1347,14 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 
 	//create Manual Csr attribute
 	if tppPolicy.ManualCsr != nil {
-		_, status, _, err = createPolicyAttribute(c, policy.ServiceGenerated, []string{tppPolicy.ManualCsr.Value}, *(tppPolicy.Name), tppPolicy.ManualCsr.Locked)
+		_, status, _, err = createPolicyAttribute(ctx, c, policy.ServiceGenerated, []string{tppPolicy.ManualCsr.Value}, *(tppPolicy.Name), tppPolicy.ManualCsr.Locked)
 		if err != nil {
 			return "", err
 		}
 	}
 
 	if tppPolicy.ProhibitedSANType != nil {
-		_, status, _, err = createPolicyAttribute(c, policy.TppProhibitedSANTypes, tppPolicy.ProhibitedSANType, *(tppPolicy.Name), false)
+		_, status, _, err = createPolicyAttribute(ctx, c, policy.TppProhibitedSANTypes, tppPolicy.ProhibitedSANType, *(tppPolicy.Name), false)
 		if err != nil {
 			return "", err
 		}
@@ -1177,14 +1362,14 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 
 	//Allow Private Key Reuse" & "Want Renewal
 	if tppPolicy.AllowPrivateKeyReuse != nil {
-		_, status, _, err = createPolicyAttribute(c, policy.TppAllowPrivateKeyReuse, []string{strconv.Itoa(*(tppPolicy.AllowPrivateKeyReuse))}, *(tppPolicy.Name), true)
+		_, status, _, err = createPolicyAttribute(ctx, c, policy.TppAllowPrivateKeyReuse, []string{strconv.Itoa(*(tppPolicy.AllowPrivateKeyReuse))}, *(tppPolicy.Name), true)
 		if err != nil {
 			return "", err
 		}
 	}
 
 	if tppPolicy.WantRenewal != nil {
-		_, status, _, err = createPolicyAttribute(c, policy.TppWantRenewal, []string{strconv.Itoa(*(tppPolicy.WantRenewal))}, *(tppPolicy.Name), true)
+		_, status, _, err = createPolicyAttribute(ctx, c, policy.TppWantRenewal, []string{strconv.Itoa(*(tppPolicy.WantRenewal))}, *(tppPolicy.Name), true)
 		if err != nil {
 			return "", err
 		}
@@ -1195,17 +1380,17 @@ func (c *Connector) SetPolicy(name string, ps *policy.PolicySpecification) (stri
 	return status, nil
 }
 
-func (c *Connector) setContact(tppPolicy *policy.TppPolicy) (status string, err error) {
+func (c *Connector) setContact(ctx context.Context, tppPolicy *policy.TppPolicy) (status string, err error) {
 
 	if tppPolicy.Contact != nil {
-		contacts, err := c.resolvePrefixedUniversals(tppPolicy.Contact)
+		contacts, err := c.resolvePrefixedUniversals(ctx, tppPolicy.Contact)
 		if err != nil {
 			return "", fmt.Errorf("an error happened trying to resolve the contacts: %w", err)
 		}
 		if contacts != nil {
 			tppPolicy.Contact = contacts
 
-			_, status, _, err = createPolicyAttribute(c, policy.TppContact, tppPolicy.Contact, *(tppPolicy.Name), true)
+			_, status, _, err = createPolicyAttribute(ctx, c, policy.TppContact, tppPolicy.Contact, *(tppPolicy.Name), true)
 			if err != nil {
 				return "", err
 			}
@@ -1215,9 +1400,9 @@ func (c *Connector) setContact(tppPolicy *policy.TppPolicy) (status string, err
 	return status, nil
 }
 
-func (c *Connector) resolvePrefixedUniversals(filters []string) ([]string, error) {
+func (c *Connector) resolvePrefixedUniversals(ctx context.Context, filters []string) ([]string, error) {
 	var prefixedUniversals []string
-	identities, err := c.resolveIdentities(filters)
+	identities, err := c.resolveIdentities(ctx, filters)
 	if err != nil {
 		return nil, err
 	}
@@ -1228,11 +1413,11 @@ func (c *Connector) resolvePrefixedUniversals(filters []string) ([]string, error
 	return prefixedUniversals, nil
 }
 
-func (c *Connector) resolveIdentities(filters []string) ([]*IdentityEntry, error) {
+func (c *Connector) resolveIdentities(ctx context.Context, filters []string) ([]*IdentityEntry, error) {
 	var identities []*IdentityEntry
 	uniqueContacts := getUniqueStringSlice(filters)
 	for _, contact := range uniqueContacts {
-		identityEntry, err := c.getIdentity(contact)
+		identityEntry, err := c.getIdentity(ctx, contact)
 		if err != nil {
 			return nil, err
 		}
@@ -1257,7 +1442,7 @@ func getUniqueStringSlice(stringSlice []string) []string {
 // Searches for identities that are an exact match of the filter. When two
 // identities are found for the same filter, the first identity found is
 // returned.
-func (c *Connector) getIdentity(filter string) (*IdentityEntry, error) {
+func (c *Connector) getIdentity(ctx context.Context, filter string) (*IdentityEntry, error) {
 	if filter == "" {
 		return nil, fmt.Errorf("identity string cannot be null")
 	}
@@ -1268,7 +1453,7 @@ func (c *Connector) getIdentity(filter string) (*IdentityEntry, error) {
 		IdentityType: policy.AllIdentities,
 	}
 
-	resp, err := c.browseIdentities(req)
+	resp, err := c.browseIdentities(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1317,9 +1502,9 @@ func (c *Connector) getIdentity(filter string) (*IdentityEntry, error) {
 	return nil, fmt.Errorf("this was not supposed to happen, please report to the developer team: browseIdentities returned %d identities for the filter '%s' and none of the switch cases matched, but the switch cases are expected to catch 100%% of the cases", len(resp.Identities), filter)
 }
 
-func (c *Connector) browseIdentities(browseReq BrowseIdentitiesRequest) (*BrowseIdentitiesResponse, error) {
+func (c *Connector) browseIdentities(ctx context.Context, browseReq BrowseIdentitiesRequest) (*BrowseIdentitiesResponse, error) {
 
-	statusCode, status, body, err := c.request("POST", urlResourceBrowseIdentities, browseReq)
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceBrowseIdentities, browseReq)
 	if err != nil {
 		return nil, err
 	}
@@ -1332,13 +1517,18 @@ func (c *Connector) browseIdentities(browseReq BrowseIdentitiesRequest) (*Browse
 
 // RetrieveCertificate attempts to retrieve the requested certificate
 func (c *Connector) RetrieveCertificate(req *certificate.Request) (certificates *certificate.PEMCollection, err error) {
+	return c.RetrieveCertificateContext(context.Background(), req)
+}
+
+// RetrieveCertificateContext is RetrieveCertificate with a context that cancels its requests to the platform
+func (c *Connector) RetrieveCertificateContext(ctx context.Context, req *certificate.Request) (certificates *certificate.PEMCollection, err error) {
 
 	includeChain := req.ChainOption != certificate.ChainOptionIgnore
 	rootFirstOrder := includeChain && req.ChainOption == certificate.ChainOptionRootFirst
 
 	if req.PickupID == "" && req.Thumbprint != "" {
 		// search cert by Thumbprint and fill pickupID
-		searchResult, err := c.searchCertificatesByFingerprint(req.Thumbprint)
+		searchResult, err := c.searchCertificatesByFingerprint(ctx, req.Thumbprint)
 		if err != nil {
 			return nil, fmt.Errorf("Failed to create renewal request: %s", err)
 		}
@@ -1368,9 +1558,9 @@ func (c *Connector) RetrieveCertificate(req *certificate.Request) (certificates
 	startTime := time.Now()
 	for {
 		var retrieveResponse *certificateRetrieveResponse
-		retrieveResponse, err = c.retrieveCertificateOnce(certReq)
+		retrieveResponse, err = c.retrieveCertificateOnce(ctx, certReq)
 		if err != nil {
-			return nil, fmt.Errorf("unable to retrieve: %s", err)
+			return nil, fmt.Errorf("unable to retrieve: %w", err)
 		}
 		if retrieveResponse.CertificateData != "" {
 			certificates, err = newPEMCollectionFromResponse(retrieveResponse.CertificateData, req.ChainOption)
@@ -1386,12 +1576,14 @@ func (c *Connector) RetrieveCertificate(req *certificate.Request) (certificates
 		if time.Now().After(startTime.Add(req.Timeout)) {
 			return nil, endpoint.ErrRetrieveCertificateTimeout{CertificateID: req.PickupID}
 		}
-		time.Sleep(2 * time.Second)
+		if err = util.SleepContext(ctx, 2*time.Second); err != nil {
+			return nil, err
+		}
 	}
 }
 
-func (c *Connector) retrieveCertificateOnce(certReq certificateRetrieveRequest) (*certificateRetrieveResponse, error) {
-	statusCode, status, body, err := c.request("POST", urlResourceCertificateRetrieve, certReq)
+func (c *Connector) retrieveCertificateOnce(ctx context.Context, certReq certificateRetrieveRequest) (*certificateRetrieveResponse, error) {
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceCertificateRetrieve, certReq)
 	if err != nil {
 		return nil, err
 	}
@@ -1402,12 +1594,12 @@ func (c *Connector) retrieveCertificateOnce(certReq certificateRetrieveRequest)
 	return &retrieveResponse, nil
 }
 
-func (c *Connector) putCertificateInfo(dn string, attributes []nameSliceValuePair) error {
-	guid, err := c.configDNToGuid(dn)
+func (c *Connector) putCertificateInfo(ctx context.Context, dn string, attributes []nameSliceValuePair) error {
+	guid, err := c.configDNToGuid(ctx, dn)
 	if err != nil {
 		return err
 	}
-	statusCode, _, _, err := c.request("PUT", urlResourceCertificate+urlResource(guid), struct{ AttributeData []nameSliceValuePair }{attributes})
+	statusCode, _, _, err := c.request(ctx, "PUT", urlResourceCertificate+urlResource(guid), struct{ AttributeData []nameSliceValuePair }{attributes})
 	if err != nil {
 		return err
 	}
@@ -1417,7 +1609,7 @@ func (c *Connector) putCertificateInfo(dn string, attributes []nameSliceValuePai
 	return nil
 }
 
-func (c *Connector) prepareRenewalRequest(renewReq *certificate.RenewalRequest) error {
+func (c *Connector) prepareRenewalRequest(ctx context.Context, renewReq *certificate.RenewalRequest) error {
 	if renewReq.CertificateRequest != nil && len(renewReq.CertificateRequest.GetCSR()) != 0 {
 		return nil
 	}
@@ -1427,7 +1619,7 @@ func (c *Connector) prepareRenewalRequest(renewReq *certificate.RenewalRequest)
 	}
 
 	// here we fetch old cert anyway
-	oldPcc, err := c.RetrieveCertificate(searchReq)
+	oldPcc, err := c.RetrieveCertificateContext(ctx, searchReq)
 	if err != nil {
 		return fmt.Errorf("Failed to fetch old certificate by id %s: %s", renewReq.CertificateDN, err)
 	}
@@ -1442,15 +1634,20 @@ func (c *Connector) prepareRenewalRequest(renewReq *certificate.RenewalRequest)
 	if renewReq.CertificateRequest == nil {
 		renewReq.CertificateRequest = certificate.NewRequest(oldCert)
 	}
-	err = c.GenerateRequest(&endpoint.ZoneConfiguration{}, renewReq.CertificateRequest)
+	err = c.GenerateRequestContext(ctx, &endpoint.ZoneConfiguration{}, renewReq.CertificateRequest)
 	return err
 }
 
 // RenewCertificate attempts to renew the certificate
 func (c *Connector) RenewCertificate(renewReq *certificate.RenewalRequest) (requestID string, err error) {
+	return c.RenewCertificateContext(context.Background(), renewReq)
+}
+
+// RenewCertificateContext is RenewCertificate with a context that cancels its requests to the platform
+func (c *Connector) RenewCertificateContext(ctx context.Context, renewReq *certificate.RenewalRequest) (requestID string, err error) {
 	if renewReq.Thumbprint != "" && renewReq.CertificateDN == "" {
 		// search by Thumbprint and fill *renewReq.CertificateDN
-		searchResult, err := c.searchCertificatesByFingerprint(renewReq.Thumbprint)
+		searchResult, err := c.searchCertificatesByFingerprint(ctx, renewReq.Thumbprint)
 		if err != nil {
 			return "", fmt.Errorf("Failed to create renewal request: %s", err)
 		}
@@ -1469,7 +1666,7 @@ func (c *Connector) RenewCertificate(renewReq *certificate.RenewalRequest) (requ
 	if renewReq.CertificateRequest != nil && renewReq.CertificateRequest.OmitSANs {
 		// if OmitSANSs flag is presented we need to clean SANs values in TPP
 		// for preventing adding them to renew request on TPP side
-		err = c.putCertificateInfo(renewReq.CertificateDN, []nameSliceValuePair{
+		err = c.putCertificateInfo(ctx, renewReq.CertificateDN, []nameSliceValuePair{
 			{"X509 SubjectAltName DNS", nil},
 			{"X509 SubjectAltName IPAddress", nil},
 			{"X509 SubjectAltName RFC822", nil},
@@ -1489,7 +1686,7 @@ func (c *Connector) RenewCertificate(renewReq *certificate.RenewalRequest) (requ
 	if renewReq.CertificateRequest != nil && len(renewReq.CertificateRequest.GetCSR()) != 0 {
 		r.PKCS10 = string(renewReq.CertificateRequest.GetCSR())
 	}
-	statusCode, status, body, err := c.request("POST", urlResourceCertificateRenew, r)
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceCertificateRenew, r)
 	if err != nil {
 		return "", err
 	}
@@ -1506,6 +1703,11 @@ func (c *Connector) RenewCertificate(renewReq *certificate.RenewalRequest) (requ
 
 // RevokeCertificate attempts to revoke the certificate
 func (c *Connector) RevokeCertificate(revReq *certificate.RevocationRequest) (err error) {
+	return c.RevokeCertificateContext(context.Background(), revReq)
+}
+
+// RevokeCertificateContext is RevokeCertificate with a context that cancels its requests to the platform
+func (c *Connector) RevokeCertificateContext(ctx context.Context, revReq *certificate.RevocationRequest) (err error) {
 	reason, ok := RevocationReasonsMap[revReq.Reason]
 	if !ok {
 		return fmt.Errorf("could not parse revocation reason `%s`", revReq.Reason)
@@ -1518,7 +1720,7 @@ func (c *Connector) RevokeCertificate(revReq *certificate.RevocationRequest) (er
 		revReq.Comments,
 		revReq.Disable,
 	}
-	statusCode, status, body, err := c.request("POST", urlResourceCertificateRevoke, r)
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceCertificateRevoke, r)
 	if err != nil {
 		return err
 	}
@@ -1533,10 +1735,15 @@ func (c *Connector) RevokeCertificate(revReq *certificate.RevocationRequest) (er
 }
 
 func (c *Connector) RetireCertificate(req *certificate.RetireRequest) (err error) {
+	return c.RetireCertificateContext(context.Background(), req)
+}
+
+// RetireCertificateContext is RetireCertificate with a context that cancels its requests to the platform
+func (c *Connector) RetireCertificateContext(ctx context.Context, req *certificate.RetireRequest) (err error) {
 
 	if req.CertificateDN == "" && req.Thumbprint != "" {
 		// search cert by Thumbprint and fill pickupID
-		searchResult, err := c.searchCertificatesByFingerprint(req.Thumbprint)
+		searchResult, err := c.searchCertificatesByFingerprint(ctx, req.Thumbprint)
 		if err != nil {
 			return fmt.Errorf("Failed to create retire request: %s", err)
 		}
@@ -1553,18 +1760,23 @@ func (c *Connector) RetireCertificate(req *certificate.RetireRequest) (err error
 
 	retireSliceValuePair := []nameSliceValuePair{{Name: "Disabled", Value: []string{"1"}}}
 
-	err = c.putCertificateInfo(req.CertificateDN, retireSliceValuePair)
+	err = c.putCertificateInfo(ctx, req.CertificateDN, retireSliceValuePair)
 	return err
 }
 
 var zoneNonFoundregexp = regexp.MustCompile("PolicyDN: .+ does not exist")
 
 func (c *Connector) ReadPolicyConfiguration() (policy *endpoint.Policy, err error) {
+	return c.ReadPolicyConfigurationContext(context.Background())
+}
+
+// ReadPolicyConfigurationContext is ReadPolicyConfiguration with a context that cancels its requests to the platform
+func (c *Connector) ReadPolicyConfigurationContext(ctx context.Context) (policy *endpoint.Policy, err error) {
 	if c.zone == "" {
 		return nil, fmt.Errorf("empty zone")
 	}
 	rq := struct{ PolicyDN string }{getPolicyDN(c.zone)}
-	statusCode, status, body, err := c.request("POST", urlResourceCertificatePolicy, rq)
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceCertificatePolicy, rq)
 	if err != nil {
 		return
 	}
@@ -1595,13 +1807,18 @@ func (c *Connector) ReadPolicyConfiguration() (policy *endpoint.Policy, err erro
 
 // ReadZoneConfiguration reads the policy data from TPP to get locked and pre-configured values for certificate requests
 func (c *Connector) ReadZoneConfiguration() (config *endpoint.ZoneConfiguration, err error) {
+	return c.ReadZoneConfigurationContext(context.Background())
+}
+
+// ReadZoneConfigurationContext is ReadZoneConfiguration with a context that cancels its requests to the platform
+func (c *Connector) ReadZoneConfigurationContext(ctx context.Context) (config *endpoint.ZoneConfiguration, err error) {
 	if c.zone == "" {
 		return nil, fmt.Errorf("empty zone")
 	}
 	zoneConfig := endpoint.NewZoneConfiguration()
 	zoneConfig.HashAlgorithm = x509.SHA256WithRSA //todo: check this can have problem with ECDSA key
 	rq := struct{ PolicyDN string }{getPolicyDN(c.zone)}
-	statusCode, status, body, err := c.request("POST", urlResourceCertificatePolicy, rq)
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceCertificatePolicy, rq)
 	if err != nil {
 		return
 	}
@@ -1632,6 +1849,11 @@ func (c *Connector) ReadZoneConfiguration() (config *endpoint.ZoneConfiguration,
 }
 
 func (c *Connector) ImportCertificate(req *certificate.ImportRequest) (*certificate.ImportResponse, error) {
+	return c.ImportCertificateContext(context.Background(), req)
+}
+
+// ImportCertificateContext is ImportCertificate with a context that cancels its requests to the platform
+func (c *Connector) ImportCertificateContext(ctx context.Context, req *certificate.ImportRequest) (*certificate.ImportResponse, error) {
 	r := importRequest{
 		PolicyDN:        req.PolicyDN,
 		ObjectName:      req.ObjectName,
@@ -1651,7 +1873,7 @@ func (c *Connector) ImportCertificate(req *certificate.ImportRequest) (*certific
 			origin = f.Value + " (+)"
 		}
 	}
-	statusCode, _, body, err := c.request("POST", urlResourceCertificateImport, r)
+	statusCode, _, body, err := c.request(ctx, "POST", urlResourceCertificateImport, r)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", verror.ServerTemporaryUnavailableError, err)
 	}
@@ -1662,7 +1884,7 @@ func (c *Connector) ImportCertificate(req *certificate.ImportRequest) (*certific
 		if err != nil {
 			return nil, fmt.Errorf("%w: failed to decode import response message: %s", verror.ServerError, err)
 		}
-		err = c.putCertificateInfo(response.CertificateDN, []nameSliceValuePair{{Name: "Origin", Value: []string{origin}}})
+		err = c.putCertificateInfo(ctx, response.CertificateDN, []nameSliceValuePair{{Name: "Origin", Value: []string{origin}}})
 		if err != nil {
 			log.Println(err)
 		}
@@ -1680,11 +1902,16 @@ func (c *Connector) ImportCertificate(req *certificate.ImportRequest) (*certific
 }
 
 func (c *Connector) SearchCertificates(req *certificate.SearchRequest) (*certificate.CertSearchResponse, error) {
+	return c.SearchCertificatesContext(context.Background(), req)
+}
+
+// SearchCertificatesContext is SearchCertificates with a context that cancels its requests to the platform
+func (c *Connector) SearchCertificatesContext(ctx context.Context, req *certificate.SearchRequest) (*certificate.CertSearchResponse, error) {
 
 	var err error
 
 	url := fmt.Sprintf("%s?%s", urlResourceCertificateSearch, strings.Join(*req, "&"))
-	statusCode, _, body, err := c.request("GET", urlResource(url), nil)
+	statusCode, _, body, err := c.request(ctx, "GET", urlResource(url), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1696,12 +1923,17 @@ func (c *Connector) SearchCertificates(req *certificate.SearchRequest) (*certifi
 }
 
 func (c *Connector) SearchCertificate(zone string, cn string, sans *certificate.Sans, certMinTimeLeft time.Duration) (certificateInfo *certificate.CertificateInfo, err error) {
+	return c.SearchCertificateContext(context.Background(), zone, cn, sans, certMinTimeLeft)
+}
+
+// SearchCertificateContext is SearchCertificate with a context that cancels its requests to the platform
+func (c *Connector) SearchCertificateContext(ctx context.Context, zone string, cn string, sans *certificate.Sans, certMinTimeLeft time.Duration) (certificateInfo *certificate.CertificateInfo, err error) {
 	// format arguments for request
 	req := formatSearchCertificateArguments(cn, sans, certMinTimeLeft)
 
 	// perform request
 	url := fmt.Sprintf("%s?%s", urlResourceCertificateSearch, req)
-	statusCode, _, body, err := c.request("GET", urlResource(url), nil)
+	statusCode, _, body, err := c.request(ctx, "GET", urlResource(url), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1743,8 +1975,19 @@ func (c *Connector) SetHTTPClient(client *http.Client) {
 	c.client = client
 }
 
+// SetRetryPolicy sets how the requests to the platform are retried and rate limited. It applies to the HTTP client
+// built by the connector, not to one set with SetHTTPClient
+func (c *Connector) SetRetryPolicy(policy httputils.RetryPolicy) {
+	c.retryPolicy = &policy
+}
+
 func (c *Connector) WriteLog(logReq *endpoint.LogRequest) error {
-	statusCode, httpStatus, body, err := c.request("POST", urlResourceLog, logReq)
+	return c.WriteLogContext(context.Background(), logReq)
+}
+
+// WriteLogContext is WriteLog with a context that cancels its requests to the platform
+func (c *Connector) WriteLogContext(ctx context.Context, logReq *endpoint.LogRequest) error {
+	statusCode, httpStatus, body, err := c.request(ctx, "POST", urlResourceLog, logReq)
 	if err != nil {
 		return err
 	}
@@ -1757,6 +2000,11 @@ func (c *Connector) WriteLog(logReq *endpoint.LogRequest) error {
 }
 
 func (c *Connector) ListCertificates(filter endpoint.Filter) ([]certificate.CertificateInfo, error) {
+	return c.ListCertificatesContext(context.Background(), filter)
+}
+
+// ListCertificatesContext is ListCertificates with a context that cancels its requests to the platform
+func (c *Connector) ListCertificatesContext(ctx context.Context, filter endpoint.Filter) ([]certificate.CertificateInfo, error) {
 	if c.zone == "" {
 		return nil, fmt.Errorf("empty zone")
 	}
@@ -1775,7 +2023,7 @@ func (c *Connector) ListCertificates(filter endpoint.Filter) ([]certificate.Cert
 	for offset := 0; limit > 0; limit, offset = limit-batchSize, offset+batchSize {
 		var b []certificate.CertificateInfo
 		var err error
-		b, err = c.getCertsBatch(offset, min(limit, batchSize), filter.WithExpired)
+		b, err = c.getCertsBatch(ctx, offset, min(limit, batchSize), filter.WithExpired)
 		if err != nil {
 			return nil, err
 		}
@@ -1797,7 +2045,7 @@ func (c *Connector) ListCertificates(filter endpoint.Filter) ([]certificate.Cert
 	return infos, nil
 }
 
-func (c *Connector) getCertsBatch(offset, limit int, withExpired bool) ([]certificate.CertificateInfo, error) {
+func (c *Connector) getCertsBatch(ctx context.Context, offset, limit int, withExpired bool) ([]certificate.CertificateInfo, error) {
 	url := urlResourceCertificatesList + urlResource(
 		"?ParentDNRecursive="+neturl.QueryEscape(getPolicyDN(c.zone))+
 			"&limit="+fmt.Sprintf("%d", limit)+
@@ -1805,7 +2053,7 @@ func (c *Connector) getCertsBatch(offset, limit int, withExpired bool) ([]certif
 	if !withExpired {
 		url += urlResource("&ValidToGreater=" + neturl.QueryEscape(time.Now().Format(time.RFC3339)))
 	}
-	statusCode, status, body, err := c.request("GET", url, nil)
+	statusCode, status, body, err := c.request(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1841,7 +2089,7 @@ func parseHostPort(s string) (host string, port string, err error) {
 	return
 }
 
-func (c *Connector) dissociate(certDN, applicationDN string) error {
+func (c *Connector) dissociate(ctx context.Context, certDN, applicationDN string) error {
 	req := struct {
 		CertificateDN string
 		ApplicationDN []string
@@ -1852,7 +2100,7 @@ func (c *Connector) dissociate(certDN, applicationDN string) error {
 		true,
 	}
 	log.Println("Dissociating device", applicationDN)
-	statusCode, status, body, err := c.request("POST", urlResourceCertificatesDissociate, req)
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceCertificatesDissociate, req)
 	if err != nil {
 		return err
 	}
@@ -1862,7 +2110,7 @@ func (c *Connector) dissociate(certDN, applicationDN string) error {
 	return nil
 }
 
-func (c *Connector) associate(certDN, applicationDN string, pushToNew bool) error {
+func (c *Connector) associate(ctx context.Context, certDN, applicationDN string, pushToNew bool) error {
 	req := struct {
 		CertificateDN string
 		ApplicationDN []string
@@ -1873,7 +2121,7 @@ func (c *Connector) associate(certDN, applicationDN string, pushToNew bool) erro
 		pushToNew,
 	}
 	log.Println("Associating device", applicationDN)
-	statusCode, status, body, err := c.request("POST", urlResourceCertificatesAssociate, req)
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceCertificatesAssociate, req)
 	if err != nil {
 		return err
 	}
@@ -1884,7 +2132,7 @@ func (c *Connector) associate(certDN, applicationDN string, pushToNew bool) erro
 	return nil
 }
 
-func (c *Connector) configDNToGuid(objectDN string) (guid string, err error) {
+func (c *Connector) configDNToGuid(ctx context.Context, objectDN string) (guid string, err error) {
 
 	req := struct {
 		ObjectDN string
@@ -1901,7 +2149,7 @@ func (c *Connector) configDNToGuid(objectDN string) (guid string, err error) {
 	}
 
 	log.Println("Getting guid for object DN", objectDN)
-	statusCode, status, body, err := c.request("POST", urlResourceConfigDnToGuid, req)
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceConfigDnToGuid, req)
 
 	if err != nil {
 		return guid, err
@@ -1932,8 +2180,8 @@ func (c *Connector) configDNToGuid(objectDN string) (guid string, err error) {
 
 }
 
-func (c *Connector) findObjectsOfClass(req *findObjectsOfClassRequest) (*findObjectsOfClassResponse, error) {
-	statusCode, statusString, body, err := c.request("POST", urlResourceFindObjectsOfClass, req)
+func (c *Connector) findObjectsOfClass(ctx context.Context, req *findObjectsOfClassRequest) (*findObjectsOfClassResponse, error) {
+	statusCode, statusString, body, err := c.request(ctx, "POST", urlResourceFindObjectsOfClass, req)
 	if err != nil {
 		return nil, err
 	}
@@ -1946,6 +2194,11 @@ func (c *Connector) findObjectsOfClass(req *findObjectsOfClassRequest) (*findObj
 
 // GetZonesByParent returns a list of valid zones for a TPP parent folder specified by parent
 func (c *Connector) GetZonesByParent(parent string) ([]string, error) {
+	return c.GetZonesByParentContext(context.Background(), parent)
+}
+
+// GetZonesByParentContext is GetZonesByParent with a context that cancels its requests to the platform
+func (c *Connector) GetZonesByParentContext(ctx context.Context, parent string) ([]string, error) {
 	var zones []string
 
 	parentFolderDn := parent
@@ -1957,7 +2210,7 @@ func (c *Connector) GetZonesByParent(parent string) ([]string, error) {
 		Class:    "Policy",
 		ObjectDN: parentFolderDn,
 	}
-	response, err := c.findObjectsOfClass(&request)
+	response, err := c.findObjectsOfClass(ctx, &request)
 	if err != nil {
 		return nil, err
 	}
@@ -1969,7 +2222,7 @@ func (c *Connector) GetZonesByParent(parent string) ([]string, error) {
 	return zones, nil
 }
 
-func createPolicyAttribute(c *Connector, at string, av []string, n string, l bool) (statusCode int, statusText string, body []byte, err error) {
+func createPolicyAttribute(ctx context.Context, c *Connector, at string, av []string, n string, l bool) (statusCode int, statusText string, body []byte, err error) {
 
 	request := policy.PolicySetAttributePayloadRequest{
 		Locked:        l,
@@ -1981,7 +2234,7 @@ func createPolicyAttribute(c *Connector, at string, av []string, n string, l boo
 	// if is locked is a policy value
 	// if is not locked then is a default.
 
-	statusCode, statusText, body, err = c.request("POST", urlResourceWritePolicy, request)
+	statusCode, statusText, body, err = c.request(ctx, "POST", urlResourceWritePolicy, request)
 	if err != nil {
 		return statusCode, statusText, body, err
 	}
@@ -2001,7 +2254,7 @@ func createPolicyAttribute(c *Connector, at string, av []string, n string, l boo
 	return statusCode, statusText, body, err
 }
 
-func getPolicyAttribute(c *Connector, at string, n string) (s []string, b *bool, err error) {
+func getPolicyAttribute(ctx context.Context, c *Connector, at string, n string) (s []string, b *bool, err error) {
 
 	request := policy.PolicyGetAttributePayloadRequest{
 		ObjectDN:      n,
@@ -2011,7 +2264,7 @@ func getPolicyAttribute(c *Connector, at string, n string) (s []string, b *bool,
 	}
 	// if is locked is a policy value
 	// if is not locked then is a default.
-	_, _, body, err := c.request("POST", urlResourceReadPolicy, request)
+	_, _, body, err := c.request(ctx, "POST", urlResourceReadPolicy, request)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -2030,104 +2283,104 @@ func getPolicyAttribute(c *Connector, at string, n string) (s []string, b *bool,
 	return nil, nil, nil
 }
 
-func resetTPPAttributes(zone string, c *Connector) error {
+func resetTPPAttributes(ctx context.Context, zone string, c *Connector) error {
 
 	//reset Contact
-	err := resetTPPAttribute(c, policy.TppContact, zone)
+	err := resetTPPAttribute(ctx, c, policy.TppContact, zone)
 	if err != nil {
 		return err
 	}
 
 	//reset Domain Suffix Whitelist
-	err = resetTPPAttribute(c, policy.TppDomainSuffixWhitelist, zone)
+	err = resetTPPAttribute(ctx, c, policy.TppDomainSuffixWhitelist, zone)
 	if err != nil {
 		return err
 	}
 
 	//reset Prohibit Wildcard
-	err = resetTPPAttribute(c, policy.TppProhibitWildcard, zone)
+	err = resetTPPAttribute(ctx, c, policy.TppProhibitWildcard, zone)
 	if err != nil {
 		return err
 	}
 
 	//reset Certificate Authority
-	err = resetTPPAttribute(c, policy.TppCertificateAuthority, zone)
+	err = resetTPPAttribute(ctx, c, policy.TppCertificateAuthority, zone)
 	if err != nil {
 		return err
 	}
 
 	//reset Organization attribute
-	err = resetTPPAttribute(c, policy.TppOrganization, zone)
+	err = resetTPPAttribute(ctx, c, policy.TppOrganization, zone)
 	if err != nil {
 		return err
 	}
 
 	//reset Organizational Unit attribute
-	err = resetTPPAttribute(c, policy.TppOrganizationalUnit, zone)
+	err = resetTPPAttribute(ctx, c, policy.TppOrganizationalUnit, zone)
 	if err != nil {
 		return err
 	}
 
 	//reset City attribute
-	err = resetTPPAttribute(c, policy.TppCity, zone)
+	err = resetTPPAttribute(ctx, c, policy.TppCity, zone)
 	if err != nil {
 		return err
 	}
 
 	//reset State attribute
-	err = resetTPPAttribute(c, policy.TppState, zone)
+	err = resetTPPAttribute(ctx, c, policy.TppState, zone)
 	if err != nil {
 		return err
 	}
 
 	//reset Country attribute
-	err = resetTPPAttribute(c, policy.TppCountry, zone)
+	err = resetTPPAttribute(ctx, c, policy.TppCountry, zone)
 	if err != nil {
 		return err
 	}
 
 	//reset Key Algorithm attribute
-	err = resetTPPAttribute(c, policy.TppKeyAlgorithm, zone)
+	err = resetTPPAttribute(ctx, c, policy.TppKeyAlgorithm, zone)
 	if err != nil {
 		return err
 	}
 
 	//reset Key Bit Strength
-	err = resetTPPAttribute(c, policy.TppKeyBitStrength, zone)
+	err = resetTPPAttribute(ctx, c, policy.TppKeyBitStrength, zone)
 	if err != nil {
 		return err
 	}
 
 	//reset Elliptic Curve attribute
-	err = resetTPPAttribute(c, policy.TppEllipticCurve, zone)
+	err = resetTPPAttribute(ctx, c, policy.TppEllipticCurve, zone)
 	if err != nil {
 		return err
 	}
 
 	//reset Manual Csr attribute
-	err = resetTPPAttribute(c, policy.ServiceGenerated, zone)
+	err = resetTPPAttribute(ctx, c, policy.ServiceGenerated, zone)
 	if err != nil {
 		return err
 	}
 
 	//reset Manual Csr attribute
-	err = resetTPPAttribute(c, policy.TppProhibitedSANTypes, zone)
+	err = resetTPPAttribute(ctx, c, policy.TppProhibitedSANTypes, zone)
 	if err != nil {
 		return err
 	}
 
 	//reset Allow Private Key Reuse" & "Want Renewal
-	err = resetTPPAttribute(c, policy.TppAllowPrivateKeyReuse, zone)
+	err = resetTPPAttribute(ctx, c, policy.TppAllowPrivateKeyReuse, zone)
 	if err != nil {
 		return err
 	}
 
-	err = resetTPPAttribute(c, policy.TppWantRenewal, zone)
+	err = resetTPPAttribute(ctx, c, policy.TppWantRenewal, zone)
 	if err != nil {
 		return err
 	}
 
-	err = resetTPPAttribute(c, policy.TppManagementType, zone)
+	err = resetTPPAttribute(ctx, c, policy.TppManagementType, zone)
 	if err != nil {
 		return err
 	}
@@ -2135,7 +2388,7 @@ func resetTPPAttributes(zone string, c *Connector) error {
 	return nil
 }
 
-func resetTPPAttribute(c *Connector, at, zone string) error {
+func resetTPPAttribute(ctx context.Context, c *Connector, at, zone string) error {
 
 	request := policy.ClearTTPAttributesRequest{
 		ObjectDN:      zone,
@@ -2145,7 +2398,7 @@ func resetTPPAttribute(c *Connector, at, zone string) error {
 	// if is locked is a policy value
 	// if is not locked then is a default.
 
-	_, _, body, err := c.request("POST", urlResourceCleanPolicy, request)
+	_, _, body, err := c.request(ctx, "POST", urlResourceCleanPolicy, request)
 	if err != nil {
 		return err
 	}
@@ -2166,24 +2419,44 @@ func resetTPPAttribute(c *Connector, at, zone string) error {
 }
 
 func (c *Connector) RequestSSHCertificate(req *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
+	return c.RequestSSHCertificateContext(context.Background(), req)
+}
+
+// RequestSSHCertificateContext is RequestSSHCertificate with a context that cancels its requests to the platform
+func (c *Connector) RequestSSHCertificateContext(ctx context.Context, req *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
 
-	return RequestSshCertificate(c, req)
+	return RequestSshCertificateContext(ctx, c, req)
 
 }
 
 func (c *Connector) RetrieveSSHCertificate(req *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
-	return RetrieveSshCertificate(c, req)
+	return c.RetrieveSSHCertificateContext(context.Background(), req)
+}
+
+// RetrieveSSHCertificateContext is RetrieveSSHCertificate with a context that cancels its requests to the platform
+func (c *Connector) RetrieveSSHCertificateContext(ctx context.Context, req *certificate.SshCertRequest) (response *certificate.SshCertificateObject, err error) {
+	return RetrieveSshCertificateContext(ctx, c, req)
 }
 
-func (c *Connector) ProvisionCertificate(_ *domain.ProvisioningRequest, _ *domain.ProvisioningOptions) (*domain.ProvisioningMetadata, error) {
+func (c *Connector) ProvisionCertificate(req *domain.ProvisioningRequest, options *domain.ProvisioningOptions) (*domain.ProvisioningMetadata, error) {
+	return c.ProvisionCertificateContext(context.Background(), req, options)
+}
+
+// ProvisionCertificateContext is ProvisionCertificate with a context that cancels its requests to the platform
+func (c *Connector) ProvisionCertificateContext(_ context.Context, _ *domain.ProvisioningRequest, _ *domain.ProvisioningOptions) (*domain.ProvisioningMetadata, error) {
 	panic("operation is not supported yet")
 }
 
 func (c *Connector) RetrieveCertificateMetaData(dn string) (*certificate.CertificateMetaData, error) {
+	return c.RetrieveCertificateMetaDataContext(context.Background(), dn)
+}
+
+// RetrieveCertificateMetaDataContext is RetrieveCertificateMetaData with a context that cancels its requests to the platform
+func (c *Connector) RetrieveCertificateMetaDataContext(ctx context.Context, dn string) (*certificate.CertificateMetaData, error) {
 
 	//first step convert dn to guid
 	request := DNToGUIDRequest{ObjectDN: dn}
-	statusCode, status, body, err := c.request("POST", urlResourceDNToGUID, request)
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceDNToGUID, request)
 
 	if err != nil {
 		return nil, err
@@ -2198,7 +2471,7 @@ func (c *Connector) RetrieveCertificateMetaData(dn string) (*certificate.Certifi
 	//second step get certificate metadata
 	url := fmt.Sprintf("%s%s", urlResourceCertificate, guidInfo.GUID)
 
-	statusCode, status, body, err = c.request("GET", urlResource(url), nil)
+	statusCode, status, body, err = c.request(ctx, "GET", urlResource(url), nil)
 
 	if err != nil {
 		return nil, err
@@ -17,6 +17,7 @@
 package tpp
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/rsa"
 	"crypto/sha1"
@@ -219,7 +220,7 @@ func TestRetrieveSelfIdentity(t *testing.T) {
 		t.Fatalf("err is not nil, err: %s", err)
 	}
 
-	identity, err := tpp.retrieveSelfIdentity()
+	identity, err := tpp.retrieveSelfIdentity(context.Background())
 	if err != nil {
 		t.Fatalf("Failed to get the used user. Error: %v", err)
 	}
@@ -618,7 +619,7 @@ func TestRequestCertificateWithContactEmails(t *testing.T) {
 	if len(metadata.Contact) != 1 {
 		t.Fatalf("expected exactly one contact but got %d", len(metadata.Contact))
 	}
-	resp, err := tpp.browseIdentities(BrowseIdentitiesRequest{Filter: "mael.valais@venafi.com", Limit: 2, IdentityType: 1})
+	resp, err := tpp.browseIdentities(context.Background(), BrowseIdentitiesRequest{Filter: "mael.valais@venafi.com", Limit: 2, IdentityType: 1})
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1222,14 +1223,14 @@ func DoRevokeAndDisableCertificate(t *testing.T, tpp *Connector) (req *certifica
 	}
 
 	t.Logf("Verifying the Certificate is Disabled")
-	guid, err := tpp.configDNToGuid(certDN)
+	guid, err := tpp.configDNToGuid(context.Background(), certDN)
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
 	if guid == "" {
 		t.Fatalf("Certificate with DN %s doesn't exists", certDN)
 	}
-	details, err := tpp.searchCertificateDetails(guid)
+	details, err := tpp.searchCertificateDetails(context.Background(), guid)
 	if err != nil {
 		t.Fatalf("%s", err)
 	}
@@ -2417,7 +2418,7 @@ func TestOmitSans(t *testing.T) {
 		Timeout:   30 * time.Second,
 	}
 
-	tppReq, err := tpp.prepareRequest(&req, tpp.zone)
+	tppReq, err := tpp.prepareRequest(context.Background(), &req, tpp.zone)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -3437,7 +3438,7 @@ func Test_getIdentity(t *testing.T) {
 			tpp, err := NewConnector(server.URL, `\VED\Policy\Test`, true, ca)
 			require.NoError(t, err)
 
-			got, gotErr := tpp.getIdentity(tt.givenFilter)
+			got, gotErr := tpp.getIdentity(context.Background(), tt.givenFilter)
 			if tt.wantErr != "" {
 				require.EqualError(t, gotErr, tt.wantErr)
 				return
@@ -17,6 +17,7 @@
 package tpp
 
 import (
+	"context"
 	"crypto/sha1"
 	"encoding/json"
 	"encoding/pem"
@@ -50,7 +51,7 @@ type CertificateDetailsResponse struct {
 	Disabled  bool `json:",omitempty"`
 }
 
-func (c *Connector) searchCertificatesByFingerprint(fp string) (*certificate.CertSearchResponse, error) {
+func (c *Connector) searchCertificatesByFingerprint(ctx context.Context, fp string) (*certificate.CertSearchResponse, error) {
 	fp = strings.Replace(fp, ":", "", -1)
 	fp = strings.Replace(fp, ".", "", -1)
 	fp = strings.ToUpper(fp)
@@ -58,12 +59,12 @@ func (c *Connector) searchCertificatesByFingerprint(fp string) (*certificate.Cer
 	var req certificate.SearchRequest
 	req = append(req, fmt.Sprintf("Thumbprint=%s", fp))
 
-	return c.SearchCertificates(&req)
+	return c.SearchCertificatesContext(ctx, &req)
 }
 
-func (c *Connector) configReadDN(req ConfigReadDNRequest) (resp ConfigReadDNResponse, err error) {
+func (c *Connector) configReadDN(ctx context.Context, req ConfigReadDNRequest) (resp ConfigReadDNResponse, err error) {
 
-	statusCode, status, body, err := c.request("POST", urlResourceConfigReadDn, req)
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceConfigReadDn, req)
 	if err != nil {
 		return resp, err
 	}
@@ -80,11 +81,11 @@ func (c *Connector) configReadDN(req ConfigReadDNRequest) (resp ConfigReadDNResp
 	return resp, nil
 }
 
-func (c *Connector) searchCertificateDetails(guid string) (*CertificateDetailsResponse, error) {
+func (c *Connector) searchCertificateDetails(ctx context.Context, guid string) (*CertificateDetailsResponse, error) {
 	var err error
 
 	url := fmt.Sprintf("%s%s", urlResourceCertificateSearch, guid)
-	statusCode, _, body, err := c.request("GET", urlResource(url), nil)
+	statusCode, _, body, err := c.request(ctx, "GET", urlResource(url), nil)
 	if err != nil {
 		return nil, err
 	}
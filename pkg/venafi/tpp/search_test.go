@@ -17,6 +17,7 @@
 package tpp
 
 import (
+	"context"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
@@ -231,13 +232,13 @@ func TestRequestAndSearchCertificate(t *testing.T) {
 	}
 
 	thumbprint := calcThumbprint(certCollections.Certificate)
-	searchResult, err := tpp.searchCertificatesByFingerprint(thumbprint)
+	searchResult, err := tpp.searchCertificatesByFingerprint(context.Background(), thumbprint)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	guid := searchResult.Certificates[0].CertificateRequestGuid
-	details, err := tpp.searchCertificateDetails(guid)
+	details, err := tpp.searchCertificateDetails(context.Background(), guid)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -257,7 +258,7 @@ func TestRequestAndSearchCertificate(t *testing.T) {
 		AttributeName: "Origin",
 	}
 
-	configResp, err := tpp.configReadDN(configReq)
+	configResp, err := tpp.configReadDN(context.Background(), configReq)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -288,7 +289,7 @@ func TestRequestAndSearchCertificate(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	details, err = tpp.searchCertificateDetails(guid)
+	details, err = tpp.searchCertificateDetails(context.Background(), guid)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -325,7 +326,7 @@ func TestRequestAndSearchCertificate(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	details, err = tpp.searchCertificateDetails(guid)
+	details, err = tpp.searchCertificateDetails(context.Background(), guid)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -411,13 +412,13 @@ func TestRequestAndSearchCertificateWithFriendlyName(t *testing.T) {
 	}
 
 	thumbprint := calcThumbprint(certCollections.Certificate)
-	searchResult, err := tpp.searchCertificatesByFingerprint(thumbprint)
+	searchResult, err := tpp.searchCertificatesByFingerprint(context.Background(), thumbprint)
 	if err != nil {
 		t.Fatal(err)
 	}
 
 	guid := searchResult.Certificates[0].CertificateRequestGuid
-	details, err := tpp.searchCertificateDetails(guid)
+	details, err := tpp.searchCertificateDetails(context.Background(), guid)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -437,7 +438,7 @@ func TestRequestAndSearchCertificateWithFriendlyName(t *testing.T) {
 		AttributeName: "Origin",
 	}
 
-	configResp, err := tpp.configReadDN(configReq)
+	configResp, err := tpp.configReadDN(context.Background(), configReq)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -468,7 +469,7 @@ func TestRequestAndSearchCertificateWithFriendlyName(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	details, err = tpp.searchCertificateDetails(guid)
+	details, err = tpp.searchCertificateDetails(context.Background(), guid)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -505,7 +506,7 @@ func TestRequestAndSearchCertificateWithFriendlyName(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	details, err = tpp.searchCertificateDetails(guid)
+	details, err = tpp.searchCertificateDetails(context.Background(), guid)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -548,7 +549,7 @@ func TestSearchDevice(t *testing.T) {
 		AttributeName: "Certificate",
 	}
 
-	resp, err := tpp.configReadDN(req)
+	resp, err := tpp.configReadDN(context.Background(), req)
 	if err != nil {
 		t.Fatal(err)
 	}
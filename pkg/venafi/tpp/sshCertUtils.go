@@ -17,6 +17,7 @@
 package tpp
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -36,6 +37,11 @@ const (
 )
 
 func RequestSshCertificate(c *Connector, req *certificate.SshCertRequest) (*certificate.SshCertificateObject, error) {
+	return RequestSshCertificateContext(context.Background(), c, req)
+}
+
+// RequestSshCertificateContext is RequestSshCertificate with a context that cancels its requests to the platform
+func RequestSshCertificateContext(ctx context.Context, c *Connector, req *certificate.SshCertRequest) (*certificate.SshCertificateObject, error) {
 
 	sshCertReq := convertToSshCertReq(req)
 
@@ -47,7 +53,7 @@ func RequestSshCertificate(c *Connector, req *certificate.SshCertRequest) (*cert
 
 	//TODO: Maybe, there is a better way to set the timeout.
 	c.client.Timeout = time.Duration(req.Timeout) * time.Second
-	statusCode, status, body, err := c.request("POST", urlResourceSshCertReq, sshCertReq)
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceSshCertReq, sshCertReq)
 	if err != nil {
 		return nil, err
 	}
@@ -125,7 +131,7 @@ func convertToSshCertReq(req *certificate.SshCertRequest) certificate.TPPSshCert
 	}
 
 	if req.PublicKeyData != "" {
-		tppSshCertReq.PublicKeyData = req.PublicKeyData
+		tppSshCertReq.PublicKeyData = util.NormalizeSshPublicKey(req.PublicKeyData)
 	}
 
 	if req.Template != "" {
@@ -143,6 +149,11 @@ func convertToSshCertReq(req *certificate.SshCertRequest) certificate.TPPSshCert
 }
 
 func RetrieveSshCertificate(c *Connector, req *certificate.SshCertRequest) (*certificate.SshCertificateObject, error) {
+	return RetrieveSshCertificateContext(context.Background(), c, req)
+}
+
+// RetrieveSshCertificateContext is RetrieveSshCertificate with a context that cancels its requests to the platform
+func RetrieveSshCertificateContext(ctx context.Context, c *Connector, req *certificate.SshCertRequest) (*certificate.SshCertificateObject, error) {
 	var reqRetrieve certificate.TppSshCertRetrieveRequest
 
 	if req.PickupID != "" {
@@ -164,7 +175,7 @@ func RetrieveSshCertificate(c *Connector, req *certificate.SshCertRequest) (*cer
 	startTime := time.Now()
 	for {
 		var retrieveResponse *certificate.TppSshCertOperationResponse
-		retrieveResponse, err := retrieveSshCerOnce(reqRetrieve, c)
+		retrieveResponse, err := retrieveSshCerOnce(ctx, reqRetrieve, c)
 		if err != nil {
 			return nil, err
 		}
@@ -182,12 +193,14 @@ func RetrieveSshCertificate(c *Connector, req *certificate.SshCertRequest) (*cer
 		if time.Now().After(startTime.Add(req.Timeout)) {
 			return nil, endpoint.ErrRetrieveCertificateTimeout{CertificateID: req.PickupID}
 		}
-		time.Sleep(2 * time.Second)
+		if err = util.SleepContext(ctx, 2*time.Second); err != nil {
+			return nil, err
+		}
 	}
 }
 
-func retrieveSshCerOnce(sshRetrieveReq certificate.TppSshCertRetrieveRequest, c *Connector) (*certificate.TppSshCertOperationResponse, error) {
-	statusCode, status, body, err := c.request("POST", urlResourceSshCertRet, sshRetrieveReq)
+func retrieveSshCerOnce(ctx context.Context, sshRetrieveReq certificate.TppSshCertRetrieveRequest, c *Connector) (*certificate.TppSshCertOperationResponse, error) {
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceSshCertRet, sshRetrieveReq)
 	if err != nil {
 		return nil, err
 	}
@@ -263,20 +276,25 @@ func getSshConfigUrl(key, value string) string {
 }
 
 func RetrieveSshConfig(c *Connector, ca *certificate.SshCaTemplateRequest) (*certificate.SshConfig, error) {
+	return RetrieveSshConfigContext(context.Background(), c, ca)
+}
+
+// RetrieveSshConfigContext is RetrieveSshConfig with a context that cancels its requests to the platform
+func RetrieveSshConfigContext(ctx context.Context, c *Connector, ca *certificate.SshCaTemplateRequest) (*certificate.SshConfig, error) {
 
 	var url string
 	if ca.Template != "" {
 		fullPath := getSshCaDN(ca.Template)
 		url = getSshConfigUrl("DN", fullPath)
-		fmt.Println("Retrieving the configured CA public key for template:", fullPath)
+		log.Println("Retrieving the configured CA public key for template:", fullPath)
 	} else if ca.Guid != "" {
 		url = getSshConfigUrl("guid", ca.Guid)
-		fmt.Println("Retrieving the configured CA public key for template with GUID:", ca.Guid)
+		log.Println("Retrieving the configured CA public key for template with GUID:", ca.Guid)
 	} else {
 		return nil, fmt.Errorf("CA template or GUID are not specified")
 	}
 
-	statusCode, status, body, err := c.request("GET", urlResource(url), nil)
+	statusCode, status, body, err := c.request(ctx, "GET", urlResource(url), nil)
 
 	if err != nil {
 		return nil, err
@@ -292,7 +310,7 @@ func RetrieveSshConfig(c *Connector, ca *certificate.SshCaTemplateRequest) (*cer
 	}
 
 	if c.accessToken != "" {
-		principals, err := RetrieveSshCaPrincipals(c, ca)
+		principals, err := RetrieveSshCaPrincipalsContext(ctx, c, ca)
 		if err != nil {
 			return nil, err
 		}
@@ -306,8 +324,13 @@ func RetrieveSshConfig(c *Connector, ca *certificate.SshCaTemplateRequest) (*cer
 }
 
 func GetAvailableSshTemplates(c *Connector) ([]certificate.SshAvaliableTemplate, error) {
+	return GetAvailableSshTemplatesContext(context.Background(), c)
+}
+
+// GetAvailableSshTemplatesContext is GetAvailableSshTemplates with a context that cancels its requests to the platform
+func GetAvailableSshTemplatesContext(ctx context.Context, c *Connector) ([]certificate.SshAvaliableTemplate, error) {
 	var response []certificate.SshAvaliableTemplate
-	statusCode, status, body, err := c.request("GET", urlResourceSshTemplateAvaliable, nil)
+	statusCode, status, body, err := c.request(ctx, "GET", urlResourceSshTemplateAvaliable, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -328,6 +351,11 @@ func GetAvailableSshTemplates(c *Connector) ([]certificate.SshAvaliableTemplate,
 }
 
 func RetrieveSshCaPrincipals(c *Connector, ca *certificate.SshCaTemplateRequest) ([]string, error) {
+	return RetrieveSshCaPrincipalsContext(context.Background(), c, ca)
+}
+
+// RetrieveSshCaPrincipalsContext is RetrieveSshCaPrincipals with a context that cancels its requests to the platform
+func RetrieveSshCaPrincipalsContext(ctx context.Context, c *Connector, ca *certificate.SshCaTemplateRequest) ([]string, error) {
 
 	tppReq := certificate.SshTppCaTemplateRequest{}
 
@@ -341,7 +369,7 @@ func RetrieveSshCaPrincipals(c *Connector, ca *certificate.SshCaTemplateRequest)
 		return nil, fmt.Errorf("CA template or GUID are not specified")
 	}
 
-	statusCode, status, body, err := c.request("POST", urlResourceSshCADetails, tppReq)
+	statusCode, status, body, err := c.request(ctx, "POST", urlResourceSshCADetails, tppReq)
 
 	if err != nil {
 		return nil, err
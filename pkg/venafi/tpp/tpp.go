@@ -18,23 +18,22 @@ package tpp
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
-	"net"
 	"net/http"
 	"regexp"
 	"strings"
-	"time"
 
 	"github.com/go-http-utils/headers"
 
 	"github.com/Venafi/vcert/v5/pkg/certificate"
 	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/httputils"
 )
 
 const defaultKeySize = 2048
@@ -481,7 +480,7 @@ func retrieveChainOptionFromString(order string) retrieveChainOption {
 	}
 }
 
-func (c *Connector) request(method string, resource urlResource, data interface{}) (statusCode int, statusText string, body []byte, err error) {
+func (c *Connector) request(ctx context.Context, method string, resource urlResource, data interface{}) (statusCode int, statusText string, body []byte, err error) {
 	url := c.baseURL + string(resource)
 	var payload io.Reader
 	var b []byte
@@ -490,7 +489,10 @@ func (c *Connector) request(method string, resource urlResource, data interface{
 		payload = bytes.NewReader(b)
 	}
 
-	r, _ := http.NewRequest(method, url, payload)
+	r, err := http.NewRequestWithContext(ctx, method, url, payload)
+	if err != nil {
+		return
+	}
 	r.Close = true
 	r.Header.Set(headers.UserAgent, c.userAgent)
 	if c.accessToken != "" {
@@ -534,46 +536,23 @@ func (c *Connector) getHTTPClient() *http.Client {
 	if c.client != nil {
 		return c.client
 	}
-	var netTransport = &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		DialContext: (&net.Dialer{
-			Timeout:   30 * time.Second,
-			KeepAlive: 30 * time.Second,
-			DualStack: true,
-		}).DialContext,
-		MaxIdleConns:          100,
-		IdleConnTimeout:       90 * time.Second,
-		TLSHandshakeTimeout:   10 * time.Second,
-		ExpectContinueTimeout: 1 * time.Second,
-	}
-	tlsConfig := http.DefaultTransport.(*http.Transport).TLSClientConfig
-	/* #nosec */
-	if c.trust != nil {
-		if tlsConfig == nil {
-			tlsConfig = &tls.Config{
-				MinVersion: tls.VersionTLS12,
-			}
-		} else {
-			tlsConfig = tlsConfig.Clone()
-		}
-		tlsConfig.RootCAs = c.trust
-	}
-	netTransport.TLSClientConfig = tlsConfig
-	c.client = &http.Client{
-		Timeout:   time.Second * 30,
-		Transport: netTransport,
-	}
+	c.client = httputils.NewClient(c.trust, c.retryPolicy)
 	return c.client
 }
 
 // GenerateRequest creates a new certificate request, based on the zone/policy configuration and the user data
 func (c *Connector) GenerateRequest(config *endpoint.ZoneConfiguration, req *certificate.Request) (err error) {
+	return c.GenerateRequestContext(context.Background(), config, req)
+}
+
+// GenerateRequestContext is GenerateRequest with a context that cancels its requests to the platform
+func (c *Connector) GenerateRequestContext(ctx context.Context, config *endpoint.ZoneConfiguration, req *certificate.Request) (err error) {
 	if req.KeyType == certificate.KeyTypeED25519 {
 		return fmt.Errorf("Unable to request certificate from TPP, ed25519 key type is not for TPP")
 	}
 
 	if config == nil {
-		config, err = c.ReadZoneConfiguration()
+		config, err = c.ReadZoneConfigurationContext(ctx)
 		if err != nil {
 			return fmt.Errorf("could not read zone configuration: %s", err)
 		}
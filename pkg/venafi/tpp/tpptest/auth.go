@@ -0,0 +1,412 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tpptest
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultScope = "certificate:manage,revoke"
+
+// token is an OAuth grant issued by the server
+type token struct {
+	accessToken   string
+	refreshToken  string
+	clientID      string
+	scope         string
+	identity      string
+	grantIssuedOn time.Time
+	issuedOn      time.Time
+	expires       time.Time
+	refreshUntil  time.Time
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	Expires      int64  `json:"expires"`
+	ExpiresIn    int64  `json:"expires_in"`
+	Identity     string `json:"identity"`
+	RefreshToken string `json:"refresh_token"`
+	RefreshUntil int64  `json:"refresh_until"`
+	Scope        string `json:"scope"`
+	TokenType    string `json:"token_type"`
+}
+
+// identity is a TPP identity, as returned by the Identity endpoints
+type identity struct {
+	FullName          string `json:",omitempty"`
+	Name              string `json:",omitempty"`
+	Prefix            string `json:",omitempty"`
+	PrefixedName      string `json:",omitempty"`
+	PrefixedUniversal string `json:",omitempty"`
+	Type              int    `json:",omitempty"`
+	Universal         string `json:",omitempty"`
+	email             string
+}
+
+func newIdentity(name, email string) identity {
+	universal := newGUID()
+	return identity{
+		FullName:          "\\VED\\Identity\\" + name,
+		Name:              name,
+		Prefix:            "local",
+		PrefixedName:      "local:" + name,
+		PrefixedUniversal: "local:" + universal,
+		Type:              1,
+		Universal:         universal,
+		email:             email,
+	}
+}
+
+// AddIdentity adds a local user that can be used as contact or approver. The email is optional
+func (s *Server) AddIdentity(name, email string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.identities = append(s.identities, newIdentity(name, email))
+}
+
+// newTLSConfig requests, without requiring, a client certificate so that certificate authentication can be tested
+func newTLSConfig() *tls.Config {
+	return &tls.Config{
+		ClientAuth: tls.RequestClientCert,
+		MinVersion: tls.VersionTLS12,
+	}
+}
+
+func randomToken() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// authenticated checks the API key or access token of r, writing an authentication error when they are not valid
+func (s *Server) authenticated(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if apiKey := r.Header.Get("x-venafi-api-key"); apiKey != "" {
+		validUntil, ok := s.apiKeys[apiKey]
+		if ok && time.Now().Before(validUntil) {
+			return true
+		}
+		writeError(w, http.StatusUnauthorized, "Your authorization has been denied for this request. The API key is not valid or has expired")
+		return false
+	}
+	if _, err := s.bearerToken(r); err != nil {
+		writeAuthError(w, http.StatusUnauthorized, "invalid_token", err.Error())
+		return false
+	}
+	return true
+}
+
+// bearerToken returns the valid access token sent with r. It must be called with the lock held
+func (s *Server) bearerToken(r *http.Request) (*token, error) {
+	accessToken, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || accessToken == "" {
+		return nil, fmt.Errorf("no access token was provided")
+	}
+	t, ok := s.accessTokens[accessToken]
+	if !ok {
+		return nil, fmt.Errorf("the access token is not valid")
+	}
+	if time.Now().After(t.expires) {
+		return nil, fmt.Errorf("the access token has expired")
+	}
+	return t, nil
+}
+
+// authorize issues an API key, as the legacy vedsdk/authorize endpoint does
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username string
+		Password string
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if req.Username != s.Username || req.Password != s.Password {
+		writeError(w, http.StatusUnauthorized, "Username/password combination not valid")
+		return
+	}
+
+	s.mu.Lock()
+	apiKey := strings.Trim(randomToken(), "=")
+	validUntil := time.Now().Add(s.apiKeyLifetime())
+	s.apiKeys[apiKey] = validUntil
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, struct {
+		APIKey     string
+		ValidUntil string
+	}{apiKey, fmt.Sprintf("/Date(%d)/", validUntil.UnixMilli())})
+}
+
+func (s *Server) apiKeyLifetime() time.Duration {
+	if s.TokenLifetime > 0 && s.TokenLifetime < apiKeyTTL {
+		return s.TokenLifetime
+	}
+	return apiKeyTTL
+}
+
+func (s *Server) serveAuth(w http.ResponseWriter, r *http.Request, path string) {
+	switch r.Method + " " + path {
+	case "GET vedauth/authorize/isauthserver":
+		s.isAuthServer(w)
+	case "POST vedauth/authorize/oauth":
+		s.authorizeOAuth(w, r)
+	case "POST vedauth/authorize/certificate":
+		s.authorizeCertificate(w, r)
+	case "POST vedauth/authorize/token":
+		s.refreshAccessToken(w, r)
+	case "GET vedauth/authorize/verify":
+		s.verifyAccessToken(w, r)
+	case "GET vedauth/revoke/token":
+		s.revokeAccessToken(w, r)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Sprintf("No HTTP resource was found that matches the request URI %s", r.URL.Path))
+	}
+}
+
+// isAuthServer answers with the custom reason phrase the connector looks for, which requires writing the raw response
+func (s *Server) isAuthServer(w http.ResponseWriter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+	conn, buf, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	_, _ = buf.WriteString("HTTP/1.1 202 Venafi Authentication Server\r\nContent-Length: 0\r\nConnection: close\r\n\r\n")
+	_ = buf.Flush()
+}
+
+func (s *Server) authorizeOAuth(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ClientID string `json:"client_id"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+		Scope    string `json:"scope"`
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if req.Username != s.Username || req.Password != s.Password {
+		writeAuthError(w, http.StatusBadRequest, "invalid_grant", "Username/password combination not valid")
+		return
+	}
+	s.grant(w, req.ClientID, req.Scope)
+}
+
+// authorizeCertificate grants a token to any client presenting a certificate, whoever issued it
+func (s *Server) authorizeCertificate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ClientID string `json:"client_id"`
+		Scope    string `json:"scope"`
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		writeAuthError(w, http.StatusBadRequest, "invalid_grant", "No client certificate was provided")
+		return
+	}
+	s.grant(w, req.ClientID, req.Scope)
+}
+
+func (s *Server) grant(w http.ResponseWriter, clientID, scope string) {
+	if clientID == "" {
+		writeAuthError(w, http.StatusBadRequest, "invalid_request", "client_id is required")
+		return
+	}
+	if scope == "" {
+		scope = defaultScope
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	t := &token{
+		clientID:      clientID,
+		scope:         scope,
+		identity:      s.identities[0].PrefixedUniversal,
+		grantIssuedOn: now,
+		refreshUntil:  now.Add(90 * 24 * time.Hour),
+	}
+	s.issueToken(t)
+	resp := t.response()
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// issueToken sets a new access and refresh token pair on t. It must be called with the lock held
+func (s *Server) issueToken(t *token) {
+	delete(s.accessTokens, t.accessToken)
+	delete(s.refreshTokens, t.refreshToken)
+
+	t.accessToken = randomToken()
+	t.refreshToken = randomToken()
+	t.issuedOn = time.Now()
+	t.expires = t.issuedOn.Add(s.TokenLifetime)
+	s.accessTokens[t.accessToken] = t
+	s.refreshTokens[t.refreshToken] = t
+}
+
+func (t *token) response() tokenResponse {
+	return tokenResponse{
+		AccessToken:  t.accessToken,
+		Expires:      t.expires.Unix(),
+		ExpiresIn:    int64(time.Until(t.expires).Seconds()),
+		Identity:     t.identity,
+		RefreshToken: t.refreshToken,
+		RefreshUntil: t.refreshUntil.Unix(),
+		Scope:        t.scope,
+		TokenType:    "Bearer",
+	}
+}
+
+func (s *Server) refreshAccessToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ClientID     string `json:"client_id"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	t, ok := s.refreshTokens[req.RefreshToken]
+	if !ok || t.clientID != req.ClientID || time.Now().After(t.refreshUntil) {
+		s.mu.Unlock()
+		writeAuthError(w, http.StatusBadRequest, "invalid_grant", "The refresh token is not valid or has expired")
+		return
+	}
+	// as in TPP, refreshing invalidates the previous token pair
+	s.issueToken(t)
+	resp := t.response()
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) verifyAccessToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	t, err := s.bearerToken(r)
+	s.mu.Unlock()
+	if err != nil {
+		writeAuthError(w, http.StatusUnauthorized, "invalid_token", err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		AccessIssuedOn string `json:"access_issued_on_ISO8601"`
+		ClientID       string `json:"application"`
+		Expires        string `json:"expires_ISO8601"`
+		GrantIssuedOn  string `json:"grant_issued_on_ISO8601"`
+		Identity       string `json:"identity"`
+		Scope          string `json:"scope"`
+		ValidFor       int64  `json:"valid_for"`
+	}{
+		AccessIssuedOn: t.issuedOn.UTC().Format(time.RFC3339),
+		ClientID:       t.clientID,
+		Expires:        t.expires.UTC().Format(time.RFC3339),
+		GrantIssuedOn:  t.grantIssuedOn.UTC().Format(time.RFC3339),
+		Identity:       t.identity,
+		Scope:          t.scope,
+		ValidFor:       int64(time.Until(t.expires).Seconds()),
+	})
+}
+
+func (s *Server) revokeAccessToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	t, err := s.bearerToken(r)
+	if err == nil {
+		delete(s.accessTokens, t.accessToken)
+		delete(s.refreshTokens, t.refreshToken)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		writeAuthError(w, http.StatusUnauthorized, "invalid_token", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) identitySelf(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	self := s.identities[0]
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, struct{ Identities []identity }{[]identity{self}})
+}
+
+func (s *Server) browseIdentities(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Filter       string
+		Limit        int
+		IdentityType int
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// as in TPP, names match with an implicit trailing wildcard and emails match exactly
+	found := []identity{}
+	for _, id := range s.identities {
+		if strings.HasPrefix(strings.ToLower(id.Name), strings.ToLower(req.Filter)) || (id.email != "" && strings.EqualFold(id.email, req.Filter)) {
+			found = append(found, id)
+		}
+		if req.Limit > 0 && len(found) == req.Limit {
+			break
+		}
+	}
+	writeJSON(w, http.StatusOK, struct{ Identities []identity }{found})
+}
+
+func (s *Server) validateIdentity(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID struct{ PrefixedUniversal string }
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range s.identities {
+		if id.PrefixedUniversal == req.ID.PrefixedUniversal {
+			writeJSON(w, http.StatusOK, struct{ ID identity }{id})
+			return
+		}
+	}
+	writeError(w, http.StatusBadRequest, fmt.Sprintf("Identity %s does not exist", req.ID.PrefixedUniversal))
+}
+
+func writeAuthError(w http.ResponseWriter, statusCode int, errorID, description string) {
+	writeJSON(w, statusCode, struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}{errorID, description})
+}
@@ -0,0 +1,1066 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tpptest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/youmark/pkcs8"
+
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+)
+
+const defaultValidity = 90 * 24 * time.Hour
+
+// CertificateObject is the state of a certificate object kept by the server
+type CertificateObject struct {
+	DN               string
+	Guid             string
+	Certificate      *x509.Certificate
+	Revoked          bool
+	RevocationReason int
+	Disabled         bool
+	Consumers        []string
+	CustomFields     map[string][]string
+}
+
+type sanItem struct {
+	Type int
+	Name string
+}
+
+type nameValuePair struct {
+	Name  string
+	Value string
+}
+
+type certificateRequest struct {
+	PolicyDN             string
+	CADN                 string
+	ObjectName           string
+	Subject              string
+	OrganizationalUnit   string
+	Organization         string
+	City                 string
+	State                string
+	Country              string
+	SubjectAltNames      []sanItem
+	CASpecificAttributes []nameValuePair
+	Origin               string
+	PKCS10               string
+	KeyAlgorithm         string
+	KeyBitSize           int
+	EllipticCurve        string
+	CustomFields         []struct {
+		Name   string
+		Values []string
+	}
+	Devices []struct {
+		PolicyDN     string
+		ObjectName   string
+		Applications []struct{ ObjectName string }
+	}
+	Reenable bool
+}
+
+type certificateObject struct {
+	dn           string
+	guid         string
+	createdOn    time.Time
+	request      certificateRequest
+	csr          *x509.CertificateRequest
+	key          crypto.Signer
+	cert         *x509.Certificate
+	pending      int
+	revoked      bool
+	reason       int
+	disabled     bool
+	consumers    []string
+	attributes   map[string][]string
+	customFields map[string][]string
+}
+
+// Certificate returns the state of the certificate object dn
+func (s *Server) Certificate(dn string) (*CertificateObject, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.certificates[strings.ToLower(dn)]
+	if !ok {
+		return nil, false
+	}
+	customFields := make(map[string][]string, len(obj.customFields))
+	for k, v := range obj.customFields {
+		customFields[k] = v
+	}
+	return &CertificateObject{
+		DN:               obj.dn,
+		Guid:             obj.guid,
+		Certificate:      obj.cert,
+		Revoked:          obj.revoked,
+		RevocationReason: obj.reason,
+		Disabled:         obj.disabled,
+		Consumers:        append([]string(nil), obj.consumers...),
+		CustomFields:     customFields,
+	}, true
+}
+
+// CACertificate returns the certificate of the CA issuing the certificates of the server
+func (s *Server) CACertificate() *x509.Certificate {
+	return s.ca
+}
+
+func (s *Server) initCA() error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "tpptest CA", Organization: []string{"Venafi, Inc."}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(10 * 365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return err
+	}
+	s.ca, err = x509.ParseCertificate(der)
+	s.caKey = key
+	return err
+}
+
+// attribute returns the values of a Config attribute of the object
+func (obj *certificateObject) attribute(name string) []string {
+	switch name {
+	case "Disabled":
+		if obj.disabled {
+			return []string{"1"}
+		}
+		return []string{"0"}
+	case "Consumers":
+		return obj.consumers
+	case "X509 Subject":
+		if obj.cert != nil {
+			return []string{obj.cert.Subject.CommonName}
+		}
+	}
+	return obj.attributes[name]
+}
+
+func (s *Server) requestCertificate(w http.ResponseWriter, r *http.Request) {
+	var req certificateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.policies[strings.ToLower(req.PolicyDN)]; !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("PolicyDN: %s does not exist", req.PolicyDN))
+		return
+	}
+	sp := s.serverPolicy(req.PolicyDN)
+
+	var csr *x509.CertificateRequest
+	commonName := req.Subject
+	if req.PKCS10 != "" {
+		if sp.CsrGeneration.Locked && sp.CsrGeneration.Value == "ServiceGenerated" {
+			writeError(w, http.StatusBadRequest, "The policy requires the key to be generated by the service. A CSR is not allowed")
+			return
+		}
+		var err error
+		csr, err = parseCSR(req.PKCS10)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse the PKCS#10 data: %s", err))
+			return
+		}
+		commonName = csr.Subject.CommonName
+	}
+	name := req.ObjectName
+	if name == "" {
+		name = commonName
+	}
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "Subject or ObjectName is required")
+		return
+	}
+	dn := req.PolicyDN + "\\" + name
+
+	obj, exists := s.certificates[strings.ToLower(dn)]
+	if exists && obj.disabled && !req.Reenable {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Certificate %s is disabled", dn))
+		return
+	}
+	if !exists {
+		obj = &certificateObject{dn: dn, guid: newGUID(), createdOn: time.Now(), attributes: map[string][]string{}, customFields: map[string][]string{}}
+	}
+	obj.request = req
+	obj.csr = csr
+	obj.key = nil
+	obj.revoked = false
+	obj.reason = 0
+	obj.disabled = false
+	err := s.issueCertificate(obj, sp)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.certificates[strings.ToLower(dn)] = obj
+
+	for _, d := range req.Devices {
+		for _, app := range d.Applications {
+			obj.addConsumer(d.PolicyDN + "\\" + d.ObjectName + "\\" + app.ObjectName)
+		}
+	}
+	for _, f := range req.CustomFields {
+		obj.customFields[f.Name] = f.Values
+	}
+	if req.Origin != "" {
+		obj.attributes["Origin"] = []string{req.Origin}
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		CertificateDN string
+		Guid          string
+	}{obj.dn, obj.guid})
+}
+
+// issueCertificate issues a new version of the certificate of obj, from its CSR or from its request when the key is
+// generated by the service. It must be called with the lock held
+func (s *Server) issueCertificate(obj *certificateObject, sp serverPolicy) error {
+	template := &x509.Certificate{
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	var publicKey crypto.PublicKey
+	if obj.csr != nil {
+		template.Subject = obj.csr.Subject
+		template.DNSNames = obj.csr.DNSNames
+		template.EmailAddresses = obj.csr.EmailAddresses
+		template.IPAddresses = obj.csr.IPAddresses
+		template.URIs = obj.csr.URIs
+		publicKey = obj.csr.PublicKey
+	} else {
+		err := applyServiceGeneratedRequest(template, obj.request, sp)
+		if err != nil {
+			return err
+		}
+		if obj.key == nil {
+			obj.key, err = generateKey(obj.request, sp)
+			if err != nil {
+				return err
+			}
+		}
+		publicKey = obj.key.Public()
+	}
+
+	err := checkDomains(template, sp)
+	if err != nil {
+		return err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Minute)
+	template.NotAfter = time.Now().Add(defaultValidity)
+	for _, attr := range obj.request.CASpecificAttributes {
+		if strings.HasSuffix(attr.Name, "Specific End Date") {
+			if notAfter, err := time.Parse(time.RFC3339, attr.Value); err == nil {
+				template.NotAfter = notAfter
+			}
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, s.ca, publicKey, s.caKey)
+	if err != nil {
+		return fmt.Errorf("failed to issue certificate: %s", err)
+	}
+	obj.cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		return err
+	}
+	obj.pending = s.PendingRetrievals
+	return nil
+}
+
+func applyServiceGeneratedRequest(template *x509.Certificate, req certificateRequest, sp serverPolicy) error {
+	if req.Subject == "" {
+		return fmt.Errorf("Subject is required when the key is generated by the service")
+	}
+	valueOrPolicy := func(value string, p lockedValue) []string {
+		if p.Locked || (value == "" && p.Value != "") {
+			value = p.Value
+		}
+		if value == "" {
+			return nil
+		}
+		return []string{value}
+	}
+	template.Subject = pkix.Name{
+		CommonName:   req.Subject,
+		Organization: valueOrPolicy(req.Organization, sp.Subject.Organization),
+		Locality:     valueOrPolicy(req.City, sp.Subject.City),
+		Province:     valueOrPolicy(req.State, sp.Subject.State),
+		Country:      valueOrPolicy(req.Country, sp.Subject.Country),
+	}
+	if req.OrganizationalUnit != "" && !sp.Subject.OrganizationalUnit.Locked {
+		template.Subject.OrganizationalUnit = []string{req.OrganizationalUnit}
+	} else {
+		template.Subject.OrganizationalUnit = sp.Subject.OrganizationalUnit.Values
+	}
+
+	for _, san := range req.SubjectAltNames {
+		switch san.Type {
+		case 1:
+			template.EmailAddresses = append(template.EmailAddresses, san.Name)
+		case 2:
+			template.DNSNames = append(template.DNSNames, san.Name)
+		case 6:
+			u, err := url.Parse(san.Name)
+			if err != nil {
+				return fmt.Errorf("invalid URI %s: %s", san.Name, err)
+			}
+			template.URIs = append(template.URIs, u)
+		case 7:
+			ip := net.ParseIP(san.Name)
+			if ip == nil {
+				return fmt.Errorf("invalid IP address %s", san.Name)
+			}
+			template.IPAddresses = append(template.IPAddresses, ip)
+		}
+	}
+	return nil
+}
+
+func generateKey(req certificateRequest, sp serverPolicy) (crypto.Signer, error) {
+	algorithm := req.KeyAlgorithm
+	if algorithm == "" || sp.KeyPair.KeyAlgorithm.Locked {
+		algorithm = sp.KeyPair.KeyAlgorithm.Value
+	}
+	switch strings.ToUpper(algorithm) {
+	case "ECC", "ECDSA", "EC":
+		curve := req.EllipticCurve
+		if curve == "" {
+			curve = sp.KeyPair.EllipticCurve.Value
+		}
+		switch strings.ToUpper(strings.ReplaceAll(curve, "-", "")) {
+		case "P384":
+			return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		case "P521":
+			return ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+		default:
+			return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		}
+	default:
+		bits := req.KeyBitSize
+		if bits == 0 || (sp.KeyPair.KeySize.Locked && bits < sp.KeyPair.KeySize.Value) {
+			bits = sp.KeyPair.KeySize.Value
+		}
+		if bits == 0 {
+			bits = 2048
+		}
+		return rsa.GenerateKey(rand.Reader, bits)
+	}
+}
+
+// checkDomains enforces the domain whitelist and wildcard settings of the policy on the subject and DNS names
+func checkDomains(template *x509.Certificate, sp serverPolicy) error {
+	names := template.DNSNames
+	if template.Subject.CommonName != "" {
+		names = append([]string{template.Subject.CommonName}, names...)
+	}
+	for _, name := range names {
+		if strings.Contains(name, "*") && !sp.WildcardsAllowed {
+			return fmt.Errorf("Wildcards are not allowed by policy: %s", name)
+		}
+		if len(sp.WhitelistedDomains) == 0 {
+			continue
+		}
+		allowed := false
+		for _, domain := range sp.WhitelistedDomains {
+			domain = strings.TrimPrefix(domain, ".")
+			if strings.EqualFold(name, domain) || strings.HasSuffix(strings.ToLower(name), "."+strings.ToLower(domain)) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("%s does not match any domain allowed by policy", name)
+		}
+	}
+	return nil
+}
+
+func (obj *certificateObject) addConsumer(dn string) {
+	for _, c := range obj.consumers {
+		if strings.EqualFold(c, dn) {
+			return
+		}
+	}
+	obj.consumers = append(obj.consumers, dn)
+}
+
+func (s *Server) retrieveCertificate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CertificateDN     string
+		Format            string
+		Password          string
+		IncludePrivateKey bool
+		IncludeChain      bool
+		RootFirstOrder    bool
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.certificates[strings.ToLower(req.CertificateDN)]
+	if !ok || obj.cert == nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Certificate %s does not exist", req.CertificateDN))
+		return
+	}
+	if obj.pending > 0 {
+		obj.pending--
+		writeJSON(w, http.StatusAccepted, struct {
+			Stage  int
+			Status string
+		}{500, "Post CSR"})
+		return
+	}
+
+	var data []byte
+	leaf := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: obj.cert.Raw})
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.ca.Raw})
+	switch {
+	case !req.IncludeChain:
+		data = leaf
+	case req.RootFirstOrder:
+		data = append(ca, leaf...)
+	default:
+		data = append(leaf, ca...)
+	}
+	if req.IncludePrivateKey {
+		if obj.key == nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("The private key of %s is not available", obj.dn))
+			return
+		}
+		if req.Password == "" {
+			writeError(w, http.StatusBadRequest, "A password is required to retrieve the private key")
+			return
+		}
+		der, err := pkcs8.MarshalPrivateKey(obj.key, []byte(req.Password), nil)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: der})...)
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		CertificateData string
+		Filename        string
+		Format          string
+	}{base64.StdEncoding.EncodeToString(data), obj.cert.Subject.CommonName + ".cer", "Base64"})
+}
+
+func (s *Server) renewCertificate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CertificateDN string
+		PKCS10        string
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	type renewResponse struct {
+		Success bool
+		Error   string `json:",omitempty"`
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.certificates[strings.ToLower(req.CertificateDN)]
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, renewResponse{Error: fmt.Sprintf("Certificate %s does not exist", req.CertificateDN)})
+		return
+	}
+	if obj.disabled {
+		writeJSON(w, http.StatusBadRequest, renewResponse{Error: fmt.Sprintf("Certificate %s is disabled", obj.dn)})
+		return
+	}
+	if req.PKCS10 != "" {
+		csr, err := parseCSR(req.PKCS10)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, renewResponse{Error: fmt.Sprintf("Failed to parse the PKCS#10 data: %s", err)})
+			return
+		}
+		obj.csr = csr
+		obj.key = nil
+	} else if obj.csr == nil && obj.request.Subject == "" && obj.cert != nil {
+		// imported certificates are renewed with a service generated key for the same subject
+		obj.request.Subject = obj.cert.Subject.CommonName
+		for _, name := range obj.cert.DNSNames {
+			obj.request.SubjectAltNames = append(obj.request.SubjectAltNames, sanItem{2, name})
+		}
+	}
+	sp := s.serverPolicy(getParentDN(obj.dn))
+	if obj.csr == nil && !sp.PrivateKeyReuseAllowed {
+		obj.key = nil
+	}
+	err := s.issueCertificate(obj, sp)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, renewResponse{Error: err.Error()})
+		return
+	}
+	obj.revoked = false
+	writeJSON(w, http.StatusOK, renewResponse{Success: true})
+}
+
+func (s *Server) revokeCertificate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CertificateDN string
+		Thumbprint    string
+		Reason        int
+		Comments      string
+		Disable       bool
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	type revokeResponse struct {
+		Requested bool
+		Success   bool
+		Error     string `json:",omitempty"`
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj := s.findCertificate(req.CertificateDN, req.Thumbprint)
+	if obj == nil || obj.cert == nil {
+		writeJSON(w, http.StatusBadRequest, revokeResponse{Error: "Certificate does not exist"})
+		return
+	}
+	if obj.revoked {
+		writeJSON(w, http.StatusOK, revokeResponse{Success: true})
+		return
+	}
+	obj.revoked = true
+	obj.reason = req.Reason
+	obj.disabled = obj.disabled || req.Disable
+	writeJSON(w, http.StatusOK, revokeResponse{Requested: true, Success: true})
+}
+
+// findCertificate returns the certificate object by DN or by the thumbprint of its current version. It must be
+// called with the lock held
+func (s *Server) findCertificate(dn, thumbprint string) *certificateObject {
+	if dn != "" {
+		return s.certificates[strings.ToLower(dn)]
+	}
+	for _, obj := range s.certificates {
+		if obj.cert != nil && strings.EqualFold(calcThumbprint(obj.cert.Raw), thumbprint) {
+			return obj
+		}
+	}
+	return nil
+}
+
+func (s *Server) resetCertificate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CertificateDN string
+		Restart       bool
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.certificates[strings.ToLower(req.CertificateDN)]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Certificate %s does not exist or you do not have sufficient rights to the object.", req.CertificateDN))
+		return
+	}
+	if obj.pending == 0 {
+		writeError(w, http.StatusBadRequest, "Reset is not completed. No reset is required for the certificate.")
+		return
+	}
+	obj.pending = 0
+	if req.Restart {
+		err := s.issueCertificate(obj, s.serverPolicy(getParentDN(obj.dn)))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, struct {
+		ProcessingResetCompleted bool
+		RestartCompleted         bool
+	}{true, req.Restart})
+}
+
+func (s *Server) importCertificate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PolicyDN        string
+		ObjectName      string
+		CertificateData string
+		PrivateKeyData  string
+		Password        string
+		Reconcile       bool
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	cert, err := parseCertificate(req.CertificateData)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse the certificate data: %s", err))
+		return
+	}
+	var key crypto.Signer
+	if req.PrivateKeyData != "" {
+		key, err = parsePrivateKey(req.PrivateKeyData, req.Password)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Failed to parse the private key data: %s", err))
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.policies[strings.ToLower(req.PolicyDN)]; !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("PolicyDN: %s does not exist", req.PolicyDN))
+		return
+	}
+	name := req.ObjectName
+	if name == "" {
+		name = cert.Subject.CommonName
+	}
+	dn := req.PolicyDN + "\\" + name
+	obj, exists := s.certificates[strings.ToLower(dn)]
+	if exists && !req.Reconcile {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Certificate %s already exists", dn))
+		return
+	}
+	if !exists {
+		obj = &certificateObject{dn: dn, guid: newGUID(), createdOn: time.Now(), attributes: map[string][]string{}, customFields: map[string][]string{}}
+		s.certificates[strings.ToLower(dn)] = obj
+	}
+	obj.cert = cert
+	obj.csr = nil
+	obj.key = key
+	obj.request = certificateRequest{}
+	obj.pending = 0
+
+	writeJSON(w, http.StatusOK, certificate.ImportResponse{
+		CertificateDN:      obj.dn,
+		CertificateVaultId: len(s.certificates),
+		Guid:               obj.guid,
+	})
+}
+
+type associationRequest struct {
+	CertificateDN string
+	ApplicationDN []string
+	PushToNew     bool
+	DeleteOrphans bool
+}
+
+func (s *Server) associateCertificate(w http.ResponseWriter, r *http.Request) {
+	var req associationRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.certificates[strings.ToLower(req.CertificateDN)]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Certificate %s does not exist", req.CertificateDN))
+		return
+	}
+	for _, app := range req.ApplicationDN {
+		obj.addConsumer(app)
+	}
+	writeJSON(w, http.StatusOK, struct{ Success bool }{true})
+}
+
+func (s *Server) dissociateCertificate(w http.ResponseWriter, r *http.Request) {
+	var req associationRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.certificates[strings.ToLower(req.CertificateDN)]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Certificate %s does not exist", req.CertificateDN))
+		return
+	}
+	var consumers []string
+	for _, c := range obj.consumers {
+		keep := true
+		for _, app := range req.ApplicationDN {
+			if strings.EqualFold(c, app) {
+				keep = false
+			}
+		}
+		if keep {
+			consumers = append(consumers, c)
+		}
+	}
+	obj.consumers = consumers
+	writeJSON(w, http.StatusOK, struct{ Success bool }{true})
+}
+
+type searchInfo struct {
+	CreatedOn   string
+	DN          string
+	Guid        string
+	Name        string
+	ParentDn    string
+	SchemaClass string
+	X509        certificate.CertificateInfo
+}
+
+// searchCertificates supports the attributes of the certificate search used by the connectors. Values separated by
+// commas match any of the values
+func (s *Server) searchCertificates(w http.ResponseWriter, r *http.Request) {
+	query := map[string]string{}
+	for k, v := range r.URL.Query() {
+		query[strings.ToLower(k)] = v[0]
+	}
+	limit, offset := 100, 0
+	var err error
+	if v, ok := query["limit"]; ok {
+		limit, err = strconv.Atoi(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid limit %s", v))
+			return
+		}
+	}
+	if v, ok := query["offset"]; ok {
+		offset, err = strconv.Atoi(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Invalid offset %s", v))
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var found []searchInfo
+	for _, obj := range s.certificates {
+		if obj.cert == nil {
+			continue
+		}
+		match, err := obj.matches(query)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if match {
+			found = append(found, obj.searchInfo())
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].DN < found[j].DN })
+	total := len(found)
+	if offset > len(found) {
+		offset = len(found)
+	}
+	found = found[offset:]
+	if limit < len(found) {
+		found = found[:limit]
+	}
+	if found == nil {
+		found = []searchInfo{}
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Certificates []searchInfo
+		TotalCount   int
+	}{found, total})
+}
+
+func (obj *certificateObject) matches(query map[string]string) (bool, error) {
+	anyOf := func(value string, candidates ...string) bool {
+		for _, v := range strings.Split(value, ",") {
+			for _, c := range candidates {
+				if strings.EqualFold(strings.TrimSpace(v), c) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	var ips, uris []string
+	for _, ip := range obj.cert.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+	for _, u := range obj.cert.URIs {
+		uris = append(uris, u.String())
+	}
+	parent := getParentDN(obj.dn)
+
+	for key, value := range query {
+		var ok bool
+		switch key {
+		case "thumbprint":
+			ok = anyOf(value, calcThumbprint(obj.cert.Raw))
+		case "serial":
+			ok = anyOf(value, strings.ToUpper(obj.cert.SerialNumber.Text(16)))
+		case "cn":
+			ok = anyOf(value, obj.cert.Subject.CommonName)
+		case "issuer":
+			ok = strings.Contains(strings.ToLower(obj.cert.Issuer.String()), strings.ToLower(value))
+		case "san-dns":
+			ok = anyOf(value, obj.cert.DNSNames...)
+		case "san-email":
+			ok = anyOf(value, obj.cert.EmailAddresses...)
+		case "san-ip":
+			ok = anyOf(value, ips...)
+		case "san-uri":
+			ok = anyOf(value, uris...)
+		case "parentdn":
+			ok = strings.EqualFold(parent, value)
+		case "parentdnrecursive":
+			ok = strings.EqualFold(parent, value) || strings.HasPrefix(strings.ToLower(parent), strings.ToLower(value)+"\\")
+		case "disabled":
+			ok = (value == "1") == obj.disabled
+		case "validtogreater", "validtoless", "validfromgreater", "validfromless":
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return false, fmt.Errorf("Invalid date %s for %s", value, key)
+			}
+			date := obj.cert.NotAfter
+			if strings.HasPrefix(key, "validfrom") {
+				date = obj.cert.NotBefore
+			}
+			if strings.HasSuffix(key, "greater") {
+				ok = date.After(t)
+			} else {
+				ok = date.Before(t)
+			}
+		default:
+			// paging and unsupported attributes don't filter
+			ok = true
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (obj *certificateObject) searchInfo() searchInfo {
+	info := certificate.CertificateInfo{
+		CN:         obj.cert.Subject.CommonName,
+		SANS:       certificate.Sans{DNS: obj.cert.DNSNames, Email: obj.cert.EmailAddresses},
+		Issuer:     obj.cert.Issuer.String(),
+		Serial:     strings.ToUpper(obj.cert.SerialNumber.Text(16)),
+		Thumbprint: calcThumbprint(obj.cert.Raw),
+		ValidFrom:  obj.cert.NotBefore,
+		ValidTo:    obj.cert.NotAfter,
+	}
+	for _, ip := range obj.cert.IPAddresses {
+		info.SANS.IP = append(info.SANS.IP, ip.String())
+	}
+	for _, u := range obj.cert.URIs {
+		info.SANS.URI = append(info.SANS.URI, u.String())
+	}
+	return searchInfo{
+		CreatedOn:   obj.createdOn.UTC().Format(time.RFC3339),
+		DN:          obj.dn,
+		Guid:        obj.guid,
+		Name:        obj.dn[strings.LastIndex(obj.dn, "\\")+1:],
+		ParentDn:    getParentDN(obj.dn),
+		SchemaClass: certificateType,
+		X509:        info,
+	}
+}
+
+// findByGUID must be called with the lock held
+func (s *Server) findByGUID(guid string) *certificateObject {
+	for _, obj := range s.certificates {
+		if strings.EqualFold(obj.guid, guid) {
+			return obj
+		}
+	}
+	return nil
+}
+
+func (s *Server) certificateDetails(w http.ResponseWriter, _ *http.Request, guid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj := s.findByGUID(guid)
+	if obj == nil {
+		writeJSON(w, http.StatusBadRequest, struct{ ErrorDetails string }{fmt.Sprintf("Certificate %s does not exist", guid)})
+		return
+	}
+
+	var details struct {
+		certificate.CertificateMetaData
+		Consumers []string
+		Disabled  bool
+	}
+	details.DN = obj.dn
+	details.Guid = obj.guid
+	details.Name = obj.dn[strings.LastIndex(obj.dn, "\\")+1:]
+	details.ParentDn = getParentDN(obj.dn)
+	details.SchemaClass = certificateType
+	details.CreatedOn = obj.createdOn.UTC().Format(time.RFC3339)
+	details.ManagementType = s.serverPolicy(details.ParentDn).ManagementType.Value
+	if origin := obj.attributes["Origin"]; len(origin) > 0 {
+		details.Origin = origin[0]
+	}
+	details.Consumers = obj.consumers
+	if details.Consumers == nil {
+		details.Consumers = []string{}
+	}
+	details.Disabled = obj.disabled
+	for name, values := range obj.customFields {
+		details.CustomFields = append(details.CustomFields, certificate.CustomFieldDetails{Name: name, Type: "1", Value: values})
+	}
+	if obj.cert != nil {
+		cd := &details.CertificateDetails
+		cd.CN = obj.cert.Subject.CommonName
+		cd.Issuer = obj.cert.Issuer.String()
+		cd.Subject = obj.cert.Subject.String()
+		cd.Serial = strings.ToUpper(obj.cert.SerialNumber.Text(16))
+		cd.Thumbprint = calcThumbprint(obj.cert.Raw)
+		cd.ValidFrom = obj.cert.NotBefore
+		cd.ValidTo = obj.cert.NotAfter
+		cd.SignatureAlgorithm = obj.cert.SignatureAlgorithm.String()
+		cd.KeyAlgorithm = obj.cert.PublicKeyAlgorithm.String()
+		cd.O = strings.Join(obj.cert.Subject.Organization, ",")
+		cd.OU = obj.cert.Subject.OrganizationalUnit
+		if k, ok := obj.cert.PublicKey.(*rsa.PublicKey); ok {
+			cd.KeySize = k.N.BitLen()
+		} else if k, ok := obj.cert.PublicKey.(*ecdsa.PublicKey); ok {
+			cd.KeySize = k.Curve.Params().BitSize
+		}
+	}
+	writeJSON(w, http.StatusOK, details)
+}
+
+func (s *Server) putCertificateAttributes(w http.ResponseWriter, r *http.Request, guid string) {
+	var req struct {
+		AttributeData []struct {
+			Name  string
+			Value []string
+		}
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj := s.findByGUID(guid)
+	if obj == nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Certificate %s does not exist", guid))
+		return
+	}
+	for _, attr := range req.AttributeData {
+		if attr.Name == "Disabled" {
+			obj.disabled = len(attr.Value) > 0 && attr.Value[0] == "1"
+			continue
+		}
+		obj.attributes[attr.Name] = attr.Value
+	}
+	writeJSON(w, http.StatusOK, struct{ Success bool }{true})
+}
+
+func parseCSR(data string) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	return csr, csr.CheckSignature()
+}
+
+// parseCertificate accepts the PEM or base64 encoded certificates the import endpoint accepts
+func parseCertificate(data string) (*x509.Certificate, error) {
+	if block, _ := pem.Decode([]byte(data)); block != nil {
+		return x509.ParseCertificate(block.Bytes)
+	}
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(data))
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+func parsePrivateKey(data, password string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found")
+	}
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "ENCRYPTED PRIVATE KEY":
+		key, err = pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(password))
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+func calcThumbprint(der []byte) string {
+	h := sha1.Sum(der)
+	return strings.ToUpper(hex.EncodeToString(h[:]))
+}
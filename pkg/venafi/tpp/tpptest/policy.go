@@ -0,0 +1,564 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tpptest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Venafi/vcert/v5/pkg/policy"
+)
+
+// WebSDK result codes used by the Config endpoints
+const (
+	resultSuccess          = 1
+	resultObjectNotFound   = 400
+	resultObjectExists     = 401
+	resultAttributeInvalid = 102
+)
+
+const (
+	policyClass     = "Policy"
+	certificateType = "X509 Server Certificate"
+)
+
+type policyAttribute struct {
+	values []string
+	locked bool
+}
+
+type policyFolder struct {
+	dn         string
+	guid       string
+	createdOn  time.Time
+	attributes map[string]policyAttribute
+}
+
+// AddPolicy creates the policy folder dn and its missing parents. dn may be given relative to \VED\Policy
+func (s *Server) AddPolicy(dn string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addPolicy(getPolicyDN(dn))
+}
+
+// SetPolicyAttribute sets an attribute of the X509 Certificate class on the policy folder dn, creating the folder
+// if needed. Locked values are enforced on the folders below dn, the others are defaults
+func (s *Server) SetPolicyAttribute(dn, name string, values []string, locked bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	folder := s.addPolicy(getPolicyDN(dn))
+	folder.attributes[name] = policyAttribute{values: values, locked: locked}
+}
+
+// addPolicy must be called with the lock held
+func (s *Server) addPolicy(dn string) *policyFolder {
+	if folder, ok := s.policies[strings.ToLower(dn)]; ok {
+		return folder
+	}
+	if parent := getParentDN(dn); parent != "" && !strings.EqualFold(dn, policyRoot) {
+		s.addPolicy(parent)
+	}
+	folder := &policyFolder{dn: dn, guid: newGUID(), createdOn: time.Now(), attributes: map[string]policyAttribute{}}
+	s.policies[strings.ToLower(dn)] = folder
+	return folder
+}
+
+// effectivePolicy returns the attributes applying to the folder dn: a locked value set on a parent folder wins over
+// the values set below it. It must be called with the lock held
+func (s *Server) effectivePolicy(dn string) map[string]policyAttribute {
+	var chain []*policyFolder
+	for current := dn; current != ""; current = getParentDN(current) {
+		if folder, ok := s.policies[strings.ToLower(current)]; ok {
+			chain = append([]*policyFolder{folder}, chain...)
+		}
+	}
+	effective := map[string]policyAttribute{}
+	for _, folder := range chain {
+		for name, attr := range folder.attributes {
+			if current, ok := effective[name]; ok && current.locked {
+				continue
+			}
+			effective[name] = attr
+		}
+	}
+	return effective
+}
+
+type lockedValue struct {
+	Locked bool
+	Value  string
+}
+
+type lockedValues struct {
+	Locked bool
+	Values []string
+}
+
+type lockedInt struct {
+	Locked bool
+	Value  int
+}
+
+type serverPolicy struct {
+	CertificateAuthority lockedValue
+	CsrGeneration        lockedValue
+	KeyGeneration        lockedValue
+	KeyPair              struct {
+		KeyAlgorithm  lockedValue
+		KeySize       lockedInt
+		EllipticCurve lockedValue
+	}
+	ManagementType          lockedValue
+	PrivateKeyReuseAllowed  bool
+	SubjAltNameDnsAllowed   bool
+	SubjAltNameEmailAllowed bool
+	SubjAltNameIpAllowed    bool
+	SubjAltNameUpnAllowed   bool
+	SubjAltNameUriAllowed   bool
+	Subject                 struct {
+		City               lockedValue
+		Country            lockedValue
+		Organization       lockedValue
+		OrganizationalUnit lockedValues
+		State              lockedValue
+	}
+	UniqueSubjectEnforced bool
+	WhitelistedDomains    []string
+	WildcardsAllowed      bool
+}
+
+// serverPolicy builds the CheckPolicy view of the folder dn. It must be called with the lock held
+func (s *Server) serverPolicy(dn string) serverPolicy {
+	attrs := s.effectivePolicy(dn)
+	single := func(name, defaultValue string) lockedValue {
+		attr, ok := attrs[name]
+		if !ok || len(attr.values) == 0 {
+			return lockedValue{Value: defaultValue}
+		}
+		return lockedValue{Locked: attr.locked, Value: attr.values[0]}
+	}
+
+	var p serverPolicy
+	p.CertificateAuthority = single(policy.TppCertificateAuthority, "")
+	p.CsrGeneration = lockedValue{Value: "UserProvided"}
+	if manualCsr := single(policy.ServiceGenerated, ""); manualCsr.Value != "" {
+		p.CsrGeneration.Locked = manualCsr.Locked
+		if manualCsr.Value == "0" {
+			p.CsrGeneration.Value = "ServiceGenerated"
+		}
+	}
+	p.KeyGeneration = lockedValue{Value: "Central"}
+	p.KeyPair.KeyAlgorithm = single(policy.TppKeyAlgorithm, "RSA")
+	keySize := single(policy.TppKeyBitStrength, "2048")
+	p.KeyPair.KeySize.Locked = keySize.Locked
+	p.KeyPair.KeySize.Value, _ = strconv.Atoi(keySize.Value)
+	p.KeyPair.EllipticCurve = single(policy.TppEllipticCurve, "")
+	p.ManagementType = single(policy.TppManagementType, policy.TppManagementTypeEnrollment)
+	p.PrivateKeyReuseAllowed = single(policy.TppAllowPrivateKeyReuse, "0").Value == "1"
+
+	prohibited := map[string]bool{}
+	for _, sanType := range attrs[policy.TppProhibitedSANTypes].values {
+		prohibited[sanType] = true
+	}
+	p.SubjAltNameDnsAllowed = !prohibited[policy.TppDnsAllowed]
+	p.SubjAltNameEmailAllowed = !prohibited[policy.TppEmailAllowed]
+	p.SubjAltNameIpAllowed = !prohibited[policy.TppIpAllowed]
+	p.SubjAltNameUpnAllowed = !prohibited[policy.TppUpnAllowed]
+	p.SubjAltNameUriAllowed = !prohibited[policy.TppUriAllowed]
+
+	p.Subject.City = single(policy.TppCity, "")
+	p.Subject.Country = single(policy.TppCountry, "")
+	p.Subject.Organization = single(policy.TppOrganization, "")
+	p.Subject.State = single(policy.TppState, "")
+	if ou, ok := attrs[policy.TppOrganizationalUnit]; ok {
+		p.Subject.OrganizationalUnit = lockedValues{Locked: ou.locked, Values: ou.values}
+	}
+	p.WhitelistedDomains = attrs[policy.TppDomainSuffixWhitelist].values
+	p.WildcardsAllowed = single(policy.TppProhibitWildcard, "0").Value != "1"
+	return p
+}
+
+func (s *Server) checkPolicy(w http.ResponseWriter, r *http.Request) {
+	var req struct{ PolicyDN string }
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.policies[strings.ToLower(req.PolicyDN)]; !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("PolicyDN: %s does not exist", req.PolicyDN))
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Error  string `json:",omitempty"`
+		Policy serverPolicy
+	}{Policy: s.serverPolicy(req.PolicyDN)})
+}
+
+type configObject struct {
+	AbsoluteGUID string
+	DN           string
+	GUID         string
+	Id           int
+	Name         string
+	Parent       string
+	Revision     int64
+	TypeName     string
+}
+
+// configObject returns the Config view of the object dn. It must be called with the lock held
+func (s *Server) configObject(dn string) (configObject, bool) {
+	key := strings.ToLower(dn)
+	if folder, ok := s.policies[key]; ok {
+		return newConfigObject(folder.dn, folder.guid, policyClass, folder.createdOn), true
+	}
+	if cert, ok := s.certificates[key]; ok {
+		return newConfigObject(cert.dn, cert.guid, certificateType, cert.createdOn), true
+	}
+	if cert, ok := s.sshCertificates[key]; ok {
+		return newConfigObject(cert.dn, cert.guid, "SSH Certificate", cert.createdOn), true
+	}
+	return configObject{}, false
+}
+
+func newConfigObject(dn, guid, typeName string, createdOn time.Time) configObject {
+	return configObject{
+		AbsoluteGUID: guid,
+		DN:           dn,
+		GUID:         guid,
+		Name:         dn[strings.LastIndex(dn, "\\")+1:],
+		Parent:       getParentDN(dn),
+		Revision:     createdOn.Unix(),
+		TypeName:     typeName,
+	}
+}
+
+func (s *Server) dnToGUID(w http.ResponseWriter, r *http.Request) {
+	var req struct{ ObjectDN string }
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	obj, ok := s.configObject(req.ObjectDN)
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusOK, struct{ Result int }{resultObjectNotFound})
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		ClassName        string
+		GUID             string
+		HierarchicalGUID string
+		Result           int
+		Revision         int64
+	}{obj.TypeName, obj.GUID, obj.GUID, resultSuccess, obj.Revision})
+}
+
+func (s *Server) isValid(w http.ResponseWriter, r *http.Request) {
+	var req struct{ ObjectDN string }
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	obj, ok := s.configObject(req.ObjectDN)
+	s.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusOK, struct {
+			Error  string
+			Result int
+		}{"Object does not exist", resultObjectNotFound})
+		return
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Object configObject
+		Result int
+	}{obj, resultSuccess})
+}
+
+func (s *Server) createObject(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Class    string
+		ObjectDN string
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	if req.Class != policyClass {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("Class %s is not supported", req.Class))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.configObject(req.ObjectDN); ok {
+		writeJSON(w, http.StatusOK, struct {
+			Error  string
+			Result int
+		}{"Object already exists", resultObjectExists})
+		return
+	}
+	if _, ok := s.policies[strings.ToLower(getParentDN(req.ObjectDN))]; !ok {
+		writeJSON(w, http.StatusOK, struct {
+			Error  string
+			Result int
+		}{"Parent object does not exist", resultObjectNotFound})
+		return
+	}
+	folder := s.addPolicy(req.ObjectDN)
+	writeJSON(w, http.StatusOK, struct {
+		Object configObject
+		Result int
+	}{newConfigObject(folder.dn, folder.guid, policyClass, folder.createdOn), resultSuccess})
+}
+
+func (s *Server) findObjectsOfClass(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Class     string
+		ObjectDN  string
+		Recursive interface{}
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	recursive := fmt.Sprint(req.Recursive) == "1" || fmt.Sprint(req.Recursive) == "true"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	objects := []configObject{}
+	if req.Class == policyClass {
+		prefix := strings.ToLower(req.ObjectDN) + "\\"
+		for key, folder := range s.policies {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			if !recursive && strings.Contains(key[len(prefix):], "\\") {
+				continue
+			}
+			objects = append(objects, newConfigObject(folder.dn, folder.guid, policyClass, folder.createdOn))
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].DN < objects[j].DN })
+	writeJSON(w, http.StatusOK, struct {
+		Objects []configObject
+		Result  int
+	}{objects, resultSuccess})
+}
+
+func (s *Server) readDN(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ObjectDN      string
+		AttributeName string
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := strings.ToLower(req.ObjectDN)
+	var values []string
+	if folder, ok := s.policies[key]; ok {
+		values = folder.attributes[req.AttributeName].values
+	} else if cert, ok := s.certificates[key]; ok {
+		values = cert.attribute(req.AttributeName)
+	} else {
+		writeJSON(w, http.StatusOK, struct{ Result int }{resultObjectNotFound})
+		return
+	}
+	if values == nil {
+		values = []string{}
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Result int
+		Values []string
+	}{resultSuccess, values})
+}
+
+type policyAttributeRequest struct {
+	Locked        bool
+	ObjectDN      string
+	Class         string
+	AttributeName string
+	Values        []string
+}
+
+func (s *Server) readPolicy(w http.ResponseWriter, r *http.Request) {
+	var req policyAttributeRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	folder, ok := s.policies[strings.ToLower(req.ObjectDN)]
+	if !ok {
+		writeJSON(w, http.StatusOK, struct {
+			Error  string
+			Result int
+		}{fmt.Sprintf("Policy %s does not exist", req.ObjectDN), resultObjectNotFound})
+		return
+	}
+	attr := folder.attributes[req.AttributeName]
+	values := attr.values
+	if values == nil {
+		values = []string{}
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Locked bool
+		Result int
+		Values []string
+	}{attr.locked, resultSuccess, values})
+}
+
+func (s *Server) writePolicy(w http.ResponseWriter, r *http.Request) {
+	var req policyAttributeRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	folder, ok := s.policies[strings.ToLower(req.ObjectDN)]
+	switch {
+	case !ok:
+		writeJSON(w, http.StatusOK, policy.PolicySetAttributeResponse{Error: fmt.Sprintf("Policy %s does not exist", req.ObjectDN), Result: resultObjectNotFound})
+	case req.Class != policy.PolicyAttributeClass:
+		writeJSON(w, http.StatusOK, policy.PolicySetAttributeResponse{Error: fmt.Sprintf("Class %s is not supported", req.Class), Result: resultAttributeInvalid})
+	default:
+		folder.attributes[req.AttributeName] = policyAttribute{values: req.Values, locked: req.Locked}
+		writeJSON(w, http.StatusOK, policy.PolicySetAttributeResponse{Result: resultSuccess})
+	}
+}
+
+func (s *Server) clearPolicyAttribute(w http.ResponseWriter, r *http.Request) {
+	var req policyAttributeRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	folder, ok := s.policies[strings.ToLower(req.ObjectDN)]
+	if !ok {
+		writeJSON(w, http.StatusOK, policy.PolicySetAttributeResponse{Error: fmt.Sprintf("Policy %s does not exist", req.ObjectDN), Result: resultObjectNotFound})
+		return
+	}
+	delete(folder.attributes, req.AttributeName)
+	writeJSON(w, http.StatusOK, policy.PolicySetAttributeResponse{Result: resultSuccess})
+}
+
+// customField is a metadata item defined on the server
+type customField struct {
+	Guid  string
+	Label string
+	Name  string
+	Type  int
+}
+
+// AddCustomField defines a custom field that certificate requests can set
+func (s *Server) AddCustomField(label string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.customFields = append(s.customFields, customField{Guid: newGUID(), Label: label, Name: label, Type: 1})
+}
+
+func (s *Server) metadataGetItems(w http.ResponseWriter, r *http.Request) {
+	var req struct{ DN string }
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := append([]customField{}, s.customFields...)
+	writeJSON(w, http.StatusOK, struct {
+		Items  []customField
+		Locked bool
+	}{Items: items})
+}
+
+func (s *Server) metadataGet(w http.ResponseWriter, r *http.Request) {
+	var req struct{ DN string }
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cert, ok := s.certificates[strings.ToLower(req.DN)]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("%s does not exist", req.DN))
+		return
+	}
+	type keyValues struct {
+		Key   customField
+		Value []string
+	}
+	data := []keyValues{}
+	for _, field := range s.customFields {
+		if values, ok := cert.customFields[field.Label]; ok {
+			data = append(data, keyValues{field, values})
+		}
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Data   []keyValues
+		Locked bool
+	}{Data: data})
+}
+
+func (s *Server) metadataSet(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		DN       string
+		GuidData []struct {
+			ItemGuid string
+			List     []string
+		}
+		KeepExisting bool
+	}
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cert, ok := s.certificates[strings.ToLower(req.DN)]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("%s does not exist", req.DN))
+		return
+	}
+	if !req.KeepExisting {
+		cert.customFields = map[string][]string{}
+	}
+	for _, item := range req.GuidData {
+		for _, field := range s.customFields {
+			if field.Guid == item.ItemGuid {
+				cert.customFields[field.Label] = item.List
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, struct {
+		Locked bool
+		Result int
+	}{false, 0})
+}
@@ -0,0 +1,321 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tpptest provides an in-process server emulating the TPP WebSDK, so that TPP flows can be tested without a
+// live TPP. The server keeps state for policy folders, certificates, tokens and SSH certificates, and errors can be
+// injected on any endpoint:
+//
+//	server := tpptest.NewServer()
+//	defer server.Close()
+//
+//	connector, _ := tpp.NewConnector(server.URL, tpptest.DefaultZone, false, nil)
+//	connector.SetHTTPClient(server.Client())
+//	err := connector.Authenticate(&endpoint.Authentication{User: tpptest.DefaultUsername, Password: tpptest.DefaultPassword})
+package tpptest
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultUsername and DefaultPassword are the credentials accepted by a new server
+	DefaultUsername = "tppadmin"
+	DefaultPassword = "Passw0rd!"
+	// DefaultZone is a policy folder that exists on a new server
+	DefaultZone = "Certificates\\vcert"
+
+	policyRoot = "\\VED\\Policy"
+	apiKeyTTL  = 3 * time.Minute
+)
+
+// Fault describes an error response returned by the server instead of processing a request
+type Fault struct {
+	// Method and Path select the failing requests. Path is relative to the server URL, e.g.
+	// "vedsdk/certificates/request", and matched case-insensitively. An empty Method matches any method
+	Method string
+	Path   string
+	// StatusCode and Body are written as the response. Body is sent as is
+	StatusCode int
+	Body       string
+	// Count is the number of requests that fail before the fault is cleared. Zero means the fault is never cleared
+	Count int
+}
+
+// Server is an in-process TPP WebSDK. The exported fields must be set before the server receives requests
+type Server struct {
+	*httptest.Server
+
+	// Username and Password are the credentials accepted by the authorize endpoints
+	Username string
+	Password string
+	// TokenLifetime is the lifetime of the access tokens and API keys issued by the server
+	TokenLifetime time.Duration
+	// PendingRetrievals is the number of retrieve requests answered as pending before a certificate or an SSH
+	// certificate is issued
+	PendingRetrievals int
+
+	mu              sync.Mutex
+	routes          map[string]http.HandlerFunc
+	ca              *x509.Certificate
+	caKey           crypto.Signer
+	apiKeys         map[string]time.Time
+	accessTokens    map[string]*token
+	refreshTokens   map[string]*token
+	identities      []identity
+	customFields    []customField
+	policies        map[string]*policyFolder
+	certificates    map[string]*certificateObject
+	sshTemplates    map[string]*sshTemplate
+	sshCertificates map[string]*sshCertificateObject
+	faults          []*Fault
+	requests        map[string]int
+}
+
+// NewServer starts a TLS server emulating TPP. The server trusts nobody but can be reached with server.Client().
+// It must be closed when done
+func NewServer() *Server {
+	s := &Server{
+		Username:        DefaultUsername,
+		Password:        DefaultPassword,
+		TokenLifetime:   time.Hour,
+		apiKeys:         map[string]time.Time{},
+		accessTokens:    map[string]*token{},
+		refreshTokens:   map[string]*token{},
+		policies:        map[string]*policyFolder{},
+		certificates:    map[string]*certificateObject{},
+		sshTemplates:    map[string]*sshTemplate{},
+		sshCertificates: map[string]*sshCertificateObject{},
+		requests:        map[string]int{},
+	}
+	err := s.initCA()
+	if err != nil {
+		panic(fmt.Sprintf("tpptest: failed to create the test CA: %s", err))
+	}
+	s.addPolicy(getPolicyDN(DefaultZone))
+	s.identities = append(s.identities, newIdentity(s.Username, ""))
+	for _, t := range []struct {
+		name string
+		host bool
+	}{{"vcert-user-ca", false}, {"vcert-host-ca", true}} {
+		err = s.addSSHTemplate(t.name, t.host, []string{"root"})
+		if err != nil {
+			panic(fmt.Sprintf("tpptest: failed to create SSH template: %s", err))
+		}
+	}
+	s.initRoutes()
+
+	s.Server = httptest.NewUnstartedServer(s)
+	s.Server.TLS = newTLSConfig()
+	s.Server.StartTLS()
+	return s
+}
+
+func (s *Server) initRoutes() {
+	s.routes = map[string]http.HandlerFunc{
+		"GET vedsdk/systemstatus/version":                            s.systemVersion,
+		"POST vedsdk/log":                                            s.writeLog,
+		"GET vedsdk/identity/self":                                   s.identitySelf,
+		"POST vedsdk/identity/browse":                                s.browseIdentities,
+		"POST vedsdk/identity/validate":                              s.validateIdentity,
+		"POST vedsdk/certificates/request":                           s.requestCertificate,
+		"POST vedsdk/certificates/retrieve":                          s.retrieveCertificate,
+		"POST vedsdk/certificates/renew":                             s.renewCertificate,
+		"POST vedsdk/certificates/revoke":                            s.revokeCertificate,
+		"POST vedsdk/certificates/reset":                             s.resetCertificate,
+		"POST vedsdk/certificates/import":                            s.importCertificate,
+		"POST vedsdk/certificates/associate":                         s.associateCertificate,
+		"POST vedsdk/certificates/dissociate":                        s.dissociateCertificate,
+		"POST vedsdk/certificates/checkpolicy":                       s.checkPolicy,
+		"GET vedsdk/certificates":                                    s.searchCertificates,
+		"POST vedsdk/config/dntoguid":                                s.dnToGUID,
+		"POST vedsdk/config/readdn":                                  s.readDN,
+		"POST vedsdk/config/isvalid":                                 s.isValid,
+		"POST vedsdk/config/create":                                  s.createObject,
+		"POST vedsdk/config/findobjectsofclass":                      s.findObjectsOfClass,
+		"POST vedsdk/config/readpolicy":                              s.readPolicy,
+		"POST vedsdk/config/writepolicy":                             s.writePolicy,
+		"POST vedsdk/config/clearpolicyattribute":                    s.clearPolicyAttribute,
+		"POST vedsdk/metadata/getitems":                              s.metadataGetItems,
+		"POST vedsdk/metadata/get":                                   s.metadataGet,
+		"POST vedsdk/metadata/set":                                   s.metadataSet,
+		"POST vedsdk/sshcertificates/request":                        s.requestSSHCertificate,
+		"POST vedsdk/sshcertificates/retrieve":                       s.retrieveSSHCertificate,
+		"GET vedsdk/sshcertificates/template/retrieve/publickeydata": s.sshCAPublicKey,
+		"POST vedsdk/sshcertificates/template/retrieve":              s.sshTemplateDetails,
+		"GET vedsdk/sshcertificates/template/available":              s.sshAvailableTemplates,
+	}
+}
+
+// InjectFault makes the server answer the requests selected by f with an error
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f.Path = normalizePath(f.Path)
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all the injected faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// RequestCount returns the number of requests received for path, whatever their outcome
+func (s *Server) RequestCount(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[normalizePath(path)]
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := normalizePath(r.URL.Path)
+
+	s.mu.Lock()
+	s.requests[path]++
+	fault := s.matchFault(r.Method, path)
+	s.mu.Unlock()
+	if fault != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(fault.StatusCode)
+		_, _ = io.WriteString(w, fault.Body)
+		return
+	}
+
+	switch {
+	case path == "vedsdk/authorize":
+		s.authorize(w, r)
+		return
+	case strings.HasPrefix(path, "vedauth/"):
+		s.serveAuth(w, r, path)
+		return
+	case path == "vedsdk":
+		// reachable without credentials, as the WebSDK root
+		s.ping(w, r)
+		return
+	case r.Method == http.MethodGet && path == "vedsdk/sshcertificates/template/retrieve/publickeydata":
+		s.sshCAPublicKey(w, r)
+		return
+	}
+
+	if !s.authenticated(w, r) {
+		return
+	}
+	if handler, ok := s.routes[r.Method+" "+path]; ok {
+		handler(w, r)
+		return
+	}
+	// certificate objects are addressed by GUID
+	if guid, found := strings.CutPrefix(path, "vedsdk/certificates/"); found && guid != "" {
+		switch r.Method {
+		case http.MethodGet:
+			s.certificateDetails(w, r, guid)
+			return
+		case http.MethodPut:
+			s.putCertificateAttributes(w, r, guid)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, fmt.Sprintf("No HTTP resource was found that matches the request URI %s", r.URL.Path))
+}
+
+// matchFault returns the fault to answer the request with, if any. It must be called with the lock held
+func (s *Server) matchFault(method, path string) *Fault {
+	for i, f := range s.faults {
+		if f.Path != path || (f.Method != "" && !strings.EqualFold(f.Method, method)) {
+			continue
+		}
+		if f.Count > 0 {
+			f.Count--
+			if f.Count == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+func (s *Server) ping(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, struct{ Version string }{serverVersion})
+}
+
+func (s *Server) systemVersion(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, struct{ Version string }{serverVersion})
+}
+
+func (s *Server) writeLog(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, struct{ LogResult int }{0})
+}
+
+const serverVersion = "24.1.0.2450"
+
+// normalizePath returns the resource addressed by path in the form used by the routing table
+func normalizePath(path string) string {
+	return strings.Trim(strings.ToLower(path), "/")
+}
+
+// getPolicyDN returns the full DN of zone, the same way the TPP connector does
+func getPolicyDN(zone string) string {
+	if strings.HasPrefix(strings.ToLower(zone), strings.ToLower(policyRoot)) {
+		return zone
+	}
+	return policyRoot + "\\" + strings.TrimPrefix(zone, "\\")
+}
+
+// getParentDN returns the DN of the folder containing dn
+func getParentDN(dn string) string {
+	i := strings.LastIndex(dn, "\\")
+	if i <= 0 {
+		return ""
+	}
+	return dn[:i]
+}
+
+func newGUID() string {
+	return "{" + uuid.New().String() + "}"
+}
+
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("The request is invalid: %s", err))
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error the way most WebSDK endpoints do
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, struct{ Error string }{message})
+}
@@ -0,0 +1,411 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tpptest_test
+
+import (
+	"context"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+	"github.com/Venafi/vcert/v5/pkg/endpoint"
+	"github.com/Venafi/vcert/v5/pkg/policy"
+	"github.com/Venafi/vcert/v5/pkg/util"
+	"github.com/Venafi/vcert/v5/pkg/venafi/tpp"
+	"github.com/Venafi/vcert/v5/pkg/venafi/tpp/tpptest"
+	"github.com/Venafi/vcert/v5/test"
+)
+
+func newConnector(t *testing.T, server *tpptest.Server) *tpp.Connector {
+	t.Helper()
+	connector, err := tpp.NewConnector(server.URL, tpptest.DefaultZone, false, nil)
+	if err != nil {
+		t.Fatalf("failed to create connector: %s", err)
+	}
+	connector.SetHTTPClient(server.Client())
+	err = connector.Authenticate(&endpoint.Authentication{User: tpptest.DefaultUsername, Password: tpptest.DefaultPassword, Scope: "certificate:manage,revoke;configuration:manage;ssh:manage"})
+	if err != nil {
+		t.Fatalf("failed to authenticate: %s", err)
+	}
+	return connector
+}
+
+func TestAuthenticate(t *testing.T) {
+	server := tpptest.NewServer()
+	defer server.Close()
+
+	connector, err := tpp.NewConnector(server.URL, tpptest.DefaultZone, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	connector.SetHTTPClient(server.Client())
+	err = connector.Authenticate(&endpoint.Authentication{User: tpptest.DefaultUsername, Password: "wrong"})
+	if err == nil {
+		t.Fatal("authentication with a wrong password should fail")
+	}
+
+	resp, err := connector.GetRefreshToken(&endpoint.Authentication{User: tpptest.DefaultUsername, Password: tpptest.DefaultPassword, Scope: "certificate:manage"})
+	if err != nil {
+		t.Fatalf("failed to get a refresh token: %s", err)
+	}
+	if resp.Access_token == "" || resp.Refresh_token == "" {
+		t.Fatalf("expected access and refresh tokens, got %+v", resp)
+	}
+	refreshed, err := connector.RefreshAccessToken(&endpoint.Authentication{RefreshToken: resp.Refresh_token, ClientId: "vcert-sdk"})
+	if err != nil {
+		t.Fatalf("failed to refresh the access token: %s", err)
+	}
+	if refreshed.Access_token == resp.Access_token {
+		t.Fatal("refreshing should issue a new access token")
+	}
+	_, err = connector.RefreshAccessToken(&endpoint.Authentication{RefreshToken: resp.Refresh_token, ClientId: "vcert-sdk"})
+	if err == nil {
+		t.Fatal("a refresh token should only be used once")
+	}
+}
+
+func TestCertificateLifecycle(t *testing.T) {
+	server := tpptest.NewServer()
+	defer server.Close()
+	connector := newConnector(t, server)
+
+	req := test.RequestCertificate(t, connector, "lifecycle.example.com")
+	req.ChainOption = certificate.ChainOptionRootLast
+	pcc, err := connector.RetrieveCertificate(req)
+	if err != nil {
+		t.Fatalf("failed to retrieve certificate: %s", err)
+	}
+	cert := test.ParseCertificatePEM(t, pcc.Certificate)
+	if cert.Subject.CommonName != "lifecycle.example.com" {
+		t.Fatalf("unexpected common name %s", cert.Subject.CommonName)
+	}
+	if len(pcc.Chain) != 1 || test.ParseCertificatePEM(t, pcc.Chain[0]).Subject.CommonName != server.CACertificate().Subject.CommonName {
+		t.Fatalf("expected the CA in the chain, got %v", pcc.Chain)
+	}
+
+	renewID, err := connector.RenewCertificate(&certificate.RenewalRequest{CertificateDN: req.PickupID})
+	if err != nil {
+		t.Fatalf("failed to renew certificate: %s", err)
+	}
+	renewed, err := connector.RetrieveCertificate(&certificate.Request{PickupID: renewID})
+	if err != nil {
+		t.Fatalf("failed to retrieve renewed certificate: %s", err)
+	}
+	if test.ParseCertificatePEM(t, renewed.Certificate).SerialNumber.Cmp(cert.SerialNumber) == 0 {
+		t.Fatal("renewal should issue a new certificate")
+	}
+
+	err = connector.RevokeCertificate(&certificate.RevocationRequest{CertificateDN: req.PickupID, Reason: "key-compromise", Disable: true})
+	if err != nil {
+		t.Fatalf("failed to revoke certificate: %s", err)
+	}
+	obj, ok := server.Certificate(req.PickupID)
+	if !ok || !obj.Revoked || !obj.Disabled || obj.RevocationReason != 1 {
+		t.Fatalf("expected a revoked and disabled certificate, got %+v", obj)
+	}
+}
+
+func TestServiceGeneratedKey(t *testing.T) {
+	server := tpptest.NewServer()
+	defer server.Close()
+	connector := newConnector(t, server)
+
+	req := &certificate.Request{
+		Subject:         pkix.Name{CommonName: "central.example.com"},
+		DNSNames:        []string{"central.example.com"},
+		KeyType:         certificate.KeyTypeECDSA,
+		KeyCurve:        certificate.EllipticCurveP384,
+		CsrOrigin:       certificate.ServiceGeneratedCSR,
+		KeyPassword:     "Passw0rd!",
+		FetchPrivateKey: true,
+	}
+	err := connector.GenerateRequest(nil, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.PickupID, err = connector.RequestCertificate(req)
+	if err != nil {
+		t.Fatalf("failed to request certificate: %s", err)
+	}
+	pcc, err := connector.RetrieveCertificate(req)
+	if err != nil {
+		t.Fatalf("failed to retrieve certificate: %s", err)
+	}
+	if !strings.Contains(pcc.PrivateKey, "ENCRYPTED PRIVATE KEY") {
+		t.Fatalf("expected an encrypted private key, got %q", pcc.PrivateKey)
+	}
+}
+
+func TestPendingRetrieval(t *testing.T) {
+	server := tpptest.NewServer()
+	server.PendingRetrievals = 1
+	defer server.Close()
+	connector := newConnector(t, server)
+
+	req := test.RequestCertificate(t, connector, "pending.example.com")
+	_, err := connector.RetrieveCertificate(req)
+	var pending endpoint.ErrCertificatePending
+	if !errors.As(err, &pending) {
+		t.Fatalf("expected the certificate to be pending, got %v", err)
+	}
+	_, err = connector.RetrieveCertificate(req)
+	if err != nil {
+		t.Fatalf("failed to retrieve certificate: %s", err)
+	}
+}
+
+func TestRetrieveCertificateContext(t *testing.T) {
+	server := tpptest.NewServer()
+	server.PendingRetrievals = 100
+	defer server.Close()
+	connector := newConnector(t, server)
+
+	req := test.RequestCertificate(t, connector, "cancel.example.com")
+	req.Timeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := connector.RetrieveCertificateContext(ctx, req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the retrieval to stop at the deadline, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("the retrieval stopped %s after the deadline", elapsed)
+	}
+
+	cancel()
+	_, err = connector.RequestCertificateContext(ctx, req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the request to fail with the context error, got %v", err)
+	}
+}
+
+func TestSearchCertificates(t *testing.T) {
+	server := tpptest.NewServer()
+	defer server.Close()
+	connector := newConnector(t, server)
+
+	for _, cn := range []string{"one.example.com", "two.example.com"} {
+		test.RequestCertificate(t, connector, cn)
+	}
+	cases := []struct {
+		name   string
+		search certificate.SearchRequest
+		count  int
+	}{
+		{"common name", certificate.SearchRequest{"cn=two.example.com"}, 1},
+		{"issuer", certificate.SearchRequest{"issuer=" + url.QueryEscape(server.CACertificate().Subject.String())}, 2},
+		{"unknown", certificate.SearchRequest{"cn=three.example.com"}, 0},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			found, err := connector.SearchCertificates(&c.search)
+			if err != nil {
+				t.Fatalf("failed to search certificates: %s", err)
+			}
+			if found.Count != c.count {
+				t.Fatalf("expected %d certificates, got %+v", c.count, found)
+			}
+			for _, info := range found.Certificates {
+				if info.X509.CN == "" || info.X509.Issuer == "" {
+					t.Fatalf("unexpected certificate details %+v", info.X509)
+				}
+			}
+		})
+	}
+	all, err := connector.ListCertificates(endpoint.Filter{})
+	if err != nil {
+		t.Fatalf("failed to list certificates: %s", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 certificates, got %d", len(all))
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	server := tpptest.NewServer()
+	defer server.Close()
+	connector := newConnector(t, server)
+
+	ps := &policy.PolicySpecification{
+		Policy: &policy.Policy{
+			Domains:         []string{"example.com"},
+			WildcardAllowed: util.GetBooleanRef(false),
+		},
+	}
+	_, err := connector.SetPolicy("Certificates\\vcert\\app", ps)
+	if err != nil {
+		t.Fatalf("failed to set policy: %s", err)
+	}
+	got, err := connector.GetPolicy("Certificates\\vcert\\app")
+	if err != nil {
+		t.Fatalf("failed to get policy: %s", err)
+	}
+	if len(got.Policy.Domains) != 1 || got.Policy.Domains[0] != "example.com" {
+		t.Fatalf("unexpected domains %v", got.Policy.Domains)
+	}
+
+	connector.SetZone("Certificates\\vcert\\app")
+	cases := []struct {
+		cn      string
+		allowed bool
+	}{
+		{"host.example.com", true},
+		{"host.other.org", false},
+	}
+	for _, c := range cases {
+		t.Run(c.cn, func(t *testing.T) {
+			_, err := connector.RequestCertificate(test.NewRequest(t, connector, c.cn))
+			if c.allowed && err != nil {
+				t.Fatalf("a request for an allowed domain should succeed: %s", err)
+			}
+			if !c.allowed && err == nil {
+				t.Fatal("a request outside of the allowed domains should fail")
+			}
+		})
+	}
+}
+
+func TestSSHCertificate(t *testing.T) {
+	server := tpptest.NewServer()
+	defer server.Close()
+	connector := newConnector(t, server)
+
+	req := &certificate.SshCertRequest{
+		Template:             "vcert-user-ca",
+		KeyId:                "tpptest",
+		Principals:           []string{"alice"},
+		ValidityPeriod:       "4h",
+		PrivateKeyPassphrase: "Passw0rd!",
+	}
+	requested, err := connector.RequestSSHCertificate(req)
+	if err != nil {
+		t.Fatalf("failed to request SSH certificate: %s", err)
+	}
+	req.PickupID = requested.DN
+	resp, err := connector.RetrieveSSHCertificate(req)
+	if err != nil {
+		t.Fatalf("failed to retrieve SSH certificate: %s", err)
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(resp.CertificateData))
+	if err != nil {
+		t.Fatalf("failed to parse SSH certificate: %s", err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok || cert.KeyId != "tpptest" || cert.ValidPrincipals[0] != "alice" {
+		t.Fatalf("unexpected SSH certificate %+v", pub)
+	}
+	_, err = ssh.ParseRawPrivateKeyWithPassphrase([]byte(resp.PrivateKeyData), []byte("Passw0rd!"))
+	if err != nil {
+		t.Fatalf("failed to decrypt the private key: %s", err)
+	}
+
+	conf, err := connector.RetrieveSshConfig(&certificate.SshCaTemplateRequest{Template: "vcert-user-ca"})
+	if err != nil {
+		t.Fatalf("failed to retrieve SSH config: %s", err)
+	}
+	caKey, _ := server.SSHCAPublicKey("vcert-user-ca")
+	if conf.CaPublicKey != caKey {
+		t.Fatalf("unexpected SSH config %+v", conf)
+	}
+}
+
+func TestInjectFault(t *testing.T) {
+	server := tpptest.NewServer()
+	defer server.Close()
+	connector := newConnector(t, server)
+
+	server.InjectFault(tpptest.Fault{Path: "vedsdk/certificates/request", StatusCode: http.StatusInternalServerError, Body: `{"Error":"boom"}`, Count: 1})
+	req := test.NewRequest(t, connector, "fault.example.com")
+	_, err := connector.RequestCertificate(req)
+	if err == nil {
+		t.Fatal("expected the injected fault")
+	}
+	_, err = connector.RequestCertificate(req)
+	if err != nil {
+		t.Fatalf("the fault should be cleared after one request: %s", err)
+	}
+	if n := server.RequestCount("vedsdk/certificates/request"); n != 2 {
+		t.Fatalf("expected 2 requests, got %d", n)
+	}
+}
+
+func TestPolicyPlan(t *testing.T) {
+	server := tpptest.NewServer()
+	defer server.Close()
+	connector := newConnector(t, server)
+
+	locality := "Salt Lake City"
+	ps := &policy.PolicySpecification{
+		Policy: &policy.Policy{
+			Domains:         []string{"example.com", "example.org"},
+			WildcardAllowed: util.GetBooleanRef(false),
+			Subject: &policy.Subject{
+				Orgs:      []string{"Venafi"},
+				Countries: []string{"US"},
+			},
+			KeyPair: &policy.KeyPair{
+				KeyTypes:    []string{"RSA"},
+				RsaKeySizes: []int{2048},
+			},
+		},
+		Default: &policy.Default{
+			Subject: &policy.DefaultSubject{
+				Locality: &locality,
+			},
+		},
+	}
+	_, err := connector.SetPolicy("Certificates\\vcert\\plan", ps)
+	if err != nil {
+		t.Fatalf("failed to set policy: %s", err)
+	}
+	current, err := connector.GetPolicy("Certificates\\vcert\\plan")
+	if err != nil {
+		t.Fatalf("failed to get policy: %s", err)
+	}
+
+	changes, err := policy.DiffPolicySpecifications(current, ps, policy.TppUnsetValues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("an applied policy should not drift: %v", changes)
+	}
+
+	ps.Policy.Subject.Orgs = []string{"Venafi", "Acme"}
+	ps.Policy.WildcardAllowed = nil
+	changes, err = policy.DiffPolicySpecifications(current, ps, policy.TppUnsetValues)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []policy.Change{
+		{Field: "policy.subject.orgs", Current: "[Venafi]", Desired: "[Acme, Venafi]"},
+		{Field: "policy.wildcardAllowed", Current: "false", Desired: "true"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Fatalf("unexpected changes %v", changes)
+	}
+}
@@ -0,0 +1,359 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tpptest
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+)
+
+const (
+	sshTemplateRoot     = "\\VED\\Certificate Authority\\SSH\\Templates"
+	defaultSSHValidity  = 24 * time.Hour
+	sshErrorCodeUnknown = 1
+)
+
+type sshTemplate struct {
+	dn         string
+	guid       string
+	host       bool
+	principals []string
+	signer     ssh.Signer
+}
+
+type sshCertificateObject struct {
+	dn        string
+	guid      string
+	createdOn time.Time
+	template  *sshTemplate
+	cert      *ssh.Certificate
+	key       crypto.Signer
+	pending   int
+}
+
+// AddSSHTemplate creates an SSH certificate issuance template named name, signing with a new ed25519 CA key. Host
+// templates issue host certificates, the others issue user certificates for principals unless the request names
+// its own
+func (s *Server) AddSSHTemplate(name string, host bool, principals []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.addSSHTemplate(name, host, principals)
+}
+
+// SSHCAPublicKey returns the public key of the CA of the template name, in authorized_keys format
+func (s *Server) SSHCAPublicKey(name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.sshTemplates[strings.ToLower(getSSHTemplateDN(name))]
+	if !ok {
+		return "", false
+	}
+	return string(ssh.MarshalAuthorizedKey(t.signer.PublicKey())), true
+}
+
+func (s *Server) addSSHTemplate(name string, host bool, principals []string) error {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		return err
+	}
+	dn := getSSHTemplateDN(name)
+	s.sshTemplates[strings.ToLower(dn)] = &sshTemplate{dn: dn, guid: newGUID(), host: host, principals: principals, signer: signer}
+	return nil
+}
+
+// getSSHTemplateDN returns the full DN of the template name, the same way the TPP connector does
+func getSSHTemplateDN(name string) string {
+	if strings.HasPrefix(name, sshTemplateRoot) {
+		return name
+	}
+	return sshTemplateRoot + "\\" + strings.TrimPrefix(name, "\\")
+}
+
+// findSSHTemplate must be called with the lock held
+func (s *Server) findSSHTemplate(dn, guid string) *sshTemplate {
+	if dn != "" {
+		return s.sshTemplates[strings.ToLower(dn)]
+	}
+	for _, t := range s.sshTemplates {
+		if strings.EqualFold(t.guid, guid) {
+			return t
+		}
+	}
+	return nil
+}
+
+func writeSSHError(w http.ResponseWriter, statusCode int, message string) {
+	writeJSON(w, statusCode, certificate.TppSshCertOperationResponse{
+		Response: certificate.TppSshCertResponseInfo{ErrorCode: sshErrorCodeUnknown, ErrorMessage: message},
+	})
+}
+
+func (s *Server) requestSSHCertificate(w http.ResponseWriter, r *http.Request) {
+	var req certificate.TPPSshCertRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+	validity := defaultSSHValidity
+	if req.ValidityPeriod != "" {
+		var err error
+		validity, err = parseValidityPeriod(req.ValidityPeriod)
+		if err != nil {
+			writeSSHError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	var publicKey ssh.PublicKey
+	var key crypto.Signer
+	if req.PublicKeyData != "" {
+		var err error
+		publicKey, _, _, _, err = ssh.ParseAuthorizedKey([]byte(req.PublicKeyData))
+		if err != nil {
+			writeSSHError(w, http.StatusBadRequest, fmt.Sprintf("Invalid public key data: %s", err))
+			return
+		}
+	} else {
+		rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			writeSSHError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		key = rsaKey
+		publicKey, err = ssh.NewPublicKey(rsaKey.Public())
+		if err != nil {
+			writeSSHError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.findSSHTemplate(req.CADN, "")
+	if t == nil {
+		writeSSHError(w, http.StatusBadRequest, fmt.Sprintf("CA template %s does not exist", req.CADN))
+		return
+	}
+	name := req.ObjectName
+	if name == "" {
+		name = req.KeyId
+	}
+	if name == "" {
+		name = newGUID()
+	}
+	policyDN := req.PolicyDN
+	if policyDN == "" {
+		policyDN = "\\VED\\Certificates\\SSH"
+	}
+
+	principals := req.Principals
+	if len(principals) == 0 {
+		principals = t.principals
+	}
+	var serial [8]byte
+	_, err := io.ReadFull(rand.Reader, serial[:])
+	if err != nil {
+		writeSSHError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	cert := &ssh.Certificate{
+		Key:             publicKey,
+		Serial:          binary.BigEndian.Uint64(serial[:]),
+		CertType:        ssh.UserCert,
+		KeyId:           req.KeyId,
+		ValidPrincipals: principals,
+		ValidAfter:      uint64(time.Now().Add(-time.Minute).Unix()),
+		ValidBefore:     uint64(time.Now().Add(validity).Unix()),
+	}
+	if t.host {
+		cert.CertType = ssh.HostCert
+	} else {
+		cert.Permissions.Extensions = map[string]string{}
+		for k, v := range req.Extensions {
+			cert.Permissions.Extensions[k] = fmt.Sprint(v)
+		}
+		cert.Permissions.CriticalOptions = map[string]string{}
+		if req.ForceCommand != "" {
+			cert.Permissions.CriticalOptions["force-command"] = req.ForceCommand
+		}
+		if len(req.SourceAddresses) > 0 {
+			cert.Permissions.CriticalOptions["source-address"] = strings.Join(req.SourceAddresses, ",")
+		}
+	}
+	err = cert.SignCert(rand.Reader, t.signer)
+	if err != nil {
+		writeSSHError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	obj := &sshCertificateObject{
+		dn:        policyDN + "\\" + name,
+		guid:      newGUID(),
+		createdOn: time.Now(),
+		template:  t,
+		cert:      cert,
+		key:       key,
+		pending:   s.PendingRetrievals,
+	}
+	s.sshCertificates[strings.ToLower(obj.dn)] = obj
+	writeJSON(w, http.StatusOK, certificate.TppSshCertOperationResponse{
+		DN:                obj.dn,
+		Guid:              obj.guid,
+		CADN:              t.dn,
+		CAGuid:            t.guid,
+		ProcessingDetails: certificate.ProcessingDetails{Status: "Issued"},
+		Response:          certificate.TppSshCertResponseInfo{Success: true},
+	})
+}
+
+// parseValidityPeriod accepts the durations understood by Go, plus a number of days such as "7d"
+func parseValidityPeriod(period string) (time.Duration, error) {
+	if days, found := strings.CutSuffix(period, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid validity period %s", period)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid validity period %s", period)
+	}
+	return d, nil
+}
+
+func (s *Server) retrieveSSHCertificate(w http.ResponseWriter, r *http.Request) {
+	var req certificate.TppSshCertRetrieveRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var obj *sshCertificateObject
+	if req.DN != "" {
+		obj = s.sshCertificates[strings.ToLower(req.DN)]
+	} else {
+		for _, o := range s.sshCertificates {
+			if strings.EqualFold(o.guid, req.Guid) {
+				obj = o
+			}
+		}
+	}
+	if obj == nil {
+		writeSSHError(w, http.StatusBadRequest, "SSH certificate does not exist")
+		return
+	}
+
+	response := certificate.TppSshCertOperationResponse{
+		DN:       obj.dn,
+		Guid:     obj.guid,
+		CADN:     obj.template.dn,
+		CAGuid:   obj.template.guid,
+		Response: certificate.TppSshCertResponseInfo{Success: true},
+	}
+	if obj.pending > 0 {
+		obj.pending--
+		response.ProcessingDetails = certificate.ProcessingDetails{Status: "Pending", StatusDescription: "Waiting for the CA to issue the certificate"}
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+
+	response.ProcessingDetails = certificate.ProcessingDetails{Status: "Issued"}
+	response.CertificateData = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(obj.cert)))
+	response.PublicKeyData = strings.TrimSpace(string(ssh.MarshalAuthorizedKey(obj.cert.Key)))
+	if req.IncludePrivateKeyData && obj.key != nil {
+		var block *pem.Block
+		var err error
+		if req.PrivateKeyPassphrase != "" {
+			block, err = ssh.MarshalPrivateKeyWithPassphrase(obj.key, "", []byte(req.PrivateKeyPassphrase))
+		} else {
+			block, err = ssh.MarshalPrivateKey(obj.key, "")
+		}
+		if err != nil {
+			writeSSHError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		response.PrivateKeyData = string(pem.EncodeToMemory(block))
+	}
+	if req.IncludeCertificateDetails {
+		response.CertificateDetails = certificate.NewSshCertificateDetails(obj.cert)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// sshCAPublicKey answers with the CA public key in plain text. Like TPP, it doesn't require credentials
+func (s *Server) sshCAPublicKey(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	s.mu.Lock()
+	t := s.findSSHTemplate(query.Get("DN"), query.Get("guid"))
+	s.mu.Unlock()
+	if t == nil {
+		writeError(w, http.StatusBadRequest, "CA template does not exist")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(ssh.MarshalAuthorizedKey(t.signer.PublicKey()))
+}
+
+func (s *Server) sshTemplateDetails(w http.ResponseWriter, r *http.Request) {
+	var req certificate.SshTppCaTemplateRequest
+	if !decodeRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.findSSHTemplate(req.DN, req.Guid)
+	if t == nil {
+		writeJSON(w, http.StatusBadRequest, certificate.SshTppCaTemplateResponse{
+			Response: certificate.TppSshCertResponseInfo{ErrorCode: sshErrorCodeUnknown, ErrorMessage: "CA template does not exist"},
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, certificate.SshTppCaTemplateResponse{
+		AccessControl: certificate.AccessControl{DefaultPrincipals: t.principals},
+		Response:      certificate.TppSshCertResponseInfo{Success: true},
+	})
+}
+
+func (s *Server) sshAvailableTemplates(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	templates := make([]certificate.SshAvaliableTemplate, 0, len(s.sshTemplates))
+	for _, t := range s.sshTemplates {
+		templates = append(templates, certificate.SshAvaliableTemplate{DN: t.dn, Guid: t.guid})
+	}
+	writeJSON(w, http.StatusOK, templates)
+}
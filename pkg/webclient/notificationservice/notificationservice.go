@@ -1,6 +1,8 @@
 package notificationservice
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -20,6 +22,7 @@ type NotificationServiceClient struct {
 	baseURL     string
 	accessToken string
 	apiKey      string
+	tlsConfig   *tls.Config
 }
 
 func NewNotificationServiceClient(baseURL string, accessToken string, apiKey string) *NotificationServiceClient {
@@ -30,7 +33,17 @@ func NewNotificationServiceClient(baseURL string, accessToken string, apiKey str
 	}
 }
 
+// SetTLSConfig sets the TLS configuration used to connect to the notification service, e.g. to trust a private CA
+func (ns *NotificationServiceClient) SetTLSConfig(tlsConfig *tls.Config) {
+	ns.tlsConfig = tlsConfig
+}
+
 func (ns *NotificationServiceClient) Subscribe(wsClientId string) (*websocket.Conn, error) {
+	return ns.SubscribeContext(context.Background(), wsClientId)
+}
+
+// SubscribeContext is Subscribe with a context that cancels the connection to the notification service
+func (ns *NotificationServiceClient) SubscribeContext(ctx context.Context, wsClientId string) (*websocket.Conn, error) {
 
 	_, host, found := strings.Cut(ns.baseURL, "https://")
 	if !found {
@@ -49,7 +62,13 @@ func (ns *NotificationServiceClient) Subscribe(wsClientId string) (*websocket.Co
 		httpHeader = http.Header{util.HeaderTpplApikey: {ns.apiKey}}
 	}
 
-	wsConn, resp, err := websocket.DefaultDialer.Dial(notificationsUrl.String(), httpHeader)
+	dialer := websocket.DefaultDialer
+	if ns.tlsConfig != nil {
+		d := *websocket.DefaultDialer
+		d.TLSClientConfig = ns.tlsConfig
+		dialer = &d
+	}
+	wsConn, resp, err := dialer.DialContext(ctx, notificationsUrl.String(), httpHeader)
 	if err != nil {
 		return nil, err
 	}
@@ -59,13 +78,30 @@ func (ns *NotificationServiceClient) Subscribe(wsClientId string) (*websocket.Co
 	}
 	log.Print("successfully switched to websocket connection")
 
-	time.Sleep(5 * time.Second)
+	if err = util.SleepContext(ctx, 5*time.Second); err != nil {
+		_ = wsConn.Close()
+		return nil, err
+	}
 	return wsConn, nil
 }
 
 func (ns *NotificationServiceClient) ReadResponse(wsConn *websocket.Conn) (*domain.WorkflowResponse, error) {
+	return ns.ReadResponseContext(context.Background(), wsConn)
+}
+
+// ReadResponseContext is ReadResponse with a context that stops waiting for the response
+func (ns *NotificationServiceClient) ReadResponseContext(ctx context.Context, wsConn *websocket.Conn) (*domain.WorkflowResponse, error) {
+	// Closing the connection unblocks the read when ctx is done
+	stop := context.AfterFunc(ctx, func() {
+		_ = wsConn.Close()
+	})
+	defer stop()
+
 	_, msg, err := wsConn.ReadMessage()
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
 		return nil, err
 	}
 
@@ -0,0 +1,69 @@
+/*
+ * Copyright 2024 Venafi, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *  http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package test
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"testing"
+
+	"github.com/Venafi/vcert/v5/pkg/certificate"
+	"github.com/Venafi/vcert/v5/pkg/endpoint"
+)
+
+// NewRequest returns an RSA request for cn, with the CSR generated by the connector
+func NewRequest(t *testing.T, connector endpoint.Connector, cn string) *certificate.Request {
+	t.Helper()
+	req := &certificate.Request{
+		Subject:   pkix.Name{CommonName: cn},
+		DNSNames:  []string{cn},
+		KeyType:   certificate.KeyTypeRSA,
+		KeyLength: 2048,
+	}
+	err := connector.GenerateRequest(nil, req)
+	if err != nil {
+		t.Fatalf("failed to generate request: %s", err)
+	}
+	return req
+}
+
+// RequestCertificate requests a certificate for cn and returns the request, with its PickupID set
+func RequestCertificate(t *testing.T, connector endpoint.Connector, cn string) *certificate.Request {
+	t.Helper()
+	req := NewRequest(t, connector, cn)
+	var err error
+	req.PickupID, err = connector.RequestCertificate(req)
+	if err != nil {
+		t.Fatalf("failed to request certificate: %s", err)
+	}
+	return req
+}
+
+// ParseCertificatePEM returns the certificate of the first PEM block of data
+func ParseCertificatePEM(t *testing.T, data string) *x509.Certificate {
+	t.Helper()
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		t.Fatalf("no PEM data in %q", data)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}